@@ -2,21 +2,53 @@ package api
 
 import (
 	"net/http"
-	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/lbryio/lbrytv-player/pkg/paid"
+	"github.com/lbryio/lbrytv/app/admin"
 	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/availability"
+	"github.com/lbryio/lbrytv/app/balance"
+	"github.com/lbryio/lbrytv/app/canary"
+	"github.com/lbryio/lbrytv/app/cdnpurge"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/contentpage"
+	"github.com/lbryio/lbrytv/app/deadletter"
+	"github.com/lbryio/lbrytv/app/delegation"
+	"github.com/lbryio/lbrytv/app/embed"
+	"github.com/lbryio/lbrytv/app/events"
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/app/fraud"
+	"github.com/lbryio/lbrytv/app/legalhold"
+	"github.com/lbryio/lbrytv/app/limits"
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/organization"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/overview"
 	"github.com/lbryio/lbrytv/app/proxy"
 	"github.com/lbryio/lbrytv/app/publish"
+	"github.com/lbryio/lbrytv/app/quarantine"
 	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/recommendations"
+	"github.com/lbryio/lbrytv/app/recovery"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/secrets"
+	"github.com/lbryio/lbrytv/app/sessions"
+	"github.com/lbryio/lbrytv/app/taxonomy"
+	"github.com/lbryio/lbrytv/app/torrent"
+	"github.com/lbryio/lbrytv/app/trending"
+	"github.com/lbryio/lbrytv/app/uploadtoken"
+	"github.com/lbryio/lbrytv/app/userblock"
+	"github.com/lbryio/lbrytv/app/usertrace"
+	"github.com/lbryio/lbrytv/app/verification"
 	"github.com/lbryio/lbrytv/apps/lbrytv/config"
 	"github.com/lbryio/lbrytv/internal/ip"
 	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/middleware"
 	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/ratelimit"
+	"github.com/lbryio/lbrytv/internal/startup"
 	"github.com/lbryio/lbrytv/internal/status"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
@@ -25,7 +57,9 @@ var logger = monitor.NewModuleLogger("api")
 
 // InstallRoutes sets up global API handlers
 func InstallRoutes(r *mux.Router, sdkRouter *sdkrouter.Router) {
-	upHandler := &publish.Handler{UploadPath: config.GetPublishSourceDir()}
+	upHandler := &publish.Handler{
+		UploadPath: config.GetPublishSourceDir(), Storage: filestore.Default(), Scanner: publish.DefaultScanner(),
+	}
 
 	r.Use(methodTimer)
 
@@ -33,23 +67,155 @@ func InstallRoutes(r *mux.Router, sdkRouter *sdkrouter.Router) {
 		w.Write([]byte("lbrytv api"))
 	})
 	r.HandleFunc("", proxy.HandleCORS)
+	r.HandleFunc("/healthz", canary.HandleHealthz).Methods(http.MethodGet)
+	r.HandleFunc("/readyz", startup.HandleReadyz).Methods(http.MethodGet)
+	componentsHandler := status.NewComponentsHandler(sdkRouter, config.GetStatusComponentsTTL())
+	r.HandleFunc("/api/v1/status/components", componentsHandler.Handle).Methods(http.MethodGet)
+
+	adminRouter := r.PathPrefix("/api/v1/admin").Subrouter()
+	adminRouter.Use(recovery.Middleware, admin.Middleware)
+	adminRouter.HandleFunc("/debug/{user_id:[0-9]+}", usertrace.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/debug/{user_id:[0-9]+}", usertrace.HandleEnable).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/debug/{user_id:[0-9]+}", usertrace.HandleDisable).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/logging", admin.HandleGetLogging).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/logging", admin.HandleSetLogging).Methods(http.MethodPost)
+	overviewHandler := overview.Handler{Router: sdkRouter}
+	adminRouter.HandleFunc("/overview", overviewHandler.HandleOverview).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/overview/{section}", overviewHandler.HandleSection).Methods(http.MethodGet)
+	poolHandler := sdkrouter.Handler{Router: sdkRouter}
+	adminRouter.HandleFunc("/sdk_servers/{name}/drain", poolHandler.HandleDrain).Methods(http.MethodPut)
+	adminRouter.HandleFunc("/sdk_servers/{name}/drain", poolHandler.HandleUndrain).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/organizations/{id:[0-9]+}/quota", organization.HandleSetQuota).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/quarantine", quarantine.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/quarantine/{id:[0-9]+}", quarantine.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/quarantine/{id:[0-9]+}/file", quarantine.HandleDownload).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/quarantine/{id:[0-9]+}/disposition", quarantine.HandleDispose).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/legal_holds", legalhold.HandlePlace).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/legal_holds", legalhold.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/legal_holds/{id:[0-9]+}/release", legalhold.HandleRelease).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/legal_holds/{id:[0-9]+}/export", legalhold.HandleExport).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/moderation/cases", moderation.HandleFlag).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/moderation/cases", moderation.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/moderation/cases/{id:[0-9]+}", moderation.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/moderation/cases/{id:[0-9]+}/dismiss", moderation.HandleDismiss).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/moderation/cases/{id:[0-9]+}/takedown", moderation.HandleTakeDown).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/outbox", outbox.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/outbox/{id:[0-9]+}", outbox.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/outbox/{id:[0-9]+}/retry", outbox.HandleRetry).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/query_cache/invalidate", cache.HandleInvalidate).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/dead_letters", deadletter.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/dead_letters/{id:[0-9]+}", deadletter.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/dead_letters/{id:[0-9]+}/redeliver", deadletter.HandleRedeliver).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/secrets", secrets.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/secrets/{name}/rotate", secrets.HandleRotate).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/verified_channels", verification.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/verified_channels", verification.HandleVerify).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/verified_channels/{claim_id:[0-9a-f]{40}}", verification.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/verified_channels/{claim_id:[0-9a-f]{40}}", verification.HandleUnverify).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/tags", taxonomy.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/tags", taxonomy.HandleSet).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/tags/{name}", taxonomy.HandleDelete).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/cdn_purge", cdnpurge.HandlePurge).Methods(http.MethodPost)
+	adminRouter.HandleFunc("/torrents", torrent.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/torrents/{claim_id:[0-9a-f]{40}}", torrent.HandleRemove).Methods(http.MethodDelete)
+	adminRouter.HandleFunc("/fraud_reviews", fraud.HandleList).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/fraud_reviews/{id:[0-9]+}", fraud.HandleGet).Methods(http.MethodGet)
+	adminRouter.HandleFunc("/fraud_reviews/{id:[0-9]+}", fraud.HandleDecide).Methods(http.MethodPost)
+
+	// Embed endpoints are anonymous and don't go through auth or the shared query cache
+	embedRouter := r.PathPrefix("/api/v1/embed").Subrouter()
+	embedRouter.Use(recovery.Middleware, metrics.MeasureMiddleware(), ip.Middleware, sdkrouter.Middleware(sdkRouter), ratelimit.Middleware)
+	embedRouter.HandleFunc("/resolve", embed.HandleResolve).Methods(http.MethodGet)
+	embedRouter.HandleFunc("/stream", embed.HandleStream).Methods(http.MethodGet)
+
+	// Browsers can't set headers on WebSocket handshakes, so the auth token may come in the query
+	eventsRouter := r.PathPrefix("/api/v1/events").Subrouter()
+	eventsRouter.Use(proxy.TokenFromQuery, defaultMiddlewares(sdkRouter, config.GetInternalAPIHost()))
+	eventsRouter.HandleFunc("", events.HandleSubscribe).Methods(http.MethodGet)
 
 	v1Router := r.PathPrefix("/api/v1").Subrouter()
 	v1Router.Use(defaultMiddlewares(sdkRouter, config.GetInternalAPIHost()))
 
-	v1Router.HandleFunc("/proxy", upHandler.Handle).MatcherFunc(upHandler.CanHandle)
+	v1Router.Handle("/proxy", middleware.Apply(ratelimit.Middleware, upHandler.Handle)).MatcherFunc(upHandler.CanHandle)
 	v1Router.HandleFunc("/proxy", proxy.Handle).Methods(http.MethodPost)
 	v1Router.HandleFunc("/proxy", proxy.HandleCORS).Methods(http.MethodOptions)
 
+	v1Router.HandleFunc("/uploads", upHandler.HandleInitiate).Methods(http.MethodPost)
+	v1Router.HandleFunc("/uploads/{id:[0-9a-f]{32}}", upHandler.HandleStatus).Methods(http.MethodGet)
+	v1Router.HandleFunc("/uploads/{id:[0-9a-f]{32}}", upHandler.HandleAbort).Methods(http.MethodDelete)
+	v1Router.Handle("/uploads/{id:[0-9a-f]{32}}/parts/{part:[0-9]+}", middleware.Apply(ratelimit.Middleware, upHandler.HandlePart)).Methods(http.MethodPut)
+	v1Router.HandleFunc("/uploads/{id:[0-9a-f]{32}}/complete", upHandler.HandleComplete).Methods(http.MethodPost)
+	v1Router.HandleFunc("/uploads/basis/{claim_id:[0-9a-f]{40}}", upHandler.HandleSignatures).Methods(http.MethodGet)
+	v1Router.Handle("/tus", middleware.Apply(ratelimit.Middleware, upHandler.HandleTusCreate)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/tus", upHandler.HandleTusOptions).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/tus/{id:[0-9a-f]{32}}", upHandler.HandleTusHead).Methods(http.MethodHead)
+	v1Router.Handle("/tus/{id:[0-9a-f]{32}}", middleware.Apply(ratelimit.Middleware, upHandler.HandleTusPatch)).Methods(http.MethodPatch)
+	v1Router.HandleFunc("/tus/{id:[0-9a-f]{32}}", upHandler.HandleTusDelete).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/tus/{id:[0-9a-f]{32}}", upHandler.HandleTusOptions).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/tus/{id:[0-9a-f]{32}}/publish", upHandler.HandleTusPublish).Methods(http.MethodPost)
+	v1Router.Handle("/imports", middleware.Apply(ratelimit.Middleware, upHandler.HandleImport)).Methods(http.MethodPost)
+	v1Router.HandleFunc("/imports/{id:[0-9a-f]{32}}", upHandler.HandleImportStatus).Methods(http.MethodGet)
+	v1Router.HandleFunc("/publish/progress/{upload_id:[0-9A-Za-z_-]{16,64}}", upHandler.HandleProgress).Methods(http.MethodGet)
+	v1Router.HandleFunc("/publish/status/{token:[0-9a-f]{32}}", upHandler.HandlePublishStatus).Methods(http.MethodGet)
+
 	v1Router.HandleFunc("/metric/ui", metrics.TrackUIMetric).Methods(http.MethodPost)
 	v1Router.HandleFunc("/metric/ui", proxy.HandleCORS).Methods(http.MethodOptions)
 
 	v1Router.HandleFunc("/status", status.GetStatus).Methods(http.MethodGet)
 	v1Router.HandleFunc("/paid/pubkey", paid.HandlePublicKeyRequest).Methods(http.MethodGet)
 
+	v1Router.HandleFunc("/channels/{claim_id:[0-9a-f]{40}}", channels.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/channels/{claim_id:[0-9a-f]{40}}", proxy.HandleCORS).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/content_page", contentpage.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/content_page", proxy.HandleCORS).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/recommendations/{claim_id:[0-9a-f]{40}}", recommendations.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/recommendations/{claim_id:[0-9a-f]{40}}", proxy.HandleCORS).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/tags/autocomplete", taxonomy.HandleAutocomplete).Methods(http.MethodGet)
+	v1Router.HandleFunc("/tags/autocomplete", proxy.HandleCORS).Methods(http.MethodOptions)
+	v1Router.HandleFunc("/trending/{category}", trending.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/trending/{category}", proxy.HandleCORS).Methods(http.MethodOptions)
+
+	v1Router.HandleFunc("/blocked_channels", userblock.HandleList).Methods(http.MethodGet)
+	v1Router.HandleFunc("/blocked_channels", userblock.HandleBlock).Methods(http.MethodPost)
+	v1Router.HandleFunc("/blocked_channels/{channel_id:[0-9a-f]{40}}", userblock.HandleUnblock).Methods(http.MethodDelete)
+	balanceHandler := balance.Handler{}
+	v1Router.HandleFunc("/wallet/balance", balanceHandler.HandlePoll).Methods(http.MethodGet)
+	v1Router.HandleFunc("/limits", limits.HandleGet).Methods(http.MethodGet)
+	availabilityHandler := availability.Handler{Originals: upHandler}
+	v1Router.HandleFunc("/availability/{claim_id:[0-9a-f]{40}}", availabilityHandler.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/availability/{claim_id:[0-9a-f]{40}}/check", availabilityHandler.HandleCheck).Methods(http.MethodPost)
+	v1Router.HandleFunc("/availability/{claim_id:[0-9a-f]{40}}/repair", availabilityHandler.HandleRepair).Methods(http.MethodPost)
+	v1Router.HandleFunc("/sessions", sessions.HandleList).Methods(http.MethodGet)
+	v1Router.HandleFunc("/sessions/{id:[0-9]+}", sessions.HandleRevoke).Methods(http.MethodDelete)
+
+	v1Router.HandleFunc("/delegations", delegation.HandleList).Methods(http.MethodGet)
+	v1Router.HandleFunc("/delegations", delegation.HandleGrant).Methods(http.MethodPost)
+	v1Router.HandleFunc("/delegations", delegation.HandleRevoke).Methods(http.MethodDelete)
+
+	v1Router.HandleFunc("/upload_tokens", uploadtoken.HandleIssue).Methods(http.MethodPost)
+
+	v1Router.HandleFunc("/organization", organization.HandleGet).Methods(http.MethodGet)
+	v1Router.HandleFunc("/organization", organization.HandleCreate).Methods(http.MethodPost)
+	v1Router.HandleFunc("/organization/members", organization.HandleAddMember).Methods(http.MethodPost)
+	v1Router.HandleFunc("/organization/members", organization.HandleRemoveMember).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/organization/drafts", organization.HandleListDrafts).Methods(http.MethodGet)
+	v1Router.HandleFunc("/organization/drafts", organization.HandleSaveDraft).Methods(http.MethodPost)
+	v1Router.HandleFunc("/organization/drafts/{id:[0-9]+}", organization.HandleDeleteDraft).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/organization/keys", organization.HandleListKeys).Methods(http.MethodGet)
+	v1Router.HandleFunc("/organization/keys", organization.HandleCreateKey).Methods(http.MethodPost)
+	v1Router.HandleFunc("/organization/keys/{id:[0-9]+}", organization.HandleRevokeKey).Methods(http.MethodDelete)
+	v1Router.HandleFunc("/organization/keys/{id:[0-9]+}/security", organization.HandleGetKeySecurity).Methods(http.MethodGet)
+	v1Router.HandleFunc("/organization/keys/{id:[0-9]+}/security", organization.HandleSetKeySecurity).Methods(http.MethodPut)
+	v1Router.HandleFunc("/organization/keys/{id:[0-9]+}/rotate", organization.HandleRotateKey).Methods(http.MethodPost)
+
 	internalRouter := r.PathPrefix("/internal").Subrouter()
 	internalRouter.Handle("/metrics", promhttp.Handler())
 
+	// Browsers can't set the auth header on WebSockets, so it can be passed as a query param
+	wsRouter := r.PathPrefix("/api/v2/ws").Subrouter()
+	wsRouter.Use(proxy.TokenFromQuery, defaultMiddlewares(sdkRouter, config.GetInternalAPIHost()))
+	wsRouter.HandleFunc("", proxy.HandleWebSocket).Methods(http.MethodGet)
+
 	v2Router := r.PathPrefix("/api/v2").Subrouter()
 	v2Router.Use(defaultMiddlewares(sdkRouter, config.GetInternalAPIHost()))
 	v2Router.HandleFunc("/status", status.GetStatusV2).Methods(http.MethodGet)
@@ -58,13 +224,14 @@ func InstallRoutes(r *mux.Router, sdkRouter *sdkrouter.Router) {
 
 func defaultMiddlewares(rt *sdkrouter.Router, internalAPIHost string) mux.MiddlewareFunc {
 	authProvider := auth.NewIAPIProvider(rt, internalAPIHost)
-	memCache := cache.NewMemoryCache()
 	return middleware.Chain(
+		recovery.Middleware,
 		metrics.MeasureMiddleware(),
 		ip.Middleware,
 		sdkrouter.Middleware(rt),
 		auth.Middleware(authProvider),
-		cache.Middleware(memCache),
+		auth.APIKeyMiddleware(organization.KeyProvider),
+		cache.Middleware(cache.Shared()),
 	)
 }
 
@@ -74,10 +241,17 @@ func methodTimer(next http.Handler) http.Handler {
 
 		next.ServeHTTP(w, r)
 
-		path := r.URL.Path
-		if r.URL.RawQuery != "" && !strings.HasPrefix(path, "/api/v1/metric") {
-			path += "?" + r.URL.RawQuery
-		}
-		metrics.LbrytvCallDurations.WithLabelValues(path).Observe(time.Since(start).Seconds())
+		metrics.LbrytvCallDurations.WithLabelValues(metricPath(r)).Observe(time.Since(start).Seconds())
 	})
 }
+
+// metricPath is the label calls to r are timed under. It's the template of the route r matched,
+// so IDs and tokens in paths and query strings don't make a label value each.
+func metricPath(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
@@ -104,3 +104,14 @@ func TestMiddlewareOrder(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "12345", string(body))
 }
+
+func TestMetricPath(t *testing.T) {
+	r := mux.NewRouter()
+	var path string
+	r.HandleFunc("/api/v1/uploads/{id}", func(w http.ResponseWriter, r *http.Request) { path = metricPath(r) })
+	for _, url := range []string{"/api/v1/uploads/abc", "/api/v1/uploads/def?auth_token=abc"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, nil))
+		assert.Equal(t, "/api/v1/uploads/{id}", path, url)
+	}
+	assert.Equal(t, "unmatched", metricPath(httptest.NewRequest(http.MethodGet, "/api/v1/uploads/abc", nil)))
+}
@@ -0,0 +1,43 @@
+// Package admin guards operator-only HTTP endpoints with a shared admin token.
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TokenHeader is the name of HTTP header which should contain the admin token.
+const TokenHeader = "X-Lbrytv-Admin-Token"
+
+var logger = monitor.NewModuleLogger("admin")
+
+// Middleware only lets through requests carrying the configured admin token.
+// When no admin token is configured, all admin requests are rejected.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAuthorized(r) {
+			logger.WithFields(logrus.Fields{"ip": ip.AddressForRequest(r), "url": r.URL.Path}).Warn("unauthorized admin request")
+			responses.WriteError(w, http.StatusForbidden, errors.Err("admin token missing or invalid"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsAuthorized returns true if request contains a valid admin token.
+func IsAuthorized(r *http.Request) bool {
+	expected := config.GetAdminToken()
+	if expected == "" {
+		return false
+	}
+	token := r.Header.Get(TokenHeader)
+	return subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
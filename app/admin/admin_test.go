@@ -0,0 +1,51 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
+
+func TestMiddleware(t *testing.T) {
+	config.Override("AdminToken", "s3cret")
+	defer config.RestoreOverridden()
+
+	cases := map[string]int{
+		"s3cret": http.StatusOK,
+		"wrong":  http.StatusForbidden,
+		"":       http.StatusForbidden,
+	}
+	for token, status := range cases {
+		t.Run(token, func(t *testing.T) {
+			r, err := http.NewRequest(http.MethodGet, "/api/v1/admin/anything", nil)
+			require.NoError(t, err)
+			if token != "" {
+				r.Header.Set(TokenHeader, token)
+			}
+			rr := httptest.NewRecorder()
+			Middleware(http.HandlerFunc(okHandler)).ServeHTTP(rr, r)
+			assert.Equal(t, status, rr.Code)
+		})
+	}
+}
+
+func TestMiddleware_NotConfigured(t *testing.T) {
+	config.Override("AdminToken", "")
+	defer config.RestoreOverridden()
+
+	r, err := http.NewRequest(http.MethodGet, "/api/v1/admin/anything", nil)
+	require.NoError(t, err)
+	r.Header.Set(TokenHeader, "")
+	rr := httptest.NewRecorder()
+	Middleware(http.HandlerFunc(okHandler)).ServeHTTP(rr, r)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/sirupsen/logrus"
+)
+
+type samplingSettings struct {
+	Period     string `json:"period"`
+	Initial    int    `json:"initial"`
+	Thereafter int    `json:"thereafter"`
+}
+
+type loggingSettings struct {
+	Level    string            `json:"level,omitempty"`
+	Sampling *samplingSettings `json:"sampling,omitempty"`
+}
+
+func currentLoggingSettings() loggingSettings {
+	cfg := monitor.GetSampling()
+	return loggingSettings{
+		Level: monitor.GetLevel().String(),
+		Sampling: &samplingSettings{
+			Period:     cfg.Period.String(),
+			Initial:    cfg.Initial,
+			Thereafter: cfg.Thereafter,
+		},
+	}
+}
+
+// HandleGetLogging returns current log verbosity and sampling settings.
+func HandleGetLogging(w http.ResponseWriter, r *http.Request) {
+	responses.WriteJSON(w, http.StatusOK, currentLoggingSettings())
+}
+
+// HandleSetLogging changes log verbosity and/or sampling settings at runtime.
+// Omitted settings are left unchanged.
+func HandleSetLogging(w http.ResponseWriter, r *http.Request) {
+	var req loggingSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+
+	var level logrus.Level
+	if req.Level != "" {
+		var err error
+		level, err = logrus.ParseLevel(req.Level)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	var sampling monitor.SamplingConfig
+	if req.Sampling != nil {
+		period, err := time.ParseDuration(req.Sampling.Period)
+		if err != nil {
+			WriteError(w, http.StatusBadRequest, errors.Err("invalid sampling period: %v", err))
+			return
+		}
+		sampling = monitor.SamplingConfig{Period: period, Initial: req.Sampling.Initial, Thereafter: req.Sampling.Thereafter}
+	}
+
+	if req.Level != "" {
+		monitor.SetLevel(level)
+		logger.Log().Infof("log level set to %v", level)
+	}
+	if req.Sampling != nil {
+		monitor.SetSampling(sampling)
+	}
+	responses.WriteJSON(w, http.StatusOK, currentLoggingSettings())
+}
@@ -0,0 +1,42 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSetLogging(t *testing.T) {
+	defer monitor.SetLevel(monitor.GetLevel())
+	defer monitor.SetSampling(monitor.GetSampling())
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/admin/logging",
+		strings.NewReader(`{"level": "warn", "sampling": {"period": "10s", "initial": 5, "thereafter": 50}}`))
+	rr := httptest.NewRecorder()
+	HandleSetLogging(rr, r)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	var rsp loggingSettings
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+	assert.Equal(t, "warning", rsp.Level)
+	assert.Equal(t, "10s", rsp.Sampling.Period)
+	assert.Equal(t, logrus.WarnLevel, monitor.GetLevel())
+	assert.Equal(t, monitor.SamplingConfig{Period: 10 * time.Second, Initial: 5, Thereafter: 50}, monitor.GetSampling())
+}
+
+func TestHandleSetLogging_Invalid(t *testing.T) {
+	for _, body := range []string{`{"level": "loud"}`, `{"sampling": {"period": "soon"}}`, `{`} {
+		rr := httptest.NewRecorder()
+		HandleSetLogging(rr, httptest.NewRequest(http.MethodPost, "/api/v1/admin/logging", strings.NewReader(body)))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, body)
+	}
+}
@@ -3,10 +3,13 @@ package auth
 import (
 	"net/http"
 
+	"github.com/lbryio/lbrytv/app/rpcerrors"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/internal/errors"
 	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/storage"
 	"github.com/lbryio/lbrytv/models"
 )
 
@@ -36,6 +39,38 @@ func FromRequest(r *http.Request) (*models.User, error) {
 	return res.user, res.err
 }
 
+// Check returns nil when FromRequest found a user, or the JSON-RPC error to respond with otherwise.
+func Check(user *models.User, err error) error {
+	if err == nil && user != nil {
+		return nil
+	}
+
+	if errors.Is(err, ErrNoAuthInfo) {
+		return rpcerrors.NewAuthRequiredError()
+	} else if errors.Is(err, storage.ErrUnavailable) {
+		return rpcerrors.NewUnavailableError(err)
+	} else if errors.Is(err, wallet.ErrAuthThrottled) {
+		return rpcerrors.NewAuthThrottledError(err)
+	} else if err != nil {
+		return rpcerrors.NewForbiddenError(err)
+	} else if user == nil {
+		return rpcerrors.NewForbiddenError(errors.Err("must authenticate"))
+	}
+
+	return errors.Err("unknown auth error")
+}
+
+// Authenticate returns the user r is authenticated as, for REST endpoints behind Middleware.
+// When there's none, it responds with a JSON error and returns nil.
+func Authenticate(w http.ResponseWriter, r *http.Request) *models.User {
+	user, err := FromRequest(r)
+	if authErr := Check(user, err); authErr != nil {
+		responses.WriteError(w, http.StatusUnauthorized, authErr)
+		return nil
+	}
+	return user
+}
+
 // Provider tries to authenticate using the provided auth token
 type Provider func(token, metaRemoteIP string) (*models.User, error)
 
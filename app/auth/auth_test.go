@@ -3,6 +3,7 @@ package auth
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"github.com/lbryio/lbrytv/internal/errors"
 	"github.com/lbryio/lbrytv/internal/ip"
 	"github.com/lbryio/lbrytv/internal/middleware"
+	"github.com/lbryio/lbrytv/internal/responses"
 	"github.com/lbryio/lbrytv/models"
 
 	"github.com/stretchr/testify/assert"
@@ -105,6 +107,52 @@ func TestMiddleware_Error(t *testing.T) {
 	assert.Equal(t, "something broke", string(body))
 }
 
+func TestAPIKeyMiddleware(t *testing.T) {
+	tokenProvider := func(token, ip string) (*models.User, error) {
+		return &models.User{ID: 1}, nil
+	}
+	keyProvider := func(key, ip string) (*models.User, error) {
+		if key == "good-key" {
+			return &models.User{ID: 2}, nil
+		}
+		return nil, errors.Base("invalid key")
+	}
+	handler := middleware.Apply(middleware.Chain(
+		Middleware(tokenProvider), APIKeyMiddleware(keyProvider),
+	), authChecker)
+
+	cases := []struct {
+		name, token, key string
+		status           int
+		body             string
+	}{
+		{"key", "", "good-key", http.StatusAccepted, "2"},
+		{"bad key", "", "bad-key", http.StatusBadRequest, "invalid key"},
+		{"token takes precedence", "any-token", "good-key", http.StatusAccepted, "1"},
+		{"neither", "", "", http.StatusUnauthorized, "no auth info"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := http.NewRequest("GET", "/api/proxy", nil)
+			require.NoError(t, err)
+			if c.token != "" {
+				r.Header.Set(wallet.TokenHeader, c.token)
+			}
+			if c.key != "" {
+				r.Header.Set(APIKeyHeader, c.key)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			response := rr.Result()
+			body, err := ioutil.ReadAll(response.Body)
+			require.NoError(t, err)
+			assert.Equal(t, c.status, response.StatusCode)
+			assert.Equal(t, c.body, string(body))
+		})
+	}
+}
+
 func TestFromRequestSuccess(t *testing.T) {
 	expected := result{nil, errors.Base("a test")}
 	ctx := context.WithValue(context.Background(), contextKey, expected)
@@ -129,6 +177,34 @@ func TestFromRequestFail(t *testing.T) {
 	assert.Equal(t, "auth.Middleware is required", err.Error())
 }
 
+func TestAuthenticate(t *testing.T) {
+	for _, c := range []struct {
+		res     result
+		status  int
+		message string
+	}{
+		{result{&models.User{ID: 1}, nil}, http.StatusOK, ""},
+		{result{nil, ErrNoAuthInfo}, http.StatusUnauthorized, "authentication required"},
+		{result{nil, errors.Err("invalid token")}, http.StatusUnauthorized, "invalid token"},
+		{result{nil, nil}, http.StatusUnauthorized, "must authenticate"},
+	} {
+		ctx := context.WithValue(context.Background(), contextKey, c.res)
+		r, err := http.NewRequestWithContext(ctx, http.MethodGet, "", nil)
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		user := Authenticate(rr, r)
+		assert.Equal(t, c.status, rr.Code)
+		if c.message == "" {
+			assert.Equal(t, c.res.user, user)
+			continue
+		}
+		assert.Nil(t, user)
+		var body responses.ErrorResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, c.message, body.Error)
+	}
+}
+
 func authChecker(w http.ResponseWriter, r *http.Request) {
 	user, err := FromRequest(r)
 	if user != nil && err != nil {
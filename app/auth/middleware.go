@@ -33,6 +33,29 @@ func Middleware(provider Provider) mux.MiddlewareFunc {
 	}
 }
 
+// APIKeyHeader is the name of HTTP header which may contain an organization API key instead of an auth token.
+const APIKeyHeader = "X-Lbrytv-Api-Key"
+
+// APIKeyMiddleware authenticates requests that carry an API key and no auth token using keyProvider.
+// It has to be placed after Middleware.
+func APIKeyMiddleware(keyProvider Provider) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" || r.Header.Get(wallet.TokenHeader) != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			addr := ip.FromRequest(r)
+			user, err := keyProvider(key, addr)
+			if err != nil {
+				logger.WithFields(logrus.Fields{"ip": addr}).Debugf("error authenticating with api key")
+			}
+			next.ServeHTTP(w, r.Clone(context.WithValue(r.Context(), contextKey, result{user, err})))
+		})
+	}
+}
+
 // NilMiddleware is useful when you need to test your logic without involving real authentication
 var NilMiddleware = Middleware(nilProvider)
 
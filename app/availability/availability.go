@@ -0,0 +1,289 @@
+// Package availability checks that blobs of streams published through lbrytv can still be retrieved.
+//
+// Claims published through lbrytv are tracked (see InstallHooks) and checked by a scheduled task (see CheckDue)
+// or at the creator's request. A check fetches the sd blob of the stream, which lists its content blobs,
+// and probes every content blob. Creators are notified when their content becomes unavailable and can have
+// the stream published again from its original file while lbrytv still keeps it (see Checker.Repair).
+package availability
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	StatusUnknown     = "unknown"
+	StatusAvailable   = "available"
+	StatusUnavailable = "unavailable"
+
+	// maxBlobSize is the size of the largest blob, sd blobs included.
+	maxBlobSize = 2 * 1024 * 1024
+)
+
+var (
+	logger = monitor.NewModuleLogger("availability")
+
+	ErrBlobNotFound  = errors.Base("blob not found")
+	ErrNotFound      = errors.Base("claim availability is not tracked")
+	ErrNotConfigured = errors.Base("content availability checks are not set up")
+	ErrNoStream      = errors.Base("claim has no stream to check")
+	ErrNotRepairable = errors.Base("the original file of the claim is no longer kept")
+	ErrAvailable     = errors.Base("content of the claim is not known to be unavailable")
+)
+
+// BlobSource is where blobs are retrieved from.
+type BlobSource interface {
+	// Get returns the blob, ErrBlobNotFound if it cannot be retrieved.
+	Get(ctx context.Context, hash string) ([]byte, error)
+	// Exists returns true if the blob can be retrieved.
+	Exists(ctx context.Context, hash string) (bool, error)
+}
+
+// Originals are files kept from publishes, which streams of claims can be published again from.
+type Originals interface {
+	HasOriginal(userID int, claimID string) bool
+	Republish(user *models.User, claimID string, params map[string]interface{}) (*jsonrpc.RPCResponse, error)
+}
+
+// HTTPBlobs retrieves blobs from URL, which has {hash} replaced by the blob hash.
+type HTTPBlobs struct {
+	URL    string
+	Client *http.Client
+}
+
+func (b HTTPBlobs) do(ctx context.Context, method, hash string) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.Replace(b.URL, "{hash}", hash, -1), nil)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	client := b.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	return res, nil
+}
+
+// Get fetches the blob.
+func (b HTTPBlobs) Get(ctx context.Context, hash string) ([]byte, error) {
+	res, err := b.do(ctx, http.MethodGet, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrBlobNotFound
+	default:
+		return nil, errors.Err("blob %v: unexpected response %v", hash, res.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxBlobSize+1))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if len(data) > maxBlobSize {
+		return nil, errors.Err("blob %v is larger than %v bytes", hash, maxBlobSize)
+	}
+	return data, nil
+}
+
+// Exists asks for the blob without fetching it.
+func (b HTTPBlobs) Exists(ctx context.Context, hash string) (bool, error) {
+	res, err := b.do(ctx, http.MethodHead, hash)
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, errors.Err("blob %v: unexpected response %v", hash, res.Status)
+}
+
+// Result is the outcome of probing a stream.
+type Result struct {
+	Status     string
+	TotalBlobs int
+	// Missing are hashes of blobs which cannot be retrieved.
+	Missing []string
+}
+
+// sdBlob is the descriptor of a stream, listing its content blobs. The last entry terminates the stream
+// and has no blob.
+type sdBlob struct {
+	Blobs []struct {
+		BlobHash string `json:"blob_hash"`
+	} `json:"blobs"`
+}
+
+// Probe checks that the sd blob and all content blobs of the stream can be retrieved from src,
+// probing up to concurrency blobs at a time.
+func Probe(ctx context.Context, src BlobSource, sdHash string, concurrency int) (*Result, error) {
+	data, err := src.Get(ctx, sdHash)
+	if errors.Is(err, ErrBlobNotFound) {
+		return &Result{Status: StatusUnavailable, TotalBlobs: 1, Missing: []string{sdHash}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var sd sdBlob
+	if err := json.Unmarshal(data, &sd); err != nil {
+		return nil, errors.Err("malformed sd blob %v: %v", sdHash, err)
+	}
+	hashes := make(chan string)
+	go func() {
+		defer close(hashes)
+		for _, b := range sd.Blobs {
+			if b.BlobHash != "" {
+				hashes <- b.BlobHash
+			}
+		}
+	}()
+
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	res := &Result{Status: StatusAvailable, TotalBlobs: 1, Missing: []string{}}
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		probeErr error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for h := range hashes {
+				ok, err := src.Exists(ctx, h)
+				mu.Lock()
+				res.TotalBlobs++
+				if err != nil && probeErr == nil {
+					probeErr = err
+				} else if err == nil && !ok {
+					res.Missing = append(res.Missing, h)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(res.Missing) > 0 {
+		// Blobs which couldn't be probed don't matter once some are known to be missing
+		sort.Strings(res.Missing)
+		res.Status = StatusUnavailable
+		return res, nil
+	}
+	if probeErr != nil {
+		return nil, probeErr
+	}
+	return res, nil
+}
+
+// Checker checks and repairs availability of tracked claims.
+type Checker struct {
+	Blobs       BlobSource
+	Concurrency int
+	// NotifyURL is where notices of content becoming unavailable are posted, nobody is notified when it's empty.
+	NotifyURL string
+	// Originals may be nil, no claims can be repaired then.
+	Originals Originals
+}
+
+// DefaultChecker returns the checker set up in ContentAvailability config, nil if checks are disabled.
+func DefaultChecker(originals Originals) *Checker {
+	cfg := config.GetContentAvailability()
+	if cfg.BlobURL == "" {
+		return nil
+	}
+	return &Checker{
+		Blobs:       HTTPBlobs{URL: cfg.BlobURL, Client: &http.Client{Timeout: cfg.Timeout}},
+		Concurrency: cfg.Concurrency,
+		NotifyURL:   cfg.NotifyURL,
+		Originals:   originals,
+	}
+}
+
+// source returns the sd hash and media type of the stream of the claim.
+func source(sdkAddress, claimID string) (string, string, error) {
+	res, err := query.NewCaller(sdkAddress, 0).Call(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{
+		"claim_id": claimID, "no_totals": true,
+	}))
+	if err != nil {
+		return "", "", err
+	}
+	if res.Error != nil {
+		return "", "", errors.Err("%s", res.Error.Message)
+	}
+	var result struct {
+		Items []struct {
+			Value struct {
+				Source struct {
+					SDHash    string `json:"sd_hash"`
+					MediaType string `json:"media_type"`
+				} `json:"source"`
+			} `json:"value"`
+		} `json:"items"`
+	}
+	if err := res.GetObject(&result); err != nil {
+		return "", "", errors.Err(err)
+	}
+	if len(result.Items) == 0 || result.Items[0].Value.Source.SDHash == "" {
+		return "", "", ErrNoStream
+	}
+	src := result.Items[0].Value.Source
+	return src.SDHash, src.MediaType, nil
+}
+
+// Check probes the stream of the tracked claim and records the result, notifying the creator
+// when the content has become unavailable.
+func (c *Checker) Check(sdkAddress, claimID string) (*Check, error) {
+	if _, err := Get(claimID); err != nil {
+		return nil, err
+	}
+	sdHash, _, err := source(sdkAddress, claimID)
+	var res *Result
+	if err == nil {
+		res, err = Probe(context.Background(), c.Blobs, sdHash, c.Concurrency)
+	}
+	if err != nil {
+		logger.Log().Warnf("cannot check availability of claim %v: %v", claimID, err)
+	}
+	return c.record(claimID, sdHash, res, err)
+}
+
+// CheckDue checks up to batchSize tracked claims which haven't been checked within recheckAfter, oldest checks first,
+// and returns how many were checked.
+func (c *Checker) CheckDue(sdkAddress string, batchSize int, recheckAfter time.Duration) (int, error) {
+	claimIDs, err := due(batchSize, recheckAfter)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range claimIDs {
+		if _, err := c.Check(sdkAddress, id); err != nil {
+			return 0, err
+		}
+	}
+	return len(claimIDs), nil
+}
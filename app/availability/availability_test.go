@@ -0,0 +1,253 @@
+package availability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	sdHash  = "sd0000"
+	claimID = "a1b2c3d4e5f60718293a4b5c6d7e8f9012345678"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+// memBlobs serves blobs it has, failing probes of blobs listed in broken.
+type memBlobs struct {
+	blobs  map[string][]byte
+	broken map[string]bool
+}
+
+func newMemBlobs(contentBlobs ...string) *memBlobs {
+	sd := sdBlob{}
+	b := &memBlobs{blobs: map[string][]byte{}, broken: map[string]bool{}}
+	for _, h := range append(contentBlobs, "") {
+		sd.Blobs = append(sd.Blobs, struct {
+			BlobHash string `json:"blob_hash"`
+		}{h})
+		if h != "" {
+			b.blobs[h] = []byte("content")
+		}
+	}
+	b.blobs[sdHash], _ = json.Marshal(sd)
+	return b
+}
+
+func (b *memBlobs) Get(_ context.Context, hash string) ([]byte, error) {
+	if data, ok := b.blobs[hash]; ok {
+		return data, nil
+	}
+	return nil, ErrBlobNotFound
+}
+
+func (b *memBlobs) Exists(_ context.Context, hash string) (bool, error) {
+	if b.broken[hash] {
+		return false, errors.Err("blob source is down")
+	}
+	_, ok := b.blobs[hash]
+	return ok, nil
+}
+
+func TestProbe(t *testing.T) {
+	src := newMemBlobs("b1", "b2", "b3")
+	res, err := Probe(context.Background(), src, sdHash, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusAvailable, res.Status)
+	assert.Equal(t, 4, res.TotalBlobs)
+	assert.Empty(t, res.Missing)
+
+	delete(src.blobs, "b3")
+	delete(src.blobs, "b1")
+	res, err = Probe(context.Background(), src, sdHash, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnavailable, res.Status)
+	assert.Equal(t, []string{"b1", "b3"}, res.Missing)
+
+	// Missing blobs decide the check even when others couldn't be probed
+	src.broken["b2"] = true
+	res, err = Probe(context.Background(), src, sdHash, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnavailable, res.Status)
+
+	delete(src.blobs, sdHash)
+	res, err = Probe(context.Background(), src, sdHash, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnavailable, res.Status)
+	assert.Equal(t, []string{sdHash}, res.Missing)
+}
+
+func TestProbe_Error(t *testing.T) {
+	src := newMemBlobs("b1", "b2")
+	src.broken["b2"] = true
+	_, err := Probe(context.Background(), src, sdHash, 1)
+	assert.Error(t, err)
+
+	src.blobs[sdHash] = []byte("not json")
+	_, err = Probe(context.Background(), src, sdHash, 1)
+	assert.Error(t, err)
+}
+
+func TestHTTPBlobs(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("hash") {
+		case "present":
+			w.Write([]byte("blob"))
+		case "broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	b := HTTPBlobs{URL: ts.URL + "/blob?hash={hash}"}
+
+	data, err := b.Get(context.Background(), "present")
+	require.NoError(t, err)
+	assert.Equal(t, "blob", string(data))
+	_, err = b.Get(context.Background(), "absent")
+	assert.True(t, errors.Is(err, ErrBlobNotFound))
+	_, err = b.Get(context.Background(), "broken")
+	assert.Error(t, err)
+
+	ok, err := b.Exists(context.Background(), "present")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = b.Exists(context.Background(), "absent")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	_, err = b.Exists(context.Background(), "broken")
+	assert.Error(t, err)
+}
+
+// sdkServer responds to claim_search with a stream claim of sdHash.
+func sdkServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "id": 0, "result": {"items": [
+			{"claim_id": "%v", "value": {"source": {"sd_hash": "%v", "media_type": "video/mp4"}}}
+		]}}`, claimID, sdHash)
+	}))
+}
+
+// fakeOriginals has the original of every claim and records republishes.
+type fakeOriginals struct {
+	republished []map[string]interface{}
+}
+
+func (o *fakeOriginals) HasOriginal(int, string) bool { return true }
+
+func (o *fakeOriginals) Republish(_ *models.User, _ string, params map[string]interface{}) (*jsonrpc.RPCResponse, error) {
+	o.republished = append(o.republished, params)
+	return &jsonrpc.RPCResponse{Result: map[string]interface{}{"txid": "abc"}}, nil
+}
+
+func countNotices(t *testing.T, url string) int {
+	var n int
+	require.NoError(t, boil.GetDB().QueryRow(`SELECT count(*) FROM outbox_messages WHERE destination = $1`, url).Scan(&n))
+	return n
+}
+
+func TestChecker_Check(t *testing.T) {
+	sdk := sdkServer()
+	defer sdk.Close()
+	_, err := boil.GetDB().Exec(`DELETE FROM content_checks`)
+	require.NoError(t, err)
+
+	src := newMemBlobs("b1", "b2")
+	originals := &fakeOriginals{}
+	notifyURL := fmt.Sprintf("http://creators.example.com/notify/%v", time.Now().UnixNano())
+	c := &Checker{Blobs: src, Concurrency: 2, NotifyURL: notifyURL, Originals: originals}
+
+	_, err = c.Check(sdk.URL, claimID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	require.NoError(t, Track(7, claimID))
+	check, err := c.Check(sdk.URL, claimID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusAvailable, check.Status)
+	assert.Equal(t, sdHash, check.SDHash)
+	assert.Equal(t, 3, check.TotalBlobs)
+	assert.True(t, check.CheckedAt.Valid)
+	assert.Equal(t, 0, countNotices(t, notifyURL))
+
+	// The creator is notified once, when the claim becomes unavailable
+	delete(src.blobs, "b2")
+	check, err = c.Check(sdk.URL, claimID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnavailable, check.Status)
+	assert.JSONEq(t, `["b2"]`, string(check.MissingBlobs))
+	assert.True(t, check.NotifiedAt.Valid)
+	_, err = c.Check(sdk.URL, claimID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, countNotices(t, notifyURL))
+
+	// Claims of other users are not found
+	_, err = c.Repair(&models.User{ID: 8}, claimID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	res, err := c.Repair(&models.User{ID: 7}, claimID)
+	require.NoError(t, err)
+	assert.NotNil(t, res.Result)
+	require.Len(t, originals.republished, 1)
+	check, err = GetOwned(7, claimID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusUnknown, check.Status)
+	assert.True(t, check.RepairedAt.Valid)
+	assert.False(t, check.CheckedAt.Valid)
+
+	// Repaired claims aren't repaired again until found unavailable
+	_, err = c.Repair(&models.User{ID: 7}, claimID)
+	assert.True(t, errors.Is(err, ErrAvailable))
+}
+
+func TestChecker_CheckDue(t *testing.T) {
+	sdk := sdkServer()
+	defer sdk.Close()
+	_, err := boil.GetDB().Exec(`DELETE FROM content_checks`)
+	require.NoError(t, err)
+
+	c := &Checker{Blobs: newMemBlobs("b1"), Concurrency: 1}
+	require.NoError(t, Track(7, claimID))
+	n, err := c.CheckDue(sdk.URL, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	n, err = c.CheckDue(sdk.URL, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// Republished claims are due again
+	require.NoError(t, Track(7, claimID))
+	n, err = c.CheckDue(sdk.URL, 10, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
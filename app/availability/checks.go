@@ -0,0 +1,219 @@
+package availability
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Check is the latest availability check of a tracked claim.
+type Check struct {
+	ClaimID      string          `json:"claim_id"`
+	UserID       int             `json:"user_id"`
+	SDHash       string          `json:"sd_hash"`
+	Status       string          `json:"status"`
+	TotalBlobs   int             `json:"total_blobs"`
+	MissingBlobs json.RawMessage `json:"missing_blobs"`
+	Error        string          `json:"error,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	CheckedAt    null.Time       `json:"checked_at"`
+	NotifiedAt   null.Time       `json:"notified_at"`
+	RepairedAt   null.Time       `json:"repaired_at"`
+	// Repairable is set by handlers, it is true while the original file of the claim is kept.
+	Repairable bool `json:"repairable"`
+}
+
+const checkColumns = `claim_id, user_id, sd_hash, status, total_blobs, missing_blobs, error, created_at,
+	checked_at, notified_at, repaired_at`
+
+func scanCheck(s interface{ Scan(...interface{}) error }) (*Check, error) {
+	c := &Check{}
+	var missing []byte
+	err := s.Scan(&c.ClaimID, &c.UserID, &c.SDHash, &c.Status, &c.TotalBlobs, &missing, &c.Error, &c.CreatedAt,
+		&c.CheckedAt, &c.NotifiedAt, &c.RepairedAt)
+	c.MissingBlobs = missing
+	return c, err
+}
+
+// Track adds the claim published by the user to claims checked for availability. Claims tracked already
+// are checked again soon, as their stream could have been replaced.
+func Track(userID int, claimID string) error {
+	_, err := boil.GetDB().Exec(
+		`INSERT INTO content_checks (claim_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (claim_id) DO UPDATE SET user_id = $2, status = $3, checked_at = NULL`,
+		claimID, userID, StatusUnknown,
+	)
+	return errors.Err(err)
+}
+
+// Get returns the latest check of the claim.
+func Get(claimID string) (*Check, error) {
+	c, err := scanCheck(boil.GetDB().QueryRow(`SELECT `+checkColumns+` FROM content_checks WHERE claim_id = $1`, claimID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return c, nil
+}
+
+// GetOwned returns the latest check of the claim if it was published by the user, ErrNotFound otherwise.
+func GetOwned(userID int, claimID string) (*Check, error) {
+	c, err := Get(claimID)
+	if err != nil {
+		return nil, err
+	}
+	if c.UserID != userID {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+// due returns up to limit claims which haven't been checked within recheckAfter, never checked ones first.
+func due(limit int, recheckAfter time.Duration) ([]string, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT claim_id FROM content_checks WHERE checked_at IS NULL OR checked_at < $1
+		ORDER BY checked_at NULLS FIRST LIMIT $2`,
+		time.Now().Add(-recheckAfter), limit,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Err(err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, errors.Err(rows.Err())
+}
+
+// record stores the outcome of checking the claim. Claims which couldn't be checked get the unknown status
+// along with the error. The creator is notified the first time the claim is found unavailable, the notice
+// being queued in the same transaction as the status change.
+func (c *Checker) record(claimID, sdHash string, res *Result, checkErr error) (*Check, error) {
+	status, total, missing, errMsg := StatusUnknown, 0, []string{}, ""
+	if checkErr != nil {
+		errMsg = checkErr.Error()
+	} else {
+		status, total, missing = res.Status, res.TotalBlobs, res.Missing
+	}
+	rawMissing, err := json.Marshal(missing)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+
+	tx, err := boil.Begin()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	var prevStatus string
+	if err := tx.QueryRow(`SELECT status FROM content_checks WHERE claim_id = $1 FOR UPDATE`, claimID).Scan(&prevStatus); err != nil {
+		tx.Rollback()
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Err(err)
+	}
+	check, err := scanCheck(tx.QueryRow(
+		`UPDATE content_checks SET sd_hash = $2, status = $3, total_blobs = $4, missing_blobs = $5, error = $6,
+		checked_at = now() WHERE claim_id = $1 RETURNING `+checkColumns,
+		claimID, sdHash, status, total, rawMissing, errMsg,
+	))
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	if status == StatusUnavailable && prevStatus != StatusUnavailable && c.NotifyURL != "" {
+		if _, err := outbox.Enqueue(tx, outbox.KindWebhook, c.NotifyURL, c.unavailableNotice(check)); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err := tx.QueryRow(
+			`UPDATE content_checks SET notified_at = now() WHERE claim_id = $1 RETURNING notified_at`, claimID,
+		).Scan(&check.NotifiedAt); err != nil {
+			tx.Rollback()
+			return nil, errors.Err(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+
+	metrics.ContentChecks.WithLabelValues(status).Inc()
+	if status != prevStatus {
+		logger.WithFields(logrus.Fields{"claim_id": claimID, "user_id": check.UserID, "missing_blobs": len(missing)}).
+			Infof("content availability changed from %v to %v", prevStatus, status)
+	}
+	return check, nil
+}
+
+func (c *Checker) unavailableNotice(check *Check) map[string]interface{} {
+	return map[string]interface{}{
+		"event":         "content_unavailable",
+		"claim_id":      check.ClaimID,
+		"user_id":       check.UserID,
+		"sd_hash":       check.SDHash,
+		"total_blobs":   check.TotalBlobs,
+		"missing_blobs": check.MissingBlobs,
+		"repairable":    c.Originals != nil && c.Originals.HasOriginal(check.UserID, check.ClaimID),
+	}
+}
+
+// Repair publishes the stream of the user's claim again from its original file. Only claims found unavailable
+// can be repaired, as the update is a transaction paid for by the user. The claim is checked again
+// once the SDK has reflected the new stream.
+func (c *Checker) Repair(user *models.User, claimID string) (*jsonrpc.RPCResponse, error) {
+	check, err := GetOwned(user.ID, claimID)
+	if err != nil {
+		return nil, err
+	}
+	if check.Status != StatusUnavailable {
+		return nil, ErrAvailable
+	}
+	if c.Originals == nil || !c.Originals.HasOriginal(user.ID, claimID) {
+		return nil, ErrNotRepairable
+	}
+
+	// The SDK guesses the media type from the file name, which the kept original doesn't have
+	params := map[string]interface{}{}
+	if address := sdkrouter.GetSDKAddress(user); address != "" {
+		if _, mediaType, err := source(address, claimID); err == nil && mediaType != "" {
+			params["media_type"] = mediaType
+		}
+	}
+	res, err := c.Originals.Republish(user, claimID, params)
+	if err == nil && res.Error != nil {
+		err = errors.Err("%s", res.Error.Message)
+	}
+	if err != nil {
+		metrics.ContentRepairs.WithLabelValues(metrics.ContentRepairFail).Inc()
+		logger.WithFields(logrus.Fields{"claim_id": claimID, "user_id": user.ID}).Errorf("cannot repair content: %v", err)
+		return res, err
+	}
+
+	_, err = boil.GetDB().Exec(
+		`UPDATE content_checks SET status = $2, checked_at = NULL, notified_at = NULL, repaired_at = now()
+		WHERE claim_id = $1`, claimID, StatusUnknown,
+	)
+	if err != nil {
+		logger.Log().Errorf("cannot record repair of %v: %v", claimID, err)
+	}
+	metrics.ContentRepairs.WithLabelValues(metrics.ContentRepaired).Inc()
+	logger.WithFields(logrus.Fields{"claim_id": claimID, "user_id": user.ID}).Info("content republished")
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package availability
+
+import (
+	"net/http"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler serves availability of claims to their creators. Claims of other users are reported as not found.
+type Handler struct {
+	Originals Originals
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotConfigured):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, ErrNotRepairable), errors.Is(err, ErrAvailable):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+// request authenticates the user and returns the claim ID from the path.
+func (h Handler) request(w http.ResponseWriter, r *http.Request) (*models.User, string, bool) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return nil, "", false
+	}
+	return user, mux.Vars(r)["claim_id"], true
+}
+
+func (h Handler) write(w http.ResponseWriter, c *Check) {
+	c.Repairable = h.Originals != nil && h.Originals.HasOriginal(c.UserID, c.ClaimID)
+	w.Header().Set("Cache-Control", "no-store")
+	responses.WriteJSON(w, http.StatusOK, c)
+}
+
+// HandleGet returns the latest availability check of the user's claim.
+func (h Handler) HandleGet(w http.ResponseWriter, r *http.Request) {
+	user, claimID, ok := h.request(w, r)
+	if !ok {
+		return
+	}
+	c, err := GetOwned(user.ID, claimID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.write(w, c)
+}
+
+// HandleCheck checks availability of the user's claim right away.
+func (h Handler) HandleCheck(w http.ResponseWriter, r *http.Request) {
+	user, claimID, ok := h.request(w, r)
+	if !ok {
+		return
+	}
+	checker := DefaultChecker(h.Originals)
+	if checker == nil {
+		writeError(w, ErrNotConfigured)
+		return
+	}
+	if _, err := GetOwned(user.ID, claimID); err != nil {
+		writeError(w, err)
+		return
+	}
+	c, err := checker.Check(sdkrouter.GetSDKAddress(user), claimID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	h.write(w, c)
+}
+
+// HandleRepair publishes the stream of the user's unavailable claim again from its original file
+// and responds with the stream_update response, errors returned by the SDK included.
+func (h Handler) HandleRepair(w http.ResponseWriter, r *http.Request) {
+	user, claimID, ok := h.request(w, r)
+	if !ok {
+		return
+	}
+	checker := DefaultChecker(h.Originals)
+	if checker == nil {
+		writeError(w, ErrNotConfigured)
+		return
+	}
+	res, err := checker.Repair(user, claimID)
+	if err != nil && res == nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, res)
+}
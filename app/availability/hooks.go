@@ -0,0 +1,49 @@
+package availability
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "availability"
+
+// streamMethods publish streams whose claims are tracked.
+var streamMethods = []string{"publish", "stream_create", "stream_update"}
+
+// InstallHooks makes c track streams published by its user.
+func InstallHooks(c *query.Caller) {
+	for _, m := range streamMethods {
+		c.AddPostflightHook(m, track, hookName)
+	}
+}
+
+func track(c *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if c.UserID() == 0 || hctx.Response == nil || hctx.Response.Error != nil || !isStreamMethod(hctx.Query.Method()) {
+		return nil, nil
+	}
+	tx, _ := hctx.Response.Result.(map[string]interface{})
+	outputs, _ := tx["outputs"].([]interface{})
+	for _, o := range outputs {
+		txo, ok := o.(map[string]interface{})
+		if !ok || txo["type"] != "claim" || txo["value_type"] != "stream" {
+			continue
+		}
+		if id, ok := txo["claim_id"].(string); ok && id != "" {
+			if err := Track(c.UserID(), id); err != nil {
+				logger.Log().Errorf("cannot track availability of claim %v: %v", id, err)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// isStreamMethod is needed because hooks also match methods by prefix.
+func isStreamMethod(method string) bool {
+	for _, m := range streamMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+// Package balance lets clients wait for wallet balance changes instead of polling wallet_balance.
+//
+// A long poll (see Handler.HandlePoll) is held until the balance differs from the one the client has seen,
+// identified by its digest. Wallet calls proxied through lbrytv which may change the balance wake waiting
+// polls of the user (see InstallHooks), changes made elsewhere, like confirmations of incoming transactions,
+// are picked up by re-checking the balance periodically. Changes noticed by one instance are passed on to
+// the others through Redis, see ShareNotifications.
+package balance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "balance_notify"
+
+var (
+	logger = monitor.NewModuleLogger("balance")
+
+	// ErrTooManyWaiters is returned by Notifier.Subscribe when the user already has the maximum number of waiters.
+	ErrTooManyWaiters = errors.Base("too many balance polls are waiting")
+
+	notifier = NewNotifier()
+)
+
+// changingMethods spend from or add to the wallet.
+var changingMethods = []string{
+	"wallet_send",
+	"support_create",
+	"support_abandon",
+	"publish",
+	"stream_create",
+	"stream_update",
+	"stream_abandon",
+	"stream_repost",
+	"channel_create",
+	"channel_update",
+	"channel_abandon",
+	"collection_create",
+	"collection_update",
+	"collection_abandon",
+	"purchase_create",
+	"txo_spend",
+	"account_fund",
+}
+
+// Notifier wakes up goroutines waiting for balance changes of a user.
+type Notifier struct {
+	mu      sync.Mutex
+	waiters map[int]map[chan struct{}]bool
+}
+
+// NewNotifier creates a notifier with no waiters.
+func NewNotifier() *Notifier {
+	return &Notifier{waiters: map[int]map[chan struct{}]bool{}}
+}
+
+// Subscribe returns a channel which receives a value on every Notify for the user, and a function
+// to call when the channel is no longer waited on. Notifications made while the previous one hasn't been
+// received yet are merged into it. ErrTooManyWaiters is returned if the user already has max subscriptions,
+// there's no limit when it's zero.
+func (n *Notifier) Subscribe(userID, max int) (<-chan struct{}, func(), error) {
+	ch := make(chan struct{}, 1)
+	n.mu.Lock()
+	if max > 0 && len(n.waiters[userID]) >= max {
+		n.mu.Unlock()
+		return nil, nil, ErrTooManyWaiters
+	}
+	if n.waiters[userID] == nil {
+		n.waiters[userID] = map[chan struct{}]bool{}
+	}
+	n.waiters[userID][ch] = true
+	n.mu.Unlock()
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if w, ok := n.waiters[userID]; ok {
+			delete(w, ch)
+			if len(w) == 0 {
+				delete(n.waiters, userID)
+			}
+		}
+	}, nil
+}
+
+// Notify wakes up everyone waiting for balance changes of the user.
+func (n *Notifier) Notify(userID int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.waiters[userID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Waiting returns the number of subscriptions for the user.
+func (n *Notifier) Waiting(userID int) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.waiters[userID])
+}
+
+// Notify wakes up polls waiting for balance changes of the user, on all instances if they're shared.
+func Notify(userID int) {
+	notifier.Notify(userID)
+	publish(userID)
+}
+
+// InstallHooks makes c wake up balance polls of its user after successful calls which may change the balance.
+func InstallHooks(c *query.Caller) {
+	c.AddPostflightHook(query.AllMethodsHook, notify, hookName)
+}
+
+func notify(c *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if c.UserID() == 0 || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	if !isChanging(hctx.Query.Method()) {
+		return nil, nil
+	}
+	logger.Log().Debugf("balance of user %v may have changed after %v", c.UserID(), hctx.Query.Method())
+	Notify(c.UserID())
+	return nil, nil
+}
+
+func isChanging(method string) bool {
+	for _, m := range changingMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Digest identifies a balance, so clients can tell the proxy which one they've already seen.
+func Digest(balance interface{}) (string, error) {
+	b, err := json.Marshal(balance)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8]), nil
+}
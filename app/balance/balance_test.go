@@ -0,0 +1,219 @@
+package balance
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// counterFetcher returns a balance equal to the number of times it was incremented.
+type counterFetcher struct {
+	value, calls int32
+}
+
+func (f *counterFetcher) fetch(_ *http.Request, _ *models.User) (interface{}, error) {
+	atomic.AddInt32(&f.calls, 1)
+	return map[string]interface{}{"available": atomic.LoadInt32(&f.value)}, nil
+}
+
+func poll(h Handler, query string) (*httptest.ResponseRecorder, State) {
+	provider := func(token, ip string) (*models.User, error) { return &models.User{ID: 42}, nil }
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/wallet/balance?"+query, nil)
+	r.Header.Set(wallet.TokenHeader, "token")
+	rr := httptest.NewRecorder()
+	auth.Middleware(provider)(http.HandlerFunc(h.HandlePoll)).ServeHTTP(rr, r)
+	var s State
+	json.Unmarshal(rr.Body.Bytes(), &s)
+	return rr, s
+}
+
+func TestNotifier(t *testing.T) {
+	n := NewNotifier()
+	ch, unsubscribe, err := n.Subscribe(1, 0)
+	require.NoError(t, err)
+	other, unsubscribeOther, err := n.Subscribe(2, 0)
+	require.NoError(t, err)
+	defer unsubscribeOther()
+	assert.Equal(t, 1, n.Waiting(1))
+
+	n.Notify(1)
+	// Notifications not received yet are merged
+	n.Notify(1)
+	select {
+	case <-ch:
+	default:
+		t.Fatal("subscriber was not notified")
+	}
+	select {
+	case <-ch:
+		t.Fatal("subscriber was notified twice")
+	case <-other:
+		t.Fatal("another user's subscriber was notified")
+	default:
+	}
+	assert.Equal(t, 1, n.Waiting(1))
+	unsubscribe()
+	assert.Equal(t, 0, n.Waiting(1))
+}
+
+func TestNotifier_MaxWaiters(t *testing.T) {
+	n := NewNotifier()
+	_, unsubscribe, err := n.Subscribe(1, 2)
+	require.NoError(t, err)
+	_, _, err = n.Subscribe(1, 2)
+	require.NoError(t, err)
+	_, _, err = n.Subscribe(1, 2)
+	assert.True(t, errors.Is(err, ErrTooManyWaiters))
+	_, _, err = n.Subscribe(2, 2)
+	assert.NoError(t, err)
+
+	unsubscribe()
+	_, _, err = n.Subscribe(1, 2)
+	assert.NoError(t, err)
+}
+
+func TestHandlePoll_Immediate(t *testing.T) {
+	f := &counterFetcher{}
+	h := Handler{Fetch: f.fetch}
+
+	rr, s := poll(h, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, s.Changed)
+	assert.NotEmpty(t, s.Digest)
+
+	// A stale digest is answered at once
+	rr, s2 := poll(h, "since=0000")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, s2.Changed)
+	assert.Equal(t, s.Digest, s2.Digest)
+}
+
+func TestHandlePoll_WokenByChange(t *testing.T) {
+	config.Override("BalancePolling", map[string]interface{}{"MaxWait": 5 * time.Second, "CheckInterval": 0})
+	defer config.RestoreOverridden()
+
+	f := &counterFetcher{}
+	h := Handler{Fetch: f.fetch}
+	_, s := poll(h, "")
+
+	done := make(chan State)
+	go func() {
+		_, s := poll(h, "since="+s.Digest)
+		done <- s
+	}()
+	for notifier.Waiting(42) == 0 || atomic.LoadInt32(&f.calls) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	atomic.AddInt32(&f.value, 1)
+	Notify(42)
+
+	select {
+	case changed := <-done:
+		assert.True(t, changed.Changed)
+		assert.NotEqual(t, s.Digest, changed.Digest)
+	case <-time.After(3 * time.Second):
+		t.Fatal("poll was not woken up")
+	}
+}
+
+func TestHandlePoll_PicksUpOutsideChanges(t *testing.T) {
+	config.Override("BalancePolling", map[string]interface{}{"MaxWait": 5 * time.Second, "CheckInterval": 20 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	f := &counterFetcher{}
+	h := Handler{Fetch: f.fetch}
+	_, s := poll(h, "")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&f.value, 1)
+	}()
+	_, changed := poll(h, "since="+s.Digest)
+	assert.True(t, changed.Changed)
+}
+
+func TestHandlePoll_Timeout(t *testing.T) {
+	f := &counterFetcher{}
+	h := Handler{Fetch: f.fetch}
+	_, s := poll(h, "")
+
+	start := time.Now()
+	rr, same := poll(h, "timeout=1&since="+s.Digest)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, same.Changed)
+	assert.Equal(t, s.Digest, same.Digest)
+	assert.True(t, time.Since(start) >= time.Second)
+
+	rr, _ = poll(h, "timeout=soon")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandlePoll_TooManyWaiters(t *testing.T) {
+	config.Override("BalancePolling", map[string]interface{}{"MaxWait": 5 * time.Second, "MaxWaitersPerUser": 1})
+	defer config.RestoreOverridden()
+
+	_, unsubscribe, err := notifier.Subscribe(42, 1)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	rr, _ := poll(Handler{Fetch: (&counterFetcher{}).fetch}, "")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+// recordingPublisher keeps messages published instead of sending them to Redis.
+type recordingPublisher struct {
+	messages []string
+}
+
+func (p *recordingPublisher) Publish(channel string, message []byte) (int, error) {
+	p.messages = append(p.messages, channel+" "+string(message))
+	return 1, nil
+}
+
+func TestSharedNotifications(t *testing.T) {
+	p := &recordingPublisher{}
+	setShared(p, "lbrytv:balance:changes")
+	defer setShared(nil, "")
+
+	wake, unsubscribe, err := notifier.Subscribe(43, 0)
+	require.NoError(t, err)
+	defer unsubscribe()
+
+	Notify(43)
+	require.Equal(t, []string{"lbrytv:balance:changes " + instanceID + ":43"}, p.messages)
+	<-wake
+
+	// Messages of this instance have already been acted on
+	receive([]byte(instanceID + ":43"))
+	receive([]byte("other:abc"))
+	select {
+	case <-wake:
+		t.Fatal("poll was woken up again")
+	default:
+	}
+
+	receive([]byte("other:43"))
+	select {
+	case <-wake:
+	default:
+		t.Fatal("poll was not woken up by a change made elsewhere")
+	}
+}
+
+func TestIsChanging(t *testing.T) {
+	assert.True(t, isChanging("wallet_send"))
+	assert.True(t, isChanging("support_create"))
+	assert.False(t, isChanging("wallet_balance"))
+	assert.False(t, isChanging("support_"))
+}
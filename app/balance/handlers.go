@@ -0,0 +1,145 @@
+package balance
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// Fetcher returns the current balance of the user's wallet.
+type Fetcher func(r *http.Request, user *models.User) (interface{}, error)
+
+// Handler serves wallet balance long polls.
+type Handler struct {
+	// Fetch defaults to calling wallet_balance on the user's SDK server.
+	Fetch Fetcher
+}
+
+// State is the response to a balance poll.
+type State struct {
+	Balance interface{} `json:"balance"`
+	Digest  string      `json:"digest"`
+	// Changed is false when the poll timed out with the balance the client has already seen.
+	Changed bool `json:"changed"`
+}
+
+// HandlePoll returns the wallet balance of the authenticated user. When the since query parameter is set
+// to the digest of the balance the client has seen, the request is held until the balance changes,
+// for up to the timeout parameter (in seconds) or BalancePolling.MaxWait, whichever is shorter.
+func (h Handler) HandlePoll(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+
+	cfg := config.GetBalancePolling()
+	wait := cfg.MaxWait
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		secs, err := strconv.Atoi(t)
+		if err != nil || secs < 0 {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("timeout must be a number of seconds"))
+			return
+		}
+		if d := time.Duration(secs) * time.Second; d < wait {
+			wait = d
+		}
+	}
+	since := r.URL.Query().Get("since")
+
+	// Subscribing before the first fetch so a change made right after it isn't missed
+	wake, unsubscribe, err := notifier.Subscribe(user.ID, cfg.MaxWaitersPerUser)
+	if err != nil {
+		metrics.BalancePolls.WithLabelValues(metrics.BalancePollRejected).Inc()
+		responses.WriteError(w, http.StatusTooManyRequests, err)
+		return
+	}
+	defer unsubscribe()
+
+	state, err := h.state(r, user, since)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	if state.Changed || wait <= 0 {
+		metrics.BalancePolls.WithLabelValues(metrics.BalancePollImmediate).Inc()
+		responses.WriteJSON(w, http.StatusOK, state)
+		return
+	}
+
+	metrics.BalanceWaiting.Inc()
+	defer metrics.BalanceWaiting.Dec()
+	timeout := time.NewTimer(wait)
+	defer timeout.Stop()
+	var check <-chan time.Time
+	if cfg.CheckInterval > 0 {
+		ticker := time.NewTicker(cfg.CheckInterval)
+		defer ticker.Stop()
+		check = ticker.C
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timeout.C:
+			metrics.BalancePolls.WithLabelValues(metrics.BalancePollTimedOut).Inc()
+			responses.WriteJSON(w, http.StatusOK, state)
+			return
+		case <-wake:
+		case <-check:
+		}
+		state, err = h.state(r, user, since)
+		if err != nil {
+			responses.WriteError(w, http.StatusBadGateway, err)
+			return
+		}
+		if state.Changed {
+			metrics.BalancePolls.WithLabelValues(metrics.BalancePollChanged).Inc()
+			responses.WriteJSON(w, http.StatusOK, state)
+			return
+		}
+	}
+}
+
+func (h Handler) state(r *http.Request, user *models.User, since string) (*State, error) {
+	fetch := h.Fetch
+	if fetch == nil {
+		fetch = fetchFromSDK
+	}
+	b, err := fetch(r, user)
+	if err != nil {
+		return nil, err
+	}
+	digest, err := Digest(b)
+	if err != nil {
+		return nil, err
+	}
+	return &State{Balance: b, Digest: digest, Changed: digest != since}, nil
+}
+
+func fetchFromSDK(r *http.Request, user *models.User) (interface{}, error) {
+	addr := sdkrouter.GetSDKAddress(user)
+	if addr == "" {
+		addr = sdkrouter.FromRequest(r).ServerFor(query.MethodWalletBalance).Address
+	}
+	c := query.NewCaller(addr, user.ID)
+	c.Context = r.Context()
+	res, err := c.Call(jsonrpc.NewRequest(query.MethodWalletBalance))
+	if err != nil {
+		return nil, errors.Prefix("error fetching balance", err)
+	}
+	if res.Error != nil {
+		return nil, errors.Err("error fetching balance: %v", res.Error.Message)
+	}
+	return res.Result, nil
+}
@@ -0,0 +1,93 @@
+package balance
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/redis"
+)
+
+// Balance changes are published on a Redis channel as "<instance ID>:<user ID>", so polls held by any instance
+// are woken up. Instances skip their own messages as they've already notified their polls.
+
+// publisher is the part of redis.Client used to send changes to other instances.
+type publisher interface {
+	Publish(channel string, message []byte) (int, error)
+}
+
+var (
+	instanceID = newInstanceID()
+
+	sharedMu      sync.RWMutex
+	sharedClient  publisher
+	sharedChannel string
+)
+
+func newInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// NewRedisClient returns a client for sharing balance changes as set in cfg.
+func NewRedisClient(cfg config.BalancePollingRedis) (*redis.Client, error) {
+	client := redis.New(redis.Config{
+		Address:  cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		Timeout:  cfg.Timeout,
+		PoolSize: cfg.PoolSize,
+	})
+	if err := client.Ping(); err != nil {
+		return nil, errors.Prefix("cannot connect to balance polling redis", err)
+	}
+	return client, nil
+}
+
+// ShareNotifications publishes balance changes noticed by this instance on channel and wakes up
+// polls of users whose balance changed through other instances publishing there.
+func ShareNotifications(client *redis.Client, channel string) (*redis.Subscription, error) {
+	sub, err := client.Subscribe(channel, receive)
+	if err != nil {
+		return nil, errors.Prefix("cannot subscribe to balance changes", err)
+	}
+	setShared(client, channel)
+	return sub, nil
+}
+
+func setShared(client publisher, channel string) {
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	sharedClient, sharedChannel = client, channel
+}
+
+func publish(userID int) {
+	sharedMu.RLock()
+	client, channel := sharedClient, sharedChannel
+	sharedMu.RUnlock()
+	if client == nil {
+		return
+	}
+	if _, err := client.Publish(channel, []byte(fmt.Sprintf("%v:%v", instanceID, userID))); err != nil {
+		logger.Log().Warnf("cannot publish balance change of user %v: %v", userID, err)
+	}
+}
+
+func receive(message []byte) {
+	parts := strings.SplitN(string(message), ":", 2)
+	if len(parts) != 2 || parts[0] == instanceID {
+		return
+	}
+	userID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		logger.Log().Warnf("malformed balance change message %q", message)
+		return
+	}
+	notifier.Notify(userID)
+}
@@ -0,0 +1,192 @@
+// Package canary runs synthetic end-to-end probes against the service dependencies
+// so failures are noticed before users start reporting them.
+package canary
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+	StatusPending = "pending"
+)
+
+var (
+	logger = monitor.NewModuleLogger("canary")
+
+	runnerMu      sync.RWMutex
+	defaultRunner *Runner
+)
+
+// Probe is a single canary operation. Run should return an error if the operation did not succeed.
+type Probe struct {
+	Name string
+	Run  func() error
+}
+
+// Result holds the outcome of the last probe run.
+type Result struct {
+	Name    string    `json:"name"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+	Latency float64   `json:"latency_seconds"`
+	LastRun time.Time `json:"last_run,omitempty"`
+}
+
+// Runner periodically executes probes and keeps their latest results.
+type Runner struct {
+	interval time.Duration
+	probes   []Probe
+	stop     chan struct{}
+
+	mu      sync.RWMutex
+	results map[string]*Result
+}
+
+// NewRunner creates a runner which will execute probes every interval once started.
+func NewRunner(interval time.Duration, probes ...Probe) *Runner {
+	r := &Runner{
+		interval: interval,
+		probes:   probes,
+		stop:     make(chan struct{}),
+		results:  map[string]*Result{},
+	}
+	for _, p := range probes {
+		r.results[p.Name] = &Result{Name: p.Name, Status: StatusPending}
+	}
+	return r
+}
+
+// SetRunner sets the runner whose results are reported by HandleHealthz and Current.
+func SetRunner(r *Runner) {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	defaultRunner = r
+}
+
+// Current returns the runner set by SetRunner, nil if canaries are not running.
+func Current() *Runner {
+	runnerMu.RLock()
+	defer runnerMu.RUnlock()
+	return defaultRunner
+}
+
+// Start runs probes immediately and then every interval until Stop is called. It blocks.
+func (r *Runner) Start() {
+	logger.Log().Infof("starting %d canary probes every %v", len(r.probes), r.interval)
+	r.RunOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.RunOnce()
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the probe loop.
+func (r *Runner) Stop() {
+	close(r.stop)
+}
+
+// RunOnce executes all probes concurrently and waits for them to finish.
+func (r *Runner) RunOnce() {
+	var wg sync.WaitGroup
+	for _, p := range r.probes {
+		wg.Add(1)
+		go func(p Probe) {
+			defer wg.Done()
+			r.run(p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (r *Runner) run(p Probe) {
+	start := time.Now()
+	err := p.Run()
+	latency := time.Since(start).Seconds()
+
+	res := &Result{Name: p.Name, Status: StatusOK, Latency: latency, LastRun: start}
+	if err != nil {
+		res.Status = StatusFailing
+		res.Error = err.Error()
+		metrics.CanaryProbeSuccess.WithLabelValues(p.Name).Set(0)
+		logger.WithFields(logrus.Fields{"probe": p.Name, "latency": latency}).Errorf("canary probe failed: %v", err)
+	} else {
+		metrics.CanaryProbeSuccess.WithLabelValues(p.Name).Set(1)
+		logger.WithFields(logrus.Fields{"probe": p.Name, "latency": latency}).Debug("canary probe succeeded")
+	}
+	metrics.CanaryProbeDurations.WithLabelValues(p.Name, res.Status).Observe(latency)
+
+	r.mu.Lock()
+	r.results[p.Name] = res
+	r.mu.Unlock()
+}
+
+// Results returns the latest results of all probes sorted by name.
+func (r *Runner) Results() []Result {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	results := []Result{}
+	for _, res := range r.results {
+		results = append(results, *res)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// Healthy returns false if any of the probes failed on its last run.
+func (r *Runner) Healthy() bool {
+	for _, res := range r.Results() {
+		if res.Status == StatusFailing {
+			return false
+		}
+	}
+	return true
+}
+
+type healthzResponse struct {
+	Status string   `json:"status"`
+	Probes []Result `json:"probes"`
+}
+
+// HandleHealthz reports canary probe results, responding with 503 if any of them is failing.
+func HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	rsp := healthzResponse{Status: StatusOK, Probes: []Result{}}
+	status := http.StatusOK
+	if runner := Current(); runner != nil {
+		rsp.Probes = runner.Results()
+		if !runner.Healthy() {
+			rsp.Status = StatusFailing
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if err := responses.WriteJSON(w, status, rsp); err != nil {
+		logger.Log().Error(err)
+	}
+}
+
+// marshalResult is a helper for probes that need to inspect SDK responses.
+func marshalResult(v interface{}, target interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, target)
+}
@@ -0,0 +1,69 @@
+package canary
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_RunOnce(t *testing.T) {
+	r := NewRunner(time.Minute,
+		Probe{Name: "good", Run: func() error { return nil }},
+		Probe{Name: "bad", Run: func() error { return errors.Err("boom") }},
+	)
+	for _, res := range r.Results() {
+		assert.Equal(t, StatusPending, res.Status)
+	}
+	assert.True(t, r.Healthy())
+
+	r.RunOnce()
+	results := r.Results()
+	require.Len(t, results, 2)
+	assert.Equal(t, "bad", results[0].Name)
+	assert.Equal(t, StatusFailing, results[0].Status)
+	assert.Equal(t, "boom", results[0].Error)
+	assert.Equal(t, StatusOK, results[1].Status)
+	assert.False(t, results[1].LastRun.IsZero())
+	assert.False(t, r.Healthy())
+}
+
+func TestHandleHealthz(t *testing.T) {
+	defer SetRunner(nil)
+
+	rr := httptest.NewRecorder()
+	HandleHealthz(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	r := NewRunner(time.Minute, Probe{Name: "bad", Run: func() error { return errors.Err("boom") }})
+	r.RunOnce()
+	SetRunner(r)
+
+	rr = httptest.NewRecorder()
+	HandleHealthz(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	var rsp healthzResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+	assert.Equal(t, StatusFailing, rsp.Status)
+	require.Len(t, rsp.Probes, 1)
+	assert.Equal(t, "boom", rsp.Probes[0].Error)
+}
+
+// lossyCache serves reads from a layer in front of a store which doesn't keep anything.
+type lossyCache struct {
+	cache.QueryCache
+}
+
+func (lossyCache) Invalidate(string, interface{}) bool { return false }
+
+func TestCacheProbe(t *testing.T) {
+	assert.NoError(t, CacheProbe(cache.NewMemoryCache()).Run())
+	assert.Error(t, CacheProbe(lossyCache{cache.NewMemoryCache()}).Run())
+}
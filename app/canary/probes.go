@@ -0,0 +1,120 @@
+package canary
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	ProbeResolve = "resolve"
+	ProbePublish = "publish"
+	ProbeCache   = "cache"
+)
+
+// DefaultProbes returns probes enabled in the config.
+// The publish probe only runs when a test node and channel are configured.
+func DefaultProbes(rt *sdkrouter.Router) []Probe {
+	probes := []Probe{
+		ResolveProbe(rt, config.GetCanaryResolveURL()),
+		CacheProbe(cache.Shared()),
+	}
+	if server, channelID := config.GetCanaryPublishServer(), config.GetCanaryPublishChannelID(); server != "" && channelID != "" {
+		probes = append(probes, PublishProbe(server, channelID, config.GetPublishSourceDir()))
+	}
+	return probes
+}
+
+// ResolveProbe resolves a known claim on a random SDK instance.
+func ResolveProbe(rt *sdkrouter.Router, url string) Probe {
+	return Probe{Name: ProbeResolve, Run: func() error {
+		server := rt.RandomServer()
+		if server == nil {
+			return errors.Err("no lbrynet servers available")
+		}
+		res, err := query.NewCaller(server.Address, 0).Call(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": url}))
+		if err != nil {
+			return err
+		}
+		if res.Error != nil {
+			return errors.Err("%v: %v", server.Name, res.Error.Message)
+		}
+		var resolved map[string]map[string]interface{}
+		if err := marshalResult(res.Result, &resolved); err != nil {
+			return err
+		}
+		claim, ok := resolved[url]
+		if !ok {
+			return errors.Err("%v: %v missing from resolve response", server.Name, url)
+		}
+		if e, ok := claim["error"]; ok {
+			return errors.Err("%v: %v", server.Name, e)
+		}
+		return nil
+	}}
+}
+
+// PublishProbe publishes a tiny stream into a test channel on a test node.
+// The file is written into dir which has to be accessible to the node.
+func PublishProbe(server, channelID, dir string) Probe {
+	return Probe{Name: ProbePublish, Run: func() error {
+		name := fmt.Sprintf("canary-%v", time.Now().Unix())
+		f, err := ioutil.TempFile(dir, name+"-*.txt")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.WriteString(name); err != nil {
+			f.Close()
+			return err
+		}
+		f.Close()
+
+		// Calling the node directly as query.Caller only allows stream_create on user wallets
+		res, err := jsonrpc.NewClient(server).Call("stream_create", map[string]interface{}{
+			"name":       name,
+			"title":      name,
+			"bid":        config.GetCanaryPublishBid(),
+			"file_path":  filepath.Clean(f.Name()),
+			"channel_id": channelID,
+		})
+		if err != nil {
+			return err
+		}
+		if res.Error != nil {
+			return errors.Err(res.Error.Message)
+		}
+		return nil
+	}}
+}
+
+// CacheProbe saves a value into the query cache, checks that it can be retrieved and drops it.
+// Reads may be served by a local layer, so the value has to be found when dropped too, which tells
+// it made it to where the cache is stored.
+func CacheProbe(c cache.QueryCache) Probe {
+	return Probe{Name: ProbeCache, Run: func() error {
+		params := map[string]interface{}{"ts": time.Now().UnixNano()}
+		// Values are saved the way Caller saves them so every serialization can store them
+		value := json.RawMessage(fmt.Sprint(params["ts"]))
+		c.Save(ProbeCache, params, value)
+		if v, ok := c.Retrieve(ProbeCache, params).(json.RawMessage); !ok || !bytes.Equal(v, value) {
+			return errors.Err("cache returned %v, expected %s", v, value)
+		}
+		if !c.Invalidate(ProbeCache, params) {
+			return errors.Err("cache lost the value before it could be dropped")
+		}
+		return nil
+	}}
+}
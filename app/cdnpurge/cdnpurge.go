@@ -0,0 +1,199 @@
+// Package cdnpurge purges CDN URLs of claims updated, abandoned or blocked through lbrytv, so stale
+// streams and thumbnails stop being served right away instead of when the CDN cache expires.
+// Purges are retried with backoff and recorded in the audit log.
+package cdnpurge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/audit"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	ProviderCloudflare = "cloudflare"
+	ProviderFastly     = "fastly"
+
+	auditAction = "cdn_purge"
+	// cloudflareBatch is the most URLs Cloudflare purges in a single call.
+	cloudflareBatch = 30
+	requestTimeout  = 30 * time.Second
+)
+
+var (
+	logger = monitor.NewModuleLogger("cdnpurge")
+
+	// API base URLs are variables so tests can point them to a mock server.
+	cloudflareAPI = "https://api.cloudflare.com/client/v4"
+	fastlyAPI     = "https://api.fastly.com"
+
+	ErrUnknownProvider = errors.Base("unknown CDN provider")
+)
+
+// Claim identifies content whose URLs are purged.
+type Claim struct {
+	ClaimID string `json:"claim_id"`
+	Name    string `json:"name"`
+}
+
+// URLs returns CDN URLs serving claims, from templates with {claim_id} and {name} placeholders.
+// Templates using the name are skipped for claims without one.
+func URLs(templates []string, claims []Claim) []string {
+	urls := []string{}
+	seen := map[string]bool{}
+	for _, c := range claims {
+		for _, t := range templates {
+			if c.Name == "" && strings.Contains(t, "{name}") {
+				continue
+			}
+			u := strings.NewReplacer("{claim_id}", c.ClaimID, "{name}", url.PathEscape(c.Name)).Replace(t)
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// Purge purges URLs of claims in the background, reason is recorded in the audit log along with them.
+// Nothing is done when purging is not configured.
+func Purge(userID int, remoteIP, reason string, claims []Claim) {
+	cfg := config.GetCDNPurge()
+	if cfg.Provider == "" || len(claims) == 0 {
+		return
+	}
+	urls := URLs(cfg.URLs, claims)
+	if len(urls) == 0 {
+		return
+	}
+	body, err := json.Marshal(map[string]interface{}{"reason": reason, "claims": claims, "urls": urls})
+	if err == nil {
+		audit.LogQuery(userID, remoteIP, auditAction, body)
+	} else {
+		logger.Log().Errorf("cannot marshal audit details: %v", err)
+	}
+	go purgeWithRetries(cfg, urls)
+}
+
+// purgeWithRetries makes up to cfg.Retries further attempts at a failed purge, doubling the delay each time.
+func purgeWithRetries(cfg config.CDNPurge, urls []string) error {
+	log := logger.WithFields(logrus.Fields{"provider": cfg.Provider, "urls": len(urls)})
+	delay := cfg.RetryDelay
+	var err error
+	for attempt := 0; attempt <= cfg.Retries; attempt++ {
+		if attempt > 0 {
+			metrics.CDNPurges.WithLabelValues(metrics.CDNPurgeRetried).Inc()
+			time.Sleep(delay)
+			delay *= 2
+		}
+		if err = purge(cfg, urls); err == nil {
+			metrics.CDNPurges.WithLabelValues(metrics.CDNPurgePurged).Inc()
+			log.Infof("purged urls: %v", strings.Join(urls, ", "))
+			return nil
+		}
+		log.Warnf("purge attempt %v failed: %v", attempt+1, err)
+	}
+	metrics.CDNPurges.WithLabelValues(metrics.CDNPurgeFailed).Inc()
+	log.Errorf("giving up purging urls %v: %v", strings.Join(urls, ", "), err)
+	monitor.ErrorToSentry(err, map[string]string{"urls": strings.Join(urls, ", ")})
+	return err
+}
+
+func purge(cfg config.CDNPurge, urls []string) error {
+	switch cfg.Provider {
+	case ProviderCloudflare:
+		for i := 0; i < len(urls); i += cloudflareBatch {
+			end := i + cloudflareBatch
+			if end > len(urls) {
+				end = len(urls)
+			}
+			if err := purgeCloudflare(cfg, urls[i:end]); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ProviderFastly:
+		for _, u := range urls {
+			if err := purgeFastly(cfg, u); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return ErrUnknownProvider
+}
+
+func purgeCloudflare(cfg config.CDNPurge, urls []string) error {
+	body, _ := json.Marshal(map[string]interface{}{"files": urls})
+	res, err := apiCall(fmt.Sprintf("%s/zones/%s/purge_cache", cloudflareAPI, cfg.ZoneID), body, map[string]string{
+		"Authorization": "Bearer " + cfg.APIToken,
+		"Content-Type":  "application/json",
+	})
+	if err != nil {
+		return err
+	}
+	var r struct {
+		Success bool `json:"success"`
+		Errors  []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(res, &r); err != nil {
+		return errors.Err("malformed cloudflare response: %v", err)
+	}
+	if !r.Success {
+		msgs := []string{}
+		for _, e := range r.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return errors.Err("cloudflare purge failed: %v", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// purgeFastly purges a single URL, addressed on the API by host and path.
+func purgeFastly(cfg config.CDNPurge, u string) error {
+	u = strings.TrimPrefix(strings.TrimPrefix(u, "https://"), "http://")
+	_, err := apiCall(fmt.Sprintf("%s/purge/%s", fastlyAPI, u), nil, map[string]string{"Fastly-Key": cfg.APIToken})
+	return err
+}
+
+func apiCall(url string, body []byte, headers map[string]string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Err("CDN API responded with %v: %s", res.StatusCode, data)
+	}
+	return data, nil
+}
@@ -0,0 +1,91 @@
+package cdnpurge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLs(t *testing.T) {
+	templates := []string{"https://cdn.example.com/{name}/{claim_id}", "https://thumbs.example.com/{claim_id}"}
+	urls := URLs(templates, []Claim{{ClaimID: "abc", Name: "my video"}, {ClaimID: "def"}, {ClaimID: "abc", Name: "my video"}})
+	assert.Equal(t, []string{
+		"https://cdn.example.com/my%20video/abc",
+		"https://thumbs.example.com/abc",
+		"https://thumbs.example.com/def",
+	}, urls)
+}
+
+func TestChangedClaims(t *testing.T) {
+	result := map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{"type": "claim", "claim_id": "abc", "name": "old-name"},
+			map[string]interface{}{"type": "payment"},
+		},
+		"outputs": []interface{}{
+			map[string]interface{}{"type": "claim", "claim_id": "abc", "name": "new-name"},
+			map[string]interface{}{"type": "claim", "claim_id": "abc", "name": "new-name"},
+		},
+	}
+	assert.Equal(t, []Claim{{"abc", "old-name"}, {"abc", "new-name"}}, changedClaims(result))
+	assert.Empty(t, changedClaims("unexpected"))
+}
+
+func TestPurgeCloudflare(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/zones/zone1/purge_cache", r.URL.Path)
+		assert.Equal(t, "Bearer cfToken", r.Header.Get("Authorization"))
+		var body struct{ Files []string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, []string{"https://cdn.example.com/abc"}, body.Files)
+		// The first attempt fails
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Write([]byte(`{"success": false, "errors": [{"message": "rate limited"}]}`))
+			return
+		}
+		w.Write([]byte(`{"success": true, "errors": []}`))
+	}))
+	defer ts.Close()
+	origAPI := cloudflareAPI
+	cloudflareAPI = ts.URL
+	defer func() { cloudflareAPI = origAPI }()
+
+	cfg := config.CDNPurge{Provider: ProviderCloudflare, APIToken: "cfToken", ZoneID: "zone1", Retries: 1}
+	require.NoError(t, purgeWithRetries(cfg, []string{"https://cdn.example.com/abc"}))
+	assert.EqualValues(t, 2, calls)
+
+	cfg.Retries = 0
+	atomic.StoreInt32(&calls, 0)
+	err := purgeWithRetries(cfg, []string{"https://cdn.example.com/abc"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limited")
+}
+
+func TestPurgeFastly(t *testing.T) {
+	purged := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "fastlyKey", r.Header.Get("Fastly-Key"))
+		purged = append(purged, r.URL.Path)
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer ts.Close()
+	origAPI := fastlyAPI
+	fastlyAPI = ts.URL
+	defer func() { fastlyAPI = origAPI }()
+
+	cfg := config.CDNPurge{Provider: ProviderFastly, APIToken: "fastlyKey"}
+	require.NoError(t, purge(cfg, []string{"https://cdn.example.com/abc", "http://thumbs.example.com/abc"}))
+	assert.Equal(t, []string{"/purge/cdn.example.com/abc", "/purge/thumbs.example.com/abc"}, purged)
+
+	cfg.Provider = "akamai"
+	assert.Equal(t, ErrUnknownProvider, purge(cfg, []string{"https://cdn.example.com/abc"}))
+}
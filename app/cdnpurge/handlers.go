@@ -0,0 +1,47 @@
+package cdnpurge
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/responses"
+)
+
+type purgeRequest struct {
+	Claims []Claim `json:"claims"`
+	// Reason, such as a takedown notice reference, is recorded in the audit log.
+	Reason string `json:"reason"`
+}
+
+type purgeResponse struct {
+	URLs []string `json:"urls"`
+}
+
+// HandlePurge purges URLs of claims blocked by admins, responding with URLs being purged.
+func HandlePurge(w http.ResponseWriter, r *http.Request) {
+	var req purgeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	if len(req.Claims) == 0 || req.Reason == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("claims and reason are required"))
+		return
+	}
+	for _, c := range req.Claims {
+		if c.ClaimID == "" {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("claim_id is required"))
+			return
+		}
+	}
+	cfg := config.GetCDNPurge()
+	if cfg.Provider == "" {
+		responses.WriteError(w, http.StatusServiceUnavailable, errors.Err("CDN purging is not configured"))
+		return
+	}
+	Purge(0, ip.AddressForRequest(r), req.Reason, req.Claims)
+	responses.WriteJSON(w, http.StatusAccepted, purgeResponse{URLs: URLs(cfg.URLs, req.Claims)})
+}
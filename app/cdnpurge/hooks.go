@@ -0,0 +1,76 @@
+package cdnpurge
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "cdnpurge"
+
+// purgingMethods change or remove claims, content of which may be cached by the CDN.
+var purgingMethods = []string{
+	"stream_update",
+	"stream_abandon",
+	"channel_update",
+	"channel_abandon",
+	"collection_update",
+	"collection_abandon",
+}
+
+// InstallHooks makes c purge CDN URLs of claims updated or abandoned by successful calls.
+func InstallHooks(c *query.Caller) {
+	for _, m := range purgingMethods {
+		c.AddPostflightHook(m, purgeChanged, hookName)
+	}
+}
+
+func purgeChanged(c *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil || !isPurging(hctx.Query.Method()) {
+		return nil, nil
+	}
+	claims := changedClaims(hctx.Response.Result)
+	if len(claims) > 0 {
+		Purge(c.UserID(), "", hctx.Query.Method(), claims)
+		hctx.AddLogField("cdn_purged", len(claims))
+	}
+	return nil, nil
+}
+
+// isPurging is needed because isMatchingHook also matches by prefix.
+func isPurging(method string) bool {
+	for _, m := range purgingMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// changedClaims collects claims from the transaction, updates have the previous claim among inputs
+// and the new one among outputs, abandons only have inputs.
+func changedClaims(result interface{}) []Claim {
+	claims := []Claim{}
+	tx, ok := result.(map[string]interface{})
+	if !ok {
+		return claims
+	}
+	seen := map[Claim]bool{}
+	for _, key := range []string{"inputs", "outputs"} {
+		txos, _ := tx[key].([]interface{})
+		for _, txo := range txos {
+			o, ok := txo.(map[string]interface{})
+			if !ok || o["type"] != "claim" {
+				continue
+			}
+			c := Claim{}
+			c.ClaimID, _ = o["claim_id"].(string)
+			c.Name, _ = o["name"].(string)
+			if c.ClaimID != "" && !seen[c] {
+				seen[c] = true
+				claims = append(claims, c)
+			}
+		}
+	}
+	return claims
+}
@@ -0,0 +1,250 @@
+// Package channels keeps a read-through cache of channel metadata used for rendering channel headers.
+//
+// Entries are fetched from the SDK on the first request, refreshed in bulk on a schedule (see Refresh)
+// and invalidated when a publish or an update affecting the channel is proxied through lbrytv (see InstallHooks).
+package channels
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// refreshBatchSize is how many channels are requested from the SDK in a single claim_search call.
+const refreshBatchSize = 50
+
+// ErrNotFound is returned for claim IDs which don't belong to an existing channel.
+var ErrNotFound = errors.Base("channel not found")
+
+var (
+	logger = monitor.NewModuleLogger("channels")
+
+	current *Cache
+)
+
+// Metadata is what is needed to render a channel header.
+type Metadata struct {
+	ClaimID      string    `json:"claim_id"`
+	Name         string    `json:"name"`
+	Title        string    `json:"title,omitempty"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+	CoverURL     string    `json:"cover_url,omitempty"`
+	ClaimCount   int       `json:"claim_count"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// Fetcher retrieves metadata for claim IDs. Channels missing from the returned map are considered non-existent.
+type Fetcher func(claimIDs []string) (map[string]*Metadata, error)
+
+// Cache stores channel metadata for up to TTL, fetching missing entries with Fetch.
+type Cache struct {
+	Fetch Fetcher
+	TTL   time.Duration
+	// Size caps the number of stored channels, an arbitrary entry is dropped to make room for a new one.
+	Size int
+
+	mu      sync.RWMutex
+	entries map[string]*Metadata
+	// generation is bumped by every invalidation, invalidated keeps the generation at which each channel
+	// was last invalidated so fetches started before that don't store stale data.
+	generation  uint64
+	invalidated map[string]uint64
+	// pruneBelow is the generation at the previous refresh, older invalidation markers are dropped by the next one.
+	pruneBelow uint64
+}
+
+// NewCache creates a channel metadata cache.
+func NewCache(fetch Fetcher, ttl time.Duration, size int) *Cache {
+	return &Cache{Fetch: fetch, TTL: ttl, Size: size, entries: map[string]*Metadata{}, invalidated: map[string]uint64{}}
+}
+
+// SetCache sets the cache used by HTTP handlers, proxy hooks and scheduled refreshes.
+func SetCache(c *Cache) {
+	current = c
+}
+
+// Current returns the cache set by SetCache, nil if channel metadata caching is not set up.
+func Current() *Cache {
+	return current
+}
+
+// Get returns channel metadata from cache, fetching it if it's not there or is older than TTL.
+// Expired metadata is returned when fetching fails.
+func (c *Cache) Get(claimID string) (*Metadata, error) {
+	c.mu.RLock()
+	m, ok := c.entries[claimID]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && time.Since(m.FetchedAt) < c.TTL {
+		metrics.ChannelCacheCount.WithLabelValues(metrics.ChannelCacheHit).Inc()
+		return m, nil
+	}
+	metrics.ChannelCacheCount.WithLabelValues(metrics.ChannelCacheMiss).Inc()
+
+	fetched, err := c.Fetch([]string{claimID})
+	if err != nil {
+		// A stale header is better than none while the SDK is unavailable
+		if ok {
+			logger.Log().Warnf("serving stale metadata for channel %v: %v", claimID, err)
+			return m, nil
+		}
+		return nil, err
+	}
+	m, ok = fetched[claimID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c.store(generation, m)
+	return m, nil
+}
+
+// Invalidate drops the channel from cache so it is re-fetched on the next request.
+func (c *Cache) Invalidate(claimID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.invalidated[claimID] = c.generation
+	if _, ok := c.entries[claimID]; ok {
+		delete(c.entries, claimID)
+		metrics.ChannelCacheCount.WithLabelValues(metrics.ChannelCacheInvalidated).Inc()
+		logger.Log().Debugf("channel %v invalidated", claimID)
+	}
+}
+
+// Refresh re-fetches all cached channels in batches, dropping ones that no longer exist.
+func (c *Cache) Refresh() error {
+	c.mu.Lock()
+	ids := make([]string, 0, len(c.entries))
+	for id := range c.entries {
+		ids = append(ids, id)
+	}
+	// Fetches don't outlive the interval between refreshes, so markers set before the previous one aren't needed
+	for id, g := range c.invalidated {
+		if g <= c.pruneBelow {
+			delete(c.invalidated, id)
+		}
+	}
+	c.pruneBelow = c.generation
+	c.mu.Unlock()
+
+	for start := 0; start < len(ids); start += refreshBatchSize {
+		end := start + refreshBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		c.mu.RLock()
+		generation := c.generation
+		c.mu.RUnlock()
+		fetched, err := c.Fetch(batch)
+		if err != nil {
+			return errors.Prefix("channel refresh failed", err)
+		}
+		for _, id := range batch {
+			if m, ok := fetched[id]; ok {
+				c.store(generation, m)
+			} else {
+				c.mu.Lock()
+				delete(c.entries, id)
+				c.mu.Unlock()
+			}
+		}
+	}
+	metrics.ChannelCacheRefreshed.Add(float64(len(ids)))
+	logger.Log().Debugf("refreshed %v channels", len(ids))
+	return nil
+}
+
+// Count returns the number of cached channels.
+func (c *Cache) Count() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// store saves fetched metadata unless the channel was invalidated after the fetch began.
+func (c *Cache) store(generation uint64, m *Metadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.invalidated[m.ClaimID] > generation {
+		return
+	}
+	if _, ok := c.entries[m.ClaimID]; !ok && c.Size > 0 && len(c.entries) >= c.Size {
+		for id := range c.entries {
+			delete(c.entries, id)
+			break
+		}
+	}
+	c.entries[m.ClaimID] = m
+}
+
+type channelClaim struct {
+	ClaimID string `json:"claim_id"`
+	Name    string `json:"name"`
+	Meta    struct {
+		ClaimsInChannel int `json:"claims_in_channel"`
+	} `json:"meta"`
+	Value struct {
+		Title     string `json:"title"`
+		Thumbnail struct {
+			URL string `json:"url"`
+		} `json:"thumbnail"`
+		Cover struct {
+			URL string `json:"url"`
+		} `json:"cover"`
+	} `json:"value"`
+}
+
+// SDKFetcher returns a Fetcher looking channels up with claim_search on a random SDK node.
+func SDKFetcher(rt *sdkrouter.Router) Fetcher {
+	return func(claimIDs []string) (map[string]*Metadata, error) {
+		c := query.NewCaller(rt.RandomServer().Address, 0)
+		res, err := c.Call(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{
+			"claim_ids":  claimIDs,
+			"claim_type": "channel",
+			"page_size":  len(claimIDs),
+			"no_totals":  true,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if res.Error != nil {
+			return nil, errors.Err(res.Error.Message)
+		}
+
+		var result struct {
+			Items []channelClaim `json:"items"`
+		}
+		serialized, err := json.Marshal(res.Result)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		if err := json.Unmarshal(serialized, &result); err != nil {
+			return nil, errors.Prefix("unexpected claim_search response", err)
+		}
+
+		now := time.Now()
+		fetched := map[string]*Metadata{}
+		for _, claim := range result.Items {
+			fetched[claim.ClaimID] = &Metadata{
+				ClaimID:      claim.ClaimID,
+				Name:         claim.Name,
+				Title:        claim.Value.Title,
+				ThumbnailURL: claim.Value.Thumbnail.URL,
+				CoverURL:     claim.Value.Cover.URL,
+				ClaimCount:   claim.Meta.ClaimsInChannel,
+				FetchedAt:    now,
+			}
+		}
+		return fetched, nil
+	}
+}
@@ -0,0 +1,184 @@
+package channels
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	channelID = "beef0000000000000000000000000000000000aa"
+	missingID = "dead0000000000000000000000000000000000aa"
+)
+
+// fakeSDK serves channels with claim counts that can be changed between fetches.
+type fakeSDK struct {
+	claimCounts map[string]int
+	fetches     int
+	err         error
+}
+
+func (f *fakeSDK) fetch(ids []string) (map[string]*Metadata, error) {
+	f.fetches++
+	if f.err != nil {
+		return nil, f.err
+	}
+	fetched := map[string]*Metadata{}
+	for _, id := range ids {
+		if n, ok := f.claimCounts[id]; ok {
+			fetched[id] = &Metadata{ClaimID: id, Name: "@channel", ClaimCount: n, FetchedAt: time.Now()}
+		}
+	}
+	return fetched, nil
+}
+
+func TestCache_Get(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{channelID: 5}}
+	c := NewCache(sdk.fetch, time.Minute, 10)
+
+	m, err := c.Get(channelID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, m.ClaimCount)
+
+	sdk.claimCounts[channelID] = 6
+	m, err = c.Get(channelID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, m.ClaimCount)
+	assert.Equal(t, 1, sdk.fetches)
+
+	c.Invalidate(channelID)
+	m, err = c.Get(channelID)
+	require.NoError(t, err)
+	assert.Equal(t, 6, m.ClaimCount)
+
+	_, err = c.Get(missingID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestCache_Stale(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{channelID: 5}}
+	c := NewCache(sdk.fetch, time.Millisecond, 10)
+
+	_, err := c.Get(channelID)
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	sdk.err = errors.Err("sdk is down")
+	m, err := c.Get(channelID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, m.ClaimCount)
+
+	_, err = c.Get(missingID)
+	assert.EqualError(t, err, "sdk is down")
+}
+
+func TestCache_InvalidatedDuringFetch(t *testing.T) {
+	c := NewCache(nil, time.Minute, 10)
+	c.Fetch = func(ids []string) (map[string]*Metadata, error) {
+		// A publish went through while the SDK was being asked
+		c.Invalidate(channelID)
+		return map[string]*Metadata{channelID: {ClaimID: channelID, FetchedAt: time.Now()}}, nil
+	}
+	_, err := c.Get(channelID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, c.Count())
+}
+
+func TestCache_Refresh(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{}}
+	for i := 0; i < refreshBatchSize+10; i++ {
+		sdk.claimCounts[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	c := NewCache(sdk.fetch, time.Minute, 0)
+	for id := range sdk.claimCounts {
+		_, err := c.Get(id)
+		require.NoError(t, err)
+	}
+	sdk.fetches = 0
+
+	delete(sdk.claimCounts, "aa")
+	sdk.claimCounts["ba"] = 100
+	require.NoError(t, c.Refresh())
+	assert.Equal(t, 2, sdk.fetches)
+	assert.Equal(t, refreshBatchSize+9, c.Count())
+	m, err := c.Get("ba")
+	require.NoError(t, err)
+	assert.Equal(t, 100, m.ClaimCount)
+}
+
+func TestCache_Size(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{"a": 1, "b": 2, "c": 3}}
+	c := NewCache(sdk.fetch, time.Minute, 2)
+	for _, id := range []string{"a", "b", "c"} {
+		_, err := c.Get(id)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, c.Count())
+}
+
+func TestInvalidateHook(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{channelID: 5, missingID: 1}}
+	SetCache(NewCache(sdk.fetch, time.Minute, 10))
+	defer SetCache(nil)
+	_, err := Current().Get(channelID)
+	require.NoError(t, err)
+	_, err = Current().Get(missingID)
+	require.NoError(t, err)
+
+	q, err := query.NewQuery(jsonrpc.NewRequest("stream_abandon", map[string]interface{}{"claim_id": "abc"}), "lbrytv-id.1.wallet")
+	require.NoError(t, err)
+	res := &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"inputs": []interface{}{
+			map[string]interface{}{"claim_id": "abc", "value_type": "stream", "signing_channel": map[string]interface{}{"claim_id": channelID}},
+		},
+		"outputs": []interface{}{},
+	}}
+	_, err = invalidate(nil, &query.HookContext{Query: q, Response: res})
+	require.NoError(t, err)
+	assert.Equal(t, 1, Current().Count())
+}
+
+func TestAffectedChannels(t *testing.T) {
+	assert.Equal(t, []string{channelID}, affectedChannels("publish", map[string]interface{}{"channel_id": channelID}, nil))
+	assert.Equal(t, []string{channelID}, affectedChannels("channel_update", map[string]interface{}{"claim_id": channelID}, nil))
+	assert.Empty(t, affectedChannels("stream_update", map[string]interface{}{"claim_id": "abc"}, nil))
+
+	result := map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{"claim_id": channelID, "value_type": "channel"},
+			map[string]interface{}{"claim_id": "abc", "value_type": "stream", "signing_channel": map[string]interface{}{"claim_id": missingID}},
+		},
+	}
+	assert.Equal(t, []string{channelID, missingID}, affectedChannels("stream_update", map[string]interface{}{"channel_id": channelID}, result))
+}
+
+func TestHandleGet(t *testing.T) {
+	sdk := &fakeSDK{claimCounts: map[string]int{channelID: 5}}
+	SetCache(NewCache(sdk.fetch, time.Minute, 10))
+	defer SetCache(nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/channels/{claim_id}", HandleGet)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/channels/"+channelID, nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var m Metadata
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &m))
+	assert.Equal(t, channelID, m.ClaimID)
+	assert.Equal(t, 5, m.ClaimCount)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/channels/"+missingID, nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
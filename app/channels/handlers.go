@@ -0,0 +1,31 @@
+package channels
+
+import (
+	"net/http"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleGet returns metadata of the channel with claim_id from the URL.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	cache := Current()
+	if cache == nil {
+		responses.WriteError(w, http.StatusServiceUnavailable, errors.Err("channel metadata is not available"))
+		return
+	}
+	m, err := cache.Get(mux.Vars(r)["claim_id"])
+	if errors.Is(err, ErrNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error getting channel metadata: %v", err)
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, m)
+}
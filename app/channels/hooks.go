@@ -0,0 +1,94 @@
+package channels
+
+import (
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "channels_invalidate"
+
+// invalidatingMethods change a channel's metadata or the number of its claims.
+var invalidatingMethods = []string{
+	"publish",
+	"stream_create",
+	"stream_update",
+	"stream_abandon",
+	"stream_repost",
+	"channel_update",
+	"channel_abandon",
+}
+
+// InstallHooks makes c invalidate cached metadata of channels affected by successful calls.
+func InstallHooks(c *query.Caller) {
+	for _, m := range invalidatingMethods {
+		c.AddPostflightHook(m, invalidate, hookName)
+	}
+}
+
+func invalidate(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	cache := Current()
+	if cache == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	// isMatchingHook also matches by prefix, so only the exact methods are handled
+	if !isInvalidating(hctx.Query.Method()) {
+		return nil, nil
+	}
+	for _, id := range affectedChannels(hctx.Query.Method(), hctx.Query.ParamsAsMap(), hctx.Response.Result) {
+		cache.Invalidate(id)
+	}
+	return nil, nil
+}
+
+func isInvalidating(method string) bool {
+	for _, m := range invalidatingMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// affectedChannels collects channel IDs from call params (channel_id for streams, claim_id for channels)
+// and from the resulting transaction, whose inputs and outputs are either channels or claims signed by one.
+// The latter covers abandoning and moving streams, where the channel is not among the params.
+func affectedChannels(method string, params map[string]interface{}, result interface{}) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	add := func(id interface{}) {
+		if s, ok := id.(string); ok && s != "" && !seen[s] {
+			seen[s] = true
+			ids = append(ids, s)
+		}
+	}
+
+	if params != nil {
+		add(params[query.ParamChannelID])
+		if strings.HasPrefix(method, "channel_") {
+			add(params["claim_id"])
+		}
+	}
+	tx, ok := result.(map[string]interface{})
+	if !ok {
+		return ids
+	}
+	for _, key := range []string{"inputs", "outputs"} {
+		txos, _ := tx[key].([]interface{})
+		for _, txo := range txos {
+			claim, ok := txo.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if claim["value_type"] == "channel" {
+				add(claim["claim_id"])
+			}
+			if signing, ok := claim["signing_channel"].(map[string]interface{}); ok {
+				add(signing["claim_id"])
+			}
+		}
+	}
+	return ids
+}
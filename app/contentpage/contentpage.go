@@ -0,0 +1,245 @@
+// Package contentpage composes everything a content page shows about a claim — resolve data, channel header,
+// related content, view count and the user's purchase and ownership state — into a single response,
+// so the page doesn't have to make a separate call for each.
+package contentpage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	// maxRelatedTags is how many of the claim's tags related content is searched by.
+	maxRelatedTags = 5
+	viewCountPath  = "/file/view_count"
+)
+
+var (
+	logger = monitor.NewModuleLogger("contentpage")
+
+	ErrNotFound = errors.Base("claim not found")
+
+	viewCounts      *gocache.Cache
+	viewCountsOnce  sync.Once
+	viewCountClient = &http.Client{Timeout: 5 * time.Second}
+)
+
+// Page is the composed content page data. Parts which could not be fetched are left out and their errors
+// reported in Errors, so the page can still be shown.
+type Page struct {
+	Claim     map[string]interface{} `json:"claim"`
+	Channel   *channels.Metadata     `json:"channel,omitempty"`
+	Related   []interface{}          `json:"related"`
+	ViewCount *int                   `json:"view_count,omitempty"`
+	// Ownership is only set for authenticated users.
+	Ownership *Ownership        `json:"ownership,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// Ownership is the user's relation to the claim.
+type Ownership struct {
+	Purchased  bool `json:"purchased"`
+	IsMyOutput bool `json:"is_my_output"`
+}
+
+// Compose resolves claimURL through c and fetches the rest of the page data in parallel.
+// Purchase and ownership state is included when authenticated is set. authToken is passed to the view count API.
+func Compose(c *query.Caller, claimURL string, authenticated bool, authToken string) (*Page, error) {
+	params := map[string]interface{}{query.ParamUrls: []interface{}{claimURL}}
+	if authenticated {
+		params["include_purchase_receipt"] = true
+		params["include_is_my_output"] = true
+	}
+	res, err := c.Call(jsonrpc.NewRequest(query.MethodResolve, params))
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, errors.Err(res.Error.Message)
+	}
+	claim, err := claimFromResolve(res, claimURL)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Claim: claim, Related: []interface{}{}, Errors: map[string]string{}}
+	if authenticated {
+		_, purchased := claim[query.ParamPurchaseReceipt].(map[string]interface{})
+		isMine, _ := claim["is_my_output"].(bool)
+		page.Ownership = &Ownership{Purchased: purchased, IsMyOutput: isMine}
+	}
+
+	claimID, _ := claim["claim_id"].(string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	fail := func(part string, err error) {
+		logger.Log().Warnf("cannot get %v for claim %v: %v", part, claimID, err)
+		mu.Lock()
+		page.Errors[part] = err.Error()
+		mu.Unlock()
+	}
+
+	if channelID := signingChannelID(claim); channelID != "" && channels.Current() != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, err := channels.Current().Get(channelID)
+			if err != nil {
+				fail("channel", err)
+				return
+			}
+			mu.Lock()
+			page.Channel = m
+			mu.Unlock()
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		n, err := viewCount(claimID, authToken)
+		if err != nil {
+			fail("view_count", err)
+			return
+		}
+		mu.Lock()
+		page.ViewCount = &n
+		mu.Unlock()
+	}()
+	// Related content goes through the caller, which isn't safe for concurrent use, so it's the only SDK call here
+	related, err := relatedContent(c, claim)
+	if err != nil {
+		fail("related", err)
+	} else {
+		mu.Lock()
+		page.Related = related
+		mu.Unlock()
+	}
+	wg.Wait()
+
+	if len(page.Errors) == 0 {
+		page.Errors = nil
+	}
+	return page, nil
+}
+
+// claimFromResolve returns the claim resolved for claimURL in res, ErrNotFound if the SDK reported an error for it.
+func claimFromResolve(res *jsonrpc.RPCResponse, claimURL string) (map[string]interface{}, error) {
+	resolved, err := query.ResultMap(res)
+	if err != nil {
+		return nil, err
+	}
+	claim, ok := resolved[claimURL].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if e, ok := claim["error"].(map[string]interface{}); ok {
+		return nil, errors.Prefix(fmt.Sprint(e["text"]), ErrNotFound)
+	}
+	return claim, nil
+}
+
+func signingChannelID(claim map[string]interface{}) string {
+	ch, ok := claim["signing_channel"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	id, _ := ch["claim_id"].(string)
+	return id
+}
+
+func claimTags(claim map[string]interface{}) []interface{} {
+	value, ok := claim["value"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	tags, _ := value["tags"].([]interface{})
+	if len(tags) > maxRelatedTags {
+		tags = tags[:maxRelatedTags]
+	}
+	return tags
+}
+
+// relatedContent returns trending claims sharing tags with claim, excluding the claim itself.
+func relatedContent(c *query.Caller, claim map[string]interface{}) ([]interface{}, error) {
+	tags := claimTags(claim)
+	if len(tags) == 0 {
+		return []interface{}{}, nil
+	}
+	size := config.GetContentPage().RelatedCount
+	res, err := c.Call(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{
+		"any_tags":          tags,
+		"claim_type":        "stream",
+		"order_by":          []interface{}{"trending_group", "trending_mixed"},
+		"page_size":         size + 1,
+		"no_totals":         true,
+		"has_source":        true,
+		"remove_duplicates": true,
+	}))
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, errors.Err(res.Error.Message)
+	}
+	result, err := query.ResultMap(res)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := result["items"].([]interface{})
+	related := []interface{}{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok && m["claim_id"] == claim["claim_id"] {
+			continue
+		}
+		if len(related) < size {
+			related = append(related, item)
+		}
+	}
+	return related, nil
+}
+
+// viewCount returns the number of views of the claim from the internal API, cached for ViewCountTTL.
+func viewCount(claimID, authToken string) (int, error) {
+	viewCountsOnce.Do(func() {
+		viewCounts = gocache.New(config.GetContentPage().ViewCountTTL, 10*time.Minute)
+	})
+	if n, ok := viewCounts.Get(claimID); ok {
+		return n.(int), nil
+	}
+
+	q := url.Values{"claim_id": {claimID}}
+	if authToken != "" {
+		q.Set("auth_token", authToken)
+	}
+	res, err := viewCountClient.Get(config.GetInternalAPIHost() + viewCountPath + "?" + q.Encode())
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	defer res.Body.Close()
+	var parsed struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+		Data    []int  `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+		return 0, errors.Err(err)
+	}
+	if !parsed.Success || len(parsed.Data) == 0 {
+		return 0, errors.Err("view count API responded with %v: %v", res.Status, parsed.Error)
+	}
+	viewCounts.SetDefault(claimID, parsed.Data[0])
+	return parsed.Data[0], nil
+}
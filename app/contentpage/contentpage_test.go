@@ -0,0 +1,144 @@
+package contentpage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const claimID = "19b9c243bea0c45175e6a6027911abbad53e983e"
+
+// sdkServer responds to resolve and claim_search, recording their params.
+func sdkServer(t *testing.T, resolved map[string]interface{}, calls map[string]map[string]interface{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		calls[req.Method], _ = req.Params.(map[string]interface{})
+		var result interface{}
+		switch req.Method {
+		case query.MethodResolve:
+			result = resolved
+		case query.MethodClaimSearch:
+			result = map[string]interface{}{"items": []interface{}{
+				map[string]interface{}{"claim_id": claimID},
+				map[string]interface{}{"claim_id": "aaaa"},
+				map[string]interface{}{"claim_id": "bbbb"},
+			}}
+		}
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result})
+	}))
+}
+
+func TestCompose(t *testing.T) {
+	vs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, claimID, r.URL.Query().Get("claim_id"))
+		fmt.Fprint(w, `{"success": true, "error": null, "data": [42]}`)
+	}))
+	defer vs.Close()
+	config.Override("InternalAPIHost", vs.URL)
+	config.Override("ContentPage", map[string]interface{}{"RelatedCount": 1, "ViewCountTTL": "1m"})
+	defer config.RestoreOverridden()
+
+	calls := map[string]map[string]interface{}{}
+	ts := sdkServer(t, map[string]interface{}{
+		"lbry://what": map[string]interface{}{
+			"claim_id":         claimID,
+			"value":            map[string]interface{}{"tags": []interface{}{"science", "space"}},
+			"purchase_receipt": map[string]interface{}{"txid": "abc"},
+		},
+	}, calls)
+	defer ts.Close()
+
+	page, err := Compose(query.NewCaller(ts.URL, 0), "lbry://what", true, "")
+	require.NoError(t, err)
+	assert.Equal(t, claimID, page.Claim["claim_id"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"claim_id": "aaaa"}}, page.Related)
+	require.NotNil(t, page.ViewCount)
+	assert.Equal(t, 42, *page.ViewCount)
+	assert.Equal(t, &Ownership{Purchased: true}, page.Ownership)
+	assert.Nil(t, page.Errors)
+
+	assert.Equal(t, true, calls[query.MethodResolve]["include_purchase_receipt"])
+	assert.Equal(t, []interface{}{"science", "space"}, calls[query.MethodClaimSearch]["any_tags"])
+}
+
+func TestCompose_Cached(t *testing.T) {
+	vs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": true, "error": null, "data": [42]}`)
+	}))
+	defer vs.Close()
+	config.Override("InternalAPIHost", vs.URL)
+	config.Override("ContentPage", map[string]interface{}{"RelatedCount": 1, "ViewCountTTL": "1m"})
+	config.Override("QueryCacheTTLs", map[string]interface{}{query.MethodClaimSearch: time.Minute})
+	defer config.RestoreOverridden()
+
+	calls := map[string]map[string]interface{}{}
+	ts := sdkServer(t, map[string]interface{}{
+		"lbry://what": map[string]interface{}{
+			"claim_id": claimID,
+			"value":    map[string]interface{}{"tags": []interface{}{"science"}},
+		},
+	}, calls)
+	defer ts.Close()
+
+	qCache := cache.NewMemoryCache()
+	for i := 0; i < 2; i++ {
+		c := query.NewCaller(ts.URL, 0)
+		c.Cache = qCache
+		delete(calls, query.MethodClaimSearch)
+		page, err := Compose(c, "lbry://what", false, "")
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{map[string]interface{}{"claim_id": "aaaa"}}, page.Related)
+		assert.Nil(t, page.Errors)
+	}
+	// Related content came from the cache the second time
+	assert.NotContains(t, calls, query.MethodClaimSearch)
+}
+
+func TestCompose_PartialFailure(t *testing.T) {
+	vs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"success": false, "error": "no such claim", "data": null}`)
+	}))
+	defer vs.Close()
+	config.Override("InternalAPIHost", vs.URL)
+	defer config.RestoreOverridden()
+
+	calls := map[string]map[string]interface{}{}
+	ts := sdkServer(t, map[string]interface{}{
+		"lbry://untagged": map[string]interface{}{"claim_id": "cccc"},
+	}, calls)
+	defer ts.Close()
+
+	page, err := Compose(query.NewCaller(ts.URL, 0), "lbry://untagged", false, "")
+	require.NoError(t, err)
+	assert.Nil(t, page.Ownership)
+	assert.Nil(t, page.ViewCount)
+	assert.Empty(t, page.Related)
+	assert.Contains(t, page.Errors["view_count"], "no such claim")
+	assert.NotContains(t, calls, query.MethodClaimSearch)
+	assert.NotContains(t, calls[query.MethodResolve], "include_purchase_receipt")
+}
+
+func TestCompose_NotFound(t *testing.T) {
+	ts := sdkServer(t, map[string]interface{}{
+		"lbry://nothing": map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND", "text": "Could not find claim."}},
+	}, map[string]map[string]interface{}{})
+	defer ts.Close()
+
+	_, err := Compose(query.NewCaller(ts.URL, 0), "lbry://nothing", false, "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,68 @@
+package contentpage
+
+import (
+	"net/http"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/proxy"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/rules"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/urlfilter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/slo"
+)
+
+// HandleGet returns content page data of the claim at the `url` query param. Anonymous requests are served
+// without the ownership part, requests with invalid auth tokens are rejected.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	claimURL := r.URL.Query().Get("url")
+	if claimURL == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("url is required"))
+		return
+	}
+
+	user, err := auth.FromRequest(r)
+	if err != nil && !errors.Is(err, auth.ErrNoAuthInfo) {
+		responses.WriteError(w, http.StatusUnauthorized, auth.Check(user, err))
+		return
+	}
+
+	var userID int
+	sdkAddress := sdkrouter.GetSDKAddress(user)
+	if user != nil && sdkAddress != "" {
+		userID = user.ID
+	} else {
+		sdkAddress = sdkrouter.FromRequest(r).ServerFor(query.MethodResolve).Address
+	}
+
+	c := query.NewCaller(sdkAddress, userID)
+	channels.InstallHooks(c)
+	urlfilter.InstallHooks(c)
+	moderation.InstallHooks(c)
+	rules.InstallHooks(c)
+	if cache.IsOnRequest(r) {
+		c.Cache = cache.FromRequest(r)
+	}
+	c.Deadline = proxy.Deadline(r, query.MethodResolve, slo.ClassRead)
+	c.Context = r.Context()
+
+	page, err := Compose(c, claimURL, userID != 0, r.Header.Get(wallet.TokenHeader))
+	if errors.Is(err, ErrNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, query.ErrLatencyBudgetExceeded) {
+		responses.WriteError(w, http.StatusGatewayTimeout, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error composing content page for %v: %v", claimURL, err)
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, page)
+}
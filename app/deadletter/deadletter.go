@@ -0,0 +1,172 @@
+// Package deadletter keeps work which has failed for good, like outbox messages which ran out of delivery attempts
+// and failed background jobs, so admins can inspect it and have it redelivered once the cause is fixed.
+// Each source of dead letters registers a Redeliverer which hands a letter back to it for processing.
+// Work failing again after redelivery ends up as a new dead letter.
+package deadletter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	SourceOutbox = "outbox"
+	SourceJob    = "job"
+
+	StatusDead        = "dead"
+	StatusRedelivered = "redelivered"
+)
+
+var (
+	logger = monitor.NewModuleLogger("deadletter")
+
+	redeliverersMu sync.RWMutex
+	redeliverers   = map[string]Redeliverer{}
+
+	ErrNotFound      = errors.Base("dead letter not found")
+	ErrUnknownSource = errors.Base("dead letters of this source can't be redelivered")
+	ErrRedelivered   = errors.Base("dead letter has already been redelivered")
+)
+
+// Letter is a piece of work which has failed for good.
+type Letter struct {
+	ID     int64  `json:"id"`
+	Source string `json:"source"`
+	// Kind is the kind of the outbox message or job.
+	Kind string `json:"kind"`
+	// Ref identifies the work in its source, like the outbox message ID or the job name.
+	Ref           string          `json:"ref"`
+	Payload       json.RawMessage `json:"payload"`
+	Error         string          `json:"error"`
+	Attempts      int             `json:"attempts"`
+	Status        string          `json:"status"`
+	CreatedAt     time.Time       `json:"created_at"`
+	RedeliveredAt null.Time       `json:"redelivered_at"`
+}
+
+// Redeliverer hands a dead letter back to its source for processing.
+type Redeliverer func(l *Letter) error
+
+// RegisterRedeliverer makes dead letters of source redeliverable, replacing the redeliverer registered for it before.
+func RegisterRedeliverer(source string, r Redeliverer) {
+	redeliverersMu.Lock()
+	defer redeliverersMu.Unlock()
+	redeliverers[source] = r
+}
+
+func getRedeliverer(source string) Redeliverer {
+	redeliverersMu.RLock()
+	defer redeliverersMu.RUnlock()
+	return redeliverers[source]
+}
+
+const letterColumns = `id, source, kind, ref, payload, error, attempts, status, created_at, redelivered_at`
+
+func scanLetter(s interface{ Scan(...interface{}) error }) (*Letter, error) {
+	l := &Letter{}
+	var payload []byte
+	err := s.Scan(&l.ID, &l.Source, &l.Kind, &l.Ref, &payload, &l.Error, &l.Attempts, &l.Status, &l.CreatedAt, &l.RedeliveredAt)
+	l.Payload = payload
+	return l, err
+}
+
+// Record stores a dead letter. exec lets it be stored in the transaction marking the work failed.
+// payload is marshaled to JSON unless it's already json.RawMessage.
+func Record(exec boil.Executor, source, kind, ref string, payload interface{}, attempts int, failure error) (int64, error) {
+	raw, ok := payload.(json.RawMessage)
+	if !ok {
+		var err error
+		raw, err = json.Marshal(payload)
+		if err != nil {
+			return 0, errors.Err(err)
+		}
+	}
+	msg := ""
+	if failure != nil {
+		msg = failure.Error()
+	}
+	var id int64
+	err := exec.QueryRow(
+		`INSERT INTO dead_letters (source, kind, ref, payload, error, attempts) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		source, kind, ref, []byte(raw), msg, attempts,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	metrics.DeadLetters.WithLabelValues(source, kind).Inc()
+	return id, nil
+}
+
+// Get returns the dead letter with id.
+func Get(id int64) (*Letter, error) {
+	l, err := scanLetter(boil.GetDB().QueryRow(`SELECT `+letterColumns+` FROM dead_letters WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return l, nil
+}
+
+// List returns dead letters, optionally filtered by source and status, newest first.
+func List(source, status string, limit, offset int) ([]*Letter, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+letterColumns+` FROM dead_letters WHERE ($1 = '' OR source = $1) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC LIMIT $3 OFFSET $4`, source, status, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	letters := []*Letter{}
+	for rows.Next() {
+		l, err := scanLetter(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		letters = append(letters, l)
+	}
+	return letters, errors.Err(rows.Err())
+}
+
+// Redeliver hands the dead letter with id back to its source and marks it redelivered.
+func Redeliver(id int64) (*Letter, error) {
+	l, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	redeliver := getRedeliverer(l.Source)
+	if redeliver == nil {
+		return nil, errors.Prefix(l.Source, ErrUnknownSource)
+	}
+	// Marked first so concurrent redeliveries don't both go through
+	l, err = scanLetter(boil.GetDB().QueryRow(
+		`UPDATE dead_letters SET status = 'redelivered', redelivered_at = now()
+		WHERE id = $1 AND status = 'dead' RETURNING `+letterColumns, id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRedelivered
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	if err := redeliver(l); err != nil {
+		if _, dbErr := boil.GetDB().Exec(
+			`UPDATE dead_letters SET status = 'dead', redelivered_at = NULL WHERE id = $1`, id,
+		); dbErr != nil {
+			logger.Log().Errorf("cannot restore dead letter %v after failed redelivery: %v", id, dbErr)
+		}
+		return nil, err
+	}
+	logger.WithFields(logrus.Fields{"id": id, "source": l.Source, "kind": l.Kind, "ref": l.Ref}).Info("dead letter redelivered")
+	return l, nil
+}
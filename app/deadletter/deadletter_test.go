@@ -0,0 +1,158 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestRecordAndRedeliver(t *testing.T) {
+	var redelivered []*Letter
+	fail := false
+	RegisterRedeliverer("test", func(l *Letter) error {
+		if fail {
+			return errors.Err("still down")
+		}
+		redelivered = append(redelivered, l)
+		return nil
+	})
+
+	id, err := Record(boil.GetDB(), "test", "ping", "42", map[string]interface{}{"claim_id": "abc"}, 3, errors.Err("503"))
+	require.NoError(t, err)
+
+	l, err := Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, "test", l.Source)
+	assert.Equal(t, "ping", l.Kind)
+	assert.Equal(t, "42", l.Ref)
+	assert.JSONEq(t, `{"claim_id": "abc"}`, string(l.Payload))
+	assert.Equal(t, "503", l.Error)
+	assert.Equal(t, 3, l.Attempts)
+	assert.Equal(t, StatusDead, l.Status)
+	assert.False(t, l.RedeliveredAt.Valid)
+
+	// Failed redelivery leaves the letter dead
+	fail = true
+	_, err = Redeliver(id)
+	assert.EqualError(t, err, "still down")
+	l, err = Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDead, l.Status)
+
+	fail = false
+	l, err = Redeliver(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRedelivered, l.Status)
+	assert.True(t, l.RedeliveredAt.Valid)
+	require.Len(t, redelivered, 1)
+	assert.Equal(t, "42", redelivered[0].Ref)
+
+	_, err = Redeliver(id)
+	assert.True(t, errors.Is(err, ErrRedelivered))
+	require.Len(t, redelivered, 1)
+
+	_, err = Redeliver(id + 1000)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestRedeliverUnknownSource(t *testing.T) {
+	id, err := Record(boil.GetDB(), "nonexistent", "ping", "1", json.RawMessage(`[1, 2]`), 1, nil)
+	require.NoError(t, err)
+	l, err := Get(id)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[1, 2]`, string(l.Payload))
+
+	_, err = Redeliver(id)
+	assert.True(t, errors.Is(err, ErrUnknownSource))
+}
+
+func TestList(t *testing.T) {
+	_, err := boil.GetDB().Exec(`TRUNCATE dead_letters`)
+	require.NoError(t, err)
+	RegisterRedeliverer("listed", func(l *Letter) error { return nil })
+	ids := []int64{}
+	for i := 0; i < 3; i++ {
+		id, err := Record(boil.GetDB(), "listed", "ping", fmt.Sprint(i), nil, 1, nil)
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+	_, err = Record(boil.GetDB(), "other", "ping", "x", nil, 1, nil)
+	require.NoError(t, err)
+	_, err = Redeliver(ids[0])
+	require.NoError(t, err)
+
+	letters, err := List("listed", "", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, letters, 3)
+	assert.Equal(t, ids[2], letters[0].ID)
+
+	letters, err = List("listed", StatusDead, 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, letters, 2)
+
+	letters, err = List("", "", 2, 1)
+	require.NoError(t, err)
+	require.Len(t, letters, 2)
+	assert.Equal(t, ids[2], letters[0].ID)
+}
+
+func TestHandlers(t *testing.T) {
+	RegisterRedeliverer("handled", func(l *Letter) error { return nil })
+	id, err := Record(boil.GetDB(), "handled", "ping", "1", map[string]string{"a": "b"}, 1, nil)
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/dead_letters", HandleList).Methods(http.MethodGet)
+	router.HandleFunc("/dead_letters/{id:[0-9]+}", HandleGet).Methods(http.MethodGet)
+	router.HandleFunc("/dead_letters/{id:[0-9]+}/redeliver", HandleRedeliver).Methods(http.MethodPost)
+	do := func(method, url string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(method, url, nil))
+		return rr
+	}
+
+	rr := do(http.MethodGet, fmt.Sprintf("/dead_letters/%v", id))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var l Letter
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &l))
+	assert.JSONEq(t, `{"a": "b"}`, string(l.Payload))
+
+	rr = do(http.MethodGet, "/dead_letters?source=handled&status=dead")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var letters []Letter
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &letters))
+	require.Len(t, letters, 1)
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/dead_letters?status=lost").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, fmt.Sprintf("/dead_letters/%v/redeliver", id)).Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, fmt.Sprintf("/dead_letters/%v/redeliver", id)).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, fmt.Sprintf("/dead_letters/%v", id+1000)).Code)
+}
@@ -0,0 +1,112 @@
+package deadletter
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrRedelivered):
+		status = http.StatusConflict
+	case errors.Is(err, ErrUnknownSource):
+		status = http.StatusUnprocessableEntity
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+func letterID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, errors.Err("invalid dead letter id")
+	}
+	return id, nil
+}
+
+// HandleList returns dead letters, optionally filtered by `source` and `status`
+// and paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", StatusDead, StatusRedelivered:
+	default:
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid status %q", status))
+		return
+	}
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	letters, err := List(r.URL.Query().Get("source"), status, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, letters)
+}
+
+// HandleGet returns the dead letter with id from the URL, along with its payload.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := letterID(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	l, err := Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, l)
+}
+
+// HandleRedeliver hands the dead letter with id from the URL back to its source.
+func HandleRedeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := letterID(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	l, err := Redeliver(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, l)
+}
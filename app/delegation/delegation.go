@@ -0,0 +1,299 @@
+// Package delegation lets channel owners allow other users to publish into their channels.
+// Delegated publishes are sent to the owner's SDK node and signed with the owner's wallet,
+// every grant, revocation and delegated publish is recorded in the audit log.
+package delegation
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/internal/audit"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	auditGrant   = "delegation_grant"
+	auditRevoke  = "delegation_revoke"
+	auditPublish = "delegated_publish"
+)
+
+var (
+	logger = monitor.NewModuleLogger("delegation")
+
+	ErrSelfDelegation    = errors.Base("cannot delegate a channel to its owner")
+	ErrNotChannelOwner   = errors.Base("channel does not belong to the user")
+	ErrUnknownDelegate   = errors.Base("delegate user does not exist")
+	ErrNotFound          = errors.Base("delegation not found")
+	ErrMethodForbidden   = errors.Base("only publishing methods can be called in a delegated channel")
+	ErrParamForbidden    = errors.Base("delegated publishes cannot set claim_address, funding_account_ids, account_id or bid")
+	ErrClaimNotInChannel = errors.Base("claim is not signed by the delegated channel")
+
+	// ownsChannel and claimChannels are variables so tests don't need a running SDK.
+	ownsChannel   = sdkOwnsChannel
+	claimChannels = sdkClaimChannels
+)
+
+// publishMethods are the only methods delegates may call, anything else would run against the owner's wallet.
+var publishMethods = []string{"publish", "stream_create", "stream_update"}
+
+// ownerParams would let delegates spend from the owner's accounts or send claims out of the owner's wallet.
+var ownerParams = []string{"claim_address", "funding_account_ids", "account_id", "bid"}
+
+// maxClaimsChecked is how many claims of the owner by the published name are checked, publishing a name
+// the owner has more claims for is rejected.
+const maxClaimsChecked = 100
+
+// Grant allows delegate to publish into the owner's channel. Granting an existing delegation is a no-op.
+func Grant(owner *models.User, delegateID int, channelID, remoteIP string) (*models.ChannelDelegation, error) {
+	if owner.ID == delegateID {
+		return nil, ErrSelfDelegation
+	}
+	exists, err := models.UserExistsG(delegateID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if !exists {
+		return nil, ErrUnknownDelegate
+	}
+	owns, err := ownsChannel(owner, channelID)
+	if err != nil {
+		return nil, err
+	}
+	if !owns {
+		return nil, ErrNotChannelOwner
+	}
+
+	// Concurrent grants of the same delegation only insert it once
+	d := &models.ChannelDelegation{ChannelID: channelID, OwnerID: owner.ID, DelegateID: delegateID}
+	err = d.UpsertG(false, []string{models.ChannelDelegationColumns.ChannelID, models.ChannelDelegationColumns.DelegateID},
+		boil.None(), boil.Infer())
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if d.ID == 0 {
+		d, err = Find(channelID, delegateID)
+		if err != nil {
+			return nil, err
+		}
+		if d == nil {
+			return nil, errors.Err("delegation revoked while granted")
+		}
+		return d, nil
+	}
+
+	logAudit(owner.ID, remoteIP, auditGrant, map[string]interface{}{"channel_id": channelID, "delegate_id": delegateID})
+	logger.WithFields(logrus.Fields{"owner_id": owner.ID, "delegate_id": delegateID, "channel_id": channelID}).Info("channel delegation granted")
+	return d, nil
+}
+
+// Revoke removes delegate's permission to publish into the owner's channel.
+func Revoke(owner *models.User, delegateID int, channelID, remoteIP string) error {
+	n, err := models.ChannelDelegations(
+		models.ChannelDelegationWhere.OwnerID.EQ(owner.ID),
+		models.ChannelDelegationWhere.DelegateID.EQ(delegateID),
+		models.ChannelDelegationWhere.ChannelID.EQ(channelID),
+	).DeleteAll(boil.GetDB())
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	logAudit(owner.ID, remoteIP, auditRevoke, map[string]interface{}{"channel_id": channelID, "delegate_id": delegateID})
+	logger.WithFields(logrus.Fields{"owner_id": owner.ID, "delegate_id": delegateID, "channel_id": channelID}).Info("channel delegation revoked")
+	return nil
+}
+
+// Find returns delegation of the channel to delegate or nil if there is none.
+func Find(channelID string, delegateID int) (*models.ChannelDelegation, error) {
+	d, err := models.ChannelDelegations(
+		models.ChannelDelegationWhere.ChannelID.EQ(channelID),
+		models.ChannelDelegationWhere.DelegateID.EQ(delegateID),
+	).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, errors.Err(err)
+}
+
+// ListGranted returns delegations granted by the user.
+func ListGranted(ownerID int) (models.ChannelDelegationSlice, error) {
+	ds, err := models.ChannelDelegations(models.ChannelDelegationWhere.OwnerID.EQ(ownerID)).AllG()
+	return ds, errors.Err(err)
+}
+
+// ListReceived returns delegations granted to the user.
+func ListReceived(delegateID int) (models.ChannelDelegationSlice, error) {
+	ds, err := models.ChannelDelegations(models.ChannelDelegationWhere.DelegateID.EQ(delegateID)).AllG()
+	return ds, errors.Err(err)
+}
+
+// Publisher returns the user whose wallet should sign a publish into the channel made by user.
+// That's the channel owner when the channel is delegated to user and user themselves otherwise.
+func Publisher(user *models.User, channelID string) (*models.User, error) {
+	if channelID == "" {
+		return user, nil
+	}
+	d, err := Find(channelID, user.ID)
+	if err != nil || d == nil {
+		return user, err
+	}
+	owner, err := wallet.GetDBUserG(d.OwnerID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if sdkrouter.GetSDKAddress(owner) == "" {
+		return nil, errors.Err("channel owner does not have sdk address assigned")
+	}
+	return owner, nil
+}
+
+// AllowsMethod returns true if delegates may call method with the channel owner's wallet.
+func AllowsMethod(method string) bool {
+	for _, m := range publishMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCall returns an error if a call to method with params, made by a delegate with the owner's wallet,
+// could do more than publish into channelID. Delegates can't fund claims from or send them out of the owner's
+// wallet, nor update claims of the owner outside the channel.
+func CheckCall(owner *models.User, channelID, method string, params map[string]interface{}) error {
+	if !AllowsMethod(method) {
+		return ErrMethodForbidden
+	}
+	for _, p := range ownerParams {
+		if _, ok := params[p]; ok {
+			return ErrParamForbidden
+		}
+	}
+
+	var filter map[string]interface{}
+	switch method {
+	case "stream_update":
+		claimID, _ := params["claim_id"].(string)
+		if claimID == "" {
+			return ErrClaimNotInChannel
+		}
+		filter = map[string]interface{}{"claim_id": claimID}
+	case "publish":
+		// Publishing a name the wallet has a claim for updates that claim
+		name, _ := params["name"].(string)
+		filter = map[string]interface{}{"name": name}
+	default:
+		return nil
+	}
+	channels, err := claimChannels(owner, filter)
+	if err != nil {
+		return err
+	}
+	if method == "stream_update" && len(channels) == 0 {
+		return ErrClaimNotInChannel
+	}
+	for _, c := range channels {
+		if c != channelID {
+			return ErrClaimNotInChannel
+		}
+	}
+	return nil
+}
+
+// LogPublish records a publish made by delegate into the owner's channel.
+func LogPublish(delegate, owner *models.User, channelID, remoteIP string, req *jsonrpc.RPCRequest) {
+	logAudit(delegate.ID, remoteIP, auditPublish, map[string]interface{}{
+		"channel_id": channelID,
+		"owner_id":   owner.ID,
+		"request":    req,
+	})
+	logger.WithFields(logrus.Fields{
+		"owner_id": owner.ID, "delegate_id": delegate.ID, "channel_id": channelID, "method": req.Method,
+	}).Info("delegated publish")
+}
+
+func logAudit(userID int, remoteIP, action string, details map[string]interface{}) {
+	body, err := json.Marshal(details)
+	if err != nil {
+		logger.Log().Errorf("cannot marshal audit details: %v", err)
+		return
+	}
+	audit.LogQuery(userID, remoteIP, action, body)
+}
+
+func sdkOwnsChannel(owner *models.User, channelID string) (bool, error) {
+	var page struct {
+		Items []struct {
+			ClaimID string `json:"claim_id"`
+		} `json:"items"`
+	}
+	err := callOwner(owner, "channel_list", map[string]interface{}{"claim_id": []string{channelID}}, &page)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range page.Items {
+		if c.ClaimID == channelID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sdkClaimChannels returns IDs of channels signing claims in the owner's wallet matching filter,
+// empty for claims not signed by any.
+func sdkClaimChannels(owner *models.User, filter map[string]interface{}) ([]string, error) {
+	params := map[string]interface{}{"page_size": maxClaimsChecked}
+	for k, v := range filter {
+		params[k] = v
+	}
+	var page struct {
+		Items []struct {
+			SigningChannel struct {
+				ClaimID string `json:"claim_id"`
+			} `json:"signing_channel"`
+		} `json:"items"`
+		TotalItems int `json:"total_items"`
+	}
+	if err := callOwner(owner, "claim_list", params, &page); err != nil {
+		return nil, err
+	}
+	if page.TotalItems > len(page.Items) {
+		return nil, ErrClaimNotInChannel
+	}
+	channels := make([]string, len(page.Items))
+	for i, c := range page.Items {
+		channels[i] = c.SigningChannel.ClaimID
+	}
+	return channels, nil
+}
+
+// callOwner calls method with the owner's wallet and decodes the result into v.
+func callOwner(owner *models.User, method string, params map[string]interface{}, v interface{}) error {
+	addr := sdkrouter.GetSDKAddress(owner)
+	if addr == "" {
+		return errors.Err("user does not have sdk address assigned")
+	}
+	res, err := query.NewCaller(addr, owner.ID).Call(jsonrpc.NewRequest(method, params))
+	if err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return errors.Err(res.Error.Message)
+	}
+	b, err := json.Marshal(res.Result)
+	if err != nil {
+		return errors.Err(err)
+	}
+	return errors.Err(json.Unmarshal(b, v))
+}
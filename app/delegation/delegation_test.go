@@ -0,0 +1,153 @@
+package delegation
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const testChannelID = "3fda836a92faaceedfe398225fb9b2ee2ed1f01a"
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	ownsChannel = func(owner *models.User, channelID string) (bool, error) {
+		return channelID == testChannelID, nil
+	}
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func createUsers(t *testing.T) (*models.User, *models.User) {
+	srv := &models.LbrynetServer{Name: "delegation-test", Address: "http://lbrynet:5279/"}
+	require.NoError(t, srv.InsertG(boil.Infer()))
+	owner := &models.User{LbrynetServerID: null.IntFrom(srv.ID)}
+	require.NoError(t, owner.InsertG(boil.Infer()))
+	delegate := &models.User{}
+	require.NoError(t, delegate.InsertG(boil.Infer()))
+	return owner, delegate
+}
+
+func TestGrantAndRevoke(t *testing.T) {
+	owner, delegate := createUsers(t)
+
+	d, err := Grant(owner, delegate.ID, testChannelID, "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, d.OwnerID)
+	assert.Equal(t, delegate.ID, d.DelegateID)
+
+	again, err := Grant(owner, delegate.ID, testChannelID, "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, d.ID, again.ID)
+
+	received, err := ListReceived(delegate.ID)
+	require.NoError(t, err)
+	assert.Len(t, received, 1)
+
+	require.NoError(t, Revoke(owner, delegate.ID, testChannelID, "8.8.8.8"))
+	d, err = Find(testChannelID, delegate.ID)
+	require.NoError(t, err)
+	assert.Nil(t, d)
+
+	err = Revoke(owner, delegate.ID, testChannelID, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestGrant_Errors(t *testing.T) {
+	owner, delegate := createUsers(t)
+
+	_, err := Grant(owner, owner.ID, testChannelID, "")
+	assert.True(t, errors.Is(err, ErrSelfDelegation))
+
+	_, err = Grant(owner, delegate.ID+1000, testChannelID, "")
+	assert.True(t, errors.Is(err, ErrUnknownDelegate))
+
+	_, err = Grant(owner, delegate.ID, "beef", "")
+	assert.True(t, errors.Is(err, ErrNotChannelOwner))
+}
+
+func TestPublisher(t *testing.T) {
+	owner, delegate := createUsers(t)
+
+	p, err := Publisher(delegate, testChannelID)
+	require.NoError(t, err)
+	assert.Equal(t, delegate.ID, p.ID)
+
+	_, err = Grant(owner, delegate.ID, testChannelID, "")
+	require.NoError(t, err)
+
+	p, err = Publisher(delegate, testChannelID)
+	require.NoError(t, err)
+	assert.Equal(t, owner.ID, p.ID)
+
+	p, err = Publisher(delegate, "")
+	require.NoError(t, err)
+	assert.Equal(t, delegate.ID, p.ID)
+}
+
+func TestAllowsMethod(t *testing.T) {
+	for _, m := range []string{"publish", "stream_create", "stream_update"} {
+		assert.True(t, AllowsMethod(m), m)
+	}
+	for _, m := range []string{"wallet_send", "channel_export", "account_send", "stream_abandon", "stream_"} {
+		assert.False(t, AllowsMethod(m), m)
+	}
+}
+
+func TestCheckCall(t *testing.T) {
+	owner := &models.User{ID: 1}
+	orig := claimChannels
+	defer func() { claimChannels = orig }()
+	claimChannels = func(owner *models.User, filter map[string]interface{}) ([]string, error) {
+		switch {
+		case filter["claim_id"] == "inchannel", filter["name"] == "inchannel":
+			return []string{testChannelID}, nil
+		case filter["claim_id"] == "elsewhere", filter["name"] == "elsewhere":
+			return []string{testChannelID, "beef"}, nil
+		}
+		return nil, nil
+	}
+
+	assert.NoError(t, CheckCall(owner, testChannelID, "stream_create", map[string]interface{}{"name": "new"}))
+	assert.NoError(t, CheckCall(owner, testChannelID, "publish", map[string]interface{}{"name": "new"}))
+	assert.NoError(t, CheckCall(owner, testChannelID, "publish", map[string]interface{}{"name": "inchannel"}))
+	assert.NoError(t, CheckCall(owner, testChannelID, "stream_update", map[string]interface{}{"claim_id": "inchannel"}))
+
+	for _, c := range []struct {
+		method string
+		params map[string]interface{}
+		err    error
+	}{
+		{"wallet_send", map[string]interface{}{}, ErrMethodForbidden},
+		{"stream_create", map[string]interface{}{"name": "new", "bid": "1.0"}, ErrParamForbidden},
+		{"stream_create", map[string]interface{}{"name": "new", "claim_address": "bXYZ"}, ErrParamForbidden},
+		{"publish", map[string]interface{}{"name": "new", "funding_account_ids": []string{"abc"}}, ErrParamForbidden},
+		{"stream_update", map[string]interface{}{"claim_id": "inchannel", "account_id": "abc"}, ErrParamForbidden},
+		{"publish", map[string]interface{}{"name": "elsewhere"}, ErrClaimNotInChannel},
+		{"stream_update", map[string]interface{}{"claim_id": "elsewhere"}, ErrClaimNotInChannel},
+		{"stream_update", map[string]interface{}{"claim_id": "unknown"}, ErrClaimNotInChannel},
+		{"stream_update", map[string]interface{}{}, ErrClaimNotInChannel},
+	} {
+		err := CheckCall(owner, testChannelID, c.method, c.params)
+		assert.True(t, errors.Is(err, c.err), "%v %v: %v", c.method, c.params, err)
+	}
+}
@@ -0,0 +1,106 @@
+package delegation
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+)
+
+type delegationRequest struct {
+	ChannelID  string `json:"channel_id"`
+	DelegateID int    `json:"delegate_id"`
+}
+
+type listResponse struct {
+	Granted  models.ChannelDelegationSlice `json:"granted"`
+	Received models.ChannelDelegationSlice `json:"received"`
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request) *delegationRequest {
+	var req delegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return nil
+	}
+	if req.ChannelID == "" || req.DelegateID <= 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("channel_id and delegate_id are required"))
+		return nil
+	}
+	return &req
+}
+
+// HandleList returns delegations granted by and to the authenticated user.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	granted, err := ListGranted(user.ID)
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	received, err := ListReceived(user.ID)
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if granted == nil {
+		granted = models.ChannelDelegationSlice{}
+	}
+	if received == nil {
+		received = models.ChannelDelegationSlice{}
+	}
+	responses.WriteJSON(w, http.StatusOK, listResponse{Granted: granted, Received: received})
+}
+
+// HandleGrant allows another user to publish into a channel of the authenticated user.
+func HandleGrant(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	req := decodeRequest(w, r)
+	if req == nil {
+		return
+	}
+	d, err := Grant(user, req.DelegateID, req.ChannelID, ip.FromRequest(r))
+	switch {
+	case errors.Is(err, ErrSelfDelegation), errors.Is(err, ErrUnknownDelegate):
+		responses.WriteError(w, http.StatusBadRequest, err)
+	case errors.Is(err, ErrNotChannelOwner):
+		responses.WriteError(w, http.StatusForbidden, err)
+	case err != nil:
+		logger.Log().Errorf("cannot grant delegation: %v", err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+	default:
+		responses.WriteJSON(w, http.StatusOK, d)
+	}
+}
+
+// HandleRevoke removes a delegation granted by the authenticated user.
+func HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	req := decodeRequest(w, r)
+	if req == nil {
+		return
+	}
+	err := Revoke(user, req.DelegateID, req.ChannelID, ip.FromRequest(r))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		responses.WriteError(w, http.StatusNotFound, err)
+	case err != nil:
+		logger.Log().Errorf("cannot revoke delegation: %v", err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
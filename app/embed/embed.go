@@ -0,0 +1,125 @@
+// Package embed serves claims and streams to the embed player through a small anonymous facade over the SDK.
+//
+// Only resolve and get are available, without authentication. Responses are cached for long, requests are
+// rate limited per IP and the number of calls in flight is capped, so a popular embed can't take down
+// the SDK servers logged-in users are on. Those can be kept apart entirely by listing dedicated servers
+// in config.Embed.Servers.
+package embed
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/proxy"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/rules"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/urlfilter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/slo"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	endpointResolve = "resolve"
+	endpointStream  = "stream"
+)
+
+var (
+	logger = monitor.NewModuleLogger("embed")
+
+	// ErrNotFound is returned for URLs which don't resolve to a claim.
+	ErrNotFound = errors.Base("claim not found")
+	// ErrOverloaded is returned when MaxConcurrent calls are already in flight.
+	ErrOverloaded = errors.Base("too many embed requests in flight")
+
+	responseCache = cache.New(10*time.Minute, 10*time.Minute)
+	limiter       = newLimiter()
+
+	slotsOnce sync.Once
+	slots     chan struct{}
+)
+
+// acquire takes one of MaxConcurrent slots for a call to the SDK, returning false if none is free.
+// The returned function frees the slot.
+func acquire() (func(), bool) {
+	slotsOnce.Do(func() {
+		if n := config.GetEmbed().MaxConcurrent; n > 0 {
+			slots = make(chan struct{}, n)
+		}
+	})
+	if slots == nil {
+		return func() {}, true
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, true
+	default:
+		return nil, false
+	}
+}
+
+// server returns the address of the SDK server an embed call to method is sent to.
+func server(r *http.Request, method string) string {
+	if servers := config.GetEmbed().Servers; len(servers) > 0 {
+		return servers[rand.Intn(len(servers))]
+	}
+	return sdkrouter.FromRequest(r).ServerFor(method).Address
+}
+
+// call makes an anonymous call to the SDK for the embed request r.
+func call(r *http.Request, method string, params map[string]interface{}) (interface{}, error) {
+	c := query.NewCaller(server(r, method), 0)
+	urlfilter.InstallHooks(c)
+	moderation.InstallHooks(c)
+	rules.InstallHooks(c)
+	c.Deadline = proxy.Deadline(r, method, slo.ClassRead)
+	c.Context = r.Context()
+
+	res, err := c.Call(jsonrpc.NewRequest(method, params))
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, errors.Err(res.Error.Message)
+	}
+	return res.Result, nil
+}
+
+// fetch returns the serialized response of the embed endpoint for url.
+func fetch(r *http.Request, endpoint, url string) ([]byte, error) {
+	if endpoint == endpointStream {
+		result, err := call(r, query.MethodGet, map[string]interface{}{"uri": url})
+		if err != nil {
+			return nil, err
+		}
+		got, _ := result.(map[string]interface{})
+		streamingURL, _ := got[query.ParamStreamingUrl].(string)
+		if streamingURL == "" {
+			return nil, ErrNotFound
+		}
+		return json.Marshal(map[string]string{query.ParamStreamingUrl: streamingURL})
+	}
+
+	result, err := call(r, query.MethodResolve, map[string]interface{}{query.ParamUrls: []interface{}{url}})
+	if err != nil {
+		return nil, err
+	}
+	resolved, _ := result.(map[string]interface{})
+	claim, ok := resolved[url].(map[string]interface{})
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if _, ok := claim["error"]; ok {
+		return nil, ErrNotFound
+	}
+	return json.Marshal(claim)
+}
@@ -0,0 +1,128 @@
+package embed
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/ip"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// resolveServer responds to resolve with the claims given, counting calls.
+func resolveServer(t *testing.T, claims map[string]interface{}) (*httptest.Server, *int32) {
+	var calls int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, query.MethodResolve, req.Method)
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: claims})
+	})), &calls
+}
+
+func get(handler http.HandlerFunc, url, remoteAddr string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/embed/resolve?url="+url, nil)
+	r.RemoteAddr = remoteAddr
+	rr := httptest.NewRecorder()
+	ip.Middleware(handler).ServeHTTP(rr, r)
+	return rr
+}
+
+func TestHandleResolve(t *testing.T) {
+	ts, calls := resolveServer(t, map[string]interface{}{
+		"lbry://embedded": map[string]interface{}{"claim_id": "abc", "name": "embedded"},
+		"lbry://missing":  map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND"}},
+	})
+	defer ts.Close()
+	config.Override("Embed", map[string]interface{}{"Servers": []string{ts.URL}, "ResolveTTL": time.Minute})
+	defer config.RestoreOverridden()
+
+	rr := get(HandleResolve, "lbry://embedded", "10.0.0.1:1234")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var claim map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &claim))
+	assert.Equal(t, "abc", claim["claim_id"])
+	assert.Equal(t, "public, max-age=60", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// Served from cache
+	rr = get(HandleResolve, "lbry://embedded", "10.0.0.2:1234")
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+
+	rr = get(HandleResolve, "lbry://missing", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = get(HandleResolve, "", "10.0.0.1:1234")
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestHandleResolve_RateLimited(t *testing.T) {
+	ts, _ := resolveServer(t, map[string]interface{}{
+		"lbry://limited": map[string]interface{}{"claim_id": "abc"},
+	})
+	defer ts.Close()
+	config.Override("Embed", map[string]interface{}{"Servers": []string{ts.URL}, "Rate": 0.01, "Burst": 3})
+	defer config.RestoreOverridden()
+	limiter = newLimiter()
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, get(HandleResolve, "lbry://limited", "10.0.0.3:1234").Code)
+	}
+	rr := get(HandleResolve, "lbry://limited", "10.0.0.3:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	// Other IPs are not affected
+	assert.Equal(t, http.StatusOK, get(HandleResolve, "lbry://limited", "10.0.0.4:1234").Code)
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter()
+	now := time.Now()
+	assert.True(t, l.allow("a", 1, 2, now))
+	assert.True(t, l.allow("a", 1, 2, now))
+	assert.False(t, l.allow("a", 1, 2, now))
+	assert.True(t, l.allow("b", 1, 2, now))
+
+	// A token is added every second
+	assert.True(t, l.allow("a", 1, 2, now.Add(time.Second)))
+	assert.False(t, l.allow("a", 1, 2, now.Add(time.Second)))
+
+	// Full buckets are dropped
+	assert.Equal(t, 2, l.size())
+	assert.True(t, l.allow("c", 1, 2, now.Add(2*sweepInterval)))
+	assert.Equal(t, 1, l.size())
+
+	assert.True(t, l.allow("a", 0, 0, now))
+}
+
+func TestLimiterStatus(t *testing.T) {
+	l := newLimiter()
+	now := time.Unix(1600000000, 0)
+
+	s, ok := l.status("a", 1, 2, now)
+	require.True(t, ok)
+	assert.EqualValues(t, 2, s.Limit)
+	assert.EqualValues(t, 2, s.Remaining)
+	assert.EqualValues(t, now.Unix(), s.Reset)
+
+	l.allow("a", 1, 2, now)
+	l.allow("a", 1, 2, now)
+	s, _ = l.status("a", 1, 2, now)
+	assert.EqualValues(t, 0, s.Remaining)
+	assert.EqualValues(t, now.Unix()+2, s.Reset)
+
+	_, ok = l.status("a", 0, 0, now)
+	assert.False(t, ok)
+}
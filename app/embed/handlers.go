@@ -0,0 +1,95 @@
+package embed
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/ratelimit"
+	"github.com/lbryio/lbrytv/internal/responses"
+)
+
+// HandleResolve returns the claim at the `url` query param.
+func HandleResolve(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, endpointResolve)
+}
+
+// HandleStream returns the streaming URL of the claim at the `url` query param.
+func HandleStream(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, endpointStream)
+}
+
+func serve(w http.ResponseWriter, r *http.Request, endpoint string) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("url is required"))
+		return
+	}
+	cfg := config.GetEmbed()
+	now := time.Now()
+	allowed := limiter.allow(ip.FromRequest(r), cfg.Rate, cfg.Burst, now)
+	if s, ok := limiter.status(ip.FromRequest(r), cfg.Rate, cfg.Burst, now); ok {
+		ratelimit.Report(r, s)
+	}
+	if !allowed {
+		metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedRateLimited).Inc()
+		w.Header().Set("Retry-After", "1")
+		responses.WriteError(w, http.StatusTooManyRequests, errors.Err("too many requests"))
+		return
+	}
+	ttl := cfg.ResolveTTL
+	if endpoint == endpointStream {
+		ttl = cfg.StreamTTL
+	}
+
+	key := endpoint + "|" + url
+	if cached, ok := responseCache.Get(key); ok {
+		metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedCached).Inc()
+		write(w, cached.([]byte), ttl)
+		return
+	}
+
+	release, ok := acquire()
+	if !ok {
+		metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedOverloaded).Inc()
+		w.Header().Set("Retry-After", "1")
+		responses.WriteError(w, http.StatusServiceUnavailable, ErrOverloaded)
+		return
+	}
+	body, err := fetch(r, endpoint, url)
+	release()
+	if errors.Is(err, ErrNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, query.ErrLatencyBudgetExceeded) {
+		metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedFailed).Inc()
+		responses.WriteError(w, http.StatusGatewayTimeout, err)
+		return
+	} else if err != nil {
+		metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedFailed).Inc()
+		logger.Log().Errorf("error serving embed %v for %v: %v", endpoint, url, err)
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	metrics.EmbedRequests.WithLabelValues(endpoint, metrics.EmbedFetched).Inc()
+	if ttl > 0 {
+		responseCache.Set(key, body, ttl)
+	}
+	write(w, body, ttl)
+}
+
+// write sends a cached or fetched response, letting browsers and CDNs keep it for ttl.
+func write(w http.ResponseWriter, body []byte, ttl time.Duration) {
+	responses.AddJSONContentType(w)
+	if ttl > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(ttl.Seconds())))
+	}
+	w.Write(body)
+}
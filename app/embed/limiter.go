@@ -0,0 +1,102 @@
+package embed
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/ratelimit"
+)
+
+// sweepInterval is how often buckets which have filled up again are dropped.
+const sweepInterval = time.Minute
+
+// bucket holds tokens of a single IP, refilled at the limiter rate.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter is a token bucket limiter keyed by client IP.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newLimiter() *rateLimiter {
+	return &rateLimiter{buckets: map[string]*bucket{}, lastSweep: time.Now()}
+}
+
+// allow takes a token from the bucket of key, returning false if it's empty.
+// Buckets get rate tokens per second and hold up to burst of them. Zero rate disables limiting.
+func (l *rateLimiter) allow(key string, rate float64, burst int, now time.Time) bool {
+	if rate <= 0 {
+		return true
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > sweepInterval {
+		l.sweep(rate, burst, now)
+	}
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * rate
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// status returns how many tokens are left in the bucket of key and when it fills up again.
+// Nothing is returned when rate is zero as there's no limit.
+func (l *rateLimiter) status(key string, rate float64, burst int, now time.Time) (ratelimit.Status, bool) {
+	if rate <= 0 {
+		return ratelimit.Status{}, false
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tokens := float64(burst)
+	if b, ok := l.buckets[key]; ok {
+		tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*rate, tokens)
+	}
+	full := now.Add(time.Duration((float64(burst) - tokens) / rate * float64(time.Second)))
+	reset := full.Unix()
+	if full.Nanosecond() > 0 {
+		reset++
+	}
+	return ratelimit.Status{Limit: int64(burst), Remaining: int64(tokens), Reset: reset}, true
+}
+
+// sweep drops buckets which would be full by now, as they're no different from new ones.
+func (l *rateLimiter) sweep(rate float64, burst int, now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(burst) {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
+
+// size returns the number of IPs tracked.
+func (l *rateLimiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
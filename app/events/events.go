@@ -0,0 +1,294 @@
+// Package events passes SDK events, like download progress and transaction confirmations, on to clients.
+//
+// Clients subscribe to topics (see config.Events.Topics) over a WebSocket, see HandleSubscribe. While a user
+// has subscriptions, a Hub keeps a connection open to the event stream of their SDK server, subscribed
+// to the events of all their topics, and passes events on to each subscription of matching topics.
+// Events carrying a wallet ID are only passed to the user owning the wallet.
+package events
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/sdktls"
+	"github.com/lbryio/lbrytv/internal/websocket"
+)
+
+const dialTimeout = 5 * time.Second
+
+var (
+	logger = monitor.NewModuleLogger("events")
+
+	// ErrUnknownTopic is returned for subscriptions to topics not listed in config.
+	ErrUnknownTopic = errors.Base("unknown topic")
+
+	defaultHub = NewHub(DialSDK)
+)
+
+// Event is an SDK event passed on to subscribers.
+type Event struct {
+	Topic   string          `json:"topic"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// sdkMessage is an event as sent over the SDK event stream.
+type sdkMessage struct {
+	Module  string          `json:"module"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Upstream is a connection to the event stream of an SDK server.
+type Upstream interface {
+	WriteJSON(v interface{}) error
+	Close() error
+	Done() <-chan struct{}
+}
+
+// Dialer connects to the event stream of the SDK server at address, passing messages it sends to handle.
+type Dialer func(address string, handle func(message []byte)) (Upstream, error)
+
+// DialSDK connects to the event stream of the SDK server at address over a WebSocket.
+func DialSDK(address string, handle func(message []byte)) (Upstream, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	u.Path = config.GetEvents().Path
+	conn, err := websocket.Dial(u.String(), sdktls.ClientConfig(), func(_ *websocket.Conn, m []byte) { handle(m) }, dialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Subscription receives events of its topics for a user until it's closed with Hub.Unsubscribe.
+// Its channel is closed when the connection to the SDK breaks.
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	userID int
+	topics map[string]bool
+}
+
+// bridge is the connection to the SDK event stream shared by subscriptions of a user.
+type bridge struct {
+	conn Upstream
+	subs map[*Subscription]bool
+	// subscribed are SDK events subscribed to so far, as component.event
+	subscribed map[string]bool
+}
+
+// Hub bridges per-user SDK event streams to subscriptions.
+type Hub struct {
+	dial Dialer
+
+	mu      sync.Mutex
+	bridges map[int]*bridge
+}
+
+// NewHub creates a hub connecting to SDK event streams with dial.
+func NewHub(dial Dialer) *Hub {
+	return &Hub{dial: dial, bridges: map[int]*bridge{}}
+}
+
+// Subscribe subscribes the user whose wallet is on the SDK server at address to topics,
+// connecting to its event stream if the user has no other subscriptions.
+func (h *Hub) Subscribe(userID int, address string, topics []string) (*Subscription, error) {
+	cfg := config.GetEvents()
+	sub := &Subscription{c: make(chan Event, cfg.BufferSize), userID: userID, topics: map[string]bool{}}
+	sub.C = sub.c
+	sdkEvents := map[string]bool{}
+	for _, t := range topics {
+		e, ok := cfg.Topics[t]
+		if !ok {
+			return nil, errors.Prefix(t, ErrUnknownTopic)
+		}
+		sub.topics[t] = true
+		sdkEvents[e] = true
+	}
+
+	b, err := h.getBridge(userID, address)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.bridges[userID] != b {
+		// The connection broke in the meantime
+		return nil, errors.Err("connection to sdk event stream closed")
+	}
+	requested := map[string][]string{}
+	for e := range sdkEvents {
+		if b.subscribed[e] {
+			continue
+		}
+		parts := strings.SplitN(e, ".", 2)
+		if len(parts) != 2 {
+			h.closeUnused(userID, b)
+			return nil, errors.Err("invalid sdk event %v", e)
+		}
+		requested[parts[0]] = append(requested[parts[0]], parts[1])
+	}
+	if len(requested) > 0 {
+		if err := b.conn.WriteJSON(map[string]interface{}{"action": "subscribe", "data": requested}); err != nil {
+			h.closeUnused(userID, b)
+			return nil, errors.Prefix("error subscribing to sdk events", err)
+		}
+		for e := range sdkEvents {
+			b.subscribed[e] = true
+		}
+	}
+	b.subs[sub] = true
+	metrics.EventSubscriptions.Inc()
+	return sub, nil
+}
+
+// getBridge returns the user's bridge, connecting to the SDK if there's none yet.
+func (h *Hub) getBridge(userID int, address string) (*bridge, error) {
+	h.mu.Lock()
+	b, ok := h.bridges[userID]
+	h.mu.Unlock()
+	if ok {
+		return b, nil
+	}
+
+	b = &bridge{subs: map[*Subscription]bool{}, subscribed: map[string]bool{}}
+	conn, err := h.dial(address, func(message []byte) { h.deliver(userID, b, message) })
+	if err != nil {
+		return nil, errors.Prefix("error connecting to sdk event stream", err)
+	}
+
+	h.mu.Lock()
+	if other, ok := h.bridges[userID]; ok {
+		// Another subscription of the user connected first
+		h.mu.Unlock()
+		conn.Close()
+		return other, nil
+	}
+	b.conn = conn
+	h.bridges[userID] = b
+	h.mu.Unlock()
+	metrics.EventSDKConnections.Inc()
+	logger.Log().Debugf("connected to sdk event stream at %v for user %v", address, userID)
+
+	go func() {
+		<-conn.Done()
+		h.drop(userID, b)
+	}()
+	return b, nil
+}
+
+// Unsubscribe closes the subscription, disconnecting from the SDK if it was the last one of the user.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	b, ok := h.bridges[sub.userID]
+	if !ok || !b.subs[sub] {
+		h.mu.Unlock()
+		return
+	}
+	delete(b.subs, sub)
+	metrics.EventSubscriptions.Dec()
+	last := len(b.subs) == 0
+	if last {
+		delete(h.bridges, sub.userID)
+	}
+	h.mu.Unlock()
+
+	if last {
+		b.conn.Close()
+	}
+}
+
+// closeUnused disconnects the bridge from the SDK if it has no subscriptions. h.mu has to be held.
+func (h *Hub) closeUnused(userID int, b *bridge) {
+	if len(b.subs) > 0 {
+		return
+	}
+	delete(h.bridges, userID)
+	b.conn.Close()
+}
+
+// drop forgets the bridge once its connection is closed, closing subscriptions still using it.
+func (h *Hub) drop(userID int, b *bridge) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.bridges[userID] == b {
+		delete(h.bridges, userID)
+		logger.Log().Infof("sdk event stream of user %v closed", userID)
+	}
+	for sub := range b.subs {
+		close(sub.c)
+		delete(b.subs, sub)
+		metrics.EventSubscriptions.Dec()
+	}
+	metrics.EventSDKConnections.Dec()
+}
+
+// deliver passes a message from the SDK event stream of the user on to their subscriptions.
+func (h *Hub) deliver(userID int, b *bridge, message []byte) {
+	var m sdkMessage
+	if err := json.Unmarshal(message, &m); err != nil || m.Module == "" {
+		logger.Log().Debugf("ignoring sdk event stream message: %s", message)
+		return
+	}
+	cfg := config.GetEvents()
+	public := map[string]bool{}
+	for _, t := range cfg.PublicTopics {
+		public[t] = true
+	}
+	sdkEvent := m.Module + "." + m.Event
+	var topics []string
+	for t, e := range cfg.Topics {
+		if e == sdkEvent && forWallet(m.Payload, sdkrouter.WalletID(userID), public[t]) {
+			topics = append(topics, t)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, t := range topics {
+		for sub := range b.subs {
+			if !sub.topics[t] {
+				continue
+			}
+			select {
+			case sub.c <- Event{Topic: t, Payload: m.Payload}:
+				metrics.EventCount.WithLabelValues(t, metrics.EventDelivered).Inc()
+			default:
+				metrics.EventCount.WithLabelValues(t, metrics.EventDropped).Inc()
+			}
+		}
+	}
+}
+
+// forWallet returns true for event payloads naming walletID. Payloads not naming any wallet are only
+// for everybody when public is set, events of a shared SDK server could otherwise leak to other users.
+func forWallet(payload json.RawMessage, walletID string, public bool) bool {
+	var p struct {
+		WalletID string `json:"wallet_id"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return false
+	}
+	if p.WalletID == "" {
+		return public
+	}
+	return p.WalletID == walletID
+}
+
+// Default returns the hub used by HandleSubscribe.
+func Default() *Hub {
+	return defaultHub
+}
@@ -0,0 +1,161 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpstream records messages written to it, send passes a message to the hub as if it came from the SDK.
+type fakeUpstream struct {
+	mu      sync.Mutex
+	written []interface{}
+	done    chan struct{}
+	once    sync.Once
+	send    func(message []byte)
+}
+
+func (u *fakeUpstream) WriteJSON(v interface{}) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.written = append(u.written, v)
+	return nil
+}
+
+func (u *fakeUpstream) Close() error {
+	u.once.Do(func() { close(u.done) })
+	return nil
+}
+
+func (u *fakeUpstream) Done() <-chan struct{} { return u.done }
+
+func (u *fakeUpstream) writes() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.written)
+}
+
+// fakeDialer returns a dialer creating fake upstreams and the list of upstreams it created.
+func fakeDialer() (Dialer, *[]*fakeUpstream) {
+	var ups []*fakeUpstream
+	return func(address string, handle func([]byte)) (Upstream, error) {
+		u := &fakeUpstream{done: make(chan struct{}), send: handle}
+		ups = append(ups, u)
+		return u, nil
+	}, &ups
+}
+
+func sdkEvent(t *testing.T, module, event string, payload interface{}) []byte {
+	p, err := json.Marshal(payload)
+	require.NoError(t, err)
+	m, err := json.Marshal(sdkMessage{Module: module, Event: event, Payload: p})
+	require.NoError(t, err)
+	return m
+}
+
+func receive(t *testing.T, sub *Subscription) (Event, bool) {
+	select {
+	case ev, ok := <-sub.C:
+		return ev, ok
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}, false
+	}
+}
+
+func TestHub_Deliver(t *testing.T) {
+	config.Override("Events.PublicTopics", []string{"download_progress"})
+	defer config.RestoreOverridden()
+	dial, ups := fakeDialer()
+	h := NewHub(dial)
+
+	progress, err := h.Subscribe(1, "http://sdk:5279", []string{"download_progress"})
+	require.NoError(t, err)
+	both, err := h.Subscribe(1, "http://sdk:5279", []string{"download_progress", "transaction_confirmed"})
+	require.NoError(t, err)
+	require.Len(t, *ups, 1)
+	up := (*ups)[0]
+	// The second subscription only asks the SDK for events not subscribed to yet
+	assert.Equal(t, 2, up.writes())
+
+	// Events not naming a wallet are only passed on for public topics
+	up.send(sdkEvent(t, "wallet", "transaction_confirmed", map[string]string{"txid": "abc"}))
+	up.send(sdkEvent(t, "wallet", "transaction_confirmed", map[string]string{"txid": "abc", "wallet_id": sdkrouter.WalletID(1)}))
+	ev, _ := receive(t, both)
+	assert.Equal(t, "transaction_confirmed", ev.Topic)
+	assert.JSONEq(t, `{"txid": "abc", "wallet_id": "`+sdkrouter.WalletID(1)+`"}`, string(ev.Payload))
+	assert.Len(t, both.C, 0)
+	assert.Len(t, progress.C, 0)
+
+	up.send(sdkEvent(t, "file_manager", "status", map[string]string{"sd_hash": "def"}))
+	ev, _ = receive(t, progress)
+	assert.Equal(t, "download_progress", ev.Topic)
+	ev, _ = receive(t, both)
+	assert.Equal(t, "download_progress", ev.Topic)
+
+	// Events of other wallets on the same server aren't passed on
+	up.send(sdkEvent(t, "wallet", "transaction_confirmed", map[string]string{"wallet_id": sdkrouter.WalletID(2)}))
+	up.send(sdkEvent(t, "wallet", "transaction_confirmed", map[string]string{"wallet_id": sdkrouter.WalletID(1)}))
+	ev, _ = receive(t, both)
+	assert.JSONEq(t, `{"wallet_id": "`+sdkrouter.WalletID(1)+`"}`, string(ev.Payload))
+	assert.Len(t, both.C, 0)
+
+	up.send([]byte(`not json`))
+	assert.Len(t, both.C, 0)
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	dial, ups := fakeDialer()
+	h := NewHub(dial)
+
+	first, err := h.Subscribe(1, "http://sdk:5279", []string{"download_progress"})
+	require.NoError(t, err)
+	second, err := h.Subscribe(1, "http://sdk:5279", []string{"download_progress"})
+	require.NoError(t, err)
+	up := (*ups)[0]
+
+	h.Unsubscribe(first)
+	h.Unsubscribe(first)
+	assert.NotNil(t, h.bridges[1])
+	h.Unsubscribe(second)
+	select {
+	case <-up.Done():
+	case <-time.After(time.Second):
+		t.Fatal("upstream not closed")
+	}
+
+	// A new subscription connects again
+	_, err = h.Subscribe(1, "http://sdk:5279", []string{"download_progress"})
+	require.NoError(t, err)
+	assert.Len(t, *ups, 2)
+}
+
+func TestHub_UpstreamClosed(t *testing.T) {
+	dial, ups := fakeDialer()
+	h := NewHub(dial)
+
+	sub, err := h.Subscribe(1, "http://sdk:5279", []string{"blob_announced"})
+	require.NoError(t, err)
+	(*ups)[0].Close()
+
+	_, ok := receive(t, sub)
+	assert.False(t, ok)
+	h.Unsubscribe(sub)
+}
+
+func TestHub_UnknownTopic(t *testing.T) {
+	dial, ups := fakeDialer()
+	h := NewHub(dial)
+
+	_, err := h.Subscribe(1, "http://sdk:5279", []string{"download_progress", "nonexistent"})
+	assert.True(t, errors.Is(err, ErrUnknownTopic))
+	assert.Len(t, *ups, 0)
+}
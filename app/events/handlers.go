@@ -0,0 +1,72 @@
+package events
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/websocket"
+)
+
+// HandleSubscribe streams SDK events of comma-separated `topics` from the query to the authenticated user
+// over a WebSocket, until the client disconnects or the connection to the SDK breaks.
+func HandleSubscribe(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var topics []string
+	for _, t := range strings.Split(r.URL.Query().Get("topics"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+	if len(topics) == 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("topics are required"))
+		return
+	}
+	address := sdkrouter.GetSDKAddress(user)
+	if address == "" {
+		responses.WriteError(w, http.StatusServiceUnavailable, errors.Err("user has no sdk server assigned"))
+		return
+	}
+
+	hub := Default()
+	sub, err := hub.Subscribe(user.ID, address, topics)
+	if errors.Is(err, ErrUnknownTopic) {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error subscribing user %v to %v: %v", user.ID, topics, err)
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	defer hub.Unsubscribe(sub)
+
+	conn, err := websocket.Upgrade(w, r)
+	if errors.Is(err, websocket.ErrNotWebSocket) {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error upgrading events connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-conn.Done():
+			return
+		}
+	}
+}
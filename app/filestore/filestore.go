@@ -0,0 +1,90 @@
+// Package filestore keeps uploaded files where SDK nodes can take them from for publishing.
+// Files are kept on local disk by default. With S3 or GCS, they are uploaded to a bucket and SDK nodes
+// are given presigned URLs instead of local paths, so API nodes and SDK nodes don't need a shared volume.
+package filestore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendGCS   = "gcs"
+)
+
+var (
+	logger = monitor.NewModuleLogger("filestore")
+
+	ErrUnknownBackend = errors.Base("unknown storage backend")
+	ErrNotFound       = errors.Base("file not found in storage")
+
+	defaultMu      sync.RWMutex
+	defaultStorage Storage = LocalStorage{}
+)
+
+// Storage keeps files uploaded for publishing.
+type Storage interface {
+	// Put stores the file at localPath under key and returns the location SDK nodes should read it from.
+	Put(key, localPath string) (string, error)
+	// Remove deletes the file stored under key.
+	Remove(key string) error
+}
+
+// Fetcher is storage files can be taken back from, which makes it shared between API instances.
+type Fetcher interface {
+	// Fetch saves the file stored under key at localPath. It returns ErrNotFound if there's no such file.
+	Fetch(key, localPath string) error
+}
+
+// New returns storage of the configured backend.
+func New(cfg config.UploadStorage) (Storage, error) {
+	switch cfg.Backend {
+	case "", BackendLocal:
+		return LocalStorage{}, nil
+	case BackendS3:
+		return NewS3Storage(cfg)
+	case BackendGCS:
+		return NewGCSStorage(cfg)
+	}
+	return nil, errors.Prefix(cfg.Backend, ErrUnknownBackend)
+}
+
+// SetDefault sets storage used by publish handlers.
+func SetDefault(s Storage) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultStorage = s
+}
+
+// Default returns storage used by publish handlers.
+func Default() Storage {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultStorage
+}
+
+// LocalStorage hands files to SDK nodes by their local path, which has to be on a volume shared with them.
+type LocalStorage struct{}
+
+// Put returns localPath as is.
+func (LocalStorage) Put(_, localPath string) (string, error) {
+	return localPath, nil
+}
+
+// Remove does nothing, local files are removed by their owners.
+func (LocalStorage) Remove(string) error {
+	return nil
+}
+
+func defaultURLExpiry(d time.Duration) time.Duration {
+	if d <= 0 {
+		return time.Hour
+	}
+	return d
+}
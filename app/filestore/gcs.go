@@ -0,0 +1,28 @@
+package filestore
+
+import (
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+)
+
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// GCSStorage keeps files in a Google Cloud Storage bucket through its XML API, which accepts
+// Signature Version 4 requests made with HMAC keys of a service account.
+type GCSStorage struct {
+	*S3Storage
+}
+
+// NewGCSStorage returns GCS storage for the configured bucket, AccessKey and SecretKey being an HMAC key.
+func NewGCSStorage(cfg config.UploadStorage) (*GCSStorage, error) {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = gcsEndpoint
+	}
+	if cfg.Region == "" {
+		cfg.Region = "auto"
+	}
+	s, err := NewS3Storage(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSStorage{s}, nil
+}
@@ -0,0 +1,275 @@
+package filestore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	sigAlgorithm    = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+	requestTimeout  = 30 * time.Minute
+)
+
+// S3Storage keeps files in an S3 bucket, SDK nodes get presigned URLs to them.
+// Requests are signed with AWS Signature Version 4, so any S3-compatible service can be used via Endpoint.
+type S3Storage struct {
+	endpoint  string
+	bucket    string
+	region    string
+	service   string
+	accessKey string
+	secretKey string
+	urlExpiry time.Duration
+	client    *http.Client
+}
+
+// NewS3Storage returns S3 storage for the configured bucket. Endpoint defaults to the AWS one of the region.
+func NewS3Storage(cfg config.UploadStorage) (*S3Storage, error) {
+	if cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, errors.Err("bucket and access keys are required for s3 storage")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%v.amazonaws.com", region)
+	}
+	return &S3Storage{
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		bucket:    cfg.Bucket,
+		region:    region,
+		service:   "s3",
+		accessKey: cfg.AccessKey,
+		secretKey: cfg.SecretKey,
+		urlExpiry: defaultURLExpiry(cfg.URLExpiry),
+		client:    &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// objectURL returns path-style URL of the object stored under key.
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	return url.Parse(s.endpoint + "/" + s.bucket + "/" + escape(key, false))
+}
+
+// Put uploads the file and returns a presigned URL to it, valid for URLExpiry.
+func (s *S3Storage) Put(key, localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), f)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	req.ContentLength = stat.Size()
+	if err := s.do(req); err != nil {
+		return "", err
+	}
+	logger.WithFields(logrus.Fields{"bucket": s.bucket, "key": key, "size": stat.Size()}).Info("uploaded file stored")
+	return s.presign(http.MethodGet, u, time.Now(), s.urlExpiry), nil
+}
+
+// Fetch downloads the object stored under key to localPath.
+func (s *S3Storage) Fetch(key, localPath string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return errors.Err(err)
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return errors.Err(err)
+	}
+	s.sign(req, time.Now())
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return errors.Prefix(key, ErrNotFound)
+	} else if res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return errors.Err("%v %v responded with %v: %s", req.Method, req.URL.Path, res.Status, body)
+	}
+
+	// Downloaded under a temporary name so a broken download doesn't leave a partial file behind
+	f, err := ioutil.TempFile(filepath.Dir(localPath), "*.fetch")
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer os.Remove(f.Name())
+	_, err = io.Copy(f, res.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Err(err)
+	}
+	return errors.Err(os.Rename(f.Name(), localPath))
+}
+
+// Remove deletes the object stored under key.
+func (s *S3Storage) Remove(key string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return errors.Err(err)
+	}
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return errors.Err(err)
+	}
+	return s.do(req)
+}
+
+func (s *S3Storage) do(req *http.Request) error {
+	s.sign(req, time.Now())
+	res, err := s.client.Do(req)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		body, _ := ioutil.ReadAll(res.Body)
+		return errors.Err("%v %v responded with %v: %s", req.Method, req.URL.Path, res.Status, body)
+	}
+	return nil
+}
+
+// sign adds Signature Version 4 authorization headers to req. Payloads are not signed so files can be streamed.
+func (s *S3Storage) sign(req *http.Request, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": unsignedPayload,
+		"x-amz-date":           t.Format(amzDateFormat),
+	}
+	signed, canonicalHeaders := canonicalizeHeaders(headers)
+	canonical := strings.Join([]string{
+		req.Method, canonicalURI(req.URL), canonicalQuery(req.URL.Query()), canonicalHeaders, signed, unsignedPayload,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%v Credential=%v/%v, SignedHeaders=%v, Signature=%v",
+		sigAlgorithm, s.accessKey, s.scope(t), signed, s.signature(t, canonical)))
+}
+
+// presign returns u with query authentication valid for expiry from t.
+func (s *S3Storage) presign(method string, u *url.URL, t time.Time, expiry time.Duration) string {
+	t = t.UTC()
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", sigAlgorithm)
+	q.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(t))
+	q.Set("X-Amz-Date", t.Format(amzDateFormat))
+	q.Set("X-Amz-Expires", fmt.Sprintf("%d", int(expiry.Seconds())))
+	q.Set("X-Amz-SignedHeaders", "host")
+	_, canonicalHeaders := canonicalizeHeaders(map[string]string{"host": u.Host})
+	canonical := strings.Join([]string{
+		method, canonicalURI(u), canonicalQuery(q), canonicalHeaders, "host", unsignedPayload,
+	}, "\n")
+	signed := *u
+	signed.RawQuery = canonicalQuery(q) + "&X-Amz-Signature=" + s.signature(t, canonical)
+	return signed.String()
+}
+
+func (s *S3Storage) scope(t time.Time) string {
+	return strings.Join([]string{t.Format("20060102"), s.region, s.service, "aws4_request"}, "/")
+}
+
+func (s *S3Storage) signature(t time.Time, canonicalRequest string) string {
+	h := sha256.Sum256([]byte(canonicalRequest))
+	toSign := strings.Join([]string{sigAlgorithm, t.Format(amzDateFormat), s.scope(t), hex.EncodeToString(h[:])}, "\n")
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{t.Format("20060102"), s.region, s.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// canonicalizeHeaders returns the list of signed header names and their canonical form.
+func canonicalizeHeaders(headers map[string]string) (string, string) {
+	names := []string{}
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, k := range names {
+		b.WriteString(k + ":" + strings.TrimSpace(headers[k]) + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+func canonicalURI(u *url.URL) string {
+	if u.EscapedPath() == "" {
+		return "/"
+	}
+	return escape(u.Path, false)
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := []string{}
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, k := range keys {
+		vs := q[k]
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, escape(k, true)+"="+escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape URI-encodes s the way Signature Version 4 expects, leaving slashes as is unless encodeSlash is set.
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
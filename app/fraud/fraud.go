@@ -0,0 +1,278 @@
+// Package fraud has publishes and purchases scored for fraud risk by an external service before they go through.
+//
+// Operations scoring FraudScoring.HoldScore or more are held for review and those scoring RejectScore or more
+// are rejected. A held operation is recorded as a Review, which admins approve or reject. Once approved,
+// the same operation made again by the user goes through without being scored. Every decision is written
+// to the audit log.
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/audit"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	KindPublish  = "publish"
+	KindPurchase = "purchase"
+
+	auditAction = "fraud_scoring"
+)
+
+var (
+	logger = monitor.NewModuleLogger("fraud")
+
+	ErrHeld     = errors.Base("operation is held for review")
+	ErrRejected = errors.Base("operation was rejected as risky")
+)
+
+// Operation is a publish or purchase to be scored.
+type Operation struct {
+	Kind     string                 `json:"kind"`
+	Method   string                 `json:"method"`
+	UserID   int                    `json:"user_id"`
+	RemoteIP string                 `json:"remote_ip"`
+	Params   map[string]interface{} `json:"params"`
+}
+
+// Score is how risky the scoring service found an operation, from 0 to 1.
+type Score struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Provider scores operations.
+type Provider interface {
+	Score(ctx context.Context, op Operation) (*Score, error)
+}
+
+// HTTPProvider posts operations as JSON to URL, which responds with a Score.
+type HTTPProvider struct {
+	URL      string
+	APIToken string
+	Client   *http.Client
+}
+
+// Score sends op to the scoring service.
+func (p HTTPProvider) Score(ctx context.Context, op Operation) (*Score, error) {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	req, err := http.NewRequest(http.MethodPost, p.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if p.APIToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIToken)
+	}
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Err("scoring service responded with %v: %s", res.Status, data)
+	}
+	var s Score
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, errors.Err("malformed scoring response: %v", err)
+	}
+	return &s, nil
+}
+
+// Checker decides whether operations go through, according to scores given by Provider.
+type Checker struct {
+	Provider Provider
+	Config   config.FraudScoring
+}
+
+// DefaultChecker returns the checker set up in FraudScoring config, nil if scoring is disabled.
+func DefaultChecker() *Checker {
+	cfg := config.GetFraudScoring()
+	if cfg.URL == "" {
+		return nil
+	}
+	return &Checker{Provider: HTTPProvider{URL: cfg.URL, APIToken: cfg.APIToken}, Config: cfg}
+}
+
+// Check has op scored with the default checker, see Checker.Check.
+func Check(ctx context.Context, op Operation) error {
+	c := DefaultChecker()
+	if c == nil {
+		return nil
+	}
+	return c.Check(ctx, op)
+}
+
+// Covers returns true if calls to method are scored.
+func (c *Checker) Covers(method string) bool {
+	for _, m := range c.Config.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// decision is written to the audit log for every operation checked.
+type decision struct {
+	Kind     string   `json:"kind"`
+	Method   string   `json:"method"`
+	Decision string   `json:"decision"`
+	Score    *float64 `json:"score,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+	ReviewID int64    `json:"review_id,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// Check returns nil if op may go through. Operations held for review or rejected get an RPC error
+// to be returned to the client, errors of scoring are handled according to FailOpen.
+func (c *Checker) Check(ctx context.Context, op Operation) error {
+	if !c.Covers(op.Method) {
+		return nil
+	}
+	d := decision{Kind: op.Kind, Method: op.Method}
+	err := c.check(ctx, op, &d)
+	metrics.FraudDecisions.WithLabelValues(op.Kind, d.Decision).Inc()
+	log := logger.WithFields(logrus.Fields{"user_id": op.UserID, "method": op.Method, "decision": d.Decision})
+	if d.Decision != metrics.FraudAllowed {
+		log.WithFields(logrus.Fields{"score": d.Score, "reasons": d.Reasons, "review_id": d.ReviewID}).Info("fraud scoring decision")
+	}
+	if body, merr := json.Marshal(d); merr == nil {
+		audit.LogQuery(op.UserID, op.RemoteIP, auditAction, body)
+	} else {
+		logger.Log().Errorf("cannot marshal audit details: %v", merr)
+	}
+	return err
+}
+
+func (c *Checker) check(ctx context.Context, op Operation, d *decision) error {
+	fingerprint, err := Fingerprint(op)
+	if err != nil {
+		return c.failed(d, err)
+	}
+	// A review of the same operation decides it without scoring it again
+	r, err := findReview(op.UserID, fingerprint)
+	if err != nil {
+		return c.failed(d, err)
+	}
+	if r != nil {
+		d.ReviewID = r.ID
+		switch r.Status {
+		case StatusApproved:
+			err := consumeReview(r.ID)
+			if err == nil {
+				d.Decision = metrics.FraudApproved
+				return nil
+			} else if !errors.Is(err, ErrAlreadyReviewed) {
+				return c.failed(d, err)
+			}
+			// Another call used the approval in the meantime, this one is scored as usual
+		case StatusPending:
+			d.Decision = metrics.FraudHeld
+			return declined(ErrHeld, r.ID)
+		case StatusRejected:
+			d.Decision = metrics.FraudRejected
+			return declined(ErrRejected, r.ID)
+		}
+		d.ReviewID = 0
+	}
+
+	timeout := c.Config.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	sctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	s, err := c.Provider.Score(sctx, op)
+	metrics.FraudScoringDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return c.failed(d, err)
+	}
+	d.Score, d.Reasons = &s.Score, s.Reasons
+
+	switch {
+	case s.Score >= c.Config.RejectScore:
+		d.Decision = metrics.FraudRejected
+		return declined(ErrRejected, 0)
+	case s.Score >= c.Config.HoldScore:
+		id, err := createReview(op, fingerprint, s)
+		if err != nil {
+			return c.failed(d, err)
+		}
+		d.Decision, d.ReviewID = metrics.FraudHeld, id
+		return declined(ErrHeld, id)
+	}
+	d.Decision = metrics.FraudAllowed
+	return nil
+}
+
+// failed applies the failure policy to an operation which couldn't be scored.
+func (c *Checker) failed(d *decision, err error) error {
+	d.Error = err.Error()
+	logger.Log().Errorf("fraud scoring of %v failed: %v", d.Method, err)
+	if c.Config.FailOpen {
+		d.Decision = metrics.FraudFailedOpen
+		return nil
+	}
+	d.Decision = metrics.FraudFailedClosed
+	return rpcerrors.NewRiskDeclinedError(errors.Err("operation cannot be checked at the moment, try again later"))
+}
+
+// DeclinedDetails is sent in the data field of errors for declined operations.
+type DeclinedDetails struct {
+	ReviewID int64 `json:"review_id,omitempty"`
+}
+
+func declined(err error, reviewID int64) error {
+	return rpcerrors.NewRiskDeclinedError(err).WithData(DeclinedDetails{ReviewID: reviewID})
+}
+
+// Fingerprint identifies the operation, so a review of it applies when the user makes it again.
+func Fingerprint(op Operation) (string, error) {
+	// Keys of maps are marshaled sorted, so equal params give equal JSON
+	params, err := json.Marshal(op.Params)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	h := sha256.Sum256([]byte(strings.Join([]string{op.Kind, op.Method, string(params)}, "|")))
+	return hex.EncodeToString(h[:]), nil
+}
+
+// KindOf returns the kind of operation made by calling method.
+func KindOf(method string) string {
+	if strings.HasPrefix(method, "purchase") {
+		return KindPurchase
+	}
+	return KindPublish
+}
@@ -0,0 +1,158 @@
+package fraud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+// fixedProvider gives every operation the same score and counts calls.
+type fixedProvider struct {
+	score *Score
+	err   error
+	calls int
+}
+
+func (p *fixedProvider) Score(_ context.Context, _ Operation) (*Score, error) {
+	p.calls++
+	return p.score, p.err
+}
+
+func newChecker(p Provider) *Checker {
+	return &Checker{Provider: p, Config: config.FraudScoring{
+		Timeout: time.Second, HoldScore: 0.7, RejectScore: 0.9, Methods: []string{"publish", "purchase_create"},
+	}}
+}
+
+func rpcCode(t *testing.T, err error) int {
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr), err)
+	return rpcErr.Code()
+}
+
+func TestHTTPProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var op Operation
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&op))
+		assert.Equal(t, KindPurchase, op.Kind)
+		assert.Equal(t, "lbry://one", op.Params["url"])
+		w.Write([]byte(`{"score": 0.42, "reasons": ["new account"]}`))
+	}))
+	defer ts.Close()
+
+	op := Operation{Kind: KindPurchase, Method: "purchase_create", UserID: 1, Params: map[string]interface{}{"url": "lbry://one"}}
+	s, err := HTTPProvider{URL: ts.URL, APIToken: "token"}.Score(context.Background(), op)
+	require.NoError(t, err)
+	assert.Equal(t, 0.42, s.Score)
+	assert.Equal(t, []string{"new account"}, s.Reasons)
+
+	_, err = HTTPProvider{URL: ts.URL}.Score(context.Background(), op)
+	assert.Error(t, err)
+}
+
+func TestChecker_Decisions(t *testing.T) {
+	_, err := boil.GetDB().Exec(`TRUNCATE fraud_reviews`)
+	require.NoError(t, err)
+	p := &fixedProvider{score: &Score{Score: 0.1}}
+	c := newChecker(p)
+	op := Operation{Kind: KindPublish, Method: "publish", UserID: 1, Params: map[string]interface{}{"name": "video"}}
+
+	require.NoError(t, c.Check(context.Background(), op))
+	require.NoError(t, c.Check(context.Background(), Operation{Method: "resolve"}))
+	assert.Equal(t, 1, p.calls)
+
+	p.score = &Score{Score: 0.95}
+	assert.Equal(t, -32094, rpcCode(t, c.Check(context.Background(), op)))
+
+	p.score = &Score{Score: 0.8, Reasons: []string{"velocity"}}
+	err = c.Check(context.Background(), op)
+	assert.True(t, errors.Is(err, ErrHeld))
+	reviews, err := ListReviews(StatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, 0.8, reviews[0].Score)
+	assert.JSONEq(t, `["velocity"]`, string(reviews[0].Reasons))
+
+	// Held operations aren't scored again until reviewed
+	assert.True(t, errors.Is(c.Check(context.Background(), op), ErrHeld))
+	assert.Equal(t, 3, p.calls)
+
+	_, err = Decide(reviews[0].ID, "maybe", "")
+	assert.True(t, errors.Is(err, ErrInvalidStatus))
+	_, err = Decide(reviews[0].ID, StatusApproved, "")
+	require.NoError(t, err)
+	_, err = Decide(reviews[0].ID, StatusRejected, "")
+	assert.True(t, errors.Is(err, ErrAlreadyReviewed))
+	_, err = Decide(reviews[0].ID+100, StatusRejected, "")
+	assert.True(t, errors.Is(err, ErrReviewNotFound))
+
+	// The approval lets the operation through once
+	require.NoError(t, c.Check(context.Background(), op))
+	assert.Equal(t, 3, p.calls)
+	assert.True(t, errors.Is(c.Check(context.Background(), op), ErrHeld))
+	assert.Equal(t, 4, p.calls)
+
+	reviews, err = ListReviews(StatusPending, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	_, err = Decide(reviews[0].ID, StatusRejected, "")
+	require.NoError(t, err)
+	assert.True(t, errors.Is(c.Check(context.Background(), op), ErrRejected))
+	assert.Equal(t, 4, p.calls)
+}
+
+func TestChecker_FailurePolicy(t *testing.T) {
+	c := newChecker(&fixedProvider{err: errors.Err("connection refused")})
+	op := Operation{Kind: KindPurchase, Method: "purchase_create", UserID: 2, Params: map[string]interface{}{"url": "lbry://two"}}
+
+	c.Config.FailOpen = true
+	assert.NoError(t, c.Check(context.Background(), op))
+	c.Config.FailOpen = false
+	assert.Equal(t, -32094, rpcCode(t, c.Check(context.Background(), op)))
+}
+
+func TestFingerprint(t *testing.T) {
+	a, err := Fingerprint(Operation{Kind: KindPublish, Method: "publish", Params: map[string]interface{}{"name": "a", "bid": "1.0"}})
+	require.NoError(t, err)
+	b, err := Fingerprint(Operation{Kind: KindPublish, Method: "publish", Params: map[string]interface{}{"bid": "1.0", "name": "a"}})
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	c, err := Fingerprint(Operation{Kind: KindPublish, Method: "publish", Params: map[string]interface{}{"bid": "2.0", "name": "a"}})
+	require.NoError(t, err)
+	assert.NotEqual(t, a, c)
+	assert.Equal(t, KindPurchase, KindOf("purchase_create"))
+	assert.Equal(t, KindPublish, KindOf("stream_update"))
+}
@@ -0,0 +1,123 @@
+package fraud
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type decideRequest struct {
+	Status string `json:"status"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrReviewNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrAlreadyReviewed):
+		status = http.StatusConflict
+	case errors.Is(err, ErrInvalidStatus):
+		status = http.StatusBadRequest
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+func reviewID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		return 0, errors.Err("invalid review id")
+	}
+	return id, nil
+}
+
+// HandleList returns fraud reviews, optionally filtered by `status` and paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", StatusPending, StatusApproved, StatusRejected, StatusUsed:
+	default:
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid status %q", status))
+		return
+	}
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	reviews, err := ListReviews(status, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, reviews)
+}
+
+// HandleGet returns the fraud review with id from the URL.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := reviewID(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	review, err := GetReview(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, review)
+}
+
+// HandleDecide approves or rejects the pending fraud review with id from the URL,
+// the body being {"status": "approved"} or {"status": "rejected"}.
+func HandleDecide(w http.ResponseWriter, r *http.Request) {
+	id, err := reviewID(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req decideRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	review, err := Decide(id, req.Status, ip.AddressForRequest(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, review)
+}
@@ -0,0 +1,28 @@
+package fraud
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "fraud_scoring"
+
+// InstallHooks makes c have calls to FraudScoring.Methods scored before they are sent to the SDK.
+// remoteIP is passed on to the scoring service.
+func InstallHooks(c *query.Caller, remoteIP string) {
+	checker := DefaultChecker()
+	if checker == nil {
+		return
+	}
+	c.RegisterHook(hookName, query.StagePreflight, func(c *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+		method := hctx.Query.Method()
+		if c.UserID() == 0 || !checker.Covers(method) {
+			return nil, nil
+		}
+		op := Operation{
+			Kind: KindOf(method), Method: method, UserID: c.UserID(), RemoteIP: remoteIP, Params: hctx.Query.ParamsAsMap(),
+		}
+		return nil, checker.Check(c.Context, op)
+	}, query.HookOptions{Method: query.AllMethodsHook, Priority: query.HookPriorityLate})
+}
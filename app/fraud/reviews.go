@@ -0,0 +1,171 @@
+package fraud
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/audit"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	// StatusPending is set on held operations awaiting review.
+	StatusPending = "pending"
+	// StatusApproved lets the operation through the next time the user makes it.
+	StatusApproved = "approved"
+	// StatusRejected keeps the operation from going through.
+	StatusRejected = "rejected"
+	// StatusUsed is set on approved reviews once the operation went through.
+	StatusUsed = "used"
+)
+
+var (
+	ErrReviewNotFound  = errors.Base("fraud review not found")
+	ErrAlreadyReviewed = errors.Base("fraud review has already been decided")
+	ErrInvalidStatus   = errors.Base("status must be one of: approved, rejected")
+)
+
+// Review is an operation held for review by fraud scoring.
+type Review struct {
+	ID          int64           `json:"id"`
+	UserID      int             `json:"user_id"`
+	Kind        string          `json:"kind"`
+	Method      string          `json:"method"`
+	Fingerprint string          `json:"fingerprint"`
+	Score       float64         `json:"score"`
+	Reasons     json.RawMessage `json:"reasons"`
+	Params      json.RawMessage `json:"params"`
+	RemoteIP    string          `json:"remote_ip"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	ReviewedAt  null.Time       `json:"reviewed_at"`
+}
+
+const reviewColumns = `id, user_id, kind, method, fingerprint, score, reasons, params, remote_ip, status, created_at, reviewed_at`
+
+func scanReview(s interface{ Scan(...interface{}) error }) (*Review, error) {
+	r := &Review{}
+	var reasons, params []byte
+	err := s.Scan(&r.ID, &r.UserID, &r.Kind, &r.Method, &r.Fingerprint, &r.Score, &reasons, &params,
+		&r.RemoteIP, &r.Status, &r.CreatedAt, &r.ReviewedAt)
+	r.Reasons, r.Params = reasons, params
+	return r, err
+}
+
+// createReview records op as held for review.
+func createReview(op Operation, fingerprint string, s *Score) (int64, error) {
+	reasons := s.Reasons
+	if reasons == nil {
+		reasons = []string{}
+	}
+	rawReasons, err := json.Marshal(reasons)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	params, err := json.Marshal(op.Params)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	var id int64
+	err = boil.GetDB().QueryRow(
+		`INSERT INTO fraud_reviews (user_id, kind, method, fingerprint, score, reasons, params, remote_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		op.UserID, op.Kind, op.Method, fingerprint, s.Score, rawReasons, params, op.RemoteIP,
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	return id, nil
+}
+
+// findReview returns the latest review of the user's operation which still decides it, nil if there's none.
+func findReview(userID int, fingerprint string) (*Review, error) {
+	r, err := scanReview(boil.GetDB().QueryRow(
+		`SELECT `+reviewColumns+` FROM fraud_reviews WHERE user_id = $1 AND fingerprint = $2 AND status <> $3
+		ORDER BY id DESC LIMIT 1`, userID, fingerprint, StatusUsed,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return r, nil
+}
+
+// consumeReview marks an approved review used, so the approval lets the operation through only once.
+func consumeReview(id int64) error {
+	res, err := boil.GetDB().Exec(
+		`UPDATE fraud_reviews SET status = $1 WHERE id = $2 AND status = $3`, StatusUsed, id, StatusApproved,
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Err(err)
+	} else if n == 0 {
+		return ErrAlreadyReviewed
+	}
+	return nil
+}
+
+// GetReview returns the review with id.
+func GetReview(id int64) (*Review, error) {
+	r, err := scanReview(boil.GetDB().QueryRow(`SELECT `+reviewColumns+` FROM fraud_reviews WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReviewNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return r, nil
+}
+
+// ListReviews returns reviews, optionally filtered by status, newest first.
+func ListReviews(status string, limit, offset int) ([]*Review, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+reviewColumns+` FROM fraud_reviews WHERE ($1 = '' OR status = $1) ORDER BY id DESC LIMIT $2 OFFSET $3`,
+		status, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	reviews := []*Review{}
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		reviews = append(reviews, r)
+	}
+	return reviews, errors.Err(rows.Err())
+}
+
+// Decide approves or rejects the pending review with id. The decision is recorded in the audit log
+// as made by an admin at remoteIP.
+func Decide(id int64, status string, remoteIP string) (*Review, error) {
+	if status != StatusApproved && status != StatusRejected {
+		return nil, ErrInvalidStatus
+	}
+	r, err := scanReview(boil.GetDB().QueryRow(
+		`UPDATE fraud_reviews SET status = $1, reviewed_at = now() WHERE id = $2 AND status = $3 RETURNING `+reviewColumns,
+		status, id, StatusPending,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := GetReview(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrAlreadyReviewed
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	if body, err := json.Marshal(map[string]interface{}{"review_id": id, "status": status, "user_id": r.UserID}); err == nil {
+		audit.LogQuery(0, remoteIP, auditAction+"_review", body)
+	}
+	logger.WithFields(logrus.Fields{"id": id, "user_id": r.UserID, "method": r.Method}).Infof("fraud review %v", status)
+	return r, nil
+}
@@ -0,0 +1,227 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// Multipart fields of publish calls sent to the proxy, see publish.Handler.
+const (
+	jsonRPCField = "json_payload"
+	fileField    = "file"
+)
+
+// resolve serves Proxy.Resolve with a single resolve call.
+func (s *Server) resolve(st *stream) error {
+	msg, err := st.recvRequest()
+	if err != nil {
+		return err
+	}
+	var req ResolveRequest
+	if err := req.unmarshal(msg); err != nil {
+		return errorf(codeInvalidArgument, "malformed request: %v", err)
+	}
+	if len(req.URLs) == 0 {
+		return errorf(codeInvalidArgument, "urls are missing")
+	}
+	params := fromStruct(req.Extra)
+	params[query.ParamUrls] = req.URLs
+
+	result, err := s.call(st, query.MethodResolve, params)
+	if err != nil {
+		return err
+	}
+	res := ResolveResponse{Claims: map[string]*Claim{}}
+	items, _ := result.(map[string]interface{})
+	for url, item := range items {
+		claim, _ := item.(map[string]interface{})
+		res.Claims[url] = claimFrom(claim)
+	}
+	b, err := res.marshal()
+	if err != nil {
+		return err
+	}
+	return st.send(b)
+}
+
+// claimSearch serves Proxy.ClaimSearch, making a claim_search call per page and streaming claims as they come.
+func (s *Server) claimSearch(st *stream) error {
+	msg, err := st.recvRequest()
+	if err != nil {
+		return err
+	}
+	var req ClaimSearchRequest
+	if err := req.unmarshal(msg); err != nil {
+		return errorf(codeInvalidArgument, "malformed request: %v", err)
+	}
+	pages := int(req.Pages)
+	if pages == 0 {
+		pages = 1
+	}
+	if max := config.GetGRPC().MaxClaimSearchPages; max > 0 && pages > max {
+		return errorf(codeInvalidArgument, "at most %v pages can be requested at once", max)
+	}
+	page := int(req.Page)
+	if page == 0 {
+		page = 1
+	}
+
+	params := fromStruct(req.Extra)
+	setString(params, "text", req.Text)
+	setStrings(params, "channel_ids", req.ChannelIDs)
+	setStrings(params, "claim_type", req.ClaimType)
+	setStrings(params, "any_tags", req.AnyTags)
+	setStrings(params, "order_by", req.OrderBy)
+	if req.PageSize > 0 {
+		params["page_size"] = req.PageSize
+	}
+	for last := page + pages; page < last; page++ {
+		params["page"] = page
+		result, err := s.call(st, query.MethodClaimSearch, params)
+		if err != nil {
+			return err
+		}
+		res, _ := result.(map[string]interface{})
+		items, _ := res["items"].([]interface{})
+		for _, item := range items {
+			claim, _ := item.(map[string]interface{})
+			b, err := claimFrom(claim).marshal()
+			if err != nil {
+				return err
+			}
+			if err := st.send(b); err != nil {
+				return err
+			}
+		}
+		if total, ok := res["total_pages"].(float64); len(items) == 0 || ok && float64(page) >= total {
+			return nil
+		}
+	}
+	return nil
+}
+
+// publish serves Proxy.Publish, streaming the file to the proxy as a multipart upload while it's received.
+func (s *Server) publish(st *stream) error {
+	msg, err := st.recvRequest()
+	if err != nil {
+		return err
+	}
+	var first PublishChunk
+	if err := first.unmarshal(msg); err != nil {
+		return errorf(codeInvalidArgument, "malformed request: %v", err)
+	}
+	req := first.Request
+	if req == nil {
+		return errorf(codeInvalidArgument, "the first message must carry the request")
+	}
+	if req.FileName == "" {
+		return errorf(codeInvalidArgument, "file_name is missing")
+	}
+	params := fromStruct(req.Extra)
+	setString(params, "name", req.Name)
+	setString(params, "bid", req.Bid)
+	setString(params, "title", req.Title)
+	setString(params, "description", req.Description)
+	setString(params, query.ParamChannelID, req.ChannelID)
+	setStrings(params, "tags", req.Tags)
+	payload, err := json.Marshal(jsonrpc.NewRequest("publish", params))
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	written := make(chan error, 1)
+	go func() {
+		err := writeUpload(st, mw, payload, req.FileName)
+		pw.CloseWithError(err)
+		written <- err
+	}()
+	result, err := s.send(st, mw.FormDataContentType(), pr)
+	// Unblocks the writer if the proxy turned the upload down before reading all of it
+	pr.Close()
+	if werr := <-written; werr != nil && werr != io.ErrClosedPipe {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	res, _ := result.(map[string]interface{})
+	b, err := (&PublishResponse{Result: toStruct(res)}).marshal()
+	if err != nil {
+		return err
+	}
+	return st.send(b)
+}
+
+// writeUpload writes the publish call and the file received from the client as a multipart form.
+func writeUpload(st *stream, mw *multipart.Writer, payload []byte, fileName string) error {
+	if err := mw.WriteField(jsonRPCField, string(payload)); err != nil {
+		return err
+	}
+	fw, err := mw.CreateFormFile(fileField, fileName)
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := st.recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		var chunk PublishChunk
+		if err := chunk.unmarshal(msg); err != nil {
+			return errorf(codeInvalidArgument, "malformed chunk: %v", err)
+		}
+		if chunk.Request != nil {
+			return errorf(codeInvalidArgument, "only the first message can carry the request")
+		}
+		if _, err := fw.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// claimFrom picks the fields of Claim out of a claim returned by the SDK, keeping all of it in Raw.
+func claimFrom(c map[string]interface{}) *Claim {
+	str := func(k string) string {
+		s, _ := c[k].(string)
+		return s
+	}
+	claim := &Claim{
+		ClaimID:      str("claim_id"),
+		Name:         str("name"),
+		CanonicalURL: str("canonical_url"),
+		PermanentURL: str("permanent_url"),
+		ValueType:    str("value_type"),
+		Raw:          toStruct(c),
+	}
+	// Resolve reports URLs which couldn't be resolved in place of their claim
+	switch e := c["error"].(type) {
+	case string:
+		claim.Error = e
+	case map[string]interface{}:
+		claim.Error, _ = e["text"].(string)
+	}
+	return claim
+}
+
+func setString(params map[string]interface{}, key, v string) {
+	if v != "" {
+		params[key] = v
+	}
+}
+
+func setStrings(params map[string]interface{}, key string, v []string) {
+	if len(v) > 0 {
+		params[key] = v
+	}
+}
@@ -0,0 +1,294 @@
+// Package grpcapi serves the Proxy service of api/proto/lbrytv.proto to native clients over gRPC.
+//
+// Each call is translated into JSON-RPC calls sent through the API router to /api/v1/proxy, which makes them
+// with query.Caller, so they go through the same auth, hooks, limits and metrics as calls made over HTTP.
+// gRPC is spoken over the HTTP/2 support of net/http, see Server.ServeHTTP.
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	// ServicePath is the path prefix of Proxy service calls.
+	ServicePath = "/lbrytv.v1.Proxy/"
+	// RPCCodeTrailer carries the JSON-RPC code of errors returned by the proxy, alongside the gRPC status.
+	RPCCodeTrailer = "Lbrytv-Rpc-Code"
+
+	contentType = "application/grpc"
+	proxyPath   = "/api/v1/proxy"
+
+	// maxMessageSize is the largest message accepted from clients, same as the default of gRPC implementations.
+	maxMessageSize = 4 << 20
+)
+
+// gRPC status codes.
+const (
+	codeOK                = 0
+	codeCanceled          = 1
+	codeInvalidArgument   = 3
+	codeDeadlineExceeded  = 4
+	codePermissionDenied  = 7
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+	codeUnavailable       = 14
+	codeUnauthenticated   = 16
+)
+
+// rpcStatusCodes translate JSON-RPC error codes of the proxy, see rpcerrors, into gRPC status codes.
+// Other errors are INTERNAL.
+var rpcStatusCodes = map[int]int{
+	-32084: codeUnauthenticated,
+	-32085: codePermissionDenied,
+	-32601: codePermissionDenied,
+	-32602: codeInvalidArgument,
+	-32700: codeInvalidArgument,
+	-32086: codeResourceExhausted,
+	-32087: codeDeadlineExceeded,
+	-32088: codeUnavailable,
+	-32089: codeResourceExhausted,
+	-32092: codeResourceExhausted,
+	-32093: codeUnavailable,
+}
+
+var logger = monitor.NewModuleLogger("grpcapi")
+
+// status ends a call which didn't succeed. Errors returned by the proxy keep their JSON-RPC code in rpcCode.
+type status struct {
+	code    int
+	message string
+	rpcCode int
+}
+
+func (s *status) Error() string {
+	return s.message
+}
+
+func errorf(code int, format string, args ...interface{}) *status {
+	return &status{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Server serves gRPC calls of the Proxy service, sending the JSON-RPC calls they translate into to api.
+type Server struct {
+	api http.Handler
+}
+
+// NewServer creates a server passing calls on to the API router api.
+func NewServer(api http.Handler) *Server {
+	return &Server{api: api}
+}
+
+// ServeHTTP implements the gRPC protocol over HTTP/2. Messages are sent uncompressed, a deadline set by
+// the client in grpc-timeout cancels the call. Auth goes in the x-lbry-auth-token metadata key
+// which arrives as the X-Lbry-Auth-Token header.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !isGRPC(r.Header.Get("Content-Type")) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	ctx := r.Context()
+	if d, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+	st := &stream{w: w, r: r.WithContext(ctx)}
+	w.Header().Set("Content-Type", contentType)
+
+	var err error
+	switch strings.TrimPrefix(r.URL.Path, ServicePath) {
+	case "Resolve":
+		err = s.resolve(st)
+	case "ClaimSearch":
+		err = s.claimSearch(st)
+	case "Publish":
+		err = s.publish(st)
+	default:
+		err = errorf(codeUnimplemented, "unknown method %v", r.URL.Path)
+	}
+	if err != nil {
+		logger.Log().Debugf("grpc call %v failed: %v", r.URL.Path, err)
+	}
+	st.finish(err)
+}
+
+func isGRPC(ct string) bool {
+	return ct == contentType || strings.HasPrefix(ct, contentType+"+proto") || strings.HasPrefix(ct, contentType+";")
+}
+
+// parseTimeout parses grpc-timeout values, like 100m for 100 milliseconds.
+func parseTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second, 'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[v[len(v)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v[:len(v)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// stream is a gRPC call being served.
+type stream struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	started bool
+}
+
+// recv reads the next message sent by the client. It returns io.EOF once the client is done sending.
+func (st *stream) recv() ([]byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(st.r.Body, head[:]); err == io.EOF {
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, errorf(codeInternal, "error reading message: %v", err)
+	}
+	if head[0] != 0 {
+		return nil, errorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(head[1:])
+	if n > maxMessageSize {
+		return nil, errorf(codeResourceExhausted, "message is larger than %v bytes", maxMessageSize)
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(st.r.Body, msg); err != nil {
+		return nil, errorf(codeInternal, "error reading message: %v", err)
+	}
+	return msg, nil
+}
+
+// recvRequest reads the request message of calls which take a single one.
+func (st *stream) recvRequest() ([]byte, error) {
+	msg, err := st.recv()
+	if err == io.EOF {
+		return nil, errorf(codeInvalidArgument, "request message is missing")
+	}
+	return msg, err
+}
+
+func (st *stream) send(msg []byte) error {
+	b := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(b[1:], uint32(len(msg)))
+	b = append(b, msg...)
+	st.started = true
+	if _, err := st.w.Write(b); err != nil {
+		return errorf(codeCanceled, "error sending message: %v", err)
+	}
+	if f, ok := st.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// finish ends the call with the status err translates into, sent in trailers.
+func (st *stream) finish(err error) {
+	s := &status{code: codeOK}
+	if err != nil && !errors.As(err, &s) {
+		s = errorf(codeInternal, "%v", err)
+	}
+	if s.code != codeOK {
+		switch st.r.Context().Err() {
+		case context.DeadlineExceeded:
+			s = errorf(codeDeadlineExceeded, "deadline exceeded")
+		case context.Canceled:
+			s = errorf(codeCanceled, "call canceled")
+		}
+	}
+	h := st.w.Header()
+	h.Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(s.code))
+	if s.message != "" {
+		h.Set(http.TrailerPrefix+"Grpc-Message", encodeMessage(s.message))
+	}
+	if s.rpcCode != 0 {
+		h.Set(http.TrailerPrefix+RPCCodeTrailer, strconv.Itoa(s.rpcCode))
+	}
+	if !st.started {
+		st.w.WriteHeader(http.StatusOK)
+	}
+}
+
+// encodeMessage percent-encodes the status message as grpc-message requires.
+func encodeMessage(m string) string {
+	var b strings.Builder
+	for i := 0; i < len(m); i++ {
+		c := m[i]
+		if c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// bufferedResponse collects the response of the proxy to a call made for a gRPC client.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(status int)      { b.status = status }
+
+// call makes a JSON-RPC call to the proxy on behalf of the client and returns its result.
+func (s *Server) call(st *stream, method string, params map[string]interface{}) (interface{}, error) {
+	body, err := json.Marshal(jsonrpc.NewRequest(method, params))
+	if err != nil {
+		return nil, err
+	}
+	return s.send(st, "application/json", bytes.NewReader(body))
+}
+
+// send posts body to the proxy with the headers the client sent, so it's authenticated like the client.
+func (s *Server) send(st *stream, contentType string, body io.Reader) (interface{}, error) {
+	r := st.r.Clone(st.r.Context())
+	r.URL = &url.URL{Path: proxyPath}
+	r.RequestURI = proxyPath
+	r.Body = ioutil.NopCloser(body)
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", contentType)
+	r.Header.Del("Grpc-Timeout")
+	r.Header.Del("Te")
+
+	res := &bufferedResponse{header: http.Header{}}
+	s.api.ServeHTTP(res, r)
+
+	var rpcRes jsonrpc.RPCResponse
+	if err := json.Unmarshal(res.body.Bytes(), &rpcRes); err != nil {
+		return nil, errorf(codeInternal, "unexpected response from the proxy (status %v)", res.status)
+	}
+	if rpcRes.Error != nil {
+		code, ok := rpcStatusCodes[rpcRes.Error.Code]
+		if !ok {
+			code = codeInternal
+		}
+		return nil, &status{code: code, message: rpcRes.Error.Message, rpcCode: rpcRes.Error.Code}
+	}
+	return rpcRes.Result, nil
+}
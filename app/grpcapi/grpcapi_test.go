@@ -0,0 +1,221 @@
+package grpcapi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// fakeProxy answers JSON-RPC calls sent to /api/v1/proxy with respond, recording them.
+type fakeProxy struct {
+	t       *testing.T
+	calls   []*jsonrpc.RPCRequest
+	respond func(req *jsonrpc.RPCRequest, r *http.Request) *jsonrpc.RPCResponse
+}
+
+func (p *fakeProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(p.t, proxyPath, r.URL.Path)
+	assert.Equal(p.t, http.MethodPost, r.Method)
+	assert.Equal(p.t, "abc", r.Header.Get("X-Lbry-Auth-Token"))
+	assert.Empty(p.t, r.Header.Get("Grpc-Timeout"))
+
+	req := &jsonrpc.RPCRequest{}
+	if r.Header.Get("Content-Type") == "application/json" {
+		require.NoError(p.t, json.NewDecoder(r.Body).Decode(req))
+	} else {
+		require.NoError(p.t, json.Unmarshal([]byte(r.FormValue(jsonRPCField)), req))
+	}
+	p.calls = append(p.calls, req)
+	json.NewEncoder(w).Encode(p.respond(req, r))
+}
+
+func frame(msgs ...[]byte) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	for _, m := range msgs {
+		head := make([]byte, 5)
+		binary.BigEndian.PutUint32(head[1:], uint32(len(m)))
+		b.Write(head)
+		b.Write(m)
+	}
+	return b
+}
+
+func unframe(t *testing.T, b []byte) [][]byte {
+	var msgs [][]byte
+	for len(b) > 0 {
+		require.True(t, len(b) >= 5)
+		n := binary.BigEndian.Uint32(b[1:5])
+		msgs = append(msgs, b[5:5+n])
+		b = b[5+n:]
+	}
+	return msgs
+}
+
+func call(t *testing.T, api http.Handler, method string, msgs ...[]byte) ([][]byte, http.Header) {
+	r := httptest.NewRequest(http.MethodPost, ServicePath+method, frame(msgs...))
+	r.Header.Set("Content-Type", "application/grpc")
+	r.Header.Set("X-Lbry-Auth-Token", "abc")
+	r.Header.Set("Grpc-Timeout", "5S")
+	rec := httptest.NewRecorder()
+	NewServer(api).ServeHTTP(rec, r)
+
+	res := rec.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/grpc", res.Header.Get("Content-Type"))
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	return unframe(t, body), res.Trailer
+}
+
+func TestServer_Resolve(t *testing.T) {
+	api := &fakeProxy{t: t, respond: func(req *jsonrpc.RPCRequest, _ *http.Request) *jsonrpc.RPCResponse {
+		return &jsonrpc.RPCResponse{JSONRPC: "2.0", Result: map[string]interface{}{
+			"lbry://one": map[string]interface{}{"claim_id": "abc", "name": "one", "value_type": "stream"},
+			"lbry://two": map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND", "text": "not found"}},
+		}}
+	}}
+	req := appendString(nil, 1, "lbry://one")
+	req = appendString(req, 1, "lbry://two")
+	req, err := appendStruct(req, 15, toStruct(map[string]interface{}{"include_purchase_receipt": true}))
+	require.NoError(t, err)
+
+	msgs, trailer := call(t, api, "Resolve", req)
+	assert.Equal(t, "0", trailer.Get("Grpc-Status"))
+	require.Len(t, api.calls, 1)
+	assert.Equal(t, "resolve", api.calls[0].Method)
+	assert.Equal(t, map[string]interface{}{
+		"urls":                     []interface{}{"lbry://one", "lbry://two"},
+		"include_purchase_receipt": true,
+	}, api.calls[0].Params)
+
+	require.Len(t, msgs, 1)
+	entries := fields(t, msgs[0])[1]
+	require.Len(t, entries, 2)
+	one := fields(t, fields(t, entries[0])[2][0])
+	assert.Equal(t, "abc", string(one[1][0]))
+	assert.Equal(t, "stream", string(one[5][0]))
+	two := fields(t, fields(t, entries[1])[2][0])
+	assert.Equal(t, "not found", string(two[14][0]))
+}
+
+func TestServer_ClaimSearch(t *testing.T) {
+	api := &fakeProxy{t: t, respond: func(req *jsonrpc.RPCRequest, _ *http.Request) *jsonrpc.RPCResponse {
+		page := req.Params.(map[string]interface{})["page"].(float64)
+		items := []interface{}{}
+		if page < 3 {
+			items = append(items, map[string]interface{}{"claim_id": "a"}, map[string]interface{}{"claim_id": "b"})
+		}
+		return &jsonrpc.RPCResponse{JSONRPC: "2.0", Result: map[string]interface{}{"items": items, "total_pages": 2}}
+	}}
+	req := appendString(nil, 4, "cats")
+	req = protowire.AppendTag(req, 8, protowire.VarintType)
+	req = protowire.AppendVarint(req, 5)
+
+	// Pages past the last one aren't requested
+	msgs, trailer := call(t, api, "ClaimSearch", req)
+	assert.Equal(t, "0", trailer.Get("Grpc-Status"))
+	assert.Len(t, msgs, 4)
+	require.Len(t, api.calls, 2)
+	assert.Equal(t, "claim_search", api.calls[1].Method)
+	assert.Equal(t, map[string]interface{}{"any_tags": []interface{}{"cats"}, "page": 2.0}, api.calls[1].Params)
+
+	req = protowire.AppendTag(nil, 8, protowire.VarintType)
+	req = protowire.AppendVarint(req, 11)
+	msgs, trailer = call(t, api, "ClaimSearch", req)
+	assert.Empty(t, msgs)
+	assert.Equal(t, "3", trailer.Get("Grpc-Status"))
+}
+
+func TestServer_Publish(t *testing.T) {
+	api := &fakeProxy{t: t, respond: func(req *jsonrpc.RPCRequest, r *http.Request) *jsonrpc.RPCResponse {
+		f, h, err := r.FormFile(fileField)
+		require.NoError(t, err)
+		data, err := ioutil.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "cat.mp4", h.Filename)
+		assert.Equal(t, "first second", string(data))
+		return &jsonrpc.RPCResponse{JSONRPC: "2.0", Result: map[string]interface{}{"txid": "123"}}
+	}}
+	header := appendString(nil, 1, "cat-video")
+	header = appendString(header, 2, "0.01")
+	header = appendString(header, 7, "cat.mp4")
+
+	msgs, trailer := call(t, api, "Publish",
+		appendMessage(nil, 1, header),
+		appendMessage(nil, 2, []byte("first ")),
+		appendMessage(nil, 2, []byte("second")),
+	)
+	assert.Equal(t, "0", trailer.Get("Grpc-Status"))
+	require.Len(t, api.calls, 1)
+	assert.Equal(t, "publish", api.calls[0].Method)
+	assert.Equal(t, map[string]interface{}{"name": "cat-video", "bid": "0.01"}, api.calls[0].Params)
+	require.Len(t, msgs, 1)
+	assert.Contains(t, string(fields(t, msgs[0])[1][0]), "123")
+
+	// The request has to come first
+	api.calls = nil
+	msgs, trailer = call(t, api, "Publish", appendMessage(nil, 2, []byte("data")))
+	assert.Empty(t, msgs)
+	assert.Equal(t, "3", trailer.Get("Grpc-Status"))
+	assert.Empty(t, api.calls)
+}
+
+func TestServer_Errors(t *testing.T) {
+	api := &fakeProxy{t: t, respond: func(req *jsonrpc.RPCRequest, _ *http.Request) *jsonrpc.RPCResponse {
+		return &jsonrpc.RPCResponse{JSONRPC: "2.0", Error: &jsonrpc.RPCError{Code: -32084, Message: "authentication required"}}
+	}}
+	msgs, trailer := call(t, api, "Resolve", appendString(nil, 1, "lbry://one"))
+	assert.Empty(t, msgs)
+	assert.Equal(t, "16", trailer.Get("Grpc-Status"))
+	assert.Equal(t, "authentication required", trailer.Get("Grpc-Message"))
+	assert.Equal(t, "-32084", trailer.Get(RPCCodeTrailer))
+
+	_, trailer = call(t, api, "Unknown")
+	assert.Equal(t, "12", trailer.Get("Grpc-Status"))
+
+	_, trailer = call(t, api, "Resolve")
+	assert.Equal(t, "3", trailer.Get("Grpc-Status"))
+
+	compressed := frame(appendString(nil, 1, "lbry://one")).Bytes()
+	compressed[0] = 1
+	r := httptest.NewRequest(http.MethodPost, ServicePath+"Resolve", bytes.NewReader(compressed))
+	r.Header.Set("Content-Type", "application/grpc+proto")
+	rec := httptest.NewRecorder()
+	NewServer(api).ServeHTTP(rec, r)
+	assert.Equal(t, "12", rec.Result().Trailer.Get("Grpc-Status"))
+
+	r = httptest.NewRequest(http.MethodPost, ServicePath+"Resolve", nil)
+	r.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	NewServer(api).ServeHTTP(rec, r)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
+
+func TestStream_Finish(t *testing.T) {
+	rec := httptest.NewRecorder()
+	st := &stream{w: rec, r: httptest.NewRequest(http.MethodPost, ServicePath+"Resolve", nil)}
+	st.finish(errors.Err("disk full: %v", "100%"))
+	assert.Equal(t, "13", rec.Result().Trailer.Get("Grpc-Status"))
+	assert.Equal(t, "disk full: 100%25", rec.Result().Trailer.Get("Grpc-Message"))
+}
+
+func TestParseTimeout(t *testing.T) {
+	d, ok := parseTimeout("250m")
+	assert.True(t, ok)
+	assert.Equal(t, "250ms", d.String())
+	_, ok = parseTimeout("10x")
+	assert.False(t, ok)
+	_, ok = parseTimeout("S")
+	assert.False(t, ok)
+}
@@ -0,0 +1,347 @@
+package grpcapi
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Messages of api/proto/lbrytv.proto, encoded by hand as the module doesn't generate code from it.
+// Requests are only decoded and responses only encoded, fields unknown to the server are skipped.
+
+// ResolveRequest is the request of Proxy.Resolve.
+type ResolveRequest struct {
+	URLs  []string
+	Extra *structpb.Struct
+}
+
+func (m *ResolveRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeRepeatedString(typ, b, &m.URLs)
+		case 15:
+			return consumeStruct(typ, b, &m.Extra)
+		}
+		return 0
+	})
+}
+
+// ResolveResponse is the response of Proxy.Resolve.
+type ResolveResponse struct {
+	Claims map[string]*Claim
+}
+
+func (m *ResolveResponse) marshal() ([]byte, error) {
+	urls := make([]string, 0, len(m.Claims))
+	for u := range m.Claims {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	var b []byte
+	for _, u := range urls {
+		claim, err := m.Claims[u].marshal()
+		if err != nil {
+			return nil, err
+		}
+		// Map entries are messages with the key in field 1 and the value in field 2
+		entry := appendString(nil, 1, u)
+		entry = appendMessage(entry, 2, claim)
+		b = appendMessage(b, 1, entry)
+	}
+	return b, nil
+}
+
+// Claim is a claim as sent by Proxy.Resolve and Proxy.ClaimSearch.
+type Claim struct {
+	ClaimID      string
+	Name         string
+	CanonicalURL string
+	PermanentURL string
+	ValueType    string
+	Error        string
+	Raw          *structpb.Struct
+}
+
+func (m *Claim) marshal() ([]byte, error) {
+	b := appendString(nil, 1, m.ClaimID)
+	b = appendString(b, 2, m.Name)
+	b = appendString(b, 3, m.CanonicalURL)
+	b = appendString(b, 4, m.PermanentURL)
+	b = appendString(b, 5, m.ValueType)
+	b = appendString(b, 14, m.Error)
+	return appendStruct(b, 15, m.Raw)
+}
+
+// ClaimSearchRequest is the request of Proxy.ClaimSearch.
+type ClaimSearchRequest struct {
+	Text       string
+	ChannelIDs []string
+	ClaimType  []string
+	AnyTags    []string
+	OrderBy    []string
+	Page       uint32
+	PageSize   uint32
+	Pages      uint32
+	Extra      *structpb.Struct
+}
+
+func (m *ClaimSearchRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &m.Text)
+		case 2:
+			return consumeRepeatedString(typ, b, &m.ChannelIDs)
+		case 3:
+			return consumeRepeatedString(typ, b, &m.ClaimType)
+		case 4:
+			return consumeRepeatedString(typ, b, &m.AnyTags)
+		case 5:
+			return consumeRepeatedString(typ, b, &m.OrderBy)
+		case 6:
+			return consumeUint32(typ, b, &m.Page)
+		case 7:
+			return consumeUint32(typ, b, &m.PageSize)
+		case 8:
+			return consumeUint32(typ, b, &m.Pages)
+		case 15:
+			return consumeStruct(typ, b, &m.Extra)
+		}
+		return 0
+	})
+}
+
+// PublishRequest is the header of the file sent to Proxy.Publish.
+type PublishRequest struct {
+	Name        string
+	Bid         string
+	Title       string
+	Description string
+	ChannelID   string
+	Tags        []string
+	FileName    string
+	Extra       *structpb.Struct
+}
+
+func (m *PublishRequest) unmarshal(b []byte) error {
+	return decode(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		switch num {
+		case 1:
+			return consumeString(typ, b, &m.Name)
+		case 2:
+			return consumeString(typ, b, &m.Bid)
+		case 3:
+			return consumeString(typ, b, &m.Title)
+		case 4:
+			return consumeString(typ, b, &m.Description)
+		case 5:
+			return consumeString(typ, b, &m.ChannelID)
+		case 6:
+			return consumeRepeatedString(typ, b, &m.Tags)
+		case 7:
+			return consumeString(typ, b, &m.FileName)
+		case 15:
+			return consumeStruct(typ, b, &m.Extra)
+		}
+		return 0
+	})
+}
+
+// PublishChunk is a message of the Proxy.Publish stream, carrying either the request or a piece of the file.
+type PublishChunk struct {
+	Request *PublishRequest
+	Data    []byte
+}
+
+func (m *PublishChunk) unmarshal(b []byte) error {
+	var err error
+	derr := decode(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if typ != protowire.BytesType {
+			return 0
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return n
+		}
+		switch num {
+		case 1:
+			m.Request, m.Data = &PublishRequest{}, nil
+			err = m.Request.unmarshal(v)
+		case 2:
+			m.Request, m.Data = nil, v
+		}
+		return n
+	})
+	if derr != nil {
+		return derr
+	}
+	return err
+}
+
+// PublishResponse is the response of Proxy.Publish.
+type PublishResponse struct {
+	Result *structpb.Struct
+}
+
+func (m *PublishResponse) marshal() ([]byte, error) {
+	return appendStruct(nil, 1, m.Result)
+}
+
+// decode calls field with the value of each field in the encoded message b. It returns the length of the value
+// it has consumed, or zero to have the field skipped.
+func decode(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) int) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+		n = field(num, typ, b)
+		if n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return errors.Prefix(fmt.Sprintf("field %v", num), protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
+
+func consumeString(typ protowire.Type, b []byte, dst *string) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeString(b)
+	if n >= 0 {
+		*dst = v
+	}
+	return n
+}
+
+func consumeRepeatedString(typ protowire.Type, b []byte, dst *[]string) int {
+	var v string
+	n := consumeString(typ, b, &v)
+	if n > 0 {
+		*dst = append(*dst, v)
+	}
+	return n
+}
+
+func consumeUint32(typ protowire.Type, b []byte, dst *uint32) int {
+	if typ != protowire.VarintType {
+		return 0
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n >= 0 {
+		*dst = uint32(v)
+	}
+	return n
+}
+
+func consumeStruct(typ protowire.Type, b []byte, dst **structpb.Struct) int {
+	if typ != protowire.BytesType {
+		return 0
+	}
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n
+	}
+	s := &structpb.Struct{}
+	if err := proto.Unmarshal(v, s); err != nil {
+		// Reported like any other malformed value
+		return -1
+	}
+	*dst = s
+	return n
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendMessage(b []byte, num protowire.Number, v []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendStruct(b []byte, num protowire.Number, s *structpb.Struct) ([]byte, error) {
+	if s == nil {
+		return b, nil
+	}
+	v, err := proto.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	return appendMessage(b, num, v), nil
+}
+
+// toStruct converts a JSON object decoded by encoding/json into a Struct.
+func toStruct(m map[string]interface{}) *structpb.Struct {
+	s := &structpb.Struct{Fields: make(map[string]*structpb.Value, len(m))}
+	for k, v := range m {
+		s.Fields[k] = toValue(v)
+	}
+	return s
+}
+
+func toValue(v interface{}) *structpb.Value {
+	switch v := v.(type) {
+	case nil:
+		return &structpb.Value{Kind: &structpb.Value_NullValue{}}
+	case bool:
+		return &structpb.Value{Kind: &structpb.Value_BoolValue{BoolValue: v}}
+	case float64:
+		return &structpb.Value{Kind: &structpb.Value_NumberValue{NumberValue: v}}
+	case string:
+		return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}
+	case []interface{}:
+		l := &structpb.ListValue{Values: make([]*structpb.Value, len(v))}
+		for i, item := range v {
+			l.Values[i] = toValue(item)
+		}
+		return &structpb.Value{Kind: &structpb.Value_ListValue{ListValue: l}}
+	case map[string]interface{}:
+		return &structpb.Value{Kind: &structpb.Value_StructValue{StructValue: toStruct(v)}}
+	}
+	return &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: fmt.Sprintf("%v", v)}}
+}
+
+// fromStruct converts s into a map which encodes to the same JSON object.
+func fromStruct(s *structpb.Struct) map[string]interface{} {
+	m := make(map[string]interface{}, len(s.GetFields()))
+	for k, v := range s.GetFields() {
+		m[k] = fromValue(v)
+	}
+	return m
+}
+
+func fromValue(v *structpb.Value) interface{} {
+	switch k := v.GetKind().(type) {
+	case *structpb.Value_BoolValue:
+		return k.BoolValue
+	case *structpb.Value_NumberValue:
+		return k.NumberValue
+	case *structpb.Value_StringValue:
+		return k.StringValue
+	case *structpb.Value_ListValue:
+		l := make([]interface{}, len(k.ListValue.GetValues()))
+		for i, item := range k.ListValue.GetValues() {
+			l[i] = fromValue(item)
+		}
+		return l
+	case *structpb.Value_StructValue:
+		return fromStruct(k.StructValue)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package grpcapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fields splits an encoded message into values of its length-delimited fields.
+func fields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	f := map[protowire.Number][][]byte{}
+	require.NoError(t, decode(b, func(num protowire.Number, typ protowire.Type, b []byte) int {
+		if typ != protowire.BytesType {
+			return 0
+		}
+		v, n := protowire.ConsumeBytes(b)
+		if n > 0 {
+			f[num] = append(f[num], v)
+		}
+		return n
+	}))
+	return f
+}
+
+func TestClaimSearchRequest_Unmarshal(t *testing.T) {
+	extra := toStruct(map[string]interface{}{"has_source": true, "fee_amount": ">0"})
+	b := appendString(nil, 1, "cats")
+	b = appendString(b, 2, "abc")
+	b = appendString(b, 2, "def")
+	b = appendString(b, 5, "release_time")
+	b = protowire.AppendTag(b, 7, protowire.VarintType)
+	b = protowire.AppendVarint(b, 20)
+	b = protowire.AppendTag(b, 8, protowire.VarintType)
+	b = protowire.AppendVarint(b, 3)
+	// Unknown fields are skipped
+	b = protowire.AppendTag(b, 11, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, 42)
+	b, err := appendStruct(b, 15, extra)
+	require.NoError(t, err)
+
+	var req ClaimSearchRequest
+	require.NoError(t, req.unmarshal(b))
+	assert.Equal(t, "cats", req.Text)
+	assert.Equal(t, []string{"abc", "def"}, req.ChannelIDs)
+	assert.Equal(t, []string{"release_time"}, req.OrderBy)
+	assert.EqualValues(t, 0, req.Page)
+	assert.EqualValues(t, 20, req.PageSize)
+	assert.EqualValues(t, 3, req.Pages)
+	assert.Equal(t, map[string]interface{}{"has_source": true, "fee_amount": ">0"}, fromStruct(req.Extra))
+
+	assert.Error(t, req.unmarshal(b[:len(b)-1]))
+}
+
+func TestPublishChunk_Unmarshal(t *testing.T) {
+	header := appendString(nil, 1, "cat-video")
+	header = appendString(header, 6, "cats")
+	header = appendString(header, 7, "cat.mp4")
+
+	var chunk PublishChunk
+	require.NoError(t, chunk.unmarshal(appendMessage(nil, 1, header)))
+	require.NotNil(t, chunk.Request)
+	assert.Equal(t, "cat-video", chunk.Request.Name)
+	assert.Equal(t, []string{"cats"}, chunk.Request.Tags)
+	assert.Equal(t, "cat.mp4", chunk.Request.FileName)
+
+	chunk = PublishChunk{}
+	require.NoError(t, chunk.unmarshal(appendMessage(nil, 2, []byte("data"))))
+	assert.Nil(t, chunk.Request)
+	assert.Equal(t, []byte("data"), chunk.Data)
+}
+
+func TestResolveResponse_Marshal(t *testing.T) {
+	res := ResolveResponse{Claims: map[string]*Claim{
+		"lbry://one": {ClaimID: "abc", Name: "one", Raw: toStruct(map[string]interface{}{"amount": "1.0"})},
+		"lbry://two": {Error: "not found"},
+	}}
+	b, err := res.marshal()
+	require.NoError(t, err)
+
+	entries := fields(t, b)[1]
+	require.Len(t, entries, 2)
+	one := fields(t, entries[0])
+	assert.Equal(t, "lbry://one", string(one[1][0]))
+	claim := fields(t, one[2][0])
+	assert.Equal(t, "abc", string(claim[1][0]))
+	assert.Equal(t, "one", string(claim[2][0]))
+	raw := &structpb.Struct{}
+	require.NoError(t, proto.Unmarshal(claim[15][0], raw))
+	assert.Equal(t, map[string]interface{}{"amount": "1.0"}, fromStruct(raw))
+
+	two := fields(t, entries[1])
+	assert.Equal(t, "not found", string(fields(t, two[2][0])[14][0]))
+}
+
+func TestStructConversion(t *testing.T) {
+	m := map[string]interface{}{
+		"null":   nil,
+		"bool":   true,
+		"number": 1.5,
+		"list":   []interface{}{"a", 2.0, map[string]interface{}{"b": false}},
+		"object": map[string]interface{}{"c": "d"},
+	}
+	assert.Equal(t, m, fromStruct(toStruct(m)))
+	assert.Equal(t, map[string]interface{}{}, fromStruct(nil))
+}
@@ -0,0 +1,224 @@
+package legalhold
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/torrent"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+)
+
+const (
+	manifestFile = "manifest.json"
+	// basisDir is where publish.Handler keeps files of previous publishes, see publish.Handler.keepBasis.
+	basisDir = "basis"
+)
+
+// manifestEntry lets recipients verify the bundle wasn't altered.
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type manifest struct {
+	Hold        *Hold           `json:"hold"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Files       []manifestEntry `json:"files"`
+}
+
+// bundle writes files into a ZIP archive, keeping track of their checksums.
+type bundle struct {
+	zw      *zip.Writer
+	entries []manifestEntry
+}
+
+type countingWriter struct {
+	w    io.Writer
+	h    hash.Hash
+	size int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.h.Write(p[:n])
+	c.size += int64(n)
+	return n, err
+}
+
+func (b *bundle) add(name string, write func(w io.Writer) error) error {
+	fw, err := b.zw.Create(name)
+	if err != nil {
+		return errors.Err(err)
+	}
+	cw := &countingWriter{w: fw, h: sha256.New()}
+	if err := write(cw); err != nil {
+		return err
+	}
+	b.entries = append(b.entries, manifestEntry{Name: name, Size: cw.size, SHA256: hex.EncodeToString(cw.h.Sum(nil))})
+	return nil
+}
+
+func (b *bundle) addJSON(name string, v interface{}) error {
+	return b.add(name, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return errors.Err(enc.Encode(v))
+	})
+}
+
+func (b *bundle) addFile(name, filePath string) error {
+	return b.add(name, func(w io.Writer) error {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return errors.Err(err)
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return errors.Err(err)
+	})
+}
+
+// Export writes an evidence bundle of records under the hold to w as a ZIP archive: the user, their audit log
+// (with wallet transactions also split out), quarantined files, moderation cases, torrents and files kept
+// from publishes. Soft-deleted records are included. The manifest lists SHA-256 checksums of all files.
+func Export(w io.Writer, h *Hold, uploadPath string) error {
+	b := &bundle{zw: zip.NewWriter(w)}
+	log := logger.WithFields(logrus.Fields{"hold_id": h.ID})
+
+	if err := b.addJSON("hold.json", h); err != nil {
+		return err
+	}
+
+	if h.UserID.Valid {
+		user, err := models.FindUserG(h.UserID.Int)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return errors.Err(err)
+		}
+		if err := b.addJSON("user.json", user); err != nil {
+			return err
+		}
+	}
+
+	logs, err := models.QueryLogs(h.related(models.QueryLogColumns.UserID, models.QueryLogColumns.Body), qm.OrderBy("id")).AllG()
+	if err != nil {
+		return errors.Err(err)
+	}
+	transactions := models.QueryLogSlice{}
+	for _, l := range logs {
+		if l.Method == query.MethodWalletSend {
+			transactions = append(transactions, l)
+		}
+	}
+	if err := b.addJSON("query_log.json", logs); err != nil {
+		return err
+	}
+	if err := b.addJSON("transactions.json", transactions); err != nil {
+		return err
+	}
+
+	if h.UserID.Valid {
+		files, err := models.QuarantinedFiles(models.QuarantinedFileWhere.UserID.EQ(h.UserID.Int), qm.OrderBy("id")).AllG()
+		if err != nil {
+			return errors.Err(err)
+		}
+		if err := b.addJSON("quarantined_files.json", files); err != nil {
+			return err
+		}
+		for _, qf := range files {
+			name := fmt.Sprintf("quarantined/%d_%s", qf.ID, path.Base(qf.FileName))
+			if err := b.addFile(name, qf.Path); err != nil {
+				log.Warnf("quarantined file %v not included: %v", qf.ID, err)
+			}
+		}
+	}
+
+	cases, err := moderation.Find(h.ClaimID.String, h.UserID.Int)
+	if err != nil {
+		return err
+	}
+	if err := b.addJSON("moderation_cases.json", cases); err != nil {
+		return err
+	}
+	torrents, err := torrent.Find(h.ClaimID.String, h.UserID.Int)
+	if err != nil {
+		return err
+	}
+	if err := b.addJSON("torrents.json", torrents); err != nil {
+		return err
+	}
+
+	for _, p := range h.uploads(uploadPath) {
+		rel, _ := filepath.Rel(uploadPath, p)
+		if err := b.addFile(path.Join("uploads", filepath.ToSlash(rel)), p); err != nil {
+			log.Warnf("upload %v not included: %v", rel, err)
+		}
+	}
+
+	if err := b.addJSON(manifestFile, manifest{Hold: h, GeneratedAt: time.Now().UTC(), Files: b.entries}); err != nil {
+		return err
+	}
+	if err := b.zw.Close(); err != nil {
+		return errors.Err(err)
+	}
+	log.Infof("evidence bundle exported with %v files", len(b.entries))
+	return nil
+}
+
+// related returns a query mod matching records of the held user through userColumn or mentioning
+// the held claim in textColumn.
+func (h *Hold) related(userColumn, textColumn string) qm.QueryMod {
+	userID, claimID := -1, ""
+	if h.UserID.Valid {
+		userID = h.UserID.Int
+	}
+	if h.ClaimID.Valid {
+		claimID = h.ClaimID.String
+	}
+	return qm.Where(
+		fmt.Sprintf(`"%s" = ? OR (? <> '' AND "%s"::text LIKE '%%' || ? || '%%')`, userColumn, textColumn),
+		userID, claimID, claimID,
+	)
+}
+
+// uploads returns files kept from publishes by the held user or of the held claim.
+func (h *Hold) uploads(uploadPath string) []string {
+	if uploadPath == "" {
+		return nil
+	}
+	var patterns []string
+	if h.UserID.Valid {
+		patterns = append(patterns, path.Join(uploadPath, fmt.Sprintf("%d", h.UserID.Int), basisDir, "*"))
+	}
+	if h.ClaimID.Valid && h.ClaimID.String != "" && h.ClaimID.String == path.Base(h.ClaimID.String) {
+		patterns = append(patterns, path.Join(uploadPath, "*", basisDir, h.ClaimID.String))
+	}
+	seen := map[string]bool{}
+	files := []string{}
+	for _, p := range patterns {
+		matches, _ := filepath.Glob(p)
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	return files
+}
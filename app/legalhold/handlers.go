@@ -0,0 +1,145 @@
+package legalhold
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type releaseRequest struct {
+	ReleasedBy string `json:"released_by"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNoSubject), errors.Is(err, ErrInvalidCountry), errors.Is(err, ErrMissingFields):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyReleased):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func idFromRequest(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		return 0, errors.Err("invalid id")
+	}
+	return id, nil
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+// HandlePlace puts a user and/or claim under legal hold.
+func HandlePlace(w http.ResponseWriter, r *http.Request) {
+	var h Hold
+	if err := json.NewDecoder(r.Body).Decode(&h); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	placed, err := Place(&h)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusCreated, placed)
+}
+
+// HandleList returns legal holds, filtered by `country` and `active` (true by default), paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	active := r.URL.Query().Get("active") != "false"
+	holds, err := List(r.URL.Query().Get("country"), active, limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, holds)
+}
+
+// HandleRelease lifts a legal hold.
+func HandleRelease(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req releaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	if req.ReleasedBy == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("released_by is required"))
+		return
+	}
+	h, err := Release(id, req.ReleasedBy)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, h)
+}
+
+// HandleExport sends the evidence bundle of a legal hold as a ZIP attachment. Exports are logged
+// as they contain personal data.
+func HandleExport(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	h, err := Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	logger.WithFields(logrus.Fields{"hold_id": id, "ip": ip.AddressForRequest(r)}).Info("evidence bundle requested")
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("legal-hold-%d.zip", id)))
+	// Headers are already sent by the time the archive fails, so the error only ends up in the log
+	if err := Export(w, h, config.GetPublishSourceDir()); err != nil {
+		logger.WithFields(logrus.Fields{"hold_id": id}).Errorf("evidence bundle export failed: %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Package legalhold preserves records of users and claims subject to lawful requests.
+//
+// While a hold is active, retention jobs leave records related to the held user or claim alone
+// (see Exclusion), and an evidence bundle of them can be exported (see Export).
+// Holds are tied to the country whose authority requested them, and are released rather than deleted,
+// so it remains known what was held, when and why.
+package legalhold
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+var (
+	logger = monitor.NewModuleLogger("legalhold")
+
+	ErrNotFound        = errors.Base("legal hold not found")
+	ErrAlreadyReleased = errors.Base("legal hold has already been released")
+	ErrNoSubject       = errors.Base("user_id or claim_id is required")
+	ErrInvalidCountry  = errors.Base("country must be an ISO 3166-1 alpha-2 code")
+	ErrMissingFields   = errors.Base("reference and placed_by are required")
+
+	countryPattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Hold keeps records of a user, a claim or both from being deleted.
+type Hold struct {
+	ID      int         `json:"id"`
+	UserID  null.Int    `json:"user_id"`
+	ClaimID null.String `json:"claim_id"`
+	Country string      `json:"country"`
+	// Reference identifies the lawful request, such as a court order number.
+	Reference  string      `json:"reference"`
+	PlacedBy   string      `json:"placed_by"`
+	Note       string      `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
+	ReleasedAt null.Time   `json:"released_at"`
+	ReleasedBy null.String `json:"released_by"`
+}
+
+const holdColumns = `id, user_id, claim_id, country, reference, placed_by, note, created_at, released_at, released_by`
+
+func scanHold(s interface{ Scan(...interface{}) error }) (*Hold, error) {
+	h := &Hold{}
+	err := s.Scan(&h.ID, &h.UserID, &h.ClaimID, &h.Country, &h.Reference, &h.PlacedBy, &h.Note,
+		&h.CreatedAt, &h.ReleasedAt, &h.ReleasedBy)
+	return h, err
+}
+
+// Place puts a hold on the user and/or claim of h.
+func Place(h *Hold) (*Hold, error) {
+	if !h.UserID.Valid && (!h.ClaimID.Valid || h.ClaimID.String == "") {
+		return nil, ErrNoSubject
+	}
+	h.Country = strings.ToUpper(h.Country)
+	if !countryPattern.MatchString(h.Country) {
+		return nil, ErrInvalidCountry
+	}
+	if h.Reference == "" || h.PlacedBy == "" {
+		return nil, ErrMissingFields
+	}
+	placed, err := scanHold(boil.GetDB().QueryRow(
+		`INSERT INTO legal_holds (user_id, claim_id, country, reference, placed_by, note)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING `+holdColumns,
+		h.UserID, h.ClaimID, h.Country, h.Reference, h.PlacedBy, h.Note,
+	))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	metrics.LegalHolds.WithLabelValues(metrics.LegalHoldPlaced).Inc()
+	logger.WithFields(logrus.Fields{
+		"hold_id": placed.ID, "user_id": h.UserID.Int, "claim_id": h.ClaimID.String, "country": h.Country,
+	}).Info("legal hold placed")
+	return placed, nil
+}
+
+// Get returns the hold with id.
+func Get(id int) (*Hold, error) {
+	h, err := scanHold(boil.GetDB().QueryRow(`SELECT `+holdColumns+` FROM legal_holds WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return h, errors.Err(err)
+}
+
+// List returns holds, optionally only active ones and ones of a country, newest first.
+func List(country string, activeOnly bool, limit, offset int) ([]*Hold, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+holdColumns+` FROM legal_holds
+		WHERE ($1 = '' OR country = $1) AND (NOT $2 OR released_at IS NULL)
+		ORDER BY created_at DESC LIMIT $3 OFFSET $4`,
+		strings.ToUpper(country), activeOnly, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	holds := []*Hold{}
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		holds = append(holds, h)
+	}
+	return holds, errors.Err(rows.Err())
+}
+
+// Release lifts the hold, records it protected become subject to retention again.
+func Release(id int, releasedBy string) (*Hold, error) {
+	if releasedBy == "" {
+		return nil, errors.Err("released_by is required")
+	}
+	h, err := scanHold(boil.GetDB().QueryRow(
+		`UPDATE legal_holds SET released_at = now(), released_by = $2 WHERE id = $1 AND released_at IS NULL
+		RETURNING `+holdColumns, id, releasedBy,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrAlreadyReleased
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	metrics.LegalHolds.WithLabelValues(metrics.LegalHoldReleased).Inc()
+	logger.WithFields(logrus.Fields{"hold_id": id, "released_by": releasedBy}).Info("legal hold released")
+	return h, nil
+}
+
+// Exclusion returns an SQL condition leaving out records of table which are under an active hold:
+// ones belonging to a held user through userColumn, or mentioning a held claim in textColumn.
+// Either column can be empty.
+func Exclusion(table, userColumn, textColumn string) string {
+	matches := []string{}
+	if userColumn != "" {
+		matches = append(matches, fmt.Sprintf(`h.user_id = "%s"."%s"`, table, userColumn))
+	}
+	if textColumn != "" {
+		matches = append(matches, fmt.Sprintf(`(h.claim_id IS NOT NULL AND "%s"."%s"::text LIKE '%%' || h.claim_id || '%%')`, table, textColumn))
+	}
+	if len(matches) == 0 {
+		return "TRUE"
+	}
+	return fmt.Sprintf(
+		`NOT EXISTS (SELECT 1 FROM legal_holds h WHERE h.released_at IS NULL AND (%s))`, strings.Join(matches, " OR "),
+	)
+}
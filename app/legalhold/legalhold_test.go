@@ -0,0 +1,121 @@
+package legalhold
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestPlaceAndRelease(t *testing.T) {
+	_, err := Place(&Hold{Country: "US", Reference: "subpoena", PlacedBy: "legal"})
+	assert.True(t, errors.Is(err, ErrNoSubject))
+	_, err = Place(&Hold{UserID: null.IntFrom(1), Country: "USA", Reference: "subpoena", PlacedBy: "legal"})
+	assert.True(t, errors.Is(err, ErrInvalidCountry))
+	_, err = Place(&Hold{UserID: null.IntFrom(1), Country: "US"})
+	assert.True(t, errors.Is(err, ErrMissingFields))
+
+	h, err := Place(&Hold{UserID: null.IntFrom(8120), Country: "us", Reference: "subpoena 42", PlacedBy: "legal"})
+	require.NoError(t, err)
+	assert.Equal(t, "US", h.Country)
+
+	holds, err := List("US", true, 10, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, holds)
+	assert.Equal(t, h.ID, holds[0].ID)
+
+	released, err := Release(h.ID, "legal")
+	require.NoError(t, err)
+	assert.True(t, released.ReleasedAt.Valid)
+	_, err = Release(h.ID, "legal")
+	assert.True(t, errors.Is(err, ErrAlreadyReleased))
+	_, err = Release(h.ID+1000, "legal")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	holds, err = List("US", true, 10, 0)
+	require.NoError(t, err)
+	for _, held := range holds {
+		assert.NotEqual(t, h.ID, held.ID)
+	}
+}
+
+func TestExport(t *testing.T) {
+	uploadPath, err := ioutil.TempDir("", "legalhold")
+	require.NoError(t, err)
+	defer os.RemoveAll(uploadPath)
+	claimID := "e1a8a3b5f0b0d2e1e4a1f87a7b3f4e6b9c5d1a2f"
+	require.NoError(t, os.MkdirAll(path.Join(uploadPath, "7300", basisDir), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(path.Join(uploadPath, "7300", basisDir, claimID), []byte("video"), 0644))
+
+	send := &models.QueryLog{Method: "wallet_send", UserID: null.IntFrom(7300), Timestamp: time.Now()}
+	other := &models.QueryLog{Method: "resolve", UserID: null.IntFrom(7301), Timestamp: time.Now()}
+	require.NoError(t, send.InsertG(boil.Infer()))
+	require.NoError(t, other.InsertG(boil.Infer()))
+
+	h, err := Place(&Hold{UserID: null.IntFrom(7300), Country: "GB", Reference: "order 7", PlacedBy: "legal"})
+	require.NoError(t, err)
+	defer Release(h.ID, "legal")
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(&buf, h, uploadPath))
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	contents := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		contents[f.Name], err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+	assert.Equal(t, []byte("video"), contents["uploads/7300/basis/"+claimID])
+
+	var logs []*models.QueryLog
+	require.NoError(t, json.Unmarshal(contents["query_log.json"], &logs))
+	require.Len(t, logs, 1)
+	assert.Equal(t, send.ID, logs[0].ID)
+	require.NoError(t, json.Unmarshal(contents["transactions.json"], &logs))
+	assert.Len(t, logs, 1)
+
+	var m manifest
+	require.NoError(t, json.Unmarshal(contents[manifestFile], &m))
+	assert.Equal(t, h.ID, m.Hold.ID)
+	assert.Len(t, m.Files, len(contents)-1)
+	for _, e := range m.Files {
+		sum := sha256.Sum256(contents[e.Name])
+		assert.Equal(t, hex.EncodeToString(sum[:]), e.SHA256, e.Name)
+	}
+}
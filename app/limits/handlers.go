@@ -0,0 +1,24 @@
+package limits
+
+import (
+	"net/http"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/internal/responses"
+)
+
+// HandleGet returns limits effective for the authenticated user and their quota usage.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	l, err := For(user)
+	if err != nil {
+		logger.Log().Errorf("cannot get limits of user %v: %v", user.ID, err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	responses.WriteJSON(w, http.StatusOK, l)
+}
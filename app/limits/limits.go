@@ -0,0 +1,106 @@
+// Package limits reports limits applying to a user along with how much of their quotas they have used,
+// so clients can adapt to them up front instead of finding out from rejected calls and uploads.
+package limits
+
+import (
+	"github.com/lbryio/lbrytv/app/organization"
+	"github.com/lbryio/lbrytv/app/policy"
+	"github.com/lbryio/lbrytv/app/publish"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+)
+
+var logger = monitor.NewModuleLogger("limits")
+
+// Limits are the limits effective for a user. Zero limits mean there are none.
+type Limits struct {
+	Uploads Uploads `json:"uploads"`
+	Calls   Calls   `json:"calls"`
+}
+
+// Uploads are limits of files uploaded for publishing.
+type Uploads struct {
+	MaxFileSize int64 `json:"max_file_size"`
+	// ResumableMaxSize is the largest file which can be uploaded in resumable uploads.
+	ResumableMaxSize int64 `json:"resumable_max_size"`
+	// DailyQuota is nil when uploads aren't limited per day.
+	DailyQuota *Quota `json:"daily_quota"`
+	// OrganizationQuota is the quota shared with members of the user's organization, nil outside of organizations.
+	OrganizationQuota *Quota `json:"organization_quota"`
+}
+
+// Quota is a number of bytes which can be uploaded and how many of them are used.
+type Quota struct {
+	Limit     int64 `json:"limit"`
+	Used      int64 `json:"used"`
+	Remaining int64 `json:"remaining"`
+	// ResetsAt is the Unix time the quota renews at, zero for quotas that don't renew.
+	ResetsAt int64 `json:"resets_at,omitempty"`
+}
+
+// Calls are limits of proxied calls.
+type Calls struct {
+	MaxBatchSize int `json:"max_batch_size"`
+	// ParamLimits are the highest values allowed for params, keyed by method and param name.
+	// List params are limited by length.
+	ParamLimits map[string]map[string]int `json:"param_limits"`
+	// AllowedMethods are methods the user can call, according to current method policies.
+	AllowedMethods []string `json:"allowed_methods"`
+}
+
+// For returns limits effective for user.
+func For(user *models.User) (*Limits, error) {
+	uploads := config.GetUploadLimits()
+	l := &Limits{
+		Uploads: Uploads{
+			MaxFileSize:      uploads.MaxFileSize,
+			ResumableMaxSize: config.GetResumableUploads().MaxSize,
+		},
+		Calls: Calls{
+			MaxBatchSize:   config.GetBatchRequests().MaxSize,
+			ParamLimits:    config.GetParamLimits(),
+			AllowedMethods: AllowedMethods(policy.TierOf(user)),
+		},
+	}
+
+	if uploads.DailyQuota > 0 {
+		used, reset, err := publish.DailyUsage(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		l.Uploads.DailyQuota = newQuota(uploads.DailyQuota, used)
+		l.Uploads.DailyQuota.ResetsAt = reset
+	}
+
+	org, _, err := organization.Get(user.ID)
+	if err == nil {
+		if org.UploadQuota > 0 {
+			l.Uploads.OrganizationQuota = newQuota(org.UploadQuota, org.UploadUsed)
+		}
+	} else if !errors.Is(err, organization.ErrNotMember) {
+		return nil, err
+	}
+	return l, nil
+}
+
+// AllowedMethods returns methods known to the proxy which aren't denied to users of tier.
+func AllowedMethods(tier string) []string {
+	methods := []string{}
+	for _, m := range query.KnownMethods() {
+		if p := policy.Decide(m, tier); p == nil || p.Action != policy.ActionDeny {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+func newQuota(limit, used int64) *Quota {
+	remaining := limit - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &Quota{Limit: limit, Used: used, Remaining: remaining}
+}
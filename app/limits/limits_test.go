@@ -0,0 +1,106 @@
+package limits
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/organization"
+	"github.com/lbryio/lbrytv/app/policy"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func get(user *models.User) *httptest.ResponseRecorder {
+	provider := func(token, ip string) (*models.User, error) { return user, nil }
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/limits", nil)
+	if user != nil {
+		r.Header.Set(wallet.TokenHeader, "token")
+	}
+	rr := httptest.NewRecorder()
+	auth.Middleware(provider)(http.HandlerFunc(HandleGet)).ServeHTTP(rr, r)
+	return rr
+}
+
+func TestHandleGet(t *testing.T) {
+	config.Override("UploadLimits", map[string]interface{}{"MaxFileSize": 100, "DailyQuota": 1000})
+	config.Override("BatchRequests", map[string]interface{}{"MaxSize": 20})
+	config.Override("ParamLimits", map[string]interface{}{"claim_search": map[string]interface{}{"page_size": 50}})
+	defer config.RestoreOverridden()
+
+	user := &models.User{}
+	require.NoError(t, user.InsertG(boil.Infer()))
+	_, err := boil.GetDB().Exec(`INSERT INTO upload_usage (user_id, day, bytes) VALUES ($1, current_date, 300)`, user.ID)
+	require.NoError(t, err)
+
+	rr := get(user)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var l Limits
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &l))
+	assert.EqualValues(t, 100, l.Uploads.MaxFileSize)
+	require.NotNil(t, l.Uploads.DailyQuota)
+	assert.EqualValues(t, 1000, l.Uploads.DailyQuota.Limit)
+	assert.EqualValues(t, 300, l.Uploads.DailyQuota.Used)
+	assert.EqualValues(t, 700, l.Uploads.DailyQuota.Remaining)
+	assert.Greater(t, l.Uploads.DailyQuota.ResetsAt, time.Now().Unix())
+	assert.Nil(t, l.Uploads.OrganizationQuota)
+	assert.Equal(t, 20, l.Calls.MaxBatchSize)
+	assert.Equal(t, 50, l.Calls.ParamLimits["claim_search"]["page_size"])
+	assert.Contains(t, l.Calls.AllowedMethods, "wallet_send")
+
+	org, err := organization.Create(user, fmt.Sprintf("org-%v", time.Now().UnixNano()))
+	require.NoError(t, err)
+	_, err = organization.SetQuota(org.ID, 5000)
+	require.NoError(t, err)
+	l2, err := For(user)
+	require.NoError(t, err)
+	require.NotNil(t, l2.Uploads.OrganizationQuota)
+	assert.EqualValues(t, 5000, l2.Uploads.OrganizationQuota.Remaining)
+}
+
+func TestHandleGet_Unauthenticated(t *testing.T) {
+	assert.Equal(t, http.StatusUnauthorized, get(nil).Code)
+}
+
+func TestAllowedMethods(t *testing.T) {
+	set, err := policy.NewSet([]policy.Policy{
+		{Methods: []string{"wallet_*"}, Action: policy.ActionDeny},
+		{Methods: []string{"resolve"}, Tiers: []string{policy.TierUser}, Action: policy.ActionDeny},
+	})
+	require.NoError(t, err)
+	policy.SetCurrent(set)
+	defer policy.SetCurrent(policy.Set{})
+
+	methods := AllowedMethods(policy.TierUser)
+	assert.NotContains(t, methods, "wallet_send")
+	assert.NotContains(t, methods, "resolve")
+	assert.Contains(t, methods, "claim_search")
+	assert.Contains(t, AllowedMethods(policy.TierAnonymous), "resolve")
+}
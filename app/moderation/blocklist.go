@@ -0,0 +1,120 @@
+package moderation
+
+import (
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "moderation"
+
+var (
+	blockedMu sync.RWMutex
+	blocked   = map[string]bool{}
+)
+
+func block(claimID string) {
+	blockedMu.Lock()
+	defer blockedMu.Unlock()
+	blocked[claimID] = true
+}
+
+// IsBlocked returns true for claims taken down.
+func IsBlocked(claimID string) bool {
+	blockedMu.RLock()
+	defer blockedMu.RUnlock()
+	return blocked[claimID]
+}
+
+// LoadBlocklist replaces the in-memory blocklist with the one in the database. It's run on startup and
+// periodically after, so takedowns made on other instances are picked up.
+func LoadBlocklist() error {
+	rows, err := boil.GetDB().Query(`SELECT claim_id FROM blocked_claims`)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer rows.Close()
+	loaded := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return errors.Err(err)
+		}
+		loaded[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Err(err)
+	}
+	blockedMu.Lock()
+	blocked = loaded
+	blockedMu.Unlock()
+	metrics.ModerationBlockedClaims.Set(float64(len(loaded)))
+	return nil
+}
+
+// blockedError is served in resolve results in place of blocked claims, the way the Hub reports them.
+func blockedError() map[string]interface{} {
+	return map[string]interface{}{"name": "BLOCKED", "text": "Claim has been taken down."}
+}
+
+// InstallHooks makes c withhold blocked claims from resolve and claim_search responses.
+func InstallHooks(c *query.Caller) {
+	c.AddPostflightHook(query.MethodResolve, withholdResolved, hookName)
+	c.AddPostflightHook(query.MethodClaimSearch, withholdSearched, hookName)
+}
+
+func withholdResolved(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for u, v := range result {
+		if claim, ok := v.(map[string]interface{}); ok && isBlockedClaim(claim) {
+			result[u] = map[string]interface{}{"error": blockedError()}
+			hctx.AddLogField("blocked", true)
+		}
+	}
+	return nil, nil
+}
+
+func withholdSearched(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	items, _ := result["items"].([]interface{})
+	kept := make([]interface{}, 0, len(items))
+	for _, i := range items {
+		if claim, ok := i.(map[string]interface{}); ok && isBlockedClaim(claim) {
+			continue
+		}
+		kept = append(kept, i)
+	}
+	if len(kept) < len(items) {
+		result["items"] = kept
+		hctx.AddLogField("blocked", len(items)-len(kept))
+	}
+	return nil, nil
+}
+
+// isBlockedClaim checks the claim and, for reposts, the reposted claim.
+func isBlockedClaim(claim map[string]interface{}) bool {
+	if id, ok := claim["claim_id"].(string); ok && IsBlocked(id) {
+		return true
+	}
+	if reposted, ok := claim["reposted_claim"].(map[string]interface{}); ok {
+		return isBlockedClaim(reposted)
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+package moderation
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/volatiletech/null"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type flagRequest struct {
+	ClaimID    string   `json:"claim_id"`
+	ClaimName  string   `json:"claim_name"`
+	UploaderID null.Int `json:"uploader_id"`
+	Reason     string   `json:"reason"`
+	FlaggedBy  string   `json:"flagged_by"`
+}
+
+type reviewRequest struct {
+	Reviewer string `json:"reviewer"`
+	Note     string `json:"note"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrMissingFields), errors.Is(err, ErrMissingReviewer):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyReviewed):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func idFromRequest(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		return 0, errors.Err("invalid id")
+	}
+	return id, nil
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+// HandleFlag opens a moderation case for a claim.
+func HandleFlag(w http.ResponseWriter, r *http.Request) {
+	var req flagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	c, err := Flag(&Case{
+		ClaimID: req.ClaimID, ClaimName: req.ClaimName, UploaderID: req.UploaderID, Reason: req.Reason, FlaggedBy: req.FlaggedBy,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusCreated, c)
+}
+
+// HandleList returns moderation cases, optionally filtered by `status`, paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	cases, err := List(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, cases)
+}
+
+// HandleGet returns a moderation case with its chain of actions.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	c, err := Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, c)
+}
+
+func handleReview(w http.ResponseWriter, r *http.Request, review func(id int, reviewer, note string) (*Case, error)) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	c, err := review(id, req.Reviewer, req.Note)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, c)
+}
+
+// HandleDismiss closes a moderation case without action.
+func HandleDismiss(w http.ResponseWriter, r *http.Request) {
+	handleReview(w, r, Dismiss)
+}
+
+// HandleTakeDown takes down the claim of a moderation case.
+func HandleTakeDown(w http.ResponseWriter, r *http.Request) {
+	handleReview(w, r, TakeDown)
+}
@@ -0,0 +1,349 @@
+// Package moderation handles flagged claims. Admins open a case for a claim, then either dismiss it
+// or take the claim down. A takedown adds the claim to the blocklist (see InstallHooks), purges the query cache
+// and the CDN, stops seeding it and queues a notice for the uploader in the outbox. Each step is recorded as an action of the case,
+// so the full chain can be produced for legal compliance.
+package moderation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/cdnpurge"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/torrent"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	// StatusFlagged is set on newly opened cases awaiting review.
+	StatusFlagged = "flagged"
+	// StatusDismissed means the claim was found not to be violating.
+	StatusDismissed = "dismissed"
+	// StatusTakenDown means the claim was blocked.
+	StatusTakenDown = "taken_down"
+
+	ActionFlagged          = "flagged"
+	ActionDismissed        = "dismissed"
+	ActionBlocked          = "blocked"
+	ActionCachePurged      = "cache_purged"
+	ActionCDNPurged        = "cdn_purged"
+	ActionSeedingStopped   = "seeding_stopped"
+	ActionUploaderNotified = "uploader_notified"
+	ActionNotifyFailed     = "uploader_notify_failed"
+
+	// systemActor is recorded for actions done automatically as part of a takedown.
+	systemActor = "lbrytv"
+)
+
+var (
+	logger = monitor.NewModuleLogger("moderation")
+
+	ErrNotFound        = errors.Base("moderation case not found")
+	ErrAlreadyReviewed = errors.Base("moderation case has already been reviewed")
+	ErrMissingFields   = errors.Base("claim_id, reason and flagged_by are required")
+	ErrMissingReviewer = errors.Base("reviewer is required")
+)
+
+// Case is a claim flagged for review.
+type Case struct {
+	ID        int    `json:"id"`
+	ClaimID   string `json:"claim_id"`
+	ClaimName string `json:"claim_name"`
+	// UploaderID is the user who published the claim through lbrytv, when known. They are notified of a takedown.
+	UploaderID null.Int    `json:"uploader_id"`
+	Reason     string      `json:"reason"`
+	FlaggedBy  string      `json:"flagged_by"`
+	Status     string      `json:"status"`
+	ReviewedBy null.String `json:"reviewed_by"`
+	Note       string      `json:"note"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+	Actions    []*Action   `json:"actions,omitempty"`
+}
+
+// Action is a step taken on a case.
+type Action struct {
+	Action    string          `json:"action"`
+	Actor     string          `json:"actor"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+const caseColumns = `id, claim_id, claim_name, uploader_id, reason, flagged_by, status, reviewed_by, note, created_at, updated_at`
+
+func scanCase(s interface{ Scan(...interface{}) error }) (*Case, error) {
+	c := &Case{}
+	err := s.Scan(&c.ID, &c.ClaimID, &c.ClaimName, &c.UploaderID, &c.Reason, &c.FlaggedBy, &c.Status,
+		&c.ReviewedBy, &c.Note, &c.CreatedAt, &c.UpdatedAt)
+	return c, err
+}
+
+// recordAction appends an action to the case chain. Details are stored as JSON.
+func recordAction(exec boil.Executor, caseID int, action, actor string, details map[string]interface{}) error {
+	var body interface{}
+	if details != nil {
+		b, err := json.Marshal(details)
+		if err != nil {
+			return errors.Err(err)
+		}
+		body = string(b)
+	}
+	_, err := exec.Exec(
+		`INSERT INTO moderation_actions (case_id, action, actor, details) VALUES ($1, $2, $3, $4)`,
+		caseID, action, actor, body,
+	)
+	return errors.Err(err)
+}
+
+// Flag opens a case for the claim.
+func Flag(c *Case) (*Case, error) {
+	if c.ClaimID == "" || c.Reason == "" || c.FlaggedBy == "" {
+		return nil, ErrMissingFields
+	}
+	tx, err := boil.Begin()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	flagged, err := scanCase(tx.QueryRow(
+		`INSERT INTO moderation_cases (claim_id, claim_name, uploader_id, reason, flagged_by)
+		VALUES ($1, $2, $3, $4, $5) RETURNING `+caseColumns,
+		c.ClaimID, c.ClaimName, c.UploaderID, c.Reason, c.FlaggedBy,
+	))
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	if err := recordAction(tx, flagged.ID, ActionFlagged, c.FlaggedBy, map[string]interface{}{"reason": c.Reason}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+	metrics.ModerationCases.WithLabelValues(StatusFlagged).Inc()
+	logger.WithFields(logrus.Fields{"case_id": flagged.ID, "claim_id": c.ClaimID}).Info("claim flagged")
+	return flagged, nil
+}
+
+// Get returns the case with its chain of actions.
+func Get(id int) (*Case, error) {
+	c, err := scanCase(boil.GetDB().QueryRow(`SELECT `+caseColumns+` FROM moderation_cases WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	rows, err := boil.GetDB().Query(
+		`SELECT action, actor, details, created_at FROM moderation_actions WHERE case_id = $1 ORDER BY id`, id,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	c.Actions = []*Action{}
+	for rows.Next() {
+		a := &Action{}
+		var details null.String
+		if err := rows.Scan(&a.Action, &a.Actor, &details, &a.CreatedAt); err != nil {
+			return nil, errors.Err(err)
+		}
+		if details.Valid {
+			a.Details = json.RawMessage(details.String)
+		}
+		c.Actions = append(c.Actions, a)
+	}
+	return c, errors.Err(rows.Err())
+}
+
+// Find returns cases of the claim or of claims uploaded by the user, with their chains of actions.
+func Find(claimID string, uploaderID int) ([]*Case, error) {
+	rows, err := boil.GetDB().Query(`SELECT id FROM moderation_cases WHERE claim_id = $1 OR uploader_id = $2 ORDER BY id`, claimID, uploaderID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, errors.Err(err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, errors.Err(err)
+	}
+	cases := []*Case{}
+	for _, id := range ids {
+		c, err := Get(id)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// List returns cases, optionally filtered by status, newest first.
+func List(status string, limit, offset int) ([]*Case, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+caseColumns+` FROM moderation_cases WHERE $1 = '' OR status = $1
+		ORDER BY created_at DESC LIMIT $2 OFFSET $3`, status, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	cases := []*Case{}
+	for rows.Next() {
+		c, err := scanCase(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		cases = append(cases, c)
+	}
+	return cases, errors.Err(rows.Err())
+}
+
+// review moves a flagged case to status, recording the action in the same transaction.
+// Further takedown steps are recorded by the caller with the returned transaction, which it has to commit.
+func review(id int, status, action, reviewer, note string) (*Case, boil.Transactor, error) {
+	if reviewer == "" {
+		return nil, nil, ErrMissingReviewer
+	}
+	tx, err := boil.Begin()
+	if err != nil {
+		return nil, nil, errors.Err(err)
+	}
+	c, err := scanCase(tx.QueryRow(
+		`UPDATE moderation_cases SET status = $2, reviewed_by = $3, note = $4, updated_at = now()
+		WHERE id = $1 AND status = 'flagged' RETURNING `+caseColumns,
+		id, status, reviewer, note,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		if _, err := Get(id); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrAlreadyReviewed
+	} else if err != nil {
+		tx.Rollback()
+		return nil, nil, errors.Err(err)
+	}
+	if err := recordAction(tx, id, action, reviewer, map[string]interface{}{"note": note}); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	return c, tx, nil
+}
+
+// Dismiss closes the case without action.
+func Dismiss(id int, reviewer, note string) (*Case, error) {
+	c, tx, err := review(id, StatusDismissed, ActionDismissed, reviewer, note)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+	metrics.ModerationCases.WithLabelValues(StatusDismissed).Inc()
+	logger.WithFields(logrus.Fields{"case_id": id, "reviewer": reviewer}).Info("moderation case dismissed")
+	return Get(c.ID)
+}
+
+// TakeDown blocks the claim of the case and propagates the takedown. The claim is blocked in the same
+// transaction as the review, following steps are best-effort and recorded whether they succeed or not.
+func TakeDown(id int, reviewer, note string) (*Case, error) {
+	c, tx, err := review(id, StatusTakenDown, ActionBlocked, reviewer, note)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(
+		`INSERT INTO blocked_claims (claim_id, case_id) VALUES ($1, $2) ON CONFLICT (claim_id) DO NOTHING`, c.ClaimID, id,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	// The notice is queued in the same transaction so the uploader gets it even if we crash right after the commit
+	var noticeID int64
+	url := config.GetModerationNotifyURL()
+	if c.UploaderID.Valid && url != "" {
+		noticeID, err = outbox.Enqueue(tx, outbox.KindWebhook, url, takedownNotice(c))
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+	block(c.ClaimID)
+	metrics.ModerationCases.WithLabelValues(StatusTakenDown).Inc()
+	log := logger.WithFields(logrus.Fields{"case_id": id, "claim_id": c.ClaimID, "reviewer": reviewer})
+	log.Info("claim taken down")
+
+	record := func(action string, details map[string]interface{}) {
+		if err := recordAction(boil.GetDB(), id, action, systemActor, details); err != nil {
+			log.Errorf("cannot record %v: %v", action, err)
+		}
+	}
+
+	record(ActionCachePurged, map[string]interface{}{"invalidated": invalidateCached(c)})
+
+	if cfg := config.GetCDNPurge(); cfg.Provider != "" {
+		claims := []cdnpurge.Claim{{ClaimID: c.ClaimID, Name: c.ClaimName}}
+		cdnpurge.Purge(0, "", "takedown of moderation case", claims)
+		record(ActionCDNPurged, map[string]interface{}{"urls": cdnpurge.URLs(cfg.URLs, claims)})
+	}
+
+	if t, err := torrent.Remove(c.ClaimID); err == nil {
+		record(ActionSeedingStopped, map[string]interface{}{"info_hash": t.InfoHash})
+	} else if !errors.Is(err, torrent.ErrNotFound) {
+		log.Errorf("cannot stop seeding: %v", err)
+	}
+
+	if c.UploaderID.Valid {
+		if noticeID == 0 {
+			log.Warn("cannot notify uploader: uploader notifications are not configured")
+			record(ActionNotifyFailed, map[string]interface{}{"error": "uploader notifications are not configured"})
+		} else {
+			record(ActionUploaderNotified, map[string]interface{}{"user_id": c.UploaderID.Int, "outbox_message_id": noticeID})
+		}
+	}
+	return Get(id)
+}
+
+// invalidateCached drops shared cache responses to calls referencing the claim, by its ID or, in resolve calls,
+// by its name. Search results the claim turns up in are left to expire.
+func invalidateCached(c *Case) int {
+	qc := cache.Shared()
+	n := qc.InvalidateMatching("", c.ClaimID)
+	if c.ClaimName != "" {
+		n += qc.InvalidateMatching(query.MethodResolve, c.ClaimName)
+	}
+	return n
+}
+
+// takedownNotice is the payload of the takedown notice posted to ModerationNotifyURL, which delivers it to the uploader.
+func takedownNotice(c *Case) map[string]interface{} {
+	return map[string]interface{}{
+		"event":      "takedown",
+		"case_id":    c.ID,
+		"claim_id":   c.ClaimID,
+		"claim_name": c.ClaimName,
+		"user_id":    c.UploaderID.Int,
+		"reason":     c.Reason,
+	}
+}
@@ -0,0 +1,149 @@
+package moderation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func actions(c *Case) []string {
+	names := []string{}
+	for _, a := range c.Actions {
+		names = append(names, a.Action)
+	}
+	return names
+}
+
+func TestTakeDown(t *testing.T) {
+	notices := make(chan map[string]interface{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&n)
+		notices <- n
+	}))
+	defer ts.Close()
+	config.Override("ModerationNotifyURL", ts.URL)
+	defer config.RestoreOverridden()
+
+	claimID := "d9f0ee9dc0fc4a6ed5ed7bf3c2b4f1afa8b5c3c1"
+	c, err := Flag(&Case{ClaimID: claimID, ClaimName: "stolen", UploaderID: null.IntFrom(4311), Reason: "DMCA #123", FlaggedBy: "legal"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusFlagged, c.Status)
+	assert.False(t, IsBlocked(claimID))
+
+	_, err = TakeDown(c.ID, "", "")
+	assert.True(t, errors.Is(err, ErrMissingReviewer))
+
+	c, err = TakeDown(c.ID, "moderator", "confirmed infringement")
+	require.NoError(t, err)
+	assert.Equal(t, StatusTakenDown, c.Status)
+	assert.Equal(t, "moderator", c.ReviewedBy.String)
+	assert.Equal(t, []string{ActionFlagged, ActionBlocked, ActionCachePurged, ActionUploaderNotified}, actions(c))
+	assert.True(t, IsBlocked(claimID))
+
+	_, err = outbox.NewDispatcher(time.Second).DeliverDue()
+	require.NoError(t, err)
+	n := <-notices
+	assert.Equal(t, "takedown", n["event"])
+	assert.Equal(t, claimID, n["claim_id"])
+	assert.EqualValues(t, 4311, n["user_id"])
+
+	_, err = Dismiss(c.ID, "moderator", "")
+	assert.True(t, errors.Is(err, ErrAlreadyReviewed))
+	_, err = Dismiss(c.ID+1000, "moderator", "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	// The blocklist survives restarts
+	blocked = map[string]bool{}
+	require.NoError(t, LoadBlocklist())
+	assert.True(t, IsBlocked(claimID))
+}
+
+func TestInvalidateCached(t *testing.T) {
+	qc := cache.NewMemoryCache()
+	defer cache.SetShared(cache.Shared())
+	cache.SetShared(qc)
+
+	claimID := "d9f0ee9dc0fc4a6ed5ed7bf3c2b4f1afa8b5c3c1"
+	qc.Save(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"lbry://stolen"}}, json.RawMessage(`{}`))
+	qc.Save(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"lbry://other"}}, json.RawMessage(`{}`))
+	qc.Save(query.MethodClaimSearch, map[string]interface{}{"claim_ids": []interface{}{claimID}}, json.RawMessage(`{}`))
+	qc.Save(query.MethodClaimSearch, map[string]interface{}{"text": "stolen"}, json.RawMessage(`{}`))
+
+	assert.Equal(t, 2, invalidateCached(&Case{ClaimID: claimID, ClaimName: "stolen"}))
+	assert.Equal(t, 2, qc.Count())
+}
+
+func TestDismiss(t *testing.T) {
+	c, err := Flag(&Case{ClaimID: "aa0ee9dc0fc4a6ed5ed7bf3c2b4f1afa8b5c3c10", Reason: "spam", FlaggedBy: "user report"})
+	require.NoError(t, err)
+	c, err = Dismiss(c.ID, "moderator", "not spam")
+	require.NoError(t, err)
+	assert.Equal(t, StatusDismissed, c.Status)
+	assert.Equal(t, []string{ActionFlagged, ActionDismissed}, actions(c))
+	assert.False(t, IsBlocked(c.ClaimID))
+
+	cases, err := List(StatusDismissed, 10, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, cases)
+	assert.Equal(t, c.ID, cases[0].ID)
+
+	_, err = Flag(&Case{ClaimID: "aa0ee9dc0fc4a6ed5ed7bf3c2b4f1afa8b5c3c10"})
+	assert.True(t, errors.Is(err, ErrMissingFields))
+}
+
+func TestWithholdBlocked(t *testing.T) {
+	block("blockedclaim")
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []string{"lbry://a", "lbry://b"}}), "")
+	require.NoError(t, err)
+	res := &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"lbry://a": map[string]interface{}{"claim_id": "blockedclaim"},
+		"lbry://b": map[string]interface{}{"claim_id": "other", "reposted_claim": map[string]interface{}{"claim_id": "fine"}},
+	}}
+	_, err = withholdResolved(nil, &query.HookContext{Query: q, Response: res})
+	require.NoError(t, err)
+	result := res.Result.(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"error": blockedError()}, result["lbry://a"])
+	assert.Equal(t, "other", result["lbry://b"].(map[string]interface{})["claim_id"])
+
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{}), "")
+	require.NoError(t, err)
+	res = &jsonrpc.RPCResponse{Result: map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"claim_id": "other"},
+		map[string]interface{}{"claim_id": "repost", "reposted_claim": map[string]interface{}{"claim_id": "blockedclaim"}},
+	}}}
+	_, err = withholdSearched(nil, &query.HookContext{Query: q, Response: res})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{map[string]interface{}{"claim_id": "other"}}, res.Result.(map[string]interface{})["items"])
+}
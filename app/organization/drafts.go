@@ -0,0 +1,99 @@
+package organization
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+)
+
+// Drafts returns publish drafts of the user's organization, newest first. Drafts are visible to all members.
+func Drafts(user *models.User) (models.OrganizationDraftSlice, error) {
+	m, err := Membership(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotMember
+	}
+	ds, err := models.OrganizationDrafts(
+		models.OrganizationDraftWhere.OrganizationID.EQ(m.OrganizationID),
+		qm.OrderBy(models.OrganizationDraftColumns.UpdatedAt+" DESC"),
+	).AllG()
+	return ds, errors.Err(err)
+}
+
+// SaveDraft creates a draft when id is 0 and updates the existing one otherwise.
+// Any member of the organization can edit its drafts.
+func SaveDraft(user *models.User, id int, title string, params json.RawMessage) (*models.OrganizationDraft, error) {
+	if title == "" {
+		return nil, ErrEmptyName
+	}
+	m, err := Membership(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotMember
+	}
+
+	if id == 0 {
+		d := &models.OrganizationDraft{
+			OrganizationID: m.OrganizationID,
+			UserID:         user.ID,
+			Title:          title,
+			Params:         null.JSONFrom(params),
+		}
+		if err := d.InsertG(boil.Infer()); err != nil {
+			return nil, errors.Err(err)
+		}
+		return d, nil
+	}
+
+	d, err := findDraft(m.OrganizationID, id)
+	if err != nil {
+		return nil, err
+	}
+	d.Title = title
+	d.Params = null.JSONFrom(params)
+	if _, err := d.UpdateG(boil.Infer()); err != nil {
+		return nil, errors.Err(err)
+	}
+	return d, nil
+}
+
+// DeleteDraft removes a draft. Only its author and organization admins can do that.
+func DeleteDraft(user *models.User, id int) error {
+	m, err := Membership(user.ID)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return ErrNotMember
+	}
+	d, err := findDraft(m.OrganizationID, id)
+	if err != nil {
+		return err
+	}
+	if d.UserID != user.ID && m.Role != RoleAdmin {
+		return ErrNotAdmin
+	}
+	_, err = d.DeleteG()
+	return errors.Err(err)
+}
+
+func findDraft(orgID, id int) (*models.OrganizationDraft, error) {
+	d, err := models.OrganizationDrafts(
+		models.OrganizationDraftWhere.ID.EQ(id),
+		models.OrganizationDraftWhere.OrganizationID.EQ(orgID),
+	).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return d, errors.Err(err)
+}
@@ -0,0 +1,375 @@
+package organization
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+	"github.com/volatiletech/null"
+)
+
+type orgResponse struct {
+	*models.Organization
+	Members models.OrganizationMemberSlice `json:"members"`
+}
+
+type createRequest struct {
+	Name string `json:"name"`
+}
+
+type memberRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type draftRequest struct {
+	ID     int             `json:"id"`
+	Title  string          `json:"title"`
+	Params json.RawMessage `json:"params"`
+}
+
+type keyRequest struct {
+	Name   string `json:"name"`
+	UserID int    `json:"user_id"`
+}
+
+type keyResponse struct {
+	*models.OrganizationAPIKey
+	Key string `json:"key"`
+}
+
+type keySecurityRequest struct {
+	WebhookURL string    `json:"webhook_url"`
+	RotateAt   null.Time `json:"rotate_at"`
+	ExpiresAt  null.Time `json:"expires_at"`
+}
+
+type rotateKeyRequest struct {
+	// Overlap is a duration like "48h", CredentialLifecycle.Overlap is used when it's empty.
+	Overlap string `json:"overlap"`
+}
+
+type quotaRequest struct {
+	UploadQuota int64 `json:"upload_quota"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrNotMember):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrNotAdmin):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrAlreadyMember), errors.Is(err, ErrAlreadyRotated):
+		status = http.StatusConflict
+	case errors.Is(err, ErrInvalidRole), errors.Is(err, ErrUnknownUser),
+		errors.Is(err, ErrEmptyName), errors.Is(err, ErrLastAdminLeave), errors.Is(err, ErrInvalidWebhookURL):
+		status = http.StatusBadRequest
+	default:
+		logger.Log().Errorf("organization request failed: %v", err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return false
+	}
+	return true
+}
+
+func idFromRequest(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid id"))
+		return 0, false
+	}
+	return id, true
+}
+
+func writeOrganization(w http.ResponseWriter, org *models.Organization) {
+	members, err := Members(org.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, orgResponse{Organization: org, Members: members})
+}
+
+// HandleGet returns the organization of the authenticated user with its members and quota usage.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	org, _, err := Get(user.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeOrganization(w, org)
+}
+
+// HandleCreate creates an organization administered by the authenticated user.
+func HandleCreate(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req createRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	org, err := Create(user, req.Name)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeOrganization(w, org)
+}
+
+// HandleAddMember adds a user to the organization of the authenticated admin.
+func HandleAddMember(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req memberRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.Role == "" {
+		req.Role = RoleEditor
+	}
+	m, err := AddMember(user, req.UserID, req.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, m)
+}
+
+// HandleRemoveMember removes a user from the organization.
+func HandleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req memberRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if err := RemoveMember(user, req.UserID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListDrafts returns drafts shared within the organization.
+func HandleListDrafts(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	ds, err := Drafts(user)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if ds == nil {
+		ds = models.OrganizationDraftSlice{}
+	}
+	responses.WriteJSON(w, http.StatusOK, ds)
+}
+
+// HandleSaveDraft creates or updates a draft.
+func HandleSaveDraft(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req draftRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	d, err := SaveDraft(user, req.ID, req.Title, req.Params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, d)
+}
+
+// HandleDeleteDraft removes the draft specified in the URL.
+func HandleDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := DeleteDraft(user, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListKeys returns API keys of the organization, without the keys themselves.
+func HandleListKeys(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	ks, err := APIKeys(user)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if ks == nil {
+		ks = models.OrganizationAPIKeySlice{}
+	}
+	responses.WriteJSON(w, http.StatusOK, ks)
+}
+
+// HandleCreateKey issues a new API key. The key is only ever returned in this response.
+func HandleCreateKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req keyRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	key, k, err := CreateAPIKey(user, req.Name, req.UserID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, keyResponse{OrganizationAPIKey: k, Key: key})
+}
+
+// HandleRevokeKey deletes the API key specified in the URL.
+func HandleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := RevokeAPIKey(user, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleRotateKey issues a replacement of the API key specified in the URL, which keeps working
+// for the requested overlap. The new key is only ever returned in this response.
+func HandleRotateKey(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var req rotateKeyRequest
+	if r.ContentLength != 0 && !decode(w, r, &req) {
+		return
+	}
+	overlap := config.GetCredentialLifecycle().Overlap
+	if req.Overlap != "" {
+		var err error
+		overlap, err = time.ParseDuration(req.Overlap)
+		if err != nil || overlap < 0 {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid overlap"))
+			return
+		}
+	}
+	key, k, err := RotateAPIKey(user, id, overlap)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, keyResponse{OrganizationAPIKey: k, Key: key})
+}
+
+// HandleGetKeySecurity returns security settings of the API key specified in the URL.
+func HandleGetKeySecurity(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	s, err := GetKeySecurity(user, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, s)
+}
+
+// HandleSetKeySecurity sets the webhook security events are posted to and the rotation time
+// of the API key specified in the URL.
+func HandleSetKeySecurity(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var req keySecurityRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s, err := SetKeySecurity(user, id, req.WebhookURL, req.RotateAt, req.ExpiresAt)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, s)
+}
+
+// HandleSetQuota changes upload quota of the organization specified in the URL, it's meant for the admin API.
+func HandleSetQuota(w http.ResponseWriter, r *http.Request) {
+	id, ok := idFromRequest(w, r)
+	if !ok {
+		return
+	}
+	var req quotaRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.UploadQuota < 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("upload_quota cannot be negative"))
+		return
+	}
+	org, err := SetQuota(id, req.UploadQuota)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, org)
+}
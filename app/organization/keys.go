@@ -0,0 +1,224 @@
+package organization
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const keyPrefix = "lbrytv_org_"
+
+var (
+	ErrInvalidKey     = errors.Base("invalid API key")
+	ErrAlreadyRotated = errors.Base("API key has already been rotated")
+)
+
+// CreateAPIKey issues an API key which acts as userID (admin themselves when 0) within the admin's organization.
+// Only a hash is stored so the returned plain key can't be retrieved later.
+func CreateAPIKey(admin *models.User, name string, userID int) (string, *models.OrganizationAPIKey, error) {
+	if name == "" {
+		return "", nil, ErrEmptyName
+	}
+	am, err := adminMembership(admin.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	if userID == 0 {
+		userID = admin.ID
+	}
+	m, err := Membership(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if m == nil || m.OrganizationID != am.OrganizationID {
+		return "", nil, ErrNotMember
+	}
+
+	key, err := newKey()
+	if err != nil {
+		return "", nil, err
+	}
+	k := &models.OrganizationAPIKey{
+		OrganizationID: am.OrganizationID,
+		UserID:         userID,
+		Name:           name,
+		KeyHash:        hashKey(key),
+	}
+	if err := k.InsertG(boil.Infer()); err != nil {
+		return "", nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{
+		"organization_id": am.OrganizationID, "user_id": userID, "key_id": k.ID, "created_by": admin.ID,
+	}).Info("organization API key created")
+	return key, k, nil
+}
+
+// APIKeys returns API keys of the admin's organization.
+func APIKeys(admin *models.User) (models.OrganizationAPIKeySlice, error) {
+	am, err := adminMembership(admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	ks, err := models.OrganizationAPIKeys(models.OrganizationAPIKeyWhere.OrganizationID.EQ(am.OrganizationID)).AllG()
+	return ks, errors.Err(err)
+}
+
+// RevokeAPIKey deletes an API key of the admin's organization.
+func RevokeAPIKey(admin *models.User, id int) error {
+	am, err := adminMembership(admin.ID)
+	if err != nil {
+		return err
+	}
+	n, err := models.OrganizationAPIKeys(
+		models.OrganizationAPIKeyWhere.ID.EQ(id),
+		models.OrganizationAPIKeyWhere.OrganizationID.EQ(am.OrganizationID),
+	).DeleteAll(boil.GetDB())
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	logger.WithFields(logrus.Fields{"organization_id": am.OrganizationID, "key_id": id, "revoked_by": admin.ID}).Info("organization API key revoked")
+	return nil
+}
+
+// RotateAPIKey issues a replacement of an API key of the admin's organization, acting as the same member
+// and reporting security events to the same webhook. The old key keeps working for overlap and expires
+// CredentialLifecycle.RotatedKeyRetention later, its use in between is reported as EventRotatedKeyUsed.
+// When the old key was set to expire, the new one gets the same lifetime.
+func RotateAPIKey(admin *models.User, id int, overlap time.Duration) (string, *models.OrganizationAPIKey, error) {
+	old, err := adminKey(admin, id)
+	if err != nil {
+		return "", nil, err
+	}
+	s, err := keySecurity(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if s.ReplacedBy.Valid {
+		return "", nil, ErrAlreadyRotated
+	}
+	key, err := newKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	tx, err := boil.Begin()
+	if err != nil {
+		return "", nil, errors.Err(err)
+	}
+	k := &models.OrganizationAPIKey{
+		OrganizationID: old.OrganizationID,
+		UserID:         old.UserID,
+		Name:           old.Name,
+		KeyHash:        hashKey(key),
+	}
+	if err := k.Insert(tx, boil.Infer()); err != nil {
+		tx.Rollback()
+		return "", nil, errors.Err(err)
+	}
+	var expiresAt null.Time
+	if s.ExpiresAt.Valid {
+		expiresAt = null.TimeFrom(k.CreatedAt.Add(s.ExpiresAt.Time.Sub(old.CreatedAt)))
+	}
+	_, err = tx.Exec(
+		`INSERT INTO organization_api_key_security (key_id, webhook_url, expires_at) VALUES ($1, $2, $3)`,
+		k.ID, s.WebhookURL, expiresAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return "", nil, errors.Err(err)
+	}
+	_, err = tx.Exec(
+		`INSERT INTO organization_api_key_security (key_id, rotate_at, expires_at, replaced_by)
+		VALUES ($1, now() + $2 * interval '1 second', now() + $3 * interval '1 second', $4)
+		ON CONFLICT (key_id) DO UPDATE SET rotate_at = EXCLUDED.rotate_at, expires_at = EXCLUDED.expires_at,
+			replaced_by = EXCLUDED.replaced_by, updated_at = now()`,
+		old.ID, overlap.Seconds(), (overlap + config.GetCredentialLifecycle().RotatedKeyRetention).Seconds(), k.ID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return "", nil, errors.Err(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", nil, errors.Err(err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"organization_id": old.OrganizationID, "key_id": old.ID, "new_key_id": k.ID, "overlap": overlap, "rotated_by": admin.ID,
+	}).Info("organization API key rotated")
+	return key, k, nil
+}
+
+// ExpireAPIKeys deletes API keys past their expiry and returns how many were deleted.
+// Expired keys are rejected anyway, this only keeps them from piling up.
+func ExpireAPIKeys() (int64, error) {
+	res, err := boil.GetDB().Exec(
+		`DELETE FROM organization_api_keys
+		WHERE id IN (SELECT key_id FROM organization_api_key_security WHERE expires_at <= now())`,
+	)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	if n > 0 {
+		logger.Log().Infof("deleted %v expired API keys", n)
+	}
+	return n, nil
+}
+
+// KeyProvider authenticates requests carrying an organization API key, it satisfies auth.Provider.
+// The key resolves to the member it was issued for, as long as they still belong to the organization
+// and the key hasn't been rotated or expired.
+func KeyProvider(key, metaRemoteIP string) (*models.User, error) {
+	k, err := models.OrganizationAPIKeys(models.OrganizationAPIKeyWhere.KeyHash.EQ(hashKey(key))).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidKey
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	m, err := Membership(k.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || m.OrganizationID != k.OrganizationID {
+		return nil, ErrInvalidKey
+	}
+	if err := checkKeyUse(k, metaRemoteIP); err != nil {
+		return nil, err
+	}
+	user, err := wallet.GetDBUserG(k.UserID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{"key_id": k.ID, "user_id": k.UserID, "ip": metaRemoteIP}).Debug("authenticated with organization API key")
+	return user, nil
+}
+
+func newKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Err(err)
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
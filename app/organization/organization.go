@@ -0,0 +1,225 @@
+// Package organization groups users into organizations which share an upload quota, publish drafts
+// and API keys, so several editors can work on behalf of the same media company.
+package organization
+
+import (
+	"database/sql"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	// RoleAdmin members manage membership and API keys of the organization.
+	RoleAdmin = "admin"
+	// RoleEditor members can upload and edit drafts.
+	RoleEditor = "editor"
+)
+
+var (
+	logger = monitor.NewModuleLogger("organization")
+
+	ErrNotMember      = errors.Base("user is not a member of an organization")
+	ErrAlreadyMember  = errors.Base("user is already a member of an organization")
+	ErrNotAdmin       = errors.Base("only organization admins can do that")
+	ErrInvalidRole    = errors.Base("role must be either admin or editor")
+	ErrUnknownUser    = errors.Base("user does not exist")
+	ErrNotFound       = errors.Base("not found")
+	ErrQuotaExceeded  = errors.Base("organization upload quota exceeded")
+	ErrEmptyName      = errors.Base("name is required")
+	ErrLastAdminLeave = errors.Base("the last admin cannot leave the organization")
+)
+
+// Membership returns organization membership of the user or nil if they don't belong to any.
+func Membership(userID int) (*models.OrganizationMember, error) {
+	m, err := models.OrganizationMembers(models.OrganizationMemberWhere.UserID.EQ(userID)).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return m, errors.Err(err)
+}
+
+// Get returns the organization the user belongs to along with their membership.
+func Get(userID int) (*models.Organization, *models.OrganizationMember, error) {
+	m, err := Membership(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil {
+		return nil, nil, ErrNotMember
+	}
+	org, err := models.FindOrganizationG(m.OrganizationID)
+	if err != nil {
+		return nil, nil, errors.Err(err)
+	}
+	return org, m, nil
+}
+
+// Members returns all members of the organization.
+func Members(orgID int) (models.OrganizationMemberSlice, error) {
+	ms, err := models.OrganizationMembers(models.OrganizationMemberWhere.OrganizationID.EQ(orgID)).AllG()
+	return ms, errors.Err(err)
+}
+
+// Create creates an organization with the user as its first admin.
+func Create(user *models.User, name string) (*models.Organization, error) {
+	if name == "" {
+		return nil, ErrEmptyName
+	}
+	m, err := Membership(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	tx, err := boil.Begin()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	org := &models.Organization{Name: name, UploadQuota: config.GetOrganizationUploadQuota()}
+	if err := org.Insert(tx, boil.Infer()); err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	m = &models.OrganizationMember{OrganizationID: org.ID, UserID: user.ID, Role: RoleAdmin}
+	if err := m.Insert(tx, boil.Infer()); err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "organization_id": org.ID}).Info("organization created")
+	return org, nil
+}
+
+// AddMember adds a user to the organization administered by admin.
+func AddMember(admin *models.User, userID int, role string) (*models.OrganizationMember, error) {
+	if role != RoleAdmin && role != RoleEditor {
+		return nil, ErrInvalidRole
+	}
+	am, err := adminMembership(admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := models.UserExistsG(userID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if !exists {
+		return nil, ErrUnknownUser
+	}
+	m, err := Membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	m = &models.OrganizationMember{OrganizationID: am.OrganizationID, UserID: userID, Role: role}
+	if err := m.InsertG(boil.Infer()); err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{
+		"user_id": userID, "organization_id": am.OrganizationID, "role": role, "added_by": admin.ID,
+	}).Info("organization member added")
+	return m, nil
+}
+
+// RemoveMember removes a user from the organization. Admins can remove anyone, other members only themselves.
+// API keys acting as the removed member are deleted along with the membership.
+func RemoveMember(actor *models.User, userID int) error {
+	am, err := Membership(actor.ID)
+	if err != nil {
+		return err
+	}
+	if am == nil {
+		return ErrNotMember
+	}
+	if actor.ID != userID && am.Role != RoleAdmin {
+		return ErrNotAdmin
+	}
+	m, err := Membership(userID)
+	if err != nil {
+		return err
+	}
+	if m == nil || m.OrganizationID != am.OrganizationID {
+		return ErrNotFound
+	}
+	if m.Role == RoleAdmin {
+		admins, err := models.OrganizationMembers(
+			models.OrganizationMemberWhere.OrganizationID.EQ(m.OrganizationID),
+			models.OrganizationMemberWhere.Role.EQ(RoleAdmin),
+		).CountG()
+		if err != nil {
+			return errors.Err(err)
+		}
+		if admins == 1 {
+			return ErrLastAdminLeave
+		}
+	}
+
+	tx, err := boil.Begin()
+	if err != nil {
+		return errors.Err(err)
+	}
+	if _, err := m.Delete(tx); err != nil {
+		tx.Rollback()
+		return errors.Err(err)
+	}
+	_, err = models.OrganizationAPIKeys(
+		models.OrganizationAPIKeyWhere.OrganizationID.EQ(m.OrganizationID),
+		models.OrganizationAPIKeyWhere.UserID.EQ(userID),
+	).DeleteAll(tx)
+	if err != nil {
+		tx.Rollback()
+		return errors.Err(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Err(err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"user_id": userID, "organization_id": m.OrganizationID, "removed_by": actor.ID,
+	}).Info("organization member removed")
+	return nil
+}
+
+// SetQuota changes the upload quota of the organization, 0 means unlimited.
+func SetQuota(orgID int, quota int64) (*models.Organization, error) {
+	org, err := models.FindOrganizationG(orgID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	org.UploadQuota = quota
+	if _, err := org.UpdateG(boil.Whitelist(models.OrganizationColumns.UploadQuota, models.OrganizationColumns.UpdatedAt)); err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{"organization_id": orgID, "upload_quota": quota}).Info("organization quota changed")
+	return org, nil
+}
+
+func adminMembership(userID int) (*models.OrganizationMember, error) {
+	m, err := Membership(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrNotMember
+	}
+	if m.Role != RoleAdmin {
+		return nil, ErrNotAdmin
+	}
+	return m, nil
+}
@@ -0,0 +1,284 @@
+package organization
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func createUser(t *testing.T) *models.User {
+	u := &models.User{}
+	require.NoError(t, u.InsertG(boil.Infer()))
+	return u
+}
+
+func createOrganization(t *testing.T) (*models.Organization, *models.User, *models.User) {
+	admin, editor := createUser(t), createUser(t)
+	org, err := Create(admin, fmt.Sprintf("org-%v", time.Now().UnixNano()))
+	require.NoError(t, err)
+	_, err = AddMember(admin, editor.ID, RoleEditor)
+	require.NoError(t, err)
+	return org, admin, editor
+}
+
+func TestMembership(t *testing.T) {
+	org, admin, editor := createOrganization(t)
+
+	_, err := Create(editor, "another")
+	assert.True(t, errors.Is(err, ErrAlreadyMember))
+
+	members, err := Members(org.ID)
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	_, err = AddMember(editor, createUser(t).ID, RoleEditor)
+	assert.True(t, errors.Is(err, ErrNotAdmin))
+	_, err = AddMember(admin, createUser(t).ID, "owner")
+	assert.True(t, errors.Is(err, ErrInvalidRole))
+
+	assert.True(t, errors.Is(RemoveMember(admin, admin.ID), ErrLastAdminLeave))
+	assert.True(t, errors.Is(RemoveMember(editor, admin.ID), ErrNotAdmin))
+	require.NoError(t, RemoveMember(editor, editor.ID))
+
+	_, _, err = Get(editor.ID)
+	assert.True(t, errors.Is(err, ErrNotMember))
+}
+
+func TestReserveUpload(t *testing.T) {
+	org, _, editor := createOrganization(t)
+	_, err := SetQuota(org.ID, 100)
+	require.NoError(t, err)
+
+	release, err := ReserveUpload(editor.ID, 60)
+	require.NoError(t, err)
+	_, err = ReserveUpload(editor.ID, 60)
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+
+	release()
+	_, err = ReserveUpload(editor.ID, 60)
+	require.NoError(t, err)
+
+	require.NoError(t, org.ReloadG())
+	assert.EqualValues(t, 60, org.UploadUsed)
+
+	_, err = ReserveUpload(createUser(t).ID, 1<<40)
+	assert.NoError(t, err)
+}
+
+func TestDrafts(t *testing.T) {
+	_, admin, editor := createOrganization(t)
+
+	d, err := SaveDraft(editor, 0, "Episode 1", json.RawMessage(`{"name": "episode-1"}`))
+	require.NoError(t, err)
+
+	ds, err := Drafts(admin)
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "Episode 1", ds[0].Title)
+
+	_, err = SaveDraft(admin, d.ID, "Episode one", json.RawMessage(`{"name": "episode-1"}`))
+	require.NoError(t, err)
+
+	_, err = Drafts(createUser(t))
+	assert.True(t, errors.Is(err, ErrNotMember))
+
+	require.NoError(t, DeleteDraft(admin, d.ID))
+	assert.True(t, errors.Is(DeleteDraft(editor, d.ID), ErrNotFound))
+}
+
+func TestAPIKeys(t *testing.T) {
+	_, admin, editor := createOrganization(t)
+
+	_, _, err := CreateAPIKey(editor, "ci", 0)
+	assert.True(t, errors.Is(err, ErrNotAdmin))
+
+	key, k, err := CreateAPIKey(admin, "ci", editor.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, k.KeyHash, key)
+
+	user, err := KeyProvider(key, "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, editor.ID, user.ID)
+
+	_, err = KeyProvider("lbrytv_org_bogus", "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	require.NoError(t, RemoveMember(admin, editor.ID))
+	_, err = KeyProvider(key, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+
+	key, k, err = CreateAPIKey(admin, "ci", 0)
+	require.NoError(t, err)
+	require.NoError(t, RevokeAPIKey(admin, k.ID))
+	_, err = KeyProvider(key, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
+
+// securityEvents returns payloads of security events queued for the key.
+func securityEvents(t *testing.T, keyID int) []map[string]interface{} {
+	rows, err := boil.GetDB().Query(`SELECT outbox_message_id FROM organization_api_key_events WHERE key_id = $1 ORDER BY id`, keyID)
+	require.NoError(t, err)
+	defer rows.Close()
+	var events []map[string]interface{}
+	for rows.Next() {
+		var id int64
+		require.NoError(t, rows.Scan(&id))
+		m, err := outbox.Get(id)
+		require.NoError(t, err)
+		var e map[string]interface{}
+		require.NoError(t, json.Unmarshal(m.Payload, &e))
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestKeySecurity(t *testing.T) {
+	_, admin, editor := createOrganization(t)
+	key, k, err := CreateAPIKey(admin, "ci", 0)
+	require.NoError(t, err)
+
+	_, err = SetKeySecurity(editor, k.ID, "https://partner.example.com/hook", null.Time{}, null.Time{})
+	assert.True(t, errors.Is(err, ErrNotAdmin))
+	_, err = SetKeySecurity(admin, k.ID, "ftp://partner.example.com/hook", null.Time{}, null.Time{})
+	assert.True(t, errors.Is(err, ErrInvalidWebhookURL))
+	s, err := SetKeySecurity(admin, k.ID, "https://partner.example.com/hook", null.Time{}, null.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/hook", s.WebhookURL)
+
+	_, err = KeyProvider(key, "8.8.8.8")
+	require.NoError(t, err)
+	_, err = KeyProvider(key, "8.8.8.9")
+	require.NoError(t, err)
+	assert.Empty(t, securityEvents(t, k.ID), "the first network and addresses within it are not reported")
+
+	_, err = KeyProvider(key, "1.1.1.1")
+	require.NoError(t, err)
+	_, err = KeyProvider(key, "1.1.1.1")
+	require.NoError(t, err)
+	events := securityEvents(t, k.ID)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventNewNetwork, events[0]["event"])
+	assert.Equal(t, "1.1.1.0/24", events[0]["network"])
+
+	_, err = SetKeySecurity(admin, k.ID, "https://partner.example.com/hook", null.TimeFrom(time.Now().Add(-time.Hour)), null.Time{})
+	require.NoError(t, err)
+	_, err = KeyProvider(key, "1.1.1.1")
+	assert.True(t, errors.Is(err, ErrKeyRotated))
+	_, err = KeyProvider(key, "1.1.1.1")
+	assert.True(t, errors.Is(err, ErrKeyRotated))
+	events = securityEvents(t, k.ID)
+	require.Len(t, events, 2, "repeated events are reported once per cooldown")
+	assert.Equal(t, EventRotatedKeyUsed, events[1]["event"])
+}
+
+func TestNotifyQuotaExhausted(t *testing.T) {
+	_, admin, _ := createOrganization(t)
+	key, k, err := CreateAPIKey(admin, "ci", 0)
+	require.NoError(t, err)
+	_, err = SetKeySecurity(admin, k.ID, "https://partner.example.com/hook", null.Time{}, null.Time{})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/proxy", nil)
+	NotifyQuotaExhausted(r)
+	r.Header.Set(auth.APIKeyHeader, key)
+	NotifyQuotaExhausted(r)
+
+	events := securityEvents(t, k.ID)
+	require.Len(t, events, 1)
+	assert.Equal(t, EventQuotaExhausted, events[0]["event"])
+	assert.EqualValues(t, k.ID, events[0]["key_id"])
+}
+
+func TestAddrNetwork(t *testing.T) {
+	assert.Equal(t, "203.0.113.0/24", addrNetwork("203.0.113.77"))
+	assert.Equal(t, "2001:db8:1::/48", addrNetwork("2001:db8:1:2::1"))
+	assert.Equal(t, "", addrNetwork("not an address"))
+}
+
+func TestRotateAPIKey(t *testing.T) {
+	_, admin, editor := createOrganization(t)
+	key, k, err := CreateAPIKey(admin, "ci", editor.ID)
+	require.NoError(t, err)
+	_, err = SetKeySecurity(admin, k.ID, "https://partner.example.com/hook", null.Time{}, null.TimeFrom(time.Now().Add(30*24*time.Hour)))
+	require.NoError(t, err)
+	_, err = KeyProvider(key, "8.8.8.8")
+	require.NoError(t, err)
+	s, err := GetKeySecurity(admin, k.ID)
+	require.NoError(t, err)
+	assert.True(t, s.LastUsedAt.Valid)
+
+	newKey, newK, err := RotateAPIKey(admin, k.ID, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "ci", newK.Name)
+	user, err := KeyProvider(newKey, "8.8.8.8")
+	require.NoError(t, err)
+	assert.Equal(t, editor.ID, user.ID)
+	_, err = KeyProvider(key, "8.8.8.8")
+	assert.NoError(t, err, "the old key keeps working within overlap")
+
+	s, err = GetKeySecurity(admin, k.ID)
+	require.NoError(t, err)
+	assert.EqualValues(t, newK.ID, s.ReplacedBy.Int)
+	assert.True(t, s.RotateAt.Valid)
+	newS, err := GetKeySecurity(admin, newK.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "https://partner.example.com/hook", newS.WebhookURL)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), newS.ExpiresAt.Time, time.Hour)
+
+	_, _, err = RotateAPIKey(admin, k.ID, time.Hour)
+	assert.True(t, errors.Is(err, ErrAlreadyRotated))
+
+	_, _, err = RotateAPIKey(admin, newK.ID, 0)
+	require.NoError(t, err)
+	_, err = KeyProvider(newKey, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrKeyRotated))
+}
+
+func TestExpireAPIKeys(t *testing.T) {
+	_, admin, _ := createOrganization(t)
+	key, k, err := CreateAPIKey(admin, "ci", 0)
+	require.NoError(t, err)
+	_, err = SetKeySecurity(admin, k.ID, "", null.Time{}, null.TimeFrom(time.Now().Add(-time.Minute)))
+	require.NoError(t, err)
+
+	_, err = KeyProvider(key, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrKeyExpired))
+
+	n, err := ExpireAPIKeys()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(1))
+	_, err = KeyProvider(key, "8.8.8.8")
+	assert.True(t, errors.Is(err, ErrInvalidKey))
+}
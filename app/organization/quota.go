@@ -0,0 +1,48 @@
+package organization
+
+import (
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+)
+
+// ReserveUpload counts size bytes against the upload quota of the user's organization.
+// The returned release func gives the bytes back and should be called if the upload doesn't go through.
+// Users outside of organizations are not limited.
+func ReserveUpload(userID int, size int64) (func(), error) {
+	noop := func() {}
+	m, err := Membership(userID)
+	if err != nil {
+		return noop, err
+	}
+	if m == nil {
+		return noop, nil
+	}
+
+	res, err := queries.Raw(
+		`UPDATE organizations SET upload_used = upload_used + $1, updated_at = now()
+		WHERE id = $2 AND (upload_quota = 0 OR upload_used + $1 <= upload_quota)`,
+		size, m.OrganizationID,
+	).Exec(boil.GetDB())
+	if err != nil {
+		return noop, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return noop, errors.Err(err)
+	}
+	if n == 0 {
+		return noop, ErrQuotaExceeded
+	}
+
+	return func() {
+		_, err := queries.Raw(
+			`UPDATE organizations SET upload_used = GREATEST(upload_used - $1, 0), updated_at = now() WHERE id = $2`,
+			size, m.OrganizationID,
+		).Exec(boil.GetDB())
+		if err != nil {
+			logger.Log().Errorf("cannot release %v bytes of organization %v quota: %v", size, m.OrganizationID, err)
+		}
+	}, nil
+}
@@ -0,0 +1,273 @@
+package organization
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+// Integrators can set a webhook URL on their API keys to be told when a key is used in a way suggesting it has leaked.
+// Security events are queued in the outbox, so they are delivered even when the receiver is down for a while.
+
+const (
+	// EventNewNetwork is reported when a key authenticates from a network it hasn't been used from before.
+	// The network a key is first used from is not reported.
+	EventNewNetwork = "new_network"
+	// EventRotatedKeyUsed is reported when a key is presented after its rotate_at. Such requests are rejected.
+	EventRotatedKeyUsed = "rotated_key_used"
+	// EventQuotaExhausted is reported when an upload made with a key exceeds the organization upload quota.
+	EventQuotaExhausted = "quota_exhausted"
+)
+
+// lastUsedPrecision limits how often last use of a key is recorded.
+const lastUsedPrecision = time.Minute
+
+var (
+	ErrKeyRotated        = errors.Base("API key has been rotated")
+	ErrKeyExpired        = errors.Base("API key has expired")
+	ErrInvalidWebhookURL = errors.Base("webhook_url must be an http or https URL")
+)
+
+// KeySecurity holds security settings and lifecycle of an API key.
+type KeySecurity struct {
+	KeyID      int       `json:"key_id"`
+	WebhookURL string    `json:"webhook_url"`
+	RotateAt   null.Time `json:"rotate_at"`
+	ExpiresAt  null.Time `json:"expires_at"`
+	LastUsedAt null.Time `json:"last_used_at"`
+	// ReplacedBy is the ID of the key this one has been rotated to.
+	ReplacedBy null.Int `json:"replaced_by"`
+
+	// rotated and expired are set when rotate_at and expires_at have passed, as told by the DB
+	// so clocks of lbrytv instances don't matter.
+	rotated, expired bool
+}
+
+// GetKeySecurity returns security settings of an API key of the admin's organization.
+func GetKeySecurity(admin *models.User, id int) (*KeySecurity, error) {
+	if _, err := adminKey(admin, id); err != nil {
+		return nil, err
+	}
+	return keySecurity(id)
+}
+
+// SetKeySecurity sets the URL security events of an API key of the admin's organization are posted to,
+// the time after which the key is considered rotated and when it expires. Empty webhookURL turns events off.
+func SetKeySecurity(admin *models.User, id int, webhookURL string, rotateAt, expiresAt null.Time) (*KeySecurity, error) {
+	k, err := adminKey(admin, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrInvalidWebhookURL
+		}
+	}
+	_, err = boil.GetDB().Exec(
+		`INSERT INTO organization_api_key_security (key_id, webhook_url, rotate_at, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (key_id) DO UPDATE SET webhook_url = $2, rotate_at = $3, expires_at = $4, updated_at = now()`,
+		k.ID, webhookURL, rotateAt, expiresAt,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{
+		"organization_id": k.OrganizationID, "key_id": k.ID, "rotate_at": rotateAt.Time, "expires_at": expiresAt.Time,
+		"changed_by": admin.ID,
+	}).Info("organization API key security settings changed")
+	return keySecurity(id)
+}
+
+// NotifyQuotaExhausted reports EventQuotaExhausted for the API key r was authenticated with, if any.
+func NotifyQuotaExhausted(r *http.Request) {
+	key := r.Header.Get(auth.APIKeyHeader)
+	if key == "" || r.Header.Get(wallet.TokenHeader) != "" {
+		return
+	}
+	k, err := models.OrganizationAPIKeys(models.OrganizationAPIKeyWhere.KeyHash.EQ(hashKey(key))).OneG()
+	if err != nil {
+		return
+	}
+	s, err := keySecurity(k.ID)
+	if err != nil {
+		logger.Log().Errorf("cannot load security settings of API key %v: %v", k.ID, err)
+		return
+	}
+	notify(k, s, EventQuotaExhausted, "", ip.FromRequest(r))
+}
+
+// checkKeyUse rejects rotated and expired keys, records use of the key and reports anomalies in its use from addr.
+func checkKeyUse(k *models.OrganizationAPIKey, addr string) error {
+	s, err := keySecurity(k.ID)
+	if err != nil {
+		return err
+	}
+	network := addrNetwork(addr)
+	if s.rotated {
+		notify(k, s, EventRotatedKeyUsed, network, addr)
+		return ErrKeyRotated
+	}
+	if s.expired {
+		return ErrKeyExpired
+	}
+	_, err = boil.GetDB().Exec(
+		`INSERT INTO organization_api_key_security (key_id, last_used_at) VALUES ($1, now())
+		ON CONFLICT (key_id) DO UPDATE SET last_used_at = now()
+		WHERE organization_api_key_security.last_used_at IS NULL
+			OR organization_api_key_security.last_used_at < now() - $2 * interval '1 second'`,
+		k.ID, lastUsedPrecision.Seconds(),
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if network == "" {
+		return nil
+	}
+
+	var isNew bool
+	err = boil.GetDB().QueryRow(
+		`INSERT INTO organization_api_key_networks (key_id, network) VALUES ($1, $2)
+		ON CONFLICT (key_id, network) DO UPDATE SET last_seen_at = now()
+		RETURNING xmax = 0`,
+		k.ID, network,
+	).Scan(&isNew)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if !isNew {
+		return nil
+	}
+	var known int
+	err = boil.GetDB().QueryRow(`SELECT count(*) FROM organization_api_key_networks WHERE key_id = $1`, k.ID).Scan(&known)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if known > 1 {
+		notify(k, s, EventNewNetwork, network, addr)
+	}
+	return nil
+}
+
+// notify queues a security event for the key webhook, unless the same event has been reported within cooldown.
+// Failures are logged, they never stand in the way of the request.
+func notify(k *models.OrganizationAPIKey, s *KeySecurity, event, network, addr string) {
+	if s.WebhookURL == "" {
+		return
+	}
+	log := logger.WithFields(logrus.Fields{"key_id": k.ID, "event": event, "ip": addr})
+	cooldown := config.GetAPIKeySecurity().Cooldown
+
+	tx, err := boil.Begin()
+	if err != nil {
+		log.Errorf("cannot report API key security event: %v", err)
+		return
+	}
+	var recent bool
+	err = tx.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM organization_api_key_events
+		WHERE key_id = $1 AND kind = $2 AND network = $3 AND created_at > now() - $4 * interval '1 second')`,
+		k.ID, event, network, cooldown.Seconds(),
+	).Scan(&recent)
+	if err != nil || recent {
+		tx.Rollback()
+		if err != nil {
+			log.Errorf("cannot report API key security event: %v", err)
+		}
+		return
+	}
+	msgID, err := outbox.Enqueue(tx, outbox.KindWebhook, s.WebhookURL, securityEvent(k, event, network, addr))
+	if err != nil {
+		tx.Rollback()
+		log.Errorf("cannot report API key security event: %v", err)
+		return
+	}
+	_, err = tx.Exec(
+		`INSERT INTO organization_api_key_events (key_id, kind, network, outbox_message_id) VALUES ($1, $2, $3, $4)`,
+		k.ID, event, network, msgID,
+	)
+	if err != nil {
+		tx.Rollback()
+		log.Errorf("cannot report API key security event: %v", err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Errorf("cannot report API key security event: %v", err)
+		return
+	}
+	metrics.APIKeySecurityEvents.WithLabelValues(event).Inc()
+	log.Info("API key security event reported")
+}
+
+// securityEvent is the payload posted to the key webhook.
+func securityEvent(k *models.OrganizationAPIKey, event, network, addr string) map[string]interface{} {
+	return map[string]interface{}{
+		"event":           event,
+		"organization_id": k.OrganizationID,
+		"key_id":          k.ID,
+		"key_name":        k.Name,
+		"ip":              addr,
+		"network":         network,
+		"occurred_at":     time.Now().UTC(),
+	}
+}
+
+// addrNetwork returns the network addr belongs to, as set in APIKeySecurity, or an empty string for invalid addresses.
+func addrNetwork(addr string) string {
+	parsed := net.ParseIP(addr)
+	if parsed == nil {
+		return ""
+	}
+	cfg := config.GetAPIKeySecurity()
+	mask := net.CIDRMask(cfg.IPv6Prefix, 128)
+	if v4 := parsed.To4(); v4 != nil {
+		parsed, mask = v4, net.CIDRMask(cfg.IPv4Prefix, 32)
+	}
+	if mask == nil {
+		return ""
+	}
+	return (&net.IPNet{IP: parsed.Mask(mask), Mask: mask}).String()
+}
+
+func keySecurity(id int) (*KeySecurity, error) {
+	s := &KeySecurity{KeyID: id}
+	err := boil.GetDB().QueryRow(
+		`SELECT webhook_url, rotate_at, expires_at, last_used_at, replaced_by,
+			COALESCE(rotate_at <= now(), false), COALESCE(expires_at <= now(), false)
+		FROM organization_api_key_security WHERE key_id = $1`, id,
+	).Scan(&s.WebhookURL, &s.RotateAt, &s.ExpiresAt, &s.LastUsedAt, &s.ReplacedBy, &s.rotated, &s.expired)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s, nil
+	}
+	return s, errors.Err(err)
+}
+
+func adminKey(admin *models.User, id int) (*models.OrganizationAPIKey, error) {
+	am, err := adminMembership(admin.ID)
+	if err != nil {
+		return nil, err
+	}
+	k, err := models.OrganizationAPIKeys(
+		models.OrganizationAPIKeyWhere.ID.EQ(id),
+		models.OrganizationAPIKeyWhere.OrganizationID.EQ(am.OrganizationID),
+	).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return k, errors.Err(err)
+}
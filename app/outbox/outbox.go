@@ -0,0 +1,406 @@
+// Package outbox delivers side effects of database changes, such as webhooks, at least once.
+// A message is inserted into the outbox table in the same transaction as the change triggering it,
+// so it can't be lost when lbrytv crashes right after the commit, and a Dispatcher delivers it in the background.
+// Failed deliveries are retried with backoff until MaxAttempts, after which the message is marked failed
+// and can be retried by admins. Messages of each kind are delivered by a Sender registered with RegisterSender,
+// webhooks are built in. Receivers may get a message more than once and should deduplicate it by its ID.
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/deadletter"
+	"github.com/lbryio/lbrytv/app/secrets"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/pkg/webhook"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	// KindWebhook messages are POSTed as JSON to their destination URL.
+	KindWebhook = "webhook"
+
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+
+	// maxBackoff caps the delay between delivery attempts.
+	maxBackoff = 24 * time.Hour
+
+	// SignatureHeader carries signatures of webhook deliveries, see package webhook for verifying them.
+	SignatureHeader = webhook.SignatureHeader
+)
+
+var (
+	logger = monitor.NewModuleLogger("outbox")
+
+	sendersMu sync.RWMutex
+	senders   = map[string]Sender{KindWebhook: SendWebhook}
+
+	ErrNotFound      = errors.Base("outbox message not found")
+	ErrUnknownKind   = errors.Base("unknown outbox message kind")
+	ErrMissingFields = errors.Base("kind and destination are required")
+	ErrNotFailed     = errors.Base("only failed outbox messages can be retried")
+)
+
+func init() {
+	// Messages which failed for good are kept as dead letters, redelivering one retries its message
+	deadletter.RegisterRedeliverer(deadletter.SourceOutbox, func(l *deadletter.Letter) error {
+		id, err := strconv.ParseInt(l.Ref, 10, 64)
+		if err != nil {
+			return errors.Err("invalid outbox message id %q", l.Ref)
+		}
+		_, err = Retry(id)
+		if errors.Is(err, ErrNotFailed) {
+			// Retried through the outbox already
+			return errors.Prefix(err.Error(), deadletter.ErrRedelivered)
+		}
+		return err
+	})
+}
+
+// Message is a side effect waiting for delivery or delivered already.
+type Message struct {
+	ID            int64           `json:"id"`
+	Kind          string          `json:"kind"`
+	Destination   string          `json:"destination"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     null.String     `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   null.Time       `json:"delivered_at"`
+}
+
+// Sender delivers a message. Returning an error makes the message retried later.
+type Sender func(ctx context.Context, m *Message) error
+
+// RegisterSender makes messages of kind deliverable, replacing the sender previously registered for it.
+func RegisterSender(kind string, s Sender) {
+	sendersMu.Lock()
+	defer sendersMu.Unlock()
+	senders[kind] = s
+}
+
+func getSender(kind string) Sender {
+	sendersMu.RLock()
+	defer sendersMu.RUnlock()
+	return senders[kind]
+}
+
+const messageColumns = `id, kind, destination, payload, status, attempts, last_error, next_attempt_at, created_at, delivered_at`
+
+func scanMessage(s interface{ Scan(...interface{}) error }) (*Message, error) {
+	m := &Message{}
+	var payload []byte
+	err := s.Scan(&m.ID, &m.Kind, &m.Destination, &payload, &m.Status, &m.Attempts, &m.LastError,
+		&m.NextAttemptAt, &m.CreatedAt, &m.DeliveredAt)
+	m.Payload = json.RawMessage(payload)
+	return m, err
+}
+
+// Enqueue adds a message with payload marshaled into JSON to the outbox. exec should be the transaction
+// making the change the message is triggered by, so the message is recorded if and only if it's committed.
+func Enqueue(exec boil.Executor, kind, destination string, payload interface{}) (int64, error) {
+	if kind == "" || destination == "" {
+		return 0, ErrMissingFields
+	}
+	if getSender(kind) == nil {
+		return 0, errors.Prefix(kind, ErrUnknownKind)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	var id int64
+	err = exec.QueryRow(
+		`INSERT INTO outbox_messages (kind, destination, payload) VALUES ($1, $2, $3) RETURNING id`,
+		kind, destination, string(body),
+	).Scan(&id)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	metrics.OutboxMessages.WithLabelValues(kind, metrics.OutboxEnqueued).Inc()
+	return id, nil
+}
+
+// Get returns the message with id.
+func Get(id int64) (*Message, error) {
+	m, err := scanMessage(boil.GetDB().QueryRow(`SELECT `+messageColumns+` FROM outbox_messages WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return m, nil
+}
+
+// List returns messages, optionally filtered by status, newest first.
+func List(status string, limit, offset int) ([]*Message, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+messageColumns+` FROM outbox_messages WHERE $1 = '' OR status = $1
+		ORDER BY id DESC LIMIT $2 OFFSET $3`, status, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	messages := []*Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		messages = append(messages, m)
+	}
+	return messages, errors.Err(rows.Err())
+}
+
+// Retry makes a failed message pending again, with a fresh set of delivery attempts.
+func Retry(id int64) (*Message, error) {
+	m, err := scanMessage(boil.GetDB().QueryRow(
+		`UPDATE outbox_messages SET status = 'pending', attempts = 0, next_attempt_at = now()
+		WHERE id = $1 AND status = 'failed' RETURNING `+messageColumns, id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFailed
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{"message_id": id, "kind": m.Kind}).Info("outbox message queued for retry")
+	return m, nil
+}
+
+// Prune deletes messages delivered more than olderThan ago and returns how many were deleted.
+func Prune(olderThan time.Duration) (int64, error) {
+	res, err := boil.GetDB().Exec(
+		`DELETE FROM outbox_messages WHERE status = 'delivered' AND delivered_at < now() - $1 * interval '1 second'`,
+		olderThan.Seconds(),
+	)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	return n, errors.Err(err)
+}
+
+// SendWebhook POSTs the message payload to its destination URL. The message ID is sent
+// in the X-Outbox-Message-ID header for the receiver to recognize repeated deliveries.
+// Deliveries are signed with webhook signing secrets, each attempt with a new nonce.
+func SendWebhook(ctx context.Context, m *Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.Destination, bytes.NewReader(m.Payload))
+	if err != nil {
+		return errors.Err(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.MessageIDHeader, strconv.FormatInt(m.ID, 10))
+	keys, err := secrets.Values(secrets.WebhookSigning)
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		if err := webhook.SetHeaders(req, keys, m.Payload); err != nil {
+			return err
+		}
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Err(err)
+	}
+	res.Body.Close()
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Err("webhook responded with %v", res.StatusCode)
+	}
+	return nil
+}
+
+// Dispatcher delivers pending messages, polling the outbox every Interval.
+// Several instances can run at once, each message is locked by the one delivering it.
+type Dispatcher struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	// RetryDelay is how long the first retry is delayed for, doubling with every further attempt.
+	RetryDelay time.Duration
+	// Timeout limits a single delivery attempt.
+	Timeout time.Duration
+
+	stop chan struct{}
+}
+
+// NewDispatcher creates a dispatcher polling the outbox every interval once started.
+func NewDispatcher(interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		Interval:    interval,
+		BatchSize:   100,
+		MaxAttempts: 10,
+		RetryDelay:  30 * time.Second,
+		Timeout:     30 * time.Second,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start delivers due messages immediately and then every Interval until Stop is called. It blocks.
+func (d *Dispatcher) Start() {
+	logger.Log().Infof("delivering outbox messages every %v", d.Interval)
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		d.drain()
+		select {
+		case <-ticker.C:
+		case <-d.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the delivery loop.
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+}
+
+// drain delivers batches of due messages until fewer than a full batch is left.
+func (d *Dispatcher) drain() {
+	for {
+		n, err := d.DeliverDue()
+		if err != nil {
+			logger.Log().Errorf("cannot deliver outbox messages: %v", err)
+			monitor.ErrorToSentry(err)
+			return
+		}
+		if n < d.BatchSize {
+			return
+		}
+	}
+}
+
+// DeliverDue makes a delivery attempt for up to BatchSize pending messages which are due and returns
+// how many were attempted. Messages stay locked until all of them are attempted, and when lbrytv crashes
+// before recording the outcome, they are attempted again.
+func (d *Dispatcher) DeliverDue() (int, error) {
+	tx, err := boil.Begin()
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	rows, err := tx.Query(
+		`SELECT `+messageColumns+` FROM outbox_messages WHERE status = 'pending' AND next_attempt_at <= now()
+		ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED`, d.BatchSize,
+	)
+	if err != nil {
+		tx.Rollback()
+		return 0, errors.Err(err)
+	}
+	messages := []*Message{}
+	for rows.Next() {
+		m, err := scanMessage(rows)
+		if err != nil {
+			rows.Close()
+			tx.Rollback()
+			return 0, errors.Err(err)
+		}
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return 0, errors.Err(err)
+	}
+
+	for _, m := range messages {
+		if err := d.record(tx, m, d.deliver(m)); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Err(err)
+	}
+	return len(messages), nil
+}
+
+func (d *Dispatcher) deliver(m *Message) (err error) {
+	defer errors.Recover(&err)
+	send := getSender(m.Kind)
+	if send == nil {
+		return errors.Prefix(m.Kind, ErrUnknownKind)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), d.Timeout)
+	defer cancel()
+	return send(ctx, m)
+}
+
+// record stores the outcome of a delivery attempt, scheduling the next one if it failed.
+func (d *Dispatcher) record(exec boil.Executor, m *Message, deliveryErr error) error {
+	log := logger.WithFields(logrus.Fields{"message_id": m.ID, "kind": m.Kind, "attempt": m.Attempts + 1})
+	if deliveryErr == nil {
+		_, err := exec.Exec(
+			`UPDATE outbox_messages SET status = 'delivered', attempts = attempts + 1, delivered_at = now() WHERE id = $1`,
+			m.ID,
+		)
+		if err != nil {
+			return errors.Err(err)
+		}
+		metrics.OutboxMessages.WithLabelValues(m.Kind, metrics.OutboxDelivered).Inc()
+		log.Debug("outbox message delivered")
+		return nil
+	}
+
+	status := StatusPending
+	if m.Attempts+1 >= d.MaxAttempts {
+		status = StatusFailed
+	}
+	_, err := exec.Exec(
+		`UPDATE outbox_messages SET status = $2, attempts = attempts + 1, last_error = $3,
+		next_attempt_at = now() + $4 * interval '1 second' WHERE id = $1`,
+		m.ID, status, deliveryErr.Error(), d.backoff(m.Attempts+1).Seconds(),
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if status == StatusFailed {
+		_, err := deadletter.Record(
+			exec, deadletter.SourceOutbox, m.Kind, strconv.FormatInt(m.ID, 10),
+			map[string]interface{}{"destination": m.Destination, "payload": m.Payload}, m.Attempts+1, deliveryErr,
+		)
+		if err != nil {
+			return err
+		}
+		metrics.OutboxMessages.WithLabelValues(m.Kind, metrics.OutboxFailed).Inc()
+		log.Errorf("giving up delivering outbox message: %v", deliveryErr)
+		monitor.ErrorToSentry(deliveryErr, map[string]string{"message_id": fmt.Sprint(m.ID), "kind": m.Kind})
+		return nil
+	}
+	metrics.OutboxMessages.WithLabelValues(m.Kind, metrics.OutboxRetried).Inc()
+	log.Warnf("outbox message delivery failed, will retry: %v", deliveryErr)
+	return nil
+}
+
+// backoff returns the delay before the attempt following attempt number n.
+func (d *Dispatcher) backoff(n int) time.Duration {
+	delay := d.RetryDelay
+	for i := 1; i < n && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
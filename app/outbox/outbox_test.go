@@ -0,0 +1,265 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/deadletter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/pkg/webhook"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestEnqueueAndDeliver(t *testing.T) {
+	type delivery struct {
+		id   string
+		body map[string]interface{}
+	}
+	deliveries := make(chan delivery, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := delivery{id: r.Header.Get("X-Outbox-Message-ID")}
+		json.NewDecoder(r.Body).Decode(&d.body)
+		deliveries <- d
+	}))
+	defer ts.Close()
+
+	// Messages of rolled back transactions are never delivered
+	tx, err := boil.Begin()
+	require.NoError(t, err)
+	_, err = Enqueue(tx, KindWebhook, ts.URL, map[string]interface{}{"event": "discarded"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Rollback())
+
+	tx, err = boil.Begin()
+	require.NoError(t, err)
+	id, err := Enqueue(tx, KindWebhook, ts.URL, map[string]interface{}{"event": "takedown"})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	m, err := Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, m.Status)
+
+	n, err := NewDispatcher(time.Second).DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	d := <-deliveries
+	assert.Equal(t, strconv.FormatInt(id, 10), d.id)
+	assert.Equal(t, "takedown", d.body["event"])
+	assert.Len(t, deliveries, 0)
+
+	m, err = Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDelivered, m.Status)
+	assert.Equal(t, 1, m.Attempts)
+	assert.True(t, m.DeliveredAt.Valid)
+
+	// Delivered messages are not attempted again
+	n, err = NewDispatcher(time.Second).DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	pruned, err := Prune(time.Hour)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, pruned)
+	pruned, err = Prune(-time.Hour)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, pruned)
+	_, err = Get(id)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestRetries(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	id, err := Enqueue(boil.GetDB(), KindWebhook, ts.URL, map[string]interface{}{"event": "takedown"})
+	require.NoError(t, err)
+
+	d := NewDispatcher(time.Second)
+	d.MaxAttempts, d.RetryDelay = 2, 0
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+	m, err := Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, m.Status)
+	assert.Equal(t, 1, m.Attempts)
+	assert.Contains(t, m.LastError.String, "503")
+
+	_, err = Retry(id)
+	assert.True(t, errors.Is(err, ErrNotFailed))
+
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+	m, err = Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusFailed, m.Status)
+	assert.Equal(t, 2, calls)
+
+	// Failed messages are given up on until retried
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	failed, err := List(StatusFailed, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, id, failed[0].ID)
+
+	m, err = Retry(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, m.Status)
+	assert.Equal(t, 0, m.Attempts)
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	_, err = Retry(id + 1000)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestFailedMessagesBecomeDeadLetters(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	id, err := Enqueue(boil.GetDB(), KindWebhook, ts.URL, map[string]interface{}{"event": "publish"})
+	require.NoError(t, err)
+
+	d := NewDispatcher(time.Second)
+	d.MaxAttempts, d.RetryDelay = 1, 0
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+
+	letters, err := deadletter.List(deadletter.SourceOutbox, deadletter.StatusDead, 10, 0)
+	require.NoError(t, err)
+	var letter *deadletter.Letter
+	for _, l := range letters {
+		if l.Ref == strconv.FormatInt(id, 10) {
+			letter = l
+		}
+	}
+	require.NotNil(t, letter)
+	assert.Equal(t, KindWebhook, letter.Kind)
+	assert.Equal(t, 1, letter.Attempts)
+	assert.Contains(t, letter.Error, "502")
+	assert.Contains(t, string(letter.Payload), ts.URL)
+	assert.Contains(t, string(letter.Payload), "publish")
+
+	_, err = deadletter.Redeliver(letter.ID)
+	require.NoError(t, err)
+	m, err := Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, m.Status)
+	_, err = d.DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = deadletter.Redeliver(letter.ID)
+	assert.True(t, errors.Is(err, deadletter.ErrRedelivered))
+	// Failing again makes a new dead letter
+	letters, err = deadletter.List(deadletter.SourceOutbox, deadletter.StatusDead, 10, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, letters)
+	assert.Equal(t, strconv.FormatInt(id, 10), letters[0].Ref)
+	assert.NotEqual(t, letter.ID, letters[0].ID)
+}
+
+func TestEnqueueUnknownKind(t *testing.T) {
+	_, err := Enqueue(boil.GetDB(), "carrier_pigeon", "coop", nil)
+	assert.True(t, errors.Is(err, ErrUnknownKind))
+	_, err = Enqueue(boil.GetDB(), KindWebhook, "", nil)
+	assert.True(t, errors.Is(err, ErrMissingFields))
+}
+
+func TestRegisterSender(t *testing.T) {
+	sent := make(chan string, 1)
+	RegisterSender("test", func(ctx context.Context, m *Message) error {
+		sent <- m.Destination
+		return nil
+	})
+	_, err := Enqueue(boil.GetDB(), "test", "queue", map[string]string{"event": "test"})
+	require.NoError(t, err)
+	_, err = NewDispatcher(time.Second).DeliverDue()
+	require.NoError(t, err)
+	assert.Equal(t, "queue", <-sent)
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(time.Second)
+	d.RetryDelay = time.Minute
+	assert.Equal(t, time.Minute, d.backoff(1))
+	assert.Equal(t, 2*time.Minute, d.backoff(2))
+	assert.Equal(t, 8*time.Minute, d.backoff(4))
+	assert.Equal(t, maxBackoff, d.backoff(100))
+}
+
+func TestSendWebhook(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "7", r.Header.Get("X-Outbox-Message-ID"))
+		assert.JSONEq(t, `{"a":1}`, string(body))
+	}))
+	defer ts.Close()
+	err := SendWebhook(context.Background(), &Message{ID: 7, Destination: ts.URL, Payload: json.RawMessage(`{"a":1}`)})
+	assert.NoError(t, err)
+}
+
+func TestSendWebhook_Signed(t *testing.T) {
+	config.Override("WebhookSigningSecret", "s3cr3t")
+	config.Override("CredentialLifecycle", map[string]interface{}{"Overlap": "24h", "SecretsCacheTTL": 0})
+	defer config.RestoreOverridden()
+
+	v := webhook.NewVerifier("s3cr3t")
+	var nonces []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := v.Verify(r)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":1}`, string(body))
+		assert.Equal(t, "7", r.Header.Get(webhook.MessageIDHeader))
+		nonces = append(nonces, r.Header.Get(webhook.NonceHeader))
+	}))
+	defer ts.Close()
+	m := &Message{ID: 7, Destination: ts.URL, Payload: json.RawMessage(`{"a":1}`)}
+	require.NoError(t, SendWebhook(context.Background(), m))
+	// Retried deliveries are not taken for replays
+	require.NoError(t, SendWebhook(context.Background(), m))
+	require.Len(t, nonces, 2)
+	assert.NotEqual(t, nonces[0], nonces[1])
+}
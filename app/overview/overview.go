@@ -0,0 +1,236 @@
+// Package overview serves a summary of the whole system state to operators,
+// meant to be polled by live dashboards instead of scraping and combining many metrics.
+package overview
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/prefetch"
+	"github.com/lbryio/lbrytv/app/proxy"
+	"github.com/lbryio/lbrytv/app/publish"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/slo"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	nodeOK           = "ok"
+	nodeUnresponsive = "unresponsive"
+	nodeSlow         = "slow"
+	nodeUnknown      = "unknown"
+
+	// errorWindow is the SLO window error rates are reported for.
+	errorWindow = "5m"
+)
+
+// Node is the health of a single SDK node.
+type Node struct {
+	Name          string     `json:"name"`
+	Address       string     `json:"address"`
+	Status        string     `json:"status"`
+	WalletsLoaded uint64     `json:"wallets_loaded"`
+	Latency       float64    `json:"latency_seconds"`
+	Draining      bool       `json:"draining"`
+	Error         string     `json:"error,omitempty"`
+	CheckedAt     *time.Time `json:"checked_at,omitempty"`
+}
+
+// Caches holds numbers of items in in-memory caches.
+type Caches struct {
+	Queries  int `json:"queries"`
+	Channels int `json:"channels"`
+}
+
+// Uploads holds the number of uploads in progress.
+type Uploads struct {
+	Active int `json:"active"`
+}
+
+// Queues holds the depth of internal queues and pools.
+type Queues struct {
+	Sentry        int  `json:"sentry"`
+	SentryDropped int  `json:"sentry_dropped"`
+	Prefetch      int  `json:"prefetch"`
+	DBInUse       int  `json:"db_in_use"`
+	DBIdle        int  `json:"db_idle"`
+	DBWaitCount   int  `json:"db_wait_count"`
+	DBBreakerOpen bool `json:"db_breaker_open"`
+}
+
+// Method holds proxy stats of an SDK method. Counters are totals since the instance started,
+// so clients compute throughput from the difference between two overviews.
+type Method struct {
+	InFlight    int     `json:"in_flight"`
+	Calls       float64 `json:"calls"`
+	Failures    float64 `json:"failures"`
+	CacheHits   float64 `json:"cache_hits"`
+	CacheMisses float64 `json:"cache_misses"`
+}
+
+// ErrorRate is the share of failed and slow requests of an endpoint class over the last 5 minutes.
+type ErrorRate struct {
+	Requests int     `json:"requests"`
+	Errors   float64 `json:"errors"`
+	Slow     float64 `json:"slow"`
+}
+
+// Overview is the state of the system at a point in time.
+type Overview struct {
+	Timestamp time.Time            `json:"timestamp"`
+	Nodes     []Node               `json:"nodes"`
+	Caches    Caches               `json:"caches"`
+	Uploads   Uploads              `json:"uploads"`
+	Queues    Queues               `json:"queues"`
+	Methods   map[string]Method    `json:"methods"`
+	Errors    map[string]ErrorRate `json:"errors"`
+}
+
+// Handler serves the system overview for SDK nodes of Router.
+type Handler struct {
+	Router *sdkrouter.Router
+}
+
+func (h Handler) nodes() []Node {
+	nodes := []Node{}
+	for _, s := range h.Router.Statuses() {
+		n := Node{
+			Name: s.Name, Address: s.Address, WalletsLoaded: s.WalletsLoaded, Latency: s.Latency.Seconds(),
+			Draining: s.Draining, Error: s.Error, Status: nodeUnknown,
+		}
+		if !s.CheckedAt.IsZero() {
+			checkedAt := s.CheckedAt
+			n.CheckedAt = &checkedAt
+			n.Status = nodeUnresponsive
+			if s.Healthy {
+				n.Status = nodeOK
+			} else if s.Responding {
+				n.Status = nodeSlow
+			}
+		}
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+func caches() Caches {
+	c := Caches{Queries: cache.Shared().Count()}
+	if cc := channels.Current(); cc != nil {
+		c.Channels = cc.Count()
+	}
+	return c
+}
+
+func uploads() Uploads {
+	return Uploads{Active: publish.ActiveUploads()}
+}
+
+func queues() Queues {
+	async := monitor.GetAsyncStats()
+	q := Queues{
+		Sentry:        async.QueueLength,
+		SentryDropped: int(async.Dropped),
+		Prefetch:      prefetch.Running(),
+		DBBreakerOpen: storage.Conn.BreakerOpen(),
+	}
+	if storage.Conn != nil && storage.Conn.DB != nil {
+		stats := storage.Conn.DB.Stats()
+		q.DBInUse = stats.InUse
+		q.DBIdle = stats.Idle
+		q.DBWaitCount = int(stats.WaitCount)
+	}
+	return q
+}
+
+func methods() map[string]Method {
+	stats := map[string]*Method{}
+	method := func(name string) *Method {
+		if stats[name] == nil {
+			stats[name] = &Method{}
+		}
+		return stats[name]
+	}
+	for name, n := range proxy.InFlight() {
+		method(name).InFlight = n
+	}
+	for name, v := range metrics.GetCounterValuesByLabel(metrics.ProxyE2ECallCounter, "method") {
+		method(name).Calls = v
+	}
+	for name, v := range metrics.GetCounterValuesByLabel(metrics.ProxyE2ECallFailedCounter, "method") {
+		method(name).Failures = v
+	}
+	for name, v := range metrics.GetCounterValuesByLabel(metrics.ProxyQueryCacheHitCount, "method") {
+		method(name).CacheHits = v
+	}
+	for name, v := range metrics.GetCounterValuesByLabel(metrics.ProxyQueryCacheMissCount, "method") {
+		method(name).CacheMisses = v
+	}
+
+	result := map[string]Method{}
+	for name, m := range stats {
+		// Calls rejected before the method is known are counted under an empty method name
+		if name != "" {
+			result[name] = *m
+		}
+	}
+	return result
+}
+
+func errorRates() map[string]ErrorRate {
+	rates := map[string]ErrorRate{}
+	for class, sli := range slo.SLIs(slo.Windows[errorWindow]) {
+		rates[class] = ErrorRate{Requests: sli.Total, Errors: 1 - sli.Availability, Slow: 1 - sli.Latency}
+	}
+	return rates
+}
+
+// Get collects the current system overview.
+func (h Handler) Get() Overview {
+	return Overview{
+		Timestamp: time.Now().UTC(),
+		Nodes:     h.nodes(),
+		Caches:    caches(),
+		Uploads:   uploads(),
+		Queues:    queues(),
+		Methods:   methods(),
+		Errors:    errorRates(),
+	}
+}
+
+// HandleOverview responds with the whole system overview.
+func (h Handler) HandleOverview(w http.ResponseWriter, r *http.Request) {
+	responses.WriteJSON(w, http.StatusOK, h.Get())
+}
+
+// HandleSection responds with a single section of the overview, for dashboard panels refreshed at different rates.
+func (h Handler) HandleSection(w http.ResponseWriter, r *http.Request) {
+	var section interface{}
+	switch mux.Vars(r)["section"] {
+	case "nodes":
+		section = h.nodes()
+	case "caches":
+		section = caches()
+	case "uploads":
+		section = uploads()
+	case "queues":
+		section = queues()
+	case "methods":
+		section = methods()
+	case "errors":
+		section = errorRates()
+	default:
+		responses.WriteError(w, http.StatusNotFound, errors.Err("unknown section, must be one of: nodes, caches, uploads, queues, methods, errors"))
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, section)
+}
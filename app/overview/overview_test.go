@@ -0,0 +1,88 @@
+package overview
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler{Router: sdkrouter.NewWithServers(
+		&models.LbrynetServer{Name: "b", Address: "http://b/"},
+		&models.LbrynetServer{Name: "a", Address: "http://a/"},
+	)}
+	r := mux.NewRouter()
+	r.HandleFunc("/overview", h.HandleOverview)
+	r.HandleFunc("/overview/{section}", h.HandleSection)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/overview", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var o Overview
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &o))
+	require.Len(t, o.Nodes, 2)
+	assert.Equal(t, "a", o.Nodes[0].Name)
+	assert.Equal(t, nodeUnknown, o.Nodes[0].Status)
+	assert.Contains(t, o.Errors, "read")
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/overview/uploads", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"active": 0}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/overview/whatever", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestFetchAndRender(t *testing.T) {
+	now := time.Now()
+	prev := &Overview{Timestamp: now, Methods: map[string]Method{
+		"resolve": {Calls: 100, CacheHits: 10, CacheMisses: 10},
+		"idle":    {Calls: 5},
+	}}
+	cur := &Overview{
+		Timestamp: now.Add(10 * time.Second),
+		Nodes:     []Node{{Name: "default", Status: nodeOK, WalletsLoaded: 3}},
+		Methods: map[string]Method{
+			"resolve":     {InFlight: 2, Calls: 150, Failures: 10, CacheHits: 40, CacheMisses: 20},
+			"wallet_send": {InFlight: 5, Calls: 1},
+			"idle":        {Calls: 5},
+		},
+		Errors: map[string]ErrorRate{"read": {Requests: 50, Errors: 0.02}},
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Lbrytv-Admin-Token") != "s3cret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(cur)
+	}))
+	defer ts.Close()
+	_, err := Fetch(ts.Client(), ts.URL, "wrong")
+	assert.Error(t, err)
+	fetched, err := Fetch(ts.Client(), ts.URL+"/", "s3cret")
+	require.NoError(t, err)
+
+	out := &bytes.Buffer{}
+	Render(out, prev, fetched)
+	text := out.String()
+	assert.Regexp(t, regexp.MustCompile(`default\s+ok\s+3`), text)
+	assert.Regexp(t, regexp.MustCompile(`resolve\s+2\s+5.00\s+1.00\s+75.0%`), text)
+	assert.Contains(t, text, "cache hit rate: 75.0%")
+	assert.Regexp(t, regexp.MustCompile(`read\s+50\s+2.00%`), text)
+	assert.NotRegexp(t, regexp.MustCompile(`(?m)^idle`), text, "methods without traffic are not shown")
+	assert.Less(t, bytes.Index(out.Bytes(), []byte("wallet_send")), bytes.Index(out.Bytes(), []byte("resolve")))
+}
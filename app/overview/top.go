@@ -0,0 +1,148 @@
+package overview
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lbryio/lbrytv/app/admin"
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+// maxMethods is how many of the busiest methods are shown by Render.
+const maxMethods = 20
+
+// Fetch gets the overview from the admin API of the lbrytv instance at baseURL.
+func Fetch(client *http.Client, baseURL, token string) (*Overview, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/v1/admin/overview", nil)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	req.Header.Set(admin.TokenHeader, token)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Err("admin API responded with %v", res.Status)
+	}
+	o := &Overview{}
+	if err := json.NewDecoder(res.Body).Decode(o); err != nil {
+		return nil, errors.Prefix("malformed overview", err)
+	}
+	return o, nil
+}
+
+type methodRow struct {
+	name     string
+	inFlight int
+	rate     float64
+	failRate float64
+	hitRate  float64
+	hasHits  bool
+}
+
+// hitRate returns the share of cache hits, false if there were no cacheable calls.
+func hitRate(hits, misses float64) (float64, bool) {
+	if hits+misses <= 0 {
+		return 0, false
+	}
+	return hits / (hits + misses), true
+}
+
+// Render writes a plain text view of cur. Throughput and cache hit rates are computed from changes since prev,
+// which can be nil, in which case totals since the instance started are shown instead.
+func Render(w io.Writer, prev, cur *Overview) {
+	elapsed := 0.0
+	if prev != nil {
+		elapsed = cur.Timestamp.Sub(prev.Timestamp).Seconds()
+	}
+
+	fmt.Fprintf(w, "%v\n", cur.Timestamp.Format("2006-01-02 15:04:05 MST"))
+	breaker := "closed"
+	if cur.Queues.DBBreakerOpen {
+		breaker = "OPEN"
+	}
+	fmt.Fprintf(w, "uploads: %v active    prefetch: %v running    sentry queue: %v (%v dropped)\n",
+		cur.Uploads.Active, cur.Queues.Prefetch, cur.Queues.Sentry, cur.Queues.SentryDropped)
+	fmt.Fprintf(w, "db: %v in use, %v idle, %v waits, breaker %v    cache: %v queries, %v channels\n\n",
+		cur.Queues.DBInUse, cur.Queues.DBIdle, cur.Queues.DBWaitCount, breaker, cur.Caches.Queries, cur.Caches.Channels)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tSTATUS\tWALLETS\tERROR")
+	for _, n := range cur.Nodes {
+		status := n.Status
+		if n.Draining {
+			status += ", draining"
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", n.Name, status, n.WalletsLoaded, n.Error)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	rows := []methodRow{}
+	var hits, misses float64
+	for name, m := range cur.Methods {
+		row := methodRow{name: name, inFlight: m.InFlight}
+		calls, failures, mHits, mMisses := m.Calls, m.Failures, m.CacheHits, m.CacheMisses
+		if elapsed > 0 {
+			p := prev.Methods[name]
+			calls, failures, mHits, mMisses = calls-p.Calls, failures-p.Failures, mHits-p.CacheHits, mMisses-p.CacheMisses
+			row.rate, row.failRate = calls/elapsed, failures/elapsed
+		}
+		row.hitRate, row.hasHits = hitRate(mHits, mMisses)
+		hits += mHits
+		misses += mMisses
+		if row.inFlight == 0 && calls <= 0 && elapsed > 0 {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].inFlight != rows[j].inFlight {
+			return rows[i].inFlight > rows[j].inFlight
+		}
+		if rows[i].rate != rows[j].rate {
+			return rows[i].rate > rows[j].rate
+		}
+		return rows[i].name < rows[j].name
+	})
+	if len(rows) > maxMethods {
+		rows = rows[:maxMethods]
+	}
+
+	overall := "-"
+	if rate, ok := hitRate(hits, misses); ok {
+		overall = fmt.Sprintf("%.1f%%", rate*100)
+	}
+	fmt.Fprintf(w, "cache hit rate: %v\n", overall)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tIN FLIGHT\tREQ/S\tFAIL/S\tCACHE HITS")
+	for _, r := range rows {
+		hit := "-"
+		if r.hasHits {
+			hit = fmt.Sprintf("%.1f%%", r.hitRate*100)
+		}
+		fmt.Fprintf(tw, "%v\t%v\t%.2f\t%.2f\t%v\n", r.name, r.inFlight, r.rate, r.failRate, hit)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+
+	classes := []string{}
+	for class := range cur.Errors {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLASS\tREQUESTS (5M)\tERRORS\tSLOW")
+	for _, class := range classes {
+		e := cur.Errors[class]
+		fmt.Fprintf(tw, "%v\t%v\t%.2f%%\t%.2f%%\n", class, e.Requests, e.Errors*100, e.Slow*100)
+	}
+	tw.Flush()
+}
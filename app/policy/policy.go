@@ -0,0 +1,254 @@
+// Package policy decides per method and user tier whether proxied calls are forwarded to the SDK,
+// rejected or rewritten, so restricting methods doesn't need code changes.
+//
+// Policies are read from a YAML file:
+//
+//	policies:
+//	  - methods: [account_*]
+//	    action: deny
+//	    message: accounts are managed by lbrytv
+//	  - methods: [wallet_send]
+//	    tiers: [anonymous]
+//	    action: deny
+//	  - methods: [txo_list]
+//	    action: rewrite
+//	    rewrite: {params: {page_size: 20}}
+//
+// The first policy matching the method and the tier of the caller decides, calls matched by none are forwarded.
+// Method patterns ending in * match methods starting with what comes before it. Policies without tiers apply
+// to all tiers. Rewrites replace the method and set params, they can't reach methods unknown to the proxy
+// nor change whether the call needs a wallet.
+package policy
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/spf13/viper"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	ActionAllow   = "allow"
+	ActionDeny    = "deny"
+	ActionRewrite = "rewrite"
+
+	// TierAnonymous is the tier of calls made without authentication.
+	TierAnonymous = "anonymous"
+	// TierUser is the tier of calls made by authenticated users.
+	TierUser = "user"
+)
+
+var logger = monitor.NewModuleLogger("policy")
+
+var tiers = []string{TierAnonymous, TierUser}
+
+// Policy decides about calls to Methods made by users of Tiers.
+type Policy struct {
+	Methods []string `mapstructure:"methods"`
+	Tiers   []string `mapstructure:"tiers"`
+	Action  string   `mapstructure:"action"`
+	// Message is returned to clients of denied calls.
+	Message string  `mapstructure:"message"`
+	Rewrite Rewrite `mapstructure:"rewrite"`
+}
+
+// Rewrite changes calls matched by a policy. Empty Method keeps the method called.
+type Rewrite struct {
+	Method string                 `mapstructure:"method"`
+	Params map[string]interface{} `mapstructure:"params"`
+}
+
+// Set is an ordered list of policies.
+type Set []Policy
+
+var (
+	currentMu sync.RWMutex
+	current   = Set{}
+)
+
+// NewSet validates policies.
+func NewSet(policies []Policy) (Set, error) {
+	for i, p := range policies {
+		if len(p.Methods) == 0 {
+			return nil, errors.Err("policy %v: methods are required", i+1)
+		}
+		for _, t := range p.Tiers {
+			if !contains(tiers, t) {
+				return nil, errors.Err("policy %v: unknown tier %v", i+1, t)
+			}
+		}
+		switch p.Action {
+		case ActionAllow, ActionDeny:
+		case ActionRewrite:
+			if p.Rewrite.Method == "" && len(p.Rewrite.Params) == 0 {
+				return nil, errors.Err("policy %v: rewrite changes nothing", i+1)
+			}
+			if p.Rewrite.Method != "" && !query.MethodAllowed(p.Rewrite.Method) {
+				return nil, errors.Err("policy %v: cannot rewrite to unknown method %v", i+1, p.Rewrite.Method)
+			}
+			if p.Rewrite.Method != "" {
+				for _, m := range query.KnownMethods() {
+					if p.matchesMethod(m) && query.MethodRequiresWallet(m, nil) != query.MethodRequiresWallet(p.Rewrite.Method, nil) {
+						return nil, errors.Err(
+							"policy %v: cannot rewrite %v to %v as only one of them needs a wallet", i+1, m, p.Rewrite.Method)
+					}
+				}
+			}
+		default:
+			return nil, errors.Err("policy %v: unknown action %v", i+1, p.Action)
+		}
+	}
+	return Set(policies), nil
+}
+
+// ReadFile loads policies from a YAML file.
+func ReadFile(path string) (Set, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Err(err)
+	}
+	var policies []Policy
+	if err := v.UnmarshalKey("policies", &policies); err != nil {
+		return nil, errors.Err(err)
+	}
+	return NewSet(policies)
+}
+
+// SetCurrent replaces policies applied to calls.
+func SetCurrent(s Set) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = s
+}
+
+// Load reads policies from path and starts applying them.
+func Load(path string) error {
+	s, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	SetCurrent(s)
+	return nil
+}
+
+// Watch reloads policies every interval when the file has been modified. Broken files are reported
+// and the policies loaded before are kept.
+func Watch(path string, interval time.Duration) {
+	var modTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		modTime = fi.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = fi.ModTime()
+		if err := Load(path); err != nil {
+			logger.Log().Errorf("error reloading method policies: %v", err)
+			monitor.ErrorToSentry(err)
+		} else {
+			logger.Log().Info("method policies reloaded")
+		}
+	}
+}
+
+// TierOf returns the tier of calls made by user, nil being an anonymous caller.
+func TierOf(user *models.User) string {
+	if user == nil {
+		return TierAnonymous
+	}
+	return TierUser
+}
+
+// Decide returns the current policy matching calls to method made by users of tier, nil if none does.
+func Decide(method, tier string) *Policy {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	for i, p := range current {
+		if p.matches(method, tier) {
+			return &current[i]
+		}
+	}
+	return nil
+}
+
+// Apply decides about req made by users of tier, rewriting it when the matching policy says so.
+// An RPC error to be returned to the client is returned for denied calls.
+func Apply(req *jsonrpc.RPCRequest, tier string) error {
+	method := req.Method
+	p := Decide(method, tier)
+	if p == nil {
+		return nil
+	}
+	label := method
+	if !query.MethodAllowed(method) {
+		label = "other"
+	}
+	metrics.MethodPolicyDecisions.WithLabelValues(label, p.Action).Inc()
+
+	switch p.Action {
+	case ActionDeny:
+		msg := p.Message
+		if msg == "" {
+			msg = "method is not allowed"
+		}
+		return rpcerrors.NewMethodNotAllowedError(errors.Err("%s", msg))
+	case ActionRewrite:
+		if p.Rewrite.Method != "" {
+			req.Method = p.Rewrite.Method
+		}
+		if len(p.Rewrite.Params) > 0 {
+			params, ok := req.Params.(map[string]interface{})
+			if !ok {
+				if req.Params != nil {
+					return rpcerrors.NewInvalidParamsError(errors.Err("params must be an object"))
+				}
+				params = map[string]interface{}{}
+			}
+			for k, v := range p.Rewrite.Params {
+				params[k] = v
+			}
+			req.Params = params
+		}
+		logger.Log().Debugf("rewrote %v call of %v tier to %v", method, tier, req.Method)
+	}
+	return nil
+}
+
+func (p Policy) matches(method, tier string) bool {
+	if len(p.Tiers) > 0 && !contains(p.Tiers, tier) {
+		return false
+	}
+	return p.matchesMethod(method)
+}
+
+func (p Policy) matchesMethod(method string) bool {
+	for _, m := range p.Methods {
+		if m == method || strings.HasSuffix(m, "*") && strings.HasPrefix(method, strings.TrimSuffix(m, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const policiesFile = `
+policies:
+  - methods: [account_*]
+    action: deny
+    message: accounts are managed by lbrytv
+  - methods: [wallet_send]
+    tiers: [anonymous]
+    action: deny
+  - methods: [wallet_send]
+    action: allow
+  - methods: [txo_list, wallet_*]
+    action: rewrite
+    rewrite: {params: {page_size: 20}}
+  - methods: [file_list]
+    tiers: [user]
+    action: rewrite
+    rewrite: {method: claim_list}
+`
+
+func writePolicies(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "policies")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "policies.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestNewSet_Invalid(t *testing.T) {
+	for _, p := range []Policy{
+		{Action: ActionDeny},
+		{Methods: []string{"resolve"}, Action: "block"},
+		{Methods: []string{"resolve"}, Tiers: []string{"premium"}, Action: ActionDeny},
+		{Methods: []string{"resolve"}, Action: ActionRewrite},
+		{Methods: []string{"resolve"}, Action: ActionRewrite, Rewrite: Rewrite{Method: "account_add"}},
+		// Rewrites can't change whether calls need a wallet
+		{Methods: []string{"resolve"}, Action: ActionRewrite, Rewrite: Rewrite{Method: "claim_list"}},
+		{Methods: []string{"claim_*"}, Tiers: []string{TierUser}, Action: ActionRewrite, Rewrite: Rewrite{Method: "txo_list"}},
+	} {
+		_, err := NewSet([]Policy{p})
+		assert.Error(t, err, p)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	path, cleanup := writePolicies(t, policiesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	assert.Equal(t, ActionDeny, Decide("account_add", TierUser).Action)
+	assert.Equal(t, ActionDeny, Decide("account_list", TierAnonymous).Action)
+	assert.Equal(t, ActionDeny, Decide("wallet_send", TierAnonymous).Action)
+	// The first matching policy decides
+	assert.Equal(t, ActionAllow, Decide("wallet_send", TierUser).Action)
+	assert.Equal(t, ActionRewrite, Decide("wallet_list", TierUser).Action)
+	assert.Nil(t, Decide("file_list", TierAnonymous))
+	assert.Nil(t, Decide("resolve", TierAnonymous))
+}
+
+func TestApply(t *testing.T) {
+	path, cleanup := writePolicies(t, policiesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	req := jsonrpc.NewRequest("account_add", map[string]interface{}{})
+	err := Apply(req, TierOf(&models.User{ID: 1}))
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32601, rpcErr.Code())
+	assert.Equal(t, "accounts are managed by lbrytv", rpcErr.Error())
+
+	err = Apply(jsonrpc.NewRequest("wallet_send"), TierOf(nil))
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, "method is not allowed", rpcErr.Error())
+
+	req = jsonrpc.NewRequest("txo_list", map[string]interface{}{"page_size": 500, "type": "stream"})
+	require.NoError(t, Apply(req, TierUser))
+	assert.Equal(t, map[string]interface{}{"page_size": 20, "type": "stream"}, req.Params)
+
+	req = jsonrpc.NewRequest("txo_list")
+	require.NoError(t, Apply(req, TierUser))
+	assert.Equal(t, map[string]interface{}{"page_size": 20}, req.Params)
+
+	req = jsonrpc.NewRequest("file_list", map[string]interface{}{"page": 2})
+	require.NoError(t, Apply(req, TierUser))
+	assert.Equal(t, "claim_list", req.Method)
+	assert.Equal(t, map[string]interface{}{"page": 2}, req.Params)
+
+	req = jsonrpc.NewRequest("resolve", map[string]interface{}{"urls": "what"})
+	require.NoError(t, Apply(req, TierAnonymous))
+	assert.Equal(t, "resolve", req.Method)
+}
+
+func TestLoad_KeepsPoliciesOnError(t *testing.T) {
+	path, cleanup := writePolicies(t, policiesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("policies:\n  - methods: [resolve]\n    action: block\n"), 0644))
+	assert.Error(t, Load(path))
+	assert.Equal(t, ActionDeny, Decide("account_add", TierUser).Action)
+}
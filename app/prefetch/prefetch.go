@@ -0,0 +1,210 @@
+// Package prefetch warms the query cache with claim_search calls the frontend is expected to make next.
+//
+// When a response of a configured method (usually resolve of a channel page) contains channel claims,
+// queries from the method's rule are sent asynchronously with the channel ID substituted,
+// so their results are cached by the time the client asks for them.
+package prefetch
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	hookName = "prefetch"
+
+	// ChannelIDPlaceholder is replaced with the channel claim ID in prefetch query params.
+	ChannelIDPlaceholder = "$channel_id"
+
+	defaultMaxChannels = 1
+	defaultTimeout     = 5 * time.Second
+
+	valueTypeChannel = "channel"
+)
+
+var (
+	logger = monitor.NewModuleLogger("prefetch")
+
+	// running is the number of prefetch queries currently in progress, capped by PrefetchMaxConcurrent.
+	running int32
+	// pending holds cache keys of queries being prefetched so concurrent resolves don't duplicate them.
+	pending sync.Map
+)
+
+// Running returns the number of prefetch queries currently in progress.
+func Running() int {
+	return int(atomic.LoadInt32(&running))
+}
+
+// InstallHooks adds a postflight hook to c for each method having a prefetch rule configured.
+func InstallHooks(c *query.Caller) {
+	for method := range config.GetPrefetchRules() {
+		c.AddPostflightHook(method, prefetchRelated, hookName)
+	}
+}
+
+// prefetchRelated finds channel claims in the response and starts prefetching queries for them.
+// It never modifies the response.
+func prefetchRelated(c *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if c.Cache == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	method := hctx.Query.Method()
+	rule, ok := config.GetPrefetchRules()[method]
+	if !ok || len(rule.Queries) == 0 {
+		return nil, nil
+	}
+	maxChannels := rule.MaxChannels
+	if maxChannels <= 0 {
+		maxChannels = defaultMaxChannels
+	}
+	timeout := rule.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	channelIDs := findChannels(hctx.Response.Result, maxChannels)
+	for _, id := range channelIDs {
+		for _, tpl := range rule.Queries {
+			params, err := fillParams(tpl, id)
+			if err != nil {
+				logger.Log().Errorf("invalid prefetch query for %v: %v", method, err)
+				continue
+			}
+			// Authenticated clients get wallet_id added to their claim_search params, which is a part of the cache key
+			if hctx.Query.IsAuthenticated() {
+				params[query.ParamWalletID] = hctx.Query.WalletID
+			}
+			go prefetch(c, method, params, timeout)
+		}
+	}
+	if len(channelIDs) > 0 {
+		hctx.AddLogField("prefetched_channels", len(channelIDs))
+	}
+	return nil, nil
+}
+
+// prefetch sends a claim_search query through a copy of c sharing its cache, SDK endpoint and wallet.
+func prefetch(c *query.Caller, method string, params map[string]interface{}, timeout time.Duration) {
+	log := logger.WithFields(logrus.Fields{"method": method, "params": params})
+
+	if c.Cache.Retrieve(query.MethodClaimSearch, params) != nil {
+		metrics.ProxyPrefetchCount.WithLabelValues(method, metrics.PrefetchCached).Inc()
+		return
+	}
+
+	key := c.Endpoint() + "|" + string(mustMarshal(params))
+	if _, loaded := pending.LoadOrStore(key, true); loaded {
+		metrics.ProxyPrefetchCount.WithLabelValues(method, metrics.PrefetchInFlight).Inc()
+		return
+	}
+	defer pending.Delete(key)
+
+	if atomic.AddInt32(&running, 1) > int32(config.GetPrefetchMaxConcurrent()) {
+		atomic.AddInt32(&running, -1)
+		metrics.ProxyPrefetchCount.WithLabelValues(method, metrics.PrefetchSkipped).Inc()
+		log.Debug("prefetch skipped, too many in progress")
+		return
+	}
+	defer atomic.AddInt32(&running, -1)
+
+	// The hook is dropped so a claim_search rule doesn't trigger prefetching from prefetched results
+	cc := c.CloneWithoutHook(c.Endpoint(), query.MethodClaimSearch, hookName)
+	cc.Cache = c.Cache
+	cc.Deadline = time.Now().Add(timeout)
+	res, err := cc.Call(jsonrpc.NewRequest(query.MethodClaimSearch, params))
+	if err != nil || res.Error != nil {
+		if err == nil {
+			err = res.Error
+		}
+		metrics.ProxyPrefetchCount.WithLabelValues(method, metrics.PrefetchFailed).Inc()
+		log.Warnf("prefetch failed: %v", err)
+		return
+	}
+	metrics.ProxyPrefetchCount.WithLabelValues(method, metrics.PrefetchWarmed).Inc()
+	log.Debug("prefetched")
+}
+
+// findChannels returns claim IDs of up to max channel claims found in a resolve result (keyed by URL)
+// or a claim_search result (with an `items` list).
+func findChannels(result interface{}, max int) []string {
+	var claims []interface{}
+	switch typed := result.(type) {
+	case map[string]interface{}:
+		if items, ok := typed["items"].([]interface{}); ok {
+			claims = items
+		} else {
+			for _, v := range typed {
+				claims = append(claims, v)
+			}
+		}
+	case []interface{}:
+		claims = typed
+	}
+
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, claim := range claims {
+		if len(ids) >= max {
+			break
+		}
+		m, ok := claim.(map[string]interface{})
+		if !ok || m["value_type"] != valueTypeChannel {
+			continue
+		}
+		id, ok := m["claim_id"].(string)
+		if !ok || id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// fillParams copies tpl replacing ChannelIDPlaceholder values with channelID.
+// Params are passed through JSON so they are typed exactly like ones coming from clients,
+// otherwise prefetched results would be cached under a different key.
+func fillParams(tpl map[string]interface{}, channelID string) (map[string]interface{}, error) {
+	b, err := json.Marshal(tpl)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(b, &params); err != nil {
+		return nil, err
+	}
+	for k, v := range params {
+		params[k] = substitute(v, channelID)
+	}
+	return params, nil
+}
+
+func substitute(v interface{}, channelID string) interface{} {
+	switch typed := v.(type) {
+	case string:
+		if typed == ChannelIDPlaceholder {
+			return channelID
+		}
+	case []interface{}:
+		for i := range typed {
+			typed[i] = substitute(typed[i], channelID)
+		}
+	}
+	return v
+}
+
+func mustMarshal(v interface{}) []byte {
+	b, _ := json.Marshal(v)
+	return b
+}
@@ -0,0 +1,131 @@
+package prefetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const channelID = "beef0000000000000000000000000000000000aa"
+
+var recentClaims = map[string]interface{}{
+	"channel_ids": []interface{}{ChannelIDPlaceholder},
+	"order_by":    []interface{}{"release_time"},
+	"page":        1,
+	"page_size":   20,
+}
+
+// sdk responds to resolve with a channel claim and to claim_search with an empty page, counting claim_search calls.
+func sdk(t *testing.T, claimSearches *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		var result interface{}
+		switch req.Method {
+		case query.MethodResolve:
+			result = map[string]interface{}{
+				"lbry://@channel": map[string]interface{}{"claim_id": channelID, "value_type": "channel"},
+			}
+		case query.MethodClaimSearch:
+			atomic.AddInt32(claimSearches, 1)
+			result = map[string]interface{}{"items": []interface{}{}, "page": 1, "page_size": 20}
+		}
+		b, err := json.Marshal(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result})
+		require.NoError(t, err)
+		fmt.Fprint(w, string(b))
+	}))
+}
+
+func TestPrefetch(t *testing.T) {
+	var claimSearches int32
+	srv := sdk(t, &claimSearches)
+	defer srv.Close()
+
+	config.Override("Prefetch", map[string]interface{}{
+		query.MethodResolve: map[string]interface{}{"Queries": []map[string]interface{}{recentClaims}},
+	})
+	defer config.RestoreOverridden()
+
+	qCache := cache.NewMemoryCache()
+	c := query.NewCaller(srv.URL, 0)
+	c.Cache = qCache
+	InstallHooks(c)
+
+	res, err := c.Call(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"@channel"}}))
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+
+	// Params as the frontend would send them
+	var params map[string]interface{}
+	require.NoError(t, json.Unmarshal(
+		[]byte(`{"channel_ids": ["`+channelID+`"], "order_by": ["release_time"], "page": 1, "page_size": 20}`), &params))
+	assert.Eventually(t, func() bool {
+		return qCache.Retrieve(query.MethodClaimSearch, params) != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// Already cached queries are not sent again
+	res, err = c.Call(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"@channel"}}))
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&claimSearches))
+}
+
+func TestPrefetch_OverBudget(t *testing.T) {
+	var claimSearches int32
+	srv := sdk(t, &claimSearches)
+	defer srv.Close()
+
+	config.Override("Prefetch", map[string]interface{}{
+		query.MethodResolve: map[string]interface{}{"Queries": []map[string]interface{}{recentClaims}},
+	})
+	config.Override("PrefetchMaxConcurrent", 0)
+	defer config.RestoreOverridden()
+
+	c := query.NewCaller(srv.URL, 0)
+	c.Cache = cache.NewMemoryCache()
+	InstallHooks(c)
+
+	_, err := c.Call(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"@channel"}}))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 0, atomic.LoadInt32(&claimSearches))
+}
+
+func TestFindChannels(t *testing.T) {
+	result := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"claim_id": "a", "value_type": "stream"},
+			map[string]interface{}{"claim_id": "b", "value_type": "channel"},
+			map[string]interface{}{"claim_id": "b", "value_type": "channel"},
+			map[string]interface{}{"claim_id": "c", "value_type": "channel"},
+			map[string]interface{}{"claim_id": "d", "value_type": "channel"},
+		},
+	}
+	assert.Equal(t, []string{"b", "c"}, findChannels(result, 2))
+	assert.Empty(t, findChannels(map[string]interface{}{"lbry://what": map[string]interface{}{"error": "not found"}}, 2))
+}
+
+func TestFillParams(t *testing.T) {
+	params, err := fillParams(recentClaims, channelID)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{channelID}, params["channel_ids"])
+	assert.Equal(t, float64(20), params["page_size"])
+	assert.Equal(t, []interface{}{ChannelIDPlaceholder}, recentClaims["channel_ids"], "template must not be modified")
+}
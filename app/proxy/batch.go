@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/recovery"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// Clients can send several calls in one JSON-RPC 2.0 batch array. Each call is handled like a separate request,
+// with its own auth, limits and metrics, by a bounded number of workers, and the batch response lists responses
+// in the order of calls, leaving out notifications. Failures of single calls are reported in their responses,
+// the batch itself succeeds.
+
+// isBatch returns true if the request body is a JSON array.
+func isBatch(body []byte) bool {
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// bufferedResponse collects the response to a single call out of a batch.
+type bufferedResponse struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header         { return b.header }
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *bufferedResponse) WriteHeader(int)             {}
+
+// handleBatch handles calls in the batch array body concurrently and writes the array of their responses.
+func handleBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var calls []json.RawMessage
+	if err := json.Unmarshal(body, &calls); err != nil {
+		writeResponse(w, rpcerrors.NewJSONParseError(err).JSON())
+		observeFailure(metrics.GetDuration(r), "", metrics.FailureKindClientJSON)
+		return
+	}
+	cfg := config.GetBatchRequests()
+	if len(calls) == 0 {
+		writeResponse(w, rpcerrors.NewInvalidParamsError(errors.Err("empty batch")).JSON())
+		observeFailure(metrics.GetDuration(r), "", metrics.FailureKindClient)
+		return
+	}
+	if cfg.MaxSize > 0 && len(calls) > cfg.MaxSize {
+		writeResponse(w, rpcerrors.NewInvalidParamsError(fmt.Errorf("batch cannot contain more than %v calls", cfg.MaxSize)).JSON())
+		observeFailure(metrics.GetDuration(r), "", metrics.FailureKindClient)
+		return
+	}
+	metrics.ProxyBatchSize.Observe(float64(len(calls)))
+
+	workers := cfg.Workers
+	if workers <= 0 || workers > len(calls) {
+		workers = len(calls)
+	}
+	reqID := w.Header().Get(recovery.RequestIDHeader)
+	results := make([]*bufferedResponse, len(calls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				results[n] = handleBatchCall(r, reqID, calls[n])
+			}
+		}()
+	}
+	for n := range calls {
+		next <- n
+	}
+	close(next)
+	wg.Wait()
+
+	elements := [][]byte{}
+	for n, res := range results {
+		if isNotification(calls[n]) {
+			continue
+		}
+		body := bytes.TrimSpace(res.body.Bytes())
+		if len(body) == 0 {
+			// The client went away before the call was answered
+			body = callError(calls[n], rpcerrors.NewInternalError(errors.Err("call was not answered")))
+		}
+		elements = append(elements, body)
+	}
+	if len(elements) == 0 {
+		// Batches of notifications only get no response
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	responses.AddJSONContentType(w)
+	w.Write([]byte("["))
+	w.Write(bytes.Join(elements, []byte(",")))
+	w.Write([]byte("]"))
+}
+
+// isNotification returns true for calls without an id, which JSON-RPC 2.0 doesn't respond to.
+func isNotification(call json.RawMessage) bool {
+	head, err := query.PreParse(call)
+	return err == nil && head.Method != nil && head.ID == nil
+}
+
+// callError returns the error response to call carrying its id.
+func callError(call json.RawMessage, rpcErr rpcerrors.RPCError) []byte {
+	var id int
+	if head, err := query.PreParse(call); err == nil && head.ID != nil {
+		json.Unmarshal(head.ID, &id)
+	}
+	b, _ := json.Marshal(jsonrpc.RPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &jsonrpc.RPCError{Code: rpcErr.Code(), Message: rpcErr.Error()},
+	})
+	return b
+}
+
+// handleBatchCall handles a single call out of a batch as if it was sent on its own.
+// Panics are answered with an internal error to the call, the recovery middleware doesn't reach worker goroutines.
+func handleBatchCall(r *http.Request, reqID string, call json.RawMessage) (res *bufferedResponse) {
+	res = &bufferedResponse{header: http.Header{}}
+	defer func() {
+		if p := recover(); p != nil {
+			res.body.Reset()
+			res.Write(recovery.Recover(r, reqID, p, call))
+		}
+	}()
+	if isBatch(call) {
+		res.Write(rpcerrors.NewJSONParseError(errors.Err("batches cannot be nested")).JSON())
+		return res
+	}
+	cr := r.Clone(r.Context())
+	cr.Body = ioutil.NopCloser(bytes.NewReader(call))
+	cr.ContentLength = int64(len(call))
+	Handle(res, cr)
+	return res
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+const (
+	// ClientHeader names the app making the request. Deadlines are only honored for apps listed in the config.
+	ClientHeader = "X-Lbrytv-Client"
+	// DeadlineHeader is the time at which the client gives up on the request, in Unix milliseconds.
+	DeadlineHeader = "X-Request-Deadline"
+	// TimeoutHeader is how long the client waits for the response in milliseconds,
+	// for clients whose clocks can't be relied on. DeadlineHeader takes precedence.
+	TimeoutHeader = "X-Request-Timeout"
+)
+
+// Deadline returns the time by which a call to method has to complete according to its latency budget,
+// or zero time if neither the method nor its class have a budget configured.
+// Time the request has already spent in the proxy counts against the budget.
+// When a trusted client sends its own deadline which is earlier, that one is returned instead.
+func Deadline(r *http.Request, method, class string) time.Time {
+	var deadline time.Time
+	budgets := config.GetLatencyBudgets()
+	budget, ok := budgets[method]
+	if !ok {
+		budget = budgets[class]
+	}
+	if budget > 0 {
+		if elapsed := metrics.GetDuration(r); elapsed > 0 {
+			budget -= time.Duration(elapsed * float64(time.Second))
+		}
+		deadline = time.Now().Add(budget)
+	}
+	if cd := clientDeadline(r); !cd.IsZero() && (deadline.IsZero() || cd.Before(deadline)) {
+		deadline = cd
+	}
+	return deadline
+}
+
+// clientDeadline returns the deadline sent by the client, less the configured margin for getting the response
+// back to it, or zero time if the client is not trusted or didn't send one.
+func clientDeadline(r *http.Request) time.Time {
+	cfg := config.GetClientDeadlines()
+	client := r.Header.Get(ClientHeader)
+	trusted := false
+	for _, c := range cfg.Clients {
+		if c == client {
+			trusted = true
+		}
+	}
+	if client == "" || !trusted {
+		return time.Time{}
+	}
+
+	var deadline time.Time
+	if v := r.Header.Get(DeadlineHeader); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms <= 0 {
+			metrics.ClientDeadlines.WithLabelValues(client, metrics.ClientDeadlineInvalid).Inc()
+			return time.Time{}
+		}
+		deadline = time.Unix(0, ms*int64(time.Millisecond))
+	} else if v := r.Header.Get(TimeoutHeader); v != "" {
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || ms <= 0 {
+			metrics.ClientDeadlines.WithLabelValues(client, metrics.ClientDeadlineInvalid).Inc()
+			return time.Time{}
+		}
+		// The timeout started when the request was sent, which is the closest we know to when it was received
+		received := time.Now()
+		if elapsed := metrics.GetDuration(r); elapsed > 0 {
+			received = received.Add(-time.Duration(elapsed * float64(time.Second)))
+		}
+		deadline = received.Add(time.Duration(ms) * time.Millisecond)
+	} else {
+		return time.Time{}
+	}
+
+	deadline = deadline.Add(-cfg.Margin)
+	if !time.Now().Before(deadline) {
+		// The call will be rejected without reaching the SDK
+		metrics.ClientDeadlines.WithLabelValues(client, metrics.ClientDeadlineExpired).Inc()
+	} else {
+		metrics.ClientDeadlines.WithLabelValues(client, metrics.ClientDeadlineApplied).Inc()
+	}
+	return deadline
+}
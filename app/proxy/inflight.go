@@ -0,0 +1,33 @@
+package proxy
+
+import "sync"
+
+var (
+	inFlightMu sync.Mutex
+	inFlight   = map[string]int{}
+)
+
+// trackInFlight counts a request for method as being handled until the returned function is called.
+func trackInFlight(method string) func() {
+	inFlightMu.Lock()
+	inFlight[method]++
+	inFlightMu.Unlock()
+	return func() {
+		inFlightMu.Lock()
+		defer inFlightMu.Unlock()
+		if inFlight[method]--; inFlight[method] == 0 {
+			delete(inFlight, method)
+		}
+	}
+}
+
+// InFlight returns the number of requests currently being handled for each SDK method.
+func InFlight() map[string]int {
+	inFlightMu.Lock()
+	defer inFlightMu.Unlock()
+	counts := make(map[string]int, len(inFlight))
+	for m, n := range inFlight {
+		counts[m] = n
+	}
+	return counts
+}
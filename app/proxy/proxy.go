@@ -13,19 +13,37 @@ import (
 	"net/http"
 
 	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/balance"
+	"github.com/lbryio/lbrytv/app/cdnpurge"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/fraud"
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/policy"
+	"github.com/lbryio/lbrytv/app/prefetch"
+	"github.com/lbryio/lbrytv/app/published"
 	"github.com/lbryio/lbrytv/app/query"
 	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/recommendations"
 	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/rules"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/torrent"
+	"github.com/lbryio/lbrytv/app/urlfilter"
+	"github.com/lbryio/lbrytv/app/userblock"
+	"github.com/lbryio/lbrytv/app/usertrace"
+	"github.com/lbryio/lbrytv/app/verification"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/internal/audit"
+	"github.com/lbryio/lbrytv/internal/bufpool"
 	"github.com/lbryio/lbrytv/internal/errors"
 	"github.com/lbryio/lbrytv/internal/ip"
 	"github.com/lbryio/lbrytv/internal/lbrynext"
 	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
 	"github.com/lbryio/lbrytv/internal/responses"
-	"github.com/lbryio/lbrytv/models"
+	"github.com/lbryio/lbrytv/internal/slo"
+	"github.com/lbryio/lbrytv/internal/spill"
+	"github.com/lbryio/lbrytv/internal/telemetry"
 	"github.com/sirupsen/logrus"
 
 	"github.com/ybbus/jsonrpc"
@@ -35,22 +53,41 @@ var logger = monitor.NewModuleLogger("proxy")
 
 // observeFailure requires metrics.MeasureMiddleware middleware to be present on the request
 func observeFailure(d float64, method, kind string) {
+	slo.Observe(sloClass(method), d, kind)
 	metrics.ProxyE2ECallDurations.WithLabelValues(method).Observe(d)
 	metrics.ProxyE2ECallFailedDurations.WithLabelValues(method, kind).Observe(d)
 	metrics.ProxyE2ECallCounter.WithLabelValues(method).Inc()
 	metrics.ProxyE2ECallFailedCounter.WithLabelValues(method, kind).Inc()
+	observeUsage(d, method, true)
 }
 
 // observeSuccess requires metrics.MeasureMiddleware middleware to be present on the request
 func observeSuccess(d float64, method string) {
+	slo.Observe(sloClass(method), d, "")
 	metrics.ProxyE2ECallDurations.WithLabelValues(method).Observe(d)
 	metrics.ProxyE2ECallCounter.WithLabelValues(method).Inc()
+	observeUsage(d, method, false)
+}
+
+// observeUsage reports calls to telemetry, leaving out ones to unknown methods as they could be anything the client sent.
+func observeUsage(d float64, method string, failed bool) {
+	if query.MethodAllowed(method) {
+		telemetry.Observe(method, d, failed)
+	}
 }
 
 func writeResponse(w http.ResponseWriter, b []byte) {
 	w.Write(b)
 }
 
+// sloClass returns the SLO endpoint class for the SDK method.
+func sloClass(method string) string {
+	if query.MethodRequiresWallet(method, nil) {
+		return slo.ClassWallet
+	}
+	return slo.ClassRead
+}
+
 // Handle forwards client JSON-RPC request to proxy.
 func Handle(w http.ResponseWriter, r *http.Request) {
 	responses.AddJSONContentType(w)
@@ -75,6 +112,20 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if isBatch(body) {
+		handleBatch(w, r, body)
+		return
+	}
+
+	// Rejecting forbidden methods before the full parse. Malformed bodies are left for json.Unmarshal
+	// below to report as it produces more detailed errors. Method name is not used as a metric label
+	// as it's arbitrary client input at this point.
+	if head, err := query.PreParse(body); err == nil && !query.MethodAllowed(string(head.Method)) {
+		writeResponse(w, rpcerrors.NewMethodNotAllowedError(errors.Err("forbidden method")).JSON())
+		observeFailure(metrics.GetDuration(r), "", metrics.FailureKindClient)
+		return
+	}
+
 	var rpcReq *jsonrpc.RPCRequest
 	err = json.Unmarshal(body, &rpcReq)
 	if err != nil {
@@ -87,10 +138,18 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Log().Tracef("call to method %s", rpcReq.Method)
+	defer trackInFlight(rpcReq.Method)()
 
 	user, err := auth.FromRequest(r)
+	// Policies go first so authentication is checked for the method actually called
+	if err := policy.Apply(rpcReq, policy.TierOf(user)); err != nil {
+		writeResponse(w, rpcerrors.ErrorToJSON(err))
+		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindClient)
+		return
+	}
+
 	if query.MethodRequiresWallet(rpcReq.Method, rpcReq.Params) {
-		authErr := GetAuthError(user, err)
+		authErr := auth.Check(user, err)
 		if authErr != nil {
 			writeResponse(w, rpcerrors.ErrorToJSON(authErr))
 			observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindAuth)
@@ -107,7 +166,13 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 	sdkAddress := sdkrouter.GetSDKAddress(user)
 	if sdkAddress == "" {
 		rt := sdkrouter.FromRequest(r)
-		sdkAddress = rt.RandomServer().Address
+		sdkAddress = rt.ServerFor(rpcReq.Method).Address
+	} else if userID == 0 && sdkrouter.RequiredVersion(rpcReq.Method) != "" {
+		// Calls not using the wallet don't have to go to the server it's loaded on
+		rt := sdkrouter.FromRequest(r)
+		if !rt.Supports(sdkAddress, rpcReq.Method) {
+			sdkAddress = rt.ServerFor(rpcReq.Method).Address
+		}
 	}
 
 	var qCache cache.QueryCache
@@ -128,10 +193,53 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 	}, "")
 
 	lbrynext.InstallHooks(c)
+	prefetch.InstallHooks(c)
+	channels.InstallHooks(c)
+	urlfilter.InstallHooks(c)
+	published.InstallHooks(c)
+	recommendations.InstallHooks(c)
+	moderation.InstallHooks(c)
+	verification.InstallHooks(c)
+	torrent.InstallHooks(c)
+	cdnpurge.InstallHooks(c)
+	rules.InstallHooks(c)
+	balance.InstallHooks(c)
+	fraud.InstallHooks(c, remoteIP)
 	c.Cache = qCache
+	c.UserCache = cache.User()
+	c.Deadline = Deadline(r, rpcReq.Method, sloClass(rpcReq.Method))
+	c.Context = r.Context()
+	if userID == 0 {
+		c.ResolveServers = sdkrouter.FromRequest(r).AvailableAddresses
+	}
 
 	rpcRes, err := c.Call(rpcReq)
+	if user != nil {
+		usertrace.Record(user.ID, rpcReq, rpcRes, err)
+		// Done here rather than in a hook so filtered results don't end up in the shared cache
+		if err == nil && rpcReq.Method == query.MethodClaimSearch {
+			userblock.Filter(user.ID, rpcRes)
+		}
+	}
 
+	if errors.Is(err, query.ErrLatencyBudgetExceeded) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		writeResponse(w, rpcerrors.ToJSON(err))
+		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindTimeout)
+		return
+	}
+	if err != nil && r.Context().Err() != nil {
+		// Nobody is waiting for the response
+		logger.Log().Debugf("client went away during %v call: %v", rpcReq.Method, err)
+		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindClient)
+		return
+	}
+	if errors.Is(err, query.ErrSDKUnavailable) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		writeResponse(w, rpcerrors.ToJSON(err))
+		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindUnavailable)
+		return
+	}
 	if err != nil {
 		monitor.ErrorToSentry(err, map[string]string{"request": fmt.Sprintf("%+v", rpcReq), "response": fmt.Sprintf("%+v", rpcRes)})
 		writeResponse(w, rpcerrors.ToJSON(err))
@@ -142,7 +250,20 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	serialized, err := responses.JSONRPCSerialize(rpcRes)
+	serialized := bufpool.GetBuffer(0)
+	defer bufpool.PutBuffer(serialized)
+	sw := spill.NewWriter(serialized)
+	defer sw.Close()
+	err = responses.JSONRPCSerializeStream(sw, rpcRes)
+	// The decoded result isn't needed once serialized, so it can be collected while the response is being sent
+	rpcErr := rpcRes.Error
+	rpcRes = nil
+	if errors.Is(err, spill.ErrTooManySpills) {
+		writeResponse(w, rpcerrors.NewResponseTooLargeError(err).JSON())
+		logger.Log().Warnf("rejected large response for %v: %v", rpcReq.Method, err)
+		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindInternal)
+		return
+	}
 	if err != nil {
 		monitor.ErrorToSentry(err)
 
@@ -154,18 +275,20 @@ func Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if rpcRes.Error != nil {
+	if rpcErr != nil {
 		observeFailure(metrics.GetDuration(r), rpcReq.Method, metrics.FailureKindRPC)
 		logger.WithFields(logrus.Fields{
 			"method":   rpcReq.Method,
 			"endpoint": sdkAddress,
-			"response": rpcRes.Error,
-		}).Errorf("proxy handler got rpc error: %v", rpcRes.Error)
+			"response": rpcErr,
+		}).Errorf("proxy handler got rpc error: %v", rpcErr)
 	} else {
 		observeSuccess(metrics.GetDuration(r), rpcReq.Method)
 	}
 
-	writeResponse(w, serialized)
+	if _, err := sw.WriteTo(w); err != nil {
+		logger.Log().Errorf("error writing response: %v", err)
+	}
 }
 
 // HandleCORS returns necessary CORS headers for pre-flight requests to proxy API
@@ -173,22 +296,7 @@ func HandleCORS(w http.ResponseWriter, r *http.Request) {
 	hs := w.Header()
 	hs.Set("Access-Control-Max-Age", "7200")
 	hs.Set("Access-Control-Allow-Origin", "*")
-	hs.Set("Access-Control-Allow-Headers", wallet.TokenHeader+", Origin, X-Requested-With, Content-Type, Accept")
+	hs.Set("Access-Control-Allow-Headers", wallet.TokenHeader+", "+ClientHeader+", "+DeadlineHeader+", "+TimeoutHeader+
+		", Origin, X-Requested-With, Content-Type, Accept")
 	w.WriteHeader(http.StatusOK)
 }
-
-func GetAuthError(user *models.User, err error) error {
-	if err == nil && user != nil {
-		return nil
-	}
-
-	if errors.Is(err, auth.ErrNoAuthInfo) {
-		return rpcerrors.NewAuthRequiredError()
-	} else if err != nil {
-		return rpcerrors.NewForbiddenError(err)
-	} else if user == nil {
-		return rpcerrors.NewForbiddenError(errors.Err("must authenticate"))
-	}
-
-	return errors.Err("unknown auth error")
-}
@@ -3,11 +3,14 @@ package proxy
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/policy"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/apps/lbrytv/config"
@@ -89,3 +92,275 @@ func TestProxyDontAuthRelaxedMethods(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 0, apiCalls)
 }
+
+func TestProxyLatencyBudget(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+	config.Override("LbrynetServers", map[string]string{"a": ts.URL})
+	config.Override("LatencyBudgets", map[string]time.Duration{"read": 100 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	raw, err := json.Marshal(jsonrpc.NewRequest("resolve", map[string]string{"urls": "what"}))
+	require.NoError(t, err)
+	r, err := http.NewRequest("POST", "", bytes.NewBuffer(raw))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	handler := middleware.Apply(
+		middleware.Chain(
+			sdkrouter.Middleware(rt),
+			auth.NilMiddleware,
+		), Handle)
+	handler.ServeHTTP(rr, r)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	var parsedResponse jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponse))
+	require.NotNil(t, parsedResponse.Error)
+	assert.Equal(t, "latency budget exceeded", parsedResponse.Error.Message)
+}
+
+func TestDeadline(t *testing.T) {
+	config.Override("LatencyBudgets", map[string]time.Duration{"read": time.Second, "claim_search": time.Minute})
+	defer config.RestoreOverridden()
+
+	r, err := http.NewRequest("POST", "", nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Second), Deadline(r, "resolve", "read"), 100*time.Millisecond)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), Deadline(r, "claim_search", "read"), 100*time.Millisecond)
+	assert.True(t, Deadline(r, "wallet_balance", "wallet").IsZero())
+}
+
+func TestDeadline_Client(t *testing.T) {
+	config.Override("LatencyBudgets", map[string]time.Duration{"read": time.Second})
+	config.Override("ClientDeadlines", map[string]interface{}{"Clients": []string{"android"}, "Margin": 100 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	request := func(client string, headers map[string]string) *http.Request {
+		r, err := http.NewRequest("POST", "", nil)
+		require.NoError(t, err)
+		r.Header.Set(ClientHeader, client)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+		return r
+	}
+	unixMs := func(t time.Time) string { return fmt.Sprintf("%d", t.UnixNano()/int64(time.Millisecond)) }
+
+	r := request("android", map[string]string{TimeoutHeader: "500"})
+	assert.WithinDuration(t, time.Now().Add(400*time.Millisecond), Deadline(r, "resolve", "read"), 50*time.Millisecond)
+	assert.WithinDuration(t, time.Now().Add(400*time.Millisecond), Deadline(r, "wallet_balance", "wallet"), 50*time.Millisecond)
+
+	r = request("android", map[string]string{DeadlineHeader: unixMs(time.Now().Add(300 * time.Millisecond)), TimeoutHeader: "500"})
+	assert.WithinDuration(t, time.Now().Add(200*time.Millisecond), Deadline(r, "resolve", "read"), 50*time.Millisecond)
+
+	// Latency budget is kept when it's earlier
+	r = request("android", map[string]string{TimeoutHeader: "60000"})
+	assert.WithinDuration(t, time.Now().Add(time.Second), Deadline(r, "resolve", "read"), 50*time.Millisecond)
+
+	for _, r := range []*http.Request{
+		request("web", map[string]string{TimeoutHeader: "500"}),
+		request("", map[string]string{TimeoutHeader: "500"}),
+		request("android", map[string]string{TimeoutHeader: "soon"}),
+		request("android", nil),
+	} {
+		assert.True(t, Deadline(r, "wallet_balance", "wallet").IsZero())
+	}
+}
+
+func TestProxyClientDeadlineExpired(t *testing.T) {
+	sdkCalls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sdkCalls++
+	}))
+	defer ts.Close()
+	config.Override("LbrynetServers", map[string]string{"a": ts.URL})
+	config.Override("ClientDeadlines", map[string]interface{}{"Clients": []string{"android"}})
+	defer config.RestoreOverridden()
+
+	raw, err := json.Marshal(jsonrpc.NewRequest("resolve", map[string]string{"urls": "what"}))
+	require.NoError(t, err)
+	r, err := http.NewRequest("POST", "", bytes.NewBuffer(raw))
+	require.NoError(t, err)
+	r.Header.Set(ClientHeader, "android")
+	r.Header.Set(DeadlineHeader, fmt.Sprintf("%d", time.Now().Add(-time.Second).UnixNano()/int64(time.Millisecond)))
+
+	rr := httptest.NewRecorder()
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	handler := middleware.Apply(
+		middleware.Chain(
+			sdkrouter.Middleware(rt),
+			auth.NilMiddleware,
+		), Handle)
+	handler.ServeHTTP(rr, r)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rr.Code)
+	assert.Equal(t, 0, sdkCalls)
+}
+
+func TestProxyBatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Params})
+	}))
+	defer ts.Close()
+	config.Override("LbrynetServers", map[string]string{"a": ts.URL})
+	defer config.RestoreOverridden()
+
+	body := `[
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}, "id": 1},
+		{"jsonrpc": "2.0", "method": "account_add", "params": {}, "id": 2},
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "three"}, "id": 3}
+	]`
+	r, err := http.NewRequest("POST", "", bytes.NewBufferString(body))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	handler := middleware.Apply(
+		middleware.Chain(
+			sdkrouter.Middleware(rt),
+			auth.NilMiddleware,
+		), Handle)
+	handler.ServeHTTP(rr, r)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var parsedResponses []jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponses))
+	require.Len(t, parsedResponses, 3)
+	assert.Equal(t, 1, parsedResponses[0].ID)
+	assert.Equal(t, "one", parsedResponses[0].Result.(map[string]interface{})["urls"])
+	require.NotNil(t, parsedResponses[1].Error)
+	assert.Equal(t, "forbidden method", parsedResponses[1].Error.Message)
+	assert.Equal(t, 3, parsedResponses[2].ID)
+	assert.Equal(t, "three", parsedResponses[2].Result.(map[string]interface{})["urls"])
+}
+
+func TestProxyBatchNotifications(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Params})
+	}))
+	defer ts.Close()
+	config.Override("LbrynetServers", map[string]string{"a": ts.URL})
+	defer config.RestoreOverridden()
+
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	handler := middleware.Apply(middleware.Chain(sdkrouter.Middleware(rt), auth.NilMiddleware), Handle)
+
+	body := `[
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}},
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "two"}, "id": 2}
+	]`
+	r, err := http.NewRequest("POST", "", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	var parsedResponses []jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponses))
+	require.Len(t, parsedResponses, 1)
+	assert.Equal(t, 2, parsedResponses[0].ID)
+
+	body = `[{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}}]`
+	r, err = http.NewRequest("POST", "", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Body.Bytes())
+}
+
+func TestProxyBatchRecoversPanics(t *testing.T) {
+	body := `[
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}, "id": 1},
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "two"}, "id": 2}
+	]`
+	r, err := http.NewRequest("POST", "", bytes.NewBufferString(body))
+	require.NoError(t, err)
+
+	// Calls panic without the SDK router on the request
+	rr := httptest.NewRecorder()
+	assert.NotPanics(t, func() { middleware.Apply(auth.NilMiddleware, Handle).ServeHTTP(rr, r) })
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var parsedResponses []jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponses))
+	require.Len(t, parsedResponses, 2)
+	for n, res := range parsedResponses {
+		assert.Equal(t, n+1, res.ID)
+		require.NotNil(t, res.Error)
+		assert.Equal(t, -32080, res.Error.Code)
+		assert.Contains(t, res.Error.Message, "internal server error")
+	}
+}
+
+func TestProxyBatchLimits(t *testing.T) {
+	config.Override("BatchRequests", map[string]interface{}{"MaxSize": 2, "Workers": 1})
+	defer config.RestoreOverridden()
+
+	for body, message := range map[string]string{
+		`[]`: "empty batch",
+		`[{"method": "status"}, {"method": "status"}, {"method": "status"}]`: "batch cannot contain more than 2 calls",
+	} {
+		r, err := http.NewRequest("POST", "", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		rr := httptest.NewRecorder()
+		rt := sdkrouter.New(config.GetLbrynetServers())
+		sdkrouter.Middleware(rt)(http.HandlerFunc(Handle)).ServeHTTP(rr, r)
+
+		var parsedResponse jsonrpc.RPCResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponse))
+		require.NotNil(t, parsedResponse.Error)
+		assert.Equal(t, message, parsedResponse.Error.Message)
+	}
+}
+
+func TestProxyMethodPolicies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Params})
+	}))
+	defer ts.Close()
+	config.Override("LbrynetServers", map[string]string{"a": ts.URL})
+	defer config.RestoreOverridden()
+
+	set, err := policy.NewSet([]policy.Policy{
+		{Methods: []string{"claim_search"}, Tiers: []string{policy.TierAnonymous}, Action: policy.ActionDeny},
+		{Methods: []string{"resolve"}, Action: policy.ActionRewrite, Rewrite: policy.Rewrite{
+			Params: map[string]interface{}{"include_purchase_receipt": false}}},
+	})
+	require.NoError(t, err)
+	policy.SetCurrent(set)
+	defer policy.SetCurrent(policy.Set{})
+
+	body := `[
+		{"jsonrpc": "2.0", "method": "claim_search", "params": {}, "id": 1},
+		{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}, "id": 2}
+	]`
+	r, err := http.NewRequest("POST", "", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	middleware.Apply(middleware.Chain(sdkrouter.Middleware(rt), auth.NilMiddleware), Handle).ServeHTTP(rr, r)
+
+	var parsedResponses []jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &parsedResponses))
+	require.Len(t, parsedResponses, 2)
+	require.NotNil(t, parsedResponses[0].Error)
+	assert.Equal(t, -32601, parsedResponses[0].Error.Code)
+	assert.Equal(t, false, parsedResponses[1].Result.(map[string]interface{})["include_purchase_receipt"])
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/recovery"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/websocket"
+)
+
+// Clients making lots of small calls can keep a WebSocket open and send them all over it, each message being
+// a call or a batch like a request body would be. Calls are handled concurrently, so responses may come back
+// in a different order and clients match them to calls by ID. The user is authenticated once, when connecting.
+
+// AuthTokenParam is the query parameter the auth token can be passed in when connecting a WebSocket,
+// as browsers can't set headers on those.
+const AuthTokenParam = "auth_token"
+
+// TokenFromQuery copies the auth token from AuthTokenParam to the auth header for requests which don't have it.
+// The token is removed from the URL handlers get so it doesn't end up in logs. It has to be placed before auth.Middleware.
+func TokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if token := q.Get(AuthTokenParam); token != "" {
+			r = r.Clone(r.Context())
+			if r.Header.Get(wallet.TokenHeader) == "" {
+				r.Header.Set(wallet.TokenHeader, token)
+			}
+			q.Del(AuthTokenParam)
+			r.URL.RawQuery = q.Encode()
+			r.RequestURI = r.URL.RequestURI()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleWebSocket serves JSON-RPC calls sent over a WebSocket.
+func HandleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// An invalid token is rejected right away rather than on every wallet call
+	if _, ok := r.Header[wallet.TokenHeader]; ok {
+		user, err := auth.FromRequest(r)
+		if authErr := auth.Check(user, err); authErr != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			writeResponse(w, rpcerrors.ErrorToJSON(authErr))
+			return
+		}
+	}
+
+	cfg := config.GetWebSocketRPC()
+	maxInFlight := cfg.MaxInFlight
+	if maxInFlight <= 0 {
+		maxInFlight = 1
+	}
+	// Calls still in progress are cancelled when the connection goes away
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	inFlight := make(chan struct{}, maxInFlight)
+	var wg sync.WaitGroup
+	reqID := w.Header().Get(recovery.RequestIDHeader)
+
+	conn, err := websocket.Accept(w, r, func(c *websocket.Conn, message []byte) {
+		// Reading waits while MaxInFlight calls are in progress
+		inFlight <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-inFlight
+				wg.Done()
+			}()
+			metrics.ProxyWebSocketMessages.Inc()
+			c.WriteText(handleMessage(ctx, r, reqID, message))
+		}()
+	}, cfg.IdleTimeout)
+	if errors.Is(err, websocket.ErrNotWebSocket) {
+		w.WriteHeader(http.StatusBadRequest)
+		writeResponse(w, rpcerrors.NewInvalidParamsError(err).JSON())
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error upgrading websocket connection: %v", err)
+		return
+	}
+	metrics.ProxyWebSocketConnections.Inc()
+	defer metrics.ProxyWebSocketConnections.Dec()
+
+	<-conn.Done()
+	cancel()
+	wg.Wait()
+}
+
+// handleMessage handles a call or a batch received over the WebSocket opened by r as if it was a request body.
+// Panics are answered with an internal error, the recovery middleware doesn't reach per-message goroutines.
+func handleMessage(ctx context.Context, r *http.Request, reqID string, message []byte) (rsp []byte) {
+	defer func() {
+		if p := recover(); p != nil {
+			rsp = recovery.Recover(r, reqID, p, message)
+		}
+	}()
+	res := &bufferedResponse{header: http.Header{}}
+	cr := r.Clone(ctx)
+	cr.Method = http.MethodPost
+	cr.Body = ioutil.NopCloser(bytes.NewReader(message))
+	cr.ContentLength = int64(len(message))
+	// Each call is timed on its own rather than from when the connection was opened
+	metrics.MeasureMiddleware()(http.HandlerFunc(Handle)).ServeHTTP(res, cr)
+	return bytes.TrimSpace(res.body.Bytes())
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/middleware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func dialWebSocket(t *testing.T, url string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET /api/v2/ws HTTP/1.1\r\nHost: lbry.tv\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	return conn, br
+}
+
+func sendMessage(conn net.Conn, message string) {
+	mask := []byte{1, 2, 3, 4}
+	frame := append([]byte{0x81, 0x80 | byte(len(message))}, mask...)
+	for i := range message {
+		frame = append(frame, message[i]^mask[i%4])
+	}
+	conn.Write(frame)
+}
+
+func readMessage(t *testing.T, br *bufio.Reader) []byte {
+	var head [2]byte
+	_, err := io.ReadFull(br, head[:])
+	require.NoError(t, err)
+	n := int(head[1] & 0x7f)
+	if n == 126 {
+		var ext [2]byte
+		_, err = io.ReadFull(br, ext[:])
+		require.NoError(t, err)
+		n = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, n)
+	_, err = io.ReadFull(br, payload)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestHandleWebSocket(t *testing.T) {
+	sdk := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", ID: req.ID, Result: req.Params})
+	}))
+	defer sdk.Close()
+	config.Override("LbrynetServers", map[string]string{"a": sdk.URL})
+	defer config.RestoreOverridden()
+
+	rt := sdkrouter.New(config.GetLbrynetServers())
+	ts := httptest.NewServer(middleware.Apply(middleware.Chain(sdkrouter.Middleware(rt), auth.NilMiddleware), HandleWebSocket))
+	defer ts.Close()
+
+	conn, br := dialWebSocket(t, ts.URL)
+	defer conn.Close()
+	sendMessage(conn, `{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}, "id": 1}`)
+	sendMessage(conn, `{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "two"}, "id": 2}`)
+	sendMessage(conn, `[{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "three"}, "id": 3}]`)
+
+	// Responses come in the order calls complete
+	results := map[int]string{}
+	for i := 0; i < 3; i++ {
+		message := readMessage(t, br)
+		var responses []jsonrpc.RPCResponse
+		if message[0] == '[' {
+			require.NoError(t, json.Unmarshal(message, &responses))
+		} else {
+			var res jsonrpc.RPCResponse
+			require.NoError(t, json.Unmarshal(message, &res))
+			responses = append(responses, res)
+		}
+		for _, res := range responses {
+			results[res.ID] = res.Result.(map[string]interface{})["urls"].(string)
+		}
+	}
+	assert.Equal(t, map[int]string{1: "one", 2: "two", 3: "three"}, results)
+}
+
+func TestHandleWebSocket_NotWebSocket(t *testing.T) {
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/ws", nil)
+	auth.NilMiddleware(http.HandlerFunc(HandleWebSocket)).ServeHTTP(rr, r)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestTokenFromQuery(t *testing.T) {
+	var token, query string
+	h := TokenFromQuery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get(wallet.TokenHeader)
+		query = r.URL.RawQuery
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2/ws?auth_token=abc&x=1", nil))
+	assert.Equal(t, "abc", token)
+	assert.Equal(t, "x=1", query)
+}
+
+func TestHandleWebSocket_RecoversPanics(t *testing.T) {
+	// Calls panic without the SDK router on the request
+	ts := httptest.NewServer(auth.NilMiddleware(http.HandlerFunc(HandleWebSocket)))
+	defer ts.Close()
+
+	conn, br := dialWebSocket(t, ts.URL)
+	defer conn.Close()
+	for id := 1; id <= 2; id++ {
+		sendMessage(conn, fmt.Sprintf(`{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": "one"}, "id": %v}`, id))
+		var res jsonrpc.RPCResponse
+		require.NoError(t, json.Unmarshal(readMessage(t, br), &res))
+		assert.Equal(t, id, res.ID)
+		require.NotNil(t, res.Error)
+		assert.Equal(t, -32080, res.Error.Code)
+	}
+}
@@ -0,0 +1,107 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+const (
+	// suggestionsField is added to results of publish responses when the uploaded file was analyzed.
+	suggestionsField = "suggestions"
+
+	tagMature = "mature"
+)
+
+// analysis is what the analyzer command prints for a file: ISO 639-1 codes of languages spoken or written in it
+// and the probability of it not being safe for work.
+type analysis struct {
+	Languages []string `json:"languages"`
+	NSFW      float64  `json:"nsfw"`
+}
+
+// suggestions are tags and languages creators should consider adding to their publish.
+type suggestions struct {
+	Languages []string `json:"languages"`
+	Tags      []string `json:"tags"`
+}
+
+// analyzeFile runs the analyzer command with the file path appended, expecting analysis JSON on its stdout.
+func analyzeFile(cfg config.UploadAnalysis, path string) (*analysis, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.Command[0], append(cfg.Command[1:], path)...)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Err("%v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	var a analysis
+	if err := json.Unmarshal(out.Bytes(), &a); err != nil {
+		return nil, errors.Err("malformed analyzer output: %v", err)
+	}
+	return &a, nil
+}
+
+// suggest returns languages and tags from the analysis which aren't in publish params yet, nil if there are none.
+func suggest(a *analysis, params map[string]interface{}, nsfwThreshold float64) *suggestions {
+	s := &suggestions{Languages: []string{}, Tags: []string{}}
+	for _, l := range a.Languages {
+		if !hasString(params["languages"], l) {
+			s.Languages = append(s.Languages, l)
+		}
+	}
+	if a.NSFW >= nsfwThreshold && !hasString(params["tags"], tagMature) {
+		s.Tags = append(s.Tags, tagMature)
+	}
+	if len(s.Languages) == 0 && len(s.Tags) == 0 {
+		return nil
+	}
+	return s
+}
+
+func hasString(list interface{}, s string) bool {
+	switch l := list.(type) {
+	case string:
+		return strings.EqualFold(l, s)
+	case []interface{}:
+		for _, v := range l {
+			if v, ok := v.(string); ok && strings.EqualFold(v, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// analyzeUpload returns suggestions for the uploaded file when an analyzer is configured.
+// Analysis is advisory so its failures don't stop the publish.
+func analyzeUpload(path string, params map[string]interface{}) *suggestions {
+	cfg := config.GetUploadAnalysis()
+	if len(cfg.Command) == 0 {
+		return nil
+	}
+	op := metrics.StartOperation(opName, "analyze_file")
+	a, err := analyzeFile(cfg, path)
+	op.End()
+	if err != nil {
+		logger.Log().Warnf("upload analysis failed: %v", err)
+		metrics.UploadAnalysis.WithLabelValues(metrics.UploadAnalysisFailed).Inc()
+		return nil
+	}
+	s := suggest(a, params, cfg.NSFWThreshold)
+	if s == nil {
+		metrics.UploadAnalysis.WithLabelValues(metrics.UploadAnalysisNone).Inc()
+	} else {
+		metrics.UploadAnalysis.WithLabelValues(metrics.UploadAnalysisSuggested).Inc()
+	}
+	return s
+}
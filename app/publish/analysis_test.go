@@ -0,0 +1,72 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggest(t *testing.T) {
+	params := map[string]interface{}{"languages": []interface{}{"en"}, "tags": []interface{}{"art"}}
+	s := suggest(&analysis{Languages: []string{"en", "fr"}, NSFW: 0.9}, params, 0.8)
+	require.NotNil(t, s)
+	assert.Equal(t, []string{"fr"}, s.Languages)
+	assert.Equal(t, []string{tagMature}, s.Tags)
+
+	assert.Nil(t, suggest(&analysis{Languages: []string{"EN"}, NSFW: 0.2}, params, 0.8))
+	params["tags"] = []interface{}{"Mature"}
+	assert.Nil(t, suggest(&analysis{NSFW: 0.95}, params, 0.8))
+}
+
+func TestAnalyzeFile(t *testing.T) {
+	cfg := config.UploadAnalysis{
+		Command: []string{"sh", "-c", `test -f "$0" && echo '{"languages": ["de"], "nsfw": 0.5}'`},
+		Timeout: time.Minute,
+	}
+	a, err := analyzeFile(cfg, "testing.go")
+	require.NoError(t, err)
+	assert.Equal(t, &analysis{Languages: []string{"de"}, NSFW: 0.5}, a)
+
+	_, err = analyzeFile(cfg, "nonexistent")
+	assert.Error(t, err)
+	cfg.Command = []string{"echo", "not json"}
+	_, err = analyzeFile(cfg, "testing.go")
+	assert.Error(t, err)
+}
+
+func TestPublishSuggestions(t *testing.T) {
+	config.Override("UploadAnalysis", map[string]interface{}{
+		"Command":       []string{"sh", "-c", `echo '{"languages": ["en", "fr"], "nsfw": 0.99}'`},
+		"NSFWThreshold": 0.8,
+		"Timeout":       "1m",
+	})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	initiated := e.initiate(map[string]interface{}{"filename": "lbry_auto_test_file"})
+	data := []byte("file contents")
+	rr := e.call(e.handler.HandlePart, http.MethodPut, data, map[string]string{"id": initiated.UploadID, "part": "1"}, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	rr = e.complete(initiated.UploadID, map[string]interface{}{"parts": []map[string]interface{}{{"part": 1}}, "json_payload": payload})
+	require.Equal(t, http.StatusOK, rr.Code)
+	<-e.published
+
+	var res struct {
+		Result struct {
+			Suggestions suggestions `json:"suggestions"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, suggestions{Languages: []string{"fr"}, Tags: []string{tagMature}}, res.Result.Suggestions)
+}
@@ -0,0 +1,249 @@
+package publish
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/uploadtoken"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Asynchronous publishes respond as soon as the file is uploaded, with a token the outcome can be polled with,
+// while the SDK call is made by a background worker. This keeps large publishes, which the SDK can take
+// minutes to process, from running into HTTP timeouts. Job state is kept under the upload path.
+
+const (
+	// asyncFieldName is the POST field which makes a publish asynchronous when set to true.
+	asyncFieldName   = "async"
+	publishesDirName = "publishes"
+
+	PublishPending   = "pending"
+	PublishSucceeded = "succeeded"
+	PublishFailed    = "failed"
+)
+
+var (
+	ErrPublishNotFound = errors.Base("publish not found")
+	ErrQueueFull       = errors.Base("too many publishes queued, try again later")
+)
+
+type publishJob struct {
+	Token  string `json:"token"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Result is the publish response once it's done.
+	Result    json.RawMessage `json:"result,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	h        Handler
+	user     *models.User
+	file     *os.File
+	origName string
+	rawReq   []byte
+	token    *uploadtoken.Token
+}
+
+type asyncResponse struct {
+	Token     string `json:"token"`
+	Status    string `json:"status"`
+	StatusURL string `json:"status_url"`
+}
+
+var (
+	queueOnce sync.Once
+	queue     chan *publishJob
+	// publishesWG lets tests wait for queued publishes.
+	publishesWG sync.WaitGroup
+)
+
+// startWorkers starts the configured number of workers, the first time a publish is queued.
+func startWorkers() {
+	queueOnce.Do(func() {
+		cfg := config.GetAsyncPublishes()
+		queue = make(chan *publishJob, cfg.QueueSize)
+		for i := 0; i < cfg.Workers; i++ {
+			go func() {
+				for j := range queue {
+					j.run()
+				}
+			}()
+		}
+	})
+}
+
+func (h Handler) publishJobPath(userID int, token string) string {
+	return path.Join(h.UploadPath, fmt.Sprintf("%d", userID), publishesDirName, token+".json")
+}
+
+// save writes job state under a temporary name first so readers never see it half-written.
+func (j *publishJob) save() error {
+	j.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(j)
+	if err != nil {
+		return errors.Err(err)
+	}
+	p := j.h.publishJobPath(j.user.ID, j.Token)
+	if err := ioutil.WriteFile(p+".tmp", data, 0644); err != nil {
+		return errors.Err(err)
+	}
+	return errors.Err(os.Rename(p+".tmp", p))
+}
+
+func (h Handler) loadPublishJob(userID int, token string) (*publishJob, error) {
+	data, err := ioutil.ReadFile(h.publishJobPath(userID, token))
+	if os.IsNotExist(err) {
+		return nil, ErrPublishNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	var j publishJob
+	if err := json.Unmarshal(data, &j); err != nil {
+		return nil, errors.Err(err)
+	}
+	if time.Since(j.CreatedAt) > config.GetAsyncPublishes().TTL {
+		return nil, ErrPublishNotFound
+	}
+	return &j, nil
+}
+
+// removeExpiredPublishJobs removes state of the user's publishes finished longer than TTL ago.
+func (h Handler) removeExpiredPublishJobs(userID int) {
+	files, err := filepath.Glob(path.Join(h.UploadPath, fmt.Sprintf("%d", userID), publishesDirName, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		token := strings.TrimSuffix(path.Base(f), ".json")
+		if _, err := h.loadPublishJob(userID, token); errors.Is(err, ErrPublishNotFound) {
+			os.Remove(f)
+		}
+	}
+}
+
+// isAsync returns true for publish requests asking to be processed in the background.
+func isAsync(r *http.Request) bool {
+	v := r.FormValue(asyncFieldName)
+	return v == "true" || v == "1"
+}
+
+// enqueue queues publishing of the uploaded file f and responds with the token its status can be polled with.
+// The file is removed if it can't be queued.
+func (h Handler) enqueue(w http.ResponseWriter, user *models.User, f *os.File, origName string, rawReq []byte, token *uploadtoken.Token) {
+	startWorkers()
+	h.removeExpiredPublishJobs(user.ID)
+
+	id, err := randomID()
+	if err == nil {
+		err = os.MkdirAll(path.Dir(h.publishJobPath(user.ID, id)), os.ModePerm)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	now := time.Now().UTC()
+	j := &publishJob{
+		Token: id, Status: PublishPending, CreatedAt: now,
+		h: h, user: user, file: f, origName: origName, rawReq: rawReq, token: token,
+	}
+	if err := j.save(); err != nil {
+		os.Remove(f.Name())
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	publishesWG.Add(1)
+	select {
+	case queue <- j:
+	default:
+		publishesWG.Done()
+		os.Remove(f.Name())
+		os.Remove(h.publishJobPath(user.ID, id))
+		metrics.AsyncPublishes.WithLabelValues(metrics.AsyncPublishRejected).Inc()
+		responses.WriteError(w, http.StatusServiceUnavailable, ErrQueueFull)
+		return
+	}
+	metrics.AsyncPublishes.WithLabelValues(metrics.AsyncPublishQueued).Inc()
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "token": id}).Infof("publish of %v queued", origName)
+	responses.WriteJSON(w, http.StatusAccepted, asyncResponse{
+		Token: id, Status: PublishPending, StatusURL: "/api/v1/publish/status/" + id,
+	})
+}
+
+func (j *publishJob) run() {
+	defer publishesWG.Done()
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	log := logger.WithFields(logrus.Fields{"user_id": j.user.ID, "token": j.Token})
+	res, err := j.h.publishDetached("/api/v1/publish", j.user, j.file, j.origName, j.rawReq, j.token)
+	j.Result = res
+	if err != nil {
+		log.Warnf("publish failed: %v", err)
+		j.Status, j.Error = PublishFailed, err.Error()
+		metrics.AsyncPublishes.WithLabelValues(metrics.AsyncPublishFailed).Inc()
+	} else {
+		log.Info("publish succeeded")
+		j.Status = PublishSucceeded
+		metrics.AsyncPublishes.WithLabelValues(metrics.AsyncPublishSucceeded).Inc()
+	}
+	if err := j.save(); err != nil {
+		log.Errorf("error saving publish state: %v", err)
+	}
+}
+
+// publishDetached publishes f outside of the request which uploaded it and returns the publish response.
+// The error is set when the SDK or lbrytv reported one.
+func (h Handler) publishDetached(urlPath string, user *models.User, f *os.File, origName string, rawReq []byte, token *uploadtoken.Token) (json.RawMessage, error) {
+	// The publish is measured on its own since the request which started it is long gone
+	rsp := &responseBuffer{header: http.Header{}}
+	pr, _ := http.NewRequest(http.MethodPost, urlPath, nil)
+	metrics.MeasureMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.publish(w, r, user, f, origName, rawReq, token)
+	})).ServeHTTP(rsp, pr)
+
+	var rpcRes jsonrpc.RPCResponse
+	if err := json.Unmarshal(rsp.Bytes(), &rpcRes); err != nil {
+		return nil, errors.Err("malformed publish response: %v", err)
+	}
+	if rpcRes.Error != nil {
+		return json.RawMessage(rsp.Bytes()), errors.Err(rpcRes.Error.Message)
+	}
+	return json.RawMessage(rsp.Bytes()), nil
+}
+
+// HandlePublishStatus reports the state of an asynchronous publish, along with its response once it's done.
+func (h Handler) HandlePublishStatus(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	j, err := h.loadPublishJob(user.ID, mux.Vars(r)["token"])
+	if errors.Is(err, ErrPublishNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, j)
+}
@@ -0,0 +1,38 @@
+package publish
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lbryio/lbrytv/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncPublish(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+
+	body, contentType := multipartUpload(t, []byte("async file"), map[string]string{asyncFieldName: "true"})
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+	var queued asyncResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &queued))
+	assert.Equal(t, PublishPending, queued.Status)
+	assert.Equal(t, "/api/v1/publish/status/"+queued.Token, queued.StatusURL)
+
+	assert.Equal(t, []byte("async file"), <-e.published)
+	publishesWG.Wait()
+
+	rr = e.call(e.handler.HandlePublishStatus, http.MethodGet, nil, map[string]string{"token": queued.Token}, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var j publishJob
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &j))
+	assert.Equal(t, PublishSucceeded, j.Status, j.Error)
+	test.AssertEqualJSON(t, expectedStreamCreateResponse, j.Result)
+
+	rr = e.call(e.handler.HandlePublishStatus, http.MethodGet, nil, map[string]string{"token": "0123456789abcdef0123456789abcdef"}, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
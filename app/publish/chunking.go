@@ -0,0 +1,107 @@
+package publish
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+// Resumable upload clients lose the chunk in flight when their connection drops, which on mobile networks
+// makes big chunks fail over and over, while small chunks waste round trips on fast connections.
+// Throughput and interruptions of each upload are tracked, and responses suggest the size of the next chunk
+// in the Upload-Chunk-Size header: one taking about ResumableUploads.ChunkDuration to send, halved after
+// every interrupted chunk. Clients are free to ignore it.
+
+const (
+	chunkSizeHeader = "Upload-Chunk-Size"
+	// chunkSizeAlign keeps suggested sizes round.
+	chunkSizeAlign = 256 * 1024
+	// throughputWeight is how much the latest chunk weighs in the throughput average.
+	throughputWeight = 0.3
+	// maxInterruptedShare is the share of interrupted chunks above which suggestions stop growing.
+	maxInterruptedShare = 0.25
+)
+
+// chunkStats are kept along with resumable upload metadata.
+type chunkStats struct {
+	// ChunkSize is the size suggested for the next chunk, zero if nothing has been suggested yet.
+	ChunkSize int64 `json:"chunk_size,omitempty"`
+	// Throughput is the moving average of bytes per second received.
+	Throughput  float64 `json:"throughput,omitempty"`
+	Completed   int     `json:"completed,omitempty"`
+	Interrupted int     `json:"interrupted,omitempty"`
+}
+
+// clampChunkSize rounds size down to chunkSizeAlign and keeps it within configured limits.
+func clampChunkSize(cfg config.ResumableUploads, size int64) int64 {
+	if size > chunkSizeAlign {
+		size -= size % chunkSizeAlign
+	}
+	if cfg.MaxChunkSize > 0 && size > cfg.MaxChunkSize {
+		size = cfg.MaxChunkSize
+	}
+	if size < cfg.MinChunkSize {
+		size = cfg.MinChunkSize
+	}
+	if size < 1 {
+		size = chunkSizeAlign
+	}
+	return size
+}
+
+// suggested returns the chunk size to suggest to the client.
+func (s *chunkStats) suggested(cfg config.ResumableUploads) int64 {
+	if s.ChunkSize == 0 {
+		return clampChunkSize(cfg, cfg.InitialChunkSize)
+	}
+	return s.ChunkSize
+}
+
+// record updates stats after n bytes of a chunk were received in d, interrupted if the connection dropped.
+func (s *chunkStats) record(cfg config.ResumableUploads, n int64, d time.Duration, interrupted bool) {
+	current := s.suggested(cfg)
+	// Throughput of tiny chunks, like the last one of an upload, is mostly latency
+	if n >= cfg.MinChunkSize && n > 0 && d > 0 {
+		t := float64(n) / d.Seconds()
+		if s.Throughput == 0 {
+			s.Throughput = t
+		} else {
+			s.Throughput = (1-throughputWeight)*s.Throughput + throughputWeight*t
+		}
+	}
+
+	if interrupted {
+		s.Interrupted++
+		metrics.UploadChunks.WithLabelValues(metrics.UploadChunkInterrupted).Inc()
+		s.ChunkSize = clampChunkSize(cfg, current/2)
+		return
+	}
+	s.Completed++
+	metrics.UploadChunks.WithLabelValues(metrics.UploadChunkCompleted).Inc()
+	if s.Throughput == 0 {
+		s.ChunkSize = current
+		return
+	}
+	target := int64(s.Throughput * cfg.ChunkDuration.Seconds())
+	// Growing at most twofold keeps a single fast chunk from making the next one fail
+	if target > 2*current {
+		target = 2 * current
+	}
+	if float64(s.Interrupted)/float64(s.Completed+s.Interrupted) > maxInterruptedShare && target > current {
+		target = current
+	}
+	s.ChunkSize = clampChunkSize(cfg, target)
+}
+
+// writeChunkSize suggests the next chunk size to the client, unless suggestions are disabled.
+func writeChunkSize(w http.ResponseWriter, cfg config.ResumableUploads, s chunkStats) {
+	if cfg.ChunkDuration <= 0 {
+		return
+	}
+	size := s.suggested(cfg)
+	metrics.UploadChunkSizeSuggested.Observe(float64(size))
+	w.Header().Set(chunkSizeHeader, strconv.FormatInt(size, 10))
+}
@@ -0,0 +1,52 @@
+package publish
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkStats(t *testing.T) {
+	const mb = 1024 * 1024
+	cfg := config.ResumableUploads{
+		InitialChunkSize: 5 * mb, MinChunkSize: mb / 4, MaxChunkSize: 100 * mb, ChunkDuration: 10 * time.Second,
+	}
+	s := chunkStats{}
+	assert.EqualValues(t, 5*mb, s.suggested(cfg))
+
+	// 5MB in a second can grow to 50MB, but only doubles at a time
+	s.record(cfg, 5*mb, time.Second, false)
+	assert.EqualValues(t, 10*mb, s.ChunkSize)
+	s.record(cfg, 10*mb, 2*time.Second, false)
+	assert.EqualValues(t, 20*mb, s.ChunkSize)
+
+	// Slow chunks bring it down towards 10s worth of throughput
+	for i := 0; i < 5; i++ {
+		s.record(cfg, mb, 10*time.Second, false)
+	}
+	assert.True(t, s.ChunkSize < 20*mb && s.ChunkSize > mb, s.ChunkSize)
+	assert.Zero(t, s.ChunkSize%chunkSizeAlign)
+
+	// Interruptions halve it down to the minimum
+	for i := 0; i < 10; i++ {
+		s.record(cfg, 0, time.Second, true)
+	}
+	assert.EqualValues(t, mb/4, s.ChunkSize)
+
+	// Suggestions don't grow while many chunks are interrupted
+	s.record(cfg, mb/4, time.Millisecond, false)
+	assert.EqualValues(t, mb/4, s.ChunkSize)
+	assert.Equal(t, 8, s.Completed)
+	assert.Equal(t, 10, s.Interrupted)
+}
+
+func TestClampChunkSize(t *testing.T) {
+	cfg := config.ResumableUploads{MinChunkSize: 1024, MaxChunkSize: 10 * 1024 * 1024}
+	assert.EqualValues(t, 1024, clampChunkSize(cfg, 10))
+	assert.EqualValues(t, 10*1024*1024, clampChunkSize(cfg, 50*1024*1024))
+	assert.EqualValues(t, 3*chunkSizeAlign, clampChunkSize(cfg, 3*chunkSizeAlign+5))
+	assert.EqualValues(t, chunkSizeAlign, clampChunkSize(config.ResumableUploads{}, 0))
+}
@@ -0,0 +1,217 @@
+package publish
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Delta uploads let creators re-publishing a slightly edited file send only what changed, rsync-style.
+// The file of the previous publish of a claim is kept as a basis, its block signatures are advertised
+// to the client, which rolls the weak checksum over the new file to find blocks it doesn't need to send.
+// The new file is then assembled from basis blocks and uploaded parts.
+
+const basisDirName = "basis"
+
+var (
+	ErrBasisNotFound = errors.Base("no previous upload is kept for the claim")
+	ErrInvalidBlock  = errors.Base("invalid basis block")
+)
+
+type blockSignature struct {
+	// Weak is the rsync rolling checksum of the block, see weakSum.
+	Weak   uint32 `json:"weak"`
+	SHA256 string `json:"sha256"`
+}
+
+type signaturesResponse struct {
+	ClaimID   string           `json:"claim_id"`
+	Size      int64            `json:"size"`
+	BlockSize int64            `json:"block_size"`
+	Blocks    []blockSignature `json:"blocks"`
+}
+
+// weakSum is the rsync rolling checksum of data. Both halves are kept modulo 2^16, so a client can slide it
+// over a file one byte at a time: with x leaving and y entering a window of n bytes,
+// a' = a - x + y and b' = b - n*x + a'.
+func weakSum(data []byte) uint32 {
+	var a, b uint32
+	n := uint32(len(data))
+	for i, x := range data {
+		a += uint32(x)
+		b += (n - uint32(i)) * uint32(x)
+	}
+	return (a & 0xffff) | (b&0xffff)<<16
+}
+
+func (h Handler) basisPath(userID int, claimID string) string {
+	return path.Join(h.UploadPath, fmt.Sprintf("%d", userID), basisDirName, claimID)
+}
+
+// openBasis returns the kept file of the claim if it hasn't expired.
+func (h Handler) openBasis(userID int, claimID string) (*os.File, os.FileInfo, error) {
+	ttl := config.GetDeltaUploads().BasisTTL
+	if ttl <= 0 {
+		return nil, nil, ErrBasisNotFound
+	}
+	f, err := os.Open(h.basisPath(userID, claimID))
+	if os.IsNotExist(err) {
+		return nil, nil, ErrBasisNotFound
+	} else if err != nil {
+		return nil, nil, errors.Err(err)
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, errors.Err(err)
+	}
+	if time.Since(stat.ModTime()) > ttl {
+		f.Close()
+		return nil, nil, ErrBasisNotFound
+	}
+	return f, stat, nil
+}
+
+// keepBasis moves the published file into place as the basis for later delta uploads of claims created
+// or updated by the publish, replacing earlier ones. Only a single claim is expected, the file is copied
+// when there are more.
+func (h Handler) keepBasis(userID int, filePath string, res *jsonrpc.RPCResponse) {
+	cfg := config.GetDeltaUploads()
+	if cfg.BasisTTL <= 0 || res == nil || res.Error != nil {
+		return
+	}
+	log := logger.WithFields(logrus.Fields{"user_id": userID})
+	h.removeExpiredBases(userID, cfg.BasisTTL)
+
+	tx, _ := res.Result.(map[string]interface{})
+	outputs, _ := tx["outputs"].([]interface{})
+	claimIDs := []string{}
+	for _, o := range outputs {
+		txo, ok := o.(map[string]interface{})
+		if !ok || txo["type"] != "claim" || txo["value_type"] != "stream" {
+			continue
+		}
+		if claimID, _ := txo["claim_id"].(string); claimID != "" && claimID == path.Base(claimID) {
+			claimIDs = append(claimIDs, claimID)
+		}
+	}
+	if len(claimIDs) == 0 {
+		return
+	}
+	if err := os.MkdirAll(path.Dir(h.basisPath(userID, claimIDs[0])), os.ModePerm); err != nil {
+		log.Errorf("error creating basis folder: %v", err)
+		return
+	}
+	for _, claimID := range claimIDs[1:] {
+		if err := copyFile(filePath, h.basisPath(userID, claimID)); err != nil {
+			log.Errorf("error keeping upload for claim %v: %v", claimID, err)
+		}
+	}
+	if err := os.Rename(filePath, h.basisPath(userID, claimIDs[0])); err != nil {
+		log.Errorf("error keeping upload for claim %v: %v", claimIDs[0], err)
+		return
+	}
+	// Expiry is counted from the publish
+	now := time.Now()
+	os.Chtimes(h.basisPath(userID, claimIDs[0]), now, now)
+}
+
+func (h Handler) removeExpiredBases(userID int, ttl time.Duration) {
+	files, err := filepath.Glob(path.Join(h.UploadPath, fmt.Sprintf("%d", userID), basisDirName, "*"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if stat, err := os.Stat(f); err == nil && time.Since(stat.ModTime()) > ttl {
+			if err := os.Remove(f); err != nil {
+				logger.Log().Errorf("error removing expired basis %v: %v", f, err)
+			}
+		}
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// HandleSignatures advertises block signatures of the file previously published for the claim in the URL.
+func (h Handler) HandleSignatures(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	claimID := mux.Vars(r)["claim_id"]
+	f, stat, err := h.openBasis(user.ID, claimID)
+	if errors.Is(err, ErrBasisNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer f.Close()
+
+	op := metrics.StartOperation(opName, "basis_signatures")
+	defer op.End()
+
+	blockSize := config.GetDeltaUploads().BlockSize
+	rsp := signaturesResponse{ClaimID: claimID, Size: stat.Size(), BlockSize: blockSize, Blocks: []blockSignature{}}
+	block := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(f, block)
+		if n > 0 {
+			sum := sha256.Sum256(block[:n])
+			rsp.Blocks = append(rsp.Blocks, blockSignature{Weak: weakSum(block[:n]), SHA256: hex.EncodeToString(sum[:])})
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			responses.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	responses.WriteJSON(w, http.StatusOK, rsp)
+}
+
+// appendBlock copies a block of the basis file to dst.
+func appendBlock(dst io.Writer, basis *os.File, blockSize int64, index int, buf []byte) error {
+	stat, err := basis.Stat()
+	if err != nil {
+		return err
+	}
+	offset := int64(index) * blockSize
+	if index < 0 || offset >= stat.Size() {
+		return ErrInvalidBlock
+	}
+	_, err = io.CopyBuffer(dst, io.NewSectionReader(basis, offset, blockSize), buf)
+	return err
+}
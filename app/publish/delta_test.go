@@ -0,0 +1,106 @@
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const deltaClaimID = "b3f7a2c1d9e8f6a5b4c3d2e1f0a9b8c7d6e5f4a3"
+
+func TestWeakSum_Rolls(t *testing.T) {
+	data := []byte("the quick brown fox jumps over the lazy dog")
+	n := 8
+	s := weakSum(data[:n])
+	a, b := s&0xffff, s>>16
+	for i := 1; i+n <= len(data); i++ {
+		x, y := uint32(data[i-1]), uint32(data[i+n-1])
+		a = (a - x + y) & 0xffff
+		b = (b - uint32(n)*x + a) & 0xffff
+		assert.Equal(t, weakSum(data[i:i+n]), a|b<<16, i)
+	}
+}
+
+func TestDeltaUpload(t *testing.T) {
+	config.Override("AssembledUploads", map[string]interface{}{"PartMaxSize": 100, "MaxParts": 10, "TTL": "1h"})
+	config.Override("DeltaUploads", map[string]interface{}{"BlockSize": 4, "BasisTTL": "1h"})
+	defer config.RestoreOverridden()
+
+	sdkResponse := fmt.Sprintf(`{"jsonrpc": "2.0", "result": {"outputs": [
+		{"type": "claim", "value_type": "stream", "claim_id": "%s", "name": "video"}]}}`, deltaClaimID)
+	e := newUploadEnv(t, sdkResponse)
+	defer e.close()
+
+	assert.Equal(t, http.StatusNotFound, e.call(e.handler.HandleSignatures, http.MethodGet, nil, map[string]string{"claim_id": deltaClaimID}, nil).Code)
+
+	// A previous publish of the claim leaves its file behind
+	previous := []byte("aaaabbbbccccdd")
+	f, err := e.handler.createFile(20404, "video.mp4")
+	require.NoError(t, err)
+	_, err = f.Write(previous)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal([]byte(sdkResponse), &res))
+	e.handler.keepBasis(20404, f.Name(), &res)
+	_, err = os.Stat(f.Name())
+	assert.True(t, os.IsNotExist(err))
+
+	rr := e.call(e.handler.HandleSignatures, http.MethodGet, nil, map[string]string{"claim_id": deltaClaimID}, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var sigs signaturesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &sigs))
+	assert.EqualValues(t, len(previous), sigs.Size)
+	require.Len(t, sigs.Blocks, 4)
+	assert.Equal(t, weakSum([]byte("bbbb")), sigs.Blocks[1].Weak)
+	assert.Equal(t, checksum([]byte("dd")), sigs.Blocks[3].SHA256)
+
+	// The edited file only differs in the middle
+	edited := []byte("aaaaXYZccccdd")
+	id := e.initiate(map[string]interface{}{"filename": "video.mp4", "basis": deltaClaimID}).UploadID
+	rr = e.call(e.handler.HandlePart, http.MethodPut, []byte("XYZ"), map[string]string{"id": id, "part": "1"}, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	block := func(i int) map[string]interface{} { return map[string]interface{}{"block": i} }
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	segments := []interface{}{block(0), map[string]interface{}{"part": 1}, block(2), block(3)}
+
+	assert.Equal(t, http.StatusBadRequest, e.complete(id, map[string]interface{}{"parts": segments, "json_payload": payload}).Code)
+	assert.Equal(t, http.StatusBadRequest, e.complete(id, map[string]interface{}{
+		"parts": []interface{}{block(0), map[string]interface{}{"part": 1}, block(4)}, "sha256": checksum(edited), "json_payload": payload,
+	}).Code)
+
+	rr = e.complete(id, map[string]interface{}{"parts": segments, "sha256": checksum(edited), "json_payload": payload})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, edited, <-e.published)
+
+	// The new file replaces the basis
+	kept, err := ioutil.ReadFile(e.handler.basisPath(20404, deltaClaimID))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(edited, kept))
+}
+
+func TestDeltaUpload_NoBasis(t *testing.T) {
+	config.Override("DeltaUploads", map[string]interface{}{"BlockSize": 4, "BasisTTL": "1h"})
+	defer config.RestoreOverridden()
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+
+	rr := e.call(e.handler.HandleInitiate, http.MethodPost, []byte(`{"filename": "video.mp4", "basis": "`+deltaClaimID+`"}`), nil, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	id := e.initiate(map[string]interface{}{"filename": "video.mp4"}).UploadID
+	rr = e.complete(id, map[string]interface{}{"parts": []interface{}{map[string]interface{}{"block": 0}}, "sha256": checksum(nil)})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -11,10 +11,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/lbryio/lbrytv/app/auth"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/uploadtoken"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/internal/test"
 	"github.com/lbryio/lbrytv/models"
@@ -77,12 +79,17 @@ func TestUploadHandler(t *testing.T) {
 	assert.Regexp(t, expectedPath, publisher.filePath)
 	assert.Equal(t, sdkrouter.WalletID(20404), publisher.walletID)
 	expectedReq := fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), publisher.filePath)
+	// Media type detected from the file content and its hash are added
+	expectedReq = strings.Replace(expectedReq, `"file_path"`, `"media_type": "text/plain", "file_hash": "`+testFileHash+`", "file_path"`, 1)
 	test.AssertEqualJSON(t, expectedReq, publisher.rawQuery)
 
 	_, err = os.Stat(publisher.filePath)
 	assert.True(t, os.IsNotExist(err))
 }
 
+// testFileHash is the SHA-384 hash of "test file".
+const testFileHash = "d89ac10b61503696b55d4a8f69c83eb4bfea877d2acdb006065705bfa5af8e01175bf54158f416105a605801f047a37f"
+
 func TestHandler_NoAuthMiddleware(t *testing.T) {
 	r, err := http.NewRequest("POST", "/api/v1/proxy", &bytes.Buffer{})
 	require.NoError(t, err)
@@ -141,6 +148,32 @@ func TestHandler_AuthRequired(t *testing.T) {
 	require.False(t, publisher.called)
 }
 
+func TestHandler_UploadTokenMethods(t *testing.T) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fileBody, err := writer.CreateFormFile(fileFieldName, "lbry_auto_test_file")
+	require.NoError(t, err)
+	fileBody.Write([]byte("test file"))
+	payload, err := writer.CreateFormField(jsonRPCFieldName)
+	require.NoError(t, err)
+	payload.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "method": "wallet_send", "params": {"addresses": ["x"], "amount": "1.0"}}`))
+	writer.Close()
+	r, err := http.NewRequest(http.MethodPost, "/api/v1/proxy", body)
+	require.NoError(t, err)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	r.Header.Set(uploadtoken.Header, "issued-to-someone-else")
+
+	handler := &Handler{UploadPath: os.TempDir()}
+	provider := func(token, ip string) (*models.User, error) { return nil, nil }
+	rr := httptest.NewRecorder()
+	auth.Middleware(provider)(http.HandlerFunc(handler.Handle)).ServeHTTP(rr, r)
+
+	res := test.StrToRes(t, rr.Body.String())
+	require.NotNil(t, res.Error)
+	assert.Equal(t, -32601, res.Error.Code)
+	assert.Contains(t, res.Error.Message, uploadtoken.ErrMethodForbidden.Error())
+}
+
 func TestUploadHandlerSystemError(t *testing.T) {
 	// Creating POST data manually here because we need to avoid writer.Close()
 	reader := bytes.NewReader([]byte("test file"))
@@ -0,0 +1,250 @@
+package publish
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Resumable and assembled uploads are kept on the disk of the instance which received them, so a deploy would
+// throw away every upload in progress. On shutdown, Drain stops reading upload bodies, keeping what was received,
+// and HandOver stores unfinished uploads along with their metadata in the object store uploads are handed to SDK
+// nodes from. When the client carries on against another instance, the upload is taken over from the store there.
+// Each handed over upload has a manifest listing its files, which is stored last, so uploads whose handover
+// was cut short are never taken over. Their files are left in the store, which should expire handoverPrefix.
+
+const (
+	handoverPrefix      = "handover/"
+	handoverManifestExt = ".manifest"
+)
+
+// ErrDraining is returned by reads of upload bodies once the instance is shutting down.
+var ErrDraining = errors.Base("server is shutting down")
+
+var (
+	draining  = make(chan struct{})
+	drainOnce sync.Once
+
+	takeOverMu sync.Mutex
+)
+
+// handoverManifest lists files of a handed over upload, relative to the upload path. The metadata file goes last.
+type handoverManifest struct {
+	Files        []string  `json:"files"`
+	HandedOverAt time.Time `json:"handed_over_at"`
+}
+
+// unfinishedUpload is an upload found on disk, rel being its path relative to the upload path without extension.
+type unfinishedUpload struct {
+	rel    string
+	files  []string
+	remove func()
+}
+
+// Drain makes reads of upload bodies in progress fail with ErrDraining, and so do reads by requests coming later.
+// Bytes of resumable uploads read up to that point are kept.
+func Drain() {
+	drainOnce.Do(func() { close(draining) })
+}
+
+// drainingReader stops reading once done is closed.
+type drainingReader struct {
+	io.Reader
+	done <-chan struct{}
+}
+
+func (r drainingReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.done:
+		return 0, ErrDraining
+	default:
+	}
+	return r.Reader.Read(p)
+}
+
+// HandOver hands over unfinished uploads kept under PublishSourceDir to the default storage.
+func HandOver() int {
+	return Handler{UploadPath: config.GetPublishSourceDir(), Storage: filestore.Default()}.HandOver()
+}
+
+// HandOver stores unfinished uploads in Storage and removes them locally, returning how many were handed over.
+// It does nothing unless Storage is shared between instances. It should be called once requests are drained.
+func (h Handler) HandOver() int {
+	if _, ok := h.Storage.(filestore.Fetcher); !ok {
+		return 0
+	}
+	var handedOver int
+	for _, u := range h.unfinishedUploads() {
+		if err := h.handOver(u); err != nil {
+			metrics.UploadHandovers.WithLabelValues(metrics.UploadHandoverFailed).Inc()
+			logger.WithFields(logrus.Fields{"upload": u.rel}).Errorf("error handing over upload: %v", err)
+			continue
+		}
+		metrics.UploadHandovers.WithLabelValues(metrics.UploadHandedOver).Inc()
+		handedOver++
+	}
+	if handedOver > 0 {
+		logger.Log().Infof("handed over %v unfinished uploads", handedOver)
+	}
+	return handedOver
+}
+
+// unfinishedUploads lists resumable and assembled uploads of all users which haven't expired.
+func (h Handler) unfinishedUploads() []unfinishedUpload {
+	var uploads []unfinishedUpload
+	metas, _ := filepath.Glob(path.Join(h.UploadPath, "*", tusDirName, "*.json"))
+	for _, m := range metas {
+		userID, err := strconv.Atoi(path.Base(path.Dir(path.Dir(m))))
+		if err != nil {
+			continue
+		}
+		uploadID := strings.TrimSuffix(path.Base(m), ".json")
+		if _, _, err := h.openTusUpload(userID, uploadID); err != nil {
+			continue
+		}
+		rel := path.Join(strconv.Itoa(userID), tusDirName, uploadID)
+		uploads = append(uploads, unfinishedUpload{
+			rel:    rel,
+			files:  []string{rel + ".bin", rel + ".json"},
+			remove: func() { h.removeTusUpload(userID, uploadID) },
+		})
+	}
+
+	metas, _ = filepath.Glob(path.Join(h.UploadPath, "*", uploadsDirName, "*", uploadMetaFile))
+	for _, m := range metas {
+		dir := path.Dir(m)
+		userID, err := strconv.Atoi(path.Base(path.Dir(path.Dir(dir))))
+		if err != nil {
+			continue
+		}
+		if _, _, err := h.openUpload(userID, path.Base(dir)); err != nil {
+			continue
+		}
+		rel := path.Join(strconv.Itoa(userID), uploadsDirName, path.Base(dir))
+		u := unfinishedUpload{rel: rel, remove: func() { os.RemoveAll(dir) }}
+		parts, _ := filepath.Glob(path.Join(dir, "*.part"))
+		for _, p := range parts {
+			u.files = append(u.files, path.Join(rel, path.Base(p)))
+		}
+		u.files = append(u.files, path.Join(rel, uploadMetaFile))
+		uploads = append(uploads, u)
+	}
+	return uploads
+}
+
+// handOver stores files of the upload followed by its manifest, then removes the upload locally.
+func (h Handler) handOver(u unfinishedUpload) error {
+	for _, f := range u.files {
+		if _, err := h.Storage.Put(handoverPrefix+f, path.Join(h.UploadPath, f)); err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(handoverManifest{Files: u.files, HandedOverAt: time.Now().UTC()})
+	if err != nil {
+		return errors.Err(err)
+	}
+	manifestPath := path.Join(h.UploadPath, u.rel) + handoverManifestExt
+	if err := ioutil.WriteFile(manifestPath, data, 0644); err != nil {
+		return errors.Err(err)
+	}
+	defer os.Remove(manifestPath)
+	if _, err := h.Storage.Put(handoverPrefix+u.rel+handoverManifestExt, manifestPath); err != nil {
+		return err
+	}
+	u.remove()
+	return nil
+}
+
+// takeOver fetches the upload of the user handed over by another instance, unless it is here already.
+// kind is the directory uploads of its type are kept in, metaPath is where its metadata file goes.
+func (h Handler) takeOver(userID int, kind, uploadID, metaPath string) error {
+	fetcher, ok := h.Storage.(filestore.Fetcher)
+	if !ok {
+		return nil
+	}
+	takeOverMu.Lock()
+	defer takeOverMu.Unlock()
+	if _, err := os.Stat(metaPath); err == nil {
+		return nil
+	}
+
+	rel := path.Join(strconv.Itoa(userID), kind, uploadID)
+	manifestPath := path.Join(h.UploadPath, rel) + handoverManifestExt
+	if err := os.MkdirAll(path.Dir(manifestPath), os.ModePerm); err != nil {
+		return errors.Err(err)
+	}
+	err := fetcher.Fetch(handoverPrefix+rel+handoverManifestExt, manifestPath)
+	if errors.Is(err, filestore.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer os.Remove(manifestPath)
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return errors.Err(err)
+	}
+	var manifest handoverManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return errors.Err(err)
+	}
+
+	var fetched []string
+	for _, f := range manifest.Files {
+		if f = path.Clean(f); !strings.HasPrefix(f, rel+".") && !strings.HasPrefix(f, rel+"/") {
+			err = errors.Err("file %v is not a part of upload %v", f, rel)
+		} else if err = os.MkdirAll(path.Dir(path.Join(h.UploadPath, f)), os.ModePerm); err == nil {
+			err = fetcher.Fetch(handoverPrefix+f, path.Join(h.UploadPath, f))
+		}
+		if err != nil {
+			for _, f := range fetched {
+				os.Remove(path.Join(h.UploadPath, f))
+			}
+			return err
+		}
+		fetched = append(fetched, f)
+	}
+
+	// The manifest goes first so no other instance takes the upload over again
+	for _, key := range append([]string{rel + handoverManifestExt}, fetched...) {
+		if err := h.Storage.Remove(handoverPrefix + key); err != nil {
+			logger.Log().Errorf("error removing handed over file %v: %v", key, err)
+		}
+	}
+	metrics.UploadHandovers.WithLabelValues(metrics.UploadTakenOver).Inc()
+	logger.WithFields(logrus.Fields{"user_id": userID, "upload_id": uploadID}).
+		Infof("took over upload handed over at %v", manifest.HandedOverAt)
+	return nil
+}
+
+// takeOverOrLog is takeOver for lookups which carry on as if there was no upload when it fails.
+func (h Handler) takeOverOrLog(userID int, kind, uploadID, metaPath string) {
+	if _, err := os.Stat(metaPath); err == nil {
+		return
+	}
+	if err := h.takeOver(userID, kind, uploadID, metaPath); err != nil {
+		logger.WithFields(logrus.Fields{"user_id": userID, "upload_id": uploadID}).Errorf("error taking over upload: %v", err)
+	}
+}
+
+// writeDraining tells the client to carry on with the upload later, against another instance.
+func writeDraining(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	responses.WriteError(w, http.StatusServiceUnavailable, ErrDraining)
+}
@@ -0,0 +1,145 @@
+package publish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dirStorage is a filestore.Fetcher keeping files in a directory, standing in for a bucket.
+type dirStorage string
+
+func (s dirStorage) Put(key, localPath string) (string, error) {
+	data, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+	p := path.Join(string(s), key)
+	if err := os.MkdirAll(path.Dir(p), os.ModePerm); err != nil {
+		return "", err
+	}
+	return p, ioutil.WriteFile(p, data, 0644)
+}
+
+func (s dirStorage) Fetch(key, localPath string) error {
+	data, err := ioutil.ReadFile(path.Join(string(s), key))
+	if os.IsNotExist(err) {
+		return filestore.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(localPath, data, 0644)
+}
+
+func (s dirStorage) Remove(key string) error {
+	return os.Remove(path.Join(string(s), key))
+}
+
+func (s dirStorage) files(t *testing.T) []string {
+	var files []string
+	err := filepath.Walk(string(s), func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, strings.TrimPrefix(p, string(s)+"/"))
+		}
+		return err
+	})
+	require.NoError(t, err)
+	return files
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "handover")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestHandOver(t *testing.T) {
+	store := dirStorage(tempDir(t))
+	defer os.RemoveAll(string(store))
+	leaving := Handler{UploadPath: tempDir(t), Storage: store}
+	defer os.RemoveAll(leaving.UploadPath)
+	taking := Handler{UploadPath: tempDir(t), Storage: store}
+	defer os.RemoveAll(taking.UploadPath)
+
+	tusID, partsID := strings.Repeat("a", 32), strings.Repeat("b", 32)
+	require.NoError(t, os.MkdirAll(path.Dir(leaving.tusPath(1, tusID)), os.ModePerm))
+	require.NoError(t, leaving.saveTusMeta(1, tusID, &tusMeta{Filename: "video.mp4", Length: 16, CreatedAt: time.Now()}))
+	require.NoError(t, ioutil.WriteFile(leaving.tusPath(1, tusID)+".bin", []byte("partial"), 0644))
+
+	dir := leaving.uploadDir(1, partsID)
+	require.NoError(t, os.MkdirAll(dir, os.ModePerm))
+	meta, _ := json.Marshal(uploadMeta{Filename: "video.mp4", CreatedAt: time.Now()})
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, uploadMetaFile), meta, 0644))
+	require.NoError(t, ioutil.WriteFile(partPath(dir, 1), []byte("part one"), 0644))
+
+	// Expired uploads are left for the janitor
+	expiredID := strings.Repeat("c", 32)
+	require.NoError(t, leaving.saveTusMeta(1, expiredID, &tusMeta{Filename: "old.mp4", Length: 16, CreatedAt: time.Now().Add(-48 * time.Hour)}))
+	require.NoError(t, ioutil.WriteFile(leaving.tusPath(1, expiredID)+".bin", nil, 0644))
+
+	assert.Equal(t, 0, Handler{UploadPath: leaving.UploadPath, Storage: filestore.LocalStorage{}}.HandOver())
+	assert.Equal(t, 2, leaving.HandOver())
+	assert.NoFileExists(t, leaving.tusPath(1, tusID)+".json")
+	assert.NoFileExists(t, leaving.tusPath(1, tusID)+".bin")
+	assert.NoDirExists(t, dir)
+	assert.Len(t, store.files(t), 6)
+
+	m, offset, err := taking.openTusUpload(1, tusID)
+	require.NoError(t, err)
+	assert.Equal(t, "video.mp4", m.Filename)
+	assert.EqualValues(t, 7, offset)
+
+	takenDir, _, err := taking.openUpload(1, partsID)
+	require.NoError(t, err)
+	data, err := ioutil.ReadFile(partPath(takenDir, 1))
+	require.NoError(t, err)
+	assert.Equal(t, "part one", string(data))
+	assert.NoFileExists(t, takenDir+handoverManifestExt)
+
+	assert.Empty(t, store.files(t))
+	_, _, err = taking.openUpload(1, strings.Repeat("d", 32))
+	assert.True(t, errors.Is(err, ErrUploadNotFound))
+}
+
+func TestTakeOver_IncompleteHandover(t *testing.T) {
+	store := dirStorage(tempDir(t))
+	defer os.RemoveAll(string(store))
+	h := Handler{UploadPath: tempDir(t), Storage: store}
+	defer os.RemoveAll(h.UploadPath)
+
+	// Files stored without a manifest are from a handover cut short
+	id := strings.Repeat("a", 32)
+	f, err := ioutil.TempFile("", "handover")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Close()
+	_, err = store.Put(handoverPrefix+"1/tus/"+id+".json", f.Name())
+	require.NoError(t, err)
+
+	_, _, err = h.openTusUpload(1, id)
+	assert.True(t, errors.Is(err, ErrUploadNotFound))
+	assert.Len(t, store.files(t), 1)
+}
+
+func TestDrainingReader(t *testing.T) {
+	done := make(chan struct{})
+	r := drainingReader{Reader: strings.NewReader("chunk"), done: done}
+	p := make([]byte, 2)
+	n, err := r.Read(p)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	close(done)
+	_, err = r.Read(p)
+	assert.True(t, errors.Is(err, ErrDraining))
+}
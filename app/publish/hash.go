@@ -0,0 +1,78 @@
+package publish
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/ybbus/jsonrpc"
+)
+
+// fileHashParam is set in publish params to the SHA-384 hash of the uploaded file, which is what the SDK
+// puts into the stream's source, so it doesn't have to read the file once more to compute it.
+const fileHashParam = "file_hash"
+
+var ErrDuplicateUpload = errors.Base("this file has already been published")
+
+// fileHashes holds hashes computed while uploaded files were saved, keyed by file path, until they're published.
+var fileHashes sync.Map
+
+// DuplicateUploadDetails is sent in the data field of duplicate upload errors.
+type DuplicateUploadDetails struct {
+	FileHash string `json:"file_hash"`
+	// ClaimID is the claim the file was published as before, if known.
+	ClaimID string `json:"claim_id,omitempty"`
+}
+
+// checkDuplicate rejects files the user has already published within DuplicateUploadWindow.
+func checkDuplicate(userID int, fileHash string) error {
+	window := config.GetDuplicateUploadWindow()
+	if window <= 0 {
+		return nil
+	}
+	var claimID null.String
+	err := boil.GetDB().QueryRow(
+		`SELECT claim_id FROM uploaded_files WHERE user_id = $1 AND file_hash = $2 AND created_at > now() - $3 * interval '1 second'`,
+		userID, fileHash, window.Seconds(),
+	).Scan(&claimID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return errors.Err(err)
+	}
+	metrics.UploadsRejected.WithLabelValues(metrics.UploadRejectedDuplicate).Inc()
+	return rpcerrors.NewDuplicateUploadError(ErrDuplicateUpload).
+		WithData(DuplicateUploadDetails{FileHash: fileHash, ClaimID: claimID.String})
+}
+
+// recordUpload remembers the user published the file with fileHash, so it can be detected if uploaded again.
+func recordUpload(userID int, fileHash string, rpcRes *jsonrpc.RPCResponse) {
+	if config.GetDuplicateUploadWindow() <= 0 {
+		return
+	}
+	var claimID null.String
+	if result, ok := rpcRes.Result.(map[string]interface{}); ok {
+		if outputs, ok := result["outputs"].([]interface{}); ok && len(outputs) > 0 {
+			if o, ok := outputs[0].(map[string]interface{}); ok {
+				if id, ok := o["claim_id"].(string); ok {
+					claimID = null.StringFrom(id)
+				}
+			}
+		}
+	}
+	_, err := queries.Raw(
+		`INSERT INTO uploaded_files (user_id, file_hash, claim_id) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, file_hash) DO UPDATE SET claim_id = $3, created_at = now()`,
+		userID, fileHash, claimID,
+	).Exec(boil.GetDB())
+	if err != nil {
+		logger.Log().Errorf("cannot record upload of user %v: %v", userID, err)
+	}
+}
@@ -0,0 +1,48 @@
+package publish
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestDuplicateUpload(t *testing.T) {
+	dbConfig := config.GetDatabase()
+	c, connCleanup := storage.CreateTestConn(storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	})
+	defer connCleanup()
+	c.SetDefaultConnection()
+	config.Override("DuplicateUploadWindow", "1h")
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	body, contentType := multipartUpload(t, []byte("test file"), nil)
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []byte("test file"), <-e.published)
+
+	rr = e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.NotNil(t, res.Error)
+	assert.Equal(t, rpcerrors.NewDuplicateUploadError(nil).Code(), res.Error.Code)
+	assert.Equal(t, ErrDuplicateUpload.Error(), res.Error.Message)
+	details := res.Error.Data.(map[string]interface{})
+	assert.Equal(t, testFileHash, details["file_hash"])
+	assert.NotEmpty(t, details["claim_id"])
+
+	// Other users can upload the same file
+	assert.NoError(t, checkDuplicate(20405, testFileHash))
+}
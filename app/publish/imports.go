@@ -0,0 +1,393 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Cloud imports publish files straight from the creator's cloud storage, so large files don't have to make a trip
+// through their home connection. The frontend gets a short-lived access token limited to the picked file
+// (Google Drive Picker, Dropbox Chooser) and hands it to lbrytv along with the file ID. The token is only used
+// for the download and is never stored. Import state is kept under the upload path, so any API instance
+// sharing it can report progress.
+
+const (
+	importsDirName = "imports"
+
+	ImportDownloading = "downloading"
+	ImportPublishing  = "publishing"
+	ImportDone        = "done"
+	ImportFailed      = "failed"
+
+	ProviderGoogleDrive = "gdrive"
+	ProviderDropbox     = "dropbox"
+
+	// importStateInterval is how often download progress is saved.
+	importStateInterval = time.Second
+)
+
+var (
+	ErrUnknownProvider = errors.Base("unknown cloud storage provider")
+	ErrImportNotFound  = errors.Base("import not found")
+	ErrImportTooLarge  = errors.Base("file is larger than imports allow")
+
+	// importClient has no overall timeout as downloads of large files take hours, they are bounded
+	// by the context of the import instead, which ends after CloudImports.Timeout.
+	importClient = &http.Client{Transport: &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+	}}
+)
+
+// connector downloads a file from a cloud storage provider with an access token the user granted.
+type connector interface {
+	// open returns the file name, its size or -1 if the provider doesn't tell, and contents.
+	open(ctx context.Context, fileID, accessToken string) (string, int64, io.ReadCloser, error)
+}
+
+// connectors are keyed by provider name, they are variables so tests can point them to a mock server.
+var connectors = map[string]connector{
+	ProviderGoogleDrive: driveConnector{apiURL: "https://www.googleapis.com/drive/v3"},
+	ProviderDropbox:     dropboxConnector{contentURL: "https://content.dropboxapi.com/2"},
+}
+
+type driveConnector struct {
+	apiURL string
+}
+
+func (c driveConnector) open(ctx context.Context, fileID, accessToken string) (string, int64, io.ReadCloser, error) {
+	var meta struct {
+		Name string `json:"name"`
+		Size string `json:"size"`
+	}
+	res, err := authorizedGet(ctx, fmt.Sprintf("%s/files/%s?fields=name,size", c.apiURL, url.PathEscape(fileID)), accessToken, nil)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	err = json.NewDecoder(res.Body).Decode(&meta)
+	res.Body.Close()
+	if err != nil {
+		return "", 0, nil, errors.Err("malformed file metadata: %v", err)
+	}
+	size, err := strconv.ParseInt(meta.Size, 10, 64)
+	if err != nil {
+		size = -1
+	}
+	res, err = authorizedGet(ctx, fmt.Sprintf("%s/files/%s?alt=media", c.apiURL, url.PathEscape(fileID)), accessToken, nil)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	return meta.Name, size, res.Body, nil
+}
+
+type dropboxConnector struct {
+	contentURL string
+}
+
+func (c dropboxConnector) open(ctx context.Context, fileID, accessToken string) (string, int64, io.ReadCloser, error) {
+	arg, _ := json.Marshal(map[string]string{"path": fileID})
+	res, err := authorizedGet(ctx, c.contentURL+"/files/download", accessToken, map[string]string{"Dropbox-API-Arg": string(arg)})
+	if err != nil {
+		return "", 0, nil, err
+	}
+	var meta struct {
+		Name string `json:"name"`
+		Size int64  `json:"size"`
+	}
+	if err := json.Unmarshal([]byte(res.Header.Get("Dropbox-API-Result")), &meta); err != nil {
+		res.Body.Close()
+		return "", 0, nil, errors.Err("malformed file metadata: %v", err)
+	}
+	return meta.Name, meta.Size, res.Body, nil
+}
+
+// authorizedGet requests rawURL with the access token. Dropbox wants content downloads POSTed, which is done
+// when headers carry an API argument.
+func authorizedGet(ctx context.Context, rawURL, accessToken string, headers map[string]string) (*http.Response, error) {
+	method := http.MethodGet
+	if len(headers) > 0 {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := importClient.Do(req)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		res.Body.Close()
+		return nil, errors.Err("cloud storage responded with %v: %s", res.StatusCode, msg)
+	}
+	return res, nil
+}
+
+type importRequest struct {
+	Provider    string          `json:"provider"`
+	FileID      string          `json:"file_id"`
+	AccessToken string          `json:"access_token"`
+	JSONPayload json.RawMessage `json:"json_payload"`
+}
+
+type importState struct {
+	ID       string `json:"import_id"`
+	Provider string `json:"provider"`
+	Status   string `json:"status"`
+	Filename string `json:"filename,omitempty"`
+	Bytes    int64  `json:"bytes"`
+	// Total is -1 when the provider didn't report the file size.
+	Total int64  `json:"total"`
+	Error string `json:"error,omitempty"`
+	// Result is the publish response once the import is done.
+	Result    json.RawMessage `json:"result,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (h Handler) importPath(userID int, importID string) string {
+	return path.Join(h.UploadPath, fmt.Sprintf("%d", userID), importsDirName, importID+".json")
+}
+
+// saveImport writes import state under a temporary name first so readers never see it half-written.
+func (h Handler) saveImport(userID int, s *importState) error {
+	s.UpdatedAt = time.Now().UTC()
+	data, err := json.Marshal(s)
+	if err != nil {
+		return errors.Err(err)
+	}
+	p := h.importPath(userID, s.ID)
+	if err := ioutil.WriteFile(p+".tmp", data, 0644); err != nil {
+		return errors.Err(err)
+	}
+	return errors.Err(os.Rename(p+".tmp", p))
+}
+
+func (h Handler) loadImport(userID int, importID string) (*importState, error) {
+	data, err := ioutil.ReadFile(h.importPath(userID, importID))
+	if os.IsNotExist(err) {
+		return nil, ErrImportNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	var s importState
+	return &s, errors.Err(json.Unmarshal(data, &s))
+}
+
+// progressWriter counts written bytes, saving import state every importStateInterval.
+type progressWriter struct {
+	w       io.Writer
+	h       Handler
+	userID  int
+	state   *importState
+	maxSize int64
+	saved   time.Time
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.state.Bytes += int64(n)
+	if p.state.Bytes > p.maxSize {
+		return n, ErrImportTooLarge
+	}
+	if time.Since(p.saved) >= importStateInterval {
+		p.saved = time.Now()
+		if err := p.h.saveImport(p.userID, p.state); err != nil {
+			logger.Log().Warnf("error saving import progress: %v", err)
+		}
+	}
+	return n, err
+}
+
+// responseBuffer collects the response of a publish made outside of an HTTP request.
+type responseBuffer struct {
+	header http.Header
+	bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+func (b *responseBuffer) WriteHeader(int)     {}
+
+// HandleImport starts a cloud import and responds right away, progress is reported by HandleImportStatus.
+func (h Handler) HandleImport(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	reportQuota(r, user.ID)
+	if sdkrouter.GetSDKAddress(user) == "" {
+		responses.WriteError(w, http.StatusInternalServerError, errors.Err("user does not have sdk address assigned"))
+		return
+	}
+	var req importRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	if _, ok := connectors[req.Provider]; !ok {
+		responses.WriteError(w, http.StatusBadRequest, ErrUnknownProvider)
+		return
+	}
+	if req.FileID == "" || req.AccessToken == "" || len(req.JSONPayload) == 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("file_id, access_token and json_payload are required"))
+		return
+	}
+	if err := json.Unmarshal(req.JSONPayload, &jsonrpc.RPCRequest{}); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed json_payload: %v", err))
+		return
+	}
+
+	id, err := randomID()
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	now := time.Now().UTC()
+	s := &importState{ID: id, Provider: req.Provider, Status: ImportDownloading, Total: -1, CreatedAt: now}
+	if err := os.MkdirAll(path.Dir(h.importPath(user.ID, id)), os.ModePerm); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := h.saveImport(user.ID, s); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	metrics.CloudImports.WithLabelValues(req.Provider, metrics.CloudImportStarted).Inc()
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "import_id": id, "provider": req.Provider}).Info("cloud import started")
+	importsWG.Add(1)
+	go h.runImport(user, s, req)
+	responses.WriteJSON(w, http.StatusAccepted, s)
+}
+
+// importsWG lets tests wait for imports running in the background.
+var importsWG sync.WaitGroup
+
+func (h Handler) runImport(user *models.User, s *importState, req importRequest) {
+	defer importsWG.Done()
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	log := logger.WithFields(logrus.Fields{"user_id": user.ID, "import_id": s.ID, "provider": req.Provider})
+	fail := func(err error) {
+		log.Warnf("cloud import failed: %v", err)
+		metrics.CloudImports.WithLabelValues(req.Provider, metrics.CloudImportFailed).Inc()
+		s.Status, s.Error = ImportFailed, err.Error()
+		if err := h.saveImport(user.ID, s); err != nil {
+			log.Errorf("error saving import state: %v", err)
+		}
+	}
+
+	cfg := config.GetCloudImports()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	name, size, body, err := connectors[req.Provider].open(ctx, req.FileID, req.AccessToken)
+	if err != nil {
+		fail(err)
+		return
+	}
+	defer body.Close()
+	if size > cfg.MaxSize {
+		fail(ErrImportTooLarge)
+		return
+	}
+	if size >= 0 {
+		if err := checkUploadSize(user.ID, size); err != nil {
+			fail(err)
+			return
+		}
+	}
+	s.Filename, s.Total = path.Base(name), size
+	if s.Filename == "." || s.Filename == "/" {
+		s.Filename = "import"
+	}
+
+	f, err := h.createFile(user.ID, s.Filename)
+	if err != nil {
+		fail(err)
+		return
+	}
+	op := metrics.StartOperation(opName, "import_file")
+	buf := make([]byte, 1024*1024)
+	_, err = io.CopyBuffer(&progressWriter{w: f, h: h, userID: user.ID, state: s, maxSize: cfg.MaxSize}, body, buf)
+	op.End()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		fail(err)
+		return
+	}
+	log.Infof("downloaded %v bytes", s.Bytes)
+	if err := h.admitFile(user.ID, f.Name()); err != nil {
+		os.Remove(f.Name())
+		fail(err)
+		return
+	}
+
+	s.Status = ImportPublishing
+	if err := h.saveImport(user.ID, s); err != nil {
+		log.Errorf("error saving import state: %v", err)
+	}
+
+	s.Result, err = h.publishDetached("/api/v1/imports", user, f, s.Filename, req.JSONPayload, nil)
+	if err != nil {
+		fail(err)
+		return
+	}
+	s.Status = ImportDone
+	if err := h.saveImport(user.ID, s); err != nil {
+		log.Errorf("error saving import state: %v", err)
+	}
+	metrics.CloudImports.WithLabelValues(req.Provider, metrics.CloudImportDone).Inc()
+	log.Info("cloud import published")
+}
+
+// HandleImportStatus reports progress of the import in the URL.
+func (h Handler) HandleImportStatus(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	s, err := h.loadImport(user.ID, mux.Vars(r)["id"])
+	if errors.Is(err, ErrImportNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, s)
+}
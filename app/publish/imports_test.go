@@ -0,0 +1,144 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCloudStorage serves file contents the way Google Drive and Dropbox APIs do.
+func mockCloudStorage(t *testing.T, contents []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer cloudToken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.URL.Path == "/files/download" && r.Method == http.MethodPost:
+			assert.Equal(t, `{"path":"id:dropboxFile"}`, r.Header.Get("Dropbox-API-Arg"))
+			w.Header().Set("Dropbox-API-Result", fmt.Sprintf(`{"name": "lbry_auto_test_file", "size": %v}`, len(contents)))
+			w.Write(contents)
+		case r.URL.Path == "/files/driveFile" && r.URL.Query().Get("alt") == "media":
+			w.Write(contents)
+		case r.URL.Path == "/files/driveFile":
+			fmt.Fprintf(w, `{"name": "lbry_auto_test_file", "size": "%v"}`, len(contents))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestImport(t *testing.T) {
+	contents := []byte("imported file contents")
+	ts := mockCloudStorage(t, contents)
+	defer ts.Close()
+	origConnectors := connectors
+	connectors = map[string]connector{
+		ProviderGoogleDrive: driveConnector{apiURL: ts.URL},
+		ProviderDropbox:     dropboxConnector{contentURL: ts.URL},
+	}
+	defer func() { connectors = origConnectors }()
+
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	for _, c := range []struct{ provider, fileID string }{
+		{ProviderGoogleDrive, "driveFile"},
+		{ProviderDropbox, "id:dropboxFile"},
+	} {
+		t.Run(c.provider, func(t *testing.T) {
+			e := newUploadEnv(t, expectedStreamCreateResponse)
+			defer e.close()
+
+			body, _ := json.Marshal(importRequest{Provider: c.provider, FileID: c.fileID, AccessToken: "cloudToken", JSONPayload: payload})
+			rr := e.call(e.handler.HandleImport, http.MethodPost, body, nil, nil)
+			require.Equal(t, http.StatusAccepted, rr.Code, rr.Body.String())
+			var started importState
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &started))
+			assert.Equal(t, ImportDownloading, started.Status)
+
+			assert.Equal(t, contents, <-e.published)
+			importsWG.Wait()
+
+			rr = e.call(e.handler.HandleImportStatus, http.MethodGet, nil, map[string]string{"id": started.ID}, nil)
+			require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+			var s importState
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &s))
+			assert.Equal(t, ImportDone, s.Status, s.Error)
+			assert.Equal(t, "lbry_auto_test_file", s.Filename)
+			assert.EqualValues(t, len(contents), s.Bytes)
+			assert.EqualValues(t, len(contents), s.Total)
+			test.AssertEqualJSON(t, expectedStreamCreateResponse, s.Result)
+		})
+	}
+}
+
+func TestImportFailures(t *testing.T) {
+	ts := mockCloudStorage(t, []byte("imported file contents"))
+	defer ts.Close()
+	origConnectors := connectors
+	connectors = map[string]connector{ProviderGoogleDrive: driveConnector{apiURL: ts.URL}}
+	defer func() { connectors = origConnectors }()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	start := func(req importRequest) (int, importState) {
+		body, _ := json.Marshal(req)
+		rr := e.call(e.handler.HandleImport, http.MethodPost, body, nil, nil)
+		var s importState
+		json.Unmarshal(rr.Body.Bytes(), &s)
+		return rr.Code, s
+	}
+	status := func(id string) importState {
+		importsWG.Wait()
+		rr := e.call(e.handler.HandleImportStatus, http.MethodGet, nil, map[string]string{"id": id}, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		var s importState
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &s))
+		return s
+	}
+
+	code, _ := start(importRequest{Provider: "onedrive", FileID: "driveFile", AccessToken: "cloudToken", JSONPayload: payload})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = start(importRequest{Provider: ProviderGoogleDrive, FileID: "driveFile", JSONPayload: payload})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, s := start(importRequest{Provider: ProviderGoogleDrive, FileID: "driveFile", AccessToken: "expiredToken", JSONPayload: payload})
+	require.Equal(t, http.StatusAccepted, code)
+	s = status(s.ID)
+	assert.Equal(t, ImportFailed, s.Status)
+	assert.Contains(t, s.Error, "401")
+
+	config.Override("CloudImports", map[string]interface{}{"MaxSize": 10, "Timeout": "1m"})
+	defer config.RestoreOverridden()
+	code, s = start(importRequest{Provider: ProviderGoogleDrive, FileID: "driveFile", AccessToken: "cloudToken", JSONPayload: payload})
+	require.Equal(t, http.StatusAccepted, code)
+	s = status(s.ID)
+	assert.Equal(t, ImportFailed, s.Status)
+	assert.Equal(t, ErrImportTooLarge.Error(), s.Error)
+
+	rr := e.call(e.handler.HandleImportStatus, http.MethodGet, nil, map[string]string{"id": "0123456789abcdef0123456789abcdef"}, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestDriveConnectorEscapesFileID(t *testing.T) {
+	var paths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.EscapedPath())
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	_, _, _, err := driveConnector{apiURL: ts.URL}.open(context.Background(), "../about?x", "cloudToken")
+	require.Error(t, err)
+	assert.Equal(t, []string{"/files/..%2Fabout%3Fx"}, paths)
+}
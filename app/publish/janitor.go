@@ -0,0 +1,108 @@
+package publish
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+// Uploaded files are removed once published, but when lbrytv stops in the middle of a publish they stay
+// in the user directory forever. Janitor sweeps them up. Subdirectories of user directories (parts, tus uploads,
+// async publish state, delta bases) expire on their own schedule and are left alone.
+
+// Janitor removes uploaded files older than MaxAge from user directories under UploadPath.
+type Janitor struct {
+	UploadPath string
+	Interval   time.Duration
+	MaxAge     time.Duration
+
+	stop chan struct{}
+}
+
+// NewJanitor returns a Janitor sweeping uploadPath every interval.
+func NewJanitor(uploadPath string, interval, maxAge time.Duration) *Janitor {
+	return &Janitor{UploadPath: uploadPath, Interval: interval, MaxAge: maxAge, stop: make(chan struct{})}
+}
+
+// Start sweeps immediately and then every Interval until Stop is called. It blocks.
+func (j *Janitor) Start() {
+	logger.Log().Infof("sweeping orphaned uploads older than %v every %v", j.MaxAge, j.Interval)
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	for {
+		files, size := j.Sweep()
+		if files > 0 {
+			logger.Log().Infof("removed %v orphaned uploads, %v bytes reclaimed", files, size)
+		}
+		select {
+		case <-ticker.C:
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop stops the sweeping loop.
+func (j *Janitor) Stop() {
+	close(j.stop)
+}
+
+// Sweep removes stale uploads along with user directories left empty and returns how many files were removed
+// and how many bytes that reclaimed.
+func (j *Janitor) Sweep() (int, int64) {
+	dirs, err := ioutil.ReadDir(j.UploadPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Log().Errorf("cannot sweep upload path: %v", err)
+		}
+		return 0, 0
+	}
+	var files int
+	var size int64
+	for _, d := range dirs {
+		// Only user directories are swept
+		if _, err := strconv.Atoi(d.Name()); err != nil || !d.IsDir() {
+			continue
+		}
+		n, s := j.sweepUserDir(filepath.Join(j.UploadPath, d.Name()), time.Since(d.ModTime()) > j.MaxAge)
+		files += n
+		size += s
+	}
+	metrics.OrphanedUploadsRemoved.Add(float64(files))
+	metrics.OrphanedUploadsReclaimedBytes.Add(float64(size))
+	return files, size
+}
+
+// sweepUserDir removes stale files from dir, and dir itself when it's left empty and stale,
+// so it isn't removed right after an upload created it.
+func (j *Janitor) sweepUserDir(dir string, stale bool) (int, int64) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		logger.Log().Errorf("cannot sweep %v: %v", dir, err)
+		return 0, 0
+	}
+	var files int
+	var size int64
+	left := len(entries)
+	for _, e := range entries {
+		if !e.Mode().IsRegular() || time.Since(e.ModTime()) <= j.MaxAge {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			logger.Log().Errorf("error removing orphaned upload %v: %v", e.Name(), err)
+			continue
+		}
+		files++
+		size += e.Size()
+		left--
+	}
+	if left == 0 && stale {
+		// Fails harmlessly if an upload has just been started in it
+		os.Remove(dir)
+	}
+	return files, size
+}
@@ -0,0 +1,45 @@
+package publish
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJanitorSweep(t *testing.T) {
+	root, err := ioutil.TempDir("", "uploads")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	old := time.Now().Add(-48 * time.Hour)
+	write := func(name, data string, mtime time.Time) string {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), os.ModePerm))
+		require.NoError(t, ioutil.WriteFile(p, []byte(data), 0644))
+		require.NoError(t, os.Chtimes(p, mtime, mtime))
+		return p
+	}
+	orphan := write("20404/123_orphan.mp4", "orphaned", old)
+	fresh := write("20404/456_fresh.mp4", "in progress", time.Now())
+	basis := write("20404/basis/abcdef", "kept basis", old)
+	abandoned := write("20405/789_abandoned.mp4", "abandoned", old)
+	require.NoError(t, os.Chtimes(filepath.Dir(abandoned), old, old))
+	other := write("shared/notes.txt", "not a user directory", old)
+
+	files, size := NewJanitor(root, time.Hour, 24*time.Hour).Sweep()
+	assert.Equal(t, 2, files)
+	assert.EqualValues(t, len("orphaned")+len("abandoned"), size)
+
+	assert.NoFileExists(t, orphan)
+	assert.FileExists(t, fresh)
+	assert.FileExists(t, basis)
+	assert.FileExists(t, other)
+	_, err = os.Stat(filepath.Dir(abandoned))
+	assert.True(t, os.IsNotExist(err), "stale user directory left empty is removed")
+	assert.DirExists(t, filepath.Join(root, "20404"))
+}
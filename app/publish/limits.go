@@ -0,0 +1,168 @@
+package publish
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/ratelimit"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+)
+
+// maxFormOverhead is how much larger than the file a multipart publish request may be, to fit the JSON-RPC payload
+// and multipart boundaries.
+const maxFormOverhead = 1 << 20
+
+// maxFormMemory is how much of a multipart form is kept in memory when parsed, the rest goes to disk.
+const maxFormMemory = 32 << 20
+
+var (
+	ErrFileTooLarge       = errors.Base("uploaded file is too large")
+	ErrDailyQuotaExceeded = errors.Base("daily upload quota exceeded")
+)
+
+// UploadLimitDetails is sent in the data field of quota errors so clients can tell users what the limit is.
+type UploadLimitDetails struct {
+	Limit int64 `json:"limit"`
+	Size  int64 `json:"size"`
+	// Used is how much of the daily quota had been used before the upload.
+	Used int64 `json:"used,omitempty"`
+}
+
+// reserveUpload checks size against the file size cap and counts it against the user's daily quota,
+// both set in UploadLimits. The returned release func gives the bytes back and should be called
+// if the upload doesn't get saved.
+func reserveUpload(userID int, size int64) (func(), error) {
+	noop := func() {}
+	limits := config.GetUploadLimits()
+	if limits.MaxFileSize > 0 && size > limits.MaxFileSize {
+		return noop, fileTooLarge(limits, size)
+	}
+	if limits.DailyQuota <= 0 {
+		return noop, nil
+	}
+
+	res, err := queries.Raw(
+		`INSERT INTO upload_usage (user_id, day, bytes) SELECT $1, current_date, $2 WHERE $2 <= $3
+		ON CONFLICT (user_id, day) DO UPDATE SET bytes = upload_usage.bytes + $2
+		WHERE upload_usage.bytes + $2 <= $3`,
+		userID, size, limits.DailyQuota,
+	).Exec(boil.GetDB())
+	if err != nil {
+		return noop, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return noop, errors.Err(err)
+	}
+	if n == 0 {
+		var used int64
+		err := boil.GetDB().QueryRow(
+			`SELECT bytes FROM upload_usage WHERE user_id = $1 AND day = current_date`, userID,
+		).Scan(&used)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			logger.Log().Errorf("cannot get upload usage of user %v: %v", userID, err)
+		}
+		return noop, quotaExceeded(limits, size, used)
+	}
+
+	return func() {
+		_, err := queries.Raw(
+			`UPDATE upload_usage SET bytes = GREATEST(bytes - $1, 0) WHERE user_id = $2 AND day = current_date`,
+			size, userID,
+		).Exec(boil.GetDB())
+		if err != nil {
+			logger.Log().Errorf("cannot release %v bytes of user %v daily quota: %v", size, userID, err)
+		}
+	}, nil
+}
+
+// checkUploadSize rejects an upload of size which wouldn't fit the file size cap or what's left of the user's
+// daily quota, so it can be turned down before its bytes are received. Nothing is counted against the quota,
+// that's up to reserveUpload once the file is in.
+func checkUploadSize(userID int, size int64) error {
+	limits := config.GetUploadLimits()
+	if limits.MaxFileSize > 0 && size > limits.MaxFileSize {
+		return fileTooLarge(limits, size)
+	}
+	if limits.DailyQuota <= 0 {
+		return nil
+	}
+	used, _, err := DailyUsage(userID)
+	if err != nil {
+		return err
+	}
+	if used+size > limits.DailyQuota {
+		return quotaExceeded(limits, size, used)
+	}
+	return nil
+}
+
+func fileTooLarge(limits config.UploadLimits, size int64) error {
+	metrics.UploadsRejected.WithLabelValues(metrics.UploadRejectedFileSize).Inc()
+	return rpcerrors.NewQuotaExceededError(ErrFileTooLarge).WithData(UploadLimitDetails{Limit: limits.MaxFileSize, Size: size})
+}
+
+func quotaExceeded(limits config.UploadLimits, size, used int64) error {
+	metrics.UploadsRejected.WithLabelValues(metrics.UploadRejectedDailyQuota).Inc()
+	return rpcerrors.NewQuotaExceededError(ErrDailyQuotaExceeded).
+		WithData(UploadLimitDetails{Limit: limits.DailyQuota, Size: size, Used: used})
+}
+
+// limitRequestBody caps the body of a multipart publish request before its form gets parsed, which saves the file
+// to disk, and returns true if the request is over the file size cap by its Content-Length. Reads of requests sent
+// without it fail once they go over the cap, see isBodyTooLarge.
+func limitRequestBody(r *http.Request) bool {
+	limits := config.GetUploadLimits()
+	if limits.MaxFileSize <= 0 || !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		return false
+	}
+	max := limits.MaxFileSize + maxFormOverhead
+	if r.ContentLength > max {
+		return true
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, max)
+	return false
+}
+
+// isBodyTooLarge returns true for errors of reading bodies capped by limitRequestBody.
+func isBodyTooLarge(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
+
+// reportQuota reports the daily upload quota of the user in rate limit headers, so clients can hold off uploads
+// which would be rejected. Nothing is reported when there's no daily quota.
+func reportQuota(r *http.Request, userID int) {
+	if s, ok := quotaStatus(userID); ok {
+		ratelimit.Report(r, s)
+	}
+}
+
+// quotaStatus returns where the user stands against the daily upload quota in bytes, false if there's none.
+func quotaStatus(userID int) (ratelimit.Status, bool) {
+	limits := config.GetUploadLimits()
+	if limits.DailyQuota <= 0 {
+		return ratelimit.Status{}, false
+	}
+	used, reset, err := DailyUsage(userID)
+	if err != nil {
+		logger.Log().Errorf("cannot get upload usage of user %v: %v", userID, err)
+		return ratelimit.Status{}, false
+	}
+	return ratelimit.Status{Limit: limits.DailyQuota, Remaining: limits.DailyQuota - used, Reset: reset}, true
+}
+
+// DailyUsage returns how many bytes the user has uploaded today and the Unix time their daily quota renews at.
+func DailyUsage(userID int) (used int64, reset int64, err error) {
+	err = boil.GetDB().QueryRow(
+		`SELECT COALESCE((SELECT bytes FROM upload_usage WHERE user_id = $1 AND day = current_date), 0),
+		EXTRACT(EPOCH FROM (current_date + 1)::timestamptz)::bigint`, userID,
+	).Scan(&used, &reset)
+	return used, reset, errors.Err(err)
+}
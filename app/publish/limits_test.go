@@ -0,0 +1,143 @@
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// multipartUpload builds a publish request uploading data, with fields added to the form.
+func multipartUpload(t *testing.T, data []byte, fields map[string]string) ([]byte, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fileBody, err := writer.CreateFormFile(fileFieldName, "lbry_auto_test_file")
+	require.NoError(t, err)
+	fileBody.Write(data)
+	writer.WriteField(jsonRPCFieldName, fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	for k, v := range fields {
+		writer.WriteField(k, v)
+	}
+	require.NoError(t, writer.Close())
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func TestUploadLimits_FileSize(t *testing.T) {
+	config.Override("UploadLimits", map[string]interface{}{"MaxFileSize": 5})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	body, contentType := multipartUpload(t, []byte("too large"), nil)
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.NotNil(t, res.Error)
+	assert.Equal(t, rpcerrors.NewQuotaExceededError(nil).Code(), res.Error.Code)
+	assert.Equal(t, ErrFileTooLarge.Error(), res.Error.Message)
+	assert.Equal(t, map[string]interface{}{"limit": 5.0, "size": 9.0}, res.Error.Data)
+}
+
+func TestUploadLimits_RequestBody(t *testing.T) {
+	config.Override("UploadLimits", map[string]interface{}{"MaxFileSize": 5})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	body, contentType := multipartUpload(t, make([]byte, maxFormOverhead+10), nil)
+
+	for name, contentLength := range map[string]int64{"content length": int64(len(body)), "chunked": -1} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/proxy", bytes.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+			r.ContentLength = contentLength
+			// Taken by the publish handler so the client gets a quota error
+			assert.True(t, e.handler.CanHandle(r, nil))
+
+			rr := httptest.NewRecorder()
+			e.handler.Handle(rr, r)
+			var res jsonrpc.RPCResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			require.NotNil(t, res.Error)
+			assert.Equal(t, ErrFileTooLarge.Error(), res.Error.Message)
+		})
+	}
+}
+
+func TestReserveUpload_DailyQuota(t *testing.T) {
+	dbConfig := config.GetDatabase()
+	c, connCleanup := storage.CreateTestConn(storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	})
+	defer connCleanup()
+	c.SetDefaultConnection()
+	config.Override("UploadLimits", map[string]interface{}{"DailyQuota": 100})
+	defer config.RestoreOverridden()
+
+	_, err := reserveUpload(20404, 60)
+	require.NoError(t, err)
+	_, err = reserveUpload(20404, 101)
+	assert.True(t, errors.Is(err, ErrDailyQuotaExceeded))
+
+	_, err = reserveUpload(20404, 50)
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.True(t, errors.Is(err, ErrDailyQuotaExceeded))
+	assert.Equal(t, UploadLimitDetails{Limit: 100, Size: 50, Used: 60}, rpcErr.Data())
+
+	// Other users have their own quota
+	release, err := reserveUpload(20405, 50)
+	require.NoError(t, err)
+
+	// Released bytes can be uploaded again
+	release()
+	_, err = reserveUpload(20405, 100)
+	assert.NoError(t, err)
+}
+
+func TestQuotaStatus(t *testing.T) {
+	_, ok := quotaStatus(20406)
+	assert.False(t, ok)
+
+	dbConfig := config.GetDatabase()
+	c, connCleanup := storage.CreateTestConn(storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	})
+	defer connCleanup()
+	c.SetDefaultConnection()
+	config.Override("UploadLimits", map[string]interface{}{"DailyQuota": 100})
+	defer config.RestoreOverridden()
+
+	s, ok := quotaStatus(20406)
+	require.True(t, ok)
+	assert.EqualValues(t, 100, s.Remaining)
+
+	_, err := reserveUpload(20406, 60)
+	require.NoError(t, err)
+	s, ok = quotaStatus(20406)
+	require.True(t, ok)
+	assert.EqualValues(t, 100, s.Limit)
+	assert.EqualValues(t, 40, s.Remaining)
+	assert.True(t, s.Reset > time.Now().Unix())
+	assert.True(t, s.Reset <= time.Now().Add(24*time.Hour).Unix())
+}
@@ -0,0 +1,88 @@
+package publish
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+const (
+	// mediaTypeParam is set in publish params to the media type detected from the uploaded file's content.
+	mediaTypeParam = "media_type"
+
+	// sniffLen is how many bytes of the file are looked at, same as http.DetectContentType considers.
+	sniffLen = 512
+
+	mediaTypeUnknown = "application/octet-stream"
+)
+
+var ErrMediaTypeNotAllowed = errors.Base("file type is not allowed")
+
+// executableSignatures are magic bytes of executables, which http.DetectContentType doesn't recognize.
+var executableSignatures = []struct {
+	magic     []byte
+	mediaType string
+}{
+	{[]byte("MZ"), "application/x-msdownload"},
+	{[]byte("\x7fELF"), "application/x-executable"},
+	{[]byte("\xcf\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("\xce\xfa\xed\xfe"), "application/x-mach-binary"},
+	{[]byte("#!"), "text/x-shellscript"},
+}
+
+// sniffMediaType detects the media type of the file at path from its first bytes, without parameters like charset.
+func sniffMediaType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	head = head[:n]
+
+	for _, s := range executableSignatures {
+		if bytes.HasPrefix(head, s.magic) {
+			return s.mediaType, nil
+		}
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return mediaTypeUnknown, nil
+	}
+	return mediaType, nil
+}
+
+// matchMediaType checks if mediaType is one of patterns, which are either full media types or type/* wildcards.
+func matchMediaType(mediaType string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(p)
+		if p == mediaType || strings.HasSuffix(p, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(p, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMediaType rejects files of media types listed in Denied of UploadMediaTypes,
+// and ones not listed in Allowed if it's set.
+func checkMediaType(mediaType string) error {
+	cfg := config.GetUploadMediaTypes()
+	if matchMediaType(mediaType, cfg.Denied) || len(cfg.Allowed) > 0 && !matchMediaType(mediaType, cfg.Allowed) {
+		metrics.UploadsRejected.WithLabelValues(metrics.UploadRejectedMediaType).Inc()
+		return rpcerrors.NewInvalidParamsError(ErrMediaTypeNotAllowed).
+			WithData(map[string]string{mediaTypeParam: mediaType})
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package publish
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestSniffMediaType(t *testing.T) {
+	cases := map[string]string{
+		"<!DOCTYPE html><html><body>hi</body></html>": "text/html",
+		"MZ\x90\x00\x03\x00\x00\x00":                  "application/x-msdownload",
+		"\x7fELF\x02\x01\x01":                         "application/x-executable",
+		"\x89PNG\r\n\x1a\n":                           "image/png",
+		"plain text file":                             "text/plain",
+		"":                                            "text/plain",
+	}
+	for content, expected := range cases {
+		f, err := ioutil.TempFile("", "sniff")
+		require.NoError(t, err)
+		f.WriteString(content)
+		f.Close()
+		mediaType, err := sniffMediaType(f.Name())
+		os.Remove(f.Name())
+		require.NoError(t, err)
+		assert.Equal(t, expected, mediaType, content)
+	}
+}
+
+func TestCheckMediaType(t *testing.T) {
+	assert.NoError(t, checkMediaType("video/mp4"))
+	assert.Error(t, checkMediaType("text/html"))
+
+	config.Override("UploadMediaTypes", map[string]interface{}{"Allowed": []string{"video/*", "image/png"}})
+	defer config.RestoreOverridden()
+	assert.NoError(t, checkMediaType("video/webm"))
+	assert.NoError(t, checkMediaType("image/png"))
+	assert.Error(t, checkMediaType("image/gif"))
+	assert.Error(t, checkMediaType("text/plain"))
+}
+
+func TestMediaType_Rejected(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	body, contentType := multipartUpload(t, []byte("<html><script>alert(1)</script></html>"), nil)
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.NotNil(t, res.Error)
+	assert.Equal(t, rpcerrors.NewInvalidParamsError(nil).Code(), res.Error.Code)
+	assert.Equal(t, ErrMediaTypeNotAllowed.Error(), res.Error.Message)
+	assert.Equal(t, map[string]interface{}{"media_type": "text/html"}, res.Error.Data)
+	assert.Empty(t, e.published)
+}
@@ -0,0 +1,514 @@
+package publish
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/bufpool"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Assembled uploads let clients send a file in parts, in parallel and in any order, which are put together
+// and published once all of them are in. Parts are kept under the upload path, so any API instance
+// sharing it can take them.
+
+// PartChecksumHeader may carry hex-encoded SHA-256 of an uploaded part, which is then verified on receipt.
+const PartChecksumHeader = "X-Content-Sha256"
+
+const (
+	uploadsDirName = "uploads"
+	uploadMetaFile = "upload.json"
+)
+
+var (
+	ErrUploadNotFound   = errors.Base("upload not found or expired")
+	ErrInvalidPart      = errors.Base("invalid part number")
+	ErrPartTooLarge     = errors.Base("part is too large")
+	ErrChecksumMismatch = errors.Base("checksum mismatch")
+	ErrMissingParts     = errors.Base("parts have to be numbered consecutively from 1")
+)
+
+type uploadMeta struct {
+	Filename  string    `json:"filename"`
+	CreatedAt time.Time `json:"created_at"`
+	// Basis is the claim whose previous upload blocks can be taken from, for delta uploads.
+	Basis     string `json:"basis,omitempty"`
+	BlockSize int64  `json:"block_size,omitempty"`
+}
+
+type initiateRequest struct {
+	Filename string `json:"filename"`
+	// Basis is a claim ID, see HandleSignatures.
+	Basis string `json:"basis"`
+}
+
+type initiateResponse struct {
+	UploadID    string    `json:"upload_id"`
+	MaxPartSize int64     `json:"max_part_size"`
+	MaxParts    int       `json:"max_parts"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type uploadStatusResponse struct {
+	UploadID  string       `json:"upload_id"`
+	Filename  string       `json:"filename"`
+	Parts     []listedPart `json:"parts"`
+	ExpiresAt time.Time    `json:"expires_at"`
+}
+
+type listedPart struct {
+	Part int   `json:"part"`
+	Size int64 `json:"size"`
+}
+
+type partResponse struct {
+	Part   int    `json:"part"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// segment is a piece of the assembled file, either an uploaded part or a block of the basis file.
+type segment struct {
+	Part   int    `json:"part"`
+	SHA256 string `json:"sha256"`
+	Block  *int   `json:"block"`
+}
+
+type completeRequest struct {
+	Parts []segment `json:"parts"`
+	// SHA256 of the whole file, verified after assembly. It is optional unless basis blocks are used.
+	SHA256      string          `json:"sha256"`
+	JSONPayload json.RawMessage `json:"json_payload"`
+}
+
+func (h Handler) uploadDir(userID int, uploadID string) string {
+	return path.Join(h.UploadPath, fmt.Sprintf("%d", userID), uploadsDirName, uploadID)
+}
+
+func partPath(dir string, n int) string {
+	return path.Join(dir, fmt.Sprintf("%05d.part", n))
+}
+
+// partsSize returns the number of bytes in parts stored so far.
+func partsSize(dir string) int64 {
+	files, _ := filepath.Glob(path.Join(dir, "*.part"))
+	var size int64
+	for _, f := range files {
+		if fi, err := os.Stat(f); err == nil {
+			size += fi.Size()
+		}
+	}
+	return size
+}
+
+// openUpload returns the directory of an upload which hasn't expired yet, along with its metadata.
+func (h Handler) openUpload(userID int, uploadID string) (string, *uploadMeta, error) {
+	dir := h.uploadDir(userID, uploadID)
+	h.takeOverOrLog(userID, uploadsDirName, uploadID, path.Join(dir, uploadMetaFile))
+	data, err := ioutil.ReadFile(path.Join(dir, uploadMetaFile))
+	if os.IsNotExist(err) {
+		return "", nil, ErrUploadNotFound
+	} else if err != nil {
+		return "", nil, errors.Err(err)
+	}
+	var meta uploadMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return "", nil, errors.Err(err)
+	}
+	if time.Since(meta.CreatedAt) > config.GetAssembledUploads().TTL {
+		return "", nil, ErrUploadNotFound
+	}
+	return dir, &meta, nil
+}
+
+// removeExpiredUploads removes parts of uploads of the user which were never completed.
+func (h Handler) removeExpiredUploads(userID int) {
+	dirs, err := filepath.Glob(path.Join(h.UploadPath, fmt.Sprintf("%d", userID), uploadsDirName, "*"))
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if _, _, err := h.openUpload(userID, path.Base(dir)); errors.Is(err, ErrUploadNotFound) {
+			if err := os.RemoveAll(dir); err != nil {
+				logger.Log().Errorf("error removing expired upload %v: %v", dir, err)
+			}
+		}
+	}
+}
+
+// HandleInitiate starts an upload to be sent in parts.
+func (h Handler) HandleInitiate(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req initiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	filename := path.Base(req.Filename)
+	if req.Filename == "" || filename == "." || filename == "/" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("filename is required"))
+		return
+	}
+
+	h.removeExpiredUploads(user.ID)
+
+	uploadID, err := randomID()
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	dir := h.uploadDir(user.ID, uploadID)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	meta := uploadMeta{Filename: filename, CreatedAt: time.Now().UTC()}
+	if req.Basis != "" {
+		f, _, err := h.openBasis(user.ID, path.Base(req.Basis))
+		if errors.Is(err, ErrBasisNotFound) {
+			responses.WriteError(w, http.StatusNotFound, err)
+			return
+		} else if err != nil {
+			responses.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+		f.Close()
+		meta.Basis = path.Base(req.Basis)
+		meta.BlockSize = config.GetDeltaUploads().BlockSize
+	}
+	data, _ := json.Marshal(meta)
+	if err := ioutil.WriteFile(path.Join(dir, uploadMetaFile), data, 0644); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	cfg := config.GetAssembledUploads()
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Infof("upload of %v initiated", filename)
+	responses.WriteJSON(w, http.StatusOK, initiateResponse{
+		UploadID: uploadID, MaxPartSize: cfg.PartMaxSize, MaxParts: cfg.MaxParts, ExpiresAt: meta.CreatedAt.Add(cfg.TTL),
+	})
+}
+
+// HandlePart stores a single part of an upload. Sending a part again replaces it.
+func (h Handler) HandlePart(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	reportQuota(r, user.ID)
+	vars := mux.Vars(r)
+	cfg := config.GetAssembledUploads()
+	n, err := strconv.Atoi(vars["part"])
+	if err != nil || n < 1 || n > cfg.MaxParts {
+		responses.WriteError(w, http.StatusBadRequest, ErrInvalidPart)
+		return
+	}
+	dir, _, err := h.openUpload(user.ID, vars["id"])
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	op := metrics.StartOperation(opName, "save_part")
+	defer op.End()
+
+	// Written under a temporary name so a part being replaced is never seen half-written
+	tmp, err := ioutil.TempFile(dir, "*.tmp")
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	h256 := sha256.New()
+	buf := bufpool.GetBytes(bufpool.CopyBufferSize)
+	defer bufpool.PutBytes(buf)
+	body := drainingReader{Reader: r.Body, done: draining}
+	size, err := io.CopyBuffer(io.MultiWriter(tmp, h256), io.LimitReader(body, cfg.PartMaxSize+1), *buf)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if errors.Is(err, ErrDraining) {
+		// Parts are only kept whole, the client has to send it again
+		writeDraining(w)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("error reading part: %v", err))
+		return
+	}
+	if size > cfg.PartMaxSize {
+		responses.WriteError(w, http.StatusRequestEntityTooLarge, ErrPartTooLarge)
+		return
+	}
+	if limits := config.GetUploadLimits(); limits.MaxFileSize > 0 {
+		total := partsSize(dir) + size
+		if fi, err := os.Stat(partPath(dir, n)); err == nil {
+			// The part is being replaced
+			total -= fi.Size()
+		}
+		if total > limits.MaxFileSize {
+			responses.WriteError(w, http.StatusRequestEntityTooLarge, fileTooLarge(limits, total))
+			return
+		}
+	}
+	sum := hex.EncodeToString(h256.Sum(nil))
+	if expected := r.Header.Get(PartChecksumHeader); expected != "" && expected != sum {
+		responses.WriteError(w, http.StatusBadRequest, ErrChecksumMismatch)
+		return
+	}
+	if err := os.Rename(tmp.Name(), partPath(dir, n)); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// Parts arrive in parallel, so progress is reported as they are stored
+	progress.emit(ProgressEvent{UploadID: vars["id"], Stage: ProgressUploading, Bytes: partsSize(dir)})
+	responses.WriteJSON(w, http.StatusOK, partResponse{Part: n, Size: size, SHA256: sum})
+}
+
+// HandleStatus lists parts of an upload received so far, so clients can resend only the missing ones.
+func (h Handler) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	dir, meta, err := h.openUpload(user.ID, uploadID)
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	files, err := filepath.Glob(path.Join(dir, "*.part"))
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	parts := []listedPart{}
+	for _, f := range files {
+		n, err := strconv.Atoi(strings.TrimSuffix(path.Base(f), ".part"))
+		if err != nil {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		parts = append(parts, listedPart{Part: n, Size: fi.Size()})
+	}
+	responses.WriteJSON(w, http.StatusOK, uploadStatusResponse{
+		UploadID: uploadID, Filename: meta.Filename, Parts: parts,
+		ExpiresAt: meta.CreatedAt.Add(config.GetAssembledUploads().TTL),
+	})
+}
+
+// HandleAbort discards an upload along with all of its parts.
+func (h Handler) HandleAbort(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	dir, _, err := h.openUpload(user.ID, uploadID)
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Info("upload aborted")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleComplete assembles uploaded parts in order, verifying their checksums, and publishes the resulting file.
+// The response is the same as for a regular publish.
+func (h Handler) HandleComplete(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	if sdkrouter.GetSDKAddress(user) == "" {
+		responses.WriteError(w, http.StatusInternalServerError, errors.Err("user does not have sdk address assigned"))
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	dir, meta, err := h.openUpload(user.ID, uploadID)
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var req completeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	if len(req.Parts) == 0 {
+		responses.WriteError(w, http.StatusBadRequest, ErrMissingParts)
+		return
+	}
+	next := 1
+	for _, p := range req.Parts {
+		if p.Block != nil {
+			if meta.Basis == "" || req.SHA256 == "" {
+				responses.WriteError(w, http.StatusBadRequest, errors.Err("basis blocks can only be used by uploads with a basis and sha256 of the whole file"))
+				return
+			}
+			continue
+		}
+		if p.Part != next {
+			responses.WriteError(w, http.StatusBadRequest, ErrMissingParts)
+			return
+		}
+		next++
+	}
+
+	f, err := h.assemble(user.ID, dir, meta, &req)
+	if errors.Is(err, ErrChecksumMismatch) || errors.Is(err, ErrMissingParts) || errors.Is(err, ErrInvalidBlock) {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	} else if errors.Is(err, ErrBasisNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Errorf("error assembling upload: %v", err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Log().Errorf("error removing parts of upload %v: %v", dir, err)
+	}
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Infof("assembled %v parts", len(req.Parts))
+
+	if err := h.admitFile(user.ID, f.Name()); err != nil {
+		os.Remove(f.Name())
+		w.Write(rpcerrors.ToJSON(err))
+		return
+	}
+	progress.link(f.Name(), uploadID)
+	h.publish(w, r, user, f, meta.Filename, req.JSONPayload, nil)
+}
+
+// assemble concatenates parts and basis blocks into a new file in the user's upload folder.
+// Checksums of parts and of the whole file are compared against the ones in req. The file is removed on error.
+func (h Handler) assemble(userID int, dir string, meta *uploadMeta, req *completeRequest) (f *os.File, err error) {
+	op := metrics.StartOperation(opName, "assemble_file")
+	defer op.End()
+
+	var basis *os.File
+	if meta.Basis != "" {
+		basis, _, err = h.openBasis(userID, meta.Basis)
+		if err != nil {
+			return nil, err
+		}
+		defer basis.Close()
+	}
+
+	f, err = h.createFile(userID, meta.Filename)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			f = nil
+		}
+	}()
+
+	buf := bufpool.GetBytes(bufpool.CopyBufferSize)
+	defer bufpool.PutBytes(buf)
+	whole := sha256.New()
+	var fromBasis int64
+	for _, p := range req.Parts {
+		if p.Block != nil {
+			if err := appendBlock(io.MultiWriter(f, whole), basis, meta.BlockSize, *p.Block, *buf); err != nil {
+				return f, err
+			}
+			fromBasis++
+			continue
+		}
+		if err := appendPart(io.MultiWriter(f, whole), partPath(dir, p.Part), p.SHA256, *buf); err != nil {
+			return f, err
+		}
+	}
+	if fromBasis > 0 {
+		metrics.DeltaUploadBlocks.Add(float64(fromBasis))
+	}
+	if req.SHA256 != "" && req.SHA256 != hex.EncodeToString(whole.Sum(nil)) {
+		return f, ErrChecksumMismatch
+	}
+	return f, nil
+}
+
+func appendPart(dst io.Writer, partPath, expected string, buf []byte) error {
+	part, err := os.Open(partPath)
+	if os.IsNotExist(err) {
+		return ErrMissingParts
+	} else if err != nil {
+		return err
+	}
+	defer part.Close()
+
+	h := sha256.New()
+	if _, err := io.CopyBuffer(io.MultiWriter(dst, h), part, buf); err != nil {
+		return err
+	}
+	if expected != "" && expected != hex.EncodeToString(h.Sum(nil)) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// randomID returns a hex string of 32 random characters.
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Err(err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,224 @@
+package publish
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/test"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checksum(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// uploadEnv runs upload handlers as an authenticated user whose SDK responds to a single publish call.
+type uploadEnv struct {
+	t         *testing.T
+	handler   *Handler
+	sdkURL    string
+	closeSDK  func()
+	published chan []byte
+}
+
+func newUploadEnv(t *testing.T, sdkResponse string) *uploadEnv {
+	reqChan := test.ReqChan()
+	ts := test.MockHTTPServer(reqChan)
+	published := make(chan []byte, 1)
+	go func() {
+		req := <-reqChan
+		rpcReq := test.StrToReq(t, req.Body)
+		data, _ := ioutil.ReadFile(rpcReq.Params.(map[string]interface{})["file_path"].(string))
+		published <- data
+		ts.NextResponse <- sdkResponse
+	}()
+	uploadPath, err := ioutil.TempDir("", "assembled")
+	require.NoError(t, err)
+	return &uploadEnv{t: t, handler: &Handler{UploadPath: uploadPath}, sdkURL: ts.URL, closeSDK: ts.Close, published: published}
+}
+
+func (e *uploadEnv) close() {
+	e.closeSDK()
+	os.RemoveAll(e.handler.UploadPath)
+}
+
+func (e *uploadEnv) call(fn http.HandlerFunc, method string, body []byte, vars map[string]string, header map[string]string) *httptest.ResponseRecorder {
+	provider := func(token, ip string) (*models.User, error) {
+		u := &models.User{ID: 20404}
+		u.R = u.R.NewStruct()
+		u.R.LbrynetServer = &models.LbrynetServer{Address: e.sdkURL}
+		return u, nil
+	}
+	r := httptest.NewRequest(method, "/api/v1/uploads", bytes.NewReader(body))
+	r.Header.Set(wallet.TokenHeader, "uPldrToken")
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	auth.Middleware(provider)(fn).ServeHTTP(rr, mux.SetURLVars(r, vars))
+	return rr
+}
+
+func (e *uploadEnv) initiate(req map[string]interface{}) initiateResponse {
+	body, err := json.Marshal(req)
+	require.NoError(e.t, err)
+	rr := e.call(e.handler.HandleInitiate, http.MethodPost, body, nil, nil)
+	require.Equal(e.t, http.StatusOK, rr.Code, rr.Body.String())
+	var initiated initiateResponse
+	require.NoError(e.t, json.Unmarshal(rr.Body.Bytes(), &initiated))
+	return initiated
+}
+
+func (e *uploadEnv) complete(id string, req map[string]interface{}) *httptest.ResponseRecorder {
+	body, err := json.Marshal(req)
+	require.NoError(e.t, err)
+	return e.call(e.handler.HandleComplete, http.MethodPost, body, map[string]string{"id": id}, nil)
+}
+
+func TestAssembledUpload(t *testing.T) {
+	config.Override("AssembledUploads", map[string]interface{}{"PartMaxSize": 10, "MaxParts": 3, "TTL": "1h"})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	handler, call := e.handler, e.call
+
+	initiated := e.initiate(map[string]interface{}{"filename": "../lbry_auto_test_file"})
+	assert.EqualValues(t, 10, initiated.MaxPartSize)
+	id := initiated.UploadID
+
+	parts := [][]byte{[]byte("first part"), []byte("second"), []byte("end")}
+	putPart := func(n string, data []byte, header map[string]string) *httptest.ResponseRecorder {
+		return call(handler.HandlePart, http.MethodPut, data, map[string]string{"id": id, "part": n}, header)
+	}
+	// Parts can come in any order
+	for _, n := range []int{3, 1, 2} {
+		rr := putPart(fmt.Sprint(n), parts[n-1], map[string]string{PartChecksumHeader: checksum(parts[n-1])})
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+	assert.Equal(t, http.StatusBadRequest, putPart("2", parts[1], map[string]string{PartChecksumHeader: checksum(parts[0])}).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, putPart("2", []byte("way too long part"), nil).Code)
+	assert.Equal(t, http.StatusBadRequest, putPart("4", parts[0], nil).Code)
+	rr := call(handler.HandlePart, http.MethodPut, parts[0], map[string]string{"id": "0123456789abcdef0123456789abcdef", "part": "1"}, nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	complete := func(req map[string]interface{}) *httptest.ResponseRecorder { return e.complete(id, req) }
+	partList := []map[string]interface{}{
+		{"part": 1, "sha256": checksum(parts[0])},
+		{"part": 2, "sha256": checksum(parts[1])},
+		{"part": 3},
+	}
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+
+	rr = complete(map[string]interface{}{"parts": partList[1:], "json_payload": payload})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = complete(map[string]interface{}{"parts": partList, "sha256": checksum([]byte("something else")), "json_payload": payload})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	whole := bytes.Join(parts, nil)
+	rr = complete(map[string]interface{}{"parts": partList, "sha256": checksum(whole), "json_payload": payload})
+	require.Equal(t, http.StatusOK, rr.Code)
+	test.AssertEqualJSON(t, expectedStreamCreateResponse, rr.Body.Bytes())
+	assert.Equal(t, whole, <-e.published)
+
+	// Parts are gone once the upload is complete
+	assert.Equal(t, http.StatusNotFound, complete(map[string]interface{}{"parts": partList, "json_payload": payload}).Code)
+	_, err := os.Stat(handler.uploadDir(20404, id))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAssembledUploadStatusAndAbort(t *testing.T) {
+	config.Override("AssembledUploads", map[string]interface{}{"PartMaxSize": 10, "MaxParts": 3, "TTL": "1h"})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	handler, call := e.handler, e.call
+
+	id := e.initiate(map[string]interface{}{"filename": "lbry_auto_test_file"}).UploadID
+	vars := map[string]string{"id": id}
+	for _, n := range []string{"3", "1"} {
+		rr := call(handler.HandlePart, http.MethodPut, []byte("part "+n), map[string]string{"id": id, "part": n}, nil)
+		require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	rr := call(handler.HandleStatus, http.MethodGet, nil, vars, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var status uploadStatusResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+	assert.Equal(t, "lbry_auto_test_file", status.Filename)
+	assert.Equal(t, []listedPart{{Part: 1, Size: 6}, {Part: 3, Size: 6}}, status.Parts)
+
+	rr = call(handler.HandleAbort, http.MethodDelete, nil, vars, nil)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	_, err := os.Stat(handler.uploadDir(20404, id))
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, http.StatusNotFound, call(handler.HandleStatus, http.MethodGet, nil, vars, nil).Code)
+	assert.Equal(t, http.StatusNotFound, call(handler.HandleAbort, http.MethodDelete, nil, vars, nil).Code)
+}
+
+// copyStorage keeps files in a separate directory, standing in for a remote storage.
+type copyStorage struct {
+	dir     string
+	removed []string
+}
+
+func (s *copyStorage) Put(key, localPath string) (string, error) {
+	data, err := ioutil.ReadFile(localPath)
+	if err != nil {
+		return "", err
+	}
+	location := path.Join(s.dir, path.Base(key))
+	return location, ioutil.WriteFile(location, data, 0644)
+}
+
+func (s *copyStorage) Remove(key string) error {
+	s.removed = append(s.removed, key)
+	return os.Remove(path.Join(s.dir, path.Base(key)))
+}
+
+func TestAssembledUpload_Storage(t *testing.T) {
+	config.Override("AssembledUploads", map[string]interface{}{"PartMaxSize": 10, "MaxParts": 3, "TTL": "1h"})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	dir, err := ioutil.TempDir("", "stored")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	st := &copyStorage{dir: dir}
+	e.handler.Storage = st
+
+	id := e.initiate(map[string]interface{}{"filename": "lbry_auto_test_file"}).UploadID
+	rr := e.call(e.handler.HandlePart, http.MethodPut, []byte("stored"), map[string]string{"id": id, "part": "1"}, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	rr = e.complete(id, map[string]interface{}{"parts": []map[string]interface{}{{"part": 1}}, "json_payload": payload})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+
+	// The SDK reads the file from storage, which is cleaned up after publishing
+	assert.Equal(t, []byte("stored"), <-e.published)
+	require.Len(t, st.removed, 1)
+	assert.Regexp(t, `^20404/\d+_lbry_auto_test_file$`, st.removed[0])
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
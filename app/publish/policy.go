@@ -0,0 +1,96 @@
+package publish
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+)
+
+const (
+	paramChannelID = "channel_id"
+	paramName      = "name"
+	paramTags      = "tags"
+	paramLicense   = "license"
+	paramBid       = "bid"
+)
+
+// applyPolicy fills in template values and validates publish params against the policy
+// of the channel being published into. Params for channels without a policy are left untouched.
+func applyPolicy(params map[string]interface{}, policies map[string]config.PublishPolicy) error {
+	channelID, _ := params[paramChannelID].(string)
+	if channelID == "" {
+		return nil
+	}
+	policy, ok := policies[strings.ToLower(channelID)]
+	if !ok {
+		return nil
+	}
+
+	for k, v := range policy.Template {
+		if _, ok := params[k]; !ok {
+			params[k] = v
+		}
+	}
+
+	violations := []string{}
+	if len(policy.RequiredTags) > 0 {
+		tags := map[string]bool{}
+		if list, ok := params[paramTags].([]interface{}); ok {
+			for _, t := range list {
+				tags[fmt.Sprint(t)] = true
+			}
+		}
+		missing := []string{}
+		for _, t := range policy.RequiredTags {
+			if !tags[t] {
+				missing = append(missing, t)
+			}
+		}
+		if len(missing) > 0 {
+			violations = append(violations, fmt.Sprintf("missing required tags: %v", strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(policy.AllowedLicenses) > 0 {
+		license, _ := params[paramLicense].(string)
+		allowed := false
+		for _, l := range policy.AllowedLicenses {
+			if l == license {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			violations = append(violations, fmt.Sprintf("license %q is not allowed", license))
+		}
+	}
+
+	if policy.MaxBid > 0 {
+		bid, err := strconv.ParseFloat(fmt.Sprint(params[paramBid]), 64)
+		if err != nil {
+			violations = append(violations, "bid is invalid")
+		} else if bid > policy.MaxBid {
+			violations = append(violations, fmt.Sprintf("bid %v exceeds maximum of %v", bid, policy.MaxBid))
+		}
+	}
+
+	if policy.NamePattern != "" {
+		re, err := regexp.Compile(policy.NamePattern)
+		if err != nil {
+			logger.Log().Errorf("invalid name pattern in publish policy for channel %v: %v", channelID, err)
+			return fmt.Errorf("publish policy for channel %v is misconfigured", channelID)
+		}
+		name, _ := params[paramName].(string)
+		if !re.MatchString(name) {
+			violations = append(violations, fmt.Sprintf("name %q does not match %v", name, policy.NamePattern))
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("publish policy of channel %v violated: %v", channelID, strings.Join(violations, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package publish
+
+import (
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicies = map[string]config.PublishPolicy{
+	"3fda836a92faaceedfe398225fb9b2ee2ed1f01a": {
+		Template:        map[string]interface{}{"license": "CC BY 4.0", "languages": []interface{}{"en"}},
+		RequiredTags:    []string{"news", "daily"},
+		AllowedLicenses: []string{"CC BY 4.0", "Public Domain"},
+		MaxBid:          1.0,
+		NamePattern:     "^news-[a-z0-9-]+$",
+	},
+}
+
+func TestApplyPolicy(t *testing.T) {
+	params := map[string]interface{}{
+		"channel_id": "3fda836a92faaceedfe398225fb9b2ee2ed1f01a",
+		"name":       "news-2020-06-10",
+		"bid":        "0.5",
+		"tags":       []interface{}{"daily", "news", "politics"},
+	}
+	require.NoError(t, applyPolicy(params, testPolicies))
+	assert.Equal(t, "CC BY 4.0", params["license"])
+	assert.Equal(t, []interface{}{"en"}, params["languages"])
+}
+
+func TestApplyPolicy_KeepsSuppliedValues(t *testing.T) {
+	params := map[string]interface{}{
+		"channel_id": "3fda836a92faaceedfe398225fb9b2ee2ed1f01a",
+		"name":       "news-2020-06-10",
+		"bid":        "0.5",
+		"tags":       []interface{}{"daily", "news"},
+		"license":    "Public Domain",
+	}
+	require.NoError(t, applyPolicy(params, testPolicies))
+	assert.Equal(t, "Public Domain", params["license"])
+}
+
+func TestApplyPolicy_Violations(t *testing.T) {
+	params := map[string]interface{}{
+		"channel_id": "3fda836a92faaceedfe398225fb9b2ee2ed1f01a",
+		"name":       "Breaking",
+		"bid":        "5.0",
+		"tags":       []interface{}{"news"},
+		"license":    "All rights reserved",
+	}
+	err := applyPolicy(params, testPolicies)
+	require.Error(t, err)
+	assert.Equal(t,
+		`publish policy of channel 3fda836a92faaceedfe398225fb9b2ee2ed1f01a violated: `+
+			`missing required tags: daily; license "All rights reserved" is not allowed; `+
+			`bid 5 exceeds maximum of 1; name "Breaking" does not match ^news-[a-z0-9-]+$`,
+		err.Error())
+}
+
+func TestApplyPolicy_NoPolicy(t *testing.T) {
+	params := map[string]interface{}{"channel_id": "beef", "name": "anything", "bid": "100"}
+	require.NoError(t, applyPolicy(params, testPolicies))
+	assert.Len(t, params, 3)
+
+	params = map[string]interface{}{"name": "anything", "bid": "100"}
+	require.NoError(t, applyPolicy(params, testPolicies))
+}
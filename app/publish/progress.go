@@ -0,0 +1,274 @@
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/websocket"
+
+	"github.com/gorilla/mux"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Upload progress is reported over a WebSocket at /api/v1/publish/progress/{upload_id}, from the first byte
+// received to the SDK responding to the publish. Multipart uploads are identified by UploadIDHeader set
+// by the client, resumable and assembled uploads by their IDs. Upload IDs are random, so knowing one
+// is enough to follow the upload.
+
+const (
+	// UploadIDHeader identifies a multipart upload for progress reporting.
+	UploadIDHeader = "X-Upload-Id"
+
+	ProgressUploading  = "uploading"
+	ProgressProcessing = "processing"
+	ProgressDone       = "done"
+	ProgressFailed     = "failed"
+
+	// progressInterval limits how often byte counts are reported.
+	progressInterval = 250 * time.Millisecond
+	// progressRetention is how long the final state is kept for clients connecting late.
+	progressRetention = time.Minute
+	// progressIdleTimeout closes connections which haven't seen any progress for a while.
+	progressIdleTimeout = 10 * time.Minute
+)
+
+var uploadIDRe = regexp.MustCompile(`^[0-9A-Za-z_-]{16,64}$`)
+
+// ProgressEvent is the state of an upload, sent to progress subscribers every time it changes.
+type ProgressEvent struct {
+	UploadID string `json:"upload_id"`
+	Stage    string `json:"stage"`
+	Bytes    int64  `json:"bytes"`
+	// Total is 0 when the upload size is not known.
+	Total int64  `json:"total,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type progressEntry struct {
+	last ProgressEvent
+	// subs are signalled when last changes, subscribers always read the latest state so no final event is lost.
+	subs map[chan struct{}]bool
+}
+
+type progressHub struct {
+	mu      sync.Mutex
+	entries map[string]*progressEntry
+	// files maps paths of uploaded files to upload IDs, so publishing can be reported wherever it happens.
+	files map[string]string
+}
+
+var progress = &progressHub{entries: map[string]*progressEntry{}, files: map[string]string{}}
+
+func (p *progressHub) entry(uploadID string) *progressEntry {
+	e, ok := p.entries[uploadID]
+	if !ok {
+		e = &progressEntry{last: ProgressEvent{UploadID: uploadID}, subs: map[chan struct{}]bool{}}
+		p.entries[uploadID] = e
+	}
+	return e
+}
+
+// emit updates the state of an upload and notifies its subscribers.
+func (p *progressHub) emit(ev ProgressEvent) {
+	if ev.UploadID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := p.entry(ev.UploadID)
+	e.last = ev
+	for ch := range e.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+	if ev.Stage == ProgressDone || ev.Stage == ProgressFailed {
+		time.AfterFunc(progressRetention, func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if e, ok := p.entries[ev.UploadID]; ok && e.last == ev {
+				delete(p.entries, ev.UploadID)
+			}
+		})
+	}
+}
+
+// subscribe returns a channel signalled on every change of the upload state and a function to stop it.
+func (p *progressHub) subscribe(uploadID string) (<-chan struct{}, func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch := make(chan struct{}, 1)
+	e := p.entry(uploadID)
+	e.subs[ch] = true
+	if e.last.Stage != "" {
+		ch <- struct{}{}
+	}
+	return ch, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(e.subs, ch)
+		// Entries nothing was reported for are dropped along with their last subscriber
+		if len(e.subs) == 0 && e.last.Stage == "" && p.entries[uploadID] == e {
+			delete(p.entries, uploadID)
+		}
+	}
+}
+
+func (p *progressHub) state(uploadID string) ProgressEvent {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.entries[uploadID]; ok {
+		return e.last
+	}
+	return ProgressEvent{UploadID: uploadID}
+}
+
+// link associates the uploaded file at filePath with uploadID, so publishing it is reported as its progress.
+func (p *progressHub) link(filePath, uploadID string) {
+	if uploadID == "" {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files[filePath] = uploadID
+}
+
+// unlink removes the association of filePath and returns the upload ID it had.
+func (p *progressHub) unlink(filePath string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := p.files[filePath]
+	delete(p.files, filePath)
+	return id
+}
+
+// progressReader reports bytes read through it as upload progress.
+type progressReader struct {
+	io.ReadCloser
+	uploadID     string
+	bytes, total int64
+	reported     time.Time
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bytes += int64(n)
+	if err == io.EOF || time.Since(r.reported) >= progressInterval {
+		r.reported = time.Now()
+		progress.emit(ProgressEvent{UploadID: r.uploadID, Stage: ProgressUploading, Bytes: r.bytes, Total: r.total})
+	}
+	return n, err
+}
+
+// outcomeWriter keeps the publish response to report its outcome as upload progress.
+type outcomeWriter struct {
+	http.ResponseWriter
+	uploadID string
+	size     int64
+	body     bytes.Buffer
+}
+
+func (w *outcomeWriter) Write(p []byte) (int, error) {
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *outcomeWriter) report() {
+	ev := ProgressEvent{UploadID: w.uploadID, Stage: ProgressDone, Bytes: w.size, Total: w.size}
+	var res jsonrpc.RPCResponse
+	if err := json.Unmarshal(w.body.Bytes(), &res); err != nil {
+		ev.Stage, ev.Error = ProgressFailed, "malformed publish response"
+	} else if res.Error != nil {
+		ev.Stage, ev.Error = ProgressFailed, res.Error.Message
+	}
+	progress.emit(ev)
+}
+
+// trackPublish reports publishing of the file at filePath as progress of the upload it came from, if any.
+// The returned function reports the outcome once the response has been written to the returned writer.
+func trackPublish(w http.ResponseWriter, filePath string) (http.ResponseWriter, func()) {
+	id := progress.unlink(filePath)
+	if id == "" {
+		return w, func() {}
+	}
+	var size int64
+	if fi, err := os.Stat(filePath); err == nil {
+		size = fi.Size()
+	}
+	progress.emit(ProgressEvent{UploadID: id, Stage: ProgressProcessing, Bytes: size, Total: size})
+	ow := &outcomeWriter{ResponseWriter: w, uploadID: id, size: size}
+	return ow, ow.report
+}
+
+// uploadID returns the progress ID of a multipart upload, if the client set a valid one.
+func uploadID(r *http.Request) string {
+	id := r.Header.Get(UploadIDHeader)
+	if !uploadIDRe.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// TrackProgress reports how much of the body of multipart uploads carrying UploadIDHeader has been received.
+// Uploads are parsed while routes are matched, so it has to wrap the router rather than be a route middleware.
+func TrackProgress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := uploadID(r); id != "" && r.Method == http.MethodPost {
+			body := &progressReader{ReadCloser: r.Body, uploadID: id}
+			if r.ContentLength > 0 {
+				body.total = r.ContentLength
+			}
+			r.Body = body
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleProgress streams progress of the upload in the URL over a WebSocket until it's published.
+func (h Handler) HandleProgress(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["upload_id"]
+	if !uploadIDRe.MatchString(id) {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid upload ID"))
+		return
+	}
+	conn, err := websocket.Upgrade(w, r)
+	if errors.Is(err, websocket.ErrNotWebSocket) {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error upgrading progress connection: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	changed, unsubscribe := progress.subscribe(id)
+	defer unsubscribe()
+	idle := time.NewTimer(progressIdleTimeout)
+	defer idle.Stop()
+	for {
+		select {
+		case <-changed:
+			ev := progress.state(id)
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+			if ev.Stage == ProgressDone || ev.Stage == ProgressFailed {
+				return
+			}
+			idle.Reset(progressIdleTimeout)
+		case <-conn.Done():
+			return
+		case <-idle.C:
+			return
+		}
+	}
+}
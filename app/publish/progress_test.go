@@ -0,0 +1,76 @@
+package publish
+
+import (
+	"net/http"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressHub(t *testing.T) {
+	p := &progressHub{entries: map[string]*progressEntry{}, files: map[string]string{}}
+	changed, unsubscribe := p.subscribe("upload1")
+	assert.Len(t, changed, 0)
+
+	p.emit(ProgressEvent{UploadID: "upload1", Stage: ProgressUploading, Bytes: 5, Total: 10})
+	p.emit(ProgressEvent{UploadID: "upload1", Stage: ProgressUploading, Bytes: 10, Total: 10})
+	// Changes are coalesced, subscribers read the latest state
+	<-changed
+	assert.Len(t, changed, 0)
+	assert.EqualValues(t, 10, p.state("upload1").Bytes)
+
+	// Late subscribers get the current state right away
+	late, unsubscribeLate := p.subscribe("upload1")
+	assert.Len(t, late, 1)
+	unsubscribeLate()
+	unsubscribe()
+	assert.Equal(t, ProgressUploading, p.state("upload1").Stage)
+
+	_, unsubscribe = p.subscribe("upload2")
+	unsubscribe()
+	assert.NotContains(t, p.entries, "upload2")
+}
+
+func TestProgress_Multipart(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	id := "f0e1d2c3b4a59687"
+
+	body, contentType := multipartUpload(t, []byte("tracked file"), nil)
+
+	changed, unsubscribe := progress.subscribe(id)
+	defer unsubscribe()
+	handle := TrackProgress(http.HandlerFunc(e.handler.Handle)).ServeHTTP
+	rr := e.call(handle, http.MethodPost, body, nil, map[string]string{
+		"Content-Type": contentType, UploadIDHeader: id})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	assert.Equal(t, []byte("tracked file"), <-e.published)
+
+	<-changed
+	assert.Equal(t, ProgressEvent{UploadID: id, Stage: ProgressDone, Bytes: 12, Total: 12}, progress.state(id))
+	assert.Empty(t, progress.files)
+}
+
+func TestProgress_Tus(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	header := map[string]string{"Tus-Resumable": TusVersion, "Upload-Length": "10",
+		"Upload-Metadata": "filename ZmlsZS50eHQ="}
+	rr := e.call(e.handler.HandleTusCreate, http.MethodPost, nil, nil, header)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	vars := map[string]string{"id": path.Base(rr.Header().Get("Location"))}
+
+	rr = e.call(e.handler.HandleTusPatch, http.MethodPatch, []byte("first"), vars, map[string]string{
+		"Tus-Resumable": TusVersion, "Content-Type": tusContentType, "Upload-Offset": "0"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	assert.Equal(t, ProgressEvent{UploadID: vars["id"], Stage: ProgressUploading, Bytes: 5, Total: 10}, progress.state(vars["id"]))
+}
+
+func TestHandleProgress_NotWebSocket(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	rr := e.call(e.handler.HandleProgress, http.MethodGet, nil, map[string]string{"upload_id": "f0e1d2c3b4a59687"}, nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -1,6 +1,8 @@
 package publish
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,17 +10,36 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"sync/atomic"
 
 	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/availability"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/delegation"
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/app/fraud"
+	"github.com/lbryio/lbrytv/app/organization"
 	"github.com/lbryio/lbrytv/app/proxy"
+	"github.com/lbryio/lbrytv/app/published"
+	"github.com/lbryio/lbrytv/app/quarantine"
 	"github.com/lbryio/lbrytv/app/query"
 	"github.com/lbryio/lbrytv/app/query/cache"
 	"github.com/lbryio/lbrytv/app/rpcerrors"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/taxonomy"
+	"github.com/lbryio/lbrytv/app/torrent"
+	"github.com/lbryio/lbrytv/app/uploadtoken"
+	"github.com/lbryio/lbrytv/app/urlfilter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/bufpool"
 	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
 	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
 	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/slo"
+	"github.com/lbryio/lbrytv/models"
 
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -27,6 +48,14 @@ import (
 
 var logger = monitor.NewModuleLogger("publish")
 
+// activeUploads is the number of publish requests currently being handled.
+var activeUploads int32
+
+// ActiveUploads returns the number of publish requests currently being handled.
+func ActiveUploads() int {
+	return int(atomic.LoadInt32(&activeUploads))
+}
+
 const (
 	// fileFieldName refers to the POST field containing file upload
 	fileFieldName = "file"
@@ -34,19 +63,26 @@ const (
 	jsonRPCFieldName = "json_payload"
 
 	fileNameParam = "file_path"
+	// fileNameHookName is the preflight hook setting fileNameParam, it runs early so other hooks see the path.
+	fileNameHookName = "publish_file_path"
 
 	opName = "publish"
 )
 
-// Handler has path to save uploads to
+// Handler has path to save uploads to and storage to hand them to the SDK from,
+// files are given to the SDK by their local path when Storage is not set.
+// Uploads are checked for malware with Scanner when it's set.
 type Handler struct {
 	UploadPath string
+	Storage    filestore.Storage
+	Scanner    Scanner
 }
 
 var method = "publish"
 
 // observeFailure requires metrics.MeasureMiddleware middleware to be present on the request
 func observeFailure(d float64, kind string) {
+	slo.Observe(slo.ClassPublish, d, kind)
 	metrics.ProxyE2ECallDurations.WithLabelValues(method).Observe(d)
 	metrics.ProxyE2ECallFailedDurations.WithLabelValues(method, kind).Observe(d)
 	metrics.ProxyE2ECallCounter.WithLabelValues(method).Inc()
@@ -55,6 +91,7 @@ func observeFailure(d float64, kind string) {
 
 // observeSuccess requires metrics.MeasureMiddleware middleware to be present on the request
 func observeSuccess(d float64) {
+	slo.Observe(slo.ClassPublish, d, "")
 	metrics.ProxyE2ECallDurations.WithLabelValues(method).Observe(d)
 	metrics.ProxyE2ECallCounter.WithLabelValues(method).Inc()
 }
@@ -63,8 +100,35 @@ func observeSuccess(d float64) {
 // It should be wrapped with users.Authenticator.Wrap before it can be used
 // in a mux.Router.
 func (h Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	if limitRequestBody(r) || isBodyTooLarge(r.ParseMultipartForm(maxFormMemory)) {
+		w.Write(rpcerrors.ToJSON(fileTooLarge(config.GetUploadLimits(), r.ContentLength)))
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	}
+
 	user, err := auth.FromRequest(r)
-	if authErr := proxy.GetAuthError(user, err); authErr != nil {
+	// Upload tokens stand in for auth tokens of the user they were issued to, within their scope
+	var token *uploadtoken.Token
+	if t := r.Header.Get(uploadtoken.Header); t != "" {
+		// Checked before the token is used up, so a rejected call doesn't burn it
+		var head struct {
+			Method string `json:"method"`
+		}
+		json.Unmarshal([]byte(r.FormValue(jsonRPCFieldName)), &head)
+		if !uploadtoken.AllowsMethod(head.Method) {
+			w.Write(rpcerrors.NewMethodNotAllowedError(uploadtoken.ErrMethodForbidden).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		}
+		token, err = uploadtoken.Consume(t)
+		if err == nil {
+			user, err = wallet.GetDBUserG(token.UserID)
+		}
+	}
+	if authErr := auth.Check(user, err); authErr != nil {
 		w.Write(rpcerrors.ErrorToJSON(authErr))
 		observeFailure(metrics.GetDuration(r), metrics.FailureKindAuth)
 		return
@@ -76,24 +140,108 @@ func (h Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	log := logger.WithFields(logrus.Fields{"user_id": user.ID, "method_handler": method})
-
-	f, err := h.saveFile(r, user.ID)
-	if err != nil {
-		log.Error(err)
+	rawReq := []byte(r.FormValue(jsonRPCFieldName))
+	var (
+		f        *os.File
+		origName string
+	)
+	if hasFile(r) {
+		f, err = h.saveFile(r, user.ID)
+		origName = uploadedName(r)
+	} else {
+		f, origName, err = h.downloadSource(user.ID, sourceURL(rawReq))
+	}
+	// Reported once the upload is counted, so clients see what's left for the next one
+	reportQuota(r, user.ID)
+	var rpcErr rpcerrors.RPCError
+	if errors.As(err, &rpcErr) {
+		logger.WithFields(logrus.Fields{"user_id": user.ID, "method_handler": method}).Info(err)
+		w.Write(rpcErr.JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	} else if err != nil {
+		logger.WithFields(logrus.Fields{"user_id": user.ID, "method_handler": method}).Error(err)
 		monitor.ErrorToSentry(err)
 		w.Write(rpcerrors.NewInternalError(err).JSON())
 		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
 		return
 	}
+	if isAsync(r) {
+		h.enqueue(w, user, f, origName, rawReq, token)
+		return
+	}
+	h.publish(w, r, user, f, origName, rawReq, token)
+}
+
+// publish inspects the uploaded file f and sends the publish request rawReq for it to the SDK,
+// writing the response to w. The file is removed afterwards. Token limits the file when it was uploaded with one.
+func (h Handler) publish(w http.ResponseWriter, r *http.Request, user *models.User, f *os.File, origName string, rawReq []byte, token *uploadtoken.Token) {
+	log := logger.WithFields(logrus.Fields{"user_id": user.ID, "method_handler": method})
+
 	defer func() {
 		op := metrics.StartOperation(opName, "remove_file")
 		defer op.End()
 
-		if err := os.Remove(f.Name()); err != nil {
+		// Quarantined files and ones kept for delta uploads have already been moved away
+		if err := os.Remove(f.Name()); err != nil && !os.IsNotExist(err) {
 			monitor.ErrorToSentry(err, map[string]string{"file_path": f.Name()})
 		}
 	}()
+	w, reportProgress := trackPublish(w, f.Name())
+	defer reportProgress()
+	fileHash, _ := fileHashes.Load(f.Name())
+	fileHashes.Delete(f.Name())
+
+	mediaType, err := sniffMediaType(f.Name())
+	if err != nil {
+		log.Error(err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+	if err := checkMediaType(mediaType); err != nil {
+		log.Infof("rejected uploaded file %v: %v", path.Base(f.Name()), err)
+		w.Write(rpcerrors.ToJSON(err))
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	}
+
+	var rpcErr rpcerrors.RPCError
+	if err := h.scanUpload(f.Name(), user.ID); errors.As(err, &rpcErr) {
+		log.Warnf("blocked uploaded file %v: %v", path.Base(f.Name()), err)
+		w.Write(rpcErr.JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	} else if err != nil {
+		log.Error(err)
+		monitor.ErrorToSentry(err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+
+	qf, err := quarantine.Inspect(f.Name(), user.ID, path.Base(f.Name()))
+	if err != nil {
+		log.Error(err)
+		monitor.ErrorToSentry(err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	} else if qf != nil {
+		log.Warnf("uploaded file %v quarantined (id %v)", qf.FileName, qf.ID)
+		w.Write(rpcerrors.NewInvalidParamsError(quarantine.ErrFlagged).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	}
+
+	if token != nil {
+		if err := token.Check(f.Name(), origName); err != nil {
+			log.Info(err)
+			w.Write(rpcerrors.NewInvalidParamsError(err).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		}
+	}
 
 	var qCache cache.QueryCache
 	if cache.IsOnRequest(r) {
@@ -101,18 +249,132 @@ func (h Handler) Handle(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var rpcReq *jsonrpc.RPCRequest
-	err = json.Unmarshal([]byte(r.FormValue(jsonRPCFieldName)), &rpcReq)
+	err = json.Unmarshal(rawReq, &rpcReq)
 	if err != nil {
 		w.Write(rpcerrors.NewJSONParseError(err).JSON())
 		observeFailure(metrics.GetDuration(r), metrics.FailureKindClientJSON)
 		return
 	}
 
-	c := getCaller(sdkrouter.GetSDKAddress(user), f.Name(), user.ID, qCache)
+	var channelID string
+	var suggested *suggestions
+	var tags []string
+	if params, ok := rpcReq.Params.(map[string]interface{}); ok {
+		channelID, _ = params[paramChannelID].(string)
+		tags, err = normalizeTags(params)
+		if err != nil {
+			log.Info(err)
+			w.Write(rpcerrors.ToJSON(err))
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		}
+		if err := applyPolicy(params, config.GetPublishPolicies()); err != nil {
+			log.Info(err)
+			w.Write(rpcerrors.NewInvalidParamsError(err).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		}
+		// Scored before params are filled in with details of the file, which differ between uploads of it
+		op := fraud.Operation{Kind: fraud.KindPublish, Method: rpcReq.Method, UserID: user.ID, RemoteIP: ip.FromRequest(r), Params: params}
+		if err := fraud.Check(r.Context(), op); err != nil {
+			log.Info(err)
+			w.Write(rpcerrors.ToJSON(err))
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		}
+		// The SDK doesn't know the param, the file has been downloaded from it already
+		delete(params, sourceURLParam)
+		suggested = analyzeUpload(f.Name(), params)
+		addThumbnail(f.Name(), mediaType, params)
+		if fileHash != nil {
+			params[fileHashParam] = fileHash
+		}
+		if mediaType != mediaTypeUnknown {
+			params[mediaTypeParam] = mediaType
+		}
+	}
+
+	// Publishes into a delegated channel go to the owner's wallet, which holds the channel keys.
+	publisher, err := delegation.Publisher(user, channelID)
+	if err != nil {
+		log.Errorf("cannot resolve publisher for channel %v: %v", channelID, err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+	if publisher.ID != user.ID {
+		params, _ := rpcReq.Params.(map[string]interface{})
+		err := delegation.CheckCall(publisher, channelID, rpcReq.Method, params)
+		switch {
+		case errors.Is(err, delegation.ErrMethodForbidden):
+			log.Infof("rejected %v in delegated channel %v", rpcReq.Method, channelID)
+			w.Write(rpcerrors.NewMethodNotAllowedError(err).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		case errors.Is(err, delegation.ErrParamForbidden), errors.Is(err, delegation.ErrClaimNotInChannel):
+			log.Infof("rejected %v in delegated channel %v: %v", rpcReq.Method, channelID, err)
+			w.Write(rpcerrors.NewForbiddenError(err).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+			return
+		case err != nil:
+			log.Errorf("cannot check call in delegated channel %v: %v", channelID, err)
+			w.Write(rpcerrors.NewInternalError(err).JSON())
+			observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+			return
+		}
+		delegation.LogPublish(user, publisher, channelID, ip.FromRequest(r), rpcReq)
+	}
+
+	// The file has been closed once written
+	stat, err := os.Stat(f.Name())
+	if err != nil {
+		log.Error(err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+	releaseQuota, err := organization.ReserveUpload(user.ID, stat.Size())
+	if errors.Is(err, organization.ErrQuotaExceeded) {
+		log.Info(err)
+		organization.NotifyQuotaExhausted(r)
+		w.Write(rpcerrors.NewInvalidParamsError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindClient)
+		return
+	} else if err != nil {
+		log.Errorf("cannot reserve organization quota: %v", err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+
+	location, removeStored, err := h.store(user.ID, f.Name())
+	if err != nil {
+		releaseQuota()
+		log.Errorf("cannot store uploaded file: %v", err)
+		monitor.ErrorToSentry(err)
+		w.Write(rpcerrors.NewInternalError(err).JSON())
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindInternal)
+		return
+	}
+	defer removeStored()
+
+	c := getCaller(sdkrouter.GetSDKAddress(publisher), location, publisher.ID, qCache)
+	c.Deadline = proxy.Deadline(r, method, slo.ClassPublish)
+	c.Context = r.Context()
 
 	op := metrics.StartOperation("sdk", "call_publish")
 	rpcRes, err := c.Call(rpcReq)
 	op.End()
+	if err != nil || rpcRes.Error != nil {
+		releaseQuota()
+	}
+	if errors.Is(err, query.ErrLatencyBudgetExceeded) {
+		log.Warn(err)
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write(rpcerrors.ToJSON(err))
+		observeFailure(metrics.GetDuration(r), metrics.FailureKindTimeout)
+		return
+	}
 	if err != nil {
 		monitor.ErrorToSentry(
 			fmt.Errorf("error calling publish: %v", err),
@@ -126,8 +388,22 @@ func (h Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		observeFailure(metrics.GetDuration(r), metrics.FailureKindRPC)
 		return
 	}
+	if rpcRes.Error == nil {
+		taxonomy.RecordUse(tags)
+		if fileHash != nil {
+			recordUpload(user.ID, fileHash.(string), rpcRes)
+		}
+	}
+	// Claims in delegated channels belong to the owner
+	torrent.Seed(publisher.ID, f.Name(), rpcRes)
+	h.keepBasis(user.ID, f.Name(), rpcRes)
+	if result, ok := rpcRes.Result.(map[string]interface{}); ok && suggested != nil && rpcRes.Error == nil {
+		result[suggestionsField] = suggested
+	}
 
-	serialized, err := responses.JSONRPCSerialize(rpcRes)
+	serialized := bufpool.GetBuffer(0)
+	defer bufpool.PutBuffer(serialized)
+	err = responses.JSONRPCSerializeTo(serialized, rpcRes)
 	if err != nil {
 		monitor.ErrorToSentry(err)
 		logger.Log().Errorf("error marshaling response: %v", err)
@@ -136,59 +412,181 @@ func (h Handler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Write(serialized)
+	w.Write(serialized.Bytes())
 	observeSuccess(metrics.GetDuration(r))
 }
 
 func getCaller(sdkAddress, filename string, userID int, qCache cache.QueryCache) *query.Caller {
 	c := query.NewCaller(sdkAddress, userID)
 	c.Cache = qCache
-	c.AddPreflightHook(query.AllMethodsHook, func(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	channels.InstallHooks(c)
+	urlfilter.InstallHooks(c)
+	published.InstallHooks(c)
+	availability.InstallHooks(c)
+	c.RegisterHook(fileNameHookName, query.StagePreflight, func(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
 		params := hctx.Query.ParamsAsMap()
 		params[fileNameParam] = filename
 		hctx.Query.Request.Params = params
 		return nil, nil
-	}, "")
+	}, query.HookOptions{Method: query.AllMethodsHook, Priority: query.HookPriorityEarly})
 	return c
 }
 
-// CanHandle checks if http.Request contains POSTed data in an accepted format.
+// CanHandle checks if http.Request contains POSTed data in an accepted format: a file upload
+// or a publish request with source_url to download the file from, see downloadSource.
 // Supposed to be used in gorilla mux router MatcherFunc.
 func (h Handler) CanHandle(r *http.Request, _ *mux.RouteMatch) bool {
+	// Oversized uploads are left for Handle to turn down
+	if limitRequestBody(r) {
+		return true
+	}
+	rawReq := r.FormValue(jsonRPCFieldName)
+	if rawReq == "" {
+		return isBodyTooLarge(r.ParseMultipartForm(maxFormMemory))
+	}
+	return hasFile(r) || sourceURL([]byte(rawReq)) != ""
+}
+
+// hasFile checks if the request carries an uploaded file.
+func hasFile(r *http.Request) bool {
 	_, _, err := r.FormFile(fileFieldName)
-	return !errors.Is(err, http.ErrMissingFile) && r.FormValue(jsonRPCFieldName) != ""
+	return !errors.Is(err, http.ErrMissingFile) && !errors.Is(err, http.ErrNotMultipart)
+}
+
+// uploadedName returns the file name the client sent the upload under.
+func uploadedName(r *http.Request) string {
+	file, header, err := r.FormFile(fileFieldName)
+	if err != nil {
+		return ""
+	}
+	file.Close()
+	return header.Filename
 }
 
 func (h Handler) saveFile(r *http.Request, userID int) (*os.File, error) {
 	op := metrics.StartOperation(opName, "save_file")
 	defer op.End()
 
-	log := logger.WithFields(logrus.Fields{"user_id": userID, "method_handler": method})
-
 	file, header, err := r.FormFile(fileFieldName)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	f, err := h.createFile(userID, header.Filename)
+	f, err := h.writeUpload(userID, header.Filename, header.Size, file)
 	if err != nil {
 		return nil, err
 	}
-	log.Infof("processing uploaded file %v", header.Filename)
+	progress.link(f.Name(), uploadID(r))
+	return f, nil
+}
+
+// writeUpload saves src into a new file of the user, counting size against upload limits first.
+// When size is not known upfront (-1), the number of bytes actually saved is counted afterwards.
+// The file is hashed while it's written and rejected if it duplicates a recent upload.
+func (h Handler) writeUpload(userID int, name string, size int64, src io.Reader) (*os.File, error) {
+	log := logger.WithFields(logrus.Fields{"user_id": userID, "method_handler": method})
 
-	numWritten, err := io.Copy(f, file)
+	release := func() {}
+	if size >= 0 {
+		var err error
+		if release, err = reserveUpload(userID, size); err != nil {
+			return nil, err
+		}
+	}
+	f, err := h.createFile(userID, name)
 	if err != nil {
+		release()
 		return nil, err
 	}
-	log.Infof("saved uploaded file %v (%v bytes written)", f.Name(), numWritten)
+	log.Infof("processing uploaded file %v", name)
+
+	// The file is hashed while it's written so it doesn't have to be read again
+	buf := bufpool.GetBytes(bufpool.CopyBufferSize)
+	defer bufpool.PutBytes(buf)
+	hash := sha512.New384()
+	numWritten, err := io.CopyBuffer(io.MultiWriter(f, hash), src, *buf)
+	if err != nil {
+		release()
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	fileHash := hex.EncodeToString(hash.Sum(nil))
+	log.Infof("saved uploaded file %v (%v bytes written, sha384 %v)", f.Name(), numWritten, fileHash)
 
 	if err := f.Close(); err != nil {
 		return nil, err
 	}
+	if size < 0 {
+		if release, err = reserveUpload(userID, numWritten); err != nil {
+			os.Remove(f.Name())
+			return nil, err
+		}
+	}
+	if err := checkDuplicate(userID, fileHash); err != nil {
+		release()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	fileHashes.Store(f.Name(), fileHash)
 	return f, nil
 }
 
+// admitFile puts a file which was received by other means than writeUpload, like tus, part uploads or imports,
+// through the same upload limits and duplicate check. The caller is left to remove the file on errors.
+func (h Handler) admitFile(userID int, filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return errors.Err(err)
+	}
+
+	release, err := reserveUpload(userID, fi.Size())
+	if err != nil {
+		return err
+	}
+	buf := bufpool.GetBytes(bufpool.CopyBufferSize)
+	defer bufpool.PutBytes(buf)
+	hash := sha512.New384()
+	if _, err := io.CopyBuffer(hash, f, *buf); err != nil {
+		release()
+		return errors.Err(err)
+	}
+	fileHash := hex.EncodeToString(hash.Sum(nil))
+	if err := checkDuplicate(userID, fileHash); err != nil {
+		release()
+		return err
+	}
+	fileHashes.Store(filePath, fileHash)
+	return nil
+}
+
+// store hands the uploaded file to the storage SDK nodes read it from. It returns the location to give the SDK
+// and a function removing the file from storage, to be called once the SDK is done with it.
+func (h Handler) store(userID int, filePath string) (string, func(), error) {
+	if h.Storage == nil {
+		return filePath, func() {}, nil
+	}
+	op := metrics.StartOperation(opName, "store_file")
+	defer op.End()
+
+	key := fmt.Sprintf("%d/%s", userID, path.Base(filePath))
+	location, err := h.Storage.Put(key, filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	return location, func() {
+		if err := h.Storage.Remove(key); err != nil {
+			logger.Log().Errorf("error removing stored file %v: %v", key, err)
+		}
+	}, nil
+}
+
 // createFile opens an empty file for writing inside the account's designated folder.
 // The final file path looks like `/upload_path/{user_id}/{random}_filename.ext`,
 // where `user_id` is user's ID and `random` is a random string generated by ioutil.
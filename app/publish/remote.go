@@ -0,0 +1,186 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"syscall"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Instead of uploading a file, creators whose content is already online can put its URL into the source_url param
+// of the publish request. lbrytv downloads the file itself and publishes it just like an uploaded one.
+
+const (
+	sourceURLParam = "source_url"
+	// defaultSourceName is used for downloaded files when neither the response nor the URL tell their name.
+	defaultSourceName = "download"
+)
+
+var (
+	ErrInvalidSourceURL = errors.Base("source_url must be an http or https URL")
+	ErrSourceNotAllowed = errors.Base("source_url must point to a public address")
+	ErrSourceTooLarge   = errors.Base("file at source_url is larger than allowed")
+
+	// publicTransport refuses to connect to private addresses. They are checked once the host name is resolved,
+	// so neither redirects nor DNS records pointing at internal services can get around it.
+	publicTransport = sourceTransport(func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if !isPublicIP(net.ParseIP(host)) {
+			return ErrSourceNotAllowed
+		}
+		return nil
+	})
+	// privateTransport connects anywhere, it's used when RemoteSources.AllowPrivateNetworks is set.
+	privateTransport = sourceTransport(nil)
+)
+
+// sourceTransport returns a transport for source downloads, with control run on every connection made.
+func sourceTransport(control func(network, address string, c syscall.RawConn) error) *http.Transport {
+	return &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second, Control: control}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+	}
+}
+
+// sourceClient returns the client downloading sources as set in cfg. Downloads are bounded by cfg.Timeout
+// whichever networks they are allowed from.
+func sourceClient(cfg config.RemoteSources) *http.Client {
+	t := publicTransport
+	if cfg.AllowPrivateNetworks {
+		t = privateTransport
+	}
+	return &http.Client{Transport: t, Timeout: cfg.Timeout}
+}
+
+// isPublicIP checks if addr is a globally routable address outside of private ranges.
+func isPublicIP(addr net.IP) bool {
+	if addr == nil || !addr.IsGlobalUnicast() || ip.IsPrivateSubnet(addr) {
+		return false
+	}
+	// Unique local IPv6 addresses (fc00::/7)
+	return addr.To4() != nil || addr[0]&0xfe != 0xfc
+}
+
+// sourceURL returns source_url from params of the publish request rawReq, if it has one.
+func sourceURL(rawReq []byte) string {
+	var req jsonrpc.RPCRequest
+	if err := json.Unmarshal(rawReq, &req); err != nil {
+		return ""
+	}
+	params, _ := req.Params.(map[string]interface{})
+	u, _ := params[sourceURLParam].(string)
+	return u
+}
+
+// sourceName returns the name of the file downloaded from u, as set in Content-Disposition of the response
+// or the last element of the URL path.
+func sourceName(u *url.URL, res *http.Response) string {
+	name := path.Base(u.Path)
+	if _, params, err := mime.ParseMediaType(res.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		name = path.Base(params["filename"])
+	}
+	if name == "." || name == "/" {
+		return defaultSourceName
+	}
+	return name
+}
+
+// sourceReader fails with ErrSourceTooLarge once more than max bytes are read. It keeps the error
+// it got from the download, if any, to tell download failures from local ones.
+type sourceReader struct {
+	r      io.Reader
+	max, n int64
+	err    error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	s.n += int64(n)
+	if s.max > 0 && s.n > s.max {
+		s.err = ErrSourceTooLarge
+		return n, s.err
+	}
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return n, err
+}
+
+// downloadSource downloads the file at rawURL for publishing, within limits set in RemoteSources,
+// and returns it along with its name. Problems with the URL or the download are reported as client errors.
+func (h Handler) downloadSource(userID int, rawURL string) (*os.File, string, error) {
+	op := metrics.StartOperation(opName, "download_source")
+	defer op.End()
+
+	log := logger.WithFields(logrus.Fields{"user_id": userID, "method_handler": method, "source_url": rawURL})
+	reject := func(err rpcerrors.RPCError) (*os.File, string, error) {
+		metrics.RemoteSources.WithLabelValues(metrics.RemoteSourceRejected).Inc()
+		return nil, "", err
+	}
+	fail := func(err error) (*os.File, string, error) {
+		metrics.RemoteSources.WithLabelValues(metrics.RemoteSourceFailed).Inc()
+		return nil, "", rpcerrors.NewInvalidParamsError(errors.Prefix("cannot download source_url", err))
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return reject(rpcerrors.NewInvalidParamsError(ErrInvalidSourceURL))
+	}
+	cfg := config.GetRemoteSources()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return reject(rpcerrors.NewInvalidParamsError(ErrInvalidSourceURL))
+	}
+	res, err := sourceClient(cfg).Do(req)
+	if errors.Is(err, ErrSourceNotAllowed) {
+		return reject(rpcerrors.NewInvalidParamsError(ErrSourceNotAllowed))
+	} else if err != nil {
+		return fail(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fail(errors.Err("source responded with %v", res.StatusCode))
+	}
+	if cfg.MaxSize > 0 && res.ContentLength > cfg.MaxSize {
+		return reject(rpcerrors.NewQuotaExceededError(ErrSourceTooLarge).
+			WithData(UploadLimitDetails{Limit: cfg.MaxSize, Size: res.ContentLength}))
+	}
+
+	name := sourceName(u, res)
+	log.Infof("downloading %v", name)
+	src := &sourceReader{r: res.Body, max: cfg.MaxSize}
+	f, err := h.writeUpload(userID, name, res.ContentLength, src)
+	switch {
+	case errors.Is(src.err, ErrSourceTooLarge):
+		return reject(rpcerrors.NewQuotaExceededError(ErrSourceTooLarge).
+			WithData(UploadLimitDetails{Limit: cfg.MaxSize, Size: src.n}))
+	case src.err != nil:
+		return fail(src.err)
+	case err != nil:
+		return nil, "", err
+	}
+	metrics.RemoteSources.WithLabelValues(metrics.RemoteSourceDownloaded).Inc()
+	return f, name, nil
+}
@@ -0,0 +1,136 @@
+package publish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// sourceUpload returns a multipart body of a publish request with source_url and no file.
+func sourceUpload(t *testing.T, sourceURL string) ([]byte, string) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	req := fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "")
+	req = strings.Replace(req, `"file_path": ""`, `"source_url": "`+sourceURL+`"`, 1)
+	require.NoError(t, writer.WriteField(jsonRPCFieldName, req))
+	require.NoError(t, writer.Close())
+	return body.Bytes(), writer.FormDataContentType()
+}
+
+func TestRemoteSource(t *testing.T) {
+	config.Override("RemoteSources", map[string]interface{}{"MaxSize": 100, "Timeout": "1m", "AllowPrivateNetworks": true})
+	defer config.RestoreOverridden()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("test file"))
+	}))
+	defer ts.Close()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	body, contentType := sourceUpload(t, ts.URL+"/videos/clip.txt")
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/proxy", bytes.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	assert.True(t, e.handler.CanHandle(r, nil))
+
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusOK, rr.Code)
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.Nil(t, res.Error)
+	assert.Equal(t, []byte("test file"), <-e.published)
+}
+
+func TestRemoteSource_Rejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("larger than allowed"))
+	}))
+	defer ts.Close()
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+
+	cases := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		code         int
+		message      string
+	}{
+		{"private address", ts.URL + "/clip.txt", false, rpcerrors.NewInvalidParamsError(nil).Code(), ErrSourceNotAllowed.Error()},
+		{"not http", "ftp://example.com/clip.txt", true, rpcerrors.NewInvalidParamsError(nil).Code(), ErrInvalidSourceURL.Error()},
+		{"too large", ts.URL + "/clip.txt", true, rpcerrors.NewQuotaExceededError(nil).Code(), ErrSourceTooLarge.Error()},
+		{"not found", ts.URL + "/missing", true, rpcerrors.NewInvalidParamsError(nil).Code(), "cannot download source_url"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			config.Override("RemoteSources", map[string]interface{}{"MaxSize": 5, "Timeout": "1m", "AllowPrivateNetworks": c.allowPrivate})
+			defer config.RestoreOverridden()
+
+			body, contentType := sourceUpload(t, c.url)
+			rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+			var res jsonrpc.RPCResponse
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+			require.NotNil(t, res.Error)
+			assert.Equal(t, c.code, res.Error.Code)
+			assert.Contains(t, res.Error.Message, c.message)
+		})
+	}
+}
+
+func TestIsPublicIP(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34":        true,
+		"2606:2800:220:1::248": true,
+		"127.0.0.1":            false,
+		"10.1.2.3":             false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"::1":                  false,
+		"fd00::1":              false,
+		"0.0.0.0":              false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestSourceClient(t *testing.T) {
+	c := sourceClient(config.RemoteSources{Timeout: time.Minute})
+	assert.Equal(t, publicTransport, c.Transport)
+	assert.Equal(t, time.Minute, c.Timeout)
+
+	c = sourceClient(config.RemoteSources{Timeout: time.Minute, AllowPrivateNetworks: true})
+	assert.Equal(t, privateTransport, c.Transport)
+	assert.Equal(t, time.Minute, c.Timeout)
+}
+
+func TestSourceName(t *testing.T) {
+	u, _ := url.Parse("https://example.com/videos/clip.mp4?dl=1")
+	res := &http.Response{Header: http.Header{}}
+	assert.Equal(t, "clip.mp4", sourceName(u, res))
+
+	res.Header.Set("Content-Disposition", `attachment; filename="../My Video.mp4"`)
+	assert.Equal(t, "My Video.mp4", sourceName(u, res))
+
+	u, _ = url.Parse("https://example.com/")
+	assert.Equal(t, defaultSourceName, sourceName(u, &http.Response{Header: http.Header{}}))
+}
@@ -0,0 +1,68 @@
+package publish
+
+import (
+	"os"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// Files kept as bases for delta uploads are the originals of their claims' streams, which is what
+// the stream can be recreated from when its blobs are lost.
+
+const methodStreamUpdate = "stream_update"
+
+// HasOriginal returns true if the file last published into the claim by the user is still kept.
+func (h Handler) HasOriginal(userID int, claimID string) bool {
+	f, _, err := h.openBasis(userID, claimID)
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+// Republish updates the claim with the file last published into it, so the SDK creates and reflects
+// the stream anew. params are added to the stream_update call. The claim keeps its ID but gets new blobs.
+func (h Handler) Republish(user *models.User, claimID string, params map[string]interface{}) (*jsonrpc.RPCResponse, error) {
+	address := sdkrouter.GetSDKAddress(user)
+	if address == "" {
+		return nil, errors.Err("user does not have sdk address assigned")
+	}
+	f, _, err := h.openBasis(user.ID, claimID)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+
+	// The kept file is replaced once the update goes through, so a copy is published
+	tmp, err := h.createFile(user.ID, claimID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := copyFile(h.basisPath(user.ID, claimID), tmp.Name()); err != nil {
+		return nil, errors.Err(err)
+	}
+	location, removeStored, err := h.store(user.ID, tmp.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer removeStored()
+
+	p := map[string]interface{}{}
+	for k, v := range params {
+		p[k] = v
+	}
+	p["claim_id"] = claimID
+	res, err := getCaller(address, location, user.ID, nil).Call(jsonrpc.NewRequest(methodStreamUpdate, p))
+	if err != nil {
+		return nil, err
+	}
+	h.keepBasis(user.ID, tmp.Name(), res)
+	return res, nil
+}
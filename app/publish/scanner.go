@@ -0,0 +1,169 @@
+package publish
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+// clamdChunkSize is how much of the file is sent to clamd in one INSTREAM chunk.
+const clamdChunkSize = 64 * 1024
+
+var ErrMalwareFound = errors.Base("uploaded file contains malware")
+
+// ScanResult is what a Scanner found in a file.
+type ScanResult struct {
+	Infected bool
+	// Signature names the malware found.
+	Signature string
+	Duration  time.Duration
+}
+
+// Scanner checks uploaded files for viruses and other malware before they are published.
+type Scanner interface {
+	// Name identifies the scanner in logs and error reports.
+	Name() string
+	Scan(path string) (*ScanResult, error)
+}
+
+// BlockedContentDetails is sent in the data field of errors for uploads blocked by a scanner.
+type BlockedContentDetails struct {
+	Scanner   string `json:"scanner"`
+	Signature string `json:"signature"`
+}
+
+// ClamdScanner streams files to a ClamAV daemon listening on Address, which is a unix socket path
+// or a host:port depending on Network.
+type ClamdScanner struct {
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// Name returns "clamav".
+func (s ClamdScanner) Name() string {
+	return "clamav"
+}
+
+// Scan sends the file to clamd with the INSTREAM command, so clamd doesn't need access to the upload directory.
+func (s ClamdScanner) Scan(path string) (*ScanResult, error) {
+	start := time.Now()
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	conn, err := net.DialTimeout(s.Network, s.Address, s.Timeout)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	w := bufio.NewWriterSize(conn, clamdChunkSize+4)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return nil, errors.Err(err)
+	}
+	chunk := make([]byte, clamdChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := f.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			w.Write(size)
+			if _, err := w.Write(chunk[:n]); err != nil {
+				return nil, errors.Err(err)
+			}
+		}
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Err(err)
+		}
+	}
+	// Zero-length chunk ends the stream
+	binary.BigEndian.PutUint32(size, 0)
+	w.Write(size)
+	if err := w.Flush(); err != nil {
+		return nil, errors.Err(err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return nil, errors.Err(err)
+	}
+	return parseClamdReply(strings.TrimRight(reply, "\x00\n"), time.Since(start))
+}
+
+// parseClamdReply reads replies like "stream: OK", "stream: Eicar-Signature FOUND" and "... ERROR".
+func parseClamdReply(reply string, d time.Duration) (*ScanResult, error) {
+	status := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case status == "OK":
+		return &ScanResult{Duration: d}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return &ScanResult{Infected: true, Signature: strings.TrimSuffix(status, " FOUND"), Duration: d}, nil
+	}
+	return nil, errors.Err("clamd: %v", reply)
+}
+
+// DefaultScanner returns the scanner set up in UploadScanner config, nil if there's none.
+func DefaultScanner() Scanner {
+	cfg := config.GetUploadScanner()
+	if cfg.Address == "" {
+		return nil
+	}
+	return ClamdScanner{Network: cfg.Network, Address: cfg.Address, Timeout: cfg.Timeout}
+}
+
+// scanUpload runs the handler's scanner, if it has one, on the uploaded file. Infected files are reported
+// to Sentry with scan details and a blocked content error is returned for the uploader.
+// Scans failing to run are errors too, so files aren't let through unchecked.
+func (h Handler) scanUpload(path string, userID int) error {
+	if h.Scanner == nil {
+		return nil
+	}
+	op := metrics.StartOperation(opName, "scan_file")
+	res, err := h.Scanner.Scan(path)
+	op.End()
+	if err != nil {
+		metrics.UploadScans.WithLabelValues(metrics.UploadScanFailed).Inc()
+		return errors.Prefix(h.Scanner.Name()+" scan failed", err)
+	}
+	if !res.Infected {
+		metrics.UploadScans.WithLabelValues(metrics.UploadScanClean).Inc()
+		return nil
+	}
+
+	metrics.UploadScans.WithLabelValues(metrics.UploadScanInfected).Inc()
+	monitor.ErrorToSentry(errors.Prefix(res.Signature, ErrMalwareFound), map[string]string{
+		"scanner":   h.Scanner.Name(),
+		"signature": res.Signature,
+		"user_id":   fmt.Sprint(userID),
+		"file_path": path,
+		"file_size": fileSize(path),
+		"duration":  res.Duration.String(),
+	})
+	return rpcerrors.NewBlockedContentError(ErrMalwareFound).
+		WithData(BlockedContentDetails{Scanner: h.Scanner.Name(), Signature: res.Signature})
+}
+
+func fileSize(path string) string {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprint(stat.Size())
+}
@@ -0,0 +1,105 @@
+package publish
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// fakeClamd reads INSTREAM requests and reports streams containing "EICAR" as infected.
+func fakeClamd(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			cmd, _ := r.ReadString(0)
+			assert.Equal(t, "zINSTREAM\x00", cmd)
+			var data bytes.Buffer
+			for {
+				var size uint32
+				require.NoError(t, binary.Read(r, binary.BigEndian, &size))
+				if size == 0 {
+					break
+				}
+				io.CopyN(&data, r, int64(size))
+			}
+			if bytes.Contains(data.Bytes(), []byte("EICAR")) {
+				conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+			} else {
+				conn.Write([]byte("stream: OK\x00"))
+			}
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+func TestClamdScanner(t *testing.T) {
+	l := fakeClamd(t)
+	defer l.Close()
+	s := ClamdScanner{Network: "tcp", Address: l.Addr().String(), Timeout: 5 * time.Second}
+
+	f, err := ioutil.TempFile("", "scan")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Write(bytes.Repeat([]byte("clean "), clamdChunkSize))
+	f.Close()
+	res, err := s.Scan(f.Name())
+	require.NoError(t, err)
+	assert.False(t, res.Infected)
+
+	require.NoError(t, ioutil.WriteFile(f.Name(), []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR"), 0600))
+	res, err = s.Scan(f.Name())
+	require.NoError(t, err)
+	assert.True(t, res.Infected)
+	assert.Equal(t, "Eicar-Signature", res.Signature)
+}
+
+func TestParseClamdReply(t *testing.T) {
+	_, err := parseClamdReply("INSTREAM size limit exceeded. ERROR", 0)
+	assert.Error(t, err)
+}
+
+type stubScanner struct {
+	result *ScanResult
+}
+
+func (s stubScanner) Name() string                          { return "stub" }
+func (s stubScanner) Scan(path string) (*ScanResult, error) { return s.result, nil }
+
+func TestScanUpload_Blocked(t *testing.T) {
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	e.handler.Scanner = stubScanner{&ScanResult{Infected: true, Signature: "Eicar-Signature"}}
+
+	body, contentType := multipartUpload(t, []byte("infected file"), nil)
+	rr := e.call(e.handler.Handle, http.MethodPost, body, nil, map[string]string{"Content-Type": contentType})
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var res jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	require.NotNil(t, res.Error)
+	assert.Equal(t, rpcerrors.NewBlockedContentError(nil).Code(), res.Error.Code)
+	assert.Equal(t, ErrMalwareFound.Error(), res.Error.Message)
+	assert.Equal(t, map[string]interface{}{"scanner": "stub", "signature": "Eicar-Signature"}, res.Error.Data)
+	assert.Empty(t, e.published)
+}
@@ -0,0 +1,40 @@
+package publish
+
+import (
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/taxonomy"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+var ErrBannedTags = errors.Base("publish has banned tags")
+
+// normalizeTags replaces tags in publish params with their normalized form (see taxonomy.Normalize)
+// and returns them. Publishes with banned tags are rejected, listing them in the error data.
+func normalizeTags(params map[string]interface{}) ([]string, error) {
+	raw := []string{}
+	switch typed := params[paramTags].(type) {
+	case string:
+		raw = append(raw, typed)
+	case []interface{}:
+		for _, t := range typed {
+			if s, ok := t.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	default:
+		return nil, nil
+	}
+
+	normalized, banned := taxonomy.Normalize(raw)
+	if len(banned) > 0 {
+		metrics.PublishTagsRejected.Inc()
+		return nil, rpcerrors.NewInvalidParamsError(ErrBannedTags).WithData(map[string][]string{paramTags: banned})
+	}
+	tags := make([]interface{}, len(normalized))
+	for i, t := range normalized {
+		tags[i] = t
+	}
+	params[paramTags] = tags
+	return normalized, nil
+}
@@ -0,0 +1,22 @@
+package publish
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	params := map[string]interface{}{paramTags: []interface{}{" Music", "#music", "Jazz  Fusion", 5}}
+	tags, err := normalizeTags(params)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"music", "jazz fusion"}, tags)
+	assert.Equal(t, []interface{}{"music", "jazz fusion"}, params[paramTags])
+
+	params = map[string]interface{}{}
+	tags, err = normalizeTags(params)
+	require.NoError(t, err)
+	assert.Nil(t, tags)
+	assert.NotContains(t, params, paramTags)
+}
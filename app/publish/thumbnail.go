@@ -0,0 +1,37 @@
+package publish
+
+import (
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/publish/thumbnails"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+const paramThumbnailURL = "thumbnail_url"
+
+// addThumbnail sets thumbnail_url of the publish to a frame of the uploaded video when the client didn't provide one
+// and thumbnails are configured. Thumbnails are a convenience, so failing to make one doesn't stop the publish.
+func addThumbnail(path, mediaType string, params map[string]interface{}) {
+	cfg := config.GetThumbnails()
+	if cfg.UploadURL == "" || !strings.HasPrefix(mediaType, "video/") {
+		return
+	}
+	if u, _ := params[paramThumbnailURL].(string); u != "" {
+		return
+	}
+	op := metrics.StartOperation(opName, "make_thumbnail")
+	u, err := thumbnails.Make(
+		thumbnails.Extractor{FFmpeg: cfg.FFmpeg, Offset: cfg.Offset, Width: cfg.Width},
+		thumbnails.Host{UploadURL: cfg.UploadURL, URLField: cfg.URLField},
+		path, cfg.Timeout,
+	)
+	op.End()
+	if err != nil {
+		logger.Log().Warnf("cannot make thumbnail: %v", err)
+		metrics.Thumbnails.WithLabelValues(metrics.ThumbnailFailed).Inc()
+		return
+	}
+	metrics.Thumbnails.WithLabelValues(metrics.ThumbnailMade).Inc()
+	params[paramThumbnailURL] = u
+}
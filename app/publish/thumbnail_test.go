@@ -0,0 +1,49 @@
+package publish
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddThumbnail(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ffmpeg")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	require.NoError(t, ioutil.WriteFile(ffmpeg, []byte("#!/bin/sh\nprintf 'jpeg frame'\n"), 0755))
+
+	uploads := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.Write([]byte(`{"url": "https://thumbs.example.com/clip.jpg"}`))
+	}))
+	defer ts.Close()
+
+	params := map[string]interface{}{}
+	addThumbnail("clip.mp4", "video/mp4", params)
+	assert.NotContains(t, params, paramThumbnailURL, "thumbnails are not configured")
+
+	config.Override("Thumbnails", map[string]interface{}{"FFmpeg": ffmpeg, "UploadURL": ts.URL, "URLField": "url", "Timeout": "1m"})
+	defer config.RestoreOverridden()
+
+	addThumbnail("clip.mp4", "video/mp4", params)
+	assert.Equal(t, "https://thumbs.example.com/clip.jpg", params[paramThumbnailURL])
+
+	params = map[string]interface{}{paramThumbnailURL: "https://example.com/mine.jpg"}
+	addThumbnail("clip.mp4", "video/mp4", params)
+	assert.Equal(t, "https://example.com/mine.jpg", params[paramThumbnailURL])
+
+	params = map[string]interface{}{}
+	addThumbnail("notes.txt", "text/plain", params)
+	assert.NotContains(t, params, paramThumbnailURL)
+	assert.Equal(t, 1, uploads)
+}
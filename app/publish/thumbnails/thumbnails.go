@@ -0,0 +1,154 @@
+// Package thumbnails makes thumbnails for uploaded videos. A poster frame is extracted with ffmpeg
+// and uploaded to a thumbnail host, whose URL for it can then be set as thumbnail_url of the stream.
+package thumbnails
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	// uploadFieldName is the form field thumbnail images are uploaded in.
+	uploadFieldName = "file"
+	// maxResponseSize limits thumbnail host responses.
+	maxResponseSize = 1024 * 1024
+)
+
+var (
+	logger = monitor.NewModuleLogger("thumbnails")
+
+	ErrNoFrame = errors.Base("no frame extracted from video")
+	ErrNoURL   = errors.Base("thumbnail host response has no URL")
+)
+
+// Extractor extracts a frame from videos as a JPEG image with ffmpeg.
+type Extractor struct {
+	// FFmpeg is the path to the ffmpeg binary.
+	FFmpeg string
+	// Offset is how far into the video the frame is taken from. Videos shorter than that get their first frame used.
+	Offset time.Duration
+	// Width is the largest width of the image, videos wider than that are scaled down.
+	Width int
+}
+
+// Extract returns a frame of the video at path, JPEG-encoded.
+func (e Extractor) Extract(ctx context.Context, videoPath string) ([]byte, error) {
+	img, err := e.frameAt(ctx, videoPath, e.Offset)
+	if errors.Is(err, ErrNoFrame) && e.Offset > 0 {
+		return e.frameAt(ctx, videoPath, 0)
+	}
+	return img, err
+}
+
+func (e Extractor) frameAt(ctx context.Context, videoPath string, offset time.Duration) ([]byte, error) {
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-ss", strconv.FormatFloat(offset.Seconds(), 'f', -1, 64),
+		"-i", videoPath,
+		"-frames:v", "1",
+	}
+	if e.Width > 0 {
+		args = append(args, "-vf", "scale='min("+strconv.Itoa(e.Width)+",iw)':-2")
+	}
+	args = append(args, "-f", "image2pipe", "-c:v", "mjpeg", "-q:v", "3", "pipe:1")
+
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.FFmpeg, args...)
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Err("ffmpeg: %v: %v", err, strings.TrimSpace(stderr.String()))
+	}
+	// ffmpeg succeeds without output when seeking past the end of the video
+	if out.Len() == 0 {
+		return nil, ErrNoFrame
+	}
+	return out.Bytes(), nil
+}
+
+// Host is where thumbnail images are uploaded to. Images are POSTed as multipart form files,
+// the host responds with JSON containing their public URL.
+type Host struct {
+	UploadURL string
+	// URLField is the path to the URL in the response, with dots separating nested objects, e.g. "data.serveUrl".
+	URLField string
+}
+
+// Upload uploads the JPEG image under name and returns its URL.
+func (h Host) Upload(ctx context.Context, name string, img []byte) (string, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(uploadFieldName, name)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	part.Write(img)
+	if err := writer.Close(); err != nil {
+		return "", errors.Err(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.UploadURL, body)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return "", errors.Err(err)
+	}
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return "", errors.Err("thumbnail host responded with %v: %s", res.StatusCode, data)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", errors.Err("malformed thumbnail host response: %v", err)
+	}
+	field := h.URLField
+	if field == "" {
+		field = "url"
+	}
+	for _, key := range strings.Split(field, ".") {
+		obj, _ := v.(map[string]interface{})
+		v = obj[key]
+	}
+	if u, _ := v.(string); u != "" {
+		return u, nil
+	}
+	return "", ErrNoURL
+}
+
+// Make extracts a thumbnail from the video at path, uploads it to the host and returns its URL.
+// Both steps have to be done within timeout.
+func Make(e Extractor, h Host, videoPath string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	img, err := e.Extract(ctx, videoPath)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(path.Base(videoPath), path.Ext(videoPath)) + ".jpg"
+	u, err := h.Upload(ctx, name, img)
+	if err != nil {
+		return "", err
+	}
+	logger.Log().Debugf("uploaded %v bytes thumbnail of %v to %v", len(img), path.Base(videoPath), u)
+	return u, nil
+}
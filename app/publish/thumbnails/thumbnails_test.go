@@ -0,0 +1,77 @@
+package thumbnails
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFFmpeg writes a script standing in for ffmpeg, which outputs a frame only when seeking to the start,
+// like ffmpeg does for videos shorter than the offset, and returns its path.
+func fakeFFmpeg(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ffmpeg")
+	require.NoError(t, err)
+	p := filepath.Join(dir, "ffmpeg")
+	script := "#!/bin/sh\ncase \"$*\" in *\"-ss 0 \"*) printf 'jpeg frame' ;; esac\n"
+	require.NoError(t, ioutil.WriteFile(p, []byte(script), 0755))
+	return p
+}
+
+func TestExtract(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t)
+	defer os.RemoveAll(filepath.Dir(ffmpeg))
+
+	img, err := Extractor{FFmpeg: ffmpeg, Offset: 5 * time.Second, Width: 1280}.Extract(context.Background(), "video.mp4")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("jpeg frame"), img)
+
+	_, err = Extractor{FFmpeg: "/bin/true"}.Extract(context.Background(), "video.mp4")
+	assert.True(t, errors.Is(err, ErrNoFrame))
+
+	_, err = Extractor{FFmpeg: "/bin/false"}.Extract(context.Background(), "video.mp4")
+	assert.Error(t, err)
+}
+
+func TestUpload(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, header, err := r.FormFile(uploadFieldName)
+		require.NoError(t, err)
+		data, _ := ioutil.ReadAll(f)
+		assert.Equal(t, "jpeg frame", string(data))
+		assert.Equal(t, "video.jpg", header.Filename)
+		w.Write([]byte(`{"success": true, "data": {"serveUrl": "https://thumbs.example.com/video.jpg"}}`))
+	}))
+	defer ts.Close()
+
+	u, err := Host{UploadURL: ts.URL, URLField: "data.serveUrl"}.Upload(context.Background(), "video.jpg", []byte("jpeg frame"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://thumbs.example.com/video.jpg", u)
+
+	_, err = Host{UploadURL: ts.URL}.Upload(context.Background(), "video.jpg", []byte("jpeg frame"))
+	assert.True(t, errors.Is(err, ErrNoURL))
+}
+
+func TestMake(t *testing.T) {
+	ffmpeg := fakeFFmpeg(t)
+	defer os.RemoveAll(filepath.Dir(ffmpeg))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, header, err := r.FormFile(uploadFieldName)
+		require.NoError(t, err)
+		w.Write([]byte(`{"url": "https://thumbs.example.com/` + header.Filename + `"}`))
+	}))
+	defer ts.Close()
+
+	u, err := Make(Extractor{FFmpeg: ffmpeg}, Host{UploadURL: ts.URL}, "/uploads/20404/123_clip.mp4", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "https://thumbs.example.com/123_clip.jpg", u)
+}
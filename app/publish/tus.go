@@ -0,0 +1,433 @@
+package publish
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/bufpool"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// Resumable uploads implement the tus.io protocol (https://tus.io/protocols/resumable-upload.html)
+// with creation, expiration and termination extensions. Bytes received before a connection drops are kept,
+// so clients can ask for the offset and carry on from there. Once all of the file is in, it is published
+// by HandleTusPublish, which takes the same payload as HandleComplete.
+
+const (
+	TusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination"
+	tusDirName    = "tus"
+
+	tusContentType = "application/offset+octet-stream"
+)
+
+// tusHeaders have to be allowed and exposed for tus clients running in browsers.
+var tusHeaders = []string{"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size",
+	"Upload-Length", "Upload-Offset", "Upload-Metadata", "Upload-Expires", "Location", chunkSizeHeader}
+
+var ErrOffsetMismatch = errors.Base("upload offset does not match")
+
+type tusMeta struct {
+	Filename  string     `json:"filename"`
+	Length    int64      `json:"length"`
+	CreatedAt time.Time  `json:"created_at"`
+	Chunks    chunkStats `json:"chunks"`
+}
+
+type tusPublishRequest struct {
+	JSONPayload json.RawMessage `json:"json_payload"`
+}
+
+func (h Handler) tusPath(userID int, uploadID string) string {
+	return path.Join(h.UploadPath, fmt.Sprintf("%d", userID), tusDirName, uploadID)
+}
+
+// openTusUpload returns metadata of an upload and the number of bytes received so far.
+func (h Handler) openTusUpload(userID int, uploadID string) (*tusMeta, int64, error) {
+	p := h.tusPath(userID, uploadID)
+	h.takeOverOrLog(userID, tusDirName, uploadID, p+".json")
+	data, err := ioutil.ReadFile(p + ".json")
+	if os.IsNotExist(err) {
+		return nil, 0, ErrUploadNotFound
+	} else if err != nil {
+		return nil, 0, errors.Err(err)
+	}
+	var meta tusMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, 0, errors.Err(err)
+	}
+	if time.Since(meta.CreatedAt) > config.GetResumableUploads().TTL {
+		return nil, 0, ErrUploadNotFound
+	}
+	fi, err := os.Stat(p + ".bin")
+	if os.IsNotExist(err) {
+		return nil, 0, ErrUploadNotFound
+	} else if err != nil {
+		return nil, 0, errors.Err(err)
+	}
+	return &meta, fi.Size(), nil
+}
+
+// saveTusMeta stores metadata of an upload.
+func (h Handler) saveTusMeta(userID int, uploadID string, meta *tusMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Err(err)
+	}
+	return errors.Err(ioutil.WriteFile(h.tusPath(userID, uploadID)+".json", data, 0644))
+}
+
+func (h Handler) removeTusUpload(userID int, uploadID string) {
+	p := h.tusPath(userID, uploadID)
+	for _, f := range []string{p + ".bin", p + ".json"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			logger.Log().Errorf("error removing resumable upload file %v: %v", f, err)
+		}
+	}
+}
+
+// removeExpiredTusUploads removes resumable uploads of the user which were never published.
+func (h Handler) removeExpiredTusUploads(userID int) {
+	files, err := filepath.Glob(path.Join(h.UploadPath, fmt.Sprintf("%d", userID), tusDirName, "*.json"))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		uploadID := strings.TrimSuffix(path.Base(f), ".json")
+		if _, _, err := h.openTusUpload(userID, uploadID); errors.Is(err, ErrUploadNotFound) {
+			h.removeTusUpload(userID, uploadID)
+		}
+	}
+}
+
+// parseTusMetadata decodes Upload-Metadata header, which is a comma-separated list of keys and base64-encoded values.
+func parseTusMetadata(header string) (map[string]string, error) {
+	md := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		kv := strings.Fields(pair)
+		if len(kv) == 0 {
+			continue
+		}
+		var value []byte
+		if len(kv) > 1 {
+			var err error
+			if value, err = base64.StdEncoding.DecodeString(kv[1]); err != nil {
+				return nil, errors.Err("malformed metadata value of %v", kv[0])
+			}
+		}
+		md[kv[0]] = string(value)
+	}
+	return md, nil
+}
+
+func writeTusHeaders(w http.ResponseWriter) {
+	w.Header().Set("Tus-Resumable", TusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Access-Control-Expose-Headers", strings.Join(tusHeaders, ", "))
+}
+
+// checkTusVersion rejects requests made by clients speaking a different version of the protocol.
+func checkTusVersion(w http.ResponseWriter, r *http.Request) bool {
+	writeTusHeaders(w)
+	if r.Header.Get("Tus-Resumable") != TusVersion {
+		w.Header().Set("Tus-Version", TusVersion)
+		responses.WriteError(w, http.StatusPreconditionFailed, errors.Err("unsupported tus version"))
+		return false
+	}
+	return true
+}
+
+// HandleTusOptions describes the server's tus capabilities.
+func (h Handler) HandleTusOptions(w http.ResponseWriter, r *http.Request) {
+	hs := w.Header()
+	hs.Set("Tus-Resumable", TusVersion)
+	hs.Set("Tus-Version", TusVersion)
+	hs.Set("Tus-Extension", tusExtensions)
+	hs.Set("Tus-Max-Size", strconv.FormatInt(config.GetResumableUploads().MaxSize, 10))
+	hs.Set("Access-Control-Max-Age", "7200")
+	hs.Set("Access-Control-Allow-Methods", "POST, HEAD, PATCH, DELETE, OPTIONS")
+	hs.Set("Access-Control-Allow-Headers", wallet.TokenHeader+", Content-Type, "+strings.Join(tusHeaders, ", "))
+	hs.Set("Access-Control-Expose-Headers", strings.Join(tusHeaders, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTusCreate starts a resumable upload. Upload-Length is required, filename is taken from Upload-Metadata.
+func (h Handler) HandleTusCreate(w http.ResponseWriter, r *http.Request) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	reportQuota(r, user.ID)
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("Upload-Length is required"))
+		return
+	}
+	cfg := config.GetResumableUploads()
+	if length > cfg.MaxSize {
+		responses.WriteError(w, http.StatusRequestEntityTooLarge, errors.Err("upload cannot exceed %v bytes", cfg.MaxSize))
+		return
+	}
+	// Turned down before any bytes are sent rather than once the upload is published
+	if err := checkUploadSize(user.ID, length); err != nil {
+		responses.WriteError(w, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	md, err := parseTusMetadata(r.Header.Get("Upload-Metadata"))
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	filename := path.Base(md["filename"])
+	if md["filename"] == "" || filename == "." || filename == "/" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("filename is required in Upload-Metadata"))
+		return
+	}
+
+	h.removeExpiredTusUploads(user.ID)
+
+	uploadID, err := randomID()
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	p := h.tusPath(user.ID, uploadID)
+	if err := os.MkdirAll(path.Dir(p), os.ModePerm); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	meta := tusMeta{Filename: filename, Length: length, CreatedAt: time.Now().UTC()}
+	if err := h.saveTusMeta(user.ID, uploadID, &meta); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := ioutil.WriteFile(p+".bin", nil, 0644); err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Infof("resumable upload of %v created", filename)
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+uploadID)
+	w.Header().Set("Upload-Expires", meta.CreatedAt.Add(cfg.TTL).Format(http.TimeFormat))
+	writeChunkSize(w, cfg, meta.Chunks)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// HandleTusHead returns the offset at which the upload should be resumed.
+func (h Handler) HandleTusHead(w http.ResponseWriter, r *http.Request) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	meta, offset, err := h.openTusUpload(user.ID, mux.Vars(r)["id"])
+	if errors.Is(err, ErrUploadNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	cfg := config.GetResumableUploads()
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(meta.Length, 10))
+	w.Header().Set("Upload-Expires", meta.CreatedAt.Add(cfg.TTL).Format(http.TimeFormat))
+	writeChunkSize(w, cfg, meta.Chunks)
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleTusPatch appends a chunk to the upload. Upload-Offset has to match the number of bytes received so far.
+// Whatever is read before the request is interrupted stays in the upload.
+func (h Handler) HandleTusPatch(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	if !checkTusVersion(w, r) {
+		return
+	}
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	reportQuota(r, user.ID)
+	if r.Header.Get("Content-Type") != tusContentType {
+		responses.WriteError(w, http.StatusUnsupportedMediaType, errors.Err("Content-Type has to be %v", tusContentType))
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	meta, offset, err := h.openTusUpload(user.ID, uploadID)
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if r.Header.Get("Upload-Offset") != strconv.FormatInt(offset, 10) {
+		responses.WriteError(w, http.StatusConflict, ErrOffsetMismatch)
+		return
+	}
+	select {
+	case <-draining:
+		writeDraining(w)
+		return
+	default:
+	}
+
+	op := metrics.StartOperation(opName, "save_chunk")
+	defer op.End()
+
+	f, err := os.OpenFile(h.tusPath(user.ID, uploadID)+".bin", os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// Another request could have appended to the upload in the meantime
+	if fi, err := f.Stat(); err != nil || fi.Size() != offset {
+		f.Close()
+		responses.WriteError(w, http.StatusConflict, ErrOffsetMismatch)
+		return
+	}
+	buf := bufpool.GetBytes(bufpool.CopyBufferSize)
+	defer bufpool.PutBytes(buf)
+	body := &progressReader{ReadCloser: r.Body, uploadID: uploadID, bytes: offset, total: meta.Length}
+	start := time.Now()
+	n, err := io.CopyBuffer(f, io.LimitReader(drainingReader{Reader: body, done: draining}, meta.Length-offset), *buf)
+	elapsed := time.Since(start)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	cfg := config.GetResumableUploads()
+	log := logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID})
+	if cfg.ChunkDuration > 0 {
+		meta.Chunks.record(cfg, n, elapsed, err != nil && !errors.Is(err, ErrDraining))
+		if serr := h.saveTusMeta(user.ID, uploadID, meta); serr != nil {
+			log.Errorf("cannot save chunk stats: %v", serr)
+		}
+		writeChunkSize(w, cfg, meta.Chunks)
+	}
+	if errors.Is(err, ErrDraining) {
+		log.Infof("chunk cut short after %v bytes for shutdown", n)
+		writeDraining(w)
+		return
+	} else if err != nil {
+		log.Infof("chunk interrupted after %v bytes: %v", n, err)
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("error reading chunk: %v", err))
+		return
+	}
+	progress.emit(ProgressEvent{UploadID: uploadID, Stage: ProgressUploading, Bytes: offset + n, Total: meta.Length})
+	w.Header().Set("Upload-Offset", strconv.FormatInt(offset+n, 10))
+	w.Header().Set("Upload-Expires", meta.CreatedAt.Add(cfg.TTL).Format(http.TimeFormat))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTusDelete terminates an upload, removing whatever was received.
+func (h Handler) HandleTusDelete(w http.ResponseWriter, r *http.Request) {
+	if !checkTusVersion(w, r) {
+		return
+	}
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	if _, _, err := h.openTusUpload(user.ID, uploadID); errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.removeTusUpload(user.ID, uploadID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTusPublish publishes a fully received resumable upload. The response is the same as for a regular publish.
+func (h Handler) HandleTusPublish(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt32(&activeUploads, 1)
+	defer atomic.AddInt32(&activeUploads, -1)
+
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	if sdkrouter.GetSDKAddress(user) == "" {
+		responses.WriteError(w, http.StatusInternalServerError, errors.Err("user does not have sdk address assigned"))
+		return
+	}
+	uploadID := mux.Vars(r)["id"]
+	meta, offset, err := h.openTusUpload(user.ID, uploadID)
+	if errors.Is(err, ErrUploadNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if offset != meta.Length {
+		responses.WriteError(w, http.StatusConflict, errors.Err("upload is incomplete, %v of %v bytes received", offset, meta.Length))
+		return
+	}
+	var req tusPublishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+
+	// The received file is moved to where regular uploads are kept, publish takes care of it from there
+	f, err := h.createFile(user.ID, meta.Filename)
+	if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	f.Close()
+	filePath := f.Name()
+	if err := os.Rename(h.tusPath(user.ID, uploadID)+".bin", filePath); err != nil {
+		os.Remove(filePath)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	h.removeTusUpload(user.ID, uploadID)
+	if err := h.admitFile(user.ID, filePath); err != nil {
+		os.Remove(filePath)
+		w.Write(rpcerrors.ToJSON(err))
+		return
+	}
+	if f, err = os.Open(filePath); err != nil {
+		os.Remove(filePath)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer f.Close()
+	logger.WithFields(logrus.Fields{"user_id": user.ID, "upload_id": uploadID}).Infof("resumable upload of %v bytes complete", meta.Length)
+
+	progress.link(f.Name(), uploadID)
+	h.publish(w, r, user, f, meta.Filename, req.JSONPayload, nil)
+}
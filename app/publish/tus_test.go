@@ -0,0 +1,163 @@
+package publish
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/test"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTusMetadata(t *testing.T) {
+	md, err := parseTusMetadata("filename " + base64.StdEncoding.EncodeToString([]byte("video.mp4")) + ",is_private")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"filename": "video.mp4", "is_private": ""}, md)
+
+	_, err = parseTusMetadata("filename !!!")
+	assert.Error(t, err)
+}
+
+func TestTusUpload(t *testing.T) {
+	config.Override("ResumableUploads", map[string]interface{}{"MaxSize": 20, "TTL": "1h"})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	handler, call := e.handler, e.call
+	tusHeader := func(h map[string]string) map[string]string {
+		h["Tus-Resumable"] = TusVersion
+		return h
+	}
+	filename := base64.StdEncoding.EncodeToString([]byte("../lbry_auto_test_file"))
+
+	rr := call(handler.HandleTusCreate, http.MethodPost, nil, nil, map[string]string{"Upload-Length": "16"})
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	rr = call(handler.HandleTusCreate, http.MethodPost, nil, nil, tusHeader(map[string]string{"Upload-Length": "21", "Upload-Metadata": "filename " + filename}))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	rr = call(handler.HandleTusCreate, http.MethodPost, nil, nil, tusHeader(map[string]string{"Upload-Length": "16"}))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = call(handler.HandleTusCreate, http.MethodPost, nil, nil, tusHeader(map[string]string{"Upload-Length": "16", "Upload-Metadata": "filename " + filename}))
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, TusVersion, rr.Header().Get("Tus-Resumable"))
+	assert.NotEmpty(t, rr.Header().Get("Upload-Expires"))
+	id := path.Base(rr.Header().Get("Location"))
+	require.Len(t, id, 32)
+	vars := map[string]string{"id": id}
+
+	offset := func() string {
+		rr := call(handler.HandleTusHead, http.MethodHead, nil, vars, tusHeader(map[string]string{}))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "16", rr.Header().Get("Upload-Length"))
+		return rr.Header().Get("Upload-Offset")
+	}
+	patch := func(off string, data []byte) *http.Response {
+		rr := call(handler.HandleTusPatch, http.MethodPatch, data, vars, tusHeader(map[string]string{
+			"Content-Type": tusContentType, "Upload-Offset": off}))
+		return rr.Result()
+	}
+	assert.Equal(t, "0", offset())
+
+	resp := patch("0", []byte("first chu"))
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "9", resp.Header.Get("Upload-Offset"))
+	assert.Equal(t, "9", offset())
+
+	// Chunks have to continue where the upload stopped
+	assert.Equal(t, http.StatusConflict, patch("0", []byte("first chu")).StatusCode)
+	rr = call(handler.HandleTusPatch, http.MethodPatch, []byte("nk"), vars, tusHeader(map[string]string{"Upload-Offset": "9"}))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rr.Code)
+
+	payload := json.RawMessage(fmt.Sprintf(expectedStreamCreateRequest, sdkrouter.WalletID(20404), "arst"))
+	publishBody, err := json.Marshal(map[string]interface{}{"json_payload": payload})
+	require.NoError(t, err)
+	rr = call(handler.HandleTusPublish, http.MethodPost, publishBody, vars, nil)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Anything beyond the declared length is not taken
+	resp = patch("9", []byte("nk, rest and more"))
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "16", resp.Header.Get("Upload-Offset"))
+
+	rr = call(handler.HandleTusPublish, http.MethodPost, publishBody, vars, nil)
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	test.AssertEqualJSON(t, expectedStreamCreateResponse, rr.Body.Bytes())
+	assert.Equal(t, []byte("first chunk, res"), <-e.published)
+
+	// Upload is gone once published
+	rr = call(handler.HandleTusHead, http.MethodHead, nil, vars, tusHeader(map[string]string{}))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	_, err = os.Stat(handler.tusPath(20404, id) + ".bin")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTusCreate_UploadLimits(t *testing.T) {
+	config.Override("ResumableUploads", map[string]interface{}{"MaxSize": 20, "TTL": "1h"})
+	config.Override("UploadLimits", map[string]interface{}{"MaxFileSize": 10})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	filename := base64.StdEncoding.EncodeToString([]byte("lbry_auto_test_file"))
+
+	rr := e.call(e.handler.HandleTusCreate, http.MethodPost, nil, nil, map[string]string{
+		"Tus-Resumable": TusVersion, "Upload-Length": "16", "Upload-Metadata": "filename " + filename,
+	})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Contains(t, rr.Body.String(), ErrFileTooLarge.Error())
+}
+
+func TestTusTermination(t *testing.T) {
+	config.Override("ResumableUploads", map[string]interface{}{"MaxSize": 20, "TTL": "1h"})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	header := map[string]string{"Tus-Resumable": TusVersion, "Upload-Length": "5",
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("file.txt"))}
+
+	rr := e.call(e.handler.HandleTusCreate, http.MethodPost, nil, nil, header)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	vars := map[string]string{"id": path.Base(rr.Header().Get("Location"))}
+
+	rr = e.call(e.handler.HandleTusDelete, http.MethodDelete, nil, vars, map[string]string{"Tus-Resumable": TusVersion})
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = e.call(e.handler.HandleTusDelete, http.MethodDelete, nil, vars, map[string]string{"Tus-Resumable": TusVersion})
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestTusChunkSizeSuggestions(t *testing.T) {
+	config.Override("ResumableUploads", map[string]interface{}{
+		"MaxSize": 100, "TTL": "1h", "InitialChunkSize": 16, "MinChunkSize": 4, "MaxChunkSize": 64, "ChunkDuration": "10s",
+	})
+	defer config.RestoreOverridden()
+
+	e := newUploadEnv(t, expectedStreamCreateResponse)
+	defer e.close()
+	header := map[string]string{"Tus-Resumable": TusVersion, "Upload-Length": "100",
+		"Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("file.txt"))}
+
+	rr := e.call(e.handler.HandleTusCreate, http.MethodPost, nil, nil, header)
+	require.Equal(t, http.StatusCreated, rr.Code, rr.Body.String())
+	assert.Equal(t, "16", rr.Header().Get(chunkSizeHeader))
+	vars := map[string]string{"id": path.Base(rr.Header().Get("Location"))}
+
+	rr = e.call(e.handler.HandleTusPatch, http.MethodPatch, []byte("0123456789abcdef"), vars, map[string]string{
+		"Tus-Resumable": TusVersion, "Content-Type": tusContentType, "Upload-Offset": "0"})
+	require.Equal(t, http.StatusNoContent, rr.Code, rr.Body.String())
+	// A fast chunk doubles the suggestion
+	assert.Equal(t, "32", rr.Header().Get(chunkSizeHeader))
+
+	rr = e.call(e.handler.HandleTusHead, http.MethodHead, nil, vars, map[string]string{"Tus-Resumable": TusVersion})
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "32", rr.Header().Get(chunkSizeHeader))
+}
@@ -0,0 +1,332 @@
+package published
+
+import (
+	"strings"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "published"
+
+// publishingMethods create claims which are added to the index.
+var publishingMethods = []string{"publish", "stream_create", "channel_create"}
+
+// searchFilters are claim_search params published claims are matched against,
+// searches using any param not in this or searchOptions are left as they are.
+var searchFilters = []string{"claim_id", "claim_ids", query.ParamChannelID, "channel_ids", "channel", "claim_type"}
+
+// searchOptions are claim_search params which don't affect which claims are returned.
+var searchOptions = []string{
+	"page", "page_size", "order_by", "no_totals", "include_purchase_receipt", "include_is_my_output", query.ParamWalletID,
+}
+
+// InstallHooks makes c add claims it publishes to the current index and amend resolve and claim_search
+// responses missing claims from the index. Queries matching published claims bypass the query cache,
+// as cached responses could be from before the claims were published, along with those c.SkipCache already skips.
+func InstallHooks(c *query.Caller) {
+	for _, m := range publishingMethods {
+		c.AddPostflightHook(m, addPublished, hookName)
+	}
+	c.AddPostflightHook(query.MethodResolve, amendResolve, hookName)
+	c.AddPostflightHook(query.MethodClaimSearch, amendClaimSearch, hookName)
+	skip := c.SkipCache
+	c.SkipCache = func(q *query.Query) bool {
+		return Matches(q) || (skip != nil && skip(q))
+	}
+}
+
+// addPublished adds claims created by a successful publish call.
+func addPublished(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	idx := Current()
+	if idx == nil || hctx.Response == nil || hctx.Response.Error != nil || !isPublishing(hctx.Query.Method()) {
+		return nil, nil
+	}
+	params := hctx.Query.ParamsAsMap()
+	tx, _ := hctx.Response.Result.(map[string]interface{})
+	outputs, _ := tx["outputs"].([]interface{})
+	for _, o := range outputs {
+		txo, ok := o.(map[string]interface{})
+		if !ok || txo["type"] != "claim" {
+			continue
+		}
+		c := &Claim{Output: txo}
+		c.ClaimID, _ = txo["claim_id"].(string)
+		c.Name, _ = txo["name"].(string)
+		if c.ClaimID == "" || c.Name == "" {
+			continue
+		}
+		if channel, ok := txo["signing_channel"].(map[string]interface{}); ok {
+			c.ChannelID, _ = channel["claim_id"].(string)
+			c.ChannelName, _ = channel["name"].(string)
+		} else if params != nil {
+			c.ChannelID, _ = params[query.ParamChannelID].(string)
+			c.ChannelName, _ = params["channel_name"].(string)
+		}
+		idx.Add(c)
+	}
+	return nil, nil
+}
+
+// isPublishing is needed because isMatchingHook also matches by prefix.
+func isPublishing(method string) bool {
+	for _, m := range publishingMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true for resolve and claim_search queries which published claims would be added to.
+func Matches(q *query.Query) bool {
+	idx := Current()
+	if idx == nil || idx.Len() == 0 {
+		return false
+	}
+	switch q.Method() {
+	case query.MethodResolve:
+		for _, u := range q.ResolveURLs() {
+			if idx.resolve(u) != nil {
+				return true
+			}
+		}
+	case query.MethodClaimSearch:
+		return len(idx.search(q.ParamsAsMap())) > 0
+	}
+	return false
+}
+
+// amendResolve serves published claims for URLs the Hub couldn't find, and reconciles the ones it did.
+func amendResolve(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	idx := Current()
+	if idx == nil || idx.Len() == 0 || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for u, v := range result {
+		claim, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if claim["error"] == nil {
+			if id, ok := claim["claim_id"].(string); ok {
+				idx.Reconcile(id)
+			}
+			continue
+		}
+		if c := idx.resolve(u); c != nil {
+			result[u] = c.output()
+			metrics.PublishedClaims.WithLabelValues(metrics.PublishedServed).Inc()
+			hctx.AddLogField("published_echo", true)
+		}
+	}
+	return nil, nil
+}
+
+// amendClaimSearch adds published claims missing from the first page of results, and reconciles the ones present.
+func amendClaimSearch(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	idx := Current()
+	if idx == nil || idx.Len() == 0 || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	items, _ := result["items"].([]interface{})
+	found := map[string]bool{}
+	for _, i := range items {
+		if claim, ok := i.(map[string]interface{}); ok {
+			if id, ok := claim["claim_id"].(string); ok {
+				found[id] = true
+				idx.Reconcile(id)
+			}
+		}
+	}
+
+	missing := []interface{}{}
+	for _, c := range idx.search(hctx.Query.ParamsAsMap()) {
+		if !found[c.ClaimID] {
+			missing = append(missing, c.output())
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	// Listings are usually sorted newest first, which is where just published claims belong
+	result["items"] = append(missing, items...)
+	if total, ok := result["total_items"].(float64); ok {
+		result["total_items"] = total + float64(len(missing))
+	}
+	metrics.PublishedClaims.WithLabelValues(metrics.PublishedServed).Add(float64(len(missing)))
+	hctx.AddLogField("published_echo", true)
+	return nil, nil
+}
+
+// output returns a copy of the claim output so responses can't modify the stored one.
+func (c *Claim) output() map[string]interface{} {
+	o := map[string]interface{}{}
+	for k, v := range c.Output {
+		o[k] = v
+	}
+	return o
+}
+
+// urlPart is a claim name with an optional claim ID prefix, as in lbry://name#abc.
+type urlPart struct {
+	name     string
+	idPrefix string
+}
+
+func (p urlPart) matches(name, claimID string) bool {
+	return name != "" && strings.EqualFold(p.name, name) && strings.HasPrefix(claimID, strings.ToLower(p.idPrefix))
+}
+
+// parseURL splits a claim URL into the channel and stream parts, channel URLs only have the former.
+// Sequence and amount order modifiers are not supported.
+func parseURL(url string) (parts []urlPart, ok bool) {
+	url = strings.TrimPrefix(url, "lbry://")
+	segments := strings.Split(url, "/")
+	if len(segments) > 2 {
+		return nil, false
+	}
+	for _, s := range segments {
+		p := urlPart{name: s}
+		if i := strings.IndexAny(s, "#:$*"); i >= 0 {
+			if s[i] != '#' && s[i] != ':' {
+				return nil, false
+			}
+			p = urlPart{name: s[:i], idPrefix: s[i+1:]}
+		}
+		if p.name == "" {
+			return nil, false
+		}
+		parts = append(parts, p)
+	}
+	return parts, true
+}
+
+// resolve returns the published claim url points to.
+func (i *Index) resolve(url string) *Claim {
+	parts, ok := parseURL(url)
+	if !ok {
+		return nil
+	}
+	claim := parts[len(parts)-1]
+	var channel *urlPart
+	if len(parts) == 2 {
+		channel = &parts[0]
+	}
+	claims := i.filter(i.ids(nameKey(claim.name)), func(c *Claim) bool {
+		return claim.matches(c.Name, c.ClaimID) && (channel == nil || channel.matches(c.ChannelName, c.ChannelID))
+	})
+	if len(claims) != 1 {
+		// Which one of several claims with the same name a URL without a full claim ID points to is up to the Hub
+		return nil
+	}
+	return claims[0]
+}
+
+// search returns published claims matching claim_search params. Only the first page of searches
+// filtering by claim or channel IDs are matched, as matching other filters requires the Hub.
+func (i *Index) search(params map[string]interface{}) []*Claim {
+	if params == nil {
+		return nil
+	}
+	if page, ok := params["page"].(float64); ok && page > 1 {
+		return nil
+	}
+	for p := range params {
+		if !inList(p, searchFilters) && !inList(p, searchOptions) {
+			return nil
+		}
+	}
+
+	claimIDs := stringList(params["claim_id"], params["claim_ids"])
+	channels := stringList(params[query.ParamChannelID], params["channel_ids"])
+	if channel, ok := params["channel"].(string); ok {
+		if parts, ok := parseURL(channel); ok && len(parts) == 1 {
+			channels = append(channels, parts[0].name)
+		}
+	}
+	ids := claimIDs
+	if len(ids) == 0 {
+		seen := map[string]bool{}
+		for _, ch := range channels {
+			for _, id := range i.ids(channelKey(ch)) {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	claimTypes := stringList(params["claim_type"])
+	return i.filter(ids, func(c *Claim) bool {
+		if len(channels) > 0 && !c.inChannel(channels) {
+			return false
+		}
+		return len(claimTypes) == 0 || inList(c.claimType(), claimTypes)
+	})
+}
+
+// inChannel returns true if c is signed by one of channels, given by claim IDs or names.
+func (c *Claim) inChannel(channels []string) bool {
+	for _, ch := range channels {
+		if ch == c.ChannelID || (c.ChannelName != "" && strings.EqualFold(ch, c.ChannelName)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Claim) claimType() string {
+	if t, ok := c.Output["value_type"].(string); ok && t != "" {
+		return t
+	}
+	if strings.HasPrefix(c.Name, "@") {
+		return "channel"
+	}
+	return "stream"
+}
+
+// stringList collects strings from values, which can be strings or lists of them.
+func stringList(values ...interface{}) []string {
+	l := []string{}
+	for _, v := range values {
+		switch typed := v.(type) {
+		case string:
+			if typed != "" {
+				l = append(l, typed)
+			}
+		case []interface{}:
+			for _, e := range typed {
+				if s, ok := e.(string); ok && s != "" {
+					l = append(l, s)
+				}
+			}
+		case []string:
+			l = append(l, typed...)
+		}
+	}
+	return l
+}
+
+func inList(s string, l []string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
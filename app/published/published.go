@@ -0,0 +1,199 @@
+// Package published keeps claims created through this instance until the Hub indexes them.
+//
+// The SDK returns a publish transaction as soon as it's broadcast, but it takes a while before the Hub
+// serves the new claim in resolve and claim_search results. Claims from publish responses are added
+// to an index which resolve and claim_search responses are amended from (see InstallHooks),
+// so creators see their content right away. Claims are dropped from the index once the Hub returns them
+// or after TTL, whichever comes first.
+package published
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+var current *Index
+
+// Claim is a claim published through this instance.
+type Claim struct {
+	ClaimID     string
+	Name        string
+	ChannelID   string
+	ChannelName string
+	// Output is the claim output of the publish transaction, served in place of the claim the Hub doesn't have yet.
+	Output  map[string]interface{}
+	AddedAt time.Time
+}
+
+// Index stores published claims for up to TTL.
+type Index struct {
+	TTL time.Duration
+	// Size caps the number of stored claims, the oldest one is dropped to make room for a new one.
+	Size int
+
+	mu     sync.RWMutex
+	claims map[string]*Claim
+	// lookup maps claim names, channel IDs and channel names to IDs of claims having them.
+	lookup map[string]map[string]bool
+}
+
+// NewIndex creates an index of published claims.
+func NewIndex(ttl time.Duration, size int) *Index {
+	return &Index{TTL: ttl, Size: size, claims: map[string]*Claim{}, lookup: map[string]map[string]bool{}}
+}
+
+// SetIndex sets the index used by proxy hooks.
+func SetIndex(i *Index) {
+	current = i
+}
+
+// Current returns the index set by SetIndex, nil if published claims are not tracked.
+func Current() *Index {
+	return current
+}
+
+func nameKey(name string) string {
+	return "name:" + strings.ToLower(name)
+}
+
+func channelKey(channel string) string {
+	return "channel:" + strings.ToLower(channel)
+}
+
+func keys(c *Claim) []string {
+	k := []string{nameKey(c.Name)}
+	if c.ChannelID != "" {
+		k = append(k, channelKey(c.ChannelID))
+	}
+	if c.ChannelName != "" {
+		k = append(k, channelKey(c.ChannelName))
+	}
+	return k
+}
+
+// Add stores c, replacing an earlier claim with the same ID.
+func (i *Index) Add(c *Claim) {
+	if c.AddedAt.IsZero() {
+		c.AddedAt = time.Now()
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.claims[c.ClaimID]; ok {
+		i.remove(c.ClaimID)
+	} else if i.Size > 0 && len(i.claims) >= i.Size {
+		var oldest *Claim
+		for _, e := range i.claims {
+			if oldest == nil || e.AddedAt.Before(oldest.AddedAt) {
+				oldest = e
+			}
+		}
+		i.remove(oldest.ClaimID)
+		metrics.PublishedClaims.WithLabelValues(metrics.PublishedExpired).Inc()
+	}
+	i.claims[c.ClaimID] = c
+	for _, k := range keys(c) {
+		if i.lookup[k] == nil {
+			i.lookup[k] = map[string]bool{}
+		}
+		i.lookup[k][c.ClaimID] = true
+	}
+	metrics.PublishedClaims.WithLabelValues(metrics.PublishedAdded).Inc()
+}
+
+// remove must be called with the write lock held.
+func (i *Index) remove(claimID string) {
+	c, ok := i.claims[claimID]
+	if !ok {
+		return
+	}
+	delete(i.claims, claimID)
+	for _, k := range keys(c) {
+		delete(i.lookup[k], claimID)
+		if len(i.lookup[k]) == 0 {
+			delete(i.lookup, k)
+		}
+	}
+}
+
+// Reconcile drops the claim once the Hub has it.
+func (i *Index) Reconcile(claimID string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if _, ok := i.claims[claimID]; ok {
+		i.remove(claimID)
+		metrics.PublishedClaims.WithLabelValues(metrics.PublishedReconciled).Inc()
+	}
+}
+
+// Len returns the number of claims in the index, including expired ones not dropped yet.
+func (i *Index) Len() int {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return len(i.claims)
+}
+
+// Get returns the claim with claimID.
+func (i *Index) Get(claimID string) *Claim {
+	claims := i.filter([]string{claimID}, nil)
+	if len(claims) == 0 {
+		return nil
+	}
+	return claims[0]
+}
+
+// ByName returns claims named name.
+func (i *Index) ByName(name string) []*Claim {
+	return i.filter(i.ids(nameKey(name)), nil)
+}
+
+// ByChannel returns claims signed by a channel, identified either by its claim ID or its name.
+func (i *Index) ByChannel(channel string) []*Claim {
+	return i.filter(i.ids(channelKey(channel)), nil)
+}
+
+func (i *Index) ids(key string) []string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	ids := []string{}
+	for id := range i.lookup[key] {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// filter returns claims with ids which are not expired and match, if it's not nil.
+// Expired claims are dropped.
+func (i *Index) filter(ids []string, match func(*Claim) bool) []*Claim {
+	claims := []*Claim{}
+	expired := []string{}
+	i.mu.RLock()
+	for _, id := range ids {
+		c, ok := i.claims[id]
+		if !ok {
+			continue
+		}
+		if time.Since(c.AddedAt) > i.TTL {
+			expired = append(expired, id)
+			continue
+		}
+		if match == nil || match(c) {
+			claims = append(claims, c)
+		}
+	}
+	i.mu.RUnlock()
+
+	if len(expired) > 0 {
+		i.mu.Lock()
+		for _, id := range expired {
+			if c, ok := i.claims[id]; ok && time.Since(c.AddedAt) > i.TTL {
+				i.remove(id)
+				metrics.PublishedClaims.WithLabelValues(metrics.PublishedExpired).Inc()
+			}
+		}
+		i.mu.Unlock()
+	}
+	return claims
+}
@@ -0,0 +1,179 @@
+package published
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	streamID  = "b3f7a2c1d9e8f6a5b4c3d2e1f0a9b8c7d6e5f4a3"
+	channelID = "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"
+	walletID  = "lbrytv-id.751365.wallet"
+)
+
+func publishResponse() *jsonrpc.RPCResponse {
+	return &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{
+				"type":          "claim",
+				"claim_id":      streamID,
+				"name":          "my-video",
+				"value_type":    "stream",
+				"permanent_url": "lbry://my-video#" + streamID,
+			},
+			map[string]interface{}{"type": "change", "amount": "0.9"},
+		},
+	}}
+}
+
+func hookContext(t *testing.T, method string, params map[string]interface{}, res *jsonrpc.RPCResponse) *query.HookContext {
+	q, err := query.NewQuery(jsonrpc.NewRequest(method, params), walletID)
+	require.NoError(t, err)
+	return &query.HookContext{Query: q, Response: res}
+}
+
+func setupIndex(t *testing.T) *Index {
+	idx := NewIndex(time.Minute, 10)
+	SetIndex(idx)
+	_, err := addPublished(nil, hookContext(t, "publish", map[string]interface{}{
+		"name": "my-video", query.ParamChannelID: channelID, "channel_name": "@creator",
+	}, publishResponse()))
+	require.NoError(t, err)
+	require.Equal(t, 1, idx.Len())
+	return idx
+}
+
+func TestAddPublished(t *testing.T) {
+	idx := setupIndex(t)
+	c := idx.Get(streamID)
+	require.NotNil(t, c)
+	assert.Equal(t, "my-video", c.Name)
+	assert.Equal(t, channelID, c.ChannelID)
+	assert.Equal(t, "@creator", c.ChannelName)
+	assert.Len(t, idx.ByName("My-Video"), 1)
+	assert.Len(t, idx.ByChannel(channelID), 1)
+	assert.Len(t, idx.ByChannel("@Creator"), 1)
+
+	_, err := addPublished(nil, hookContext(t, "publish", map[string]interface{}{}, &jsonrpc.RPCResponse{Error: &jsonrpc.RPCError{Message: "failed"}}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, idx.Len())
+}
+
+func TestIndex_Expiry(t *testing.T) {
+	idx := NewIndex(time.Minute, 2)
+	idx.Add(&Claim{ClaimID: "1", Name: "old", AddedAt: time.Now().Add(-2 * time.Minute)})
+	idx.Add(&Claim{ClaimID: "2", Name: "new"})
+	assert.Nil(t, idx.Get("1"))
+	assert.Equal(t, 1, idx.Len())
+
+	idx.Add(&Claim{ClaimID: "3", Name: "newer"})
+	idx.Add(&Claim{ClaimID: "4", Name: "newest"})
+	assert.Equal(t, 2, idx.Len())
+	assert.Nil(t, idx.Get("2"))
+	assert.Empty(t, idx.ByName("new"))
+}
+
+func TestAmendResolve(t *testing.T) {
+	idx := setupIndex(t)
+	notFound := func() map[string]interface{} {
+		return map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND"}}
+	}
+	urls := []interface{}{
+		"lbry://my-video", "my-video#b3f", "lbry://@creator/my-video", "@creator#0a/my-video:b3",
+		"lbry://my-video#abc", "lbry://@other/my-video", "lbry://my-video$2", "lbry://other",
+	}
+	result := map[string]interface{}{}
+	for _, u := range urls {
+		result[u.(string)] = notFound()
+	}
+	hctx := hookContext(t, query.MethodResolve, map[string]interface{}{query.ParamUrls: urls}, &jsonrpc.RPCResponse{Result: result})
+
+	assert.True(t, Matches(hctx.Query))
+	_, err := amendResolve(nil, hctx)
+	require.NoError(t, err)
+	for i, u := range urls {
+		claim := result[u.(string)].(map[string]interface{})
+		if i < 4 {
+			assert.Equal(t, streamID, claim["claim_id"], u)
+		} else {
+			assert.NotNil(t, claim["error"], u)
+		}
+	}
+
+	// Once the Hub returns the claim it's not served from the index anymore
+	result = map[string]interface{}{"lbry://my-video": map[string]interface{}{"claim_id": streamID, "name": "my-video"}}
+	_, err = amendResolve(nil, hookContext(t, query.MethodResolve, map[string]interface{}{query.ParamUrls: "lbry://my-video"}, &jsonrpc.RPCResponse{Result: result}))
+	require.NoError(t, err)
+	assert.Equal(t, 0, idx.Len())
+}
+
+func TestAmendClaimSearch(t *testing.T) {
+	idx := setupIndex(t)
+	search := func(params map[string]interface{}, items ...interface{}) map[string]interface{} {
+		result := map[string]interface{}{"items": items, "page": 1.0, "total_items": float64(len(items))}
+		hctx := hookContext(t, query.MethodClaimSearch, params, &jsonrpc.RPCResponse{Result: result})
+		_, err := amendClaimSearch(nil, hctx)
+		require.NoError(t, err)
+		return result
+	}
+	older := map[string]interface{}{"claim_id": "c0ffee", "name": "older-video"}
+
+	for _, params := range []map[string]interface{}{
+		{"channel_ids": []interface{}{channelID}, "order_by": []interface{}{"release_time"}},
+		{"channel": "@creator#0a1b"},
+		{"claim_ids": []interface{}{streamID}, "claim_type": "stream"},
+	} {
+		result := search(params, older)
+		items := result["items"].([]interface{})
+		require.Len(t, items, 2, params)
+		assert.Equal(t, streamID, items[0].(map[string]interface{})["claim_id"])
+		assert.Equal(t, 2.0, result["total_items"])
+	}
+
+	for _, params := range []map[string]interface{}{
+		{"channel_ids": []interface{}{channelID}, "page": 2.0},
+		{"channel_ids": []interface{}{channelID}, "any_tags": []interface{}{"music"}},
+		{"channel_ids": []interface{}{channelID}, "claim_type": "channel"},
+		{"channel": "@other"},
+		{"order_by": []interface{}{"trending_group"}},
+	} {
+		assert.Len(t, search(params, older)["items"], 1, params)
+	}
+
+	search(map[string]interface{}{"channel_ids": []interface{}{channelID}}, map[string]interface{}{"claim_id": streamID})
+	assert.Equal(t, 0, idx.Len())
+}
+
+func TestInstallHooks_SkipsCache(t *testing.T) {
+	setupIndex(t)
+	c := query.NewCaller("http://sdk", 0)
+	InstallHooks(c)
+	matching, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{"channel_ids": []interface{}{channelID}}), "")
+	require.NoError(t, err)
+	other, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{"channel": "@other"}), "")
+	require.NoError(t, err)
+	assert.True(t, c.SkipCache(matching))
+	assert.False(t, c.SkipCache(other))
+}
+
+func TestInstallHooks_KeepsSkipCache(t *testing.T) {
+	setupIndex(t)
+	c := query.NewCaller("http://sdk", 0)
+	c.SkipCache = func(q *query.Query) bool { return q.ParamsAsMap()["channel"] == "@other" }
+	InstallHooks(c)
+	matching, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{"channel_ids": []interface{}{channelID}}), "")
+	require.NoError(t, err)
+	other, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{"channel": "@other"}), "")
+	require.NoError(t, err)
+	unrelated, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{"channel": "@third"}), "")
+	require.NoError(t, err)
+	assert.True(t, c.SkipCache(matching))
+	assert.True(t, c.SkipCache(other))
+	assert.False(t, c.SkipCache(unrelated))
+}
@@ -0,0 +1,80 @@
+package quarantine
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/models"
+)
+
+// ErrFlagged is returned to uploaders whose file has been quarantined. What exactly was found
+// is only available to admins.
+var ErrFlagged = errors.Base("uploaded file has been flagged and withheld for review")
+
+// Check inspects an uploaded file, returning true and a description of the problem if it should be quarantined.
+type Check func(path string) (bool, string, error)
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+var (
+	checksMu sync.RWMutex
+	checks   []namedCheck
+)
+
+// RegisterCheck adds a check run on every upload. name is recorded as the source of quarantined files.
+func RegisterCheck(name string, c Check) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks = append(checks, namedCheck{name, c})
+}
+
+// Inspect runs registered checks on the uploaded file and moves it into quarantine on the first one flagging it,
+// returning the quarantine record. Nil is returned for files which passed all checks.
+// Checks failing to run are treated as errors so uploads aren't let through unchecked.
+func (s *Store) Inspect(path string, userID int, fileName string) (*models.QuarantinedFile, error) {
+	checksMu.RLock()
+	current := checks
+	checksMu.RUnlock()
+
+	for _, c := range current {
+		flagged, reason, err := c.check(path)
+		if err != nil {
+			return nil, errors.Prefix(c.name+" check failed", err)
+		}
+		if flagged {
+			return s.Put(path, userID, fileName, c.name, reason)
+		}
+	}
+	return nil, nil
+}
+
+// Inspect runs registered checks against the default quarantine, see Store.Inspect.
+func Inspect(path string, userID int, fileName string) (*models.QuarantinedFile, error) {
+	return defaultStore.Inspect(path, userID, fileName)
+}
+
+// CommandCheck runs an external scanner with the file path appended to args.
+// Following the convention of clamscan/clamdscan, exit code 1 means the file is infected
+// and the scanner output is recorded as the reason, any other non-zero code is an error.
+func CommandCheck(args []string) Check {
+	return func(path string) (bool, string, error) {
+		var out bytes.Buffer
+		cmd := exec.Command(args[0], append(args[1:], path)...)
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		if err == nil {
+			return false, "", nil
+		}
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			return true, strings.TrimSpace(out.String()), nil
+		}
+		return false, "", errors.Err("%v: %v", err, strings.TrimSpace(out.String()))
+	}
+}
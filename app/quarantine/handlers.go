@@ -0,0 +1,148 @@
+package quarantine
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/ip"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+type dispositionRequest struct {
+	Disposition string `json:"disposition"`
+	Reviewer    string `json:"reviewer"`
+	Note        string `json:"note"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrFileRemoved):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidDisposition):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrAlreadyReviewed):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func idFromRequest(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		return 0, errors.Err("invalid id")
+	}
+	return id, nil
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+// HandleList returns quarantined files, optionally filtered by `status`, paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	files, err := List(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, files)
+}
+
+// HandleGet returns a single quarantined file record.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	qf, err := Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, qf)
+}
+
+// HandleDownload sends contents of a quarantined file as an attachment. Downloads are logged
+// since the file is potentially malicious and may be needed as evidence.
+func HandleDownload(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	qf, err := Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	f, err := Open(qf)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer f.Close()
+
+	logger.WithFields(logrus.Fields{"id": qf.ID, "ip": ip.AddressForRequest(r)}).Info("quarantined file downloaded")
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("quarantined-%d.bin", qf.ID)))
+	w.Header().Set("Content-Length", strconv.FormatInt(qf.Size, 10))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, f)
+}
+
+// HandleDispose records the review outcome for a quarantined file.
+func HandleDispose(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req dispositionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	qf, err := Dispose(id, req.Disposition, req.Reviewer, req.Note)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, qf)
+}
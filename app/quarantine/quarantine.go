@@ -0,0 +1,232 @@
+// Package quarantine keeps uploads flagged by content checks or moderators out of the publishing flow.
+// Flagged files are moved into a separate directory readable only by lbrytv instead of being deleted,
+// so admins can download and investigate them before deciding what to do with them.
+package quarantine
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+)
+
+const (
+	// StatusPending is set on newly quarantined files awaiting review.
+	StatusPending = "pending"
+	// StatusConfirmed means the file was found to be malicious or violating and is kept as evidence.
+	StatusConfirmed = "confirmed"
+	// StatusReleased means the file was flagged by mistake, it is removed from quarantine.
+	StatusReleased = "released"
+	// StatusDeleted means the file was removed from quarantine without further action.
+	StatusDeleted = "deleted"
+
+	dirPerm  = 0700
+	filePerm = 0600
+)
+
+var (
+	logger = monitor.NewModuleLogger("quarantine")
+
+	ErrNotFound           = errors.Base("quarantined file not found")
+	ErrInvalidDisposition = errors.Base("disposition must be one of: confirmed, released, deleted")
+	ErrAlreadyReviewed    = errors.Base("quarantined file has already been reviewed")
+	ErrFileRemoved        = errors.Base("quarantined file has been removed from storage")
+)
+
+// Store moves flagged files into Dir and keeps track of them in the database.
+type Store struct {
+	Dir string
+}
+
+// NewStore creates a store keeping quarantined files in dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+var defaultStore = NewStore(config.GetQuarantineDir())
+
+// Put moves the file at path into quarantine. source names the stage which flagged the file
+// (e.g. a scanner or moderation) and reason is what it reported.
+func (s *Store) Put(path string, userID int, fileName, source, reason string) (*models.QuarantinedFile, error) {
+	if err := os.MkdirAll(s.Dir, dirPerm); err != nil {
+		return nil, errors.Err(err)
+	}
+	target, err := s.newPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := move(path, target); err != nil {
+		return nil, errors.Prefix("cannot move file into quarantine", err)
+	}
+	if err := os.Chmod(target, filePerm); err != nil {
+		return nil, errors.Err(err)
+	}
+	size, hash, err := digest(target)
+	if err != nil {
+		return nil, err
+	}
+
+	qf := &models.QuarantinedFile{
+		UserID:   userID,
+		FileName: fileName,
+		Path:     target,
+		Size:     size,
+		Sha256:   hash,
+		Source:   source,
+		Reason:   reason,
+		Status:   StatusPending,
+	}
+	if err := qf.InsertG(boil.Infer()); err != nil {
+		return nil, errors.Err(err)
+	}
+	metrics.LbrytvQuarantinedFiles.WithLabelValues(source).Inc()
+	logger.WithFields(logrus.Fields{
+		"user_id": userID, "id": qf.ID, "file_name": fileName, "source": source, "sha256": hash,
+	}).Warnf("file quarantined: %v", reason)
+	return qf, nil
+}
+
+func (s *Store) newPath() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Err(err)
+	}
+	return filepath.Join(s.Dir, hex.EncodeToString(b)), nil
+}
+
+// move renames src to dst, falling back to copying when they are on different filesystems,
+// which is the case when quarantine is on a separate volume.
+func move(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, filePerm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+func digest(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", errors.Err(err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", errors.Err(err)
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// List returns quarantined files with the given status (all when empty), newest first.
+// Records soft-deleted by retention policies are left out.
+func List(status string, limit, offset int) (models.QuarantinedFileSlice, error) {
+	mods := []qm.QueryMod{
+		models.QuarantinedFileWhere.DeletedAt.IsNull(),
+		qm.OrderBy("id DESC"), qm.Limit(limit), qm.Offset(offset),
+	}
+	if status != "" {
+		mods = append(mods, models.QuarantinedFileWhere.Status.EQ(status))
+	}
+	files, err := models.QuarantinedFiles(mods...).AllG()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	return files, nil
+}
+
+// Get returns a quarantined file record by ID.
+func Get(id int) (*models.QuarantinedFile, error) {
+	qf, err := models.QuarantinedFiles(
+		models.QuarantinedFileWhere.ID.EQ(id),
+		models.QuarantinedFileWhere.DeletedAt.IsNull(),
+	).OneG()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return qf, nil
+}
+
+// Open returns the contents of a quarantined file for investigation.
+func Open(qf *models.QuarantinedFile) (*os.File, error) {
+	f, err := os.Open(qf.Path)
+	if os.IsNotExist(err) {
+		return nil, ErrFileRemoved
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return f, nil
+}
+
+// Dispose records the review outcome. Files are kept on disk only when confirmed,
+// so there is evidence if the uploader needs to be dealt with.
+func Dispose(id int, disposition, reviewer, note string) (*models.QuarantinedFile, error) {
+	switch disposition {
+	case StatusConfirmed, StatusReleased, StatusDeleted:
+	default:
+		return nil, ErrInvalidDisposition
+	}
+	qf, err := Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if qf.Status != StatusPending {
+		return nil, ErrAlreadyReviewed
+	}
+
+	if disposition != StatusConfirmed {
+		if err := os.Remove(qf.Path); err != nil && !os.IsNotExist(err) {
+			return nil, errors.Err(err)
+		}
+	}
+	qf.Status = disposition
+	qf.ReviewedBy = null.NewString(reviewer, reviewer != "")
+	qf.ReviewNote = null.NewString(note, note != "")
+	qf.ReviewedAt = null.TimeFrom(time.Now())
+	if _, err := qf.UpdateG(boil.Infer()); err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{
+		"id": qf.ID, "user_id": qf.UserID, "reviewer": reviewer, "disposition": disposition,
+	}).Info("quarantined file reviewed")
+	return qf, nil
+}
+
+// Put moves the file at path into the default quarantine, see Store.Put.
+func Put(path string, userID int, fileName, source, reason string) (*models.QuarantinedFile, error) {
+	return defaultStore.Put(path, userID, fileName, source, reason)
+}
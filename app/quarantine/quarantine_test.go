@@ -0,0 +1,158 @@
+package quarantine
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func setup(t *testing.T) (*Store, string, func()) {
+	dir, err := ioutil.TempDir("", "quarantine")
+	require.NoError(t, err)
+	s := NewStore(filepath.Join(dir, "quarantine"))
+	upload := filepath.Join(dir, "upload.mp4")
+	require.NoError(t, ioutil.WriteFile(upload, []byte("infected"), 0644))
+	return s, upload, func() {
+		checks = nil
+		os.RemoveAll(dir)
+	}
+}
+
+func TestPut(t *testing.T) {
+	s, upload, cleanup := setup(t)
+	defer cleanup()
+
+	qf, err := s.Put(upload, 1, "upload.mp4", "moderation", "reported by 3 users")
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, qf.Status)
+	assert.EqualValues(t, 8, qf.Size)
+	assert.Equal(t, "c810e76f2125db71bfbdd7e29ce902f37f5b2250c48c16d241bd46c70aed1a91", qf.Sha256)
+
+	_, err = os.Stat(upload)
+	assert.True(t, os.IsNotExist(err))
+	st, err := os.Stat(qf.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(filePerm), st.Mode().Perm())
+	dst, err := os.Stat(s.Dir)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(dirPerm), dst.Mode().Perm())
+
+	files, err := List(StatusPending, 10, 0)
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	assert.Equal(t, qf.ID, files[0].ID)
+}
+
+func TestDispose(t *testing.T) {
+	s, upload, cleanup := setup(t)
+	defer cleanup()
+
+	qf, err := s.Put(upload, 1, "upload.mp4", "moderation", "spam")
+	require.NoError(t, err)
+
+	_, err = Dispose(qf.ID, "whatever", "admin", "")
+	assert.True(t, errors.Is(err, ErrInvalidDisposition))
+
+	qf, err = Dispose(qf.ID, StatusReleased, "admin", "false positive")
+	require.NoError(t, err)
+	assert.Equal(t, StatusReleased, qf.Status)
+	assert.Equal(t, "admin", qf.ReviewedBy.String)
+	assert.True(t, qf.ReviewedAt.Valid)
+	_, err = os.Stat(qf.Path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = Dispose(qf.ID, StatusConfirmed, "admin", "")
+	assert.True(t, errors.Is(err, ErrAlreadyReviewed))
+
+	_, err = Dispose(999999, StatusConfirmed, "admin", "")
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestInspect(t *testing.T) {
+	s, upload, cleanup := setup(t)
+	defer cleanup()
+
+	RegisterCheck("scanner", CommandCheck([]string{"sh", "-c", `test "$(cat "$0")" != infected || { echo "$0: Eicar FOUND"; exit 1; }`}))
+
+	clean := filepath.Join(filepath.Dir(upload), "clean.mp4")
+	require.NoError(t, ioutil.WriteFile(clean, []byte("clean"), 0644))
+	qf, err := s.Inspect(clean, 1, "clean.mp4")
+	require.NoError(t, err)
+	assert.Nil(t, qf)
+	_, err = os.Stat(clean)
+	assert.NoError(t, err)
+
+	qf, err = s.Inspect(upload, 1, "upload.mp4")
+	require.NoError(t, err)
+	require.NotNil(t, qf)
+	assert.Equal(t, "scanner", qf.Source)
+	assert.Equal(t, upload+": Eicar FOUND", qf.Reason)
+}
+
+func TestInspect_CheckError(t *testing.T) {
+	s, upload, cleanup := setup(t)
+	defer cleanup()
+
+	RegisterCheck("scanner", CommandCheck([]string{"sh", "-c", "echo cannot connect to clamd; exit 2"}))
+	_, err := s.Inspect(upload, 1, "upload.mp4")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot connect to clamd")
+	_, err = os.Stat(upload)
+	assert.NoError(t, err)
+}
+
+func TestHandleDownload(t *testing.T) {
+	s, upload, cleanup := setup(t)
+	defer cleanup()
+
+	qf, err := s.Put(upload, 1, "upload.mp4", "moderation", "spam")
+	require.NoError(t, err)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/quarantine/{id:[0-9]+}/file", HandleDownload)
+	router.HandleFunc("/quarantine/{id:[0-9]+}/disposition", HandleDispose)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/quarantine/"+strconv.Itoa(qf.ID)+"/file", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "infected", rr.Body.String())
+	assert.Equal(t, "application/octet-stream", rr.Header().Get("Content-Type"))
+
+	rr = httptest.NewRecorder()
+	body := bytes.NewBufferString(`{"disposition": "deleted", "reviewer": "admin"}`)
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/quarantine/"+strconv.Itoa(qf.ID)+"/disposition", body))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/quarantine/"+strconv.Itoa(qf.ID)+"/file", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
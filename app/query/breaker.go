@@ -0,0 +1,118 @@
+package query
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+// A wedged SDK server makes every call to it hang until its deadline, tying up proxy goroutines and connections
+// meant for healthy servers. Breaker stops sending calls to an SDK server after enough of them in a row failed
+// to connect or timed out, and lets a single call through once in a while to check if it has recovered.
+
+// ErrSDKUnavailable is returned without calling the SDK server while its breaker is open.
+var ErrSDKUnavailable = errors.Base("SDK server is not responding")
+
+// Breaker tracks failures of calls to one SDK server.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	endpoint  string
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*Breaker{}
+)
+
+// GetBreaker returns the breaker of the SDK server at endpoint, set up as in config when it's first requested.
+func GetBreaker(endpoint string) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	b, ok := breakers[endpoint]
+	if !ok {
+		cfg := config.GetSDKBreaker()
+		b = NewBreaker(endpoint, cfg.Threshold, cfg.Cooldown)
+		breakers[endpoint] = b
+	}
+	return b
+}
+
+// NewBreaker returns a breaker which opens after threshold consecutive failures and stays open for cooldown.
+// Zero threshold disables it.
+func NewBreaker(endpoint string, threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{endpoint: endpoint, threshold: threshold, cooldown: cooldown}
+}
+
+// Allow returns true if a call may be sent to the SDK server. Once the breaker has been open for cooldown,
+// it returns true for a single call, whose outcome decides whether the breaker closes.
+func (b *Breaker) Allow() bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		metrics.SDKBreakerRejected.WithLabelValues(b.endpoint).Inc()
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// Record records the outcome of a call allowed by Allow.
+func (b *Breaker) Record(failed bool) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		if b.failures >= b.threshold {
+			logger.Log().Infof("breaker of %v closed", b.endpoint)
+			metrics.SDKBreakerOpen.WithLabelValues(b.endpoint).Set(0)
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			logger.Log().Warnf("breaker of %v opened after %v failed calls", b.endpoint, b.failures)
+			metrics.SDKBreakerOpen.WithLabelValues(b.endpoint).Set(1)
+		}
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// Release ends a call allowed by Allow without recording its outcome, for calls which tell nothing about the server.
+func (b *Breaker) Release() {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// Open returns true if calls to the SDK server are currently failing fast.
+func (b *Breaker) Open() bool {
+	if b == nil || b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
@@ -0,0 +1,77 @@
+package query
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestBreaker(t *testing.T) {
+	b := NewBreaker("http://sdk", 2, 50*time.Millisecond)
+	assert.True(t, b.Allow())
+	b.Record(true)
+	assert.True(t, b.Allow())
+	b.Record(false)
+	// Failures have to be consecutive
+	b.Record(true)
+	assert.False(t, b.Open())
+	b.Record(true)
+	assert.True(t, b.Open())
+	assert.False(t, b.Allow())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow(), "a probe should be let through after cooldown")
+	assert.False(t, b.Allow(), "only one probe at a time")
+	b.Record(true)
+	assert.False(t, b.Allow())
+
+	time.Sleep(60 * time.Millisecond)
+	assert.True(t, b.Allow())
+	b.Record(false)
+	assert.False(t, b.Open())
+	assert.True(t, b.Allow())
+}
+
+func TestBreaker_Disabled(t *testing.T) {
+	b := NewBreaker("http://sdk", 0, time.Minute)
+	for i := 0; i < 10; i++ {
+		b.Record(true)
+	}
+	assert.True(t, b.Allow())
+	assert.False(t, b.Open())
+}
+
+func TestCaller_BreakerFailsFast(t *testing.T) {
+	srv, calls := flakyServer(t, 100)
+	defer srv.Close()
+
+	b := NewBreaker(srv.URL, 2, time.Minute)
+	for i := 0; i < 3; i++ {
+		c := NewCaller(srv.URL, 0)
+		c.Retry = RetryPolicy{MaxAttempts: 1}
+		c.Breaker = b
+		_, err := c.Call(jsonrpc.NewRequest("claim_search", map[string]interface{}{}))
+		require.Error(t, err)
+		if i < 2 {
+			assert.False(t, errors.Is(err, ErrSDKUnavailable))
+			continue
+		}
+		assert.True(t, errors.Is(err, ErrSDKUnavailable))
+		var rpcErr rpcerrors.RPCError
+		require.True(t, errors.As(err, &rpcErr))
+		assert.Equal(t, -32093, rpcErr.Code())
+	}
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestCaller_BreakerSharedByEndpoint(t *testing.T) {
+	assert.Same(t, GetBreaker("http://sdk1"), NewCaller("http://sdk1", 0).Breaker)
+	assert.NotSame(t, GetBreaker("http://sdk1"), GetBreaker("http://sdk2"))
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/gob"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
 
 	"github.com/patrickmn/go-cache"
@@ -15,11 +18,19 @@ import (
 
 var cacheLogger = monitor.NewModuleLogger("cache")
 
+// sharedCache is used for API requests and can be populated outside of them, e.g. by scheduled tasks.
+var sharedCache QueryCache = newSharedCache()
+
 // QueryCache caches Query responses
 type QueryCache interface {
 	Save(method string, params interface{}, r interface{})
 	Retrieve(method string, params interface{}) interface{}
 	Count() int
+	// Invalidate drops the response to method called with params and returns true if it was cached.
+	Invalidate(method string, params interface{}) bool
+	// InvalidateMatching drops responses to method, or to any method if it's empty, called with params
+	// whose formatted value contains the substring, and returns how many were dropped.
+	InvalidateMatching(method, substring string) int
 
 	getKey(method string, params interface{}) (string, error)
 	flush()
@@ -34,6 +45,32 @@ func NewMemoryCache() memoryCache {
 	return memoryCache{c: cache.New(5*time.Minute, 15*time.Minute)}
 }
 
+// newSharedCache returns a memory cache reporting its size and evictions.
+func newSharedCache() memoryCache {
+	s := NewMemoryCache()
+	s.c.OnEvicted(func(key string, _ interface{}) {
+		metrics.ProxyQueryCacheEvictedCount.WithLabelValues(strings.SplitN(key, "|", 2)[0]).Inc()
+		metrics.ProxyQueryCacheSize.Set(float64(s.c.ItemCount()))
+	})
+	return s
+}
+
+// TTL returns how long responses to method are cached, zero if it's not cacheable.
+func TTL(method string) time.Duration {
+	return config.GetQueryCacheTTLs()[method]
+}
+
+// Shared returns the cache used for API requests.
+func Shared() QueryCache {
+	return sharedCache
+}
+
+// SetShared replaces the cache used for API requests, e.g. with one shared by all instances.
+// It has to be called before routes are set up.
+func SetShared(c QueryCache) {
+	sharedCache = c
+}
+
 // Save puts a response object into cache, making it available for a later retrieval by method and query params
 func (s memoryCache) Save(method string, params interface{}, r interface{}) {
 	l := cacheLogger.WithFields(logrus.Fields{"method": method})
@@ -43,7 +80,14 @@ func (s memoryCache) Save(method string, params interface{}, r interface{}) {
 	} else {
 		l.Debug("saved query result")
 	}
-	s.c.Set(cacheKey, r, cache.DefaultExpiration)
+	ttl := TTL(method)
+	if ttl <= 0 {
+		ttl = cache.DefaultExpiration
+	}
+	s.c.Set(cacheKey, entry{schema: SchemaVersion(), value: r, params: fmt.Sprintf("%v", params)}, ttl)
+	if s == sharedCache {
+		metrics.ProxyQueryCacheSize.Set(float64(s.c.ItemCount()))
+	}
 }
 
 // Retrieve earlier saved server response by method and query params
@@ -54,11 +98,19 @@ func (s memoryCache) Retrieve(method string, params interface{}) interface{} {
 		l.Errorf("unable to produce key for params: %v", params)
 		return nil
 	}
-	cachedResponse, ok := s.c.Get(cacheKey)
-	if ok {
-		l.Debug("query result found in cache")
+	cached, ok := s.c.Get(cacheKey)
+	if !ok {
+		return nil
+	}
+	e := cached.(entry)
+	if e.schema != SchemaVersion() {
+		l.Debugf("discarding query result cached under schema %v", e.schema)
+		metrics.ProxyQueryCacheStaleCount.WithLabelValues(method).Inc()
+		s.c.Delete(cacheKey)
+		return nil
 	}
-	return cachedResponse
+	l.Debug("query result found in cache")
+	return e.value
 }
 
 func (s memoryCache) getKey(method string, params interface{}) (key string, err error) {
@@ -79,8 +131,41 @@ func (s memoryCache) getKey(method string, params interface{}) (key string, err
 	return fmt.Sprintf("%v|%v", method, paramsSuffix), err
 }
 
+// Invalidate drops the response to method called with params and returns true if it was cached.
+func (s memoryCache) Invalidate(method string, params interface{}) bool {
+	cacheKey, err := s.getKey(method, params)
+	if err != nil {
+		return false
+	}
+	if _, ok := s.c.Get(cacheKey); !ok {
+		return false
+	}
+	s.c.Delete(cacheKey)
+	return true
+}
+
+// InvalidateMatching drops responses to method, or to any method if it's empty, called with params
+// whose formatted value contains the substring, and returns how many were dropped.
+func (s memoryCache) InvalidateMatching(method, substring string) int {
+	n := 0
+	for key, item := range s.c.Items() {
+		if method != "" && !strings.HasPrefix(key, method+"|") {
+			continue
+		}
+		if e, ok := item.Object.(entry); !ok || !strings.Contains(e.params, substring) {
+			continue
+		}
+		s.c.Delete(key)
+		n++
+	}
+	return n
+}
+
 func (s memoryCache) flush() {
 	s.c.Flush()
+	if s == sharedCache {
+		metrics.ProxyQueryCacheSize.Set(0)
+	}
 }
 
 // Count returns the total number of non-expired items stored in cache
@@ -3,10 +3,17 @@ package cache
 import (
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/ybbus/jsonrpc"
 )
 
@@ -52,3 +59,84 @@ func TestCacheGetKey(t *testing.T) {
 	assert.Equal(t, "wallet_balance|nil", key)
 	assert.NoError(t, err)
 }
+
+func TestCacheSchemaVersion(t *testing.T) {
+	SetSDKVersions([]string{"0.79.0", "0.79.0"})
+	c := NewMemoryCache()
+	c.Save("resolve", map[string]interface{}{"urls": "one"}, "result")
+	assert.Equal(t, "result", c.Retrieve("resolve", map[string]interface{}{"urls": "one"}))
+
+	SetSDKVersions([]string{"0.79.0", ""})
+	assert.Equal(t, "result", c.Retrieve("resolve", map[string]interface{}{"urls": "one"}))
+
+	SetSDKVersions([]string{"0.80.0", "0.79.0"})
+	assert.Nil(t, c.Retrieve("resolve", map[string]interface{}{"urls": "one"}))
+	assert.Equal(t, 0, c.Count())
+
+	c.Save("resolve", map[string]interface{}{"urls": "one"}, "result")
+	SetSDKVersions([]string{"0.79.0", "0.80.0"})
+	assert.Equal(t, "result", c.Retrieve("resolve", map[string]interface{}{"urls": "one"}))
+}
+
+func TestCacheTTL(t *testing.T) {
+	config.Override("QueryCacheTTLs", map[string]interface{}{"comment_list": 50 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	assert.Equal(t, 50*time.Millisecond, TTL("comment_list"))
+	assert.Zero(t, TTL("claim_search"))
+
+	c := NewMemoryCache()
+	c.Save("comment_list", map[string]interface{}{"claim_id": "abc"}, "comments")
+	c.Save("claim_search", map[string]interface{}{"channel": "@chan"}, "claims")
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, c.Retrieve("comment_list", map[string]interface{}{"claim_id": "abc"}))
+	assert.Equal(t, "claims", c.Retrieve("claim_search", map[string]interface{}{"channel": "@chan"}))
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	c := NewMemoryCache()
+	c.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://one", "lbry://two"}}, "1")
+	c.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://two", "lbry://three"}}, "2")
+	c.Save("claim_search", map[string]interface{}{"channel": "lbry://two"}, "3")
+	c.Save("claim_search", map[string]interface{}{"channel": "lbry://four"}, "4")
+
+	assert.True(t, c.Invalidate("claim_search", map[string]interface{}{"channel": "lbry://four"}))
+	assert.False(t, c.Invalidate("claim_search", map[string]interface{}{"channel": "lbry://four"}))
+	assert.Equal(t, 3, c.Count())
+
+	assert.Equal(t, 2, c.InvalidateMatching("resolve", "lbry://two"))
+	assert.Equal(t, "3", c.Retrieve("claim_search", map[string]interface{}{"channel": "lbry://two"}))
+	assert.Equal(t, 1, c.InvalidateMatching("", "lbry://two"))
+	assert.Equal(t, 0, c.Count())
+}
+
+func TestHandleInvalidate(t *testing.T) {
+	sharedCache.flush()
+	defer sharedCache.flush()
+	sharedCache.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://one"}}, "1")
+	sharedCache.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://two"}}, "2")
+	sharedCache.Save("claim_search", map[string]interface{}{"page": 1.0}, "3")
+
+	invalidate := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		HandleInvalidate(rr, httptest.NewRequest(http.MethodPost, "/query_cache/invalidate", strings.NewReader(body)))
+		return rr
+	}
+
+	rr := invalidate(`{"method": "claim_search", "params": {"page": 1}}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"invalidated": 1}`, rr.Body.String())
+
+	rr = invalidate(`{"method": "resolve", "contains": "lbry://one"}`)
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"invalidated": 1}`, rr.Body.String())
+	assert.Equal(t, "2", sharedCache.Retrieve("resolve", map[string]interface{}{"urls": []interface{}{"lbry://two"}}))
+
+	rr = invalidate(`{"method": "resolve"}`)
+	assert.JSONEq(t, `{"invalidated": 1}`, rr.Body.String())
+	assert.Equal(t, 0, sharedCache.Count())
+
+	assert.Equal(t, http.StatusBadRequest, invalidate(`{}`).Code)
+	assert.Equal(t, http.StatusBadRequest, invalidate(`{"params": {"page": 1}}`).Code)
+	assert.Equal(t, http.StatusBadRequest, invalidate(`{"method":`).Code)
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/responses"
+)
+
+type invalidateRequest struct {
+	Method   string      `json:"method"`
+	Params   interface{} `json:"params"`
+	Contains string      `json:"contains"`
+}
+
+// HandleInvalidate drops responses from the shared cache, so stale ones aren't served until they expire.
+// With `params`, only the response to `method` called with exactly these params is dropped. Otherwise responses
+// to `method`, or to any method if it's omitted, called with params containing the `contains` substring are dropped,
+// e.g. all cached resolves of a URL.
+func HandleInvalidate(w http.ResponseWriter, r *http.Request) {
+	var req invalidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid request: %v", err))
+		return
+	}
+	if req.Method == "" && req.Contains == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("method or contains is required"))
+		return
+	}
+	if req.Params != nil && req.Method == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("method is required with params"))
+		return
+	}
+
+	var n int
+	if req.Params != nil {
+		if sharedCache.Invalidate(req.Method, req.Params) {
+			n = 1
+		}
+	} else {
+		n = sharedCache.InvalidateMatching(req.Method, req.Contains)
+	}
+	label := req.Method
+	if label == "" {
+		label = "any"
+	}
+	metrics.ProxyQueryCacheInvalidatedCount.WithLabelValues(label).Add(float64(n))
+	cacheLogger.Log().Infof("invalidated %v cached responses to %q with params containing %q", n, label, req.Contains)
+	responses.WriteJSON(w, http.StatusOK, map[string]int{"invalidated": n})
+}
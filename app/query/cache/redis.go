@@ -0,0 +1,348 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/redis"
+
+	"github.com/patrickmn/go-cache"
+	"github.com/sirupsen/logrus"
+)
+
+// The memory cache is per instance, so every instance sends the SDK the same cacheable queries.
+// redisCache keeps responses in Redis, shared by all instances, with a local layer holding hot entries
+// for a short time so they're not fetched from Redis on every call. Entries invalidated on one instance
+// may be served by the local layers of others until they expire there.
+
+const (
+	SerializationJSON = "json"
+	SerializationGob  = "gob"
+
+	redisOpGet   = "get"
+	redisOpSet   = "set"
+	redisOpDel   = "del"
+	redisOpScan  = "scan"
+	redisOpCodec = "codec"
+
+	// defaultRedisTTL is used for methods without a TTL set, as the memory cache does
+	defaultRedisTTL = 5 * time.Minute
+)
+
+func init() {
+	gob.Register(json.RawMessage{})
+}
+
+// Serializer encodes cached values stored in Redis.
+type Serializer interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// JSONSerializer stores values as JSON, so entries are readable with redis-cli.
+// Values are returned as json.RawMessage, which is what Caller caches.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Marshal(v interface{}) ([]byte, error) {
+	if raw, ok := v.(json.RawMessage); ok {
+		return raw, nil
+	}
+	return json.Marshal(v)
+}
+
+func (JSONSerializer) Unmarshal(data []byte) (interface{}, error) {
+	return json.RawMessage(data), nil
+}
+
+// GobSerializer stores values with gob, keeping their types. Types other than built-in ones and json.RawMessage
+// have to be registered with gob.Register.
+type GobSerializer struct{}
+
+func (GobSerializer) Marshal(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(&v)
+	return b.Bytes(), err
+}
+
+func (GobSerializer) Unmarshal(data []byte) (interface{}, error) {
+	var v interface{}
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// redisStore is the part of redis.Client the cache uses.
+type redisStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Del(keys ...string) (int, error)
+	Scan(match string) ([]string, error)
+}
+
+// redisCache stores the cache in Redis under keys prefixed with namespace.
+type redisCache struct {
+	store      redisStore
+	namespace  string
+	serializer Serializer
+	local      *cache.Cache
+	localTTL   time.Duration
+}
+
+// NewRedisCache returns a cache storing responses in Redis as set in cfg.
+func NewRedisCache(cfg config.QueryCacheRedis) (QueryCache, error) {
+	serializer, err := NewSerializer(cfg.Serialization)
+	if err != nil {
+		return nil, err
+	}
+	client := redis.New(redis.Config{
+		Address:  cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		Timeout:  cfg.Timeout,
+		PoolSize: cfg.PoolSize,
+	})
+	if err := client.Ping(); err != nil {
+		return nil, errors.Prefix("cannot connect to query cache redis", err)
+	}
+	return newRedisCache(client, cfg.Namespace, serializer, cfg.LocalTTL), nil
+}
+
+// NewSerializer returns the serializer called name, gob being the default.
+func NewSerializer(name string) (Serializer, error) {
+	switch name {
+	case SerializationJSON:
+		return JSONSerializer{}, nil
+	case SerializationGob, "":
+		return GobSerializer{}, nil
+	}
+	return nil, errors.Err("unknown query cache serialization %q", name)
+}
+
+func newRedisCache(store redisStore, namespace string, serializer Serializer, localTTL time.Duration) redisCache {
+	c := redisCache{store: store, namespace: namespace, serializer: serializer, localTTL: localTTL}
+	if localTTL > 0 {
+		c.local = cache.New(localTTL, 2*localTTL)
+	}
+	return c
+}
+
+// Save puts a response object into Redis and the local layer.
+func (s redisCache) Save(method string, params interface{}, r interface{}) {
+	l := cacheLogger.WithFields(logrus.Fields{"method": method})
+	cacheKey, err := s.getKey(method, params)
+	if err != nil {
+		l.Errorf("unable to produce key for params: %v", params)
+		return
+	}
+	e := entry{schema: SchemaVersion(), value: r, params: fmt.Sprintf("%v", params)}
+	data, err := s.encode(e)
+	if err != nil {
+		s.reportError(l, redisOpCodec, err)
+		return
+	}
+	ttl := TTL(method)
+	if ttl <= 0 {
+		ttl = defaultRedisTTL
+	}
+	if err := s.store.Set(cacheKey, data, ttl); err != nil {
+		s.reportError(l, redisOpSet, err)
+		return
+	}
+	if s.local != nil {
+		s.local.Set(cacheKey, e, s.localTTLFor(ttl))
+	}
+	l.Debug("saved query result")
+}
+
+// Retrieve earlier saved server response by method and query params, from the local layer if it's there.
+func (s redisCache) Retrieve(method string, params interface{}) interface{} {
+	l := cacheLogger.WithFields(logrus.Fields{"method": method})
+	cacheKey, err := s.getKey(method, params)
+	if err != nil {
+		l.Errorf("unable to produce key for params: %v", params)
+		return nil
+	}
+
+	var (
+		e      entry
+		cached interface{}
+		ok     bool
+	)
+	if s.local != nil {
+		cached, ok = s.local.Get(cacheKey)
+	}
+	if ok {
+		e = cached.(entry)
+		metrics.ProxyQueryCacheLocalHitCount.WithLabelValues(method).Inc()
+	} else {
+		data, err := s.store.Get(cacheKey)
+		if err != nil {
+			s.reportError(l, redisOpGet, err)
+			return nil
+		}
+		if data == nil {
+			return nil
+		}
+		if e, err = s.decode(data); err != nil {
+			s.reportError(l, redisOpCodec, err)
+			return nil
+		}
+		if s.local != nil {
+			ttl := TTL(method)
+			if ttl <= 0 {
+				ttl = defaultRedisTTL
+			}
+			s.local.Set(cacheKey, e, s.localTTLFor(ttl))
+		}
+	}
+
+	if e.schema != SchemaVersion() {
+		l.Debugf("ignoring query result cached under schema %v", e.schema)
+		metrics.ProxyQueryCacheStaleCount.WithLabelValues(method).Inc()
+		return nil
+	}
+	l.Debug("query result found in cache")
+	return e.value
+}
+
+// Count returns the number of responses held in the local layer. Entries in Redis aren't counted
+// as that would take scanning the keyspace.
+func (s redisCache) Count() int {
+	if s.local == nil {
+		return 0
+	}
+	return s.local.ItemCount()
+}
+
+// Invalidate drops the response to method called with params and returns true if it was cached.
+func (s redisCache) Invalidate(method string, params interface{}) bool {
+	cacheKey, err := s.getKey(method, params)
+	if err != nil {
+		return false
+	}
+	return s.delete(cacheKey) > 0
+}
+
+// InvalidateMatching drops responses to method, or to any method if it's empty, called with params
+// whose formatted value contains the substring, and returns how many were dropped.
+// Every matching entry is fetched from Redis, so it's meant for occasional admin use.
+func (s redisCache) InvalidateMatching(method, substring string) int {
+	l := cacheLogger.WithFields(logrus.Fields{"method": method})
+	keys, err := s.store.Scan(escapeGlob(s.namespace+method) + "*")
+	if err != nil {
+		s.reportError(l, redisOpScan, err)
+		return 0
+	}
+	n := 0
+	for _, key := range keys {
+		if method != "" && !strings.HasPrefix(key, s.namespace+method+"|") {
+			continue
+		}
+		data, err := s.store.Get(key)
+		if err != nil || data == nil {
+			continue
+		}
+		e, err := s.decode(data)
+		if err != nil || !strings.Contains(e.params, substring) {
+			continue
+		}
+		n += s.delete(key)
+	}
+	return n
+}
+
+// getKey returns the Redis key of the response. Keys end with the schema version, so instances on different
+// schemas while SDK versions are rolled out keep separate entries instead of replacing each other's.
+func (s redisCache) getKey(method string, params interface{}) (string, error) {
+	key, err := memoryCache{}.getKey(method, params)
+	return s.namespace + key + "|" + SchemaVersion(), err
+}
+
+func (s redisCache) flush() {
+	keys, err := s.store.Scan(escapeGlob(s.namespace) + "*")
+	if err != nil {
+		s.reportError(cacheLogger.Log(), redisOpScan, err)
+	}
+	for i := 0; i < len(keys); i += 1000 {
+		end := i + 1000
+		if end > len(keys) {
+			end = len(keys)
+		}
+		if _, err := s.store.Del(keys[i:end]...); err != nil {
+			s.reportError(cacheLogger.Log(), redisOpDel, err)
+		}
+	}
+	if s.local != nil {
+		s.local.Flush()
+	}
+}
+
+// delete drops key from Redis and the local layer and returns 1 if it was in Redis.
+func (s redisCache) delete(key string) int {
+	if s.local != nil {
+		s.local.Delete(key)
+	}
+	n, err := s.store.Del(key)
+	if err != nil {
+		s.reportError(cacheLogger.Log(), redisOpDel, err)
+	}
+	return n
+}
+
+// localTTLFor returns how long an entry cached in Redis for ttl is kept in the local layer.
+func (s redisCache) localTTLFor(ttl time.Duration) time.Duration {
+	if ttl < s.localTTL {
+		return ttl
+	}
+	return s.localTTL
+}
+
+// encode stores schema and params in front of the serialized value, each prefixed with its length.
+func (s redisCache) encode(e entry) ([]byte, error) {
+	value, err := s.serializer.Marshal(e.value)
+	if err != nil {
+		return nil, err
+	}
+	b := make([]byte, 0, 2*binary.MaxVarintLen64+len(e.schema)+len(e.params)+len(value))
+	size := make([]byte, binary.MaxVarintLen64)
+	for _, f := range []string{e.schema, e.params} {
+		b = append(b, size[:binary.PutUvarint(size, uint64(len(f)))]...)
+		b = append(b, f...)
+	}
+	return append(b, value...), nil
+}
+
+func (s redisCache) decode(data []byte) (entry, error) {
+	fields := make([]string, 2)
+	for i := range fields {
+		n, read := binary.Uvarint(data)
+		if read <= 0 || uint64(len(data)-read) < n {
+			return entry{}, errors.Err("malformed cache entry")
+		}
+		fields[i] = string(data[read : read+int(n)])
+		data = data[read+int(n):]
+	}
+	value, err := s.serializer.Unmarshal(data)
+	if err != nil {
+		return entry{}, err
+	}
+	return entry{schema: fields[0], params: fields[1], value: value}, nil
+}
+
+func (s redisCache) reportError(l logrus.FieldLogger, op string, err error) {
+	metrics.ProxyQueryCacheRedisErrorCount.WithLabelValues(op).Inc()
+	l.Errorf("query cache redis %v failed: %v", op, err)
+}
+
+// escapeGlob escapes characters special in Redis key patterns.
+func escapeGlob(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return r.Replace(s)
+}
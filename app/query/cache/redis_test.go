@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapStore is a redisStore keeping values in a map and counting reads, expiry isn't simulated.
+type mapStore struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	gets   int
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{values: map[string][]byte{}}
+}
+
+func (s *mapStore) Get(key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	return s.values[key], nil
+}
+
+func (s *mapStore) Set(key string, value []byte, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+func (s *mapStore) Del(keys ...string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, k := range keys {
+		if _, ok := s.values[k]; ok {
+			delete(s.values, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *mapStore) Scan(match string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := strings.ReplaceAll(strings.TrimSuffix(match, "*"), `\`, "")
+	keys := []string{}
+	for k := range s.values {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func TestRedisCache(t *testing.T) {
+	for _, name := range []string{SerializationJSON, SerializationGob} {
+		t.Run(name, func(t *testing.T) {
+			serializer, err := NewSerializer(name)
+			require.NoError(t, err)
+			store := newMapStore()
+			c := newRedisCache(store, "test:", serializer, 0)
+
+			params := map[string]interface{}{"urls": []interface{}{"lbry://one"}}
+			result := json.RawMessage(`{"lbry://one": {"name": "one"}}`)
+			assert.Nil(t, c.Retrieve("resolve", params))
+			c.Save("resolve", params, result)
+			assert.Equal(t, result, c.Retrieve("resolve", params))
+
+			for k := range store.values {
+				assert.True(t, strings.HasPrefix(k, "test:resolve|"))
+			}
+
+			// Another instance sharing the store sees the entry
+			other := newRedisCache(store, "test:", serializer, 0)
+			assert.Equal(t, result, other.Retrieve("resolve", params))
+			// But not one using a different namespace
+			assert.Nil(t, newRedisCache(store, "other:", serializer, 0).Retrieve("resolve", params))
+
+			assert.True(t, other.Invalidate("resolve", params))
+			assert.Nil(t, c.Retrieve("resolve", params))
+		})
+	}
+}
+
+func TestRedisCache_GobKeepsTypes(t *testing.T) {
+	c := newRedisCache(newMapStore(), "test:", GobSerializer{}, 0)
+	c.Save("cache", "ts", int64(123))
+	assert.Equal(t, int64(123), c.Retrieve("cache", "ts"))
+}
+
+func TestRedisCache_LocalLayer(t *testing.T) {
+	store := newMapStore()
+	c := newRedisCache(store, "test:", GobSerializer{}, time.Minute)
+	c.Save("claim_search", map[string]interface{}{"page": 1.0}, "1")
+
+	assert.Equal(t, "1", c.Retrieve("claim_search", map[string]interface{}{"page": 1.0}))
+	assert.Equal(t, "1", c.Retrieve("claim_search", map[string]interface{}{"page": 1.0}))
+	assert.Equal(t, 0, store.gets)
+
+	other := newRedisCache(store, "test:", GobSerializer{}, time.Minute)
+	assert.Equal(t, "1", other.Retrieve("claim_search", map[string]interface{}{"page": 1.0}))
+	assert.Equal(t, "1", other.Retrieve("claim_search", map[string]interface{}{"page": 1.0}))
+	assert.Equal(t, 1, store.gets)
+}
+
+func TestRedisCache_InvalidateMatching(t *testing.T) {
+	c := newRedisCache(newMapStore(), "test:", JSONSerializer{}, time.Minute)
+	c.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://one"}}, json.RawMessage(`1`))
+	c.Save("resolve", map[string]interface{}{"urls": []interface{}{"lbry://two"}}, json.RawMessage(`2`))
+	c.Save("claim_search", map[string]interface{}{"text": "lbry://one"}, json.RawMessage(`3`))
+
+	assert.Equal(t, 1, c.InvalidateMatching("resolve", "lbry://one"))
+	assert.Nil(t, c.Retrieve("resolve", map[string]interface{}{"urls": []interface{}{"lbry://one"}}))
+	assert.Equal(t, 1, c.InvalidateMatching("", "lbry://one"))
+	assert.Equal(t, 1, c.Count())
+
+	c.flush()
+	assert.Equal(t, 0, c.Count())
+}
+
+func TestRedisCache_StaleSchema(t *testing.T) {
+	store := newMapStore()
+	c := newRedisCache(store, "test:", GobSerializer{}, 0)
+	c.Save("resolve", "params", "1")
+	SetSDKVersions([]string{"0.99.0"})
+	defer SetSDKVersions(nil)
+	assert.Nil(t, c.Retrieve("resolve", "params"))
+	c.Save("resolve", "params", "2")
+	assert.Equal(t, "2", c.Retrieve("resolve", "params"))
+
+	// Instances still on the old schema keep their entry
+	assert.Len(t, store.values, 2)
+	SetSDKVersions(nil)
+	assert.Equal(t, "1", c.Retrieve("resolve", "params"))
+}
+
+func TestNewSerializer(t *testing.T) {
+	_, err := NewSerializer("xml")
+	assert.Error(t, err)
+}
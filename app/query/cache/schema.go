@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lbryio/lbrytv/version"
+)
+
+var (
+	schemaMu    sync.RWMutex
+	sdkVersions string
+)
+
+// entry is a cached value along with the schema version it was saved under.
+type entry struct {
+	schema string
+	value  interface{}
+	// params are formatted params of the call, so entries can be invalidated by their content
+	params string
+}
+
+// SetSDKVersions records versions of SDK servers responses come from. Entries cached before a change
+// are treated as misses from then on, so responses shaped by an older SDK are not served after an upgrade.
+func SetSDKVersions(versions []string) {
+	unique := map[string]bool{}
+	for _, v := range versions {
+		if v != "" {
+			unique[v] = true
+		}
+	}
+	sorted := make([]string, 0, len(unique))
+	for v := range unique {
+		sorted = append(sorted, v)
+	}
+	sort.Strings(sorted)
+	joined := strings.Join(sorted, ",")
+
+	schemaMu.Lock()
+	defer schemaMu.Unlock()
+	if joined != sdkVersions {
+		if sdkVersions != "" {
+			cacheLogger.Log().Infof("SDK versions changed from %v to %v, invalidating cached responses", sdkVersions, joined)
+		}
+		sdkVersions = joined
+	}
+}
+
+// SchemaVersion returns the version of cached response shapes, derived from lbrytv build and SDK versions.
+func SchemaVersion() string {
+	schemaMu.RLock()
+	defer schemaMu.RUnlock()
+	return version.GetDevVersion() + "/" + sdkVersions
+}
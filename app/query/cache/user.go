@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// Some clients call wallet methods like channel_list over and over, and every call makes the SDK read the wallet.
+// UserCache keeps responses to methods listed in UserQueryCacheTTLs separately for each user, and drops all
+// of them when the user calls a method changing the wallet. Entries aren't shared between instances,
+// so a write going through another instance is only seen here once the entry expires, TTLs should be short.
+
+var userCache = NewUserCache()
+
+// UserCache caches responses to authenticated calls, keyed by user.
+type UserCache struct {
+	// lastGen is accessed atomically so it's kept first for alignment
+	lastGen int64
+	c       *cache.Cache
+	// generations of users are changed to invalidate all their entries at once, old entries are left to expire.
+	// A generation is kept for longer than any entry, so entries of an earlier one can't come back when it's dropped.
+	generations *cache.Cache
+}
+
+// NewUserCache returns an empty per-user cache.
+func NewUserCache() *UserCache {
+	return &UserCache{c: cache.New(time.Minute, 5*time.Minute), generations: cache.New(time.Hour, 10*time.Minute)}
+}
+
+// User returns the per-user cache used for API requests.
+func User() *UserCache {
+	return userCache
+}
+
+// UserTTL returns how long responses to method are cached for each user, zero if they're not.
+func UserTTL(method string) time.Duration {
+	return config.GetUserQueryCacheTTLs()[method]
+}
+
+// Save puts the response to method called by the user with params into cache.
+func (u *UserCache) Save(userID int, method string, params interface{}, r interface{}) {
+	ttl := UserTTL(method)
+	if ttl <= 0 {
+		return
+	}
+	key, err := u.getKey(userID, method, params)
+	if err != nil {
+		cacheLogger.Log().Errorf("unable to produce key for params: %v", params)
+		return
+	}
+	u.c.Set(key, entry{schema: SchemaVersion(), value: r}, ttl)
+}
+
+// Retrieve returns the response to method called by the user with params, or nil if it's not cached.
+func (u *UserCache) Retrieve(userID int, method string, params interface{}) interface{} {
+	key, err := u.getKey(userID, method, params)
+	if err != nil {
+		return nil
+	}
+	cached, ok := u.c.Get(key)
+	if !ok {
+		return nil
+	}
+	e := cached.(entry)
+	if e.schema != SchemaVersion() {
+		u.c.Delete(key)
+		return nil
+	}
+	return e.value
+}
+
+// InvalidateUser drops all responses cached for the user.
+func (u *UserCache) InvalidateUser(userID int) {
+	keep := time.Minute
+	for _, ttl := range config.GetUserQueryCacheTTLs() {
+		if ttl > keep {
+			keep = ttl
+		}
+	}
+	u.generations.Set(fmt.Sprintf("%v", userID), atomic.AddInt64(&u.lastGen, 1), 2*keep)
+}
+
+// Count returns the number of responses cached for all users, including invalidated ones not expired yet.
+func (u *UserCache) Count() int {
+	return u.c.ItemCount()
+}
+
+func (u *UserCache) getKey(userID int, method string, params interface{}) (string, error) {
+	key, err := memoryCache{}.getKey(method, params)
+	if err != nil {
+		return "", err
+	}
+	var gen int64
+	if g, ok := u.generations.Get(fmt.Sprintf("%v", userID)); ok {
+		gen = g.(int64)
+	}
+	return fmt.Sprintf("%v.%v|%v", userID, gen, key), nil
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserCache(t *testing.T) {
+	config.Override("UserQueryCacheTTLs", map[string]interface{}{"channel_list": time.Minute})
+	defer config.RestoreOverridden()
+
+	u := NewUserCache()
+	params := map[string]interface{}{"page": 1}
+	u.Save(1, "channel_list", params, "one")
+	u.Save(2, "channel_list", params, "two")
+	u.Save(1, "address_list", params, "not cached")
+
+	assert.Equal(t, "one", u.Retrieve(1, "channel_list", params))
+	assert.Equal(t, "two", u.Retrieve(2, "channel_list", params))
+	assert.Nil(t, u.Retrieve(1, "address_list", params))
+	assert.Equal(t, 2, u.Count())
+
+	u.InvalidateUser(1)
+	assert.Nil(t, u.Retrieve(1, "channel_list", params))
+	assert.Equal(t, "two", u.Retrieve(2, "channel_list", params))
+
+	u.Save(1, "channel_list", params, "one again")
+	assert.Equal(t, "one again", u.Retrieve(1, "channel_list", params))
+}
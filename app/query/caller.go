@@ -1,8 +1,10 @@
 package query
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
@@ -17,6 +19,8 @@ import (
 	"github.com/lbryio/lbrytv/internal/lbrynet"
 	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/sdksign"
+	"github.com/lbryio/lbrytv/internal/sdktls"
 
 	"github.com/sirupsen/logrus"
 	"github.com/ybbus/jsonrpc"
@@ -25,12 +29,14 @@ import (
 const (
 	walletLoadRetries   = 3
 	walletLoadRetryWait = 100 * time.Millisecond
-	builtinHookName     = "builtin"
 
 	// AllMethodsHook is used as the first argument to Add*Hook to make it apply to all methods
 	AllMethodsHook = ""
 )
 
+// ErrLatencyBudgetExceeded is returned when a query doesn't complete before Caller.Deadline.
+var ErrLatencyBudgetExceeded = errors.Base("latency budget exceeded")
+
 // Hook is a function that can be applied to certain methods during preflight or postflight phase
 // using context data about the client query being performed.
 // Hooks can modify both query and response, as well as perform additional queries via supplied Caller.
@@ -40,6 +46,32 @@ type hookEntry struct {
 	method   string
 	function Hook
 	name     string
+	priority int
+}
+
+// HookStage tells when a hook is applied.
+type HookStage int
+
+const (
+	// StagePreflight hooks are applied before the query is sent to the SDK and may return an early response.
+	StagePreflight HookStage = iota
+	// StagePostflight hooks are applied to the SDK response before it's returned.
+	StagePostflight
+)
+
+// Hook priorities order hooks within a stage, lower ones are applied first.
+// Hooks of the same priority are applied in the order they were registered.
+const (
+	HookPriorityEarly   = -100
+	HookPriorityDefault = 0
+	HookPriorityLate    = 100
+)
+
+// HookOptions tell which queries a registered hook is applied to and in what order.
+type HookOptions struct {
+	// Method is the method, or prefix of methods, the hook applies to. AllMethodsHook applies it to all of them.
+	Method   string
+	Priority int
 }
 
 // HookContext contains data about the query being performed.
@@ -67,73 +99,153 @@ type Caller struct {
 
 	// Cache stores cachable queries to improve performance
 	Cache cache.QueryCache
+	// SkipCache, when set, makes cachable queries it returns true for bypass Cache, both ways.
+	SkipCache func(q *Query) bool
+	// UserCache, when set, stores responses to authenticated queries listed in UserQueryCacheTTLs for the user,
+	// which are dropped when the user calls a method changing the wallet.
+	UserCache *cache.UserCache
 
 	Duration float64
 
-	client   jsonrpc.RPCClient
-	userID   int
-	endpoint string
+	// Deadline, when set, is the time by which the query has to complete.
+	// SDK requests still pending at the deadline are cancelled.
+	Deadline time.Time
+	// Context, when set, is the context of the request the query is made for. SDK requests are cancelled
+	// and retries stop once it's done, and its deadline is in effect when it's earlier than Deadline.
+	Context context.Context
+	// Retry tells how SDK calls failing in transport are retried, it's set from config by NewCaller.
+	Retry RetryPolicy
+	// Breaker fails calls fast while the SDK server isn't responding, it's shared by callers of the same endpoint.
+	Breaker *Breaker
+	// ResolveServers, when set, returns addresses of SDK servers anonymous resolves of many URLs are sharded across.
+	ResolveServers func() []string
+
+	client jsonrpc.RPCClient
+	// callCtx is the context of the SDK request being sent, see contextTransport
+	callCtx context.Context
+	// responseTooLarge is set when the SDK response being read goes over config.GetSDKResponseMaxSize
+	responseTooLarge bool
+	userID           int
+	endpoint         string
 }
 
 func NewCaller(endpoint string, userID int) *Caller {
 	c := &Caller{
-		client: jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{
-			HTTPClient: &http.Client{
-				Timeout: sdkrouter.RPCTimeout,
-				Transport: &http.Transport{
-					Dial: (&net.Dialer{
-						Timeout:   120 * time.Second,
-						KeepAlive: 120 * time.Second,
-					}).Dial,
-					TLSHandshakeTimeout:   30 * time.Second,
-					ResponseHeaderTimeout: 600 * time.Second,
-					ExpectContinueTimeout: 1 * time.Second,
-				},
-			},
-		}),
 		endpoint: endpoint,
 		userID:   userID,
+		Retry:    DefaultRetryPolicy(),
+		Breaker:  GetBreaker(endpoint),
+	}
+	// Calls are bounded by the context set up for each of them in callSDK rather than by a client timeout
+	httpClient := &http.Client{
+		Transport: contextTransport{caller: c, base: sdksign.NewTransport(&http.Transport{
+			Dial: (&net.Dialer{
+				Timeout:   120 * time.Second,
+				KeepAlive: 120 * time.Second,
+			}).Dial,
+			TLSHandshakeTimeout:   30 * time.Second,
+			ResponseHeaderTimeout: 600 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+			TLSClientConfig:       sdktls.ClientConfig(),
+		}, sdksign.DefaultKeyring)},
 	}
+	c.client = jsonrpc.NewClientWithOpts(endpoint, &jsonrpc.RPCClientOpts{HTTPClient: httpClient})
 	c.addDefaultHooks()
 	return c
 }
 
+// RegisterHook adds hook fn applied at stage to queries selected by opts. A named hook replaces the one
+// registered under the same name for the same method, so modules can install their hooks more than once.
+// Unnamed hooks are always added.
+func (c *Caller) RegisterHook(name string, stage HookStage, fn Hook, opts HookOptions) {
+	hooks := c.hooks(stage)
+	if name != "" {
+		for i, h := range *hooks {
+			if h.name == name && h.method == opts.Method {
+				*hooks = append((*hooks)[:i], (*hooks)[i+1:]...)
+				break
+			}
+		}
+	}
+	// Keep hooks sorted by priority, after those registered earlier with the same one
+	i := len(*hooks)
+	for i > 0 && (*hooks)[i-1].priority > opts.Priority {
+		i--
+	}
+	*hooks = append(*hooks, hookEntry{})
+	copy((*hooks)[i+1:], (*hooks)[i:])
+	(*hooks)[i] = hookEntry{method: opts.Method, function: fn, name: name, priority: opts.Priority}
+	logger.Log().Debugf("registered %v hook %q for method %v", stage, name, opts.Method)
+}
+
+// RemoveHook removes all hooks registered under name at stage and reports if there were any.
+func (c *Caller) RemoveHook(name string, stage HookStage) bool {
+	hooks := c.hooks(stage)
+	kept := (*hooks)[:0]
+	for _, h := range *hooks {
+		if h.name != name {
+			kept = append(kept, h)
+		}
+	}
+	removed := len(kept) < len(*hooks)
+	*hooks = kept
+	return removed
+}
+
 // AddPreflightHook adds query preflight hook function,
 // allowing to amend the query before it gets sent to the JSON-RPC server,
 // with an option to return an early response, avoiding sending the query
 // to JSON-RPC server altogether.
 func (c *Caller) AddPreflightHook(method string, hf Hook, name string) {
-	c.preflightHooks = append(c.preflightHooks, hookEntry{method, hf, name})
-	logger.Log().Debugf("added a preflight hook for method %v", method)
+	c.RegisterHook(name, StagePreflight, hf, HookOptions{Method: method})
 }
 
 // AddPostflightHook adds query postflight hook function,
 // allowing to amend the response before it gets sent back to the client
 // or to modify log entry fields.
 func (c *Caller) AddPostflightHook(method string, hf Hook, name string) {
-	c.postflightHooks = append(c.postflightHooks, hookEntry{method, hf, name})
-	logger.Log().Debugf("added a postflight hook for method %v", method)
+	c.RegisterHook(name, StagePostflight, hf, HookOptions{Method: method})
+}
+
+func (c *Caller) hooks(stage HookStage) *[]hookEntry {
+	if stage == StagePostflight {
+		return &c.postflightHooks
+	}
+	return &c.preflightHooks
+}
+
+func (s HookStage) String() string {
+	if s == StagePostflight {
+		return "postflight"
+	}
+	return "preflight"
 }
 
 func (c *Caller) addDefaultHooks() {
-	c.AddPreflightHook("", fromCache, builtinHookName)
-	c.AddPreflightHook("status", getStatusResponse, builtinHookName)
-	c.AddPreflightHook("get", preflightHookGet, builtinHookName)
+	c.RegisterHook("limit_params", StagePreflight, limitParams, HookOptions{Method: AllMethodsHook})
+	c.RegisterHook("from_cache", StagePreflight, fromCache, HookOptions{Method: AllMethodsHook})
+	c.RegisterHook("from_user_cache", StagePreflight, fromUserCache, HookOptions{Method: AllMethodsHook})
+	c.RegisterHook("status_response", StagePreflight, getStatusResponse, HookOptions{Method: "status"})
+	c.RegisterHook("get_stream", StagePreflight, preflightHookGet, HookOptions{Method: "get"})
+	c.RegisterHook("limit_response_size", StagePostflight, limitResponseSize, HookOptions{Method: AllMethodsHook})
+	c.RegisterHook("invalidate_cache", StagePostflight, invalidateCache, HookOptions{Method: AllMethodsHook})
 }
 
 func (c *Caller) CloneWithoutHook(endpoint, method, name string) *Caller {
 	cc := NewCaller(endpoint, c.userID)
+	cc.postflightHooks, cc.preflightHooks = nil, nil
+	cc.Retry = c.Retry
 	for _, h := range c.postflightHooks {
 		if h.method == method && h.name == name {
 			continue
 		}
-		cc.AddPostflightHook(h.method, h.function, h.name)
+		cc.postflightHooks = append(cc.postflightHooks, h)
 	}
 	for _, h := range c.preflightHooks {
 		if h.method == method && h.name == name {
 			continue
 		}
-		cc.AddPreflightHook(h.method, h.function, h.name)
+		cc.preflightHooks = append(cc.preflightHooks, h)
 	}
 	return cc
 }
@@ -142,6 +254,11 @@ func (c *Caller) Endpoint() string {
 	return c.endpoint
 }
 
+// UserID returns ID of the user the caller makes calls for.
+func (c *Caller) UserID() int {
+	return c.userID
+}
+
 // Call method forwards a JSON-RPC request to the lbrynet server.
 // It returns a response that is ready to be sent back to the JSON-RPC client as is.
 func (c *Caller) Call(req *jsonrpc.RPCRequest) (*jsonrpc.RPCResponse, error) {
@@ -158,6 +275,9 @@ func (c *Caller) Call(req *jsonrpc.RPCRequest) (*jsonrpc.RPCResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	if c.Preprocessor != nil {
+		c.Preprocessor(q)
+	}
 
 	// Applying preflight hooks
 	var res *jsonrpc.RPCResponse
@@ -165,7 +285,7 @@ func (c *Caller) Call(req *jsonrpc.RPCRequest) (*jsonrpc.RPCResponse, error) {
 		if isMatchingHook(q.Method(), hook) {
 			res, err = hook.function(c, &HookContext{Query: q})
 			if err != nil {
-				return nil, rpcerrors.NewSDKError(err)
+				return nil, hookError(err)
 			}
 			if res != nil {
 				return res, nil
@@ -173,15 +293,28 @@ func (c *Caller) Call(req *jsonrpc.RPCRequest) (*jsonrpc.RPCResponse, error) {
 		}
 	}
 
+	cacheable := false
 	if res == nil {
-		res, err = c.SendQuery(q)
-		if err != nil {
+		res, cacheable, err = c.sendCoalesced(q)
+		// Calls which failed may still have changed the wallet
+		if c.UserCache != nil && c.userID != 0 && !isReadOnly(q.Method()) {
+			c.UserCache.InvalidateUser(c.userID)
+			metrics.ProxyUserQueryCacheCount.WithLabelValues(q.Method(), metrics.UserCacheInvalidated).Inc()
+		}
+		if errors.Is(err, ErrLatencyBudgetExceeded) || errors.Is(err, ErrSDKUnavailable) {
+			return nil, err
+		} else if err != nil {
 			return nil, rpcerrors.NewSDKError(err)
 		}
 	}
 
-	if isCacheable(q) {
-		c.Cache.Save(q.Method(), q.Params(), res)
+	if c.usesCache(q) && res.Error == nil && cacheable {
+		c.saveToCache(q, res)
+	}
+	if usesUserCache(c, q) && res.Error == nil {
+		if serialized, ok := serializeResult(q, res); ok {
+			c.UserCache.Save(c.userID, q.Method(), q.Params(), serialized)
+		}
 	}
 
 	return res, nil
@@ -196,20 +329,9 @@ func (c *Caller) SendQuery(q *Query) (*jsonrpc.RPCResponse, error) {
 	defer op.End()
 
 	for i := 0; i < walletLoadRetries; i++ {
-		start := time.Now()
-
-		r, err = c.client.CallRaw(q.Request)
-
-		c.Duration = time.Since(start).Seconds()
-		metrics.ProxyCallDurations.WithLabelValues(q.Method(), c.endpoint).Observe(c.Duration)
-		metrics.ProxyCallCounter.WithLabelValues(q.Method(), c.endpoint).Inc()
-
-		// Generally a HTTP transport failure (connect error etc)
+		r, err = c.callSDK(q, i > 0)
 		if err != nil {
-			logger.Log().Errorf("error sending query to %v: %v", c.endpoint, err)
-			metrics.ProxyCallFailedDurations.WithLabelValues(q.Method(), c.endpoint, metrics.FailureKindNet).Observe(c.Duration)
-			metrics.ProxyCallFailedCounter.WithLabelValues(q.Method(), c.endpoint, metrics.FailureKindNet).Inc()
-			return nil, errors.Err(err)
+			return nil, err
 		}
 
 		// This checks if LbrynetServer responded with missing wallet error and tries to reload it,
@@ -254,7 +376,7 @@ func (c *Caller) SendQuery(q *Query) (*jsonrpc.RPCResponse, error) {
 		if isMatchingHook(q.Method(), hook) {
 			hookResp, err = hook.function(c, hctx)
 			if err != nil {
-				return nil, rpcerrors.NewSDKError(err)
+				return nil, hookError(err)
 			}
 			if hookResp != nil {
 				r = hookResp
@@ -277,19 +399,203 @@ func (c *Caller) SendQuery(q *Query) (*jsonrpc.RPCResponse, error) {
 	return r, err
 }
 
-// isCacheable returns true if this query can be cached
-func isCacheable(q *Query) bool {
-	if q.Method() == MethodResolve && q.Params() != nil {
-		paramsMap := q.Params().(map[string]interface{})
-		if urls, ok := paramsMap[ParamUrls].([]interface{}); ok {
-			if len(urls) > cacheResolveLongerThan {
-				return true
+// callSDK sends the query to the SDK, retrying transport failures as Retry allows.
+// sent tells whether the query has already reached the SDK before.
+func (c *Caller) callSDK(q *Query, sent bool) (*jsonrpc.RPCResponse, error) {
+	deadline := c.deadline()
+	for attempt := 1; ; attempt++ {
+		timeout := callTimeout(q.Method())
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				// Time spent on earlier attempts and wallet loading was the SDK's, before that it's ours.
+				cause := metrics.BudgetCauseProxy
+				if sent || attempt > 1 {
+					cause = metrics.BudgetCauseUpstream
+				}
+				return nil, budgetExceeded(q.Method(), cause)
+			}
+			if remaining < timeout {
+				timeout = remaining
 			}
 		}
-	} else if q.Method() == MethodClaimSearch {
-		return true
+		if !c.Breaker.Allow() {
+			return nil, rpcerrors.NewServiceUnavailableError(errors.Prefix(c.endpoint, ErrSDKUnavailable))
+		}
+
+		parent := c.Context
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		c.callCtx = ctx
+		c.responseTooLarge = false
+		start := time.Now()
+
+		r, err := c.client.CallRaw(q.Request)
+
+		cancel()
+		c.callCtx = nil
+		c.Duration = time.Since(start).Seconds()
+		metrics.ProxyCallDurations.WithLabelValues(q.Method(), c.endpoint).Observe(c.Duration)
+		metrics.ProxyCallCounter.WithLabelValues(q.Method(), c.endpoint).Inc()
+
+		// Calls cancelled because the client went away say nothing about the SDK server
+		if err != nil && c.Context != nil && c.Context.Err() == context.Canceled {
+			c.Breaker.Release()
+			metrics.ProxyCallsCancelled.WithLabelValues(q.Method()).Inc()
+			logger.Log().Debugf("%v call to %v cancelled after %.3fs: %v", q.Method(), c.endpoint, c.Duration, c.Context.Err())
+			return nil, errors.Prefix("request is done", c.Context.Err())
+		}
+
+		// Oversized responses are the query's fault rather than the SDK server's, and would be just as large on retry
+		if err != nil && c.responseTooLarge {
+			c.Breaker.Release()
+			metrics.ProxyResponseSizeLimitCount.WithLabelValues(q.Method(), sizeLimitActionRejected).Inc()
+			logger.Log().Warnf("%v response from %v exceeded %v bytes", q.Method(), c.endpoint, config.GetSDKResponseMaxSize())
+			return nil, rpcerrors.NewResponseTooLargeError(fmt.Errorf(
+				"response for %v is too large (limit is %v bytes), please narrow your query", q.Method(), config.GetSDKResponseMaxSize()))
+		}
+
+		// The request is cancelled once the deadline passes, which cancels it upstream
+		c.Breaker.Record(err != nil)
+		if err != nil && !deadline.IsZero() && !time.Now().Before(deadline) {
+			logger.Log().Warnf("%v call to %v exceeded its latency budget after %.3fs", q.Method(), c.endpoint, c.Duration)
+			return nil, budgetExceeded(q.Method(), metrics.BudgetCauseUpstream)
+		}
+		if err == nil {
+			metrics.ProxyCallAttempts.WithLabelValues(q.Method(), c.endpoint, metrics.AttemptSucceeded).Inc()
+			return r, nil
+		}
+
+		// Generally a HTTP transport failure (connect error etc)
+		logger.Log().Errorf("error sending query to %v: %v", c.endpoint, err)
+		metrics.ProxyCallFailedDurations.WithLabelValues(q.Method(), c.endpoint, metrics.FailureKindNet).Observe(c.Duration)
+		metrics.ProxyCallFailedCounter.WithLabelValues(q.Method(), c.endpoint, metrics.FailureKindNet).Inc()
+
+		backoff := c.Retry.Backoff(attempt)
+		if attempt >= c.Retry.MaxAttempts || !c.Retry.retryable(q.Method(), err) ||
+			(!deadline.IsZero() && time.Now().Add(backoff).After(deadline)) {
+			metrics.ProxyCallAttempts.WithLabelValues(q.Method(), c.endpoint, metrics.AttemptFailed).Inc()
+			return nil, errors.Err(err)
+		}
+		metrics.ProxyCallAttempts.WithLabelValues(q.Method(), c.endpoint, metrics.AttemptRetried).Inc()
+		logger.Log().Warnf("retrying %v call to %v in %v after attempt %v failed", q.Method(), c.endpoint, backoff, attempt)
+
+		var done <-chan struct{}
+		if c.Context != nil {
+			done = c.Context.Done()
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-done:
+			t.Stop()
+			return nil, errors.Prefix("request is done", c.Context.Err())
+		}
+	}
+}
+
+// callTimeout returns how long a single SDK request for method may take, see config.GetSDKCallTimeouts.
+func callTimeout(method string) time.Duration {
+	if t := config.GetSDKCallTimeouts()[method]; t > 0 {
+		return t
+	}
+	return sdkrouter.RPCTimeout
+}
+
+// contextTransport sends SDK requests with the context of the call being made by the caller,
+// as the JSON-RPC client doesn't take one.
+type contextTransport struct {
+	caller *Caller
+	base   http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if ctx := t.caller.callCtx; ctx != nil {
+		r = r.WithContext(ctx)
+	}
+	res, err := t.base.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	// Response bodies are bounded here as the JSON-RPC client decodes them whole
+	max := config.GetSDKResponseMaxSize()
+	if max <= 0 {
+		return res, nil
+	}
+	if res.ContentLength > max {
+		res.Body.Close()
+		t.caller.responseTooLarge = true
+		return nil, errResponseTooLarge
+	}
+	res.Body = &limitedBody{ReadCloser: res.Body, remaining: max, caller: t.caller}
+	return res, nil
+}
+
+var errResponseTooLarge = errors.Base("response body is too large")
+
+// limitedBody fails reads once more than remaining bytes have been read, flagging the caller.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	caller    *Caller
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, errResponseTooLarge
+	}
+	// One byte over the limit is read to tell bodies of exactly the limit from longer ones
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		b.caller.responseTooLarge = true
+		return 0, errResponseTooLarge
+	}
+	return n, err
+}
+
+// deadline returns the earlier of Deadline and the deadline of Context, or zero time if neither is set.
+func (c *Caller) deadline() time.Time {
+	d := c.Deadline
+	if c.Context != nil {
+		if cd, ok := c.Context.Deadline(); ok && (d.IsZero() || cd.Before(d)) {
+			d = cd
+		}
+	}
+	return d
+}
+
+func budgetExceeded(method, cause string) error {
+	metrics.LatencyBudgetExceeded.WithLabelValues(method, cause).Inc()
+	return rpcerrors.NewTimeoutError(ErrLatencyBudgetExceeded)
+}
+
+// isCacheable returns true if this query can be cached, see config.GetQueryCacheTTLs
+func isCacheable(q *Query) bool {
+	if cache.TTL(q.Method()) <= 0 {
+		return false
+	}
+	if q.Method() == MethodResolve {
+		paramsMap, _ := q.Params().(map[string]interface{})
+		urls, _ := paramsMap[ParamUrls].([]interface{})
+		return len(urls) > cacheResolveLongerThan
 	}
-	return false
+	return true
+}
+
+// usesUserCache returns true if q is cached for the user by c.
+func usesUserCache(c *Caller, q *Query) bool {
+	return c.UserCache != nil && c.userID != 0 && q.IsAuthenticated() && isReadOnly(q.Method()) && cache.UserTTL(q.Method()) > 0
+}
+
+// usesCache returns true if q is cached by c.
+func (c *Caller) usesCache(q *Query) bool {
+	return c.Cache != nil && isCacheable(q) && (c.SkipCache == nil || !c.SkipCache(q))
 }
 
 func getLogLevel(m string) logrus.Level {
@@ -299,13 +605,43 @@ func getLogLevel(m string) logrus.Level {
 	return logrus.InfoLevel
 }
 
+// hookError passes typed RPC errors returned by hooks through as is
+// and treats everything else as an SDK error.
+func hookError(err error) error {
+	var rpcErr rpcerrors.RPCError
+	if errors.As(err, &rpcErr) {
+		return err
+	}
+	return rpcerrors.NewSDKError(err)
+}
+
 func isMatchingHook(m string, hook hookEntry) bool {
 	return hook.method == "" || hook.method == m || strings.HasPrefix(m, hook.method)
 }
 
-// fromCache returns cached response or nil in case it's a miss
+// saveToCache stores serialized response result so cache hits can be written to clients without re-marshaling.
+// The result is indented to be embedded into a response serialized by responses.JSONRPCSerializeTo.
+func (c *Caller) saveToCache(q *Query, res *jsonrpc.RPCResponse) {
+	if serialized, ok := serializeResult(q, res); ok {
+		c.Cache.Save(q.Method(), q.Params(), serialized)
+	}
+}
+
+// serializeResult returns the response result serialized for caching.
+func serializeResult(q *Query, res *jsonrpc.RPCResponse) (json.RawMessage, bool) {
+	serialized, err := json.MarshalIndent(res.Result, "  ", "  ")
+	if err != nil {
+		metrics.ProxyQueryCacheErrorCount.WithLabelValues(q.Method()).Inc()
+		logger.Log().Errorf("error marshalling response for cache: %v", err)
+		return nil, false
+	}
+	return json.RawMessage(serialized), true
+}
+
+// fromCache returns cached response or nil in case it's a miss.
+// Result of the returned response is json.RawMessage containing the serialized result.
 func fromCache(c *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
-	if c.Cache == nil || !isCacheable(hctx.Query) {
+	if !c.usesCache(hctx.Query) {
 		return nil, nil
 	}
 
@@ -315,27 +651,54 @@ func fromCache(c *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
 		return nil, nil
 	}
 
-	s, err := json.Marshal(cached)
-	if err != nil {
+	serialized, ok := cached.(json.RawMessage)
+	if !ok {
 		metrics.ProxyQueryCacheErrorCount.WithLabelValues(hctx.Query.Method()).Inc()
-		logger.Log().Errorf("error marshalling cached response")
+		logger.Log().Errorf("unexpected cached value type %T", cached)
 		return nil, nil
 	}
 
 	response := hctx.Query.newResponse()
-
-	err = json.Unmarshal(s, &response)
-	if err != nil {
-		metrics.ProxyQueryCacheErrorCount.WithLabelValues(hctx.Query.Method()).Inc()
-		logger.Log().Errorf("error unmarshalling cached response")
-		return nil, nil
-	}
+	response.Result = serialized
 
 	metrics.ProxyQueryCacheHitCount.WithLabelValues(hctx.Query.Method()).Inc()
 	logger.WithFields(logrus.Fields{"method": hctx.Query.Method()}).Debug("cached query")
 	return response, nil
 }
 
+// ResultMap returns the result of res as a map. Results of responses served from the cache are json.RawMessage
+// and get decoded.
+func ResultMap(res *jsonrpc.RPCResponse) (map[string]interface{}, error) {
+	switch r := res.Result.(type) {
+	case map[string]interface{}:
+		return r, nil
+	case json.RawMessage:
+		var result map[string]interface{}
+		if err := json.Unmarshal(r, &result); err != nil {
+			return nil, errors.Err(err)
+		}
+		return result, nil
+	default:
+		return nil, errors.Err("unexpected result %T", res.Result)
+	}
+}
+
+// fromUserCache returns the response cached for the user or nil in case it's a miss.
+func fromUserCache(c *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
+	if !usesUserCache(c, hctx.Query) {
+		return nil, nil
+	}
+	cached, ok := c.UserCache.Retrieve(c.userID, hctx.Query.Method(), hctx.Query.Params()).(json.RawMessage)
+	if !ok {
+		metrics.ProxyUserQueryCacheCount.WithLabelValues(hctx.Query.Method(), metrics.UserCacheMiss).Inc()
+		return nil, nil
+	}
+	response := hctx.Query.newResponse()
+	response.Result = cached
+	metrics.ProxyUserQueryCacheCount.WithLabelValues(hctx.Query.Method(), metrics.UserCacheHit).Inc()
+	return response, nil
+}
+
 func isErrWalletNotLoaded(r *jsonrpc.RPCResponse) bool {
 	return r.Error != nil && errors.Is(lbrynet.NewWalletError(0, errors.Err(r.Error.Message)), lbrynet.ErrWalletNotLoaded)
 }
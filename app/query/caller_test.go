@@ -1,17 +1,24 @@
 package query
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lbryio/lbrytv-player/pkg/paid"
+	"github.com/lbryio/lbrytv/app/query/cache"
 	"github.com/lbryio/lbrytv/app/rpcerrors"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/apps/lbrytv/config"
 	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
 	"github.com/lbryio/lbrytv/internal/test"
 
 	ljsonrpc "github.com/lbryio/lbry.go/v2/extras/jsonrpc"
@@ -414,6 +421,128 @@ func TestCaller_ClientJSONError(t *testing.T) {
 	assert.Contains(t, err.Error(), "could not decode body to rpc response")
 }
 
+func TestCaller_DeadlineUpstreamSlow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Deadline = time.Now().Add(100 * time.Millisecond)
+	start := time.Now()
+	_, err := c.Call(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": "what"}))
+	assert.True(t, errors.Is(err, ErrLatencyBudgetExceeded))
+	assert.Less(t, time.Since(start).Seconds(), 1.0)
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32087, rpcErr.Code())
+}
+
+func TestCaller_DeadlineProxySlow(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Deadline = time.Now().Add(-time.Millisecond)
+	_, err := c.Call(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": "what"}))
+	assert.True(t, errors.Is(err, ErrLatencyBudgetExceeded))
+	assert.Equal(t, 0, calls)
+}
+
+func TestCaller_CancelledWithContext(t *testing.T) {
+	reqDone := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(reqDone)
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewCaller(srv.URL, 0)
+	c.Context = ctx
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := c.Call(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": "what"}))
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Less(t, time.Since(start).Seconds(), 1.0)
+	select {
+	case <-reqDone:
+	case <-time.After(time.Second):
+		t.Fatal("SDK request was not cancelled")
+	}
+	assert.False(t, c.Breaker.Open())
+}
+
+func TestCaller_MethodTimeout(t *testing.T) {
+	config.Override("SDKCallTimeouts", map[string]interface{}{MethodResolve: 100 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	assert.Equal(t, 100*time.Millisecond, callTimeout(MethodResolve))
+	assert.Equal(t, sdkrouter.RPCTimeout, callTimeout(MethodClaimSearch))
+
+	c := NewCaller(srv.URL, 0)
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	start := time.Now()
+	_, err := c.Call(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": "what"}))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrLatencyBudgetExceeded))
+	assert.Less(t, time.Since(start).Seconds(), 1.0)
+}
+
+func TestCaller_SDKResponseTooLarge(t *testing.T) {
+	config.Override("SDKResponseMaxSize", 1000)
+	defer config.RestoreOverridden()
+
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body := `{"jsonrpc": "2.0", "id": 0, "result": {"items": ["` + strings.Repeat("a", 2000) + `"]}}`
+		if r.URL.Query().Get("chunked") != "" {
+			// Flushing before writing the rest of the body leaves the response without a Content-Length
+			w.Write([]byte(body[:10]))
+			w.(http.Flusher).Flush()
+			body = body[10:]
+		}
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	for _, endpoint := range []string{srv.URL, srv.URL + "?chunked=1"} {
+		calls = 0
+		c := NewCaller(endpoint, 0)
+		_, err := c.Call(jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{}))
+		var rpcErr rpcerrors.RPCError
+		require.True(t, errors.As(err, &rpcErr), endpoint)
+		assert.Equal(t, -32086, rpcErr.Code())
+		assert.Equal(t, 1, calls)
+		assert.False(t, c.Breaker.Open())
+	}
+
+	config.Override("SDKResponseMaxSize", 0)
+	c := NewCaller(srv.URL, 0)
+	res, err := c.Call(jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.NotNil(t, res.Result)
+}
+
 func TestCaller_CallRaw(t *testing.T) {
 	c := NewCaller(test.RandServerAddress(t), 0)
 	for _, rawQ := range []string{`{}`, `{"method": " "}`} {
@@ -851,3 +980,193 @@ func TestCaller_LogLevels(t *testing.T) {
 	assert.Equal(t, "sync_apply", hook.LastEntry().Data["method"])
 	assert.Equal(t, logrus.DebugLevel, e.Level)
 }
+
+func TestCaller_CacheHitServedPreserialized(t *testing.T) {
+	reqChan := test.ReqChan()
+	srv := test.MockHTTPServer(reqChan)
+	defer srv.Close()
+	srv.NextResponse <- `{"jsonrpc": "2.0", "id": 1, "result": {"items": [{"name": "<what>"}], "page": 1}}`
+
+	c := NewCaller(srv.URL, 0)
+	c.Cache = cache.NewMemoryCache()
+
+	req := jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"channel": "@what"})
+	req.ID = 1
+	res, err := c.Call(req)
+	require.NoError(t, err)
+	<-reqChan
+	_, isRaw := res.Result.(json.RawMessage)
+	assert.False(t, isRaw)
+
+	req.ID = 2
+	cachedRes, err := c.Call(req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(reqChan), "cache hit should not reach the server")
+	_, isRaw = cachedRes.Result.(json.RawMessage)
+	assert.True(t, isRaw)
+	assert.Equal(t, 2, cachedRes.ID)
+
+	res.ID = 2
+	expected, err := responses.JSONRPCSerialize(res)
+	require.NoError(t, err)
+	actual := &bytes.Buffer{}
+	require.NoError(t, responses.JSONRPCSerializeTo(actual, cachedRes))
+	assert.Equal(t, string(expected), actual.String())
+}
+
+func TestCaller_SkipCache(t *testing.T) {
+	reqChan := test.ReqChan()
+	srv := test.MockHTTPServer(reqChan)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Cache = cache.NewMemoryCache()
+	c.SkipCache = func(q *Query) bool { return q.ParamsAsMap()["channel"] == "@skipped" }
+
+	skipped := jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"channel": "@skipped"})
+	cached := jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"channel": "@cached"})
+	for _, req := range []*jsonrpc.RPCRequest{skipped, cached} {
+		srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {"items": [], "page": 1}}`
+		_, err := c.Call(req)
+		require.NoError(t, err)
+		<-reqChan
+	}
+	assert.Nil(t, c.Cache.Retrieve(MethodClaimSearch, skipped.Params))
+	assert.NotNil(t, c.Cache.Retrieve(MethodClaimSearch, cached.Params))
+}
+
+func TestCaller_CacheTTLs(t *testing.T) {
+	config.Override("QueryCacheTTLs", map[string]interface{}{"comment_list": time.Minute})
+	defer config.RestoreOverridden()
+
+	reqChan := test.ReqChan()
+	srv := test.MockHTTPServer(reqChan)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Cache = cache.NewMemoryCache()
+
+	comments := jsonrpc.NewRequest("comment_list", map[string]interface{}{"claim_id": "abc"})
+	search := jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"channel": "@chan"})
+	for _, req := range []*jsonrpc.RPCRequest{comments, search} {
+		srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {"items": [], "page": 1}}`
+		_, err := c.Call(req)
+		require.NoError(t, err)
+		<-reqChan
+	}
+	assert.NotNil(t, c.Cache.Retrieve("comment_list", comments.Params))
+	assert.Nil(t, c.Cache.Retrieve(MethodClaimSearch, search.Params))
+}
+
+func TestCaller_RegisterHook(t *testing.T) {
+	c := NewCaller("http://lbrynet:5279", 0)
+	noop := func(_ *Caller, _ *HookContext) (*jsonrpc.RPCResponse, error) { return nil, nil }
+	names := func() []string {
+		n := []string{}
+		for _, h := range c.preflightHooks {
+			n = append(n, h.name)
+		}
+		return n
+	}
+
+	c.RegisterHook("late", StagePreflight, noop, HookOptions{Priority: HookPriorityLate})
+	c.RegisterHook("early", StagePreflight, noop, HookOptions{Priority: HookPriorityEarly})
+	c.RegisterHook("analytics", StagePreflight, noop, HookOptions{})
+	assert.Equal(t, []string{"early", "limit_params", "from_cache", "status_response", "get_stream", "analytics", "late"}, names())
+
+	c.RegisterHook("early", StagePreflight, noop, HookOptions{Priority: HookPriorityLate})
+	assert.Equal(t, []string{"limit_params", "from_cache", "status_response", "get_stream", "analytics", "late", "early"}, names(),
+		"registering under the same name replaces the hook")
+
+	assert.True(t, c.RemoveHook("analytics", StagePreflight))
+	assert.False(t, c.RemoveHook("analytics", StagePreflight))
+	assert.False(t, c.RemoveHook("late", StagePostflight))
+	assert.Equal(t, []string{"limit_params", "from_cache", "status_response", "get_stream", "late", "early"}, names())
+}
+
+func TestCaller_RegisterHookOrder(t *testing.T) {
+	srv := test.MockHTTPServer(nil)
+	defer srv.Close()
+	srv.NextResponse <- resolveResponseWithoutPurchase
+
+	var order []string
+	record := func(name string) Hook {
+		return func(_ *Caller, _ *HookContext) (*jsonrpc.RPCResponse, error) {
+			order = append(order, name)
+			return nil, nil
+		}
+	}
+	c := NewCaller(srv.URL, 0)
+	c.RegisterHook("second", StagePostflight, record("second"), HookOptions{Method: MethodResolve})
+	c.RegisterHook("first", StagePostflight, record("first"), HookOptions{Method: MethodResolve, Priority: HookPriorityEarly})
+	c.RegisterHook("other", StagePostflight, record("other"), HookOptions{Method: MethodClaimSearch})
+
+	_, err := c.Call(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": "what"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestCaller_UserCache(t *testing.T) {
+	config.Override("UserQueryCacheTTLs", map[string]interface{}{"channel_list": time.Minute})
+	defer config.RestoreOverridden()
+
+	reqChan := test.ReqChan()
+	srv := test.MockHTTPServer(reqChan)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 123)
+	c.UserCache = cache.NewUserCache()
+	other := NewCaller(srv.URL, 124)
+	other.UserCache = c.UserCache
+
+	srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {"items": [], "page": 1}}`
+	_, err := c.Call(jsonrpc.NewRequest("channel_list", map[string]interface{}{}))
+	require.NoError(t, err)
+	<-reqChan
+
+	res, err := c.Call(jsonrpc.NewRequest("channel_list", map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(reqChan), "cache hit should not reach the server")
+	_, isRaw := res.Result.(json.RawMessage)
+	assert.True(t, isRaw)
+
+	// Responses are not shared between users
+	srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {"items": [], "page": 1}}`
+	_, err = other.Call(jsonrpc.NewRequest("channel_list", map[string]interface{}{}))
+	require.NoError(t, err)
+	<-reqChan
+
+	// Writes drop cached responses of the user
+	srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {}}`
+	_, err = c.Call(jsonrpc.NewRequest("channel_create", map[string]interface{}{"name": "@new", "bid": "0.1"}))
+	require.NoError(t, err)
+	<-reqChan
+	assert.Nil(t, c.UserCache.Retrieve(123, "channel_list", map[string]interface{}{"wallet_id": sdkrouter.WalletID(123)}))
+	assert.NotNil(t, c.UserCache.Retrieve(124, "channel_list", map[string]interface{}{"wallet_id": sdkrouter.WalletID(124)}))
+}
+
+func TestCaller_WriteInvalidatesCache(t *testing.T) {
+	config.Override("QueryCacheInvalidations", map[string]interface{}{
+		"support_create": []map[string]interface{}{{"Method": MethodClaimSearch, "Param": "claim_id"}},
+	})
+	defer config.RestoreOverridden()
+
+	reqChan := test.ReqChan()
+	srv := test.MockHTTPServer(reqChan)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 123)
+	c.Cache = cache.NewMemoryCache()
+	stale := map[string]interface{}{"claim_ids": []interface{}{"abcdef"}}
+	fresh := map[string]interface{}{"claim_ids": []interface{}{"012345"}}
+	c.Cache.Save(MethodClaimSearch, stale, json.RawMessage(`{}`))
+	c.Cache.Save(MethodClaimSearch, fresh, json.RawMessage(`{}`))
+
+	srv.NextResponse <- `{"jsonrpc": "2.0", "id": 0, "result": {}}`
+	_, err := c.Call(jsonrpc.NewRequest("support_create", map[string]interface{}{"claim_id": "abcdef", "amount": "1.0"}))
+	require.NoError(t, err)
+	<-reqChan
+
+	assert.Nil(t, c.Cache.Retrieve(MethodClaimSearch, stale))
+	assert.NotNil(t, c.Cache.Retrieve(MethodClaimSearch, fresh))
+}
@@ -0,0 +1,152 @@
+package query
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// When a popular claim gets to the front page, lots of clients resolve it at once, before any response gets cached.
+// Anonymous calls to CoalescedMethods identical to one already sent to the same SDK server wait for its response
+// instead of being sent too. If the call fails because the request it was made for went away or ran out of time,
+// waiting calls are sent on their own.
+
+// flight is a call in progress, which identical calls wait for.
+type flight struct {
+	done      chan struct{}
+	res       *jsonrpc.RPCResponse
+	cacheable bool
+	err       error
+	// abandoned is set when the call failed for reasons specific to the request it was made for
+	abandoned bool
+}
+
+var (
+	flightsMu sync.Mutex
+	flights   = map[string]*flight{}
+)
+
+// coalesces returns true if q may share the response with identical queries.
+func coalesces(q *Query) bool {
+	return !q.IsAuthenticated() && methodInList(q.Method(), config.GetCoalescedMethods())
+}
+
+// send sends q to the SDK, in shards if it's a large resolve. It returns true if the response may be cached.
+func (c *Caller) send(q *Query) (*jsonrpc.RPCResponse, bool, error) {
+	if c.shardsResolve(q) {
+		res, partial, err := c.sendResolveShards(q)
+		return res, !partial, err
+	}
+	res, err := c.SendQuery(q)
+	return res, true, err
+}
+
+// sendCoalesced sends q to the SDK, or waits for the response to an identical query sent by another caller.
+// It returns true if the response was fetched for this call and may be cached.
+func (c *Caller) sendCoalesced(q *Query) (*jsonrpc.RPCResponse, bool, error) {
+	if !coalesces(q) {
+		return c.send(q)
+	}
+	params, err := json.Marshal(q.Params())
+	if err != nil {
+		return c.send(q)
+	}
+	key := c.endpoint + "|" + q.Method() + "|" + string(params)
+
+	flightsMu.Lock()
+	f, inFlight := flights[key]
+	if !inFlight {
+		f = &flight{done: make(chan struct{})}
+		flights[key] = f
+	}
+	flightsMu.Unlock()
+
+	if !inFlight {
+		return c.lead(q, key, f)
+	}
+
+	metrics.ProxyCallsCoalesced.WithLabelValues(q.Method()).Inc()
+	var done <-chan struct{}
+	if c.Context != nil {
+		done = c.Context.Done()
+	}
+	select {
+	case <-f.done:
+	case <-done:
+		return nil, false, errors.Prefix("request is done", c.Context.Err())
+	}
+	if f.abandoned {
+		return c.send(q)
+	}
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	return f.copyFor(q), false, nil
+}
+
+// lead sends q for the flight f registered under key and hands the response over to calls waiting for it.
+// Waiting calls are released even if sending panics, the panic is passed on to the caller.
+func (c *Caller) lead(q *Query, key string, f *flight) (res *jsonrpc.RPCResponse, cacheable bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			f.err = errors.Err("coalesced call failed: %v", p)
+			f.abandoned = true
+			c.land(key, f)
+			panic(p)
+		}
+	}()
+
+	f.res, f.cacheable, f.err = c.send(q)
+	f.abandoned = f.err != nil &&
+		(errors.Is(f.err, ErrLatencyBudgetExceeded) || (c.Context != nil && c.Context.Err() != nil))
+	if f.err != nil {
+		c.land(key, f)
+		return f.res, f.cacheable, f.err
+	}
+	// The leader gets a copy too, so the response waiting calls copy from stays as it came from the SDK
+	res = f.copyFor(q)
+	c.land(key, f)
+	return res, f.cacheable, nil
+}
+
+// land removes the flight f from under key and releases calls waiting for it.
+func (c *Caller) land(key string, f *flight) {
+	flightsMu.Lock()
+	delete(flights, key)
+	flightsMu.Unlock()
+	close(f.done)
+}
+
+// copyFor returns a copy of the flight response for q with its ID. The result is copied deeply
+// as responses get filtered per user after they're returned.
+func (f *flight) copyFor(q *Query) *jsonrpc.RPCResponse {
+	res := *f.res
+	res.ID = q.Request.ID
+	res.Result = copyJSON(f.res.Result)
+	return &res
+}
+
+// copyJSON returns a deep copy of a value decoded from JSON.
+func copyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = copyJSON(e)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = copyJSON(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
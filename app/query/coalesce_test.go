@@ -0,0 +1,138 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// slowServer responds to every call after release is closed.
+func slowServer() (*httptest.Server, chan struct{}, *int32) {
+	var calls int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jsonrpc": "2.0", "result": {"lbry://one": {"name": "one"}}, "id": 0}`)
+	}))
+	return srv, release, &calls
+}
+
+func TestCaller_CoalesceIdenticalResolves(t *testing.T) {
+	srv, release, calls := slowServer()
+	defer srv.Close()
+
+	var wg sync.WaitGroup
+	responses := make([]*jsonrpc.RPCResponse, 10)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: []interface{}{"lbry://one"}})
+			req.ID = i
+			res, err := NewCaller(srv.URL, 0).Call(req)
+			require.NoError(t, err)
+			responses[i] = res
+		}(i)
+	}
+	// Wait for the first call to reach the server and the rest to queue up behind it
+	for atomic.LoadInt32(calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+	for i, res := range responses {
+		assert.Equal(t, i, res.ID)
+		assert.NotNil(t, res.Result.(map[string]interface{})["lbry://one"])
+	}
+}
+
+func TestCaller_CoalescedResultsAreCopies(t *testing.T) {
+	srv, release, calls := slowServer()
+	defer srv.Close()
+
+	params := map[string]interface{}{ParamUrls: []interface{}{"lbry://one"}}
+	results := make(chan *jsonrpc.RPCResponse, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			res, err := NewCaller(srv.URL, 0).Call(jsonrpc.NewRequest(MethodResolve, params))
+			require.NoError(t, err)
+			results <- res
+		}()
+	}
+	for atomic.LoadInt32(calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	// Responses get filtered per user, which mustn't show in responses to other calls
+	first := <-results
+	delete(first.Result.(map[string]interface{})["lbry://one"].(map[string]interface{}), "name")
+	second := <-results
+	assert.Equal(t, "one", second.Result.(map[string]interface{})["lbry://one"].(map[string]interface{})["name"])
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestCopyJSON(t *testing.T) {
+	v := map[string]interface{}{"a": []interface{}{map[string]interface{}{"b": 1.0}}, "c": "d"}
+	c := copyJSON(v).(map[string]interface{})
+	assert.Equal(t, v, c)
+	c["a"].([]interface{})[0].(map[string]interface{})["b"] = 2.0
+	assert.Equal(t, 1.0, v["a"].([]interface{})[0].(map[string]interface{})["b"])
+}
+
+func TestCaller_CoalesceAbandonedCall(t *testing.T) {
+	srv, release, calls := slowServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	params := map[string]interface{}{ParamUrls: []interface{}{"lbry://one"}}
+	first := make(chan error)
+	go func() {
+		c := NewCaller(srv.URL, 0)
+		c.Context = ctx
+		c.Deadline = time.Now().Add(100 * time.Millisecond)
+		_, err := c.Call(jsonrpc.NewRequest(MethodResolve, params))
+		first <- err
+	}()
+	for atomic.LoadInt32(calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	second := make(chan *jsonrpc.RPCResponse)
+	go func() {
+		res, err := NewCaller(srv.URL, 0).Call(jsonrpc.NewRequest(MethodResolve, params))
+		require.NoError(t, err)
+		second <- res
+	}()
+	// The first call runs out of time, the one waiting for it is then sent on its own
+	assert.Error(t, <-first)
+	cancel()
+	close(release)
+	res := <-second
+	assert.Nil(t, res.Error)
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls))
+}
+
+func TestCaller_NoCoalescingForUsers(t *testing.T) {
+	q, err := NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: []interface{}{"lbry://one"}}), "wallet")
+	require.NoError(t, err)
+	assert.False(t, coalesces(q))
+	q, err = NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: []interface{}{"lbry://one"}}), "")
+	require.NoError(t, err)
+	assert.True(t, coalesces(q))
+}
@@ -22,10 +22,15 @@ const (
 	ParamUrls            = "urls"
 	ParamNewSDKServer    = "new_sdk_server"
 	ParamChannelID       = "channel_id"
+	ParamFilePath        = "file_path"
 )
 
 var forbiddenParams = []string{ParamAccountID, ParamNewSDKServer}
 
+// serverParams are set by lbrytv or point at files on SDK servers, values sent by clients are dropped.
+// wallet_id is only dropped from anonymous queries, authenticated ones get it replaced.
+var serverParams = []string{ParamFilePath, "download_directory", ParamWalletID}
+
 // relaxedMethods are methods which are allowed to be called without wallet_id.
 var relaxedMethods = []string{
 	"blob_announce",
@@ -0,0 +1,36 @@
+package query
+
+import (
+	"fmt"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// invalidateCache drops cached responses made stale by a successful write call, as set in QueryCacheInvalidations,
+// e.g. claim_search pages of the channel a stream was published into. Cached params are matched by substring,
+// so resolves are only dropped for URLs containing the full claim ID.
+func invalidateCache(c *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
+	if c.Cache == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	invalidations, ok := config.GetQueryCacheInvalidations()[hctx.Query.Method()]
+	if !ok {
+		return nil, nil
+	}
+	params := hctx.Query.ParamsAsMap()
+	for _, inv := range invalidations {
+		value, ok := params[inv.Param]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		n := c.Cache.InvalidateMatching(inv.Method, fmt.Sprintf("%v", value))
+		if n > 0 {
+			metrics.ProxyQueryCacheInvalidatedCount.WithLabelValues(inv.Method).Add(float64(n))
+			hctx.AddLogField("cache_invalidated", n)
+		}
+	}
+	return nil, nil
+}
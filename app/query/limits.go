@@ -0,0 +1,153 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	// ParamTruncated is added to truncated responses so clients know there is more data to fetch.
+	ParamTruncated = "truncated"
+
+	paramItems = "items"
+
+	sizeLimitActionTruncated = "truncated"
+	sizeLimitActionRejected  = "rejected"
+)
+
+// ParamLimitDetails are sent in the data field of errors for params over their limit.
+type ParamLimitDetails struct {
+	Param string  `json:"param"`
+	Limit int     `json:"limit"`
+	Value float64 `json:"value"`
+}
+
+// limitParams rejects queries with params over limits configured for the method. Numeric params are compared
+// by value and lists by length, so a single query can't ask the SDK for an arbitrary amount of work.
+func limitParams(_ *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
+	limits, ok := config.GetParamLimits()[hctx.Query.Method()]
+	if !ok {
+		return nil, nil
+	}
+	params := hctx.Query.ParamsAsMap()
+	if params == nil {
+		return nil, nil
+	}
+	for param, limit := range limits {
+		value, ok := paramSize(params[param])
+		if !ok || value <= float64(limit) {
+			continue
+		}
+		metrics.ProxyParamLimitCount.WithLabelValues(hctx.Query.Method(), param).Inc()
+		logger.WithFields(logrus.Fields{"method": hctx.Query.Method(), "param": param, "value": value, "limit": limit}).Info("query rejected")
+		return nil, rpcerrors.NewInvalidParamsError(fmt.Errorf("%v of %v cannot exceed %v", param, hctx.Query.Method(), limit)).
+			WithData(ParamLimitDetails{Param: param, Limit: limit, Value: value})
+	}
+	return nil, nil
+}
+
+// paramSize returns the value of a numeric param or the length of a list one.
+func paramSize(v interface{}) (float64, bool) {
+	switch typed := v.(type) {
+	case float64:
+		return typed, true
+	case int:
+		return float64(typed), true
+	case []interface{}:
+		return float64(len(typed)), true
+	case []string:
+		return float64(len(typed)), true
+	case string:
+		return 1, true
+	}
+	return 0, false
+}
+
+// limitResponseSize checks the serialized response size against the limit configured for the method.
+// Responses with an `items` list (like claim_search) are trimmed to fit when the limit allows truncation,
+// otherwise an error asking the client to narrow the query is returned.
+func limitResponseSize(_ *Caller, hctx *HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	limit, ok := config.GetResponseSizeLimits()[hctx.Query.Method()]
+	if !ok || limit.MaxSize <= 0 {
+		return nil, nil
+	}
+
+	serialized, err := json.Marshal(hctx.Response.Result)
+	if err != nil {
+		return nil, err
+	}
+	size := len(serialized)
+	if size <= limit.MaxSize {
+		return nil, nil
+	}
+
+	log := logger.WithFields(logrus.Fields{"method": hctx.Query.Method(), "size": size, "max_size": limit.MaxSize})
+	if limit.Truncate {
+		if result, ok := truncateItems(hctx.Response.Result, limit.MaxSize); ok {
+			metrics.ProxyResponseSizeLimitCount.WithLabelValues(hctx.Query.Method(), sizeLimitActionTruncated).Inc()
+			log.Info("response truncated")
+			hctx.Response.Result = result
+			return hctx.Response, nil
+		}
+	}
+
+	metrics.ProxyResponseSizeLimitCount.WithLabelValues(hctx.Query.Method(), sizeLimitActionRejected).Inc()
+	log.Info("response rejected")
+	return nil, rpcerrors.NewResponseTooLargeError(fmt.Errorf(
+		"response for %v is too large (%v bytes, limit is %v), please narrow your query",
+		hctx.Query.Method(), size, limit.MaxSize))
+}
+
+// truncateItems keeps as many leading entries of the result's `items` list as fit into maxSize
+// and marks the result as truncated. It returns false if the result cannot be truncated.
+func truncateItems(result interface{}, maxSize int) (map[string]interface{}, bool) {
+	resultMap, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	items, ok := resultMap[paramItems].([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, false
+	}
+
+	truncated := map[string]interface{}{}
+	for k, v := range resultMap {
+		truncated[k] = v
+	}
+	truncated[ParamTruncated] = true
+	truncated[paramItems] = []interface{}{}
+	envelope, err := json.Marshal(truncated)
+	if err != nil {
+		return nil, false
+	}
+
+	size := len(envelope)
+	kept := 0
+	for _, item := range items {
+		itemSerialized, err := json.Marshal(item)
+		if err != nil {
+			return nil, false
+		}
+		// +1 accounts for the comma separating list entries
+		if size+len(itemSerialized)+1 > maxSize {
+			break
+		}
+		size += len(itemSerialized) + 1
+		kept++
+	}
+	if kept == 0 {
+		return nil, false
+	}
+	truncated[paramItems] = items[:kept]
+	return truncated, true
+}
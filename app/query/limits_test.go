@@ -0,0 +1,97 @@
+package query
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func claimSearchResponse(n int) *jsonrpc.RPCResponse {
+	items := []interface{}{}
+	for i := 0; i < n; i++ {
+		items = append(items, map[string]interface{}{"claim_id": fmt.Sprintf("%040d", i)})
+	}
+	return &jsonrpc.RPCResponse{Result: map[string]interface{}{"items": items, "page": 1, "page_size": n}}
+}
+
+func TestLimitResponseSize_NoLimit(t *testing.T) {
+	config.Override("ResponseSizeLimits", map[string]interface{}{})
+	defer config.RestoreOverridden()
+
+	q, err := NewQuery(jsonrpc.NewRequest(MethodClaimSearch), "")
+	require.NoError(t, err)
+	res, err := limitResponseSize(nil, &HookContext{Query: q, Response: claimSearchResponse(100)})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestLimitResponseSize_Truncate(t *testing.T) {
+	config.Override("ResponseSizeLimits", map[string]interface{}{
+		MethodClaimSearch: map[string]interface{}{"MaxSize": 500, "Truncate": true},
+	})
+	defer config.RestoreOverridden()
+
+	q, err := NewQuery(jsonrpc.NewRequest(MethodClaimSearch), "")
+	require.NoError(t, err)
+	res, err := limitResponseSize(nil, &HookContext{Query: q, Response: claimSearchResponse(100)})
+	require.NoError(t, err)
+	require.NotNil(t, res)
+
+	result := res.Result.(map[string]interface{})
+	assert.Equal(t, true, result[ParamTruncated])
+	items := result["items"].([]interface{})
+	assert.Greater(t, len(items), 0)
+	assert.Less(t, len(items), 100)
+}
+
+func TestLimitResponseSize_Reject(t *testing.T) {
+	config.Override("ResponseSizeLimits", map[string]interface{}{
+		MethodClaimSearch: map[string]interface{}{"MaxSize": 500},
+	})
+	defer config.RestoreOverridden()
+
+	q, err := NewQuery(jsonrpc.NewRequest(MethodClaimSearch), "")
+	require.NoError(t, err)
+	res, err := limitResponseSize(nil, &HookContext{Query: q, Response: claimSearchResponse(100)})
+	assert.Nil(t, res)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "please narrow your query")
+}
+
+func TestLimitParams(t *testing.T) {
+	config.Override("ParamLimits", map[string]interface{}{
+		MethodClaimSearch: map[string]interface{}{"page_size": 50, "any_tags": 2},
+		MethodResolve:     map[string]interface{}{"urls": 2},
+	})
+	defer config.RestoreOverridden()
+
+	check := func(method string, params map[string]interface{}) error {
+		q, err := NewQuery(jsonrpc.NewRequest(method, params), "")
+		require.NoError(t, err)
+		res, err := limitParams(nil, &HookContext{Query: q})
+		assert.Nil(t, res)
+		return err
+	}
+
+	assert.NoError(t, check(MethodClaimSearch, map[string]interface{}{"page_size": 50, "any_tags": []string{"a", "b"}}))
+	assert.NoError(t, check(MethodResolve, map[string]interface{}{"urls": "lbry://what"}))
+	assert.NoError(t, check(MethodStatus, map[string]interface{}{"page_size": 500}))
+
+	err := check(MethodClaimSearch, map[string]interface{}{"page_size": 500.0})
+	require.Error(t, err)
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32602, rpcErr.Code())
+	assert.Equal(t, ParamLimitDetails{Param: "page_size", Limit: 50, Value: 500}, rpcErr.Data())
+	assert.Contains(t, string(rpcErr.JSON()), `"limit": 50`)
+
+	assert.Error(t, check(MethodClaimSearch, map[string]interface{}{"any_tags": []interface{}{"a", "b", "c"}}))
+	assert.Error(t, check(MethodResolve, map[string]interface{}{"urls": []string{"lbry://a", "lbry://b", "lbry://c"}}))
+}
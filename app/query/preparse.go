@@ -0,0 +1,178 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+var (
+	errNotObject     = errors.Base("request is not a JSON object")
+	errMalformed     = errors.Base("malformed JSON in request")
+	errMethodNotText = errors.Base("method must be a string")
+)
+
+// RequestHead contains JSON-RPC request fields extracted by PreParse.
+// Both fields point into the original request body and must not be modified.
+type RequestHead struct {
+	// Method is the method name with quotes stripped.
+	Method []byte
+	// ID is the raw JSON value of the request id, nil when it's missing.
+	ID []byte
+}
+
+// PreParse extracts method and id from a JSON-RPC request body without unmarshaling it,
+// so routing and policy checks can run before paying for a full parse.
+// It only scans the top-level object and doesn't fully validate the rest of the body.
+func PreParse(body []byte) (RequestHead, error) {
+	var head RequestHead
+
+	i := skipSpace(body, 0)
+	if i >= len(body) || body[i] != '{' {
+		return head, errNotObject
+	}
+	i = skipSpace(body, i+1)
+	if i < len(body) && body[i] == '}' {
+		return head, nil
+	}
+
+	for {
+		keyStart, keyEnd, next, ok := scanString(body, i)
+		if !ok {
+			return head, errMalformed
+		}
+		i = skipSpace(body, next)
+		if i >= len(body) || body[i] != ':' {
+			return head, errMalformed
+		}
+		i = skipSpace(body, i+1)
+		valueEnd, ok := skipValue(body, i)
+		if !ok {
+			return head, errMalformed
+		}
+
+		switch string(body[keyStart:keyEnd]) {
+		case "method":
+			if body[i] != '"' {
+				return head, errMethodNotText
+			}
+			head.Method = body[i+1 : valueEnd-1]
+			if bytes.IndexByte(head.Method, '\\') >= 0 {
+				// Escaped method names are unusual enough to not bother decoding them by hand
+				var m string
+				if err := json.Unmarshal(body[i:valueEnd], &m); err != nil {
+					return head, errMalformed
+				}
+				head.Method = []byte(m)
+			}
+		case "id":
+			head.ID = body[i:valueEnd]
+		}
+
+		i = skipSpace(body, valueEnd)
+		if i >= len(body) {
+			return head, errMalformed
+		}
+		switch body[i] {
+		case ',':
+			i = skipSpace(body, i+1)
+		case '}':
+			return head, nil
+		default:
+			return head, errMalformed
+		}
+	}
+}
+
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && (b[i] == ' ' || b[i] == '\t' || b[i] == '\n' || b[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// scanString returns bounds of the string contents starting at b[i] and the index following the closing quote.
+func scanString(b []byte, i int) (start, end, next int, ok bool) {
+	if i >= len(b) || b[i] != '"' {
+		return 0, 0, 0, false
+	}
+	for j := i + 1; j < len(b); j++ {
+		switch b[j] {
+		case '\\':
+			j++
+		case '"':
+			return i + 1, j, j + 1, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// skipValue returns the index following the JSON value starting at b[i].
+func skipValue(b []byte, i int) (int, bool) {
+	if i >= len(b) {
+		return 0, false
+	}
+	switch b[i] {
+	case '"':
+		_, _, next, ok := scanString(b, i)
+		return next, ok
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(b); j++ {
+			switch b[j] {
+			case '"':
+				_, _, next, ok := scanString(b, j)
+				if !ok {
+					return 0, false
+				}
+				j = next - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, true
+				}
+			}
+		}
+		return 0, false
+	default:
+		j := i
+		for j < len(b) && !isDelimiter(b[j]) {
+			j++
+		}
+		if j == i {
+			return 0, false
+		}
+		return j, true
+	}
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case ',', '}', ']', ' ', '\t', '\r', '\n':
+		return true
+	}
+	return false
+}
+
+// MethodAllowed returns true if the method can be proxied to the SDK.
+func MethodAllowed(method string) bool {
+	return methodInList(method, relaxedMethods) || methodInList(method, walletSpecificMethods)
+}
+
+// KnownMethods returns methods which can be proxied to the SDK, sorted.
+func KnownMethods() []string {
+	seen := map[string]bool{}
+	methods := []string{}
+	for _, m := range append(append([]string{}, relaxedMethods...), walletSpecificMethods...) {
+		if !seen[m] {
+			seen[m] = true
+			methods = append(methods, m)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,65 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreParse(t *testing.T) {
+	cases := []struct {
+		body   string
+		method string
+		id     string
+	}{
+		{`{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": ["what"]}, "id": 1}`, "resolve", "1"},
+		{`{"id":"abc","method":"claim_search"}`, "claim_search", `"abc"`},
+		{` {"params": {"method": "nested", "list": [{"id": 5}, "]}"]}, "method" : "status" } `, "status", ""},
+		{`{"method": "wallet_balance", "id": null}`, "wallet_balance", "null"},
+		{`{"params": "quote \" inside", "method": "get"}`, "get", ""},
+		{`{"method": "resol\u0076e"}`, "resolve", ""},
+		{`{}`, "", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.body, func(t *testing.T) {
+			head, err := PreParse([]byte(c.body))
+			require.NoError(t, err)
+			assert.Equal(t, c.method, string(head.Method))
+			assert.Equal(t, c.id, string(head.ID))
+		})
+	}
+}
+
+func TestPreParse_Malformed(t *testing.T) {
+	for _, body := range []string{
+		``,
+		`yo`,
+		`["method"]`,
+		`{"method": 1}`,
+		`{"method" "resolve"}`,
+		`{"method": "resolve"`,
+		`{"params": {"a": 1}`,
+		`{"method": "resolve" "id": 1}`,
+	} {
+		_, err := PreParse([]byte(body))
+		assert.Error(t, err, body)
+	}
+}
+
+func TestPreParse_NoAllocations(t *testing.T) {
+	body := []byte(`{"jsonrpc": "2.0", "method": "claim_search", "params": {"channel_ids": ["abc", "def"], "page": 1}, "id": 1603810000}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		head, _ := PreParse(body)
+		_ = MethodAllowed(string(head.Method))
+	})
+	assert.Equal(t, 0.0, allocs)
+}
+
+func BenchmarkPreParse(b *testing.B) {
+	body := []byte(`{"jsonrpc": "2.0", "method": "claim_search", "params": {"channel_ids": ["abc", "def"], "page": 1}, "id": 1603810000}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		PreParse(body)
+	}
+}
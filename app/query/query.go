@@ -6,8 +6,10 @@ import (
 
 	"github.com/lbryio/lbrytv/app/rpcerrors"
 	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
 
+	"github.com/sirupsen/logrus"
 	"github.com/ybbus/jsonrpc"
 )
 
@@ -29,7 +31,7 @@ func NewQuery(req *jsonrpc.RPCRequest, walletID string) (*Query, error) {
 
 	q := &Query{Request: req, WalletID: walletID}
 
-	if !methodInList(q.Method(), relaxedMethods) && !methodInList(q.Method(), walletSpecificMethods) {
+	if !MethodAllowed(q.Method()) {
 		return nil, rpcerrors.NewMethodNotAllowedError(errors.Err("forbidden method"))
 	}
 
@@ -41,6 +43,12 @@ func NewQuery(req *jsonrpc.RPCRequest, walletID string) (*Query, error) {
 		}
 	}
 
+	q.stripServerParams()
+
+	if err := validateParams(q.Method(), q.Params()); err != nil {
+		return nil, err
+	}
+
 	if MethodAcceptsWallet(q.Method()) {
 		if q.IsAuthenticated() {
 			if p := q.ParamsAsMap(); p != nil {
@@ -57,6 +65,19 @@ func NewQuery(req *jsonrpc.RPCRequest, walletID string) (*Query, error) {
 	return q, nil
 }
 
+// stripServerParams drops params clients are not supposed to set, see serverParams.
+func (q *Query) stripServerParams() {
+	params := q.ParamsAsMap()
+	for _, p := range serverParams {
+		if _, ok := params[p]; !ok || (p == ParamWalletID && q.IsAuthenticated()) {
+			continue
+		}
+		delete(params, p)
+		metrics.ProxyStrippedParams.WithLabelValues(q.Method(), p).Inc()
+		logger.WithFields(logrus.Fields{"method": q.Method(), "param": p}).Info("server-controlled param dropped")
+	}
+}
+
 // Method is a shortcut for query method.
 func (q *Query) Method() string {
 	return q.Request.Method
@@ -93,6 +114,28 @@ func (q *Query) CopyParamsAsMap() map[string]interface{} {
 	return nil
 }
 
+// ResolveURLs returns URLs from resolve params, which can be a single string or a list of them.
+// It returns nil when the list holds anything but strings, which the SDK would reject.
+func (q *Query) ResolveURLs() []string {
+	switch typed := q.ParamsAsMap()[ParamUrls].(type) {
+	case string:
+		return []string{typed}
+	case []interface{}:
+		urls := []string{}
+		for _, u := range typed {
+			s, ok := u.(string)
+			if !ok {
+				return nil
+			}
+			urls = append(urls, s)
+		}
+		return urls
+	case []string:
+		return typed
+	}
+	return nil
+}
+
 func (q *Query) newResponse() *jsonrpc.RPCResponse {
 	return &jsonrpc.RPCResponse{
 		JSONRPC: q.Request.JSONRPC,
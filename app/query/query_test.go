@@ -48,6 +48,38 @@ func TestQueryCopyParamsAsMap(t *testing.T) {
 	assert.NotEqual(t, params, q.ParamsAsMap())
 }
 
+func TestNewQuery_StripsServerParams(t *testing.T) {
+	q, err := NewQuery(jsonrpc.NewRequest("stream_create", map[string]interface{}{
+		"name": "one", "bid": "0.1", "file_path": "/etc/passwd", "wallet_id": "someone-else",
+	}), "123")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "one", "bid": "0.1", "wallet_id": "123"}, q.ParamsAsMap())
+
+	q, err = NewQuery(jsonrpc.NewRequest("claim_search", map[string]interface{}{
+		"claim_id": "abc", "wallet_id": "someone-else", "download_directory": "/tmp",
+	}), "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"claim_id": "abc"}, q.ParamsAsMap())
+}
+
+func TestQueryResolveURLs(t *testing.T) {
+	q, err := NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: "lbry://one"}), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lbry://one"}, q.ResolveURLs())
+
+	q, err = NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: []interface{}{"lbry://one", "lbry://two"}}), "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lbry://one", "lbry://two"}, q.ResolveURLs())
+
+	q, err = NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: []interface{}{"lbry://one", 1.0}}), "")
+	require.NoError(t, err)
+	assert.Nil(t, q.ResolveURLs())
+
+	q, err = NewQuery(jsonrpc.NewRequest(MethodResolve), "")
+	require.NoError(t, err)
+	assert.Nil(t, q.ResolveURLs())
+}
+
 func TestQueryIsAuthenticated(t *testing.T) {
 	q, err := NewQuery(jsonrpc.NewRequest("resolve"), "12345")
 	require.NoError(t, err)
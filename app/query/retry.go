@@ -0,0 +1,92 @@
+package query
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+)
+
+// SDK calls mostly fail in transport when an SDK node restarts or closes a keep-alive connection under us.
+// Retrying them keeps such blips from reaching users as internal errors. A call is only retried
+// when it never reached the SDK or repeating it is harmless.
+
+// RetryPolicy tells how Caller retries SDK calls which failed in transport.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a call is attempted, 1 or less turns retrying off.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Retryable tells whether a call to method which failed with err may be retried. IsRetryable is used when it's nil.
+	Retryable func(method string, err error) bool
+}
+
+// transportFailures are messages of errors of calls which never reached the SDK.
+var transportFailures = []string{"connection refused", "no such host"}
+
+// brokenConnections are messages of errors of calls which may have reached the SDK before the connection broke.
+var brokenConnections = []string{"connection reset by peer", "broken pipe", "EOF"}
+
+// DefaultRetryPolicy returns the retry policy set in config.
+func DefaultRetryPolicy() RetryPolicy {
+	cfg := config.GetSDKRetry()
+	return RetryPolicy{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+		Multiplier:     cfg.Multiplier,
+		Retryable:      IsRetryable,
+	}
+}
+
+// Backoff returns how long to wait before the given retry, the first one being 1.
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	b := float64(p.InitialBackoff)
+	for i := 1; i < retry && p.Multiplier > 1; i++ {
+		b *= p.Multiplier
+	}
+	if p.MaxBackoff > 0 && b > float64(p.MaxBackoff) {
+		b = float64(p.MaxBackoff)
+	}
+	// Half of it is jitter, so retries of calls which failed together are spread out
+	return time.Duration(b/2 + rand.Float64()*b/2)
+}
+
+func (p RetryPolicy) retryable(method string, err error) bool {
+	if p.Retryable == nil {
+		return IsRetryable(method, err)
+	}
+	return p.Retryable(method, err)
+}
+
+// IsRetryable returns true for errors of calls which never reached the SDK, and for broken connections
+// of calls to methods which don't change anything, so repeating them is harmless.
+func IsRetryable(method string, err error) bool {
+	// The JSON-RPC client flattens transport errors into strings, so there's nothing to unwrap
+	msg := err.Error()
+	for _, m := range transportFailures {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	if !isReadOnly(method) {
+		return false
+	}
+	for _, m := range brokenConnections {
+		if strings.Contains(msg, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReadOnly returns true for methods which don't change wallets or the SDK state.
+func isReadOnly(method string) bool {
+	switch method {
+	case MethodResolve, MethodStatus, MethodWalletBalance, "version":
+		return true
+	}
+	return strings.HasSuffix(method, "_list") || strings.HasSuffix(method, "_search")
+}
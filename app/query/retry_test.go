@@ -0,0 +1,135 @@
+package query
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// flakyServer drops connections of the first failures requests without responding.
+func flakyServer(t *testing.T, failures int32) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"jsonrpc": "2.0", "result": {}, "id": 0}`)
+	}))
+	return srv, &calls
+}
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 3, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond, Multiplier: 2}
+}
+
+func TestCaller_RetryBrokenConnection(t *testing.T) {
+	srv, calls := flakyServer(t, 2)
+	defer srv.Close()
+
+	retried := metrics.GetCounterValue(metrics.ProxyCallAttempts.WithLabelValues("claim_search", srv.URL, metrics.AttemptRetried))
+	c := NewCaller(srv.URL, 0)
+	c.Retry = testRetryPolicy()
+	res, err := c.Call(jsonrpc.NewRequest("claim_search", map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.Nil(t, res.Error)
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+	assert.Equal(t, retried+2,
+		metrics.GetCounterValue(metrics.ProxyCallAttempts.WithLabelValues("claim_search", srv.URL, metrics.AttemptRetried)))
+}
+
+func TestCaller_RetryGivesUp(t *testing.T) {
+	srv, calls := flakyServer(t, 5)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Retry = testRetryPolicy()
+	_, err := c.Call(jsonrpc.NewRequest("claim_search", map[string]interface{}{}))
+	require.Error(t, err)
+	assert.EqualValues(t, 3, atomic.LoadInt32(calls))
+}
+
+func TestCaller_NoRetryOfWrites(t *testing.T) {
+	srv, calls := flakyServer(t, 1)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Retry = testRetryPolicy()
+	_, err := c.Call(jsonrpc.NewRequest(MethodWalletSend, map[string]interface{}{}))
+	require.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestCaller_RetryStopsWithContext(t *testing.T) {
+	srv, calls := flakyServer(t, 5)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := NewCaller(srv.URL, 0)
+	c.Retry = testRetryPolicy()
+	c.Retry.InitialBackoff, c.Retry.MaxBackoff = time.Minute, 0
+	c.Context = ctx
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := c.Call(jsonrpc.NewRequest("claim_search", map[string]interface{}{}))
+	require.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+	assert.Less(t, time.Since(start).Seconds(), 5.0)
+}
+
+func TestCaller_RetryWithinDeadline(t *testing.T) {
+	srv, calls := flakyServer(t, 5)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	c := NewCaller(srv.URL, 0)
+	c.Retry = testRetryPolicy()
+	c.Retry.InitialBackoff, c.Retry.MaxBackoff = time.Second, 0
+	c.Context = ctx
+
+	// Waiting for the retry would overrun the deadline of the request
+	_, err := c.Call(jsonrpc.NewRequest("claim_search", map[string]interface{}{}))
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrLatencyBudgetExceeded))
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls))
+}
+
+func TestIsRetryable(t *testing.T) {
+	refused := errors.Err("rpc call claim_search() on http://x: dial tcp 127.0.0.1:1: connect: connection refused")
+	reset := errors.Err("rpc call claim_search() on http://x: read tcp: connection reset by peer")
+	eof := errors.Err(`rpc call resolve() on http://x: Post "http://x": EOF`)
+	other := errors.Err("rpc call resolve() on http://x: net/http: request canceled")
+
+	assert.True(t, IsRetryable(MethodWalletSend, refused))
+	assert.False(t, IsRetryable(MethodWalletSend, reset))
+	assert.True(t, IsRetryable(MethodClaimSearch, reset))
+	assert.True(t, IsRetryable(MethodResolve, eof))
+	assert.True(t, IsRetryable(MethodFileList, eof))
+	assert.False(t, IsRetryable(MethodGet, eof))
+	assert.False(t, IsRetryable(MethodResolve, other))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	for retry, max := range map[int]time.Duration{1: 100, 2: 200, 3: 300, 10: 300} {
+		b := p.Backoff(retry)
+		assert.LessOrEqual(t, int64(b), int64(max*time.Millisecond), retry)
+		assert.GreaterOrEqual(t, int64(b), int64(max*time.Millisecond/2), retry)
+	}
+}
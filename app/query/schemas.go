@@ -0,0 +1,240 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/jsonschema"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/gobuffalo/packr/v2"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// SchemaValidationStrict rejects requests with params not matching the method schema.
+	SchemaValidationStrict = "strict"
+	// SchemaValidationLenient only logs requests with params not matching the method schema.
+	SchemaValidationLenient = "lenient"
+	// SchemaValidationOff skips params validation.
+	SchemaValidationOff = "off"
+
+	// schemaManifest lists sha256 checksums of schema files in `sha256sum` output format.
+	schemaManifest = "SHA256SUMS"
+	// maxReportedViolations limits how many violations are listed in an error returned to the client.
+	maxReportedViolations = 5
+)
+
+// ParamViolation is a param not matching the method schema, Path locating it as in `params.claim_ids[2]`.
+type ParamViolation struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// InvalidParamsDetails is sent in the data field of errors for calls rejected by schema validation.
+type InvalidParamsDetails struct {
+	Method     string           `json:"method"`
+	Violations []ParamViolation `json:"violations"`
+	// Truncated is set when there were more violations than listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+var (
+	schemasMu     sync.RWMutex
+	methodSchemas map[string]*jsonschema.Schema
+	schemaMode    = SchemaValidationOff
+)
+
+// LoadSchemas loads SDK method params schemas from dir, or ones shipped with lbrytv when dir is empty,
+// and sets the validation mode. Schemas are only loaded if all of them match checksums in the SHA256SUMS file,
+// so a partially updated or corrupted schema set doesn't end up rejecting valid requests.
+func LoadSchemas(dir, mode string) error {
+	switch mode {
+	case SchemaValidationStrict, SchemaValidationLenient, SchemaValidationOff:
+	default:
+		return errors.Err("unknown schema validation mode %q", mode)
+	}
+
+	var (
+		files map[string][]byte
+		err   error
+	)
+	if dir == "" {
+		files, err = readSchemaBox()
+	} else {
+		files, err = readSchemaDir(dir)
+	}
+	if err != nil {
+		return err
+	}
+	if err := verifySchemas(files); err != nil {
+		return err
+	}
+
+	schemas := map[string]*jsonschema.Schema{}
+	for name, b := range files {
+		if name == schemaManifest {
+			continue
+		}
+		s, err := jsonschema.Parse(b)
+		if err != nil {
+			return errors.Prefix(name, err)
+		}
+		schemas[strings.TrimSuffix(name, ".json")] = s
+	}
+
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	methodSchemas = schemas
+	schemaMode = mode
+	logger.WithFields(logrus.Fields{"methods": len(schemas), "mode": mode}).Info("SDK method schemas loaded")
+	return nil
+}
+
+func readSchemaBox() (map[string][]byte, error) {
+	box := packr.New("sdk_schemas", "./schemas")
+	files := map[string][]byte{}
+	for _, name := range box.List() {
+		if !isSchemaFile(name) {
+			continue
+		}
+		b, err := box.Find(name)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		files[name] = b
+	}
+	return files, nil
+}
+
+func readSchemaDir(dir string) (map[string][]byte, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	files := map[string][]byte{}
+	for _, e := range entries {
+		if e.IsDir() || !isSchemaFile(e.Name()) {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		files[e.Name()] = b
+	}
+	return files, nil
+}
+
+func isSchemaFile(name string) bool {
+	return name == schemaManifest || strings.HasSuffix(name, ".json")
+}
+
+// verifySchemas checks that every schema file is listed in the manifest with a matching checksum
+// and that no listed file is missing.
+func verifySchemas(files map[string][]byte) error {
+	manifest, ok := files[schemaManifest]
+	if !ok {
+		return errors.Err("schema checksum file %v is missing", schemaManifest)
+	}
+	expected := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return errors.Err("malformed line in %v: %q", schemaManifest, line)
+		}
+		expected[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	for name, b := range files {
+		if name == schemaManifest {
+			continue
+		}
+		sum, ok := expected[name]
+		if !ok {
+			return errors.Err("schema %v is not listed in %v", name, schemaManifest)
+		}
+		actual := sha256.Sum256(b)
+		if hex.EncodeToString(actual[:]) != sum {
+			return errors.Err("checksum mismatch for schema %v", name)
+		}
+	}
+	for name := range expected {
+		if _, ok := files[name]; !ok {
+			return errors.Err("schema %v listed in %v is missing", name, schemaManifest)
+		}
+	}
+	return nil
+}
+
+// validateParams checks params against the schema of method. In lenient mode violations are only logged.
+func validateParams(method string, params interface{}) error {
+	schemasMu.RLock()
+	schema, mode := methodSchemas[method], schemaMode
+	schemasMu.RUnlock()
+	if schema == nil || mode == SchemaValidationOff {
+		return nil
+	}
+
+	normalized, err := normalizeParams(params)
+	if err != nil {
+		return rpcerrors.NewInvalidParamsError(err)
+	}
+	violations := schema.Validate("params", normalized)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	reported := []string{}
+	details := InvalidParamsDetails{Method: method, Violations: []ParamViolation{}}
+	for i, v := range violations {
+		if i == maxReportedViolations {
+			reported = append(reported, "...")
+			details.Truncated = true
+			break
+		}
+		reported = append(reported, v.String())
+		details.Violations = append(details.Violations, ParamViolation{Path: v.Path, Message: v.Message})
+	}
+	log := logger.WithFields(logrus.Fields{"method": method, "violations": reported})
+	if mode == SchemaValidationLenient {
+		metrics.ProxyInvalidParams.WithLabelValues(method, metrics.InvalidParamsAllowed).Inc()
+		log.Info("request params don't match method schema")
+		return nil
+	}
+	metrics.ProxyInvalidParams.WithLabelValues(method, metrics.InvalidParamsRejected).Inc()
+	log.Info("request rejected, params don't match method schema")
+	return rpcerrors.NewInvalidParamsError(errors.Err("invalid params for %v: %v", method, strings.Join(reported, "; "))).
+		WithData(details)
+}
+
+// normalizeParams converts params to what encoding/json produces, as they might have been modified by lbrytv itself.
+// Missing params are treated as an empty object.
+func normalizeParams(params interface{}) (interface{}, error) {
+	if params == nil {
+		return map[string]interface{}{}, nil
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,98 @@
+package query
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func copySchemas(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "schemas")
+	require.NoError(t, err)
+	files, err := readSchemaDir("schemas")
+	require.NoError(t, err)
+	for name, b := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), b, 0644))
+	}
+	return dir
+}
+
+func TestLoadSchemas_Shipped(t *testing.T) {
+	defer LoadSchemas("", SchemaValidationOff)
+
+	require.NoError(t, LoadSchemas("", SchemaValidationStrict))
+	assert.Contains(t, methodSchemas, MethodResolve)
+	assert.Contains(t, methodSchemas, MethodClaimSearch)
+}
+
+func TestLoadSchemas_Integrity(t *testing.T) {
+	defer LoadSchemas("", SchemaValidationOff)
+
+	dir := copySchemas(t)
+	defer os.RemoveAll(dir)
+	require.NoError(t, LoadSchemas(dir, SchemaValidationStrict))
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "resolve.json"), []byte(`{"type": "object"}`), 0644))
+	err := LoadSchemas(dir, SchemaValidationStrict)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum mismatch for schema resolve.json")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "resolve.json")))
+	err = LoadSchemas(dir, SchemaValidationStrict)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "resolve.json listed in SHA256SUMS is missing")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "status.json"), []byte(`{"type": "object"}`), 0644))
+	err = LoadSchemas(dir, SchemaValidationStrict)
+	require.Error(t, err)
+
+	assert.Error(t, LoadSchemas("", "whatever"))
+}
+
+func TestNewQuery_SchemaValidation(t *testing.T) {
+	defer LoadSchemas("", SchemaValidationOff)
+	require.NoError(t, LoadSchemas("", SchemaValidationStrict))
+
+	_, err := NewQuery(jsonrpc.NewRequest(MethodResolve, map[string]interface{}{"urls": []interface{}{"what", "lbry://@lbry"}}), "")
+	require.NoError(t, err)
+
+	_, err = NewQuery(jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"page_size": "20", "any_tags": "art"}), "")
+	require.Error(t, err)
+	var rpcErr rpcerrors.RPCError
+	require.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, -32602, rpcErr.Code())
+	assert.Contains(t, err.Error(), "params.any_tags: expected array, got string; params.page_size: expected integer, got string")
+	assert.Equal(t, InvalidParamsDetails{Method: MethodClaimSearch, Violations: []ParamViolation{
+		{Path: "params.any_tags", Message: "expected array, got string"},
+		{Path: "params.page_size", Message: "expected integer, got string"},
+	}}, rpcErr.Data())
+
+	_, err = NewQuery(jsonrpc.NewRequest(MethodResolve), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "params.urls: is required")
+
+	// Only the first violations are listed
+	_, err = NewQuery(jsonrpc.NewRequest("stream_create", map[string]interface{}{
+		"bid": 1, "title": 1, "tags": "one", "release_time": "now", "fee_currency": "EUR", "channel_id": "@channel",
+	}), "")
+	require.True(t, errors.As(err, &rpcErr))
+	details := rpcErr.Data().(InvalidParamsDetails)
+	assert.Len(t, details.Violations, maxReportedViolations)
+	assert.True(t, details.Truncated)
+
+	// Methods without a schema are not checked
+	_, err = NewQuery(jsonrpc.NewRequest(MethodStatus, map[string]interface{}{"anything": 1}), "")
+	require.NoError(t, err)
+
+	require.NoError(t, LoadSchemas("", SchemaValidationLenient))
+	_, err = NewQuery(jsonrpc.NewRequest(MethodClaimSearch, map[string]interface{}{"page_size": "20"}), "")
+	require.NoError(t, err)
+}
@@ -0,0 +1,161 @@
+package query
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+// An SDK server resolves URLs one by one, so a resolve of dozens of them takes as long as all of them together.
+// Anonymous resolves of more URLs than ResolveSharding.ShardSize are split into shards sent to available servers
+// in parallel and their results are merged. URLs of shards which failed get an error entry in the result, like
+// those the SDK returns for URLs it can't resolve, so one failing server doesn't fail the whole call.
+
+// ResolveErrorShardFailed is the name of errors in resolve results for URLs whose shard failed.
+const ResolveErrorShardFailed = "SHARD_FAILED"
+
+var (
+	shardSlotsMu sync.Mutex
+	shardSlots   = map[string]chan struct{}{}
+)
+
+// getShardSlots returns the semaphore limiting shards in flight on the SDK server at endpoint.
+func getShardSlots(endpoint string, size int) chan struct{} {
+	shardSlotsMu.Lock()
+	defer shardSlotsMu.Unlock()
+	s, ok := shardSlots[endpoint]
+	if !ok {
+		s = make(chan struct{}, size)
+		shardSlots[endpoint] = s
+	}
+	return s
+}
+
+// shardsResolve returns true if q is sent to the SDK in shards.
+func (c *Caller) shardsResolve(q *Query) bool {
+	if c.ResolveServers == nil || c.userID != 0 || q.Method() != MethodResolve {
+		return false
+	}
+	size := config.GetResolveSharding().ShardSize
+	return size > 0 && len(q.ResolveURLs()) > size
+}
+
+// sendResolveShards sends the resolve query in shards and merges their results. It returns true if some of the shards
+// failed, so the result shouldn't be cached. When all of them failed, the first failure is returned.
+func (c *Caller) sendResolveShards(q *Query) (*jsonrpc.RPCResponse, bool, error) {
+	cfg := config.GetResolveSharding()
+	servers := c.ResolveServers()
+	if len(servers) == 0 {
+		servers = []string{c.endpoint}
+	}
+
+	urls := q.ResolveURLs()
+	shards := [][]string{}
+	for i := 0; i < len(urls); i += cfg.ShardSize {
+		end := i + cfg.ShardSize
+		if end > len(urls) {
+			end = len(urls)
+		}
+		shards = append(shards, urls[i:end])
+	}
+
+	type shardResult struct {
+		endpoint string
+		res      *jsonrpc.RPCResponse
+		err      error
+	}
+	results := make([]shardResult, len(shards))
+	var wg sync.WaitGroup
+	for i, shard := range shards {
+		wg.Add(1)
+		endpoint := servers[i%len(servers)]
+		go func(i int, endpoint string, shard []string) {
+			defer wg.Done()
+			res, err := c.sendResolveShard(q, endpoint, shard, cfg.MaxShardsPerServer)
+			results[i] = shardResult{endpoint: endpoint, res: res, err: err}
+		}(i, endpoint, shard)
+	}
+	wg.Wait()
+
+	merged := map[string]interface{}{}
+	failed := 0
+	for i, r := range results {
+		err := r.err
+		var result map[string]interface{}
+		if err == nil && r.res.Error != nil {
+			err = r.res.Error
+		} else if err == nil {
+			var ok bool
+			if result, ok = r.res.Result.(map[string]interface{}); !ok {
+				err = fmt.Errorf("unexpected resolve result type %T", r.res.Result)
+			}
+		}
+		if err != nil {
+			failed++
+			metrics.ProxyResolveShards.WithLabelValues(r.endpoint, metrics.ResolveShardFailed).Inc()
+			logger.Log().Warnf("resolve shard of %v URLs sent to %v failed: %v", len(shards[i]), r.endpoint, err)
+			for _, u := range shards[i] {
+				merged[u] = map[string]interface{}{
+					"error": map[string]interface{}{"name": ResolveErrorShardFailed, "text": err.Error()},
+				}
+			}
+			continue
+		}
+		metrics.ProxyResolveShards.WithLabelValues(r.endpoint, metrics.ResolveShardSucceeded).Inc()
+		for k, v := range result {
+			merged[k] = v
+		}
+	}
+
+	if failed == len(shards) {
+		if results[0].err != nil {
+			return nil, true, results[0].err
+		}
+		return results[0].res, true, nil
+	}
+	res := q.newResponse()
+	res.Result = merged
+	return res, failed > 0, nil
+}
+
+// sendResolveShard resolves urls out of the resolve query q on the SDK server at endpoint,
+// waiting while maxInFlight shards are already sent to it.
+func (c *Caller) sendResolveShard(q *Query, endpoint string, urls []string, maxInFlight int) (*jsonrpc.RPCResponse, error) {
+	if maxInFlight > 0 {
+		var done <-chan struct{}
+		if c.Context != nil {
+			done = c.Context.Done()
+		}
+		slots := getShardSlots(endpoint, maxInFlight)
+		select {
+		case slots <- struct{}{}:
+		case <-done:
+			return nil, errors.Prefix("request is done", c.Context.Err())
+		}
+		defer func() { <-slots }()
+	}
+
+	params := q.CopyParamsAsMap()
+	params[ParamUrls] = urls
+	sq := &Query{
+		Request: &jsonrpc.RPCRequest{
+			JSONRPC: q.Request.JSONRPC,
+			ID:      q.Request.ID,
+			Method:  q.Method(),
+			Params:  params,
+		},
+		WalletID: q.WalletID,
+	}
+
+	sc := NewCaller(endpoint, c.userID)
+	sc.preflightHooks, sc.postflightHooks = nil, c.postflightHooks
+	sc.Retry = c.Retry
+	sc.Deadline = c.Deadline
+	sc.Context = c.Context
+	return sc.SendQuery(sq)
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// resolveServer resolves every URL to a claim naming it, or fails all calls with a server error if broken is set.
+func resolveServer(t *testing.T, broken bool) (*httptest.Server, *int32) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if broken {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		var req jsonrpc.RPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		params := req.Params.(map[string]interface{})
+		result := map[string]interface{}{}
+		for _, u := range params[ParamUrls].([]interface{}) {
+			result[u.(string)] = map[string]interface{}{"name": u}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jsonrpc.RPCResponse{JSONRPC: "2.0", Result: result})
+	}))
+	return srv, &calls
+}
+
+func resolveRequest(n int) *jsonrpc.RPCRequest {
+	urls := []interface{}{}
+	for i := 0; i < n; i++ {
+		urls = append(urls, fmt.Sprintf("lbry://url%v", i))
+	}
+	return jsonrpc.NewRequest(MethodResolve, map[string]interface{}{ParamUrls: urls})
+}
+
+func TestCaller_ResolveSharded(t *testing.T) {
+	config.Override("ResolveSharding", map[string]interface{}{"ShardSize": 5, "MaxShardsPerServer": 1})
+	defer config.RestoreOverridden()
+
+	srv1, calls1 := resolveServer(t, false)
+	defer srv1.Close()
+	srv2, calls2 := resolveServer(t, false)
+	defer srv2.Close()
+
+	c := NewCaller(srv1.URL, 0)
+	c.ResolveServers = func() []string { return []string{srv1.URL, srv2.URL} }
+	res, err := c.Call(resolveRequest(12))
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+
+	result := res.Result.(map[string]interface{})
+	assert.Len(t, result, 12)
+	assert.Equal(t, "lbry://url11", result["lbry://url11"].(map[string]interface{})["name"])
+	assert.EqualValues(t, 2, atomic.LoadInt32(calls1))
+	assert.EqualValues(t, 1, atomic.LoadInt32(calls2))
+}
+
+func TestCaller_ResolveShardedPartialFailure(t *testing.T) {
+	config.Override("ResolveSharding", map[string]interface{}{"ShardSize": 5, "MaxShardsPerServer": 2})
+	defer config.RestoreOverridden()
+
+	srv, _ := resolveServer(t, false)
+	defer srv.Close()
+	broken, _ := resolveServer(t, true)
+	defer broken.Close()
+
+	c := NewCaller(srv.URL, 0)
+	c.Retry = RetryPolicy{MaxAttempts: 1}
+	c.ResolveServers = func() []string { return []string{srv.URL, broken.URL} }
+	res, err := c.Call(resolveRequest(10))
+	require.NoError(t, err)
+	require.Nil(t, res.Error)
+
+	result := res.Result.(map[string]interface{})
+	assert.Len(t, result, 10)
+	assert.Equal(t, "lbry://url0", result["lbry://url0"].(map[string]interface{})["name"])
+	failed := result["lbry://url7"].(map[string]interface{})["error"].(map[string]interface{})
+	assert.Equal(t, ResolveErrorShardFailed, failed["name"])
+}
+
+func TestCaller_ResolveNotShardedForUsers(t *testing.T) {
+	config.Override("ResolveSharding", map[string]interface{}{"ShardSize": 5, "MaxShardsPerServer": 1})
+	defer config.RestoreOverridden()
+
+	srv, calls := resolveServer(t, false)
+	defer srv.Close()
+
+	c := NewCaller(srv.URL, 0)
+	assert.False(t, c.shardsResolve(&Query{Request: resolveRequest(12)}))
+	c.ResolveServers = func() []string { return []string{srv.URL} }
+	assert.True(t, c.shardsResolve(&Query{Request: resolveRequest(12)}))
+	assert.False(t, c.shardsResolve(&Query{Request: resolveRequest(5)}))
+	assert.EqualValues(t, 0, atomic.LoadInt32(calls))
+
+	c = NewCaller(srv.URL, 123)
+	c.ResolveServers = func() []string { return []string{srv.URL} }
+	assert.False(t, c.shardsResolve(&Query{Request: resolveRequest(12)}))
+}
@@ -0,0 +1,52 @@
+package recommendations
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+// maxCount caps the number of recommendations a client can ask for.
+const maxCount = 50
+
+type response struct {
+	ClaimID string           `json:"claim_id"`
+	Items   []Recommendation `json:"items"`
+}
+
+// HandleGet returns claims related to the one with claim_id from the URL. The number of them
+// can be set with the count query param.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	e := Current()
+	if e == nil {
+		responses.WriteError(w, http.StatusServiceUnavailable, errors.Err("recommendations are not available"))
+		return
+	}
+	count := e.Count
+	if c := r.URL.Query().Get("count"); c != "" {
+		n, err := strconv.Atoi(c)
+		if err != nil || n < 1 || n > maxCount {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("count must be between 1 and %v", maxCount))
+			return
+		}
+		count = n
+	}
+
+	claimID := mux.Vars(r)["claim_id"]
+	recs, err := e.Related(claimID, count)
+	if errors.Is(err, ErrNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.Log().Errorf("error getting recommendations: %v", err)
+		responses.WriteError(w, http.StatusBadGateway, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, response{ClaimID: claimID, Items: recs})
+}
@@ -0,0 +1,89 @@
+package recommendations
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	hookName = "recommendations"
+
+	// annotationField lists IDs of related claims in resolved claims.
+	annotationField = "recommended_claim_ids"
+)
+
+// InstallHooks makes c index claims from resolve and claim_search responses. With Annotate set,
+// resolved claims get IDs of related ones added, if the engine can tell them without asking the external service.
+func InstallHooks(c *query.Caller) {
+	c.AddPostflightHook(query.MethodResolve, indexResolve, hookName)
+	c.AddPostflightHook(query.MethodClaimSearch, indexClaimSearch, hookName)
+}
+
+func indexResolve(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	e := Current()
+	if e == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for _, v := range result {
+		if claim, ok := v.(map[string]interface{}); ok && claim["error"] == nil {
+			e.Add(claim)
+		}
+	}
+	if !e.Annotate {
+		return nil, nil
+	}
+	for _, v := range result {
+		claim, ok := v.(map[string]interface{})
+		if !ok || claim["value_type"] != "stream" {
+			continue
+		}
+		id, _ := claim["claim_id"].(string)
+		if ids := e.annotation(id); ids != nil {
+			claim[annotationField] = ids
+		}
+	}
+	return nil, nil
+}
+
+func indexClaimSearch(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	e := Current()
+	if e == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	items, _ := result["items"].([]interface{})
+	for _, i := range items {
+		if claim, ok := i.(map[string]interface{}); ok {
+			e.Add(claim)
+		}
+	}
+	return nil, nil
+}
+
+// annotation returns IDs of claims related to claimID. Resolve shouldn't wait for the external service,
+// so with one set only cached results are used.
+func (e *Engine) annotation(claimID string) []string {
+	recs, ok := e.cached(claimID, e.Count)
+	if !ok {
+		if e.Service != nil {
+			return nil
+		}
+		var err error
+		if recs, err = e.Related(claimID, e.Count); err != nil {
+			return nil
+		}
+	}
+	ids := []string{}
+	for _, r := range recs {
+		ids = append(ids, r.ClaimID)
+	}
+	return ids
+}
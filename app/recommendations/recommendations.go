@@ -0,0 +1,382 @@
+// Package recommendations computes claims related to a given one.
+//
+// Stream claims seen in resolve and claim_search responses proxied through lbrytv are kept in an index
+// (see InstallHooks), and claims sharing tags or the channel with the requested one are ranked by similarity.
+// An external recommendation service can be asked instead, the index is used when it fails.
+// Results are cached for a while, as they are requested for every content page view.
+package recommendations
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/ybbus/jsonrpc"
+)
+
+// ErrNotFound is returned for claims which are neither indexed nor could be looked up.
+var ErrNotFound = errors.Base("claim not found")
+
+var (
+	logger = monitor.NewModuleLogger("recommendations")
+
+	current *Engine
+)
+
+// Claim is an indexed stream claim.
+type Claim struct {
+	ClaimID   string
+	ChannelID string
+	Tags      []string
+	// Output is the claim as returned by the SDK, served along with recommendations.
+	Output map[string]interface{}
+	SeenAt time.Time
+}
+
+// Recommendation is a claim related to the requested one, higher Score meaning more related.
+type Recommendation struct {
+	ClaimID string                 `json:"claim_id"`
+	Score   float64                `json:"score"`
+	Claim   map[string]interface{} `json:"claim,omitempty"`
+}
+
+// Service asks an external recommendation service for count claims related to claimID.
+type Service func(claimID string, count int) ([]Recommendation, error)
+
+// Lookup fetches a claim missing from the index, returning nil if it doesn't exist.
+type Lookup func(claimID string) (map[string]interface{}, error)
+
+// Engine indexes claims for up to TTL and computes recommendations from them.
+type Engine struct {
+	TTL time.Duration
+	// Size caps the number of indexed claims, the least recently seen one is dropped to make room for a new one.
+	Size int
+	// Count is the number of recommendations returned when not specified.
+	Count int
+	// ChannelWeight is added to the similarity of claims from the same channel.
+	ChannelWeight float64
+	// Annotate makes resolve responses list IDs of claims related to resolved ones, see InstallHooks.
+	Annotate bool
+	Service  Service
+	Lookup   Lookup
+
+	mu     sync.RWMutex
+	claims map[string]*Claim
+	// lookup maps tags and channel IDs to IDs of claims having them.
+	lookup  map[string]map[string]bool
+	results *gocache.Cache
+}
+
+// NewEngine creates a recommendation engine caching computed results for resultTTL.
+func NewEngine(ttl time.Duration, size int, resultTTL time.Duration) *Engine {
+	return &Engine{
+		TTL:     ttl,
+		Size:    size,
+		Count:   10,
+		claims:  map[string]*Claim{},
+		lookup:  map[string]map[string]bool{},
+		results: gocache.New(resultTTL, 10*time.Minute),
+	}
+}
+
+// SetEngine sets the engine used by HTTP handlers and proxy hooks.
+func SetEngine(e *Engine) {
+	current = e
+}
+
+// Current returns the engine set by SetEngine, nil if recommendations are not set up.
+func Current() *Engine {
+	return current
+}
+
+func tagKey(tag string) string {
+	return "tag:" + strings.ToLower(tag)
+}
+
+func channelKey(id string) string {
+	return "channel:" + id
+}
+
+func keys(c *Claim) []string {
+	k := []string{}
+	for _, t := range c.Tags {
+		k = append(k, tagKey(t))
+	}
+	if c.ChannelID != "" {
+		k = append(k, channelKey(c.ChannelID))
+	}
+	return k
+}
+
+// claimFromOutput picks what's needed for computing recommendations from a claim output,
+// returning nil for anything but streams.
+func claimFromOutput(o map[string]interface{}) *Claim {
+	if o == nil || o["value_type"] != "stream" {
+		return nil
+	}
+	c := &Claim{Output: map[string]interface{}{}}
+	c.ClaimID, _ = o["claim_id"].(string)
+	if c.ClaimID == "" {
+		return nil
+	}
+	// The output is copied without annotations as responses holding it can be modified by later hooks
+	for k, v := range o {
+		if k != annotationField {
+			c.Output[k] = v
+		}
+	}
+	if ch, ok := o["signing_channel"].(map[string]interface{}); ok {
+		c.ChannelID, _ = ch["claim_id"].(string)
+	}
+	value, _ := o["value"].(map[string]interface{})
+	tags, _ := value["tags"].([]interface{})
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if s, ok := t.(string); ok && s != "" && !seen[strings.ToLower(s)] {
+			seen[strings.ToLower(s)] = true
+			c.Tags = append(c.Tags, strings.ToLower(s))
+		}
+	}
+	return c
+}
+
+// Add indexes a stream claim output, other claims are ignored.
+func (e *Engine) Add(output map[string]interface{}) {
+	c := claimFromOutput(output)
+	if c == nil {
+		return
+	}
+	c.SeenAt = time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.claims[c.ClaimID]; ok {
+		e.remove(c.ClaimID)
+	} else if e.Size > 0 && len(e.claims) >= e.Size {
+		var oldest *Claim
+		for _, i := range e.claims {
+			if oldest == nil || i.SeenAt.Before(oldest.SeenAt) {
+				oldest = i
+			}
+		}
+		e.remove(oldest.ClaimID)
+	}
+	e.claims[c.ClaimID] = c
+	for _, k := range keys(c) {
+		if e.lookup[k] == nil {
+			e.lookup[k] = map[string]bool{}
+		}
+		e.lookup[k][c.ClaimID] = true
+	}
+}
+
+// remove must be called with the write lock held.
+func (e *Engine) remove(claimID string) {
+	c, ok := e.claims[claimID]
+	if !ok {
+		return
+	}
+	delete(e.claims, claimID)
+	for _, k := range keys(c) {
+		delete(e.lookup[k], claimID)
+		if len(e.lookup[k]) == 0 {
+			delete(e.lookup, k)
+		}
+	}
+}
+
+// Len returns the number of indexed claims.
+func (e *Engine) Len() int {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return len(e.claims)
+}
+
+func (e *Engine) get(claimID string) *Claim {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	c, ok := e.claims[claimID]
+	if !ok || time.Since(c.SeenAt) > e.TTL {
+		return nil
+	}
+	return c
+}
+
+func resultKey(claimID string, count int) string {
+	return fmt.Sprintf("%v:%v", claimID, count)
+}
+
+// cached returns recommendations computed before, if they haven't expired yet.
+func (e *Engine) cached(claimID string, count int) ([]Recommendation, bool) {
+	if r, ok := e.results.Get(resultKey(claimID, count)); ok {
+		return r.([]Recommendation), true
+	}
+	return nil, false
+}
+
+// Related returns up to count claims related to claimID, most related first.
+// The external service is asked first when set, and the index when it fails.
+func (e *Engine) Related(claimID string, count int) ([]Recommendation, error) {
+	if recs, ok := e.cached(claimID, count); ok {
+		metrics.Recommendations.WithLabelValues(metrics.RecommendationsCached).Inc()
+		return recs, nil
+	}
+	var recs []Recommendation
+	if e.Service != nil {
+		var err error
+		recs, err = e.Service(claimID, count)
+		if err != nil {
+			logger.Log().Warnf("recommendation service failed for claim %v: %v", claimID, err)
+			metrics.Recommendations.WithLabelValues(metrics.RecommendationsServiceFailed).Inc()
+			recs = nil
+		} else {
+			for i := range recs {
+				if c := e.get(recs[i].ClaimID); c != nil {
+					recs[i].Claim = c.Output
+				}
+			}
+			metrics.Recommendations.WithLabelValues(metrics.RecommendationsService).Inc()
+		}
+	}
+	if recs == nil {
+		var err error
+		recs, err = e.computed(claimID, count)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Recommendations.WithLabelValues(metrics.RecommendationsComputed).Inc()
+	}
+	e.results.SetDefault(resultKey(claimID, count), recs)
+	return recs, nil
+}
+
+// computed ranks indexed claims sharing tags or the channel with claimID.
+func (e *Engine) computed(claimID string, count int) ([]Recommendation, error) {
+	c := e.get(claimID)
+	if c == nil && e.Lookup != nil {
+		output, err := e.Lookup(claimID)
+		if err != nil {
+			return nil, err
+		}
+		e.Add(output)
+		c = e.get(claimID)
+	}
+	if c == nil {
+		return nil, ErrNotFound
+	}
+
+	e.mu.RLock()
+	recs := []Recommendation{}
+	seen := map[string]bool{claimID: true}
+	for _, k := range keys(c) {
+		for id := range e.lookup[k] {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			other := e.claims[id]
+			if time.Since(other.SeenAt) > e.TTL {
+				continue
+			}
+			if score := e.similarity(c, other); score > 0 {
+				recs = append(recs, Recommendation{ClaimID: id, Score: score, Claim: other.Output})
+			}
+		}
+	}
+	e.mu.RUnlock()
+
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].ClaimID < recs[j].ClaimID
+	})
+	if len(recs) > count {
+		recs = recs[:count]
+	}
+	return recs, nil
+}
+
+// similarity is the Jaccard index of the claims' tags, plus ChannelWeight if they are in the same channel.
+func (e *Engine) similarity(a, b *Claim) float64 {
+	score := 0.0
+	if len(a.Tags) > 0 && len(b.Tags) > 0 {
+		common := 0
+		for _, t := range a.Tags {
+			for _, u := range b.Tags {
+				if t == u {
+					common++
+					break
+				}
+			}
+		}
+		score = float64(common) / float64(len(a.Tags)+len(b.Tags)-common)
+	}
+	if a.ChannelID != "" && a.ChannelID == b.ChannelID {
+		score += e.ChannelWeight
+	}
+	return score
+}
+
+// HTTPService returns a Service getting recommendations from serviceURL, which is called with claim_id
+// and count query params and responds with {"items": [{"claim_id": "...", "score": 0.9}]}.
+func HTTPService(serviceURL string, timeout time.Duration) Service {
+	client := &http.Client{Timeout: timeout}
+	return func(claimID string, count int) ([]Recommendation, error) {
+		q := url.Values{"claim_id": {claimID}, "count": {fmt.Sprintf("%d", count)}}
+		res, err := client.Get(serviceURL + "?" + q.Encode())
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.Err("recommendation service responded with %v", res.Status)
+		}
+		var parsed struct {
+			Items []Recommendation `json:"items"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+			return nil, errors.Prefix("unexpected recommendation service response", err)
+		}
+		if len(parsed.Items) > count {
+			parsed.Items = parsed.Items[:count]
+		}
+		return parsed.Items, nil
+	}
+}
+
+// SDKLookup returns a Lookup fetching claims with claim_search on a random SDK node.
+func SDKLookup(rt *sdkrouter.Router) Lookup {
+	return func(claimID string) (map[string]interface{}, error) {
+		c := query.NewCaller(rt.RandomServer().Address, 0)
+		res, err := c.Call(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{
+			"claim_ids": []string{claimID},
+			"page_size": 1,
+			"no_totals": true,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		if res.Error != nil {
+			return nil, errors.Err(res.Error.Message)
+		}
+		result, _ := res.Result.(map[string]interface{})
+		items, _ := result["items"].([]interface{})
+		if len(items) == 0 {
+			return nil, nil
+		}
+		claim, _ := items[0].(map[string]interface{})
+		return claim, nil
+	}
+}
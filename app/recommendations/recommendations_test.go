@@ -0,0 +1,189 @@
+package recommendations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	videoID   = "b3f7a2c1d9e8f6a5b4c3d2e1f0a9b8c7d6e5f4a3"
+	sameTags  = "c4a8b3d2e0f9a7b6c5d4e3f2a1b0c9d8e7f6a5b4"
+	sameChan  = "d5b9c4e3f1a0b8c7d6e5f4a3b2c1d0e9f8a7b6c5"
+	unrelated = "e6c0d5f4a2b1c9d8e7f6a5b4c3d2e1f0a9b8c7d6"
+	channelID = "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"
+)
+
+func stream(id, channel string, tags ...string) map[string]interface{} {
+	t := []interface{}{}
+	for _, tag := range tags {
+		t = append(t, tag)
+	}
+	s := map[string]interface{}{
+		"claim_id":   id,
+		"value_type": "stream",
+		"value":      map[string]interface{}{"tags": t},
+	}
+	if channel != "" {
+		s["signing_channel"] = map[string]interface{}{"claim_id": channel}
+	}
+	return s
+}
+
+func hookContext(t *testing.T, method string, res *jsonrpc.RPCResponse) *query.HookContext {
+	q, err := query.NewQuery(jsonrpc.NewRequest(method, map[string]interface{}{}), "")
+	require.NoError(t, err)
+	return &query.HookContext{Query: q, Response: res}
+}
+
+func setupEngine() *Engine {
+	e := NewEngine(time.Minute, 10, time.Minute)
+	e.ChannelWeight = 0.5
+	e.Add(stream(videoID, channelID, "Gaming", "speedrun", "retro"))
+	e.Add(stream(sameTags, "", "gaming", "retro"))
+	e.Add(stream(sameChan, channelID, "cooking"))
+	e.Add(stream(unrelated, "", "music"))
+	e.Add(map[string]interface{}{"claim_id": channelID, "value_type": "channel"})
+	return e
+}
+
+func TestEngine_Related(t *testing.T) {
+	e := setupEngine()
+	assert.Equal(t, 4, e.Len())
+
+	recs, err := e.Related(videoID, 10)
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, sameTags, recs[0].ClaimID)
+	assert.InDelta(t, 2.0/3.0, recs[0].Score, 0.001)
+	assert.Equal(t, sameChan, recs[1].ClaimID)
+	assert.InDelta(t, 0.5, recs[1].Score, 0.001)
+	assert.Equal(t, sameTags, recs[0].Claim["claim_id"])
+
+	recs, err = e.Related(videoID, 1)
+	require.NoError(t, err)
+	assert.Len(t, recs, 1)
+
+	_, err = e.Related("f7d1e6a5b3c2d0e9f8a7b6c5d4e3f2a1b0c9d8e7", 10)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestEngine_Lookup(t *testing.T) {
+	e := setupEngine()
+	missing := "f7d1e6a5b3c2d0e9f8a7b6c5d4e3f2a1b0c9d8e7"
+	e.Lookup = func(claimID string) (map[string]interface{}, error) {
+		return stream(claimID, "", "music"), nil
+	}
+	recs, err := e.Related(missing, 10)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, unrelated, recs[0].ClaimID)
+	assert.Equal(t, 5, e.Len())
+}
+
+func TestEngine_Eviction(t *testing.T) {
+	e := NewEngine(time.Minute, 2, time.Minute)
+	e.Add(stream(videoID, "", "gaming"))
+	time.Sleep(time.Millisecond)
+	e.Add(stream(sameTags, "", "gaming"))
+	time.Sleep(time.Millisecond)
+	e.Add(stream(unrelated, "", "gaming"))
+	assert.Equal(t, 2, e.Len())
+	assert.Nil(t, e.get(videoID))
+
+	e = NewEngine(time.Millisecond, 10, time.Minute)
+	e.Add(stream(videoID, "", "gaming"))
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, e.get(videoID))
+}
+
+func TestEngine_Service(t *testing.T) {
+	e := setupEngine()
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Query().Get("claim_id") != videoID {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"items": [{"claim_id": "` + unrelated + `", "score": 0.9}]}`))
+	}))
+	defer ts.Close()
+	e.Service = HTTPService(ts.URL, time.Second)
+
+	recs, err := e.Related(videoID, 5)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, unrelated, recs[0].ClaimID)
+	assert.Equal(t, unrelated, recs[0].Claim["claim_id"])
+
+	// Results are cached
+	_, err = e.Related(videoID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+
+	// The index is used when the service fails
+	recs, err = e.Related(sameTags, 5)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, videoID, recs[0].ClaimID)
+}
+
+func TestHooks(t *testing.T) {
+	e := NewEngine(time.Minute, 10, time.Minute)
+	e.Annotate = true
+	SetEngine(e)
+	defer SetEngine(nil)
+
+	_, err := indexClaimSearch(nil, hookContext(t, query.MethodClaimSearch, &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"items": []interface{}{stream(sameTags, "", "gaming"), stream(unrelated, "", "music")},
+	}}))
+	require.NoError(t, err)
+	assert.Equal(t, 2, e.Len())
+
+	result := map[string]interface{}{
+		"lbry://video":   stream(videoID, "", "gaming"),
+		"lbry://missing": map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND"}},
+	}
+	_, err = indexResolve(nil, hookContext(t, query.MethodResolve, &jsonrpc.RPCResponse{Result: result}))
+	require.NoError(t, err)
+	assert.Equal(t, 3, e.Len())
+	assert.Equal(t, []string{sameTags}, result["lbry://video"].(map[string]interface{})[annotationField])
+	assert.Nil(t, result["lbry://missing"].(map[string]interface{})[annotationField])
+	// Annotations don't end up in indexed claims
+	assert.Nil(t, e.get(videoID).Output[annotationField])
+}
+
+func TestHandleGet(t *testing.T) {
+	call := func(claimID, count string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/recommendations/"+claimID+"?count="+count, nil)
+		rr := httptest.NewRecorder()
+		HandleGet(rr, mux.SetURLVars(r, map[string]string{"claim_id": claimID}))
+		return rr
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, call(videoID, "").Code)
+
+	SetEngine(setupEngine())
+	defer SetEngine(nil)
+	rr := call(videoID, "1")
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var res response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+	assert.Equal(t, videoID, res.ClaimID)
+	require.Len(t, res.Items, 1)
+	assert.Equal(t, sameTags, res.Items[0].ClaimID)
+
+	assert.Equal(t, http.StatusBadRequest, call(videoID, "100").Code)
+	assert.Equal(t, http.StatusNotFound, call("f7d1e6a5b3c2d0e9f8a7b6c5d4e3f2a1b0c9d8e7", "").Code)
+}
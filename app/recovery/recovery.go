@@ -0,0 +1,212 @@
+// Package recovery contains the per-endpoint panic handler, so a panic while serving one request
+// is answered with a JSON-RPC error and reported instead of taking down other in-flight requests.
+package recovery
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/rpcerrors"
+	"github.com/lbryio/lbrytv/app/usertrace"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+// RequestIDHeader is the header carrying request ID. It's taken from the request when supplied
+// by a load balancer and is generated otherwise.
+const RequestIDHeader = "X-Request-Id"
+
+// maxPayloadSize is how much of the request body is kept for crash reports.
+const maxPayloadSize = 16 * 1024
+
+var logger = monitor.NewModuleLogger("recovery")
+
+// CrashReport describes a panic that occurred while serving a request.
+type CrashReport struct {
+	RequestID string
+	Endpoint  string
+	Method    string
+	RPCMethod string
+	Panic     string
+	Stack     string
+	Payload   string
+}
+
+// Middleware recovers panics in downstream handlers, responds with a JSON-RPC internal error
+// carrying the request ID and files a crash report.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reqID := r.Header.Get(RequestIDHeader)
+		if reqID == "" {
+			reqID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, reqID)
+
+		// Keep the head of the body for the report, the handler still receives it in full.
+		var payload []byte
+		if r.Body != nil {
+			payload, _ = ioutil.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+			r.Body = readCloser{io.MultiReader(bytes.NewReader(payload), r.Body), r.Body}
+		}
+
+		ww := &writer{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			report := newCrashReport(r, reqID, p, payload)
+			file(report)
+
+			if ww.wroteHeader {
+				// Part of the response has already been sent, nothing sensible can be added to it.
+				return
+			}
+			responses.AddJSONContentType(w)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(errorResponse(reqID, payload))
+		}()
+
+		next.ServeHTTP(ww, r)
+	})
+}
+
+// Recover is for panics in goroutines serving a part of the request r, like a single call out of a batch,
+// which Middleware cannot catch. It files a crash report for panic p which occurred while handling payload
+// and returns the JSON-RPC error response to send for it.
+func Recover(r *http.Request, reqID string, p interface{}, payload []byte) []byte {
+	if reqID == "" {
+		reqID = newRequestID()
+	}
+	file(newCrashReport(r, reqID, p, payload))
+	return errorResponse(reqID, payload)
+}
+
+// errorResponse is the internal error response to the JSON-RPC call in payload.
+func errorResponse(reqID string, payload []byte) []byte {
+	var id int
+	if head, err := query.PreParse(payload); err == nil && head.ID != nil {
+		json.Unmarshal(head.ID, &id)
+	}
+	rpcErr := rpcerrors.NewInternalError(fmt.Errorf("internal server error, request id %v", reqID))
+	rsp, _ := json.Marshal(jsonrpc.RPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &jsonrpc.RPCError{Code: rpcErr.Code(), Message: rpcErr.Error()},
+	})
+	return rsp
+}
+
+func newCrashReport(r *http.Request, reqID string, p interface{}, payload []byte) CrashReport {
+	report := CrashReport{
+		RequestID: reqID,
+		Endpoint:  r.URL.Path,
+		Method:    r.Method,
+		Panic:     fmt.Sprintf("%v", p),
+		Stack:     string(debug.Stack()),
+		Payload:   sanitizePayload(payload),
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			report.Endpoint = tpl
+		}
+	}
+	if head, err := query.PreParse(payload); err == nil {
+		report.RPCMethod = string(head.Method)
+	}
+	return report
+}
+
+// sanitizePayload masks sensitive values in JSON payloads. Other payloads (like file uploads)
+// are not included, only their size is.
+func sanitizePayload(payload []byte) string {
+	if len(payload) == 0 {
+		return ""
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return fmt.Sprintf("[%v bytes of non-JSON payload]", len(payload))
+	}
+	b, err := json.Marshal(usertrace.Sanitize(decoded))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func file(report CrashReport) {
+	metrics.RecoveredPanics.WithLabelValues(report.Endpoint).Inc()
+	logger.WithFields(logrus.Fields{
+		"request_id": report.RequestID,
+		"endpoint":   report.Endpoint,
+		"method":     report.Method,
+		"rpc_method": report.RPCMethod,
+		"payload":    report.Payload,
+	}).Errorf("recovered panic: %v, trace: %s", report.Panic, report.Stack)
+
+	monitor.ErrorToSentry(fmt.Errorf("recovered panic: %v", report.Panic), map[string]string{
+		"request_id": report.RequestID,
+		"endpoint":   report.Endpoint,
+		"method":     report.Method,
+		"rpc_method": report.RPCMethod,
+		"payload":    report.Payload,
+		"stack":      report.Stack,
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// writer tracks whether the response has been started so a partially written response is not appended to.
+type writer struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *writer) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Hijack lets WebSocket handlers take over the connection.
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.Err("response writer does not support hijacking")
+	}
+	w.wroteHeader = true
+	return hj.Hijack()
+}
@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestMiddleware_RecoversPanic(t *testing.T) {
+	var handlerBody string
+	r := mux.NewRouter()
+	r.Use(Middleware)
+	r.HandleFunc("/api/v1/proxy", func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		handlerBody = string(b)
+		panic("something broke")
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "resolve", "params": {"urls": ["what"]}, "id": 1234}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/proxy", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	assert.NotPanics(t, func() { r.ServeHTTP(rr, req) })
+
+	assert.Equal(t, body, handlerBody)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	reqID := rr.Header().Get(RequestIDHeader)
+	require.NotEmpty(t, reqID)
+
+	var rsp jsonrpc.RPCResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+	require.NotNil(t, rsp.Error)
+	assert.Equal(t, -32080, rsp.Error.Code)
+	assert.Contains(t, rsp.Error.Message, reqID)
+	assert.EqualValues(t, 1234, rsp.ID)
+}
+
+func TestMiddleware_KeepsRequestID(t *testing.T) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "ok", rr.Body.String())
+	assert.Equal(t, "abc", rr.Header().Get(RequestIDHeader))
+}
+
+func TestMiddleware_PartialResponse(t *testing.T) {
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("halfway")
+	}))
+	rr := httptest.NewRecorder()
+	assert.NotPanics(t, func() { h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil)) })
+	assert.Equal(t, "partial", rr.Body.String())
+}
+
+func TestNewCrashReport(t *testing.T) {
+	body := `{"jsonrpc": "2.0", "method": "account_send", "params": {"password": "secret", "amount": "1.0"}, "id": 1}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/proxy", strings.NewReader(body))
+
+	report := newCrashReport(req, "abc", "boom", []byte(body))
+	assert.Equal(t, "abc", report.RequestID)
+	assert.Equal(t, "/api/v1/proxy", report.Endpoint)
+	assert.Equal(t, "account_send", report.RPCMethod)
+	assert.Equal(t, "boom", report.Panic)
+	assert.Contains(t, report.Stack, "recovery.newCrashReport")
+	assert.NotContains(t, report.Payload, "secret")
+	assert.Contains(t, report.Payload, `"amount":"1.0"`)
+}
+
+func TestSanitizePayload(t *testing.T) {
+	assert.Equal(t, "", sanitizePayload(nil))
+	assert.Equal(t, "[5 bytes of non-JSON payload]", sanitizePayload([]byte("-----")))
+}
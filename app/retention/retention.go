@@ -0,0 +1,152 @@
+// Package retention enforces how long records are kept in the database.
+//
+// Records older than the retention window of their table are soft-deleted first: they are hidden
+// from regular queries but can still be looked up in the database if something needs to be investigated.
+// Soft-deleted records are purged for good after an additional grace period.
+// Records of users and claims under legal hold are neither soft-deleted nor purged, see package legalhold.
+package retention
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/legalhold"
+	"github.com/lbryio/lbrytv/app/quarantine"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+var logger = monitor.NewModuleLogger("retention")
+
+// target describes how retention applies to a table.
+type target struct {
+	// timeColumn is the column the age of records is counted from.
+	timeColumn string
+	// condition limits the records retention applies to.
+	condition string
+	// fileColumn holds paths of files which are removed together with purged records.
+	fileColumn string
+	// userColumn and textColumn relate records to users and claims under legal hold, which are left alone.
+	userColumn string
+	textColumn string
+}
+
+var targets = map[string]target{
+	models.TableNames.QueryLog: {
+		timeColumn: models.QueryLogColumns.Timestamp,
+		userColumn: models.QueryLogColumns.UserID,
+		textColumn: models.QueryLogColumns.Body,
+	},
+	// Quarantined files awaiting review are kept regardless of their age
+	models.TableNames.QuarantinedFiles: {
+		timeColumn: models.QuarantinedFileColumns.ReviewedAt,
+		condition:  fmt.Sprintf(`"%s" <> '%s'`, models.QuarantinedFileColumns.Status, quarantine.StatusPending),
+		fileColumn: models.QuarantinedFileColumns.Path,
+		userColumn: models.QuarantinedFileColumns.UserID,
+	},
+}
+
+// Tables returns names of tables retention policies can be applied to.
+func Tables() []string {
+	tables := []string{}
+	for t := range targets {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Policy defines how long records in Table are kept.
+type Policy struct {
+	Table string
+	// DeleteAfter is the age at which records are soft-deleted.
+	DeleteAfter time.Duration
+	// PurgeAfter is how long soft-deleted records are kept before being removed.
+	PurgeAfter time.Duration
+}
+
+// Validate checks if the policy can be enforced.
+func (p Policy) Validate() error {
+	if _, ok := targets[p.Table]; !ok {
+		return errors.Err("retention is not supported for table %q, supported tables are %v", p.Table, Tables())
+	}
+	if p.DeleteAfter <= 0 {
+		return errors.Err("retention period must be positive")
+	}
+	if p.PurgeAfter < 0 {
+		return errors.Err("purge period cannot be negative")
+	}
+	return nil
+}
+
+// Enforce soft-deletes records in p.Table older than p.DeleteAfter and purges records
+// which were soft-deleted more than p.PurgeAfter ago.
+func Enforce(exec boil.Executor, p Policy) (deleted, purged int64, err error) {
+	if err := p.Validate(); err != nil {
+		return 0, 0, err
+	}
+	t := targets[p.Table]
+	now := time.Now().UTC()
+	log := logger.WithFields(logrus.Fields{"table": p.Table})
+
+	condition := " AND " + legalhold.Exclusion(p.Table, t.userColumn, t.textColumn)
+	if t.condition != "" {
+		condition += " AND " + t.condition
+	}
+	res, err := exec.Exec(
+		fmt.Sprintf(`UPDATE "%s" SET "deleted_at" = $1 WHERE "deleted_at" IS NULL AND "%s" < $2%s`, p.Table, t.timeColumn, condition),
+		now, now.Add(-p.DeleteAfter),
+	)
+	if err != nil {
+		return 0, 0, errors.Err(err)
+	}
+	deleted, _ = res.RowsAffected()
+	metrics.RetentionRecords.WithLabelValues(p.Table, metrics.RetentionSoftDeleted).Add(float64(deleted))
+
+	purged, err = purge(exec, p.Table, t, now.Add(-p.PurgeAfter), condition)
+	if err != nil {
+		return deleted, purged, err
+	}
+	metrics.RetentionRecords.WithLabelValues(p.Table, metrics.RetentionPurged).Add(float64(purged))
+
+	log.Infof("retention enforced: %v records soft-deleted, %v purged", deleted, purged)
+	return deleted, purged, nil
+}
+
+// purge removes records soft-deleted before cutoff. Condition is applied as well, so records placed
+// under legal hold after being soft-deleted are kept.
+func purge(exec boil.Executor, table string, t target, cutoff time.Time, condition string) (int64, error) {
+	if t.fileColumn == "" {
+		res, err := exec.Exec(fmt.Sprintf(`DELETE FROM "%s" WHERE "deleted_at" < $1%s`, table, condition), cutoff)
+		if err != nil {
+			return 0, errors.Err(err)
+		}
+		n, _ := res.RowsAffected()
+		return n, nil
+	}
+
+	rows, err := exec.Query(fmt.Sprintf(`DELETE FROM "%s" WHERE "deleted_at" < $1%s RETURNING "%s"`, table, condition, t.fileColumn), cutoff)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	defer rows.Close()
+	var n int64
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return n, errors.Err(err)
+		}
+		n++
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.WithFields(logrus.Fields{"table": table, "path": path}).Errorf("error removing file of purged record: %v", err)
+		}
+	}
+	return n, errors.Err(rows.Err())
+}
@@ -0,0 +1,144 @@
+package retention
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/legalhold"
+	"github.com/lbryio/lbrytv/app/quarantine"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestPolicy_Validate(t *testing.T) {
+	assert.NoError(t, Policy{Table: "query_log", DeleteAfter: time.Hour}.Validate())
+	assert.Error(t, Policy{Table: "users", DeleteAfter: time.Hour}.Validate())
+	assert.Error(t, Policy{Table: "query_log"}.Validate())
+	assert.Error(t, Policy{Table: "query_log", DeleteAfter: time.Hour, PurgeAfter: -time.Hour}.Validate())
+}
+
+func TestEnforce_QueryLog(t *testing.T) {
+	old := &models.QueryLog{Method: "wallet_send", RemoteIP: "8.8.8.8", Timestamp: time.Now().Add(-48 * time.Hour)}
+	recent := &models.QueryLog{Method: "wallet_send", RemoteIP: "8.8.8.8", Timestamp: time.Now()}
+	require.NoError(t, old.InsertG(boil.Infer()))
+	require.NoError(t, recent.InsertG(boil.Infer()))
+
+	p := Policy{Table: models.TableNames.QueryLog, DeleteAfter: 24 * time.Hour, PurgeAfter: time.Hour}
+	deleted, purged, err := Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+	assert.EqualValues(t, 0, purged)
+	require.NoError(t, old.ReloadG())
+	assert.True(t, old.DeletedAt.Valid)
+	require.NoError(t, recent.ReloadG())
+	assert.False(t, recent.DeletedAt.Valid)
+
+	old.DeletedAt = null.TimeFrom(time.Now().Add(-2 * time.Hour))
+	_, err = old.UpdateG(boil.Infer())
+	require.NoError(t, err)
+	deleted, purged, err = Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, deleted)
+	assert.EqualValues(t, 1, purged)
+	exists, err := models.QueryLogExistsG(old.ID)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestEnforce_QuarantinedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "retention")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "evidence")
+	require.NoError(t, ioutil.WriteFile(path, []byte("x"), 0600))
+
+	reviewed := &models.QuarantinedFile{
+		UserID: 1, FileName: "a.mp4", Path: path, Sha256: "x", Source: "scan", Reason: "infected",
+		Status: quarantine.StatusConfirmed, ReviewedAt: null.TimeFrom(time.Now().Add(-48 * time.Hour)),
+	}
+	pending := &models.QuarantinedFile{
+		UserID: 1, FileName: "b.mp4", Path: path, Sha256: "x", Source: "scan", Reason: "infected",
+		Status: quarantine.StatusPending,
+	}
+	require.NoError(t, reviewed.InsertG(boil.Infer()))
+	require.NoError(t, pending.InsertG(boil.Infer()))
+
+	p := Policy{Table: models.TableNames.QuarantinedFiles, DeleteAfter: 24 * time.Hour}
+	deleted, _, err := Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+	_, err = quarantine.Get(reviewed.ID)
+	assert.Equal(t, quarantine.ErrNotFound, err)
+	_, err = quarantine.Get(pending.ID)
+	assert.NoError(t, err)
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "file should be kept until the record is purged")
+
+	reviewed.DeletedAt = null.TimeFrom(time.Now().Add(-time.Minute))
+	_, err = reviewed.UpdateG(boil.Infer())
+	require.NoError(t, err)
+	_, purged, err := Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestEnforce_LegalHold(t *testing.T) {
+	claimID := "c6a8a3b5f0b0d2e1e4a1f87a7b3f4e6b9c5d1a2f"
+	byUser := &models.QueryLog{Method: "wallet_send", UserID: null.IntFrom(5511), Timestamp: time.Now().Add(-48 * time.Hour)}
+	byClaim := &models.QueryLog{
+		Method: "stream_update", Body: null.JSONFrom([]byte(`{"claim_id": "` + claimID + `"}`)), Timestamp: time.Now().Add(-48 * time.Hour),
+	}
+	require.NoError(t, byUser.InsertG(boil.Infer()))
+	require.NoError(t, byClaim.InsertG(boil.Infer()))
+
+	userHold, err := legalhold.Place(&legalhold.Hold{UserID: null.IntFrom(5511), Country: "de", Reference: "court order 1", PlacedBy: "legal"})
+	require.NoError(t, err)
+	claimHold, err := legalhold.Place(&legalhold.Hold{ClaimID: null.StringFrom(claimID), Country: "FR", Reference: "request 2", PlacedBy: "legal"})
+	require.NoError(t, err)
+
+	p := Policy{Table: models.TableNames.QueryLog, DeleteAfter: 24 * time.Hour}
+	_, _, err = Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	require.NoError(t, byUser.ReloadG())
+	assert.False(t, byUser.DeletedAt.Valid)
+	require.NoError(t, byClaim.ReloadG())
+	assert.False(t, byClaim.DeletedAt.Valid)
+
+	_, err = legalhold.Release(userHold.ID, "legal")
+	require.NoError(t, err)
+	_, err = legalhold.Release(claimHold.ID, "legal")
+	require.NoError(t, err)
+	_, _, err = Enforce(boil.GetDB(), p)
+	require.NoError(t, err)
+	require.NoError(t, byUser.ReloadG())
+	assert.True(t, byUser.DeletedAt.Valid)
+	require.NoError(t, byClaim.ReloadG())
+	assert.True(t, byClaim.DeletedAt.Valid)
+}
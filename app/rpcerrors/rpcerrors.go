@@ -19,15 +19,32 @@ const (
 	rpcErrorCodeJSONParse        int = -32700 // invalid JSON was received by the server
 	rpcErrorCodeInvalidParams    int = -32602 // error in params that the client provided
 	rpcErrorCodeMethodNotAllowed int = -32601 // the requested method is not allowed to be called
+	rpcErrorCodeResponseTooLarge int = -32086 // the response exceeds the size allowed for the method
+	rpcErrorCodeTimeout          int = -32087 // the call didn't complete within the latency budget of the method
+	rpcErrorCodeUnavailable      int = -32088 // a backing service the call depends on, like the DB, is unavailable
+	rpcErrorCodeQuotaExceeded    int = -32089 // the user has gone over an upload size or quota limit
+	rpcErrorCodeDuplicateUpload  int = -32090 // the user has already published the uploaded file
+	rpcErrorCodeBlockedContent   int = -32091 // the uploaded file was found to be malicious
+	rpcErrorCodeAuthThrottled    int = -32092 // authentication is refused for a while after repeated failures
+	rpcErrorCodeSDKUnavailable   int = -32093 // the SDK server the call goes to stopped responding, calls to it fail fast
+	rpcErrorCodeRiskDeclined     int = -32094 // the call was held for review or rejected by fraud scoring
 )
 
 type RPCError struct {
 	err  error
 	code int
+	data interface{}
 }
 
-func (e RPCError) Code() int     { return e.code }
-func (e RPCError) Unwrap() error { return e.err }
+func (e RPCError) Code() int         { return e.code }
+func (e RPCError) Unwrap() error     { return e.err }
+func (e RPCError) Data() interface{} { return e.data }
+
+// WithData returns a copy of the error carrying structured details, which are sent in the error's data field.
+func (e RPCError) WithData(data interface{}) RPCError {
+	e.data = data
+	return e
+}
 func (e RPCError) Error() string {
 	if e.err == nil {
 		return "no wrapped error"
@@ -40,6 +57,7 @@ func (e RPCError) JSON() []byte {
 		Error: &jsonrpc.RPCError{
 			Code:    e.Code(),
 			Message: e.Error(),
+			Data:    e.data,
 		},
 		JSONRPC: "2.0",
 	}, "", "  ")
@@ -51,7 +69,7 @@ func (e RPCError) JSON() []byte {
 
 var ErrAuthRequired = errors.Base(responses.AuthRequiredErrorMessage)
 
-func newRPCErr(e error, code int) RPCError { return RPCError{errors.Err(e), code} }
+func newRPCErr(e error, code int) RPCError { return RPCError{err: errors.Err(e), code: code} }
 
 func NewInternalError(e error) RPCError         { return newRPCErr(e, rpcErrorCodeInternal) }
 func NewJSONParseError(e error) RPCError        { return newRPCErr(e, rpcErrorCodeJSONParse) }
@@ -60,6 +78,17 @@ func NewInvalidParamsError(e error) RPCError    { return newRPCErr(e, rpcErrorCo
 func NewSDKError(e error) RPCError              { return newRPCErr(e, rpcErrorCodeSDK) }
 func NewForbiddenError(e error) RPCError        { return newRPCErr(e, rpcErrorCodeForbidden) }
 func NewAuthRequiredError() RPCError            { return newRPCErr(ErrAuthRequired, rpcErrorCodeAuthRequired) }
+func NewResponseTooLargeError(e error) RPCError { return newRPCErr(e, rpcErrorCodeResponseTooLarge) }
+func NewTimeoutError(e error) RPCError          { return newRPCErr(e, rpcErrorCodeTimeout) }
+func NewUnavailableError(e error) RPCError      { return newRPCErr(e, rpcErrorCodeUnavailable) }
+func NewQuotaExceededError(e error) RPCError    { return newRPCErr(e, rpcErrorCodeQuotaExceeded) }
+func NewDuplicateUploadError(e error) RPCError  { return newRPCErr(e, rpcErrorCodeDuplicateUpload) }
+func NewBlockedContentError(e error) RPCError   { return newRPCErr(e, rpcErrorCodeBlockedContent) }
+func NewAuthThrottledError(e error) RPCError    { return newRPCErr(e, rpcErrorCodeAuthThrottled) }
+func NewRiskDeclinedError(e error) RPCError     { return newRPCErr(e, rpcErrorCodeRiskDeclined) }
+func NewServiceUnavailableError(e error) RPCError {
+	return newRPCErr(e, rpcErrorCodeSDKUnavailable)
+}
 
 func isJSONParseError(err error) bool {
 	var e RPCError
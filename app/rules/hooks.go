@@ -0,0 +1,62 @@
+package rules
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "rules"
+
+// InstallHooks makes c apply current rules to its queries. Request rules are applied before the query
+// is looked up in the cache, so cached responses are keyed by transformed params.
+func InstallHooks(c *query.Caller) {
+	c.Preprocessor = transformRequest
+	c.AddPostflightHook(query.AllMethodsHook, transformResponse, hookName)
+}
+
+func transformRequest(q *query.Query) {
+	rules := forMethod(StageRequest, q.Method())
+	if len(rules) == 0 {
+		return
+	}
+	params := q.ParamsAsMap()
+	if params == nil {
+		if q.Params() != nil {
+			return
+		}
+		params = map[string]interface{}{}
+	}
+	changed := false
+	for _, r := range rules {
+		if r.Apply(params) {
+			changed = true
+		}
+	}
+	if changed {
+		q.Request.Params = params
+		metrics.TransformRulesApplied.WithLabelValues(q.Method(), StageRequest).Inc()
+	}
+}
+
+func transformResponse(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	rules := forMethod(StageResponse, hctx.Query.Method())
+	if len(rules) == 0 || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	changed := false
+	for _, r := range rules {
+		if r.Apply(result) {
+			changed = true
+		}
+	}
+	if changed {
+		metrics.TransformRulesApplied.WithLabelValues(hctx.Query.Method(), StageResponse).Inc()
+	}
+	return nil, nil
+}
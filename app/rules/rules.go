@@ -0,0 +1,199 @@
+// Package rules applies declarative transformations to proxied requests and responses,
+// so routine tweaks like default params or clamped page sizes don't need new hooks.
+//
+// Rules are read from a YAML file:
+//
+//	rules:
+//	  - method: claim_search
+//	    defaults: {page_size: 20}
+//	    rename: {name: normalized_name}
+//	    clamp: {page_size: {min: 1, max: 50}}
+//	  - method: resolve
+//	    stage: response
+//	    remove: [debug]
+//
+// Request rules apply to params, response rules to top-level fields of the result.
+// Within a rule, fields are renamed first, then removed, then defaults are set and finally values clamped.
+package rules
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	StageRequest  = "request"
+	StageResponse = "response"
+)
+
+var logger = monitor.NewModuleLogger("rules")
+
+// Rule transforms params of requests to Method, or results of its responses when Stage is response.
+type Rule struct {
+	Method   string                 `mapstructure:"method"`
+	Stage    string                 `mapstructure:"stage"`
+	Defaults map[string]interface{} `mapstructure:"defaults"`
+	Rename   map[string]string      `mapstructure:"rename"`
+	Clamp    map[string]Range       `mapstructure:"clamp"`
+	Remove   []string               `mapstructure:"remove"`
+}
+
+// Range limits a numeric value, either bound can be omitted.
+type Range struct {
+	Min *float64 `mapstructure:"min"`
+	Max *float64 `mapstructure:"max"`
+}
+
+// Set is a collection of rules grouped by stage and method.
+type Set map[string]map[string][]Rule
+
+var (
+	currentMu sync.RWMutex
+	current   = Set{}
+)
+
+// NewSet validates rules and groups them for lookup.
+func NewSet(rules []Rule) (Set, error) {
+	s := Set{StageRequest: {}, StageResponse: {}}
+	for i, r := range rules {
+		if r.Method == "" {
+			return nil, errors.Err("rule %v: method is required", i+1)
+		}
+		if r.Stage == "" {
+			r.Stage = StageRequest
+		}
+		if _, ok := s[r.Stage]; !ok {
+			return nil, errors.Err("rule %v: unknown stage %v", i+1, r.Stage)
+		}
+		for field, rng := range r.Clamp {
+			if rng.Min != nil && rng.Max != nil && *rng.Min > *rng.Max {
+				return nil, errors.Err("rule %v: min of %v is greater than max", i+1, field)
+			}
+		}
+		s[r.Stage][r.Method] = append(s[r.Stage][r.Method], r)
+	}
+	return s, nil
+}
+
+// ReadFile loads rules from a YAML file.
+func ReadFile(path string) (Set, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Err(err)
+	}
+	var rules []Rule
+	if err := v.UnmarshalKey("rules", &rules); err != nil {
+		return nil, errors.Err(err)
+	}
+	return NewSet(rules)
+}
+
+// SetCurrent replaces rules applied to queries.
+func SetCurrent(s Set) {
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	current = s
+}
+
+func forMethod(stage, method string) []Rule {
+	currentMu.RLock()
+	defer currentMu.RUnlock()
+	return current[stage][method]
+}
+
+// Load reads rules from path and starts applying them.
+func Load(path string) error {
+	s, err := ReadFile(path)
+	if err != nil {
+		return err
+	}
+	SetCurrent(s)
+	return nil
+}
+
+// Watch reloads rules every interval when the file has been modified. Broken files are reported
+// and the rules loaded before are kept.
+func Watch(path string, interval time.Duration) {
+	var modTime time.Time
+	if fi, err := os.Stat(path); err == nil {
+		modTime = fi.ModTime()
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Equal(modTime) {
+			continue
+		}
+		modTime = fi.ModTime()
+		if err := Load(path); err != nil {
+			logger.Log().Errorf("error reloading transformation rules: %v", err)
+			monitor.ErrorToSentry(err)
+		} else {
+			logger.Log().Info("transformation rules reloaded")
+		}
+	}
+}
+
+// Apply transforms fields of m according to r and returns true if anything changed.
+func (r Rule) Apply(m map[string]interface{}) bool {
+	changed := false
+	for from, to := range r.Rename {
+		if v, ok := m[from]; ok {
+			delete(m, from)
+			m[to] = v
+			changed = true
+		}
+	}
+	for _, f := range r.Remove {
+		if _, ok := m[f]; ok {
+			delete(m, f)
+			changed = true
+		}
+	}
+	for f, v := range r.Defaults {
+		if _, ok := m[f]; !ok {
+			m[f] = v
+			changed = true
+		}
+	}
+	for f, rng := range r.Clamp {
+		v, ok := m[f]
+		if !ok {
+			continue
+		}
+		if clamped, ok := rng.clamp(v); ok {
+			m[f] = clamped
+			changed = true
+		}
+	}
+	return changed
+}
+
+// clamp returns v limited to the range and true if v had to be changed. Non-numeric values are left as is.
+func (rng Range) clamp(v interface{}) (interface{}, bool) {
+	var n float64
+	switch typed := v.(type) {
+	case float64:
+		n = typed
+	case int:
+		n = float64(typed)
+	case int64:
+		n = float64(typed)
+	default:
+		return v, false
+	}
+	switch {
+	case rng.Min != nil && n < *rng.Min:
+		return *rng.Min, true
+	case rng.Max != nil && n > *rng.Max:
+		return *rng.Max, true
+	}
+	return v, false
+}
@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const rulesFile = `
+rules:
+  - method: claim_search
+    defaults: {page_size: 20, no_totals: true}
+    rename: {name: normalized_name}
+    clamp: {page_size: {min: 1, max: 50}}
+  - method: resolve
+    stage: response
+    remove: [debug]
+`
+
+func writeRules(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "rules")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "rules.yml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestNewSet_Invalid(t *testing.T) {
+	min, max := 10.0, 5.0
+	_, err := NewSet([]Rule{{Stage: StageRequest}})
+	assert.Error(t, err)
+	_, err = NewSet([]Rule{{Method: "resolve", Stage: "later"}})
+	assert.Error(t, err)
+	_, err = NewSet([]Rule{{Method: "resolve", Clamp: map[string]Range{"page_size": {Min: &min, Max: &max}}}})
+	assert.Error(t, err)
+}
+
+func TestTransformRequest(t *testing.T) {
+	path, cleanup := writeRules(t, rulesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch, map[string]interface{}{
+		"name": "what", "page_size": 500.0, "no_totals": false}), "")
+	require.NoError(t, err)
+	transformRequest(q)
+	assert.Equal(t, map[string]interface{}{"normalized_name": "what", "page_size": 50.0, "no_totals": false}, q.ParamsAsMap())
+
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodClaimSearch), "")
+	require.NoError(t, err)
+	transformRequest(q)
+	assert.EqualValues(t, 20, q.ParamsAsMap()["page_size"])
+	assert.Equal(t, true, q.ParamsAsMap()["no_totals"])
+
+	// Methods without rules are left alone
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "what", "page_size": 500.0}), "")
+	require.NoError(t, err)
+	transformRequest(q)
+	assert.Equal(t, map[string]interface{}{"urls": "what", "page_size": 500.0}, q.ParamsAsMap())
+}
+
+func TestTransformResponse(t *testing.T) {
+	path, cleanup := writeRules(t, rulesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "what"}), "")
+	require.NoError(t, err)
+	res := &jsonrpc.RPCResponse{Result: map[string]interface{}{"what": map[string]interface{}{}, "debug": "x"}}
+	r, err := transformResponse(nil, &query.HookContext{Query: q, Response: res})
+	require.NoError(t, err)
+	assert.Nil(t, r)
+	assert.Equal(t, map[string]interface{}{"what": map[string]interface{}{}}, res.Result)
+}
+
+func TestLoad_KeepsRulesOnError(t *testing.T) {
+	path, cleanup := writeRules(t, rulesFile)
+	defer cleanup()
+	require.NoError(t, Load(path))
+	defer SetCurrent(Set{})
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("rules:\n  - stage: response\n"), 0644))
+	assert.Error(t, Load(path))
+	assert.Len(t, forMethod(StageRequest, query.MethodClaimSearch), 1)
+}
@@ -0,0 +1,44 @@
+package sdkrouter
+
+import (
+	"net/http"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+// Handler serves admin endpoints draining servers of Router for maintenance.
+type Handler struct {
+	Router *Router
+}
+
+// HandleDrain drains the server named in the URL, so it gets no new users.
+func (h Handler) HandleDrain(w http.ResponseWriter, r *http.Request) {
+	h.setDraining(w, r, true)
+}
+
+// HandleUndrain puts the server named in the URL back into rotation.
+func (h Handler) HandleUndrain(w http.ResponseWriter, r *http.Request) {
+	h.setDraining(w, r, false)
+}
+
+func (h Handler) setDraining(w http.ResponseWriter, r *http.Request, draining bool) {
+	name := mux.Vars(r)["name"]
+	var err error
+	if draining {
+		err = h.Router.Drain(name)
+	} else {
+		err = h.Router.Undrain(name)
+	}
+	if errors.Is(err, ErrServerNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.Log().Error(err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, map[string]interface{}{"name": name, "draining": draining})
+}
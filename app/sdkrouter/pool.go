@@ -0,0 +1,197 @@
+package sdkrouter
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/models"
+
+	ljsonrpc "github.com/lbryio/lbry.go/v2/extras/jsonrpc"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+// Servers are health-checked by WatchLoad. New users are assigned to the least loaded server which is healthy,
+// anonymous calls are spread over healthy servers. Servers can be drained for maintenance: they keep serving users
+// already assigned to them but get no new work. Drains are kept in the DB so all instances honour them.
+
+// ErrServerNotFound is returned when draining a server the router doesn't know about.
+var ErrServerNotFound = errors.Base("no such lbrynet server")
+
+// statusTimeout is how long health checks wait for status, so a wedged server doesn't hold them up.
+const statusTimeout = 30 * time.Second
+
+// checkStatus calls status on the server at address and returns how long it took.
+func checkStatus(address string, maxLatency time.Duration) (time.Duration, error) {
+	timeout := statusTimeout
+	if 2*maxLatency > timeout {
+		timeout = 2 * maxLatency
+	}
+	c := jsonrpc.NewClientWithOpts(address, &jsonrpc.RPCClientOpts{HTTPClient: &http.Client{Timeout: timeout}})
+	start := time.Now()
+	res, err := c.Call("status")
+	latency := time.Since(start)
+	if err != nil {
+		return 0, err
+	}
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	return latency, nil
+}
+
+// walletCount returns the number of wallets loaded on the server at address.
+func walletCount(address string) (uint64, error) {
+	walletList, err := ljsonrpc.NewClient(address).WalletList("", 1, 1)
+	if err != nil {
+		return 0, err
+	}
+	return walletList.TotalPages, nil
+}
+
+// loadDrains returns names of drained servers, or nil if they can't be loaded from the DB.
+func (r *Router) loadDrains() map[string]bool {
+	r.mu.RLock()
+	fromDB := r.drainsFromDB
+	r.mu.RUnlock()
+	if !fromDB {
+		return nil
+	}
+	rows, err := boil.GetDB().Query(`SELECT name FROM lbrynet_server_drains`)
+	if err != nil {
+		logger.Log().Errorf("cannot load drained servers: %v", err)
+		return nil
+	}
+	defer rows.Close()
+	draining := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			logger.Log().Errorf("cannot load drained servers: %v", err)
+			return nil
+		}
+		draining[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		logger.Log().Errorf("cannot load drained servers: %v", err)
+		return nil
+	}
+	return draining
+}
+
+// Drain stops assigning new users and sending anonymous calls to the named server.
+func (r *Router) Drain(name string) error {
+	return r.setDraining(name, true)
+}
+
+// Undrain puts the named server back into rotation once it's healthy.
+func (r *Router) Undrain(name string) error {
+	return r.setDraining(name, false)
+}
+
+func (r *Router) setDraining(name string, draining bool) error {
+	servers := r.GetAll()
+	found := false
+	for _, s := range servers {
+		if s.Name == name {
+			found = true
+		}
+	}
+	if !found {
+		return errors.Prefix(name, ErrServerNotFound)
+	}
+
+	r.mu.RLock()
+	fromDB := r.drainsFromDB
+	r.mu.RUnlock()
+	if fromDB {
+		var err error
+		if draining {
+			_, err = boil.GetDB().Exec(`INSERT INTO lbrynet_server_drains (name) VALUES ($1) ON CONFLICT DO NOTHING`, name)
+		} else {
+			_, err = boil.GetDB().Exec(`DELETE FROM lbrynet_server_drains WHERE name = $1`, name)
+		}
+		if err != nil {
+			return errors.Err(err)
+		}
+	}
+
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+	if r.draining == nil {
+		r.draining = map[string]bool{}
+	}
+	if draining {
+		r.draining[name] = true
+		logger.Log().Infof("lbrynet server %v drained", name)
+	} else {
+		delete(r.draining, name)
+		logger.Log().Infof("lbrynet server %v undrained", name)
+	}
+	r.pickLeastLoaded(servers)
+	return nil
+}
+
+// isAvailable returns true if the server may get new work: it's not drained, and is healthy or hasn't been checked yet.
+// r.loadMu must be held.
+func (r *Router) isAvailable(s *models.LbrynetServer) bool {
+	if r.draining[s.Name] {
+		return false
+	}
+	status, ok := r.statuses[s.Address]
+	return !ok || status.Healthy
+}
+
+// available returns servers which may get new work.
+func (r *Router) available(servers []*models.LbrynetServer) []*models.LbrynetServer {
+	r.loadMu.RLock()
+	defer r.loadMu.RUnlock()
+	available := []*models.LbrynetServer{}
+	for _, s := range servers {
+		if r.isAvailable(s) {
+			available = append(available, s)
+		}
+	}
+	return available
+}
+
+// AvailableAddresses returns addresses of servers which may get new work, or of all servers if none may.
+func (r *Router) AvailableAddresses() []string {
+	servers := r.GetAll()
+	if available := r.available(servers); len(available) > 0 {
+		servers = available
+	}
+	addresses := make([]string, len(servers))
+	for i, s := range servers {
+		addresses[i] = s.Address
+	}
+	return addresses
+}
+
+// pickLeastLoaded picks the server new users are assigned to out of checked available servers.
+// r.loadMu must be held for writing.
+func (r *Router) pickLeastLoaded(servers []*models.LbrynetServer) {
+	var best *models.LbrynetServer
+	for _, s := range servers {
+		status, checked := r.statuses[s.Address]
+		available := checked && r.isAvailable(s)
+		if available {
+			metrics.LbrynetAvailable.WithLabelValues(s.Address).Set(1)
+		} else {
+			metrics.LbrynetAvailable.WithLabelValues(s.Address).Set(0)
+			continue
+		}
+		logger.Log().Debugf("load update: considering %s with load %d", s.Address, status.WalletsLoaded)
+		if best == nil || status.WalletsLoaded < r.statuses[best.Address].WalletsLoaded {
+			best = s
+		}
+	}
+	r.leastLoaded = best
+	if best != nil {
+		logger.Log().Infof("After updating load, least loaded server is %s", best.Address)
+	} else if len(r.statuses) > 0 {
+		logger.Log().Warn("no lbrynet server is available for new users")
+	}
+}
@@ -0,0 +1,122 @@
+package sdkrouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/test"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+func TestLeastLoaded_SkipsUnhealthyAndDrained(t *testing.T) {
+	r := New(map[string]string{"a": "http://a", "b": "http://b", "c": "http://c", "down": "http://down"})
+	r.loadMu.Lock()
+	r.statuses = map[string]ServerStatus{
+		"http://a":    {Name: "a", Responding: true, Healthy: true, WalletsLoaded: 5},
+		"http://b":    {Name: "b", Responding: true, Healthy: true, WalletsLoaded: 10},
+		"http://c":    {Name: "c", Responding: true, WalletsLoaded: 1},
+		"http://down": {Name: "down"},
+	}
+	r.pickLeastLoaded(r.GetAll())
+	r.loadMu.Unlock()
+	assert.Equal(t, "a", r.LeastLoaded().Name)
+
+	require.NoError(t, r.Drain("a"))
+	assert.Equal(t, "b", r.LeastLoaded().Name)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, "b", r.RandomServer().Name)
+	}
+	for _, s := range r.Statuses() {
+		assert.Equal(t, s.Name == "a", s.Draining, s.Name)
+	}
+
+	require.NoError(t, r.Undrain("a"))
+	assert.Equal(t, "a", r.LeastLoaded().Name)
+
+	assert.True(t, errors.Is(r.Drain("nonexistent"), ErrServerNotFound))
+}
+
+func TestLeastLoaded_NoneAvailable(t *testing.T) {
+	r := New(map[string]string{"a": "http://a"})
+	require.NoError(t, r.Drain("a"))
+	// All servers being drained is better than no server at all
+	assert.Equal(t, "a", r.LeastLoaded().Name)
+	assert.Equal(t, "a", r.RandomServer().Name)
+}
+
+func TestUpdateLoad_SlowServer(t *testing.T) {
+	config.Override("SDKPool", map[string]interface{}{"MaxLatency": 50 * time.Millisecond})
+	defer config.RestoreOverridden()
+
+	fast := test.MockHTTPServer(nil)
+	defer fast.Close()
+	slow := test.MockHTTPServer(nil)
+	defer slow.Close()
+	slowProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		slow.Server.Config.Handler.ServeHTTP(w, req)
+	}))
+	defer slowProxy.Close()
+
+	r := New(map[string]string{"fast": fast.URL, "slow": slowProxy.URL})
+	version := `{"result":{"lbrynet_version":"0.79.0"}}`
+	fast.QueueResponses(`{"result":{}}`, `{"result":{"total_pages":10}}`, version)
+	slow.QueueResponses(`{"result":{}}`, `{"result":{"total_pages":1}}`, version)
+	r.updateLoadAndMetrics()
+
+	assert.Equal(t, "fast", r.LeastLoaded().Name)
+	for _, s := range r.Statuses() {
+		assert.True(t, s.Responding, s.Name)
+		assert.Equal(t, s.Name == "fast", s.Healthy, s.Name)
+		if s.Name == "slow" {
+			assert.True(t, s.Latency >= 100*time.Millisecond)
+		}
+	}
+}
+
+func TestDrain_DB(t *testing.T) {
+	_, err := boil.GetDB().Exec(`TRUNCATE lbrynet_server_drains`)
+	require.NoError(t, err)
+
+	r := New(map[string]string{"a": "http://a", "b": "http://b"})
+	r.drainsFromDB = true
+	require.NoError(t, r.Drain("b"))
+
+	// Other instances pick up drains on their next check
+	other := New(map[string]string{"a": "http://a", "b": "http://b"})
+	other.drainsFromDB = true
+	assert.Equal(t, map[string]bool{"b": true}, other.loadDrains())
+
+	require.NoError(t, r.Undrain("b"))
+	assert.Equal(t, map[string]bool{}, other.loadDrains())
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler{Router: New(map[string]string{"a": "http://a"})}
+	router := mux.NewRouter()
+	router.HandleFunc("/sdk_servers/{name}/drain", h.HandleDrain).Methods(http.MethodPut)
+	router.HandleFunc("/sdk_servers/{name}/drain", h.HandleUndrain).Methods(http.MethodDelete)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/sdk_servers/a/drain", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"name": "a", "draining": true}`, rr.Body.String())
+	assert.True(t, h.Router.Statuses()[0].Draining)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/sdk_servers/a/drain", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, h.Router.Statuses()[0].Draining)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/sdk_servers/x/drain", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -3,11 +3,17 @@ package sdkrouter
 import (
 	"fmt"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/secrets"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
 	"github.com/lbryio/lbrytv/internal/metrics"
 	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/sdksign"
+	"github.com/lbryio/lbrytv/internal/sdktls"
 	"github.com/lbryio/lbrytv/models"
 
 	ljsonrpc "github.com/lbryio/lbry.go/v2/extras/jsonrpc"
@@ -25,9 +31,16 @@ type Router struct {
 
 	loadMu      sync.RWMutex
 	leastLoaded *models.LbrynetServer
+	statuses    map[string]ServerStatus
+	// draining holds names of servers drained for maintenance
+	draining map[string]bool
 
 	useDB      bool
 	lastLoaded time.Time
+	// secretsFromDB is set when signing secrets, which may have been rotated, can be loaded from the DB
+	secretsFromDB bool
+	// drainsFromDB is set when drained servers can be loaded from the DB
+	drainsFromDB bool
 }
 
 func New(servers map[string]string) *Router {
@@ -41,7 +54,7 @@ func New(servers map[string]string) *Router {
 		return NewWithServers(s...)
 	}
 
-	r := &Router{useDB: true}
+	r := &Router{useDB: true, secretsFromDB: true, drainsFromDB: true}
 	r.reloadServersFromDB()
 	return r
 }
@@ -59,11 +72,17 @@ func (r *Router) GetAll() []*models.LbrynetServer {
 	return r.servers
 }
 
+// RandomServer returns a random server out of those available for new work,
+// or out of all servers if none is available.
 func (r *Router) RandomServer() *models.LbrynetServer {
 	r.reloadServersFromDB()
 	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.servers[rand.Intn(len(r.servers))]
+	servers := r.servers
+	r.mu.RUnlock()
+	if available := r.available(servers); len(available) > 0 {
+		servers = available
+	}
+	return servers[rand.Intn(len(servers))]
 }
 
 func (r *Router) reloadServersFromDB() {
@@ -90,18 +109,58 @@ func (r *Router) setServers(servers []*models.LbrynetServer) {
 		return
 	}
 
+	addresses := make([]string, len(servers))
+	for i, s := range servers {
+		sdksign.DefaultKeyring.Set(s.Address, r.signingSecrets(s.Name)...)
+		addresses[i] = s.Address
+	}
+	sdktls.SetSDKAddresses(addresses)
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.servers = servers
 	logger.Log().Debugf("updated server list to %d servers", len(r.servers))
 }
 
-// WatchLoad keeps updating the metrics on the number of wallets loaded for each instance
+// signingSecrets returns secrets requests to the named server are signed with.
+func (r *Router) signingSecrets(name string) []string {
+	r.mu.RLock()
+	fromDB := r.secretsFromDB
+	r.mu.RUnlock()
+	configured := config.GetSDKSigningSecrets()[strings.ToLower(name)]
+	if !fromDB {
+		return []string{configured}
+	}
+	values, err := secrets.Values(secrets.SDKSigning(name))
+	if err != nil {
+		logger.Log().Errorf("cannot load signing secrets of %v: %v", name, err)
+		return []string{configured}
+	}
+	return values
+}
+
+// refreshSigningSecrets picks up signing secrets rotated since servers were set.
+func (r *Router) refreshSigningSecrets() {
+	r.mu.RLock()
+	servers := r.servers
+	r.mu.RUnlock()
+	for _, s := range servers {
+		sdksign.DefaultKeyring.Set(s.Address, r.signingSecrets(s.Name)...)
+	}
+}
+
+// WatchLoad keeps health-checking servers and updating the metrics on the number of wallets loaded for each instance.
+// It also keeps signing secrets and drains of servers up to date, as the DB is always available when it's running.
 func (r *Router) WatchLoad() {
-	ticker := time.NewTicker(2 * time.Minute)
+	ticker := time.NewTicker(config.GetSDKPool().CheckInterval)
 
 	logger.Log().Infof("SDK router watching load on %d instances", len(r.servers))
+	r.mu.Lock()
+	r.secretsFromDB = true
+	r.drainsFromDB = true
+	r.mu.Unlock()
 	r.reloadServersFromDB()
+	r.refreshSigningSecrets()
 	r.updateLoadAndMetrics()
 
 	time.Sleep(time.Duration(rand.Intn(60)) * time.Second) // stagger these so they don't all happen at the same time for every api server
@@ -109,55 +168,115 @@ func (r *Router) WatchLoad() {
 	for {
 		<-ticker.C
 		r.reloadServersFromDB()
+		r.refreshSigningSecrets()
 		r.updateLoadAndMetrics()
 	}
 }
 
 func (r *Router) updateLoadAndMetrics() {
-	var best *models.LbrynetServer
-	var min uint64
-
 	servers := r.GetAll()
+	draining := r.loadDrains()
+	maxLatency := config.GetSDKPool().MaxLatency
+	statuses := map[string]ServerStatus{}
+	versions := []string{}
+	versionsKnown := true
 	logger.Log().Infof("updating load for %d servers", len(servers))
 	for _, server := range servers {
 		metric := metrics.LbrynetWalletsLoaded.WithLabelValues(server.Address)
-		walletList, err := ljsonrpc.NewClient(server.Address).WalletList("", 1, 1)
+		status := ServerStatus{Name: server.Name, Address: server.Address, CheckedAt: time.Now()}
+		latency, err := checkStatus(server.Address, maxLatency)
+		if err == nil {
+			status.Latency = latency
+			metrics.LbrynetStatusLatency.WithLabelValues(server.Address).Set(latency.Seconds())
+			status.WalletsLoaded, err = walletCount(server.Address)
+		}
 		if err != nil {
 			logger.Log().Errorf("lbrynet instance %s is not responding: %v", server.Address, err)
 			metric.Set(-1.0)
-			// TODO: maybe mark this instance as unresponsive so new users are assigned to other instances
+			status.Error = err.Error()
+			statuses[server.Address] = status
 			continue
 		}
-
-		numWallets := walletList.TotalPages
-		logger.Log().Debugf("load update: considering %s with load %d", server.Address, numWallets)
-		if best == nil || numWallets < min {
-			logger.Log().Debugf("load update: %s has least with %d", server.Address, numWallets)
-			best = server
-			min = numWallets
+		status.Responding = true
+		status.Healthy = maxLatency <= 0 || latency <= maxLatency
+		if !status.Healthy {
+			logger.Log().Warnf("lbrynet instance %s is slow, status took %v", server.Address, latency)
 		}
-		metric.Set(float64(walletList.TotalPages))
+		if v, err := ljsonrpc.NewClient(server.Address).Version(); err != nil {
+			logger.Log().Warnf("error getting lbrynet version of %s: %v", server.Address, err)
+			versionsKnown = false
+		} else {
+			status.SDKVersion = v.LbrynetVersion
+			versions = append(versions, v.LbrynetVersion)
+		}
+		statuses[server.Address] = status
+		metric.Set(float64(status.WalletsLoaded))
+	}
+
+	// A server failing to report its version would otherwise look like a version change
+	if versionsKnown && len(versions) > 0 {
+		cache.SetSDKVersions(versions)
 	}
 
-	if best != nil {
-		r.loadMu.Lock()
-		defer r.loadMu.Unlock()
-		r.leastLoaded = best
-		logger.Log().Infof("After updating load, least loaded server is %s", best.Address)
+	updateVersionMetrics(statuses)
+
+	r.loadMu.Lock()
+	defer r.loadMu.Unlock()
+	r.statuses = statuses
+	if draining != nil {
+		r.draining = draining
 	}
+	r.pickLeastLoaded(servers)
 }
 
-// LeastLoaded returns the least-loaded wallet
-func (r *Router) LeastLoaded() *models.LbrynetServer {
+// ServerStatus is the outcome of the last load check of an SDK server.
+type ServerStatus struct {
+	Name       string
+	Address    string
+	Responding bool
+	// Healthy is set when the server is responding within SDKPool.MaxLatency.
+	Healthy bool
+	// Latency is the time taken by the status call.
+	Latency       time.Duration
+	WalletsLoaded uint64
+	SDKVersion    string
+	Error         string
+	// Draining is set when the server is drained for maintenance.
+	Draining bool
+	// CheckedAt is zero if the server hasn't been checked yet.
+	CheckedAt time.Time
+}
+
+// Statuses returns the outcome of the last load check for each server, see WatchLoad.
+func (r *Router) Statuses() []ServerStatus {
+	servers := r.GetAll()
 	r.loadMu.RLock()
 	defer r.loadMu.RUnlock()
+	statuses := make([]ServerStatus, len(servers))
+	for i, s := range servers {
+		if status, ok := r.statuses[s.Address]; ok {
+			statuses[i] = status
+		} else {
+			statuses[i] = ServerStatus{Name: s.Name, Address: s.Address}
+		}
+		statuses[i].Draining = r.draining[s.Name]
+	}
+	return statuses
+}
+
+// LeastLoaded returns the healthy server with the fewest wallets loaded, which is not drained.
+// New users are assigned to it.
+func (r *Router) LeastLoaded() *models.LbrynetServer {
+	r.loadMu.RLock()
+	best := r.leastLoaded
+	r.loadMu.RUnlock()
 
-	if r.leastLoaded == nil {
-		logger.Log().Warnf("LeastLoaded() called before load metrics were updated. Returning random server.")
+	if best == nil {
+		logger.Log().Warnf("LeastLoaded() called before load metrics were updated or with no server available. Returning random server.")
 		return r.RandomServer()
 	}
 
-	return r.leastLoaded
+	return best
 }
 
 // WalletID formats user ID to use as an LbrynetServer wallet ID.
@@ -68,18 +68,52 @@ func TestLeastLoaded(t *testing.T) {
 	}
 	r := New(servers)
 
+	status := `{"result":{}}`
+	version := `{"result":{"lbrynet_version":"0.79.0"}}`
+
 	// try doing the load in increasing order
-	rpcServer1.NextResponse <- `{"result":{"total_pages":1}}`
-	rpcServer2.NextResponse <- `{"result":{"total_pages":2}}`
-	rpcServer3.NextResponse <- `{"result":{"total_pages":3}}`
+	rpcServer1.QueueResponses(status, `{"result":{"total_pages":1}}`, version)
+	rpcServer2.QueueResponses(status, `{"result":{"total_pages":2}}`, version)
+	rpcServer3.QueueResponses(status, `{"result":{"total_pages":3}}`, version)
 	r.updateLoadAndMetrics()
 	assert.Equal(t, "srv1", r.LeastLoaded().Name)
 
 	// now do the load in decreasing order
-	rpcServer1.NextResponse <- `{"result":{"total_pages":3}}`
-	rpcServer2.NextResponse <- `{"result":{"total_pages":2}}`
-	rpcServer3.NextResponse <- `{"result":{"total_pages":1}}`
+	rpcServer1.QueueResponses(status, `{"result":{"total_pages":3}}`, version)
+	rpcServer2.QueueResponses(status, `{"result":{"total_pages":2}}`, version)
+	rpcServer3.QueueResponses(status, `{"result":{"total_pages":1}}`, version)
 	r.updateLoadAndMetrics()
 	assert.Equal(t, "srv3", r.LeastLoaded().Name)
 
 }
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("0.79.0", "v0.79"))
+	assert.Equal(t, -1, compareVersions("0.79.1", "0.80.0"))
+	assert.Equal(t, 1, compareVersions("0.100.0", "0.99.9"))
+	assert.Equal(t, 1, compareVersions("1.0.0rc1", "0.99"))
+}
+
+func TestServerFor(t *testing.T) {
+	config.Override("SDKMethodVersions", map[string]string{"collection_resolve": "0.80.0"})
+	defer config.RestoreOverridden()
+
+	r := New(map[string]string{"old": "http://old", "new": "http://new", "down": "http://down"})
+	for _, s := range r.GetAll() {
+		assert.False(t, r.Supports(s.Address, "collection_resolve"), "version unknown yet")
+	}
+	r.statuses = map[string]ServerStatus{
+		"http://old":  {Name: "old", Responding: true, SDKVersion: "0.79.2"},
+		"http://new":  {Name: "new", Responding: true, SDKVersion: "0.80.1"},
+		"http://down": {Name: "down"},
+	}
+	assert.True(t, r.Supports("http://old", "resolve"))
+	assert.False(t, r.Supports("http://old", "collection_resolve"))
+	assert.True(t, r.Supports("http://new", "collection_resolve"))
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "new", r.ServerFor("collection_resolve").Name)
+	}
+
+	config.Override("SDKMethodVersions", map[string]string{"collection_resolve": "0.90.0"})
+	assert.NotNil(t, r.ServerFor("collection_resolve"))
+}
@@ -0,0 +1,101 @@
+package sdkrouter
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/models"
+)
+
+// compareVersions compares dotted SDK versions numerically, returning -1, 0 or 1.
+// A leading "v" is ignored, missing and non-numeric parts count as zero.
+func compareVersions(a, b string) int {
+	pa := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	pb := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		switch {
+		case na < nb:
+			return -1
+		case na > nb:
+			return 1
+		}
+	}
+	return 0
+}
+
+// RequiredVersion returns the minimum SDK version able to handle method, or an empty string if any version can.
+func RequiredVersion(method string) string {
+	return config.GetSDKMethodVersions()[method]
+}
+
+// supports returns true if a server running SDK version v can handle method.
+// Servers whose version is not known yet are only considered able to handle methods without a required version.
+func supports(v, method string) bool {
+	required := RequiredVersion(method)
+	if required == "" {
+		return true
+	}
+	return v != "" && compareVersions(v, required) >= 0
+}
+
+// Supports returns true if the server at address is known to run an SDK version able to handle method.
+func (r *Router) Supports(address, method string) bool {
+	r.loadMu.RLock()
+	defer r.loadMu.RUnlock()
+	return supports(r.statuses[address].SDKVersion, method)
+}
+
+// ServerFor returns a random server able to handle method, so version-sensitive methods can be sent
+// to upgraded servers while the rest of the fleet still runs an older SDK.
+// Compatible servers available for new work are preferred. If no server is known to be compatible,
+// any server is returned and the SDK gets to report the error.
+func (r *Router) ServerFor(method string) *models.LbrynetServer {
+	if RequiredVersion(method) == "" {
+		return r.RandomServer()
+	}
+	servers := r.GetAll()
+	compatible := []*models.LbrynetServer{}
+	r.loadMu.RLock()
+	for _, s := range servers {
+		if supports(r.statuses[s.Address].SDKVersion, method) {
+			compatible = append(compatible, s)
+		}
+	}
+	r.loadMu.RUnlock()
+
+	if len(compatible) == 0 {
+		metrics.LbrynetVersionRouted.WithLabelValues(method, metrics.VersionRoutedNone).Inc()
+		logger.Log().Warnf("no lbrynet server is known to run version %v required by %v", RequiredVersion(method), method)
+		return r.RandomServer()
+	}
+	metrics.LbrynetVersionRouted.WithLabelValues(method, metrics.VersionRoutedCompatible).Inc()
+	if available := r.available(compatible); len(available) > 0 {
+		compatible = available
+	}
+	return compatible[rand.Intn(len(compatible))]
+}
+
+// updateVersionMetrics sets the number of responding servers running each SDK version, more than one series means
+// the fleet is mid-upgrade.
+func updateVersionMetrics(statuses map[string]ServerStatus) {
+	counts := map[string]int{}
+	for _, s := range statuses {
+		if s.Responding && s.SDKVersion != "" {
+			counts[s.SDKVersion]++
+		}
+	}
+	metrics.LbrynetVersions.Reset()
+	for v, n := range counts {
+		metrics.LbrynetVersions.WithLabelValues(v).Set(float64(n))
+	}
+}
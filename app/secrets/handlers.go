@@ -0,0 +1,59 @@
+package secrets
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+type rotateRequest struct {
+	// Overlap is a duration like "48h", CredentialLifecycle.Overlap is used when it's empty.
+	Overlap string `json:"overlap"`
+}
+
+// HandleList returns versions of all secrets, without their values.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	list, err := List()
+	if err != nil {
+		logger.Log().Error(err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, list)
+}
+
+// HandleRotate rotates the secret named in the URL. The new value is only ever returned in this response.
+func HandleRotate(w http.ResponseWriter, r *http.Request) {
+	var req rotateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+			return
+		}
+	}
+	overlap := config.GetCredentialLifecycle().Overlap
+	if req.Overlap != "" {
+		var err error
+		overlap, err = time.ParseDuration(req.Overlap)
+		if err != nil || overlap < 0 {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("invalid overlap"))
+			return
+		}
+	}
+	s, err := Rotate(mux.Vars(r)["name"], overlap)
+	if errors.Is(err, ErrUnknownName) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.Log().Error(err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, s)
+}
@@ -0,0 +1,236 @@
+// Package secrets manages secrets lbrytv shares with other services to sign what it sends them:
+// requests to SDK nodes and outbox webhooks. Initial secrets come from config. Rotating a secret generates
+// a new one, which is kept in the DB, while the previous one stays valid until an overlap window passes.
+// Signers sign with all valid secrets, so receivers can switch to the new one any time within the window.
+package secrets
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+const (
+	// WebhookSigning is the secret outbox webhooks are signed with.
+	WebhookSigning = "webhook_signing"
+
+	sdkSigningPrefix = "sdk_signing:"
+)
+
+var (
+	logger = monitor.NewModuleLogger("secrets")
+
+	ErrUnknownName = errors.Base("unknown secret name")
+
+	cacheMu sync.Mutex
+	cache   = map[string]cachedValues{}
+)
+
+type cachedValues struct {
+	values   []string
+	loadedAt time.Time
+}
+
+// Secret is a version of a named secret. Value is only set on secrets returned by Rotate.
+type Secret struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Value       string    `json:"value,omitempty"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+	RetiresAt   null.Time `json:"retires_at"`
+}
+
+// SDKSigning returns the name of the secret requests to the SDK server are signed with.
+func SDKSigning(server string) string {
+	return sdkSigningPrefix + strings.ToLower(server)
+}
+
+// Values returns values of the named secret which are still valid, newest first. Until the secret is rotated
+// for the first time, that's the configured value, if any.
+func Values(name string) ([]string, error) {
+	cacheMu.Lock()
+	c, ok := cache[name]
+	cacheMu.Unlock()
+	if ok && time.Since(c.loadedAt) < config.GetCredentialLifecycle().SecretsCacheTTL {
+		return c.values, nil
+	}
+
+	rows, err := boil.GetDB().Query(
+		`SELECT value FROM secrets WHERE name = $1 AND (retires_at IS NULL OR retires_at > now()) ORDER BY id DESC`, name,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	values := []string{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, errors.Err(err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Err(err)
+	}
+	// The newest value never retires, so there's none only when the secret hasn't been rotated
+	if v := configured(name); len(values) == 0 && v != "" {
+		values = append(values, v)
+	}
+
+	cacheMu.Lock()
+	cache[name] = cachedValues{values: values, loadedAt: time.Now()}
+	cacheMu.Unlock()
+	return values, nil
+}
+
+// Rotate generates a new value of the named secret. Values valid so far, including the configured one,
+// retire after overlap, unless they were due to retire earlier.
+func Rotate(name string, overlap time.Duration) (*Secret, error) {
+	if !validName(name) {
+		return nil, ErrUnknownName
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, errors.Err(err)
+	}
+	value := hex.EncodeToString(b)
+
+	tx, err := boil.Begin()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	// Concurrent rotations of the secret would both retire the same values otherwise
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext($1))`, name); err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	var rotated bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM secrets WHERE name = $1)`, name).Scan(&rotated); err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	if v := configured(name); v != "" && !rotated {
+		// The configured value has to be known to the DB to retire
+		_, err = tx.Exec(`INSERT INTO secrets (name, value) VALUES ($1, $2)`, name, v)
+		if err != nil {
+			tx.Rollback()
+			return nil, errors.Err(err)
+		}
+	}
+	_, err = tx.Exec(
+		`UPDATE secrets SET retires_at = now() + $2 * interval '1 second'
+		WHERE name = $1 AND (retires_at IS NULL OR retires_at > now() + $2 * interval '1 second')`,
+		name, overlap.Seconds(),
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	s := &Secret{Name: name, Value: value, Fingerprint: fingerprint(value)}
+	err = tx.QueryRow(
+		`INSERT INTO secrets (name, value) VALUES ($1, $2) RETURNING id, created_at`, name, value,
+	).Scan(&s.ID, &s.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return nil, errors.Err(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Err(err)
+	}
+
+	cacheMu.Lock()
+	delete(cache, name)
+	cacheMu.Unlock()
+	logger.WithFields(logrus.Fields{"name": name, "fingerprint": s.Fingerprint, "overlap": overlap}).Info("secret rotated")
+	return s, nil
+}
+
+// List returns all versions of secrets kept in the DB, without their values, along with configured secrets
+// which have never been rotated.
+func List() ([]*Secret, error) {
+	rows, err := boil.GetDB().Query(`SELECT id, name, value, created_at, retires_at FROM secrets ORDER BY name, id DESC`)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	list := []*Secret{}
+	rotated := map[string]bool{}
+	for rows.Next() {
+		s := &Secret{}
+		var value string
+		if err := rows.Scan(&s.ID, &s.Name, &value, &s.CreatedAt, &s.RetiresAt); err != nil {
+			return nil, errors.Err(err)
+		}
+		s.Fingerprint = fingerprint(value)
+		rotated[s.Name] = true
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Err(err)
+	}
+	for _, name := range configuredNames() {
+		if v := configured(name); v != "" && !rotated[name] {
+			list = append(list, &Secret{Name: name, Fingerprint: fingerprint(v)})
+		}
+	}
+	return list, nil
+}
+
+// Retire deletes secret values whose overlap window has passed and returns how many were deleted.
+func Retire() (int64, error) {
+	res, err := boil.GetDB().Exec(`DELETE FROM secrets WHERE retires_at <= now()`)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	if n > 0 {
+		logger.Log().Infof("deleted %v retired secrets", n)
+	}
+	return n, nil
+}
+
+func validName(name string) bool {
+	return name == WebhookSigning || (strings.HasPrefix(name, sdkSigningPrefix) && len(name) > len(sdkSigningPrefix))
+}
+
+func configured(name string) string {
+	if name == WebhookSigning {
+		return config.GetWebhookSigningSecret()
+	}
+	if strings.HasPrefix(name, sdkSigningPrefix) {
+		return config.GetSDKSigningSecrets()[strings.TrimPrefix(name, sdkSigningPrefix)]
+	}
+	return ""
+}
+
+func configuredNames() []string {
+	names := []string{WebhookSigning}
+	for server := range config.GetSDKSigningSecrets() {
+		names = append(names, SDKSigning(server))
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// fingerprint identifies a secret value without revealing it.
+func fingerprint(value string) string {
+	h := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(h[:8])
+}
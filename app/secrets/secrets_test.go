@@ -0,0 +1,82 @@
+package secrets
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestRotate(t *testing.T) {
+	config.Override("WebhookSigningSecret", "initial")
+	config.Override("CredentialLifecycle", map[string]interface{}{"Overlap": "24h", "SecretsCacheTTL": 0})
+	defer config.RestoreOverridden()
+
+	values, err := Values(WebhookSigning)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"initial"}, values)
+
+	s, err := Rotate(WebhookSigning, time.Hour)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.Value)
+	values, err = Values(WebhookSigning)
+	require.NoError(t, err)
+	assert.Equal(t, []string{s.Value, "initial"}, values, "the configured value stays valid within overlap")
+
+	list, err := List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	for _, l := range list {
+		assert.Empty(t, l.Value)
+	}
+	assert.Equal(t, s.Fingerprint, list[0].Fingerprint)
+	assert.True(t, list[1].RetiresAt.Valid)
+
+	s2, err := Rotate(WebhookSigning, 0)
+	require.NoError(t, err)
+	values, err = Values(WebhookSigning)
+	require.NoError(t, err)
+	assert.Equal(t, []string{s2.Value}, values)
+
+	n, err := Retire()
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	_, err = Rotate("database_password", time.Hour)
+	assert.True(t, errors.Is(err, ErrUnknownName))
+}
+
+func TestSDKSigning(t *testing.T) {
+	config.Override("SDKSigningSecrets", map[string]interface{}{"lbrynet1": "node secret"})
+	config.Override("CredentialLifecycle", map[string]interface{}{"Overlap": "24h", "SecretsCacheTTL": 0})
+	defer config.RestoreOverridden()
+
+	values, err := Values(SDKSigning("LBRYnet1"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node secret"}, values)
+	values, err = Values(SDKSigning("lbrynet2"))
+	require.NoError(t, err)
+	assert.Empty(t, values)
+}
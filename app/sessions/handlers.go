@@ -0,0 +1,73 @@
+// Package sessions lets users see where they are signed in and revoke sessions they don't recognize.
+// Sessions are tracked by the wallet package as auth tokens are authenticated.
+package sessions
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/gorilla/mux"
+)
+
+var logger = monitor.NewModuleLogger("sessions")
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, wallet.ErrSessionNotFound):
+		status = http.StatusNotFound
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+// authenticate returns the user making the request, writing an error response when there's none.
+// Sessions only exist for auth tokens, so requests authenticated with API keys are turned away.
+func authenticate(w http.ResponseWriter, r *http.Request) *models.User {
+	user := auth.Authenticate(w, r)
+	if user != nil && r.Header.Get(wallet.TokenHeader) == "" {
+		responses.WriteError(w, http.StatusUnauthorized, errors.Err("must authenticate with an auth token"))
+		return nil
+	}
+	return user
+}
+
+// HandleList returns active sessions of the authenticated user.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	user := authenticate(w, r)
+	if user == nil {
+		return
+	}
+	sessions, err := wallet.Sessions(user.ID, r.Header.Get(wallet.TokenHeader))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, sessions)
+}
+
+// HandleRevoke revokes the session with id from the URL, which has to belong to the authenticated user.
+func HandleRevoke(w http.ResponseWriter, r *http.Request) {
+	user := authenticate(w, r)
+	if user == nil {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, wallet.ErrSessionNotFound)
+		return
+	}
+	if err := wallet.RevokeSession(user.ID, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,111 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+)
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrEmptyName), errors.Is(err, ErrInvalidCanonical):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrHasSynonyms):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+// HandleList returns tags of the taxonomy starting with `prefix`, paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	tags, err := List(r.URL.Query().Get("prefix"), limit, offset)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, tags)
+}
+
+// HandleSet adds a tag to the taxonomy or updates it, making it a synonym when canonical is set.
+func HandleSet(w http.ResponseWriter, r *http.Request) {
+	var t Tag
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	saved, err := Set(t)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, saved)
+}
+
+// HandleDelete removes the tag named in the URL from the taxonomy.
+func HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if err := Delete(mux.Vars(r)["name"]); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleAutocomplete suggests canonical tags starting with `q` for the publish form, up to `limit` of them.
+func HandleAutocomplete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	limit, err := intParam(r, "limit", defaultAutocompleteLimit)
+	if err != nil || limit == 0 || limit > maxAutocompleteLimit {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("limit must be between 1 and %v", maxAutocompleteLimit))
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if Fold(q) == "" {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("q is required"))
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, Autocomplete(q, limit))
+}
@@ -0,0 +1,260 @@
+// Package taxonomy keeps the canonical set of tags claims are published with.
+//
+// Tags entered by users are normalized on publish (see Normalize): they are case-folded, synonyms are replaced
+// with the canonical tag they stand for, and banned tags are reported so the publish can be rejected.
+// The taxonomy is managed by admins and kept in the database, each instance holds a copy of it in memory,
+// which is reloaded on startup and periodically after (see Load).
+package taxonomy
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/lib/pq"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+var (
+	logger = monitor.NewModuleLogger("taxonomy")
+
+	ErrNotFound         = errors.Base("tag not found")
+	ErrEmptyName        = errors.Base("tag name is empty")
+	ErrInvalidCanonical = errors.Base("canonical tag must exist and not be a synonym or banned itself")
+	ErrHasSynonyms      = errors.Base("tag has synonyms and can't become a synonym or banned")
+
+	mu      sync.RWMutex
+	current = &taxonomy{}
+)
+
+// Tag is an entry of the taxonomy. Tags with Canonical set are synonyms of it.
+type Tag struct {
+	Name      string      `json:"name"`
+	Canonical null.String `json:"canonical"`
+	Banned    bool        `json:"banned"`
+	// Uses is how many times the tag was published with.
+	Uses      int       `json:"uses"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// taxonomy is the in-memory copy of the tags table.
+type taxonomy struct {
+	synonyms map[string]string
+	banned   map[string]bool
+	// canonical are tags offered by autocomplete, sorted by name.
+	canonical []Tag
+}
+
+const tagColumns = `name, canonical, banned, uses, created_at`
+
+func scanTag(s interface{ Scan(...interface{}) error }) (*Tag, error) {
+	t := &Tag{}
+	err := s.Scan(&t.Name, &t.Canonical, &t.Banned, &t.Uses, &t.CreatedAt)
+	return t, err
+}
+
+// Fold returns the canonical spelling of a user-entered tag: lowercase, with surrounding whitespace
+// and leading # removed and inner whitespace collapsed into single spaces.
+func Fold(tag string) string {
+	return strings.Join(strings.Fields(strings.TrimLeft(strings.TrimSpace(strings.ToLower(tag)), "#")), " ")
+}
+
+// Normalize folds tags, replaces synonyms with their canonical tags and drops duplicates, keeping the order.
+// Banned tags are left out of normalized and returned separately.
+func Normalize(tags []string) (normalized []string, banned []string) {
+	mu.RLock()
+	t := current
+	mu.RUnlock()
+
+	normalized, banned = []string{}, []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag = Fold(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		if t.banned[tag] {
+			banned = append(banned, tag)
+			continue
+		}
+		if c, ok := t.synonyms[tag]; ok {
+			if seen[c] {
+				continue
+			}
+			seen[c] = true
+			tag = c
+		}
+		normalized = append(normalized, tag)
+	}
+	return normalized, banned
+}
+
+// Autocomplete returns up to limit canonical tags starting with prefix, the most used first.
+func Autocomplete(prefix string, limit int) []Tag {
+	mu.RLock()
+	t := current
+	mu.RUnlock()
+
+	prefix = Fold(prefix)
+	i := sort.Search(len(t.canonical), func(i int) bool { return t.canonical[i].Name >= prefix })
+	matches := []Tag{}
+	for ; i < len(t.canonical) && strings.HasPrefix(t.canonical[i].Name, prefix); i++ {
+		matches = append(matches, t.canonical[i])
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Uses > matches[j].Uses })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Load replaces the in-memory taxonomy with the one in the database. It's run on startup, after changes
+// and periodically, so changes made on other instances are picked up.
+func Load() error {
+	rows, err := boil.GetDB().Query(`SELECT ` + tagColumns + ` FROM tags ORDER BY name`)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer rows.Close()
+	loaded := &taxonomy{synonyms: map[string]string{}, banned: map[string]bool{}, canonical: []Tag{}}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return errors.Err(err)
+		}
+		switch {
+		case tag.Banned:
+			loaded.banned[tag.Name] = true
+		case tag.Canonical.Valid:
+			loaded.synonyms[tag.Name] = tag.Canonical.String
+		default:
+			loaded.canonical = append(loaded.canonical, *tag)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Err(err)
+	}
+	mu.Lock()
+	current = loaded
+	mu.Unlock()
+	metrics.TaxonomyTags.Set(float64(len(loaded.canonical)))
+	return nil
+}
+
+// Get returns the tag with name.
+func Get(name string) (*Tag, error) {
+	t, err := scanTag(boil.GetDB().QueryRow(`SELECT `+tagColumns+` FROM tags WHERE name = $1`, Fold(name)))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return t, errors.Err(err)
+}
+
+// List returns tags starting with prefix, ordered by name.
+func List(prefix string, limit, offset int) ([]*Tag, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+tagColumns+` FROM tags WHERE name LIKE $1 ORDER BY name LIMIT $2 OFFSET $3`,
+		strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(Fold(prefix))+"%", limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	tags := []*Tag{}
+	for rows.Next() {
+		t, err := scanTag(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		tags = append(tags, t)
+	}
+	return tags, errors.Err(rows.Err())
+}
+
+// Set adds tag t to the taxonomy or updates it. A synonym's Canonical must be a canonical tag,
+// and tags other tags are synonyms of can't be made synonyms or banned.
+func Set(t Tag) (*Tag, error) {
+	t.Name = Fold(t.Name)
+	if t.Name == "" {
+		return nil, ErrEmptyName
+	}
+	if t.Canonical.Valid {
+		t.Canonical.String = Fold(t.Canonical.String)
+		if t.Banned || t.Canonical.String == t.Name {
+			return nil, ErrInvalidCanonical
+		}
+		c, err := Get(t.Canonical.String)
+		if errors.Is(err, ErrNotFound) || err == nil && (c.Canonical.Valid || c.Banned) {
+			return nil, ErrInvalidCanonical
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if t.Canonical.Valid || t.Banned {
+		var n int
+		err := boil.GetDB().QueryRow(`SELECT count(*) FROM tags WHERE canonical = $1`, t.Name).Scan(&n)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		if n > 0 {
+			return nil, ErrHasSynonyms
+		}
+	}
+
+	saved, err := scanTag(boil.GetDB().QueryRow(
+		`INSERT INTO tags (name, canonical, banned) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET canonical = $2, banned = $3 RETURNING `+tagColumns,
+		t.Name, t.Canonical, t.Banned,
+	))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.Log().Infof("tag %q set (canonical: %v, banned: %v)", saved.Name, saved.Canonical.String, saved.Banned)
+	return saved, reload()
+}
+
+// Delete removes the tag with name from the taxonomy, along with its synonyms.
+func Delete(name string) error {
+	res, err := boil.GetDB().Exec(`DELETE FROM tags WHERE name = $1`, Fold(name))
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Err(err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	logger.Log().Infof("tag %q deleted", Fold(name))
+	return reload()
+}
+
+// RecordUse counts a publish with tags, which should be normalized already. Tags missing from the taxonomy
+// are added as canonical ones, so the taxonomy grows with what users publish.
+func RecordUse(tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	_, err := boil.GetDB().Exec(
+		`INSERT INTO tags (name, uses) SELECT unnest($1::varchar[]), 1
+		ON CONFLICT (name) DO UPDATE SET uses = tags.uses + 1`,
+		pq.Array(tags),
+	)
+	if err != nil {
+		logger.Log().Errorf("cannot record use of tags %v: %v", tags, err)
+	}
+}
+
+func reload() error {
+	if err := Load(); err != nil {
+		return errors.Prefix("saved but not reloaded", err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package taxonomy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/volatiletech/null"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestFold(t *testing.T) {
+	assert.Equal(t, "video games", Fold("  #Video   Games "))
+	assert.Equal(t, "", Fold(" # "))
+}
+
+func TestTaxonomy(t *testing.T) {
+	_, err := Set(Tag{Name: " "})
+	assert.True(t, errors.Is(err, ErrEmptyName))
+	_, err = Set(Tag{Name: "vidya", Canonical: null.StringFrom("gaming")})
+	assert.True(t, errors.Is(err, ErrInvalidCanonical))
+
+	_, err = Set(Tag{Name: "Gaming"})
+	require.NoError(t, err)
+	_, err = Set(Tag{Name: "gamer"})
+	require.NoError(t, err)
+	s, err := Set(Tag{Name: "Vidya", Canonical: null.StringFrom("GAMING")})
+	require.NoError(t, err)
+	assert.Equal(t, "gaming", s.Canonical.String)
+	_, err = Set(Tag{Name: "spam", Banned: true})
+	require.NoError(t, err)
+
+	_, err = Set(Tag{Name: "gaming", Banned: true})
+	assert.True(t, errors.Is(err, ErrHasSynonyms))
+	_, err = Set(Tag{Name: "games", Canonical: null.StringFrom("vidya")})
+	assert.True(t, errors.Is(err, ErrInvalidCanonical))
+
+	normalized, banned := Normalize([]string{"VIDYA", "Gaming", "#music", "Spam", "music"})
+	assert.Equal(t, []string{"gaming", "music"}, normalized)
+	assert.Equal(t, []string{"spam"}, banned)
+
+	RecordUse([]string{"gamer", "music"})
+	RecordUse([]string{"gamer"})
+	require.NoError(t, Load())
+	suggested := Autocomplete("Gam", 10)
+	require.Len(t, suggested, 2)
+	assert.Equal(t, "gamer", suggested[0].Name)
+	assert.Equal(t, 2, suggested[0].Uses)
+	assert.Equal(t, "gaming", suggested[1].Name)
+	assert.Len(t, Autocomplete("mus", 10), 1, "tags are added on use")
+
+	tags, err := List("gam", 10, 0)
+	require.NoError(t, err)
+	assert.Len(t, tags, 2)
+
+	require.NoError(t, Delete("gaming"))
+	normalized, _ = Normalize([]string{"vidya"})
+	assert.Equal(t, []string{"vidya"}, normalized, "synonyms are deleted along with their canonical tag")
+	assert.True(t, errors.Is(Delete("gaming"), ErrNotFound))
+}
+
+func TestHandleAutocomplete(t *testing.T) {
+	_, err := Set(Tag{Name: "cooking"})
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	HandleAutocomplete(rr, httptest.NewRequest(http.MethodGet, "/api/v1/tags/autocomplete?q=coo", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var tags []Tag
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &tags))
+	require.Len(t, tags, 1)
+	assert.Equal(t, "cooking", tags[0].Name)
+
+	rr = httptest.NewRecorder()
+	HandleAutocomplete(rr, httptest.NewRequest(http.MethodGet, "/api/v1/tags/autocomplete", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
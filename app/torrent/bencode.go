@@ -0,0 +1,54 @@
+package torrent
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// bencode encodes v the way BitTorrent metainfo files are. Only types metainfo consists of are supported.
+func bencode(buf *bytes.Buffer, v interface{}) error {
+	switch typed := v.(type) {
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(typed), typed)
+	case []byte:
+		fmt.Fprintf(buf, "%d:", len(typed))
+		buf.Write(typed)
+	case int:
+		fmt.Fprintf(buf, "i%de", typed)
+	case int64:
+		fmt.Fprintf(buf, "i%de", typed)
+	case []string:
+		buf.WriteByte('l')
+		for _, s := range typed {
+			bencode(buf, s)
+		}
+		buf.WriteByte('e')
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, e := range typed {
+			if err := bencode(buf, e); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		// Dictionary keys have to be sorted
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, k := range keys {
+			bencode(buf, k)
+			if err := bencode(buf, typed[k]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('e')
+	default:
+		return fmt.Errorf("cannot bencode %T", v)
+	}
+	return nil
+}
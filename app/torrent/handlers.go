@@ -0,0 +1,66 @@
+package torrent
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+func intParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.Err("invalid %v", name)
+	}
+	return n, nil
+}
+
+// HandleList returns torrents, optionally filtered by `status`, paginated with `limit` and `offset`.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	limit, err := intParam(r, "limit", defaultListLimit)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	if limit == 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+	offset, err := intParam(r, "offset", 0)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	torrents, err := List(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		logger.Log().Error(err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, torrents)
+}
+
+// HandleRemove stops seeding the claim in the URL.
+func HandleRemove(w http.ResponseWriter, r *http.Request) {
+	t, err := Remove(mux.Vars(r)["claim_id"])
+	if errors.Is(err, ErrNotFound) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		logger.Log().Error(err)
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, t)
+}
@@ -0,0 +1,62 @@
+package torrent
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	hookName = "torrent"
+
+	// torrentField is added to resolved claims being seeded.
+	torrentField = "torrent"
+)
+
+// InstallHooks makes c add magnet links to resolved claims which are being seeded.
+func InstallHooks(c *query.Caller) {
+	c.AddPostflightHook(query.MethodResolve, addMagnets, hookName)
+}
+
+func addMagnets(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	cfg := config.GetTorrents()
+	if cfg.SeedDir == "" || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	claims := map[string]map[string]interface{}{}
+	claimIDs := []string{}
+	for _, v := range result {
+		claim, ok := v.(map[string]interface{})
+		if !ok || claim["error"] != nil {
+			continue
+		}
+		if id, ok := claim["claim_id"].(string); ok && id != "" {
+			claims[id] = claim
+			claimIDs = append(claimIDs, id)
+		}
+	}
+	if len(claimIDs) == 0 {
+		return nil, nil
+	}
+	torrents, err := Seeding(claimIDs)
+	if err != nil {
+		// Magnet links are an extra, resolve responses are served without them
+		logger.Log().Warnf("error looking up torrents: %v", err)
+		return nil, nil
+	}
+	for id, t := range torrents {
+		claims[id][torrentField] = map[string]interface{}{
+			"info_hash": t.InfoHash,
+			"magnet":    t.Magnet(cfg),
+		}
+	}
+	if len(torrents) > 0 {
+		hctx.AddLogField("torrents", len(torrents))
+	}
+	return nil, nil
+}
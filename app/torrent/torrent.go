@@ -0,0 +1,379 @@
+// Package torrent seeds published files over BitTorrent, adding a P2P delivery path next to the CDN.
+//
+// After a successful publish the file is linked into the seed folder along with a metainfo (.torrent) file.
+// Seeding itself is left to a BitTorrent client watching that folder (e.g. Transmission with watch-dir
+// and download-dir both set to it). Torrents are tracked per claim, a re-publish replaces the claim torrent,
+// and resolve responses get magnet links of claims being seeded (see InstallHooks).
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	StatusSeeding = "seeding"
+	StatusRemoved = "removed"
+
+	metainfoExt = ".torrent"
+	createdBy   = "lbrytv"
+)
+
+var (
+	logger = monitor.NewModuleLogger("torrent")
+
+	ErrNotFound      = errors.Base("torrent not found")
+	ErrQuotaExceeded = errors.Base("seeding quota exceeded")
+)
+
+// Torrent is a published file being seeded.
+type Torrent struct {
+	ClaimID  string `json:"claim_id"`
+	UserID   int    `json:"user_id"`
+	InfoHash string `json:"info_hash"`
+	// FileName is the name of the file in the seed folder and in the torrent.
+	FileName  string    `json:"file_name"`
+	Size      int64     `json:"size"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Magnet returns the magnet link of the torrent, with configured trackers and web seeds.
+func (t *Torrent) Magnet(cfg config.Torrents) string {
+	q := []string{
+		"xt=urn:btih:" + t.InfoHash,
+		"dn=" + url.QueryEscape(t.FileName),
+		fmt.Sprintf("xl=%d", t.Size),
+	}
+	for _, tr := range cfg.Trackers {
+		q = append(q, "tr="+url.QueryEscape(tr))
+	}
+	for _, ws := range webSeeds(cfg, t.FileName) {
+		q = append(q, "ws="+url.QueryEscape(ws))
+	}
+	return "magnet:?" + strings.Join(q, "&")
+}
+
+// webSeeds returns URLs the file can be downloaded from over HTTP (BEP 19),
+// configured URLs ending with a slash get the file name appended.
+func webSeeds(cfg config.Torrents, fileName string) []string {
+	seeds := []string{}
+	for _, ws := range cfg.WebSeeds {
+		if strings.HasSuffix(ws, "/") {
+			ws += url.PathEscape(fileName)
+		}
+		seeds = append(seeds, ws)
+	}
+	return seeds
+}
+
+// makeMetainfo returns the info hash and contents of a single-file torrent of the file at filePath.
+func makeMetainfo(cfg config.Torrents, filePath, fileName string) (string, []byte, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", nil, errors.Err(err)
+	}
+	defer f.Close()
+
+	var pieces bytes.Buffer
+	var size int64
+	piece := make([]byte, cfg.PieceLength)
+	for {
+		n, err := io.ReadFull(f, piece)
+		if n > 0 {
+			h := sha1.Sum(piece[:n])
+			pieces.Write(h[:])
+			size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return "", nil, errors.Err(err)
+		}
+	}
+
+	info := map[string]interface{}{
+		"name":         fileName,
+		"length":       size,
+		"piece length": cfg.PieceLength,
+		"pieces":       pieces.Bytes(),
+	}
+	var infoBuf bytes.Buffer
+	if err := bencode(&infoBuf, info); err != nil {
+		return "", nil, errors.Err(err)
+	}
+	infoHash := sha1.Sum(infoBuf.Bytes())
+
+	meta := map[string]interface{}{
+		"info":          info,
+		"created by":    createdBy,
+		"creation date": time.Now().Unix(),
+	}
+	if len(cfg.Trackers) > 0 {
+		meta["announce"] = cfg.Trackers[0]
+		tiers := []interface{}{}
+		for _, tr := range cfg.Trackers {
+			tiers = append(tiers, []string{tr})
+		}
+		meta["announce-list"] = tiers
+	}
+	if seeds := webSeeds(cfg, fileName); len(seeds) > 0 {
+		meta["url-list"] = seeds
+	}
+	var buf bytes.Buffer
+	if err := bencode(&buf, meta); err != nil {
+		return "", nil, errors.Err(err)
+	}
+	return hex.EncodeToString(infoHash[:]), buf.Bytes(), nil
+}
+
+// Seed starts seeding the file of a successful publish for the stream claim it created or updated.
+// The file is linked into the seed folder right away, as the caller removes it afterwards,
+// hashing it and writing the torrent happens in the background.
+func Seed(userID int, filePath string, res *jsonrpc.RPCResponse) {
+	cfg := config.GetTorrents()
+	if cfg.SeedDir == "" || res == nil || res.Error != nil {
+		return
+	}
+	claimID := streamClaimID(res)
+	if claimID == "" {
+		return
+	}
+	log := logger.WithFields(logrus.Fields{"user_id": userID, "claim_id": claimID})
+
+	stat, err := os.Stat(filePath)
+	if err != nil {
+		log.Errorf("cannot seed published file: %v", err)
+		return
+	}
+	if cfg.MaxTotalSize > 0 {
+		total, err := seedingSize(claimID)
+		if err != nil {
+			log.Errorf("cannot check seeding quota: %v", err)
+			return
+		}
+		if total+stat.Size() > cfg.MaxTotalSize {
+			log.Info(ErrQuotaExceeded)
+			metrics.Torrents.WithLabelValues(metrics.TorrentOverQuota).Inc()
+			return
+		}
+	}
+
+	fileName := claimID + strings.ToLower(path.Ext(filePath))
+	seedPath := path.Join(cfg.SeedDir, fileName)
+	if err := os.MkdirAll(cfg.SeedDir, os.ModePerm); err != nil {
+		log.Errorf("error creating seed folder: %v", err)
+		return
+	}
+	// The file of an earlier publish of the claim is replaced
+	removeFiles(cfg.SeedDir, claimID)
+	if err := os.Link(filePath, seedPath); err != nil {
+		if err := copyFile(filePath, seedPath); err != nil {
+			log.Errorf("error copying published file into seed folder: %v", err)
+			metrics.Torrents.WithLabelValues(metrics.TorrentFailed).Inc()
+			return
+		}
+	}
+	go func() {
+		if _, err := add(cfg, userID, claimID, fileName); err != nil {
+			log.Errorf("error creating torrent: %v", err)
+			metrics.Torrents.WithLabelValues(metrics.TorrentFailed).Inc()
+			os.Remove(seedPath)
+		}
+	}()
+}
+
+// add creates the torrent of a file in the seed folder and records it.
+func add(cfg config.Torrents, userID int, claimID, fileName string) (*Torrent, error) {
+	op := metrics.StartOperation("torrent", "make_metainfo")
+	infoHash, meta, err := makeMetainfo(cfg, path.Join(cfg.SeedDir, fileName), fileName)
+	op.End()
+	if err != nil {
+		return nil, err
+	}
+	// Written under a temporary name so the BitTorrent client doesn't pick it up half-written
+	metaPath := path.Join(cfg.SeedDir, claimID+metainfoExt)
+	if err := ioutil.WriteFile(metaPath+".tmp", meta, 0644); err != nil {
+		return nil, errors.Err(err)
+	}
+	if err := os.Rename(metaPath+".tmp", metaPath); err != nil {
+		return nil, errors.Err(err)
+	}
+
+	stat, err := os.Stat(path.Join(cfg.SeedDir, fileName))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	t := &Torrent{ClaimID: claimID, UserID: userID, InfoHash: infoHash, FileName: fileName, Size: stat.Size(), Status: StatusSeeding}
+	err = boil.GetDB().QueryRow(
+		`INSERT INTO torrents (claim_id, user_id, info_hash, file_name, size) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (claim_id) DO UPDATE SET user_id = $2, info_hash = $3, file_name = $4, size = $5,
+			status = 'seeding', created_at = now()
+		RETURNING created_at`,
+		claimID, userID, infoHash, fileName, t.Size,
+	).Scan(&t.CreatedAt)
+	if err != nil {
+		os.Remove(metaPath)
+		return nil, errors.Err(err)
+	}
+	metrics.Torrents.WithLabelValues(metrics.TorrentSeeded).Inc()
+	logger.WithFields(logrus.Fields{"user_id": userID, "claim_id": claimID, "info_hash": infoHash}).Info("seeding published file")
+	return t, nil
+}
+
+// streamClaimID returns the ID of the first stream claim in publish transaction outputs.
+func streamClaimID(res *jsonrpc.RPCResponse) string {
+	tx, _ := res.Result.(map[string]interface{})
+	outputs, _ := tx["outputs"].([]interface{})
+	for _, o := range outputs {
+		txo, ok := o.(map[string]interface{})
+		if !ok || txo["type"] != "claim" || txo["value_type"] != "stream" {
+			continue
+		}
+		if claimID, _ := txo["claim_id"].(string); claimID != "" && claimID == path.Base(claimID) {
+			return claimID
+		}
+	}
+	return ""
+}
+
+// seedingSize returns the total size of files being seeded, except the one of claimID which is about to be replaced.
+func seedingSize(claimID string) (int64, error) {
+	var total int64
+	err := boil.GetDB().QueryRow(
+		`SELECT COALESCE(SUM(size), 0) FROM torrents WHERE status = 'seeding' AND claim_id != $1`, claimID,
+	).Scan(&total)
+	return total, errors.Err(err)
+}
+
+// removeFiles removes the torrent and the data file of claimID from the seed folder.
+func removeFiles(seedDir, claimID string) {
+	files, _ := ioutil.ReadDir(seedDir)
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), claimID) {
+			if err := os.Remove(path.Join(seedDir, f.Name())); err != nil && !os.IsNotExist(err) {
+				logger.Log().Errorf("error removing %v: %v", f.Name(), err)
+			}
+		}
+	}
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+const selectColumns = `SELECT claim_id, user_id, info_hash, file_name, size, status, created_at FROM torrents`
+
+func scan(s interface{ Scan(...interface{}) error }) (*Torrent, error) {
+	t := &Torrent{}
+	err := s.Scan(&t.ClaimID, &t.UserID, &t.InfoHash, &t.FileName, &t.Size, &t.Status, &t.CreatedAt)
+	return t, err
+}
+
+// Seeding returns torrents being seeded for claimIDs, keyed by claim ID.
+func Seeding(claimIDs []string) (map[string]*Torrent, error) {
+	rows, err := boil.GetDB().Query(selectColumns+` WHERE status = 'seeding' AND claim_id = ANY($1)`, pq.Array(claimIDs))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	torrents := map[string]*Torrent{}
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		torrents[t.ClaimID] = t
+	}
+	return torrents, errors.Err(rows.Err())
+}
+
+// Find returns torrents of the claim or of files uploaded by the user, whatever their status.
+func Find(claimID string, userID int) ([]*Torrent, error) {
+	rows, err := boil.GetDB().Query(selectColumns+` WHERE claim_id = $1 OR user_id = $2 ORDER BY created_at`, claimID, userID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	torrents := []*Torrent{}
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		torrents = append(torrents, t)
+	}
+	return torrents, errors.Err(rows.Err())
+}
+
+// List returns torrents, optionally filtered by status, newest first.
+func List(status string, limit, offset int) ([]*Torrent, error) {
+	rows, err := boil.GetDB().Query(
+		selectColumns+` WHERE $1 = '' OR status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`, status, limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	torrents := []*Torrent{}
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		torrents = append(torrents, t)
+	}
+	return torrents, errors.Err(rows.Err())
+}
+
+// Remove stops seeding the torrent of claimID, removing its files from the seed folder.
+func Remove(claimID string) (*Torrent, error) {
+	t, err := scan(boil.GetDB().QueryRow(
+		`UPDATE torrents SET status = 'removed' WHERE claim_id = $1 AND status = 'seeding'
+		RETURNING claim_id, user_id, info_hash, file_name, size, status, created_at`, claimID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	if seedDir := config.GetTorrents().SeedDir; seedDir != "" {
+		removeFiles(seedDir, claimID)
+	}
+	metrics.Torrents.WithLabelValues(metrics.TorrentRemoved).Inc()
+	logger.WithFields(logrus.Fields{"claim_id": claimID, "info_hash": t.InfoHash}).Info("torrent removed")
+	return t, nil
+}
@@ -0,0 +1,153 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const testClaimID = "6769855a9aa43b67086f9ff3c1a5bacb5698a27a"
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func seedConfig(t *testing.T) config.Torrents {
+	dir, err := ioutil.TempDir("", "torrents")
+	require.NoError(t, err)
+	cfg := config.Torrents{
+		SeedDir:     dir,
+		PieceLength: 4,
+		Trackers:    []string{"udp://tracker.example.com:1337/announce"},
+		WebSeeds:    []string{"https://cdn.example.com/torrents/"},
+	}
+	config.Override("Torrents", map[string]interface{}{
+		"SeedDir": cfg.SeedDir, "PieceLength": cfg.PieceLength, "Trackers": cfg.Trackers, "WebSeeds": cfg.WebSeeds,
+	})
+	return cfg
+}
+
+func TestBencode(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, bencode(&buf, map[string]interface{}{
+		"spam": []interface{}{"a", int64(-3)},
+		"cow":  []byte("moo"),
+		"list": []string{"x"},
+	}))
+	assert.Equal(t, "d3:cow3:moo4:listl1:xe4:spaml1:ai-3eee", buf.String())
+	assert.Error(t, bencode(&buf, 1.5))
+}
+
+func TestMakeMetainfo(t *testing.T) {
+	cfg := config.Torrents{PieceLength: 4, Trackers: []string{"udp://t1", "udp://t2"}, WebSeeds: []string{"https://cdn/"}}
+	f, err := ioutil.TempFile("", "metainfo")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Write([]byte("0123456789"))
+	f.Close()
+
+	infoHash, meta, err := makeMetainfo(cfg, f.Name(), "video.mp4")
+	require.NoError(t, err)
+
+	var pieces []byte
+	for _, p := range []string{"0123", "4567", "89"} {
+		h := sha1.Sum([]byte(p))
+		pieces = append(pieces, h[:]...)
+	}
+	var info bytes.Buffer
+	bencode(&info, map[string]interface{}{"name": "video.mp4", "length": int64(10), "piece length": int64(4), "pieces": pieces})
+	expected := sha1.Sum(info.Bytes())
+	assert.Equal(t, hex.EncodeToString(expected[:]), infoHash)
+	assert.Contains(t, string(meta), "4:info"+info.String())
+	assert.Contains(t, string(meta), "8:announce8:udp://t1")
+	assert.Contains(t, string(meta), "13:announce-listll8:udp://t1el8:udp://t2ee")
+	assert.Contains(t, string(meta), "8:url-listl21:https://cdn/video.mp4e")
+}
+
+func TestMagnet(t *testing.T) {
+	tr := &Torrent{InfoHash: "abcd", FileName: "my video.mp4", Size: 10}
+	cfg := config.Torrents{Trackers: []string{"udp://t1:80"}, WebSeeds: []string{"https://cdn/"}}
+	assert.Equal(t,
+		"magnet:?xt=urn:btih:abcd&dn=my+video.mp4&xl=10&tr=udp%3A%2F%2Ft1%3A80&ws=https%3A%2F%2Fcdn%2Fmy%2520video.mp4",
+		tr.Magnet(cfg))
+}
+
+func TestSeedAndRemove(t *testing.T) {
+	cfg := seedConfig(t)
+	defer config.RestoreOverridden()
+	defer os.RemoveAll(cfg.SeedDir)
+
+	f, err := ioutil.TempFile("", "*_video.MP4")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.Write([]byte("published file"))
+	f.Close()
+
+	res := &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"outputs": []interface{}{
+			map[string]interface{}{"type": "claim", "value_type": "stream", "claim_id": testClaimID},
+		},
+	}}
+	assert.Equal(t, testClaimID, streamClaimID(res))
+	// Seeding part which runs in the background is tested separately
+	fileName := testClaimID + ".mp4"
+	require.NoError(t, copyFile(f.Name(), path.Join(cfg.SeedDir, fileName)))
+	added, err := add(cfg, 9001, testClaimID, fileName)
+	require.NoError(t, err)
+	assert.FileExists(t, path.Join(cfg.SeedDir, testClaimID+metainfoExt))
+
+	seeding, err := Seeding([]string{testClaimID, "0000000000000000000000000000000000000000"})
+	require.NoError(t, err)
+	require.Len(t, seeding, 1)
+	assert.Equal(t, added.InfoHash, seeding[testClaimID].InfoHash)
+	assert.EqualValues(t, 14, seeding[testClaimID].Size)
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "lbry://video"}), "")
+	require.NoError(t, err)
+	resolved := &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"lbry://video":   map[string]interface{}{"claim_id": testClaimID},
+		"lbry://missing": map[string]interface{}{"error": map[string]interface{}{"name": "NOT_FOUND"}},
+	}}
+	_, err = addMagnets(nil, &query.HookContext{Query: q, Response: resolved})
+	require.NoError(t, err)
+	claim := resolved.Result.(map[string]interface{})["lbry://video"].(map[string]interface{})
+	require.Contains(t, claim, torrentField)
+	assert.Equal(t, added.Magnet(cfg), claim[torrentField].(map[string]interface{})["magnet"])
+
+	listed, err := List(StatusSeeding, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, testClaimID, listed[0].ClaimID)
+
+	removed, err := Remove(testClaimID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRemoved, removed.Status)
+	_, err = os.Stat(path.Join(cfg.SeedDir, fileName))
+	assert.True(t, os.IsNotExist(err))
+	_, err = Remove(testClaimID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
@@ -0,0 +1,48 @@
+package trending
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleGet returns the trending list of category from the URL. The number of claims can be limited
+// with the page_size query param.
+func HandleGet(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	e := Current()
+	if e == nil {
+		responses.WriteError(w, http.StatusServiceUnavailable, errors.Err("trending is not available"))
+		return
+	}
+	l, err := e.Get(mux.Vars(r)["category"])
+	if errors.Is(err, ErrUnknownCategory) {
+		responses.WriteError(w, http.StatusNotFound, err)
+		return
+	} else if errors.Is(err, ErrNotComputed) {
+		responses.WriteError(w, http.StatusServiceUnavailable, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if s := r.URL.Query().Get("page_size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			responses.WriteError(w, http.StatusBadRequest, errors.Err("page_size must be a positive number"))
+			return
+		}
+		if n < len(l.Items) {
+			page := *l
+			page.Items = l.Items[:n]
+			l = &page
+		}
+	}
+	responses.WriteJSON(w, http.StatusOK, l)
+}
@@ -0,0 +1,268 @@
+// Package trending computes trending claims per category server-side.
+//
+// Candidates for each category are recent claims having any of its tags. They are scored by their view count
+// decayed by age, so a claim loses half of its score every HalfLife. Lists are recomputed by Refresh,
+// which is run periodically by the refresh_trending scheduled task, and served from memory in between.
+package trending
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const viewCountPath = "/file/view_count"
+
+var (
+	logger = monitor.NewModuleLogger("trending")
+
+	ErrUnknownCategory = errors.Base("unknown trending category")
+	ErrNotComputed     = errors.Base("trending list is not computed yet")
+
+	current *Engine
+)
+
+// Search returns up to count most recent claims having any of tags.
+type Search func(tags []string, count int) ([]map[string]interface{}, error)
+
+// ViewCounts returns the number of views of claims, keyed by claim ID.
+type ViewCounts func(claimIDs []string) (map[string]int, error)
+
+// Item is a trending claim, higher Score meaning more trending.
+type Item struct {
+	ClaimID string                 `json:"claim_id"`
+	Score   float64                `json:"score"`
+	Views   int                    `json:"views"`
+	Claim   map[string]interface{} `json:"claim"`
+}
+
+// List is a computed trending list of a category.
+type List struct {
+	Category   string    `json:"category"`
+	Items      []Item    `json:"items"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// Engine computes trending lists for Categories, which map category names to their tags.
+type Engine struct {
+	Categories map[string][]string
+	// HalfLife is the age at which a claim's score is half of its view count.
+	HalfLife time.Duration
+	// Candidates is the number of recent claims scored per category.
+	Candidates int
+	// Size caps the number of claims in a list.
+	Size       int
+	Search     Search
+	ViewCounts ViewCounts
+
+	mu    sync.RWMutex
+	lists map[string]*List
+}
+
+// NewEngine creates an engine for categories, searching candidates and their view counts with search and views.
+func NewEngine(categories map[string][]string, search Search, views ViewCounts) *Engine {
+	return &Engine{
+		Categories: categories,
+		HalfLife:   24 * time.Hour,
+		Candidates: 200,
+		Size:       50,
+		Search:     search,
+		ViewCounts: views,
+		lists:      map[string]*List{},
+	}
+}
+
+// SetEngine makes e available to handlers and scheduled tasks.
+func SetEngine(e *Engine) {
+	current = e
+}
+
+// Current returns the engine set with SetEngine, or nil if trending is not set up.
+func Current() *Engine {
+	return current
+}
+
+// Get returns the last computed list of category.
+func (e *Engine) Get(category string) (*List, error) {
+	if _, ok := e.Categories[category]; !ok {
+		return nil, ErrUnknownCategory
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	l, ok := e.lists[category]
+	if !ok {
+		return nil, ErrNotComputed
+	}
+	return l, nil
+}
+
+// Refresh recomputes lists of all categories. Categories which fail keep their previous lists.
+func (e *Engine) Refresh() error {
+	var failed []string
+	for category, tags := range e.Categories {
+		l, err := e.compute(category, tags, time.Now())
+		if err != nil {
+			logger.Log().Errorf("cannot compute trending list of %v: %v", category, err)
+			metrics.TrendingRefreshes.WithLabelValues(metrics.TrendingRefreshFailed).Inc()
+			failed = append(failed, category)
+			continue
+		}
+		metrics.TrendingRefreshes.WithLabelValues(metrics.TrendingRefreshSucceeded).Inc()
+		e.mu.Lock()
+		e.lists[category] = l
+		e.mu.Unlock()
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return errors.Err("cannot compute trending lists of %v", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func (e *Engine) compute(category string, tags []string, now time.Time) (*List, error) {
+	claims, err := e.Search(tags, e.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, c := range claims {
+		if id, _ := c["claim_id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	views := map[string]int{}
+	if len(ids) > 0 {
+		views, err = e.ViewCounts(ids)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	items := []Item{}
+	for _, c := range claims {
+		id, _ := c["claim_id"].(string)
+		if id == "" {
+			continue
+		}
+		items = append(items, Item{
+			ClaimID: id,
+			Score:   Score(views[id], now.Sub(releasedAt(c)), e.HalfLife),
+			Views:   views[id],
+			Claim:   c,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+	if len(items) > e.Size {
+		items = items[:e.Size]
+	}
+	return &List{Category: category, Items: items, ComputedAt: now}, nil
+}
+
+// Score decays views by age, halving them every halfLife.
+func Score(views int, age, halfLife time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	if halfLife <= 0 {
+		return float64(views)
+	}
+	return float64(views) * math.Pow(0.5, float64(age)/float64(halfLife))
+}
+
+// releasedAt returns the release time of claim, falling back to when it was put on the blockchain.
+func releasedAt(claim map[string]interface{}) time.Time {
+	if value, ok := claim["value"].(map[string]interface{}); ok {
+		if rt, err := strconv.ParseInt(fmtString(value["release_time"]), 10, 64); err == nil && rt > 0 {
+			return time.Unix(rt, 0)
+		}
+	}
+	if ts, ok := claim["timestamp"].(float64); ok && ts > 0 {
+		return time.Unix(int64(ts), 0)
+	}
+	return time.Time{}
+}
+
+func fmtString(v interface{}) string {
+	switch typed := v.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatInt(int64(typed), 10)
+	}
+	return ""
+}
+
+// SDKSearch returns a Search running claim_search on a random SDK node, limited to streams released within maxAge.
+func SDKSearch(rt *sdkrouter.Router, maxAge time.Duration) Search {
+	return func(tags []string, count int) ([]map[string]interface{}, error) {
+		params := map[string]interface{}{
+			"any_tags":   tags,
+			"claim_type": "stream",
+			"order_by":   []string{"release_time"},
+			"page_size":  count,
+			"no_totals":  true,
+		}
+		if maxAge > 0 {
+			params["release_time"] = ">" + strconv.FormatInt(time.Now().Add(-maxAge).Unix(), 10)
+		}
+		c := query.NewCaller(rt.RandomServer().Address, 0)
+		res, err := c.Call(jsonrpc.NewRequest(query.MethodClaimSearch, params))
+		if err != nil {
+			return nil, err
+		}
+		if res.Error != nil {
+			return nil, errors.Err(res.Error.Message)
+		}
+		result, _ := res.Result.(map[string]interface{})
+		items, _ := result["items"].([]interface{})
+		claims := []map[string]interface{}{}
+		for _, item := range items {
+			if c, ok := item.(map[string]interface{}); ok {
+				claims = append(claims, c)
+			}
+		}
+		return claims, nil
+	}
+}
+
+// APIViewCounts returns ViewCounts fetching them in one request from the internal API at host.
+func APIViewCounts(host string, timeout time.Duration) ViewCounts {
+	client := &http.Client{Timeout: timeout}
+	return func(claimIDs []string) (map[string]int, error) {
+		res, err := client.PostForm(host+viewCountPath, url.Values{"claim_id": {strings.Join(claimIDs, ",")}})
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		defer res.Body.Close()
+		var parsed struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+			Data    []int  `json:"data"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&parsed); err != nil {
+			return nil, errors.Err(err)
+		}
+		if !parsed.Success || len(parsed.Data) != len(claimIDs) {
+			return nil, errors.Err("view count API responded with %v: %v", res.Status, parsed.Error)
+		}
+		views := map[string]int{}
+		for i, id := range claimIDs {
+			views[id] = parsed.Data[i]
+		}
+		return views, nil
+	}
+}
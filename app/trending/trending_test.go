@@ -0,0 +1,118 @@
+package trending
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func claim(id string, released time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"claim_id": id,
+		"value":    map[string]interface{}{"release_time": fmt.Sprint(released.Unix())},
+	}
+}
+
+func TestScore(t *testing.T) {
+	assert.Equal(t, 100.0, Score(100, 0, time.Hour))
+	assert.Equal(t, 50.0, Score(100, time.Hour, time.Hour))
+	assert.Equal(t, 25.0, Score(100, 2*time.Hour, time.Hour))
+	assert.Equal(t, 100.0, Score(100, -time.Hour, time.Hour))
+}
+
+func TestEngine_Refresh(t *testing.T) {
+	now := time.Now()
+	var searched []string
+	e := NewEngine(map[string][]string{"music": {"music"}},
+		func(tags []string, count int) ([]map[string]interface{}, error) {
+			searched = tags
+			return []map[string]interface{}{
+				claim("old", now.Add(-96*time.Hour)),
+				claim("new", now.Add(-time.Hour)),
+				claim("fresh", now),
+			}, nil
+		},
+		func(claimIDs []string) (map[string]int, error) {
+			return map[string]int{"old": 1000, "new": 300, "fresh": 100}, nil
+		},
+	)
+	e.Size = 2
+
+	_, err := e.Get("music")
+	assert.True(t, errors.Is(err, ErrNotComputed))
+	_, err = e.Get("sports")
+	assert.True(t, errors.Is(err, ErrUnknownCategory))
+
+	require.NoError(t, e.Refresh())
+	assert.Equal(t, []string{"music"}, searched)
+	l, err := e.Get("music")
+	require.NoError(t, err)
+	require.Len(t, l.Items, 2)
+	// Old claim with the most views decays below newer ones
+	assert.Equal(t, "new", l.Items[0].ClaimID)
+	assert.Equal(t, "fresh", l.Items[1].ClaimID)
+	assert.Equal(t, 300, l.Items[0].Views)
+
+	e.ViewCounts = func(claimIDs []string) (map[string]int, error) {
+		return nil, errors.Err("api is down")
+	}
+	assert.Error(t, e.Refresh())
+	l, err = e.Get("music")
+	require.NoError(t, err)
+	assert.Len(t, l.Items, 2, "previous list should be kept")
+}
+
+func TestAPIViewCounts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, viewCountPath, r.URL.Path)
+		assert.Equal(t, "abc,def", r.FormValue("claim_id"))
+		w.Write([]byte(`{"success": true, "error": null, "data": [3, 7]}`))
+	}))
+	defer ts.Close()
+
+	views, err := APIViewCounts(ts.URL, time.Second)([]string{"abc", "def"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"abc": 3, "def": 7}, views)
+}
+
+func TestHandleGet(t *testing.T) {
+	e := NewEngine(map[string][]string{"music": {"music"}},
+		func(tags []string, count int) ([]map[string]interface{}, error) {
+			return []map[string]interface{}{claim("a", time.Now()), claim("b", time.Now())}, nil
+		},
+		func(claimIDs []string) (map[string]int, error) {
+			return map[string]int{"a": 2, "b": 1}, nil
+		},
+	)
+	require.NoError(t, e.Refresh())
+	SetEngine(e)
+	defer SetEngine(nil)
+
+	call := func(category, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/trending/"+category+query, nil)
+		r = mux.SetURLVars(r, map[string]string{"category": category})
+		rr := httptest.NewRecorder()
+		HandleGet(rr, r)
+		return rr
+	}
+
+	rr := call("music", "?page_size=1")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var l List
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &l))
+	assert.Equal(t, "music", l.Category)
+	require.Len(t, l.Items, 1)
+	assert.Equal(t, "a", l.Items[0].ClaimID)
+
+	assert.Equal(t, http.StatusNotFound, call("sports", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call("music", "?page_size=x").Code)
+}
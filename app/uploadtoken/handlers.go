@@ -0,0 +1,49 @@
+package uploadtoken
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+)
+
+type issueRequest struct {
+	MaxSize      int64    `json:"max_size"`
+	ContentTypes []string `json:"content_types"`
+	// ExpiresIn is token lifetime in seconds, the configured maximum is used when omitted.
+	ExpiresIn int `json:"expires_in"`
+}
+
+type issueResponse struct {
+	Token        string    `json:"token"`
+	MaxSize      int64     `json:"max_size"`
+	ContentTypes []string  `json:"content_types"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// HandleIssue issues an upload token for the authenticated user. The token is only ever returned in this response.
+func HandleIssue(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req issueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	token, t, err := Issue(user.ID, req.MaxSize, req.ContentTypes, time.Duration(req.ExpiresIn)*time.Second)
+	if errors.Is(err, ErrInvalidSize) {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	} else if err != nil {
+		responses.WriteError(w, http.StatusInternalServerError, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, issueResponse{
+		Token: token, MaxSize: t.MaxSize, ContentTypes: t.ContentTypes, ExpiresAt: t.ExpiresAt,
+	})
+}
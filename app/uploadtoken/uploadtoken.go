@@ -0,0 +1,198 @@
+// Package uploadtoken issues single-use upload tokens, so third-party tools can upload on behalf
+// of a user without holding their auth token. Each token is limited to a file size and a set of content types,
+// and is consumed by the first upload presenting it, whether or not the upload goes through.
+package uploadtoken
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+// Header is the name of HTTP header carrying an upload token in place of an auth token.
+const Header = "X-Lbrytv-Upload-Token"
+
+const (
+	tokenPrefix = "lbrytv_upl_"
+	// sniffLen is the number of bytes http.DetectContentType looks at.
+	sniffLen = 512
+	// expiredKeep is how long expired tokens are kept around, so rejections can be looked into.
+	expiredKeep = 24 * time.Hour
+)
+
+var (
+	logger = monitor.NewModuleLogger("uploadtoken")
+
+	ErrInvalidToken        = errors.Base("upload token is invalid, expired or already used")
+	ErrInvalidSize         = errors.Base("max_size must be positive")
+	ErrTooLarge            = errors.Base("uploaded file is larger than the upload token allows")
+	ErrContentTypeRejected = errors.Base("uploaded file type is not allowed by the upload token")
+	ErrMethodForbidden     = errors.Base("upload tokens only allow publish and stream_create")
+)
+
+// Token is the scope of an upload token.
+type Token struct {
+	ID     int
+	UserID int
+	// MaxSize is the largest file size allowed, in bytes.
+	MaxSize int64
+	// ContentTypes are allowed file types, either exact ("video/mp4") or by top-level type ("video/*").
+	// Any type is allowed when empty.
+	ContentTypes []string
+	ExpiresAt    time.Time
+}
+
+// Issue creates a token letting its holder upload a single file of at most maxSize bytes as userID.
+// The token is valid for ttl, capped by the UploadTokenTTL setting. Only a hash is stored,
+// so the returned plain token can't be retrieved later.
+func Issue(userID int, maxSize int64, contentTypes []string, ttl time.Duration) (string, *Token, error) {
+	if maxSize <= 0 {
+		return "", nil, ErrInvalidSize
+	}
+	if maxTTL := config.GetUploadTokenTTL(); ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+	types := []string{}
+	for _, ct := range contentTypes {
+		if ct = strings.ToLower(strings.TrimSpace(ct)); ct != "" {
+			types = append(types, ct)
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.Err(err)
+	}
+	token := tokenPrefix + hex.EncodeToString(b)
+
+	t := &Token{UserID: userID, MaxSize: maxSize, ContentTypes: types}
+	err := boil.GetDB().QueryRow(
+		`INSERT INTO upload_tokens (user_id, token_hash, max_size, content_types, expires_at)
+		VALUES ($1, $2, $3, $4, now() + $5 * interval '1 millisecond') RETURNING id, expires_at`,
+		userID, hashToken(token), maxSize, strings.Join(types, ","), ttl.Milliseconds(),
+	).Scan(&t.ID, &t.ExpiresAt)
+	if err != nil {
+		return "", nil, errors.Err(err)
+	}
+	metrics.UploadTokens.WithLabelValues(metrics.UploadTokenIssued).Inc()
+	logger.WithFields(logrus.Fields{"user_id": userID, "token_id": t.ID, "max_size": maxSize}).Info("upload token issued")
+
+	if _, err := boil.GetDB().Exec(
+		`DELETE FROM upload_tokens WHERE expires_at < now() - $1 * interval '1 millisecond'`, expiredKeep.Milliseconds(),
+	); err != nil {
+		logger.Log().Warnf("error removing expired upload tokens: %v", err)
+	}
+	return token, t, nil
+}
+
+// methods are the only methods which can be called with an upload token. The token stands in for the auth token
+// of its issuer, so any other method would run against their wallet.
+var methods = []string{"publish", "stream_create"}
+
+// AllowsMethod returns true if method can be called with an upload token.
+func AllowsMethod(method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Consume marks the token as used and returns its scope. Checking and marking happen in a single statement,
+// so a token presented by concurrent requests is only accepted once.
+func Consume(token string) (*Token, error) {
+	var types string
+	t := &Token{}
+	err := boil.GetDB().QueryRow(
+		`UPDATE upload_tokens SET used_at = now()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+		RETURNING id, user_id, max_size, content_types, expires_at`,
+		hashToken(token),
+	).Scan(&t.ID, &t.UserID, &t.MaxSize, &types, &t.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		metrics.UploadTokens.WithLabelValues(metrics.UploadTokenRejected).Inc()
+		return nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	if types != "" {
+		t.ContentTypes = strings.Split(types, ",")
+	}
+	metrics.UploadTokens.WithLabelValues(metrics.UploadTokenConsumed).Inc()
+	logger.WithFields(logrus.Fields{"user_id": t.UserID, "token_id": t.ID}).Info("upload token consumed")
+	return t, nil
+}
+
+// Check verifies that the uploaded file at path is within the token scope. The content type is detected
+// from file contents, or from origName extension when contents are not conclusive.
+func (t *Token) Check(path, origName string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return errors.Err(err)
+	}
+	if stat.Size() > t.MaxSize {
+		return ErrTooLarge
+	}
+	if len(t.ContentTypes) == 0 {
+		return nil
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := f.Read(head)
+	if err != nil && stat.Size() > 0 {
+		return errors.Err(err)
+	}
+	ct := http.DetectContentType(head[:n])
+	if ct == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(filepath.Ext(origName)); byExt != "" {
+			ct = byExt
+		}
+	}
+	if !t.allows(ct) {
+		logger.WithFields(logrus.Fields{"token_id": t.ID, "content_type": ct}).Info("upload rejected by token scope")
+		return ErrContentTypeRejected
+	}
+	return nil
+}
+
+func (t *Token) allows(contentType string) bool {
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	for _, allowed := range t.ContentTypes {
+		if allowed == contentType || allowed == "*/*" {
+			return true
+		}
+		if strings.HasSuffix(allowed, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowed, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
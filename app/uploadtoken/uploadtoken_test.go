@@ -0,0 +1,123 @@
+package uploadtoken
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestIssueAndConsume(t *testing.T) {
+	token, issued, err := Issue(751365, 1000, []string{" Video/* ", ""}, 10*time.Minute)
+	require.NoError(t, err)
+	assert.Regexp(t, "^lbrytv_upl_[0-9a-f]{64}$", token)
+	assert.Equal(t, []string{"video/*"}, issued.ContentTypes)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), issued.ExpiresAt, time.Minute)
+
+	consumed, err := Consume(token)
+	require.NoError(t, err)
+	assert.Equal(t, issued.ID, consumed.ID)
+	assert.Equal(t, 751365, consumed.UserID)
+	assert.EqualValues(t, 1000, consumed.MaxSize)
+	assert.Equal(t, []string{"video/*"}, consumed.ContentTypes)
+
+	_, err = Consume(token)
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+	_, err = Consume("lbrytv_upl_unknown")
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestIssue_Validation(t *testing.T) {
+	_, _, err := Issue(751365, 0, nil, 0)
+	assert.True(t, errors.Is(err, ErrInvalidSize))
+
+	_, issued, err := Issue(751365, 1, nil, 1000*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(config.GetUploadTokenTTL()), issued.ExpiresAt, time.Minute)
+}
+
+func TestConsume_Expired(t *testing.T) {
+	token, _, err := Issue(751365, 1, nil, time.Millisecond)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	_, err = Consume(token)
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestConsume_Concurrent(t *testing.T) {
+	token, _, err := Issue(751365, 1, nil, time.Minute)
+	require.NoError(t, err)
+
+	var accepted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := Consume(token); err == nil {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, accepted)
+}
+
+func TestToken_Check(t *testing.T) {
+	write := func(data []byte) string {
+		f, err := ioutil.TempFile("", "uploadtoken")
+		require.NoError(t, err)
+		_, err = f.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+		return f.Name()
+	}
+	// ISO base media header is enough for content sniffing
+	mp4 := write(append([]byte{0, 0, 0, 0x18, 'f', 't', 'y', 'p', 'm', 'p', '4', '2'}, make([]byte, 100)...))
+	defer os.Remove(mp4)
+	text := write([]byte("just some text"))
+	defer os.Remove(text)
+	unknown := write([]byte{0x00, 0x01, 0x02, 0x03})
+	defer os.Remove(unknown)
+
+	video := &Token{MaxSize: 1000, ContentTypes: []string{"video/*"}}
+	assert.NoError(t, video.Check(mp4, "clip.mp4"))
+	assert.True(t, errors.Is(video.Check(text, "clip.mp4"), ErrContentTypeRejected))
+	assert.True(t, errors.Is(video.Check(unknown, "clip.png"), ErrContentTypeRejected))
+
+	image := &Token{MaxSize: 1000, ContentTypes: []string{"image/*"}}
+	assert.NoError(t, image.Check(unknown, "picture.png"))
+	assert.True(t, errors.Is(image.Check(unknown, "picture"), ErrContentTypeRejected))
+
+	exact := &Token{MaxSize: 1000, ContentTypes: []string{"text/plain"}}
+	assert.NoError(t, exact.Check(text, "notes"))
+
+	small := &Token{MaxSize: 10}
+	assert.True(t, errors.Is(small.Check(mp4, "clip.mp4"), ErrTooLarge))
+	assert.NoError(t, small.Check(unknown, "whatever"))
+}
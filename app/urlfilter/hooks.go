@@ -0,0 +1,125 @@
+package urlfilter
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/lbryio/lbrytv/app/published"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const hookName = "urlfilter"
+
+// publishingMethods create claims, names of which are added to the filter before the dump catches up.
+var publishingMethods = []string{"publish", "stream_create", "channel_create", "collection_create"}
+
+// InstallHooks makes c answer resolves of URLs missing from the filter of the default source
+// and keep the filter up to date with claims created or resolved through c.
+func InstallHooks(c *query.Caller) {
+	c.AddPreflightHook(query.MethodResolve, shortcutMissing, hookName)
+	c.AddPostflightHook(query.MethodResolve, checkResolved, hookName)
+	for _, m := range publishingMethods {
+		c.AddPostflightHook(m, addPublished, hookName)
+	}
+}
+
+// shortcutMissing responds with not found errors, the same the SDK would return, when all resolved URLs are missing.
+func shortcutMissing(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	s := getDefault()
+	if s == nil || hctx.Query.ParamsAsMap() == nil {
+		return nil, nil
+	}
+	f := s.Filter()
+	if f == nil {
+		return nil, nil
+	}
+	urls := hctx.Query.ResolveURLs()
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	for _, u := range urls {
+		if !f.Missing(u) {
+			return nil, nil
+		}
+	}
+	// Claims published but not in the dump yet are left for the SDK and the published claims index to answer
+	if published.Matches(hctx.Query) || s.addedElsewhere(urls) {
+		return nil, nil
+	}
+	if rand.Intn(100) < s.cfg.PassthroughPercentage {
+		metrics.ResolveFilterCount.WithLabelValues(metrics.ResolveFilterPassedThrough).Inc()
+		return nil, nil
+	}
+
+	result := map[string]interface{}{}
+	for _, u := range urls {
+		result[u] = map[string]interface{}{
+			"error": map[string]interface{}{
+				"name": "NOT_FOUND",
+				"text": fmt.Sprintf("Could not find claim at \"%v\".", u),
+			},
+		}
+	}
+	metrics.ResolveFilterCount.WithLabelValues(metrics.ResolveFilterShortcut).Inc()
+	hctx.AddLogField("shortcut", hookName)
+	return &jsonrpc.RPCResponse{
+		JSONRPC: hctx.Query.Request.JSONRPC,
+		ID:      hctx.Query.Request.ID,
+		Result:  result,
+	}, nil
+}
+
+// checkResolved adds names of claims the SDK found but the filter considers missing,
+// which happens when they were created after the dump was taken.
+func checkResolved(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	s := getDefault()
+	if s == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	f := s.Filter()
+	if f == nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for u, v := range result {
+		claim, ok := v.(map[string]interface{})
+		if !ok || claim["error"] != nil || !f.Missing(u) {
+			continue
+		}
+		metrics.ResolveFilterCount.WithLabelValues(metrics.ResolveFilterMispredicted).Inc()
+		name, claimID, _ := parseURL(u)
+		s.Add(name)
+		if claimID != "" {
+			s.Add(claimID)
+		}
+	}
+	return nil, nil
+}
+
+// addPublished adds the name and claim ID of a successfully created claim,
+// as clients usually resolve it by both right after publishing.
+func addPublished(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	s := getDefault()
+	if s == nil || hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	if name, ok := hctx.Query.ParamsAsMap()["name"].(string); ok && name != "" {
+		s.Add(name)
+	}
+	tx, _ := hctx.Response.Result.(map[string]interface{})
+	outputs, _ := tx["outputs"].([]interface{})
+	for _, o := range outputs {
+		if txo, ok := o.(map[string]interface{}); ok {
+			if claimID, ok := txo["claim_id"].(string); ok && claimID != "" {
+				s.Add(claimID)
+			}
+		}
+	}
+	return nil, nil
+}
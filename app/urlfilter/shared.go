@@ -0,0 +1,84 @@
+package urlfilter
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/redis"
+)
+
+// Entries added on one instance are kept in a Redis sorted set scored by the Unix time they were added,
+// so filters of all instances learn about claims published or resolved through any of them.
+
+// redisDoer is the part of redis.Client the filter uses.
+type redisDoer interface {
+	Do(args ...interface{}) (interface{}, error)
+}
+
+// sharedEntries keeps filter entries in the sorted set at key.
+type sharedEntries struct {
+	client redisDoer
+	key    string
+}
+
+// NewRedisClient returns a client for sharing filter entries as set in cfg.
+func NewRedisClient(cfg config.MissingURLFilterRedis) (*redis.Client, error) {
+	client := redis.New(redis.Config{
+		Address:  cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		Timeout:  cfg.Timeout,
+		PoolSize: cfg.PoolSize,
+	})
+	if err := client.Ping(); err != nil {
+		return nil, errors.Prefix("cannot connect to missing URL filter redis", err)
+	}
+	return client, nil
+}
+
+func (e *sharedEntries) add(entry string, at time.Time) error {
+	_, err := e.client.Do("ZADD", e.key, at.Unix(), entry)
+	return errors.Err(err)
+}
+
+// has returns true if entry was added at or after since.
+func (e *sharedEntries) has(entry string, since time.Time) (bool, error) {
+	reply, err := e.client.Do("ZSCORE", e.key, entry)
+	if err != nil {
+		return false, errors.Err(err)
+	}
+	b, ok := reply.([]byte)
+	if !ok {
+		return false, nil
+	}
+	added, err := strconv.ParseFloat(string(b), 64)
+	if err != nil {
+		return false, errors.Err("%v: unexpected ZSCORE reply %q", redis.ErrProtocol, b)
+	}
+	return int64(added) >= since.Unix(), nil
+}
+
+// since returns entries added at or after t. Older ones are dropped.
+func (e *sharedEntries) since(t time.Time) ([]string, error) {
+	min := strconv.FormatInt(t.Unix(), 10)
+	if _, err := e.client.Do("ZREMRANGEBYSCORE", e.key, "-inf", "("+min); err != nil {
+		return nil, errors.Err(err)
+	}
+	reply, err := e.client.Do("ZRANGEBYSCORE", e.key, min, "+inf")
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	items, ok := reply.([]interface{})
+	if !ok && reply != nil {
+		return nil, errors.Err("%v: unexpected ZRANGEBYSCORE reply %T", redis.ErrProtocol, reply)
+	}
+	entries := make([]string, 0, len(items))
+	for _, i := range items {
+		if b, ok := i.([]byte); ok {
+			entries = append(entries, string(b))
+		}
+	}
+	return entries, nil
+}
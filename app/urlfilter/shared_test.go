@@ -0,0 +1,140 @@
+package urlfilter
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/published"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+// fakeSortedSets serves the sorted set commands the filter uses, out of memory.
+type fakeSortedSets struct {
+	mu   sync.Mutex
+	sets map[string]map[string]int64
+	fail bool
+}
+
+func newFakeSortedSets() *fakeSortedSets {
+	return &fakeSortedSets{sets: map[string]map[string]int64{}}
+}
+
+func score(arg interface{}, inf int64) int64 {
+	s := arg.(string)
+	switch s {
+	case "-inf", "+inf":
+		return inf
+	}
+	if s[0] == '(' {
+		n, _ := strconv.ParseInt(s[1:], 10, 64)
+		return n - 1
+	}
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
+
+func (f *fakeSortedSets) Do(args ...interface{}) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return nil, errors.Err("connection refused")
+	}
+	key := args[1].(string)
+	if f.sets[key] == nil {
+		f.sets[key] = map[string]int64{}
+	}
+	set := f.sets[key]
+	switch args[0] {
+	case "ZADD":
+		set[args[3].(string)] = args[2].(int64)
+		return int64(1), nil
+	case "ZSCORE":
+		s, ok := set[args[2].(string)]
+		if !ok {
+			return nil, nil
+		}
+		return []byte(strconv.FormatInt(s, 10)), nil
+	case "ZREMRANGEBYSCORE":
+		max := score(args[3], 1<<62)
+		for m, s := range set {
+			if s <= max {
+				delete(set, m)
+			}
+		}
+		return int64(0), nil
+	case "ZRANGEBYSCORE":
+		min := score(args[2], -1<<62)
+		members := []interface{}{}
+		for m, s := range set {
+			if s >= min {
+				members = append(members, []byte(m))
+			}
+		}
+		return members, nil
+	}
+	return nil, errors.Err("unknown command %v", args[0])
+}
+
+func TestSource_SharedEntries(t *testing.T) {
+	path, cleanup := writeDump(t, dump)
+	defer cleanup()
+	redis := newFakeSortedSets()
+	cfg := Config{Source: path, Capacity: 100, FalsePositiveRate: 0.001, RefreshInterval: time.Hour, MaxAge: time.Hour}
+	one, other := NewSource(cfg), NewSource(cfg)
+	one.ShareEntries(redis, "lbrytv:urlfilter:entries")
+	other.ShareEntries(redis, "lbrytv:urlfilter:entries")
+	require.NoError(t, one.Reload())
+	require.NoError(t, other.Reload())
+
+	// Published through one instance, resolved through the other
+	one.Add("Brand-New")
+	SetDefault(other)
+	defer SetDefault(nil)
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "lbry://brand-new"}), "")
+	require.NoError(t, err)
+	res, err := shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.False(t, other.Filter().Missing("lbry://brand-new"))
+
+	// Rebuilt filters get shared entries
+	fresh := NewSource(cfg)
+	fresh.ShareEntries(redis, "lbrytv:urlfilter:entries")
+	require.NoError(t, fresh.Reload())
+	assert.False(t, fresh.Filter().Missing("lbry://brand-new"))
+
+	// Resolves go to the SDK when shared entries can't be checked
+	redis.fail = true
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "lbry://nonexistent"}), "")
+	require.NoError(t, err)
+	res, err = shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestHooks_LeavePublishedToEcho(t *testing.T) {
+	path, cleanup := writeDump(t, dump)
+	defer cleanup()
+	s := NewSource(Config{Source: path, Capacity: 100, FalsePositiveRate: 0.001, RefreshInterval: time.Hour, MaxAge: time.Hour})
+	require.NoError(t, s.Reload())
+	SetDefault(s)
+	defer SetDefault(nil)
+
+	idx := published.NewIndex(time.Hour, 10)
+	idx.Add(&published.Claim{ClaimID: "0123456789012345678901234567890123456789", Name: "just-published"})
+	published.SetIndex(idx)
+	defer published.SetIndex(nil)
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "lbry://just-published"}), "")
+	require.NoError(t, err)
+	res, err := shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
@@ -0,0 +1,341 @@
+// Package urlfilter answers resolves of obviously nonexistent URLs without calling the SDK.
+//
+// A bloom filter of existing claim names and claim IDs is periodically rebuilt from a Hub dump.
+// A URL is only considered missing when the filter says its name (or full claim ID) was never seen,
+// which bloom filters answer without false negatives, so a false positive just means the resolve goes to the SDK.
+// The dump gets outdated as new claims appear, so the filter is not used once it's older than MaxAge,
+// claims published through lbrytv are added to it right away and existing claims it misses are added
+// when the SDK resolves them. Added claims can be shared by all instances through Redis.
+package urlfilter
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/bloom"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	claimIDLength = 40
+	// maxLineLength accommodates a claim name, which is limited by the SDK to 255 bytes, plus a claim ID.
+	maxLineLength = 1024
+	fetchTimeout  = 10 * time.Minute
+)
+
+var (
+	logger = monitor.NewModuleLogger("urlfilter")
+
+	defaultMu     sync.RWMutex
+	defaultSource *Source
+)
+
+// Config defines where the dump is read from and how the filter is used.
+type Config struct {
+	// Source is a path or an http(s) URL of a dump with one claim name or ID per line,
+	// or a name followed by a claim ID separated by whitespace. Dumps ending in .gz are decompressed.
+	Source string
+	// Capacity is the expected number of entries in the dump, the false positive rate grows beyond it.
+	Capacity          int
+	FalsePositiveRate float64
+	RefreshInterval   time.Duration
+	// MaxAge is how long a filter can be used after it was built.
+	MaxAge time.Duration
+	// PassthroughPercentage is the share of resolves for missing URLs still sent to the SDK
+	// to keep track of filter accuracy.
+	PassthroughPercentage int
+}
+
+// Filter is a set of existing claim names and IDs built from a dump.
+type Filter struct {
+	entries *bloom.Filter
+	builtAt time.Time
+}
+
+// NewFilter creates an empty filter with the same sizing as Load.
+func NewFilter(capacity int, falsePositiveRate float64) *Filter {
+	return &Filter{entries: bloom.New(capacity, falsePositiveRate), builtAt: time.Now()}
+}
+
+// Load builds a filter from a dump at source.
+func Load(source string, capacity int, falsePositiveRate float64) (*Filter, error) {
+	r, err := open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	f := NewFilter(capacity, falsePositiveRate)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
+	for scanner.Scan() {
+		for _, field := range strings.Fields(scanner.Text()) {
+			f.entries.Add(normalize(field))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Prefix("error reading claim dump", err)
+	}
+	if f.entries.Count() > capacity {
+		logger.Log().Warnf("claim dump has %v entries, over the filter capacity of %v", f.entries.Count(), capacity)
+	}
+	return f, nil
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	for _, c := range r.closers {
+		c.Close()
+	}
+	return nil
+}
+
+func open(source string) (io.ReadCloser, error) {
+	var raw io.ReadCloser
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		res, err := (&http.Client{Timeout: fetchTimeout}).Get(source)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			return nil, errors.Err("claim dump download failed: %v", res.Status)
+		}
+		raw = res.Body
+	} else {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		raw = f
+	}
+	if !strings.HasSuffix(source, ".gz") {
+		return raw, nil
+	}
+	gz, err := gzip.NewReader(raw)
+	if err != nil {
+		raw.Close()
+		return nil, errors.Prefix("invalid gzip claim dump", err)
+	}
+	return readCloser{Reader: gz, closers: []io.Closer{gz, raw}}, nil
+}
+
+// Add puts a claim name or ID into the filter.
+func (f *Filter) Add(entry string) {
+	f.entries.Add(normalize(entry))
+}
+
+// Missing returns true if url definitely doesn't point to an existing claim.
+// URLs which cannot be checked reliably are never reported as missing.
+func (f *Filter) Missing(url string) bool {
+	name, claimID, ok := parseURL(url)
+	if !ok {
+		return false
+	}
+	if !f.entries.Test(name) {
+		return true
+	}
+	return claimID != "" && !f.entries.Test(claimID)
+}
+
+// parseURL extracts the first claim name in url (the channel for channel URLs) and the full claim ID
+// following it, if any. Only plain ASCII names are handled because names are matched
+// after the same normalization the Hub does, which for ASCII is just lowercasing.
+func parseURL(url string) (name, claimID string, ok bool) {
+	url = strings.TrimPrefix(url, "lbry://")
+	if i := strings.IndexByte(url, '/'); i >= 0 {
+		url = url[:i]
+	}
+	name = url
+	if i := strings.IndexAny(url, "#:$*"); i >= 0 {
+		name = url[:i]
+		if url[i] == '#' || url[i] == ':' {
+			if id := url[i+1:]; len(id) == claimIDLength && isHex(id) {
+				claimID = strings.ToLower(id)
+			}
+		}
+	}
+	if name == "" || name == "@" {
+		return "", "", false
+	}
+	for _, r := range name {
+		if r > 127 || r == '%' || r == '?' || r == '&' || r == ' ' {
+			return "", "", false
+		}
+	}
+	return normalize(name), claimID, true
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func normalize(entry string) string {
+	return strings.ToLower(entry)
+}
+
+// Source keeps the current filter, rebuilding it from the dump every RefreshInterval.
+type Source struct {
+	cfg Config
+
+	mu     sync.RWMutex
+	filter *Filter
+	// recent keeps entries added since startup with the time they were added,
+	// they are put into rebuilt filters as the dump might have been taken before they appeared.
+	recent map[string]time.Time
+	// shared keeps entries added by all instances, if set
+	shared *sharedEntries
+}
+
+// NewSource creates a source without a filter, URLs are not checked until Reload succeeds.
+func NewSource(cfg Config) *Source {
+	return &Source{cfg: cfg, recent: map[string]time.Time{}}
+}
+
+// ShareEntries makes s share entries with other instances through the sorted set at key in Redis.
+// Entries added by any instance go into rebuilt filters, and resolves of them aren't answered as missing.
+func (s *Source) ShareEntries(client redisDoer, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shared = &sharedEntries{client: client, key: key}
+}
+
+// Add puts a claim name or ID into the current filter and ones built from dumps in the near future.
+func (s *Source) Add(entry string) {
+	s.mu.Lock()
+	now := time.Now()
+	s.recent[entry] = now
+	if s.filter != nil {
+		s.filter.Add(entry)
+	}
+	shared := s.shared
+	s.mu.Unlock()
+
+	if shared != nil {
+		if err := shared.add(normalize(entry), now); err != nil {
+			logger.Log().Warnf("cannot share missing URL filter entry: %v", err)
+		}
+	}
+}
+
+// addedElsewhere returns true if the name or claim ID of any of urls has been added by another instance
+// since the current filter could have missed it, putting it into the filter if so. Failures to check
+// count as added, so resolves go to the SDK rather than be answered as missing.
+func (s *Source) addedElsewhere(urls []string) bool {
+	s.mu.RLock()
+	shared, f := s.shared, s.filter
+	s.mu.RUnlock()
+	if shared == nil || f == nil {
+		return false
+	}
+	since := time.Now().Add(-s.replayPeriod())
+	for _, u := range urls {
+		name, claimID, _ := parseURL(u)
+		for _, entry := range []string{name, claimID} {
+			if entry == "" {
+				continue
+			}
+			added, err := shared.has(entry, since)
+			if err != nil {
+				logger.Log().Warnf("cannot check shared missing URL filter entries: %v", err)
+				return true
+			}
+			if added {
+				f.Add(entry)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replayPeriod is how long added entries are put into rebuilt filters, dumps should have them by then.
+func (s *Source) replayPeriod() time.Duration {
+	return 2 * s.cfg.RefreshInterval
+}
+
+// Reload rebuilds the filter from the dump.
+func (s *Source) Reload() error {
+	start := time.Now()
+	f, err := Load(s.cfg.Source, s.cfg.Capacity, s.cfg.FalsePositiveRate)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	// Dumps lag behind, so entries are replayed until the next couple of rebuilds should have them
+	for entry, added := range s.recent {
+		if time.Since(added) > s.replayPeriod() {
+			delete(s.recent, entry)
+			continue
+		}
+		f.Add(entry)
+	}
+	shared := s.shared
+	s.mu.Unlock()
+	if shared != nil {
+		entries, err := shared.since(time.Now().Add(-s.replayPeriod()))
+		if err != nil {
+			logger.Log().Warnf("cannot replay shared missing URL filter entries: %v", err)
+		}
+		for _, entry := range entries {
+			f.Add(entry)
+		}
+	}
+	s.mu.Lock()
+	s.filter = f
+	s.mu.Unlock()
+	metrics.ResolveFilterEntries.Set(float64(f.entries.Count()))
+	logger.Log().Infof("missing URL filter rebuilt with %v entries in %.1fs", f.entries.Count(), time.Since(start).Seconds())
+	return nil
+}
+
+// Watch loads the filter and keeps reloading it every RefreshInterval.
+func (s *Source) Watch() {
+	for {
+		if err := s.Reload(); err != nil {
+			logger.Log().Errorf("error rebuilding missing URL filter: %v", err)
+			monitor.ErrorToSentry(err)
+		}
+		time.Sleep(s.cfg.RefreshInterval)
+	}
+}
+
+// Filter returns the current filter or nil if it hasn't been loaded yet or is older than MaxAge.
+func (s *Source) Filter() *Filter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.filter == nil || (s.cfg.MaxAge > 0 && time.Since(s.filter.builtAt) > s.cfg.MaxAge) {
+		return nil
+	}
+	return s.filter
+}
+
+// SetDefault makes s the source used by hooks installed with InstallHooks.
+func SetDefault(s *Source) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultSource = s
+}
+
+func getDefault() *Source {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultSource
+}
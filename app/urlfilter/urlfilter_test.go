@@ -0,0 +1,120 @@
+package urlfilter
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	whatClaimID = "19b9c243bea0c45175e6a6027911abbad53e983e"
+	dump        = "what " + whatClaimID + "\n@lbry\nlbry 3db81c073f82fd1bb670c65f526faea3b8546720\n"
+)
+
+func writeDump(t *testing.T, content string) (string, func()) {
+	dir, err := ioutil.TempDir("", "urlfilter")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "claims.txt.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	_, err = gz.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestFilter_Missing(t *testing.T) {
+	path, cleanup := writeDump(t, dump)
+	defer cleanup()
+	f, err := Load(path, 100, 0.001)
+	require.NoError(t, err)
+
+	for _, u := range []string{
+		"lbry://what",
+		"What",
+		"lbry://what#" + whatClaimID,
+		"lbry://what:19b9",
+		"lbry://@lbry/whatever",
+		"lbry://what$2",
+		// Can't be checked reliably
+		"lbry://ünïcode",
+		"lbry://what%20ever",
+		"lbry://",
+	} {
+		assert.False(t, f.Missing(u), u)
+	}
+	for _, u := range []string{
+		"lbry://nonexistent-claim-name",
+		"lbry://@nonexistent/what",
+		"lbry://what#0000000000000000000000000000000000000000",
+	} {
+		assert.True(t, f.Missing(u), u)
+	}
+}
+
+func TestHooks(t *testing.T) {
+	path, cleanup := writeDump(t, dump)
+	defer cleanup()
+	s := NewSource(Config{Source: path, Capacity: 100, FalsePositiveRate: 0.001, RefreshInterval: time.Hour, MaxAge: time.Hour})
+	SetDefault(s)
+	defer SetDefault(nil)
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"lbry://nonexistent"}}), "")
+	require.NoError(t, err)
+	res, err := shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res, "filter is not loaded yet")
+
+	require.NoError(t, s.Reload())
+	res, err = shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, map[string]interface{}{
+		"lbry://nonexistent": map[string]interface{}{
+			"error": map[string]interface{}{"name": "NOT_FOUND", "text": `Could not find claim at "lbry://nonexistent".`},
+		},
+	}, res.Result)
+
+	// A single existing URL sends the whole resolve to the SDK
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": []interface{}{"lbry://nonexistent", "what"}}), "")
+	require.NoError(t, err)
+	res, err = shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	// A claim created after the dump was taken is added once the SDK finds it and kept after the filter is rebuilt
+	q, err = query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{"urls": "lbry://brand-new"}), "")
+	require.NoError(t, err)
+	_, err = checkResolved(nil, &query.HookContext{Query: q, Response: &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"lbry://brand-new": map[string]interface{}{"claim_id": "abc", "name": "brand-new"},
+	}}})
+	require.NoError(t, err)
+	require.NoError(t, s.Reload())
+	res, err = shortcutMissing(nil, &query.HookContext{Query: q})
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestSource_MaxAge(t *testing.T) {
+	path, cleanup := writeDump(t, dump)
+	defer cleanup()
+	s := NewSource(Config{Source: path, Capacity: 100, FalsePositiveRate: 0.01, MaxAge: time.Millisecond})
+	require.NoError(t, s.Reload())
+	time.Sleep(5 * time.Millisecond)
+	assert.Nil(t, s.Filter())
+
+	s = NewSource(Config{Source: filepath.Join(filepath.Dir(path), "missing.txt")})
+	assert.Error(t, s.Reload())
+	assert.Nil(t, s.Filter())
+}
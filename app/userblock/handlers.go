@@ -0,0 +1,76 @@
+package userblock
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/lbryio/lbrytv/app/auth"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+type blockRequest struct {
+	ChannelID string `json:"channel_id"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotBlocked):
+		status = http.StatusNotFound
+	case errors.Is(err, ErrInvalidChannelID):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrTooManyBlocked):
+		status = http.StatusConflict
+	default:
+		logger.Log().Error(err)
+	}
+	responses.WriteError(w, status, err)
+}
+
+// HandleList returns channels blocked by the authenticated user.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	channels, err := List(user.ID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	responses.WriteJSON(w, http.StatusOK, channels)
+}
+
+// HandleBlock adds a channel to the authenticated user's list.
+func HandleBlock(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	var req blockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		responses.WriteError(w, http.StatusBadRequest, errors.Err("malformed JSON: %v", err))
+		return
+	}
+	if err := Block(user.ID, req.ChannelID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleUnblock removes the channel with channel_id from the URL from the authenticated user's list.
+func HandleUnblock(w http.ResponseWriter, r *http.Request) {
+	user := auth.Authenticate(w, r)
+	if user == nil {
+		return
+	}
+	if err := Unblock(user.ID, mux.Vars(r)["channel_id"]); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
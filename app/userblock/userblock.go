@@ -0,0 +1,204 @@
+// Package userblock keeps lists of channels users have blocked, stored server-side so blocking
+// works the same on all of their devices.
+//
+// Claims from blocked channels are removed from claim_search responses proxied for the user (see Filter).
+// Lists are cached in memory for a short while, so blocks made through another instance may take up to
+// cacheTTL to apply.
+package userblock
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	gocache "github.com/patrickmn/go-cache"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	// MaxBlocked is how many channels a user can block.
+	MaxBlocked = 1000
+
+	cacheTTL = time.Minute
+)
+
+var (
+	logger = monitor.NewModuleLogger("userblock")
+
+	ErrInvalidChannelID = errors.Base("channel_id must be 40 hex characters")
+	ErrTooManyBlocked   = errors.Base("too many channels blocked")
+	ErrNotBlocked       = errors.Base("channel is not blocked")
+
+	channelIDPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	blockedCache = gocache.New(cacheTTL, 10*time.Minute)
+)
+
+// BlockedChannel is a channel blocked by a user.
+type BlockedChannel struct {
+	ChannelID string    `json:"channel_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// List returns channels blocked by the user, the most recently blocked first.
+func List(userID int) ([]BlockedChannel, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT channel_id, created_at FROM user_blocked_channels WHERE user_id = $1 ORDER BY created_at DESC`, userID,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	channels := []BlockedChannel{}
+	for rows.Next() {
+		var c BlockedChannel
+		if err := rows.Scan(&c.ChannelID, &c.CreatedAt); err != nil {
+			return nil, errors.Err(err)
+		}
+		channels = append(channels, c)
+	}
+	return channels, errors.Err(rows.Err())
+}
+
+// Block adds the channel to the user's list. Blocking a channel again is not an error.
+func Block(userID int, channelID string) error {
+	if !channelIDPattern.MatchString(channelID) {
+		return ErrInvalidChannelID
+	}
+	res, err := boil.GetDB().Exec(
+		`INSERT INTO user_blocked_channels (user_id, channel_id)
+		SELECT $1, $2 WHERE (SELECT count(*) FROM user_blocked_channels WHERE user_id = $1) < $3
+		ON CONFLICT (user_id, channel_id) DO NOTHING`,
+		userID, channelID, MaxBlocked,
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Err(err)
+	} else if n == 0 {
+		blocked, err := blockedChannels(userID)
+		if err != nil {
+			return err
+		}
+		if !blocked[channelID] {
+			return ErrTooManyBlocked
+		}
+	}
+	blockedCache.Delete(cacheKey(userID))
+	return nil
+}
+
+// Unblock removes the channel from the user's list.
+func Unblock(userID int, channelID string) error {
+	res, err := boil.GetDB().Exec(
+		`DELETE FROM user_blocked_channels WHERE user_id = $1 AND channel_id = $2`, userID, channelID,
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	blockedCache.Delete(cacheKey(userID))
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Err(err)
+	} else if n == 0 {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+func cacheKey(userID int) string {
+	return strconv.Itoa(userID)
+}
+
+// blockedChannels returns the set of channel IDs blocked by the user, cached for cacheTTL.
+func blockedChannels(userID int) (map[string]bool, error) {
+	if cached, ok := blockedCache.Get(cacheKey(userID)); ok {
+		return cached.(map[string]bool), nil
+	}
+	rows, err := boil.GetDB().Query(`SELECT channel_id FROM user_blocked_channels WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	blocked := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Err(err)
+		}
+		blocked[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Err(err)
+	}
+	blockedCache.SetDefault(cacheKey(userID), blocked)
+	return blocked, nil
+}
+
+// Filter removes claims from channels blocked by the user from a claim_search response, including channel
+// claims themselves and reposts of blocked content. Results served from cache are decoded first.
+// Failures are only logged so the user still gets the response.
+func Filter(userID int, res *jsonrpc.RPCResponse) {
+	if res == nil || res.Error != nil {
+		return
+	}
+	blocked, err := blockedChannels(userID)
+	if err != nil {
+		logger.Log().Errorf("cannot get channels blocked by user %v: %v", userID, err)
+		return
+	}
+	if len(blocked) == 0 {
+		return
+	}
+
+	var result map[string]interface{}
+	switch r := res.Result.(type) {
+	case map[string]interface{}:
+		result = r
+	case json.RawMessage:
+		if err := json.Unmarshal(r, &result); err != nil {
+			logger.Log().Errorf("cannot decode cached result: %v", err)
+			return
+		}
+	default:
+		return
+	}
+
+	items, _ := result["items"].([]interface{})
+	kept := make([]interface{}, 0, len(items))
+	for _, i := range items {
+		if claim, ok := i.(map[string]interface{}); ok && isFromBlocked(claim, blocked) {
+			continue
+		}
+		kept = append(kept, i)
+	}
+	if removed := len(items) - len(kept); removed > 0 {
+		result["items"] = kept
+		res.Result = result
+		metrics.UserBlockFiltered.Add(float64(removed))
+	}
+}
+
+// isFromBlocked checks the claim, its channel and, for reposts, the reposted claim.
+func isFromBlocked(claim map[string]interface{}, blocked map[string]bool) bool {
+	if claim["value_type"] == "channel" {
+		if id, _ := claim["claim_id"].(string); blocked[id] {
+			return true
+		}
+	}
+	if channel, ok := claim["signing_channel"].(map[string]interface{}); ok {
+		if id, _ := channel["claim_id"].(string); blocked[id] {
+			return true
+		}
+	}
+	if reposted, ok := claim["reposted_claim"].(map[string]interface{}); ok {
+		return isFromBlocked(reposted, blocked)
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package userblock
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	blockedID = "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"
+	otherID   = "1b2c3d4e5f60718293a4b5c6d7e8f90123456789"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestBlockAndUnblock(t *testing.T) {
+	assert.True(t, errors.Is(Block(1, "@channel"), ErrInvalidChannelID))
+	require.NoError(t, Block(1, blockedID))
+	require.NoError(t, Block(1, blockedID))
+
+	channels, err := List(1)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, blockedID, channels[0].ChannelID)
+	channels, err = List(2)
+	require.NoError(t, err)
+	assert.Empty(t, channels)
+
+	require.NoError(t, Unblock(1, blockedID))
+	assert.True(t, errors.Is(Unblock(1, blockedID), ErrNotBlocked))
+}
+
+func searchResult() map[string]interface{} {
+	return map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"claim_id": "a", "signing_channel": map[string]interface{}{"claim_id": blockedID}},
+		map[string]interface{}{"claim_id": "b", "signing_channel": map[string]interface{}{"claim_id": otherID}},
+		map[string]interface{}{"claim_id": blockedID, "value_type": "channel"},
+		map[string]interface{}{"claim_id": "c", "value_type": "repost",
+			"reposted_claim": map[string]interface{}{"claim_id": "d", "signing_channel": map[string]interface{}{"claim_id": blockedID}}},
+	}}
+}
+
+func TestFilter(t *testing.T) {
+	require.NoError(t, Block(3, blockedID))
+	defer Unblock(3, blockedID)
+
+	res := &jsonrpc.RPCResponse{Result: searchResult()}
+	Filter(3, res)
+	items := res.Result.(map[string]interface{})["items"].([]interface{})
+	require.Len(t, items, 1)
+	assert.Equal(t, "b", items[0].(map[string]interface{})["claim_id"])
+
+	// Cached results are decoded
+	raw, err := json.Marshal(searchResult())
+	require.NoError(t, err)
+	res = &jsonrpc.RPCResponse{Result: json.RawMessage(raw)}
+	Filter(3, res)
+	assert.Len(t, res.Result.(map[string]interface{})["items"], 1)
+
+	// Other users' results are left alone
+	res = &jsonrpc.RPCResponse{Result: json.RawMessage(raw)}
+	Filter(4, res)
+	assert.Equal(t, json.RawMessage(raw), res.Result)
+}
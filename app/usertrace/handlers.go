@@ -0,0 +1,68 @@
+package usertrace
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/gorilla/mux"
+)
+
+type traceResponse struct {
+	UserID       int        `json:"user_id"`
+	Enabled      bool       `json:"enabled"`
+	EnabledUntil *time.Time `json:"enabled_until,omitempty"`
+	Traces       []Trace    `json:"traces"`
+}
+
+func userIDFromRequest(r *http.Request) (int, error) {
+	userID, err := strconv.Atoi(mux.Vars(r)["user_id"])
+	if err != nil || userID <= 0 {
+		return 0, errors.Err("invalid user id")
+	}
+	return userID, nil
+}
+
+func writeState(w http.ResponseWriter, userID int) {
+	rsp := traceResponse{UserID: userID, Traces: defaultStore.Get(userID)}
+	if until := defaultStore.EnabledUntil(userID); !until.IsZero() {
+		rsp.Enabled = true
+		rsp.EnabledUntil = &until
+	}
+	responses.WriteJSON(w, http.StatusOK, rsp)
+}
+
+// HandleEnable turns on debug mode for the user specified in the URL.
+func HandleEnable(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	defaultStore.Enable(userID)
+	writeState(w, userID)
+}
+
+// HandleDisable turns off debug mode for the user specified in the URL.
+func HandleDisable(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	defaultStore.Disable(userID)
+	writeState(w, userID)
+}
+
+// HandleList returns debug mode state and captured traces for the user specified in the URL.
+func HandleList(w http.ResponseWriter, r *http.Request) {
+	userID, err := userIDFromRequest(r)
+	if err != nil {
+		responses.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeState(w, userID)
+}
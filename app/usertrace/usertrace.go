@@ -0,0 +1,184 @@
+// Package usertrace captures full request/response pairs for users an admin has put into debug mode,
+// so problems affecting a single user can be investigated without trawling through logs.
+package usertrace
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ybbus/jsonrpc"
+)
+
+const valueMask = "****"
+
+var (
+	logger       = monitor.NewModuleLogger("usertrace")
+	defaultStore = NewStore(config.GetUserTraceTTL(), config.GetUserTraceLimit())
+
+	// sensitiveParams are masked in captured traces regardless of where they appear in the payload.
+	sensitiveParams = []string{"password", "new_password", "private_key", "auth_token", "token", "data", "seed"}
+)
+
+// Trace is a single captured request/response pair.
+type Trace struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Method    string      `json:"method"`
+	Request   interface{} `json:"request"`
+	Response  interface{} `json:"response,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// Store keeps debug flags and a bounded list of recent traces per user.
+// Both flags and traces expire after ttl.
+type Store struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	limit   int
+	enabled map[int]time.Time
+	traces  map[int][]Trace
+}
+
+// NewStore creates a trace store retaining up to limit traces per user for ttl.
+func NewStore(ttl time.Duration, limit int) *Store {
+	return &Store{
+		ttl:     ttl,
+		limit:   limit,
+		enabled: map[int]time.Time{},
+		traces:  map[int][]Trace{},
+	}
+}
+
+// Enable turns on debug mode for the user, returning the time it will be automatically turned off.
+func (s *Store) Enable(userID int) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until := time.Now().Add(s.ttl)
+	s.enabled[userID] = until
+	logger.WithFields(logrus.Fields{"user_id": userID, "until": until}).Info("debug mode enabled")
+	return until
+}
+
+// Disable turns off debug mode for the user. Captured traces are kept until they expire.
+func (s *Store) Disable(userID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enabled, userID)
+	logger.WithFields(logrus.Fields{"user_id": userID}).Info("debug mode disabled")
+}
+
+// EnabledUntil returns the time debug mode for the user expires, or zero time if it's not enabled.
+func (s *Store) EnabledUntil(userID int) time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	until, ok := s.enabled[userID]
+	if !ok || time.Now().After(until) {
+		return time.Time{}
+	}
+	return until
+}
+
+// IsEnabled returns true if debug mode is on for the user.
+func (s *Store) IsEnabled(userID int) bool {
+	return !s.EnabledUntil(userID).IsZero()
+}
+
+// Add stores a trace for the user, evicting the oldest one when the limit is reached.
+func (s *Store) Add(userID int, t Trace) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traces := append(s.expired(s.traces[userID]), t)
+	if len(traces) > s.limit {
+		traces = traces[len(traces)-s.limit:]
+	}
+	s.traces[userID] = traces
+}
+
+// Get returns unexpired traces captured for the user, oldest first.
+func (s *Store) Get(userID int) []Trace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	traces := s.expired(s.traces[userID])
+	if len(traces) == 0 {
+		delete(s.traces, userID)
+		return []Trace{}
+	}
+	s.traces[userID] = traces
+	return append([]Trace{}, traces...)
+}
+
+// expired drops traces older than ttl from the list.
+func (s *Store) expired(traces []Trace) []Trace {
+	cutoff := time.Now().Add(-s.ttl)
+	for i, t := range traces {
+		if t.Timestamp.After(cutoff) {
+			return traces[i:]
+		}
+	}
+	return nil
+}
+
+// Record captures a sanitized copy of the request and response if debug mode is on for the user.
+func Record(userID int, req *jsonrpc.RPCRequest, res *jsonrpc.RPCResponse, callErr error) {
+	if userID == 0 || req == nil || !defaultStore.IsEnabled(userID) {
+		return
+	}
+	t := Trace{
+		Timestamp: time.Now(),
+		Method:    req.Method,
+		Request:   Sanitize(req),
+	}
+	if res != nil {
+		t.Response = Sanitize(res)
+	}
+	if callErr != nil {
+		t.Error = callErr.Error()
+	}
+	defaultStore.Add(userID, t)
+}
+
+// Sanitize returns a generic copy of v with sensitive values masked.
+func Sanitize(v interface{}) interface{} {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var copied interface{}
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return nil
+	}
+	return mask(copied)
+}
+
+func mask(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		for k, val := range typed {
+			if isSensitive(k) {
+				typed[k] = valueMask
+			} else {
+				typed[k] = mask(val)
+			}
+		}
+	case []interface{}:
+		for i, val := range typed {
+			typed[i] = mask(val)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, p := range sensitiveParams {
+		if key == p {
+			return true
+		}
+	}
+	return false
+}
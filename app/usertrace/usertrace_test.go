@@ -0,0 +1,57 @@
+package usertrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestStore_EnableDisable(t *testing.T) {
+	s := NewStore(time.Hour, 10)
+	assert.False(t, s.IsEnabled(1))
+	s.Enable(1)
+	assert.True(t, s.IsEnabled(1))
+	assert.False(t, s.IsEnabled(2))
+	s.Disable(1)
+	assert.False(t, s.IsEnabled(1))
+}
+
+func TestStore_Bounded(t *testing.T) {
+	s := NewStore(time.Hour, 3)
+	for i := 0; i < 5; i++ {
+		s.Add(1, Trace{Timestamp: time.Now(), Method: string(rune('a' + i))})
+	}
+	traces := s.Get(1)
+	assert.Len(t, traces, 3)
+	assert.Equal(t, "c", traces[0].Method)
+	assert.Equal(t, "e", traces[2].Method)
+}
+
+func TestStore_Expiry(t *testing.T) {
+	s := NewStore(time.Minute, 3)
+	s.Add(1, Trace{Timestamp: time.Now().Add(-2 * time.Minute), Method: "old"})
+	s.Add(1, Trace{Timestamp: time.Now(), Method: "new"})
+	traces := s.Get(1)
+	assert.Len(t, traces, 1)
+	assert.Equal(t, "new", traces[0].Method)
+}
+
+func TestRecord(t *testing.T) {
+	defaultStore = NewStore(time.Hour, 10)
+
+	req := jsonrpc.NewRequest("wallet_decrypt", map[string]interface{}{"password": "hunter2", "wallet_id": "x"})
+	Record(5, req, nil, nil)
+	assert.Empty(t, defaultStore.Get(5))
+
+	defaultStore.Enable(5)
+	Record(5, req, &jsonrpc.RPCResponse{Result: true}, nil)
+	traces := defaultStore.Get(5)
+	if assert.Len(t, traces, 1) {
+		params := traces[0].Request.(map[string]interface{})["params"].(map[string]interface{})
+		assert.Equal(t, valueMask, params["password"])
+		assert.Equal(t, "x", params["wallet_id"])
+	}
+	assert.Equal(t, "hunter2", req.Params.(map[string]interface{})["password"])
+}
@@ -0,0 +1,71 @@
+package verification
+
+import (
+	"github.com/lbryio/lbrytv/app/query"
+
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	hookName = "verification"
+
+	// verifiedField is set on every channel in resolve and claim_search results.
+	verifiedField = "is_verified"
+)
+
+// InstallHooks makes c annotate channels in resolve and claim_search responses with their verification status.
+func InstallHooks(c *query.Caller) {
+	c.AddPostflightHook(query.MethodResolve, annotateResolved, hookName)
+	c.AddPostflightHook(query.MethodClaimSearch, annotateSearched, hookName)
+}
+
+func annotateResolved(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	for _, v := range result {
+		if claim, ok := v.(map[string]interface{}); ok {
+			annotate(claim)
+		}
+	}
+	return nil, nil
+}
+
+func annotateSearched(_ *query.Caller, hctx *query.HookContext) (*jsonrpc.RPCResponse, error) {
+	if hctx.Response == nil || hctx.Response.Error != nil {
+		return nil, nil
+	}
+	result, ok := hctx.Response.Result.(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	items, _ := result["items"].([]interface{})
+	for _, i := range items {
+		if claim, ok := i.(map[string]interface{}); ok {
+			annotate(claim)
+		}
+	}
+	return nil, nil
+}
+
+// annotate marks the claim if it's a channel, the channel it's signed by and, for reposts, the reposted claim.
+func annotate(claim map[string]interface{}) {
+	if claim["value_type"] == "channel" {
+		markChannel(claim)
+	}
+	if channel, ok := claim["signing_channel"].(map[string]interface{}); ok {
+		markChannel(channel)
+	}
+	if reposted, ok := claim["reposted_claim"].(map[string]interface{}); ok {
+		annotate(reposted)
+	}
+}
+
+func markChannel(channel map[string]interface{}) {
+	id, _ := channel["claim_id"].(string)
+	channel[verifiedField] = IsVerified(id)
+}
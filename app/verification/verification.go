@@ -0,0 +1,156 @@
+// Package verification keeps track of channels verified by admins.
+//
+// Verified channels are stored in the database and each instance holds a copy of them in memory, reloaded
+// on startup and periodically after (see Load). Channels in proxied resolve and claim_search responses are
+// annotated with their verification status (see InstallHooks), so all frontends show badges the same way.
+package verification
+
+import (
+	"database/sql"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+	"github.com/volatiletech/sqlboiler/boil"
+)
+
+var (
+	logger = monitor.NewModuleLogger("verification")
+
+	ErrNotFound       = errors.Base("channel is not verified")
+	ErrInvalidClaimID = errors.Base("claim_id must be 40 hex characters")
+	ErrMissingFields  = errors.Base("verified_by is required")
+
+	claimIDPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+	verifiedMu sync.RWMutex
+	verified   = map[string]bool{}
+)
+
+// Channel is a verified channel.
+type Channel struct {
+	ClaimID    string    `json:"claim_id"`
+	VerifiedBy string    `json:"verified_by"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+const channelColumns = `claim_id, verified_by, note, created_at`
+
+func scanChannel(s interface{ Scan(...interface{}) error }) (*Channel, error) {
+	c := &Channel{}
+	err := s.Scan(&c.ClaimID, &c.VerifiedBy, &c.Note, &c.CreatedAt)
+	return c, err
+}
+
+// IsVerified returns true for channels verified by admins.
+func IsVerified(claimID string) bool {
+	verifiedMu.RLock()
+	defer verifiedMu.RUnlock()
+	return verified[claimID]
+}
+
+// Load replaces the in-memory set of verified channels with the one in the database. It's run on startup,
+// after changes and periodically, so changes made on other instances are picked up.
+func Load() error {
+	rows, err := boil.GetDB().Query(`SELECT claim_id FROM verified_channels`)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer rows.Close()
+	loaded := map[string]bool{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return errors.Err(err)
+		}
+		loaded[id] = true
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Err(err)
+	}
+	verifiedMu.Lock()
+	verified = loaded
+	verifiedMu.Unlock()
+	metrics.VerifiedChannels.Set(float64(len(loaded)))
+	return nil
+}
+
+// Verify marks the channel with claimID as verified. Verifying it again updates verifiedBy and note.
+func Verify(claimID, verifiedBy, note string) (*Channel, error) {
+	if !claimIDPattern.MatchString(claimID) {
+		return nil, ErrInvalidClaimID
+	}
+	if verifiedBy == "" {
+		return nil, ErrMissingFields
+	}
+	c, err := scanChannel(boil.GetDB().QueryRow(
+		`INSERT INTO verified_channels (claim_id, verified_by, note) VALUES ($1, $2, $3)
+		ON CONFLICT (claim_id) DO UPDATE SET verified_by = $2, note = $3 RETURNING `+channelColumns,
+		claimID, verifiedBy, note,
+	))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	logger.WithFields(logrus.Fields{"claim_id": claimID, "verified_by": verifiedBy}).Info("channel verified")
+	return c, reload()
+}
+
+// Unverify removes the verification of the channel with claimID.
+func Unverify(claimID string) error {
+	res, err := boil.GetDB().Exec(`DELETE FROM verified_channels WHERE claim_id = $1`, claimID)
+	if err != nil {
+		return errors.Err(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return errors.Err(err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	logger.WithFields(logrus.Fields{"claim_id": claimID}).Info("channel verification removed")
+	return reload()
+}
+
+// Get returns the verification of the channel with claimID.
+func Get(claimID string) (*Channel, error) {
+	c, err := scanChannel(boil.GetDB().QueryRow(
+		`SELECT `+channelColumns+` FROM verified_channels WHERE claim_id = $1`, claimID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return c, errors.Err(err)
+}
+
+// List returns verified channels, the most recently verified first.
+func List(limit, offset int) ([]*Channel, error) {
+	rows, err := boil.GetDB().Query(
+		`SELECT `+channelColumns+` FROM verified_channels ORDER BY created_at DESC LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	channels := []*Channel{}
+	for rows.Next() {
+		c, err := scanChannel(rows)
+		if err != nil {
+			return nil, errors.Err(err)
+		}
+		channels = append(channels, c)
+	}
+	return channels, errors.Err(rows.Err())
+}
+
+func reload() error {
+	if err := Load(); err != nil {
+		return errors.Prefix("saved but not reloaded", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package verification
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+const (
+	verifiedID   = "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"
+	unverifiedID = "1b2c3d4e5f60718293a4b5c6d7e8f90123456789"
+)
+
+func TestMain(m *testing.M) {
+	dbConfig := config.GetDatabase()
+	params := storage.ConnParams{
+		Connection: dbConfig.Connection,
+		DBName:     dbConfig.DBName,
+		Options:    dbConfig.Options,
+	}
+	dbConn, connCleanup := storage.CreateTestConn(params)
+	dbConn.SetDefaultConnection()
+
+	code := m.Run()
+
+	connCleanup()
+	os.Exit(code)
+}
+
+func TestVerifyAndUnverify(t *testing.T) {
+	_, err := Verify("@channel", "admin", "")
+	assert.True(t, errors.Is(err, ErrInvalidClaimID))
+	_, err = Verify(verifiedID, "", "")
+	assert.True(t, errors.Is(err, ErrMissingFields))
+
+	c, err := Verify(verifiedID, "admin", "well-known creator")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", c.VerifiedBy)
+	assert.True(t, IsVerified(verifiedID))
+
+	channels, err := List(10, 0)
+	require.NoError(t, err)
+	require.Len(t, channels, 1)
+	assert.Equal(t, verifiedID, channels[0].ClaimID)
+
+	require.NoError(t, Unverify(verifiedID))
+	assert.False(t, IsVerified(verifiedID))
+	assert.True(t, errors.Is(Unverify(verifiedID), ErrNotFound))
+	_, err = Get(verifiedID)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestHooks(t *testing.T) {
+	_, err := Verify(verifiedID, "admin", "")
+	require.NoError(t, err)
+	defer Unverify(verifiedID)
+
+	stream := map[string]interface{}{
+		"claim_id":        "c4a8b3d2e0f9a7b6c5d4e3f2a1b0c9d8e7f6a5b4",
+		"value_type":      "stream",
+		"signing_channel": map[string]interface{}{"claim_id": verifiedID},
+	}
+	channel := map[string]interface{}{"claim_id": unverifiedID, "value_type": "channel"}
+	repost := map[string]interface{}{
+		"claim_id":       "d5b9c4e3f1a0b8c7d6e5f4a3b2c1d0e9f8a7b6c5",
+		"value_type":     "repost",
+		"reposted_claim": map[string]interface{}{"claim_id": verifiedID, "value_type": "channel"},
+	}
+
+	q, err := query.NewQuery(jsonrpc.NewRequest(query.MethodResolve, map[string]interface{}{}), "")
+	require.NoError(t, err)
+	hctx := &query.HookContext{Query: q, Response: &jsonrpc.RPCResponse{Result: map[string]interface{}{
+		"lbry://stream": stream, "lbry://@channel": channel,
+	}}}
+	_, err = annotateResolved(nil, hctx)
+	require.NoError(t, err)
+	assert.Equal(t, true, stream["signing_channel"].(map[string]interface{})[verifiedField])
+	assert.NotContains(t, stream, verifiedField)
+	assert.Equal(t, false, channel[verifiedField])
+
+	hctx.Response = &jsonrpc.RPCResponse{Result: map[string]interface{}{"items": []interface{}{repost}}}
+	_, err = annotateSearched(nil, hctx)
+	require.NoError(t, err)
+	assert.Equal(t, true, repost["reposted_claim"].(map[string]interface{})[verifiedField])
+}
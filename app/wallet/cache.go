@@ -15,7 +15,7 @@ var (
 	currentCache *tokenCache
 )
 
-// tokenCache stores the cache in memory
+// tokenCache stores the cache in memory, keyed by token hashes so revoked sessions can be evicted
 type tokenCache struct {
 	cache *gocache.Cache
 }
@@ -33,11 +33,11 @@ func SetTokenCache(c *tokenCache) {
 }
 
 func (c *tokenCache) set(token string, user *models.User) {
-	c.cache.Set(token, *user, gocache.DefaultExpiration)
+	c.cache.Set(hashToken(token), *user, gocache.DefaultExpiration)
 }
 
 func (c *tokenCache) get(token string) *models.User {
-	obj, ok := c.cache.Get(token)
+	obj, ok := c.cache.Get(hashToken(token))
 	if !ok {
 		metrics.AuthTokenCacheMisses.Inc()
 		return nil
@@ -47,6 +47,10 @@ func (c *tokenCache) get(token string) *models.User {
 	return &user
 }
 
+func (c *tokenCache) evict(tokenHash string) {
+	c.cache.Delete(tokenHash)
+}
+
 func (c *tokenCache) flush() {
 	c.cache.Flush()
 }
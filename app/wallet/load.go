@@ -0,0 +1,162 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/lbrynet"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+var currentLoader *loadCoordinator
+
+// loadCall is a wallet load in progress, concurrent callers wait for its result instead of sending their own wallet_add.
+type loadCall struct {
+	done chan struct{}
+	err  error
+}
+
+// loadCoordinator de-duplicates wallet loads, which the SDK fails on when they overlap.
+// Loads of the same wallet on the same SDK are shared by callers within an instance,
+// and serialized between instances on a Postgres advisory lock. An instance getting the lock
+// after another one has loaded the wallet less than shareFor ago reuses that result.
+type loadCoordinator struct {
+	lockTimeout time.Duration
+	shareFor    time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*loadCall
+}
+
+func init() {
+	SetLoadCoordinator(NewLoadCoordinator(30*time.Second, 10*time.Second))
+}
+
+// NewLoadCoordinator creates a coordinator waiting up to lockTimeout for loads of the same wallet by other instances.
+// The cluster-wide lock is not used when lockTimeout is zero.
+func NewLoadCoordinator(lockTimeout, shareFor time.Duration) *loadCoordinator {
+	return &loadCoordinator{lockTimeout: lockTimeout, shareFor: shareFor, calls: map[string]*loadCall{}}
+}
+
+func SetLoadCoordinator(c *loadCoordinator) {
+	currentLoader = c
+}
+
+// load calls f unless a load of the same wallet is already in progress on this instance,
+// in which case its result is returned.
+func (c *loadCoordinator) load(addr string, userID int, f func() error) error {
+	walletID := sdkrouter.WalletID(userID)
+	key := addr + "|" + walletID
+
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		metrics.WalletLoadsShared.WithLabelValues(metrics.WalletLoadSharedLocal).Inc()
+		<-call.done
+		return call.err
+	}
+	call := &loadCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	call.err = c.loadExclusive(addr, walletID, f)
+
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+	close(call.done)
+	return call.err
+}
+
+// loadExclusive calls f holding the cluster-wide lock for the wallet. Failing to get the lock
+// shouldn't prevent users from using their wallets, so f is called without it in that case.
+func (c *loadCoordinator) loadExclusive(addr, walletID string, f func() error) error {
+	if c.lockTimeout <= 0 || storage.Conn == nil || storage.Conn.DB == nil || storage.Conn.BreakerOpen() {
+		return f()
+	}
+	log := logger.WithFields(logrus.Fields{"wallet_id": walletID, "sdk": addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.lockTimeout)
+	defer cancel()
+	conn, err := storage.Conn.DB.Conn(ctx)
+	if err != nil {
+		log.Warnf("loading wallet without cluster-wide lock: %v", err)
+		return f()
+	}
+	defer conn.Close()
+
+	lockKey := loadLockKey(addr, walletID)
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		log.Warnf("loading wallet without cluster-wide lock: %v", err)
+		return f()
+	}
+	defer func() {
+		unlockCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := conn.ExecContext(unlockCtx, "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			log.Errorf("error releasing wallet load lock: %v", err)
+			// Session locks are only released when the session ends, so the connection can't go back to the pool
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+	}()
+
+	if c.shareFor > 0 {
+		var loadedAt time.Time
+		err := conn.QueryRowContext(ctx,
+			`SELECT "loaded_at" FROM "wallet_loads" WHERE "sdk_address" = $1 AND "wallet_id" = $2`,
+			addr, walletID,
+		).Scan(&loadedAt)
+		switch {
+		case err == nil && time.Since(loadedAt) < c.shareFor:
+			metrics.WalletLoadsShared.WithLabelValues(metrics.WalletLoadSharedCluster).Inc()
+			log.Debug("wallet was just loaded by another instance")
+			return nil
+		case err != nil && err != sql.ErrNoRows:
+			log.Warnf("error checking recent wallet loads: %v", err)
+		}
+	}
+
+	err = f()
+	if err != nil && !errors.Is(err, lbrynet.ErrWalletAlreadyLoaded) {
+		return err
+	}
+	_, recErr := conn.ExecContext(ctx,
+		`INSERT INTO "wallet_loads" ("sdk_address", "wallet_id", "loaded_at") VALUES ($1, $2, $3)
+		ON CONFLICT ("sdk_address", "wallet_id") DO UPDATE SET "loaded_at" = EXCLUDED."loaded_at"`,
+		addr, walletID, time.Now().UTC(),
+	)
+	if recErr != nil {
+		log.Warnf("error recording wallet load: %v", recErr)
+	}
+	return err
+}
+
+// forget removes the record of a wallet load so other instances don't reuse it after the wallet is unloaded.
+func (c *loadCoordinator) forget(addr string, userID int) {
+	if c.lockTimeout <= 0 || storage.Conn == nil || storage.Conn.DB == nil || storage.Conn.BreakerOpen() {
+		return
+	}
+	_, err := storage.Conn.DB.Exec(
+		`DELETE FROM "wallet_loads" WHERE "sdk_address" = $1 AND "wallet_id" = $2`,
+		addr, sdkrouter.WalletID(userID),
+	)
+	if err != nil {
+		logger.WithFields(logrus.Fields{"user_id": userID, "sdk": addr}).Warnf("error removing wallet load record: %v", err)
+	}
+}
+
+// loadLockKey maps a wallet on an SDK to a Postgres advisory lock key.
+func loadLockKey(addr, walletID string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(addr + "|" + walletID))
+	return int64(h.Sum64())
+}
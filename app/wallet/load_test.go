@@ -0,0 +1,93 @@
+package wallet
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/lbrynet"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadCoordinator_SharesConcurrentLoads(t *testing.T) {
+	c := NewLoadCoordinator(0, 0)
+	var calls int32
+	release := make(chan struct{})
+	f := func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return errors.Err("load failed")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.load("http://sdk", dummyUserID, f)
+		}(i)
+	}
+	// Let all callers join the first load before it completes
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+	for _, err := range errs {
+		assert.EqualError(t, err, "load failed")
+	}
+
+	// Completed loads are not reused within an instance
+	require.NoError(t, c.load("http://sdk", dummyUserID, func() error { return nil }))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestLoadCoordinator_SharesLoadsBetweenInstances(t *testing.T) {
+	storage.Conn.Truncate([]string{"wallet_loads"})
+	instance1 := NewLoadCoordinator(time.Second, time.Minute)
+	instance2 := NewLoadCoordinator(time.Second, time.Minute)
+	var calls int32
+	f := func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+
+	require.NoError(t, instance1.load("http://sdk", dummyUserID, f))
+	require.NoError(t, instance2.load("http://sdk", dummyUserID, f))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Another SDK has its own wallet
+	require.NoError(t, instance2.load("http://sdk2", dummyUserID, f))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	// Already loaded wallets count as loaded
+	instance1.forget("http://sdk", dummyUserID)
+	err := instance1.load("http://sdk", dummyUserID, func() error {
+		atomic.AddInt32(&calls, 1)
+		return lbrynet.WalletError{UserID: dummyUserID, Err: lbrynet.ErrWalletAlreadyLoaded}
+	})
+	assert.True(t, errors.Is(err, lbrynet.ErrWalletAlreadyLoaded))
+	require.NoError(t, instance2.load("http://sdk", dummyUserID, f))
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestLoadCoordinator_ExpiredLoadsNotShared(t *testing.T) {
+	storage.Conn.Truncate([]string{"wallet_loads"})
+	instance1 := NewLoadCoordinator(time.Second, time.Nanosecond)
+	instance2 := NewLoadCoordinator(time.Second, time.Nanosecond)
+	var calls int32
+	f := func() error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}
+
+	require.NoError(t, instance1.load("http://sdk", dummyUserID, f))
+	require.NoError(t, instance2.load("http://sdk", dummyUserID, f))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
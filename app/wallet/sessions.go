@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Every auth token a user signs in with is a session. Sessions are recorded when the token is authenticated
+// against internal-api, which happens once per token cache timeout, so last_seen_at is only as precise.
+// Tokens are kept hashed. A token of a revoked session is rejected here even though internal-api still accepts it,
+// and every instance drops it from its token cache within UserSessions.RevocationPollInterval.
+
+var (
+	ErrSessionRevoked  = errors.Base("session has been revoked")
+	ErrSessionNotFound = errors.Base("session not found")
+)
+
+// Session is an auth token a user is signed in with.
+type Session struct {
+	ID         int       `json:"id"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current is set on the session the listing was requested with.
+	Current bool `json:"current"`
+}
+
+// Sessions returns sessions of the user which are neither revoked nor idle, most recently used first.
+// token is the one the listing is requested with.
+func Sessions(userID int, token string) ([]*Session, error) {
+	rows, err := storage.Conn.DB.Query(
+		`SELECT id, ip, created_at, last_seen_at, token_hash = $2 FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND last_seen_at > now() - $3 * interval '1 second'
+		ORDER BY last_seen_at DESC, id DESC`,
+		userID, hashToken(token), config.GetUserSessions().IdleTimeout.Seconds(),
+	)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	defer rows.Close()
+	sessions := []*Session{}
+	for rows.Next() {
+		s := &Session{}
+		if err := rows.Scan(&s.ID, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.Current); err != nil {
+			return nil, errors.Err(err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, errors.Err(rows.Err())
+}
+
+// RevokeSession revokes a session of the user. Its token is dropped from the token cache of this instance
+// right away and from the other instances on their next revocation check.
+func RevokeSession(userID, id int) error {
+	var hash string
+	err := storage.Conn.DB.QueryRow(
+		`UPDATE user_sessions SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL RETURNING token_hash`,
+		id, userID,
+	).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	} else if err != nil {
+		return errors.Err(err)
+	}
+	currentCache.evict(hash)
+	metrics.AuthSessionsRevoked.Inc()
+	logger.WithFields(logrus.Fields{"user_id": userID, "session_id": id}).Info("session revoked")
+	return nil
+}
+
+// WatchRevocations drops tokens of sessions revoked on any instance from the token cache every interval. It blocks.
+func WatchRevocations(interval time.Duration) {
+	logger.Log().Infof("checking for revoked sessions every %v", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		// Windows of subsequent checks overlap, so revocations committed while a check runs aren't missed
+		if err := evictRevoked(2 * interval); err != nil {
+			logger.Log().Errorf("cannot check for revoked sessions: %v", err)
+		}
+	}
+}
+
+// evictRevoked drops tokens of sessions revoked within window, as told by the DB clock, from the token cache.
+func evictRevoked(window time.Duration) error {
+	rows, err := storage.Conn.DB.Query(
+		`SELECT token_hash FROM user_sessions WHERE revoked_at > now() - $1 * interval '1 second'`, window.Seconds(),
+	)
+	if err != nil {
+		return errors.Err(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return errors.Err(err)
+		}
+		currentCache.evict(hash)
+	}
+	return errors.Err(rows.Err())
+}
+
+// checkSession returns ErrSessionRevoked if the session of the token has been revoked.
+func checkSession(token string) error {
+	var revoked bool
+	err := storage.Conn.DB.QueryRow(
+		`SELECT revoked_at IS NOT NULL FROM user_sessions WHERE token_hash = $1`, hashToken(token),
+	).Scan(&revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return errors.Err(err)
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// recordSession records use of the token by the user from ip.
+func recordSession(userID int, token, ip string) error {
+	_, err := storage.Conn.DB.Exec(
+		`INSERT INTO user_sessions (user_id, token_hash, ip) VALUES ($1, $2, $3)
+		ON CONFLICT (token_hash) DO UPDATE SET ip = $3, last_seen_at = now() WHERE user_sessions.revoked_at IS NULL`,
+		userID, hashToken(token), ip,
+	)
+	return errors.Err(err)
+}
+
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,76 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessions(t *testing.T) {
+	setupTest()
+	storage.Conn.Truncate([]string{"user_sessions"})
+
+	require.NoError(t, recordSession(1, "token1", "1.2.3.4"))
+	require.NoError(t, recordSession(1, "token2", "5.6.7.8"))
+	require.NoError(t, recordSession(1, "token1", "1.2.3.5"))
+	require.NoError(t, recordSession(2, "token3", "1.2.3.4"))
+
+	sessions, err := Sessions(1, "token1")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "1.2.3.5", sessions[0].IP)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+
+	// Sessions of other users can't be revoked
+	other, err := Sessions(2, "")
+	require.NoError(t, err)
+	require.Len(t, other, 1)
+	assert.True(t, errors.Is(RevokeSession(1, other[0].ID), ErrSessionNotFound))
+
+	currentCache.set("token2", &models.User{ID: 1})
+	require.NoError(t, checkSession("token2"))
+	require.NoError(t, RevokeSession(1, sessions[1].ID))
+	assert.Nil(t, currentCache.get("token2"))
+	assert.True(t, errors.Is(checkSession("token2"), ErrSessionRevoked))
+	assert.True(t, errors.Is(RevokeSession(1, sessions[1].ID), ErrSessionNotFound))
+
+	// Revoked sessions are not brought back by later use
+	require.NoError(t, recordSession(1, "token2", "5.6.7.8"))
+	sessions, err = Sessions(1, "token1")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+
+	require.NoError(t, checkSession("unknown"))
+}
+
+func TestEvictRevoked(t *testing.T) {
+	setupTest()
+	storage.Conn.Truncate([]string{"user_sessions"})
+
+	require.NoError(t, recordSession(1, "token1", "1.2.3.4"))
+	require.NoError(t, recordSession(1, "token2", "1.2.3.4"))
+	sessions, err := Sessions(1, "")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	for _, s := range sessions {
+		require.NoError(t, RevokeSession(1, s.ID))
+	}
+
+	// Revoked on another instance
+	currentCache.set("token1", &models.User{ID: 1})
+	currentCache.set("token2", &models.User{ID: 1})
+	_, err = storage.Conn.DB.Exec(`UPDATE user_sessions SET revoked_at = now() - interval '1 hour' WHERE token_hash = $1`,
+		hashToken("token2"))
+	require.NoError(t, err)
+
+	require.NoError(t, evictRevoked(time.Minute))
+	assert.Nil(t, currentCache.get("token1"))
+	assert.NotNil(t, currentCache.get("token2"))
+}
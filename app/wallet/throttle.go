@@ -0,0 +1,169 @@
+package wallet
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Tokens rejected by internal-api are counted per token and per IP, so guessing tokens gets slower
+// with every failure and is eventually locked out, see config.AuthThrottle. Counts are kept in Redis when
+// config.AuthThrottleRedis is set and in the DB otherwise, so all instances throttle alike. Failures to reach
+// internal-api are not the client's fault and aren't counted.
+
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+
+	throttleDelayed = "delayed"
+	throttleLocked  = "locked"
+)
+
+// ErrAuthThrottled is returned instead of authenticating a token after too many failures.
+var ErrAuthThrottled = errors.Base("too many failed authentication attempts")
+
+// Lockout is passed to lockout hooks when a token or an IP gets locked out.
+type Lockout struct {
+	Scope    string    `json:"scope"`
+	IP       string    `json:"ip"`
+	Failures int       `json:"failures"`
+	Until    time.Time `json:"until"`
+}
+
+// LockoutHook is called when a token or an IP gets locked out. It's called synchronously, so it should be quick.
+type LockoutHook func(l Lockout)
+
+var (
+	lockoutHooksMu sync.RWMutex
+	lockoutHooks   []LockoutHook
+)
+
+// AddLockoutHook adds a hook called on every lockout.
+func AddLockoutHook(h LockoutHook) {
+	lockoutHooksMu.Lock()
+	defer lockoutHooksMu.Unlock()
+	lockoutHooks = append(lockoutHooks, h)
+}
+
+type throttleLimits struct {
+	scope        string
+	key          string
+	freeFailures int
+	lockoutAfter int
+}
+
+func throttleKeys(cfg config.AuthThrottle, token, ip string) []throttleLimits {
+	keys := []throttleLimits{{ThrottleScopeAccount, "account:" + hashToken(token), cfg.FreeFailures, cfg.LockoutAfter}}
+	if ip != "" {
+		keys = append(keys, throttleLimits{ThrottleScopeIP, "ip:" + ip, cfg.IPFreeFailures, cfg.IPLockoutAfter})
+	}
+	return keys
+}
+
+// checkThrottle returns ErrAuthThrottled when the token or the IP is locked out or has to wait
+// before the next attempt.
+func checkThrottle(token, ip string) error {
+	cfg := config.GetAuthThrottle()
+	if cfg.Window <= 0 {
+		return nil
+	}
+	for _, l := range throttleKeys(cfg, token, ip) {
+		st, err := currentThrottleStore.state(l.key, cfg.Window)
+		if err != nil {
+			return err
+		} else if st == nil {
+			continue
+		}
+
+		if st.locked > 0 {
+			metrics.AuthThrottled.WithLabelValues(l.scope, throttleLocked).Inc()
+			return throttled(st.locked)
+		}
+		if st.failures <= l.freeFailures {
+			continue
+		}
+		if wait := failureDelay(cfg, st.failures-l.freeFailures) - st.sinceLast; wait > 0 {
+			metrics.AuthThrottled.WithLabelValues(l.scope, throttleDelayed).Inc()
+			return throttled(wait)
+		}
+	}
+	return nil
+}
+
+// recordAuthFailure counts a failure of the token used from ip, locking them out once they reach their limits.
+// Errors reaching internal-api are not counted.
+func recordAuthFailure(token, ip string, authErr error) {
+	cfg := config.GetAuthThrottle()
+	var netErr net.Error
+	if cfg.Window <= 0 || errors.As(authErr, &netErr) {
+		return
+	}
+	for _, l := range throttleKeys(cfg, token, ip) {
+		log := logger.WithFields(logrus.Fields{"scope": l.scope, "ip": ip})
+		failures, err := currentThrottleStore.fail(l.key, cfg.Window)
+		if err != nil {
+			log.Errorf("cannot record authentication failure: %v", err)
+			continue
+		}
+		if l.lockoutAfter <= 0 || failures < l.lockoutAfter {
+			continue
+		}
+
+		until, ok, err := currentThrottleStore.lock(l.key, cfg.LockoutDuration)
+		if err != nil {
+			log.Errorf("cannot lock out after authentication failures: %v", err)
+			continue
+		} else if !ok {
+			// Already locked out
+			continue
+		}
+		metrics.AuthLockouts.WithLabelValues(l.scope).Inc()
+		log.Warnf("locked out after %v authentication failures", failures)
+		lockout := Lockout{Scope: l.scope, IP: ip, Failures: failures, Until: until}
+		lockoutHooksMu.RLock()
+		for _, h := range lockoutHooks {
+			h(lockout)
+		}
+		lockoutHooksMu.RUnlock()
+	}
+}
+
+// resetAuthFailures forgets failures of a token once it's authenticated. Failures from its IP still count.
+func resetAuthFailures(token string) {
+	if err := currentThrottleStore.reset("account:" + hashToken(token)); err != nil {
+		logger.Log().Errorf("cannot reset authentication failures: %v", err)
+	}
+}
+
+// PruneAuthFailures deletes failure counts which are past their window and not locked out,
+// and returns how many were deleted. Counts kept in Redis expire by themselves.
+func PruneAuthFailures() (int64, error) {
+	return currentThrottleStore.prune(config.GetAuthThrottle().Window)
+}
+
+// failureDelay returns how long to wait after the given failure past the free ones, the first being 1.
+func failureDelay(cfg config.AuthThrottle, failure int) time.Duration {
+	if failure > 30 {
+		failure = 30
+	}
+	d := float64(cfg.BaseDelay) * math.Pow(2, float64(failure-1))
+	if cfg.MaxDelay > 0 && d > float64(cfg.MaxDelay) {
+		return cfg.MaxDelay
+	}
+	return time.Duration(d)
+}
+
+func throttled(wait time.Duration) error {
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return errors.Prefix(fmt.Sprintf("try again in %v", wait.Round(time.Second)), ErrAuthThrottled)
+}
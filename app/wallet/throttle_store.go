@@ -0,0 +1,220 @@
+package wallet
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/redis"
+	"github.com/lbryio/lbrytv/internal/storage"
+)
+
+var currentThrottleStore throttleStore = dbThrottleStore{}
+
+// throttleState is what's known about failures of a throttle key within the window.
+type throttleState struct {
+	failures  int
+	locked    time.Duration
+	sinceLast time.Duration
+}
+
+// throttleStore keeps authentication failure counts shared by all instances.
+type throttleStore interface {
+	// state returns failures of key within window, or nil if there are none.
+	state(key string, window time.Duration) (*throttleState, error)
+	// fail counts a failure of key, starting over when the last one is older than window, and returns the count.
+	fail(key string, window time.Duration) (int, error)
+	// lock locks key out for d and returns until when. It returns false when key is already locked out.
+	lock(key string, d time.Duration) (time.Time, bool, error)
+	reset(key string) error
+	prune(window time.Duration) (int64, error)
+}
+
+// SetThrottleStore sets where authentication failures are counted, the DB being the default.
+func SetThrottleStore(s throttleStore) {
+	currentThrottleStore = s
+}
+
+// dbThrottleStore keeps failure counts in the auth_failures table.
+type dbThrottleStore struct{}
+
+func (dbThrottleStore) state(key string, window time.Duration) (*throttleState, error) {
+	var failures int
+	var locked, sinceLast float64
+	err := storage.Conn.DB.QueryRow(
+		`SELECT failures, COALESCE(EXTRACT(EPOCH FROM locked_until - now()), 0), EXTRACT(EPOCH FROM now() - last_failure_at)
+		FROM auth_failures WHERE key = $1 AND last_failure_at > now() - $2 * interval '1 second'`,
+		key, window.Seconds(),
+	).Scan(&failures, &locked, &sinceLast)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Err(err)
+	}
+	return &throttleState{
+		failures:  failures,
+		locked:    time.Duration(locked * float64(time.Second)),
+		sinceLast: time.Duration(sinceLast * float64(time.Second)),
+	}, nil
+}
+
+func (dbThrottleStore) fail(key string, window time.Duration) (int, error) {
+	var failures int
+	err := storage.Conn.DB.QueryRow(
+		`INSERT INTO auth_failures (key, failures) VALUES ($1, 1)
+		ON CONFLICT (key) DO UPDATE SET last_failure_at = now(), failures = CASE
+			WHEN auth_failures.last_failure_at < now() - $2 * interval '1 second' THEN 1
+			ELSE auth_failures.failures + 1 END
+		RETURNING failures`,
+		key, window.Seconds(),
+	).Scan(&failures)
+	return failures, errors.Err(err)
+}
+
+func (dbThrottleStore) lock(key string, d time.Duration) (time.Time, bool, error) {
+	var until time.Time
+	err := storage.Conn.DB.QueryRow(
+		`UPDATE auth_failures SET locked_until = now() + $2 * interval '1 second'
+		WHERE key = $1 AND (locked_until IS NULL OR locked_until <= now()) RETURNING locked_until`,
+		key, d.Seconds(),
+	).Scan(&until)
+	if errors.Is(err, sql.ErrNoRows) {
+		return until, false, nil
+	} else if err != nil {
+		return until, false, errors.Err(err)
+	}
+	return until, true, nil
+}
+
+func (dbThrottleStore) reset(key string) error {
+	_, err := storage.Conn.DB.Exec(`DELETE FROM auth_failures WHERE key = $1`, key)
+	return errors.Err(err)
+}
+
+func (dbThrottleStore) prune(window time.Duration) (int64, error) {
+	res, err := storage.Conn.DB.Exec(
+		`DELETE FROM auth_failures WHERE last_failure_at < now() - $1 * interval '1 second'
+		AND (locked_until IS NULL OR locked_until < now())`,
+		window.Seconds(),
+	)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	n, err := res.RowsAffected()
+	return n, errors.Err(err)
+}
+
+// redisDoer is the part of redis.Client the throttle uses.
+type redisDoer interface {
+	Do(args ...interface{}) (interface{}, error)
+}
+
+// redisThrottleStore keeps failure counts in Redis, under keys prefixed with namespace which expire a window
+// after the last failure. Lockouts are kept under separate keys expiring when they end.
+type redisThrottleStore struct {
+	client    redisDoer
+	namespace string
+}
+
+// NewRedisThrottleStore returns a store counting authentication failures in Redis as set in cfg.
+func NewRedisThrottleStore(cfg config.AuthThrottleRedis) (*redisThrottleStore, error) {
+	client := redis.New(redis.Config{
+		Address:  cfg.Address,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+		Timeout:  cfg.Timeout,
+		PoolSize: cfg.PoolSize,
+	})
+	if err := client.Ping(); err != nil {
+		return nil, errors.Prefix("cannot connect to auth throttle redis", err)
+	}
+	return &redisThrottleStore{client: client, namespace: cfg.Namespace}, nil
+}
+
+func (s *redisThrottleStore) failuresKey(key string) string {
+	return s.namespace + "failures:" + key
+}
+
+func (s *redisThrottleStore) lockKey(key string) string {
+	return s.namespace + "locked:" + key
+}
+
+func (s *redisThrottleStore) state(key string, window time.Duration) (*throttleState, error) {
+	locked, err := s.ttl(s.lockKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if locked > 0 {
+		return &throttleState{locked: locked}, nil
+	}
+
+	reply, err := s.client.Do("GET", s.failuresKey(key))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	v, ok := reply.([]byte)
+	if !ok {
+		return nil, nil
+	}
+	failures, err := strconv.Atoi(string(v))
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	left, err := s.ttl(s.failuresKey(key))
+	if err != nil {
+		return nil, err
+	}
+	if left <= 0 {
+		// Expired since it was read
+		return nil, nil
+	}
+	return &throttleState{failures: failures, sinceLast: window - left}, nil
+}
+
+func (s *redisThrottleStore) fail(key string, window time.Duration) (int, error) {
+	reply, err := s.client.Do("INCR", s.failuresKey(key))
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	failures, ok := reply.(int64)
+	if !ok {
+		return 0, errors.Err("%v: unexpected INCR reply %T", redis.ErrProtocol, reply)
+	}
+	if _, err := s.client.Do("PEXPIRE", s.failuresKey(key), int64(window/time.Millisecond)); err != nil {
+		return 0, errors.Err(err)
+	}
+	return int(failures), nil
+}
+
+func (s *redisThrottleStore) lock(key string, d time.Duration) (time.Time, bool, error) {
+	until := time.Now().Add(d)
+	reply, err := s.client.Do("SET", s.lockKey(key), "1", "NX", "PX", int64(d/time.Millisecond))
+	if err != nil {
+		return until, false, errors.Err(err)
+	}
+	return until, reply != nil, nil
+}
+
+func (s *redisThrottleStore) reset(key string) error {
+	_, err := s.client.Do("DEL", s.failuresKey(key), s.lockKey(key))
+	return errors.Err(err)
+}
+
+func (s *redisThrottleStore) prune(time.Duration) (int64, error) {
+	return 0, nil
+}
+
+// ttl returns how long key has left to live, zero or less when it doesn't exist.
+func (s *redisThrottleStore) ttl(key string) (time.Duration, error) {
+	reply, err := s.client.Do("PTTL", key)
+	if err != nil {
+		return 0, errors.Err(err)
+	}
+	ms, ok := reply.(int64)
+	if !ok {
+		return 0, errors.Err("%v: unexpected PTTL reply %T", redis.ErrProtocol, reply)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,197 @@
+package wallet
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func overrideAuthThrottle(free, lockout, ipFree, ipLockout int) {
+	config.Override("AuthThrottle", map[string]interface{}{
+		"FreeFailures":    free,
+		"LockoutAfter":    lockout,
+		"IPFreeFailures":  ipFree,
+		"IPLockoutAfter":  ipLockout,
+		"BaseDelay":       time.Hour,
+		"MaxDelay":        2 * time.Hour,
+		"LockoutDuration": time.Hour,
+		"Window":          time.Hour,
+	})
+}
+
+func TestThrottle_Account(t *testing.T) {
+	setupTest()
+	overrideAuthThrottle(2, 4, 100, 1000)
+	defer config.RestoreOverridden()
+
+	var lockouts []Lockout
+	AddLockoutHook(func(l Lockout) { lockouts = append(lockouts, l) })
+	authErr := errors.Err("could not authenticate user")
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	require.NoError(t, checkThrottle("token", "1.2.3.4"))
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	err := checkThrottle("token", "1.2.3.4")
+	assert.True(t, errors.Is(err, ErrAuthThrottled))
+	assert.Contains(t, err.Error(), "try again in 1h0m0s")
+	// Other tokens from the same IP are not affected
+	require.NoError(t, checkThrottle("other", "1.2.3.4"))
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, ThrottleScopeAccount, lockouts[0].Scope)
+	assert.Equal(t, 4, lockouts[0].Failures)
+	assert.True(t, errors.Is(checkThrottle("token", "5.6.7.8"), ErrAuthThrottled))
+
+	resetAuthFailures("token")
+	require.NoError(t, checkThrottle("token", "1.2.3.4"))
+}
+
+func TestThrottle_IP(t *testing.T) {
+	setupTest()
+	overrideAuthThrottle(100, 1000, 1, 3)
+	defer config.RestoreOverridden()
+
+	authErr := errors.Err("could not authenticate user")
+	recordAuthFailure("token1", "1.2.3.4", authErr)
+	require.NoError(t, checkThrottle("token2", "1.2.3.4"))
+	recordAuthFailure("token2", "1.2.3.4", authErr)
+	assert.True(t, errors.Is(checkThrottle("token3", "1.2.3.4"), ErrAuthThrottled))
+	require.NoError(t, checkThrottle("token3", "5.6.7.8"))
+
+	// Successful authentication doesn't clear failures from the IP
+	resetAuthFailures("token2")
+	assert.True(t, errors.Is(checkThrottle("token3", "1.2.3.4"), ErrAuthThrottled))
+}
+
+func TestThrottle_UnreachableAPINotCounted(t *testing.T) {
+	setupTest()
+	overrideAuthThrottle(0, 1, 0, 1)
+	defer config.RestoreOverridden()
+
+	recordAuthFailure("token", "1.2.3.4", errors.Err(&net.OpError{Op: "dial", Err: errors.Err("connection refused")}))
+	require.NoError(t, checkThrottle("token", "1.2.3.4"))
+}
+
+func TestPruneAuthFailures(t *testing.T) {
+	setupTest()
+	overrideAuthThrottle(0, 1000, 0, 1000)
+	defer config.RestoreOverridden()
+
+	recordAuthFailure("token", "1.2.3.4", errors.Err("could not authenticate user"))
+	recordAuthFailure("old", "", errors.Err("could not authenticate user"))
+	_, err := storage.Conn.DB.Exec(`UPDATE auth_failures SET last_failure_at = now() - interval '2 hours' WHERE key = $1`,
+		"account:"+hashToken("old"))
+	require.NoError(t, err)
+
+	n, err := PruneAuthFailures()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+	assert.True(t, errors.Is(checkThrottle("token", ""), ErrAuthThrottled))
+}
+
+func TestFailureDelay(t *testing.T) {
+	cfg := config.AuthThrottle{BaseDelay: time.Second, MaxDelay: time.Minute}
+	assert.Equal(t, time.Second, failureDelay(cfg, 1))
+	assert.Equal(t, 4*time.Second, failureDelay(cfg, 3))
+	assert.Equal(t, time.Minute, failureDelay(cfg, 10))
+	assert.Equal(t, time.Minute, failureDelay(cfg, 1000))
+}
+
+// fakeRedis handles the commands redisThrottleStore sends on an in-memory map with expiry.
+type fakeRedis struct {
+	values  map[string]int64
+	expires map[string]time.Time
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]int64{}, expires: map[string]time.Time{}}
+}
+
+func (r *fakeRedis) Do(args ...interface{}) (interface{}, error) {
+	key := args[1].(string)
+	if exp, ok := r.expires[key]; ok && !exp.After(time.Now()) {
+		delete(r.values, key)
+		delete(r.expires, key)
+	}
+	_, exists := r.values[key]
+	switch args[0] {
+	case "GET":
+		if !exists {
+			return nil, nil
+		}
+		return []byte(strconv.FormatInt(r.values[key], 10)), nil
+	case "INCR":
+		r.values[key]++
+		return r.values[key], nil
+	case "PEXPIRE":
+		r.expires[key] = time.Now().Add(time.Duration(args[2].(int64)) * time.Millisecond)
+		return int64(1), nil
+	case "PTTL":
+		if !exists {
+			return int64(-2), nil
+		}
+		return int64(time.Until(r.expires[key]) / time.Millisecond), nil
+	case "SET":
+		if exists {
+			return nil, nil
+		}
+		r.values[key] = 1
+		r.expires[key] = time.Now().Add(time.Duration(args[5].(int64)) * time.Millisecond)
+		return "OK", nil
+	case "DEL":
+		for _, k := range args[1:] {
+			delete(r.values, k.(string))
+			delete(r.expires, k.(string))
+		}
+		return int64(len(args) - 1), nil
+	}
+	return nil, errors.Err("unexpected command %v", args[0])
+}
+
+func TestThrottle_Redis(t *testing.T) {
+	setupTest()
+	overrideAuthThrottle(2, 4, 100, 1000)
+	defer config.RestoreOverridden()
+	SetThrottleStore(&redisThrottleStore{client: newFakeRedis(), namespace: "test:"})
+	defer SetThrottleStore(dbThrottleStore{})
+
+	var lockouts []Lockout
+	AddLockoutHook(func(l Lockout) { lockouts = append(lockouts, l) })
+	authErr := errors.Err("could not authenticate user")
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	require.NoError(t, checkThrottle("token", "1.2.3.4"))
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	err := checkThrottle("token", "1.2.3.4")
+	assert.True(t, errors.Is(err, ErrAuthThrottled))
+	assert.Contains(t, err.Error(), "try again in 1h0m0s")
+	require.NoError(t, checkThrottle("other", "1.2.3.4"))
+
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	recordAuthFailure("token", "1.2.3.4", authErr)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, 4, lockouts[0].Failures)
+	assert.True(t, errors.Is(checkThrottle("token", "5.6.7.8"), ErrAuthThrottled))
+
+	// Nothing is written to the DB
+	var rows int
+	require.NoError(t, storage.Conn.DB.QueryRow(`SELECT count(*) FROM auth_failures`).Scan(&rows))
+	assert.Equal(t, 0, rows)
+
+	resetAuthFailures("token")
+	require.NoError(t, checkThrottle("token", "1.2.3.4"))
+}
@@ -48,16 +48,28 @@ func GetUserWithSDKServer(rt *sdkrouter.Router, internalAPIHost, token, metaRemo
 		return cachedUser, nil
 	}
 
+	if err := checkSession(token); err != nil {
+		log.Infof("authentication error: %v", err)
+		return nil, err
+	}
+	if err := checkThrottle(token, metaRemoteIP); err != nil {
+		log.Infof("authentication error: %v", err)
+		return nil, err
+	}
+
 	remoteUser, err := getRemoteUser(internalAPIHost, token, metaRemoteIP)
 	if err != nil {
 		msg := "authentication error: %v"
 		log.Errorf(msg, err)
+		recordAuthFailure(token, metaRemoteIP, err)
 		return nil, fmt.Errorf(msg, err)
 	}
 	if !remoteUser.HasVerifiedEmail {
 		return nil, nil
 	}
 
+	resetAuthFailures(token)
+
 	log.Data["remote_user_id"] = remoteUser.ID
 	log.Data["has_email"] = remoteUser.HasVerifiedEmail
 	log.Debugf("user authenticated")
@@ -65,23 +77,28 @@ func GetUserWithSDKServer(rt *sdkrouter.Router, internalAPIHost, token, metaRemo
 	ctx, cancelFn := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancelFn()
 
-	err = inTx(ctx, storage.Conn.DB.DB, func(tx *sql.Tx) error {
-		localUser, err = getOrCreateLocalUser(tx, remoteUser.ID, log)
-		if err != nil {
-			return err
-		}
-
-		if localUser.LbrynetServerID.IsZero() {
-			err := assignSDKServerToUser(tx, localUser, rt.LeastLoaded(), log)
+	err = storage.Conn.Do(ctx, func(ctx context.Context) error {
+		return inTx(ctx, storage.Conn.DB.DB, func(tx *sql.Tx) error {
+			localUser, err = getOrCreateLocalUser(tx, remoteUser.ID, log)
 			if err != nil {
 				return err
 			}
-		}
-		return nil
+
+			if localUser.LbrynetServerID.IsZero() {
+				err := assignSDKServerToUser(tx, localUser, rt.LeastLoaded(), log)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
 	})
 
 	if err == nil && localUser != nil {
 		currentCache.set(token, localUser)
+		if err := recordSession(localUser.ID, token, metaRemoteIP); err != nil {
+			log.Errorf("cannot record session: %v", err)
+		}
 	}
 
 	return localUser, err
@@ -179,10 +196,16 @@ func GetDBUserG(id int) (*models.User, error) {
 	op := metrics.StartOperation("db", "get_user")
 	defer op.End()
 
-	return models.Users(
-		models.UserWhere.ID.EQ(id),
-		qm.Load(models.UserRels.LbrynetServer),
-	).OneG()
+	var user *models.User
+	err := storage.Conn.Do(context.Background(), func(context.Context) error {
+		var err error
+		user, err = models.Users(
+			models.UserWhere.ID.EQ(id),
+			qm.Load(models.UserRels.LbrynetServer),
+		).OneG()
+		return err
+	})
+	return user, err
 }
 
 // assignSDKServerToUser permanently assigns an sdk to a user, and creates a wallet on that sdk for that user.
@@ -313,19 +336,22 @@ func createWallet(addr string, userID int) error {
 }
 
 // loadWallet loads an existing wallet in the LbrynetServer.
+// Concurrent loads of the same wallet, including ones by other instances, are de-duplicated.
 // May return errors:
 //  WalletAlreadyLoaded - wallet is already loaded and operational
 //  WalletNotFound - wallet file does not exist and won't be loaded.
 func LoadWallet(addr string, userID int) error {
-	op := metrics.StartOperation(opName, "load")
-	defer op.End()
+	return currentLoader.load(addr, userID, func() error {
+		op := metrics.StartOperation(opName, "load")
+		defer op.End()
 
-	_, err := ljsonrpc.NewClient(addr).WalletAdd(sdkrouter.WalletID(userID))
-	if err != nil {
-		return lbrynet.NewWalletError(userID, err)
-	}
-	logger.WithFields(logrus.Fields{"user_id": userID, "sdk": addr}).Info("wallet loaded")
-	return nil
+		_, err := ljsonrpc.NewClient(addr).WalletAdd(sdkrouter.WalletID(userID))
+		if err != nil {
+			return lbrynet.NewWalletError(userID, err)
+		}
+		logger.WithFields(logrus.Fields{"user_id": userID, "sdk": addr}).Info("wallet loaded")
+		return nil
+	})
 }
 
 // UnloadWallet unloads an existing wallet from the LbrynetServer.
@@ -333,6 +359,7 @@ func LoadWallet(addr string, userID int) error {
 //  WalletAlreadyLoaded - wallet is already loaded and operational
 //  WalletNotFound - wallet file does not exist and won't be loaded.
 func UnloadWallet(addr string, userID int) error {
+	currentLoader.forget(addr, userID)
 	_, err := ljsonrpc.NewClient(addr).WalletRemove(sdkrouter.WalletID(userID))
 	if err != nil {
 		return lbrynet.NewWalletError(userID, err)
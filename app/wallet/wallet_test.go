@@ -47,7 +47,7 @@ func TestMain(m *testing.M) {
 }
 
 func setupTest() {
-	storage.Conn.Truncate([]string{"users"})
+	storage.Conn.Truncate([]string{"users", "auth_failures"})
 	currentCache.flush()
 }
 
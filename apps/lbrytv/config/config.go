@@ -18,6 +18,536 @@ const (
 	configName        = "lbrytv"
 )
 
+// ResponseSizeLimit defines how large a serialized SDK response for a method is allowed to be.
+// Responses over MaxSize are truncated when Truncate is set and rejected otherwise.
+type ResponseSizeLimit struct {
+	MaxSize  int
+	Truncate bool
+}
+
+// SLO defines service level objectives for an endpoint class.
+// Availability and LatencyTarget are the fractions of requests expected to succeed and to complete within Latency.
+type SLO struct {
+	Availability  float64
+	Latency       time.Duration
+	LatencyTarget float64
+}
+
+// LogSampling defines how identical high-frequency log lines are sampled, see monitor.SamplingConfig.
+type LogSampling struct {
+	Period     time.Duration
+	Initial    int
+	Thereafter int
+}
+
+// LogRedaction defines redaction rules added to the default ones, see monitor.RedactionRules.
+type LogRedaction struct {
+	Fields   []string
+	Patterns []string
+}
+
+// StatsD defines where metrics are pushed in addition to being exposed for Prometheus.
+type StatsD struct {
+	Address  string
+	Prefix   string
+	Format   string
+	Interval time.Duration
+}
+
+// Profiling defines where continuous profiling data is pushed to.
+type Profiling struct {
+	ServerAddress string
+	AppName       string
+	AuthToken     string
+	Interval      time.Duration
+}
+
+// StartupRetry defines how failing startup steps (like connecting to the DB) are retried.
+type StartupRetry struct {
+	Attempts        int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// DBPool defines DB connection pool limits and the statement timeout, see storage.ConnParams.
+type DBPool struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration
+}
+
+// DBBreaker defines when DB calls start failing fast, see storage.BreakerConfig.
+type DBBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+	Timeout   time.Duration
+}
+
+// SDKBreaker defines when calls to an SDK server start failing fast, see query.Breaker.
+type SDKBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
+// QueryCacheRedis sets up keeping the query cache in Redis, shared by all instances, see cache.NewRedisCache.
+// Keys are prefixed with Namespace. Values are serialized with gob or json. Entries fetched from Redis are kept
+// in memory for LocalTTL, zero disables that. The cache is kept in memory when Address is empty.
+type QueryCacheRedis struct {
+	Address       string
+	Password      string
+	DB            int
+	Namespace     string
+	Serialization string
+	LocalTTL      time.Duration
+	Timeout       time.Duration
+	PoolSize      int
+}
+
+// BatchRequests limits JSON-RPC batches sent to the proxy, see proxy.Handle. Batches can have up to MaxSize calls,
+// which are handled by up to Workers at a time.
+type BatchRequests struct {
+	MaxSize int
+	Workers int
+}
+
+// BalancePolling defines how long wallet balance long polls are held, see balance.HandlePoll. Requests wait
+// for up to MaxWait, re-checking the balance every CheckInterval to catch changes not made through lbrytv,
+// like confirmations of incoming transactions. No more than MaxWaitersPerUser polls of a user are held
+// at once on an instance, there's no limit when it's zero.
+type BalancePolling struct {
+	MaxWait           time.Duration
+	CheckInterval     time.Duration
+	MaxWaitersPerUser int
+}
+
+// BalancePollingRedis sets up passing balance changes between instances through Redis, see
+// balance.ShareNotifications. Changes are published on the Namespace + "changes" channel. Polls are
+// only woken up by changes made through the instance holding them when Address is empty.
+type BalancePollingRedis struct {
+	Address   string
+	Password  string
+	DB        int
+	Namespace string
+	Timeout   time.Duration
+	PoolSize  int
+}
+
+// Embed configures the anonymous resolve and stream endpoints used by the embed player, see embed.HandleResolve.
+// They're served by SDK servers at Servers, or by the shared pool if it's empty. Each IP can make Rate requests
+// per second with bursts of up to Burst, at most MaxConcurrent calls are sent to the SDK at once and responses
+// are cached for ResolveTTL and StreamTTL.
+type Embed struct {
+	Servers       []string
+	Rate          float64
+	Burst         int
+	MaxConcurrent int
+	ResolveTTL    time.Duration
+	StreamTTL     time.Duration
+}
+
+// WebSocketRPC configures JSON-RPC over WebSocket, see proxy.HandleWebSocket. Up to MaxInFlight calls
+// from a connection are handled at once, and connections the client sends nothing over for IdleTimeout are closed.
+type WebSocketRPC struct {
+	MaxInFlight int
+	IdleTimeout time.Duration
+}
+
+// GRPC configures the gRPC Proxy service of api/proto/lbrytv.proto, see grpcapi.Server. It's served over
+// cleartext HTTP/2 on Address, or not at all when it's empty. A ClaimSearch call can stream up to
+// MaxClaimSearchPages pages.
+type GRPC struct {
+	Address             string
+	MaxClaimSearchPages int
+}
+
+// Events configures SDK event subscriptions, see events.HandleSubscribe. Topics maps names of topics clients
+// can subscribe to onto SDK events, as component.event. Users with subscriptions have a connection open to the event
+// stream at Path of their SDK server, and up to BufferSize events wait for a slow client before more are dropped.
+// Events not naming the wallet of the user are only passed on for PublicTopics.
+type Events struct {
+	Path         string
+	Topics       map[string]string
+	PublicTopics []string
+	BufferSize   int
+}
+
+// FraudScoring defines the service scoring publishes and purchases for fraud risk before they go through,
+// see fraud.Check. Calls to Methods scoring HoldScore or more are held for review, RejectScore or more rejected.
+// When the service fails or doesn't answer within Timeout, calls go through if FailOpen is set, or are rejected.
+// Scoring is disabled when URL is empty.
+type FraudScoring struct {
+	URL         string
+	APIToken    string
+	Timeout     time.Duration
+	FailOpen    bool
+	HoldScore   float64
+	RejectScore float64
+	Methods     []string
+}
+
+// ResolveSharding defines how anonymous resolves of many URLs are split across SDK servers, see query.Caller.ResolveServers.
+// Calls with more than ShardSize URLs are sent in shards of at most ShardSize URLs, with at most MaxShardsPerServer
+// of them in flight on each server. Zero ShardSize disables sharding.
+type ResolveSharding struct {
+	ShardSize          int
+	MaxShardsPerServer int
+}
+
+// SDKPool defines how SDK servers are health-checked, see sdkrouter.Router.WatchLoad.
+// Servers taking longer than MaxLatency to answer a status call are considered unhealthy and get no new users.
+type SDKPool struct {
+	CheckInterval time.Duration
+	MaxLatency    time.Duration
+}
+
+// ClientDeadlines defines which client apps can shorten the time spent on their requests, see proxy.Deadline.
+// Margin is taken off client deadlines to leave time for the response to get back to the client.
+type ClientDeadlines struct {
+	Clients []string
+	Margin  time.Duration
+}
+
+// AssembledUploads limits uploads sent in parts, see publish.Handler.HandleInitiate.
+// Uploads not completed within TTL are discarded.
+type AssembledUploads struct {
+	PartMaxSize int64
+	MaxParts    int
+	TTL         time.Duration
+}
+
+// DeltaUploads defines how previous uploads are kept for delta re-uploads, see publish.Handler.HandleSignatures.
+// Nothing is kept when BasisTTL is zero.
+type DeltaUploads struct {
+	BlockSize int64
+	BasisTTL  time.Duration
+}
+
+// UploadStorage defines where uploaded files are kept for SDK nodes to publish them from, see filestore.New.
+// Backend is local (the default), s3 or gcs. Remote backends give SDK nodes presigned URLs valid for URLExpiry.
+type UploadStorage struct {
+	Backend   string
+	Bucket    string
+	Region    string
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	URLExpiry time.Duration
+}
+
+// ResumableUploads limits uploads sent over tus.io protocol, see publish.Handler.HandleTusCreate.
+// Uploads not published within TTL are discarded. Clients are suggested chunk sizes between MinChunkSize
+// and MaxChunkSize taking about ChunkDuration to send, starting at InitialChunkSize, see publish.chunkStats.
+// Zero ChunkDuration disables suggestions.
+type ResumableUploads struct {
+	MaxSize          int64
+	TTL              time.Duration
+	InitialChunkSize int64
+	MinChunkSize     int64
+	MaxChunkSize     int64
+	ChunkDuration    time.Duration
+}
+
+// UploadAnalysis defines the command uploaded files are analyzed with to suggest languages and tags,
+// see publish.analyzeFile. Files at least NSFWThreshold likely to be unsafe for work get the mature tag suggested.
+type UploadAnalysis struct {
+	Command       []string
+	NSFWThreshold float64
+	Timeout       time.Duration
+}
+
+// Thumbnails defines how thumbnails are made for uploaded videos published without thumbnail_url,
+// see package thumbnails. A frame Offset into the video, scaled down to Width, is extracted with the FFmpeg binary
+// and uploaded to UploadURL, the thumbnail URL is taken from URLField of the response. Both have to be done
+// within Timeout. Thumbnails are not made when UploadURL is empty.
+type Thumbnails struct {
+	FFmpeg    string
+	Offset    time.Duration
+	Width     int
+	UploadURL string
+	URLField  string
+	Timeout   time.Duration
+}
+
+// Torrents defines seeding of published files, see package torrent. Seeding is disabled when SeedDir is empty,
+// no more files are seeded once they take MaxTotalSize bytes, unless it's zero.
+type Torrents struct {
+	SeedDir      string
+	PieceLength  int64
+	Trackers     []string
+	WebSeeds     []string
+	MaxTotalSize int64
+}
+
+// CDNPurge defines how CDN URLs of updated or blocked claims are purged, see package cdnpurge.
+// URLs are templates where {claim_id} and {name} are replaced with claim values. Purging is disabled
+// when Provider (cloudflare or fastly) is empty, failed purges are retried up to Retries times.
+type CDNPurge struct {
+	Provider   string
+	APIToken   string
+	ZoneID     string
+	URLs       []string
+	Retries    int
+	RetryDelay time.Duration
+}
+
+// CloudImports limits files imported from cloud storage, see publish.Handler.HandleImport.
+// Downloads taking longer than Timeout are aborted.
+type CloudImports struct {
+	MaxSize int64
+	Timeout time.Duration
+}
+
+// RemoteSources limits files downloaded from source_url of publish requests instead of being uploaded,
+// see publish.Handler.Handle. Downloads larger than MaxSize or taking longer than Timeout are aborted.
+// Only public addresses are downloaded from unless AllowPrivateNetworks is set.
+type RemoteSources struct {
+	MaxSize              int64
+	Timeout              time.Duration
+	AllowPrivateNetworks bool
+}
+
+// UploadLimits caps the size of files uploaded for publishing and the number of bytes each user
+// can upload a day, see publish.Handler.Handle. Zero means no limit.
+type UploadLimits struct {
+	MaxFileSize int64
+	DailyQuota  int64
+}
+
+// UploadScanner defines the ClamAV daemon uploaded files are scanned with, see publish.ClamdScanner.
+// Network is "unix" or "tcp", scanning is disabled when Address is empty.
+type UploadScanner struct {
+	Network string
+	Address string
+	Timeout time.Duration
+}
+
+// UploadMediaTypes lists media types of uploaded files, detected from their content, which can't be published.
+// Entries are full media types or type/* wildcards. When Allowed is set, only types matching it are accepted.
+type UploadMediaTypes struct {
+	Allowed []string
+	Denied  []string
+}
+
+// Outbox defines delivery of side effects queued in the outbox, see outbox.Dispatcher. Due messages are polled
+// every Interval, BatchSize at a time. Failed deliveries are retried after RetryDelay, doubling with every attempt,
+// and given up on after MaxAttempts. Delivery is disabled when Interval is zero.
+type Outbox struct {
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Timeout     time.Duration
+}
+
+// ContentAvailability sets up checks of whether blobs of published streams can be retrieved. Blobs are fetched
+// from BlobURL, with {hash} replaced by the blob hash, up to Concurrency at a time, each within Timeout.
+// Creators are notified at NotifyURL when their content becomes unavailable. Checks are disabled when BlobURL is empty.
+type ContentAvailability struct {
+	BlobURL     string
+	Timeout     time.Duration
+	Concurrency int
+	NotifyURL   string
+}
+
+// CredentialLifecycle sets up rotation of organization API keys and internal secrets. A rotated credential
+// stays valid for Overlap, unless another overlap is requested, so clients can switch to its replacement.
+// Rotated API keys are kept for RotatedKeyRetention afterwards, so their use is still reported to the key webhook,
+// and expire then. Secrets are cached for SecretsCacheTTL, which should be much shorter than Overlap.
+type CredentialLifecycle struct {
+	Overlap             time.Duration
+	RotatedKeyRetention time.Duration
+	SecretsCacheTTL     time.Duration
+}
+
+// SDKRetry sets up retrying of SDK calls which failed before reaching the SDK or whose connection broke.
+// A call is attempted at most MaxAttempts times, waiting InitialBackoff before the first retry, Multiplier times longer
+// before each next one, up to MaxBackoff. Retries never go past the call deadline. MaxAttempts of 1 turns retrying off.
+type SDKRetry struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+}
+
+// AuthThrottle sets up throttling of auth token failures, counted per token and per IP over Window.
+// After FreeFailures (IPFreeFailures per IP) further attempts have to wait BaseDelay, doubled with every failure
+// up to MaxDelay, and after LockoutAfter (IPLockoutAfter) failures attempts are refused for LockoutDuration.
+// Lockouts are posted to WebhookURL when it's set. Throttling is off when Window is zero.
+type AuthThrottle struct {
+	FreeFailures    int
+	LockoutAfter    int
+	IPFreeFailures  int
+	IPLockoutAfter  int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+	Window          time.Duration
+	WebhookURL      string
+}
+
+// AuthThrottleRedis sets up counting auth token failures in Redis instead of the DB, see wallet.NewRedisThrottleStore.
+// Keys are prefixed with Namespace. Failures are counted in the DB when Address is empty.
+type AuthThrottleRedis struct {
+	Address   string
+	Password  string
+	DB        int
+	Namespace string
+	Timeout   time.Duration
+	PoolSize  int
+}
+
+// UserSessions sets up session listing and revocation for users. Instances check for revoked sessions every
+// RevocationPollInterval and drop them from the token cache. Sessions unused for IdleTimeout aren't listed.
+type UserSessions struct {
+	RevocationPollInterval time.Duration
+	IdleTimeout            time.Duration
+}
+
+// UploadJanitor sets up removal of uploaded files left behind when lbrytv stops in the middle of a publish.
+// UploadPath is swept every Interval for files older than MaxAge, the janitor is disabled when Interval is zero.
+type UploadJanitor struct {
+	Interval time.Duration
+	MaxAge   time.Duration
+}
+
+// APIKeySecurity sets up detection of organization API key anomalies reported to key webhooks.
+// Addresses are grouped into networks of IPv4Prefix and IPv6Prefix bits, a key used from a network it hasn't been
+// seen in before is reported. The same anomaly of a key is reported at most once per Cooldown.
+type APIKeySecurity struct {
+	IPv4Prefix int
+	IPv6Prefix int
+	Cooldown   time.Duration
+}
+
+// AsyncPublishes sets up background processing of publishes made with async field set, see publish.Handler.Handle.
+// Publishes beyond QueueSize waiting for one of Workers are rejected, their outcome is kept for TTL.
+type AsyncPublishes struct {
+	Workers   int
+	QueueSize int
+	TTL       time.Duration
+}
+
+// Telemetry defines where anonymized usage reports are sent to, see telemetry.Config.
+type Telemetry struct {
+	Endpoint   string
+	Interval   time.Duration
+	SampleRate float64
+	Epsilon    float64
+}
+
+// SDKTLS defines client certificate and CA files for mutual TLS with SDK nodes, see sdktls.Config.
+type SDKTLS struct {
+	CertFile       string
+	KeyFile        string
+	CAFile         string
+	SPIFFEIDs      []string
+	ReloadInterval time.Duration
+}
+
+// ContentPage defines how content page data is composed, see contentpage.Compose.
+// RelatedCount claims are listed as related content and view counts are cached for ViewCountTTL.
+type ContentPage struct {
+	RelatedCount int
+	ViewCountTTL time.Duration
+}
+
+// Recommendations defines how related claims are computed, see the recommendations package.
+// Up to IndexSize claims seen in proxied responses are kept for IndexTTL, results are cached for ResultTTL.
+// When ServiceURL is set, it's asked first and waited for up to ServiceTimeout.
+type Recommendations struct {
+	IndexSize       int
+	IndexTTL        time.Duration
+	ResultTTL       time.Duration
+	Count           int
+	ChannelWeight   float64
+	AnnotateResolve bool
+	ServiceURL      string
+	ServiceTimeout  time.Duration
+}
+
+// Trending defines categories trending lists are computed for, mapping their names to tags.
+// Up to Candidates streams released within MaxAge are scored per category, losing half of their score
+// every HalfLife, and the top Size of them are served. ViewCountTimeout limits view count API requests.
+type Trending struct {
+	Categories       map[string][]string
+	HalfLife         time.Duration
+	MaxAge           time.Duration
+	Candidates       int
+	Size             int
+	ViewCountTimeout time.Duration
+}
+
+// TransformRules defines the file declarative request and response transformations are read from,
+// see the rules package. It's checked for changes every ReloadInterval.
+type TransformRules struct {
+	File           string
+	ReloadInterval time.Duration
+}
+
+// MethodPolicies defines the file per-method allow, deny and rewrite policies are read from,
+// see the policy package. It's checked for changes every ReloadInterval.
+type MethodPolicies struct {
+	File           string
+	ReloadInterval time.Duration
+}
+
+// ScheduledTask defines a periodic task of a registered kind running on a cron Schedule.
+type ScheduledTask struct {
+	Name     string
+	Schedule string
+	Kind     string
+	Params   map[string]interface{}
+}
+
+// PublishPolicy defines rules for publishing into a channel. Template values are used for params
+// missing from the publish request, the rest are checked against the resulting params.
+type PublishPolicy struct {
+	Template        map[string]interface{}
+	RequiredTags    []string
+	AllowedLicenses []string
+	MaxBid          float64
+	NamePattern     string
+}
+
+// MissingURLFilter defines the source of claim dumps for answering resolves of nonexistent URLs locally,
+// see urlfilter.Config.
+type MissingURLFilter struct {
+	Source                string
+	Capacity              int
+	FalsePositiveRate     float64
+	RefreshInterval       time.Duration
+	MaxAge                time.Duration
+	PassthroughPercentage int
+}
+
+// MissingURLFilterRedis sets up sharing entries added to the missing URL filter by any instance through Redis,
+// see urlfilter.Source.ShareEntries. They are kept in a sorted set at Namespace + "entries". Entries added
+// on an instance are only known to it when Address is empty.
+type MissingURLFilterRedis struct {
+	Address   string
+	Password  string
+	DB        int
+	Namespace string
+	Timeout   time.Duration
+	PoolSize  int
+}
+
+// PrefetchRule defines claim_search queries sent after a call to a method returns channel claims,
+// see app/prefetch. Queries are sent for at most MaxChannels channels and cancelled after Timeout.
+type PrefetchRule struct {
+	Queries     []map[string]interface{}
+	MaxChannels int
+	Timeout     time.Duration
+}
+
+// CacheInvalidation drops cached responses to Method called with params containing the value of Param
+// of a write call, see query.Caller.
+type CacheInvalidation struct {
+	Method string
+	Param  string
+}
+
 // overriddenValues stores overridden v values
 // and is initialized as an empty map in the read method
 var (
@@ -38,17 +568,229 @@ func init() {
 	c.Viper.BindEnv("Lbrynet")
 	c.Viper.BindEnv("SentryDSN")
 	c.Viper.BindEnv("DatabaseDSN")
+	c.Viper.BindEnv("AdminToken")
 
 	c.Viper.SetDefault("Address", ":8080")
 	c.Viper.SetDefault("Host", "http://localhost:8080")
 	c.Viper.SetDefault("FreeContentURL", "http://localhost:8080/content/")
 	c.Viper.SetDefault("ReflectorTimeout", int64(10))
 	c.Viper.SetDefault("RefractorTimeout", int64(10))
+	c.Viper.SetDefault("UserTraceTTL", time.Hour)
+	c.Viper.SetDefault("UserTraceLimit", 200)
+	c.Viper.SetDefault("StatsD.Format", "datadog")
+	c.Viper.SetDefault("StatsD.Interval", 10*time.Second)
+	c.Viper.SetDefault("Profiling.AppName", "lbrytv")
+	c.Viper.SetDefault("Profiling.Interval", 10*time.Second)
+	c.Viper.SetDefault("ResponseSpillThreshold", 10<<20)
+	c.Viper.SetDefault("ResponseSpillMaxConcurrent", 4)
+	c.Viper.SetDefault("ResponseSpillDir", os.TempDir())
+	c.Viper.SetDefault("SDKResponseMaxSize", 256<<20)
+	c.Viper.SetDefault("SentryWorkers", 2)
+	c.Viper.SetDefault("SentryQueueSize", 1000)
+	c.Viper.SetDefault("StartupRetry.Attempts", 10)
+	c.Viper.SetDefault("StartupRetry.InitialInterval", time.Second)
+	c.Viper.SetDefault("StartupRetry.MaxInterval", 30*time.Second)
+	c.Viper.SetDefault("CanaryInterval", 5*time.Minute)
+	c.Viper.SetDefault("CanaryResolveURL", "what#19b9c243bea0c45175e6a6027911abbad53e983e")
+	c.Viper.SetDefault("CanaryPublishBid", "0.0001")
+	c.Viper.SetDefault("OrganizationUploadQuota", int64(50<<30))
+	c.Viper.SetDefault("SDKTLS.ReloadInterval", time.Minute)
+	c.Viper.SetDefault("QuarantineDir", "/storage/quarantine")
+	c.Viper.SetDefault("SDKSchemaValidation", "lenient")
+	c.Viper.SetDefault("PrefetchMaxConcurrent", 8)
+	c.Viper.SetDefault("ChannelCacheTTL", 10*time.Minute)
+	c.Viper.SetDefault("QueryCacheTTLs", map[string]interface{}{
+		"resolve":      5 * time.Minute,
+		"claim_search": 5 * time.Minute,
+	})
+	c.Viper.SetDefault("ChannelCacheSize", 10000)
+	c.Viper.SetDefault("CoalescedMethods", []string{"resolve"})
+	c.Viper.SetDefault("QueryCacheInvalidations", map[string]interface{}{
+		"publish":        []map[string]interface{}{{"Method": "claim_search", "Param": "channel_id"}},
+		"stream_create":  []map[string]interface{}{{"Method": "claim_search", "Param": "channel_id"}},
+		"stream_update":  []map[string]interface{}{{"Method": "claim_search", "Param": "channel_id"}, {"Method": "resolve", "Param": "claim_id"}},
+		"stream_abandon": []map[string]interface{}{{"Method": "resolve", "Param": "claim_id"}},
+		"channel_update": []map[string]interface{}{{"Method": "claim_search", "Param": "claim_id"}, {"Method": "resolve", "Param": "claim_id"}},
+		"support_create": []map[string]interface{}{{"Method": "resolve", "Param": "claim_id"}},
+	})
+	c.Viper.SetDefault("QueryCacheRedis.Namespace", "lbrytv:query:")
+	c.Viper.SetDefault("QueryCacheRedis.Serialization", "gob")
+	c.Viper.SetDefault("QueryCacheRedis.LocalTTL", 10*time.Second)
+	c.Viper.SetDefault("QueryCacheRedis.Timeout", 500*time.Millisecond)
+	c.Viper.SetDefault("QueryCacheRedis.PoolSize", 20)
+	c.Viper.SetDefault("MissingURLFilter.Capacity", 20000000)
+	c.Viper.SetDefault("MissingURLFilter.FalsePositiveRate", 0.01)
+	c.Viper.SetDefault("MissingURLFilter.RefreshInterval", 30*time.Minute)
+	c.Viper.SetDefault("MissingURLFilter.MaxAge", 2*time.Hour)
+	c.Viper.SetDefault("MissingURLFilter.PassthroughPercentage", 1)
+	c.Viper.SetDefault("MissingURLFilterRedis.Namespace", "lbrytv:urlfilter:")
+	c.Viper.SetDefault("MissingURLFilterRedis.Timeout", 200*time.Millisecond)
+	c.Viper.SetDefault("MissingURLFilterRedis.PoolSize", 10)
+	c.Viper.SetDefault("DBSlowQueryThreshold", 250*time.Millisecond)
+	c.Viper.SetDefault("DBSlowTransactionThreshold", time.Second)
+	c.Viper.SetDefault("DBPool.MaxOpenConns", 50)
+	c.Viper.SetDefault("DBPool.MaxIdleConns", 10)
+	c.Viper.SetDefault("DBPool.ConnMaxLifetime", 30*time.Minute)
+	c.Viper.SetDefault("DBPool.StatementTimeout", 10*time.Second)
+	c.Viper.SetDefault("DBBreaker.Threshold", 5)
+	c.Viper.SetDefault("DBBreaker.Cooldown", 5*time.Second)
+	c.Viper.SetDefault("DBBreaker.Timeout", 3*time.Second)
+	c.Viper.SetDefault("SDKBreaker.Threshold", 10)
+	c.Viper.SetDefault("SDKBreaker.Cooldown", 10*time.Second)
+	c.Viper.SetDefault("SDKPool.CheckInterval", 2*time.Minute)
+	c.Viper.SetDefault("ResolveSharding.ShardSize", 25)
+	c.Viper.SetDefault("BatchRequests.MaxSize", 50)
+	c.Viper.SetDefault("BatchRequests.Workers", 8)
+	c.Viper.SetDefault("Embed.Rate", 2)
+	c.Viper.SetDefault("Embed.Burst", 20)
+	c.Viper.SetDefault("Embed.MaxConcurrent", 32)
+	c.Viper.SetDefault("Embed.ResolveTTL", 30*time.Minute)
+	c.Viper.SetDefault("Embed.StreamTTL", 10*time.Minute)
+	c.Viper.SetDefault("WebSocketRPC.MaxInFlight", 16)
+	c.Viper.SetDefault("WebSocketRPC.IdleTimeout", 5*time.Minute)
+	c.Viper.SetDefault("GRPC.MaxClaimSearchPages", 10)
+	c.Viper.SetDefault("Events.Path", "/ws")
+	c.Viper.SetDefault("Events.BufferSize", 64)
+	c.Viper.SetDefault("Events.Topics", map[string]interface{}{
+		"download_progress":     "file_manager.status",
+		"blob_announced":        "blob_manager.announced",
+		"transaction_confirmed": "wallet.transaction_confirmed",
+	})
+	c.Viper.SetDefault("FraudScoring.Timeout", 2*time.Second)
+	c.Viper.SetDefault("FraudScoring.FailOpen", true)
+	c.Viper.SetDefault("FraudScoring.HoldScore", 0.7)
+	c.Viper.SetDefault("FraudScoring.RejectScore", 0.9)
+	c.Viper.SetDefault("FraudScoring.Methods", []string{"publish", "stream_create", "stream_update", "purchase_create"})
+	c.Viper.SetDefault("BalancePolling.MaxWait", 30*time.Second)
+	c.Viper.SetDefault("BalancePolling.CheckInterval", 10*time.Second)
+	c.Viper.SetDefault("BalancePolling.MaxWaitersPerUser", 5)
+	c.Viper.SetDefault("BalancePollingRedis.Namespace", "lbrytv:balance:")
+	c.Viper.SetDefault("BalancePollingRedis.Timeout", 200*time.Millisecond)
+	c.Viper.SetDefault("BalancePollingRedis.PoolSize", 4)
+	c.Viper.SetDefault("ResolveSharding.MaxShardsPerServer", 4)
+	c.Viper.SetDefault("SDKPool.MaxLatency", 5*time.Second)
+	c.Viper.SetDefault("WalletLoadLockTimeout", 30*time.Second)
+	c.Viper.SetDefault("PublishedEchoTTL", 10*time.Minute)
+	c.Viper.SetDefault("StatusComponentsTTL", 30*time.Second)
+	c.Viper.SetDefault("ClientDeadlines.Margin", 200*time.Millisecond)
+	c.Viper.SetDefault("UploadTokenTTL", time.Hour)
+	c.Viper.SetDefault("AssembledUploads.PartMaxSize", 100*1024*1024)
+	c.Viper.SetDefault("AssembledUploads.MaxParts", 1000)
+	c.Viper.SetDefault("AssembledUploads.TTL", 24*time.Hour)
+	c.Viper.SetDefault("DeltaUploads.BlockSize", 1024*1024)
+	c.Viper.SetDefault("ParamLimits", map[string]interface{}{
+		"claim_search": map[string]interface{}{"page_size": 50, "any_tags": 100, "not_tags": 100, "channel_ids": 500},
+		"resolve":      map[string]interface{}{"urls": 100},
+	})
+	c.Viper.SetDefault("ContentPage.RelatedCount", 10)
+	c.Viper.SetDefault("ContentPage.ViewCountTTL", 5*time.Minute)
+	c.Viper.SetDefault("Recommendations.IndexSize", 50000)
+	c.Viper.SetDefault("Recommendations.IndexTTL", 6*time.Hour)
+	c.Viper.SetDefault("Recommendations.ResultTTL", 10*time.Minute)
+	c.Viper.SetDefault("Recommendations.Count", 10)
+	c.Viper.SetDefault("Recommendations.ChannelWeight", 0.5)
+	c.Viper.SetDefault("Recommendations.ServiceTimeout", 2*time.Second)
+	c.Viper.SetDefault("Trending.HalfLife", 24*time.Hour)
+	c.Viper.SetDefault("Trending.MaxAge", 7*24*time.Hour)
+	c.Viper.SetDefault("Trending.Candidates", 200)
+	c.Viper.SetDefault("Trending.Size", 50)
+	c.Viper.SetDefault("Trending.ViewCountTimeout", 10*time.Second)
+	c.Viper.SetDefault("TransformRules.ReloadInterval", time.Minute)
+	c.Viper.SetDefault("MethodPolicies.ReloadInterval", time.Minute)
+	c.Viper.SetDefault("UploadStorage.URLExpiry", time.Hour)
+	c.Viper.SetDefault("ResumableUploads.MaxSize", 10*1024*1024*1024)
+	c.Viper.SetDefault("ResumableUploads.TTL", 24*time.Hour)
+	c.Viper.SetDefault("ResumableUploads.InitialChunkSize", 5*1024*1024)
+	c.Viper.SetDefault("ResumableUploads.MinChunkSize", 256*1024)
+	c.Viper.SetDefault("ResumableUploads.MaxChunkSize", 100*1024*1024)
+	c.Viper.SetDefault("ResumableUploads.ChunkDuration", 10*time.Second)
+	c.Viper.SetDefault("UploadAnalysis.NSFWThreshold", 0.8)
+	c.Viper.SetDefault("UploadAnalysis.Timeout", 5*time.Minute)
+	c.Viper.SetDefault("Thumbnails.FFmpeg", "ffmpeg")
+	c.Viper.SetDefault("Thumbnails.Offset", 5*time.Second)
+	c.Viper.SetDefault("Thumbnails.Width", 1280)
+	c.Viper.SetDefault("Thumbnails.URLField", "url")
+	c.Viper.SetDefault("Thumbnails.Timeout", time.Minute)
+	c.Viper.SetDefault("Torrents.PieceLength", 4*1024*1024)
+	c.Viper.SetDefault("CDNPurge.Retries", 5)
+	c.Viper.SetDefault("CDNPurge.RetryDelay", 10*time.Second)
+	c.Viper.SetDefault("CloudImports.MaxSize", 10*1024*1024*1024)
+	c.Viper.SetDefault("CloudImports.Timeout", 6*time.Hour)
+	c.Viper.SetDefault("RemoteSources.MaxSize", 10*1024*1024*1024)
+	c.Viper.SetDefault("RemoteSources.Timeout", time.Hour)
+	c.Viper.SetDefault("UploadMediaTypes.Denied", []string{
+		"text/html", "application/x-msdownload", "application/x-executable", "application/x-mach-binary", "text/x-shellscript",
+	})
+	c.Viper.SetDefault("UploadScanner.Network", "unix")
+	c.Viper.SetDefault("UploadScanner.Timeout", 2*time.Minute)
+	c.Viper.SetDefault("Outbox.Interval", 5*time.Second)
+	c.Viper.SetDefault("Outbox.BatchSize", 100)
+	c.Viper.SetDefault("Outbox.MaxAttempts", 10)
+	c.Viper.SetDefault("Outbox.RetryDelay", 30*time.Second)
+	c.Viper.SetDefault("Outbox.Timeout", 30*time.Second)
+	c.Viper.SetDefault("ContentAvailability.Timeout", 10*time.Second)
+	c.Viper.SetDefault("ContentAvailability.Concurrency", 8)
+	c.Viper.SetDefault("CredentialLifecycle.Overlap", 24*time.Hour)
+	c.Viper.SetDefault("CredentialLifecycle.RotatedKeyRetention", 7*24*time.Hour)
+	c.Viper.SetDefault("CredentialLifecycle.SecretsCacheTTL", time.Minute)
+	c.Viper.SetDefault("SDKRetry.MaxAttempts", 3)
+	c.Viper.SetDefault("SDKRetry.InitialBackoff", 50*time.Millisecond)
+	c.Viper.SetDefault("SDKRetry.MaxBackoff", time.Second)
+	c.Viper.SetDefault("SDKRetry.Multiplier", 2.0)
+	c.Viper.SetDefault("AuthThrottle.FreeFailures", 3)
+	c.Viper.SetDefault("AuthThrottle.LockoutAfter", 10)
+	c.Viper.SetDefault("AuthThrottle.IPFreeFailures", 20)
+	c.Viper.SetDefault("AuthThrottle.IPLockoutAfter", 100)
+	c.Viper.SetDefault("AuthThrottle.BaseDelay", time.Second)
+	c.Viper.SetDefault("AuthThrottle.MaxDelay", time.Minute)
+	c.Viper.SetDefault("AuthThrottle.LockoutDuration", 15*time.Minute)
+	c.Viper.SetDefault("AuthThrottle.Window", time.Hour)
+	c.Viper.SetDefault("AuthThrottleRedis.Namespace", "lbrytv:auth:")
+	c.Viper.SetDefault("AuthThrottleRedis.Timeout", 200*time.Millisecond)
+	c.Viper.SetDefault("AuthThrottleRedis.PoolSize", 10)
+	c.Viper.SetDefault("UserSessions.RevocationPollInterval", 5*time.Second)
+	c.Viper.SetDefault("UserSessions.IdleTimeout", 30*24*time.Hour)
+	c.Viper.SetDefault("UploadJanitor.Interval", time.Hour)
+	c.Viper.SetDefault("UploadJanitor.MaxAge", 24*time.Hour)
+	c.Viper.SetDefault("APIKeySecurity.IPv4Prefix", 24)
+	c.Viper.SetDefault("APIKeySecurity.IPv6Prefix", 48)
+	c.Viper.SetDefault("APIKeySecurity.Cooldown", time.Hour)
+	c.Viper.SetDefault("AsyncPublishes.Workers", 4)
+	c.Viper.SetDefault("AsyncPublishes.QueueSize", 100)
+	c.Viper.SetDefault("AsyncPublishes.TTL", 24*time.Hour)
+	c.Viper.SetDefault("Telemetry.Interval", time.Hour)
+	c.Viper.SetDefault("Telemetry.SampleRate", 0.1)
+	c.Viper.SetDefault("Telemetry.Epsilon", 1.0)
+	c.Viper.SetDefault("PublishedEchoSize", 10000)
+	c.Viper.SetDefault("WalletLoadShareWindow", 10*time.Second)
 
 	c.Viper.AddConfigPath(os.Getenv("LBRYTV_CONFIG_DIR"))
 	c.Viper.AddConfigPath(ProjectRoot())
 }
 
+// parsed holds settings unmarshalled by parsedKey until the config changes.
+var parsed struct {
+	sync.Mutex
+	generation uint64
+	values     map[string]interface{}
+}
+
+// parsedKey returns the value of key unmarshalled by parse, which is only called again once the config changes.
+// It's for settings read on every request, which would be costly to unmarshal each time.
+func parsedKey(key string, parse func() interface{}) interface{} {
+	parsed.Lock()
+	defer parsed.Unlock()
+	if g := Config.Generation(); parsed.values == nil || g != parsed.generation {
+		parsed.generation, parsed.values = g, map[string]interface{}{}
+	}
+	v, ok := parsed.values[key]
+	if !ok {
+		v = parse()
+		parsed.values[key] = v
+	}
+	return v
+}
+
 func ProjectRoot() string {
 	ex, err := os.Executable()
 	if err != nil {
@@ -83,6 +825,27 @@ func GetPublishSourceDir() string {
 	return Config.Viper.GetString("PublishSourceDir")
 }
 
+// GetQuarantineDir returns directory where flagged uploads are moved for review.
+// It should not be accessible to SDK instances or anything serving files.
+func GetQuarantineDir() string {
+	return Config.Viper.GetString("QuarantineDir")
+}
+
+// GetUploadScanCommand returns a scanner command which uploaded files are checked with, see quarantine.CommandCheck.
+func GetUploadScanCommand() []string {
+	return Config.Viper.GetStringSlice("UploadScanCommand")
+}
+
+// GetSDKSchemaValidation returns how requests not matching SDK method schemas are treated: strict, lenient or off.
+func GetSDKSchemaValidation() string {
+	return Config.Viper.GetString("SDKSchemaValidation")
+}
+
+// GetSDKSchemaDir returns directory to load SDK method schemas from instead of the ones shipped with lbrytv.
+func GetSDKSchemaDir() string {
+	return Config.Viper.GetString("SDKSchemaDir")
+}
+
 // GetBlobFilesDir returns directory where SDK instance stores blob files.
 func GetBlobFilesDir() string {
 	return Config.Viper.GetString("BlobFilesDir")
@@ -150,3 +913,625 @@ func GetLbrynetXPercentage() int {
 func GetTokenCacheTimeout() time.Duration {
 	return Config.Viper.GetDuration("TokenCacheTimeout") * time.Second
 }
+
+// GetParamLimits returns the highest values allowed for params of SDK methods, keyed by method and param name.
+// List params are limited by length.
+func GetParamLimits() map[string]map[string]int {
+	limits := map[string]map[string]int{}
+	Config.Viper.UnmarshalKey("ParamLimits", &limits)
+	return limits
+}
+
+// GetResponseSizeLimits returns response size limits (in bytes) keyed by SDK method name.
+// It's checked on every call, so the map is shared and must not be modified.
+func GetResponseSizeLimits() map[string]ResponseSizeLimit {
+	return parsedKey("ResponseSizeLimits", func() interface{} {
+		limits := map[string]ResponseSizeLimit{}
+		Config.Viper.UnmarshalKey("ResponseSizeLimits", &limits)
+		return limits
+	}).(map[string]ResponseSizeLimit)
+}
+
+// GetAdminToken returns the token required for accessing admin API endpoints.
+// Admin API is disabled when it's empty.
+func GetAdminToken() string {
+	return Config.Viper.GetString("AdminToken")
+}
+
+// GetUserTraceTTL returns how long per-user debug traces are retained.
+func GetUserTraceTTL() time.Duration {
+	return Config.Viper.GetDuration("UserTraceTTL")
+}
+
+// GetUserTraceLimit returns how many debug traces are retained for a single user.
+func GetUserTraceLimit() int {
+	return Config.Viper.GetInt("UserTraceLimit")
+}
+
+// GetCanaryInterval returns how often canary probes are run. Probes are disabled when it's zero.
+func GetCanaryInterval() time.Duration {
+	return Config.Viper.GetDuration("CanaryInterval")
+}
+
+// GetCanaryResolveURL returns the URL of a known claim which is resolved by the canary probe.
+func GetCanaryResolveURL() string {
+	return Config.Viper.GetString("CanaryResolveURL")
+}
+
+// GetCanaryPublishServer returns the address of a test SDK node used by the publish canary probe.
+func GetCanaryPublishServer() string {
+	return Config.Viper.GetString("CanaryPublishServer")
+}
+
+// GetCanaryPublishChannelID returns the ID of a test channel the publish canary probe publishes into.
+func GetCanaryPublishChannelID() string {
+	return Config.Viper.GetString("CanaryPublishChannelID")
+}
+
+// GetCanaryPublishBid returns the bid amount for streams published by the canary probe.
+func GetCanaryPublishBid() string {
+	return Config.Viper.GetString("CanaryPublishBid")
+}
+
+// GetSLOs returns service level objectives keyed by endpoint class.
+func GetSLOs() map[string]SLO {
+	slos := map[string]SLO{}
+	Config.Viper.UnmarshalKey("SLOs", &slos)
+	return slos
+}
+
+// GetPrefetchRules returns cache prefetch rules keyed by SDK method name. Prefetching is disabled when empty.
+func GetPrefetchRules() map[string]PrefetchRule {
+	rules := map[string]PrefetchRule{}
+	Config.Viper.UnmarshalKey("Prefetch", &rules)
+	return rules
+}
+
+// GetPrefetchMaxConcurrent returns how many prefetch queries can be in progress at once, the rest are skipped.
+func GetPrefetchMaxConcurrent() int {
+	return Config.Viper.GetInt("PrefetchMaxConcurrent")
+}
+
+// GetMissingURLFilter returns missing URL filter settings. The filter is disabled when Source is empty.
+func GetMissingURLFilter() MissingURLFilter {
+	var f MissingURLFilter
+	Config.Viper.UnmarshalKey("MissingURLFilter", &f)
+	return f
+}
+
+// GetMissingURLFilterRedis returns settings of sharing missing URL filter entries through Redis.
+func GetMissingURLFilterRedis() MissingURLFilterRedis {
+	var r MissingURLFilterRedis
+	Config.Viper.UnmarshalKey("MissingURLFilterRedis", &r)
+	return r
+}
+
+// GetDBSlowQueryThreshold returns the duration over which DB queries are logged as slow.
+func GetDBSlowQueryThreshold() time.Duration {
+	return Config.Viper.GetDuration("DBSlowQueryThreshold")
+}
+
+// GetDBSlowTransactionThreshold returns the duration over which DB transactions are logged as slow.
+func GetDBSlowTransactionThreshold() time.Duration {
+	return Config.Viper.GetDuration("DBSlowTransactionThreshold")
+}
+
+// GetDBPool returns DB connection pool settings.
+func GetDBPool() DBPool {
+	var p DBPool
+	Config.Viper.UnmarshalKey("DBPool", &p)
+	return p
+}
+
+// GetSDKPool returns settings of SDK server health checks.
+func GetSDKPool() SDKPool {
+	var p SDKPool
+	Config.Viper.UnmarshalKey("SDKPool", &p)
+	return p
+}
+
+// GetQueryCacheInvalidations returns cached responses dropped by successful calls, keyed by the write method.
+func GetQueryCacheInvalidations() map[string][]CacheInvalidation {
+	invalidations := map[string][]CacheInvalidation{}
+	Config.Viper.UnmarshalKey("QueryCacheInvalidations", &invalidations)
+	return invalidations
+}
+
+// GetCoalescedMethods returns SDK methods whose identical anonymous calls share a single call to the SDK.
+func GetCoalescedMethods() []string {
+	return Config.Viper.GetStringSlice("CoalescedMethods")
+}
+
+// GetUserQueryCacheTTLs returns how long responses to authenticated calls are cached for each user,
+// methods not listed are not cached.
+func GetUserQueryCacheTTLs() map[string]time.Duration {
+	ttls := map[string]time.Duration{}
+	Config.Viper.UnmarshalKey("UserQueryCacheTTLs", &ttls)
+	return ttls
+}
+
+// GetQueryCacheRedis returns settings of the query cache shared by all instances through Redis.
+func GetQueryCacheRedis() QueryCacheRedis {
+	var r QueryCacheRedis
+	Config.Viper.UnmarshalKey("QueryCacheRedis", &r)
+	return r
+}
+
+// GetBatchRequests returns limits of JSON-RPC batches.
+func GetBatchRequests() BatchRequests {
+	var b BatchRequests
+	Config.Viper.UnmarshalKey("BatchRequests", &b)
+	return b
+}
+
+// GetBalancePolling returns settings of wallet balance long polls.
+func GetBalancePolling() BalancePolling {
+	var b BalancePolling
+	Config.Viper.UnmarshalKey("BalancePolling", &b)
+	return b
+}
+
+// GetBalancePollingRedis returns settings of passing balance changes between instances through Redis.
+func GetBalancePollingRedis() BalancePollingRedis {
+	var r BalancePollingRedis
+	Config.Viper.UnmarshalKey("BalancePollingRedis", &r)
+	return r
+}
+
+// GetEmbed returns settings of the embed player endpoints.
+func GetEmbed() Embed {
+	var e Embed
+	Config.Viper.UnmarshalKey("Embed", &e)
+	return e
+}
+
+// GetWebSocketRPC returns settings of JSON-RPC over WebSocket.
+func GetWebSocketRPC() WebSocketRPC {
+	var ws WebSocketRPC
+	Config.Viper.UnmarshalKey("WebSocketRPC", &ws)
+	return ws
+}
+
+// GetGRPC returns settings of the gRPC Proxy service.
+func GetGRPC() GRPC {
+	var g GRPC
+	Config.Viper.UnmarshalKey("GRPC", &g)
+	return g
+}
+
+// GetEvents returns settings of SDK event subscriptions.
+func GetEvents() Events {
+	var e Events
+	Config.Viper.UnmarshalKey("Events", &e)
+	return e
+}
+
+// GetFraudScoring returns settings of fraud scoring of publishes and purchases.
+func GetFraudScoring() FraudScoring {
+	var f FraudScoring
+	Config.Viper.UnmarshalKey("FraudScoring", &f)
+	return f
+}
+
+// GetResolveSharding returns settings of splitting large resolves across SDK servers.
+func GetResolveSharding() ResolveSharding {
+	var s ResolveSharding
+	Config.Viper.UnmarshalKey("ResolveSharding", &s)
+	return s
+}
+
+// GetSDKBreaker returns settings of the breakers failing calls fast to SDK servers which stopped responding.
+func GetSDKBreaker() SDKBreaker {
+	var b SDKBreaker
+	Config.Viper.UnmarshalKey("SDKBreaker", &b)
+	return b
+}
+
+// GetDBBreaker returns settings of the breaker failing DB calls fast when the DB is unavailable.
+func GetDBBreaker() DBBreaker {
+	var b DBBreaker
+	Config.Viper.UnmarshalKey("DBBreaker", &b)
+	return b
+}
+
+// GetDBExplainSlowQueries returns true if slow DB query warnings should include the query plan.
+func GetDBExplainSlowQueries() bool {
+	return Config.Viper.GetBool("DBExplainSlowQueries")
+}
+
+// GetWalletLoadLockTimeout returns how long a wallet load waits for loads of the same wallet by other instances.
+func GetWalletLoadLockTimeout() time.Duration {
+	return Config.Viper.GetDuration("WalletLoadLockTimeout")
+}
+
+// GetWalletLoadShareWindow returns for how long a wallet load by one instance is reused by others.
+func GetWalletLoadShareWindow() time.Duration {
+	return Config.Viper.GetDuration("WalletLoadShareWindow")
+}
+
+// GetDuplicateUploadWindow returns for how long files published by a user are rejected when uploaded again.
+// Zero disables duplicate detection.
+func GetDuplicateUploadWindow() time.Duration {
+	return Config.Viper.GetDuration("DuplicateUploadWindow")
+}
+
+// GetPublishedEchoTTL returns for how long claims published through lbrytv are added to resolve and claim_search
+// responses if the Hub doesn't return them yet. Zero disables it.
+func GetPublishedEchoTTL() time.Duration {
+	return Config.Viper.GetDuration("PublishedEchoTTL")
+}
+
+// GetPublishedEchoSize returns how many published claims are kept until the Hub returns them.
+func GetPublishedEchoSize() int {
+	return Config.Viper.GetInt("PublishedEchoSize")
+}
+
+// GetStatusComponentsTTL returns how long the public component status is cached for.
+func GetStatusComponentsTTL() time.Duration {
+	return Config.Viper.GetDuration("StatusComponentsTTL")
+}
+
+// GetSDKCallTimeouts returns how long a single SDK request for each method may take before it's cancelled.
+// Methods not listed use sdkrouter.RPCTimeout. Latency budgets still apply to the whole call on top of that.
+func GetSDKCallTimeouts() map[string]time.Duration {
+	var timeouts map[string]time.Duration
+	Config.Viper.UnmarshalKey("SDKCallTimeouts", &timeouts)
+	return timeouts
+}
+
+// GetQueryCacheTTLs returns how long responses to SDK methods are cached, methods not listed are not cached.
+// It's checked on every call, so the map is shared and must not be modified.
+func GetQueryCacheTTLs() map[string]time.Duration {
+	return parsedKey("QueryCacheTTLs", func() interface{} {
+		ttls := map[string]time.Duration{}
+		Config.Viper.UnmarshalKey("QueryCacheTTLs", &ttls)
+		return ttls
+	}).(map[string]time.Duration)
+}
+
+// GetChannelCacheTTL returns how long channel metadata is served from cache before being re-fetched.
+func GetChannelCacheTTL() time.Duration {
+	return Config.Viper.GetDuration("ChannelCacheTTL")
+}
+
+// GetChannelCacheSize returns how many channels are kept in the channel metadata cache.
+func GetChannelCacheSize() int {
+	return Config.Viper.GetInt("ChannelCacheSize")
+}
+
+// GetLogSampling returns log sampling settings applied on startup.
+func GetLogSampling() LogSampling {
+	var ls LogSampling
+	Config.Viper.UnmarshalKey("LogSampling", &ls)
+	return ls
+}
+
+// GetLogRedaction returns custom rules for stripping secrets from logs and Sentry reports.
+func GetLogRedaction() LogRedaction {
+	var lr LogRedaction
+	Config.Viper.UnmarshalKey("LogRedaction", &lr)
+	return lr
+}
+
+// GetStatsD returns StatsD sink settings. The sink is disabled when Address is empty.
+func GetStatsD() StatsD {
+	var s StatsD
+	Config.Viper.UnmarshalKey("StatsD", &s)
+	return s
+}
+
+// GetProfiling returns continuous profiling settings. Profiling is disabled when ServerAddress is empty.
+func GetProfiling() Profiling {
+	var p Profiling
+	Config.Viper.UnmarshalKey("Profiling", &p)
+	return p
+}
+
+// GetResponseSpillThreshold returns serialized response size (in bytes) above which responses are spilled to disk.
+// Spilling is disabled when it's zero.
+func GetResponseSpillThreshold() int {
+	return Config.Viper.GetInt("ResponseSpillThreshold")
+}
+
+// GetResponseSpillMaxConcurrent returns how many responses can be spilled to disk at the same time.
+func GetResponseSpillMaxConcurrent() int {
+	return Config.Viper.GetInt("ResponseSpillMaxConcurrent")
+}
+
+// GetResponseSpillDir returns the directory spilled responses are written to.
+func GetResponseSpillDir() string {
+	return Config.Viper.GetString("ResponseSpillDir")
+}
+
+// GetSDKResponseMaxSize returns the largest SDK response body (in bytes) the proxy will read and decode.
+// Larger responses are rejected before they are decoded into memory. There is no limit when it's zero.
+func GetSDKResponseMaxSize() int64 {
+	return Config.Viper.GetInt64("SDKResponseMaxSize")
+}
+
+// GetSentryWorkers returns the number of background workers sending Sentry reports.
+func GetSentryWorkers() int {
+	return Config.Viper.GetInt("SentryWorkers")
+}
+
+// GetSentryQueueSize returns how many Sentry reports can wait for sending before new ones are dropped.
+func GetSentryQueueSize() int {
+	return Config.Viper.GetInt("SentryQueueSize")
+}
+
+// GetStartupRetry returns retry settings for startup steps.
+func GetStartupRetry() StartupRetry {
+	var r StartupRetry
+	Config.Viper.UnmarshalKey("StartupRetry", &r)
+	return r
+}
+
+// GetScheduledTasks returns periodic tasks to run, see jobs.ParseSchedule for the schedule format.
+func GetScheduledTasks() []ScheduledTask {
+	tasks := []ScheduledTask{}
+	Config.Viper.UnmarshalKey("ScheduledTasks", &tasks)
+	return tasks
+}
+
+// GetContentAvailability returns settings of content availability checks.
+func GetContentAvailability() ContentAvailability {
+	var a ContentAvailability
+	Config.Viper.UnmarshalKey("ContentAvailability", &a)
+	return a
+}
+
+// GetModerationNotifyURL returns the URL takedown notices for uploaders are posted to.
+func GetModerationNotifyURL() string {
+	return Config.Viper.GetString("ModerationNotifyURL")
+}
+
+// GetUploadTokenTTL returns the longest time an upload token stays valid for.
+func GetUploadTokenTTL() time.Duration {
+	return Config.Viper.GetDuration("UploadTokenTTL")
+}
+
+// GetAssembledUploads returns limits for uploads sent in parts.
+func GetAssembledUploads() AssembledUploads {
+	var u AssembledUploads
+	Config.Viper.UnmarshalKey("AssembledUploads", &u)
+	return u
+}
+
+// GetDeltaUploads returns settings for delta re-uploads.
+func GetDeltaUploads() DeltaUploads {
+	var d DeltaUploads
+	Config.Viper.UnmarshalKey("DeltaUploads", &d)
+	return d
+}
+
+// GetUploadStorage returns settings of the storage uploaded files are handed to SDK nodes from.
+func GetUploadStorage() UploadStorage {
+	var s UploadStorage
+	Config.Viper.UnmarshalKey("UploadStorage", &s)
+	return s
+}
+
+// GetResumableUploads returns limits for uploads sent over tus.io protocol.
+func GetResumableUploads() ResumableUploads {
+	var u ResumableUploads
+	Config.Viper.UnmarshalKey("ResumableUploads", &u)
+	return u
+}
+
+// GetUploadAnalysis returns settings of uploaded file analysis, it is disabled when Command is empty.
+func GetUploadAnalysis() UploadAnalysis {
+	var a UploadAnalysis
+	Config.Viper.UnmarshalKey("UploadAnalysis", &a)
+	return a
+}
+
+// GetThumbnails returns settings of thumbnails made for uploaded videos.
+func GetThumbnails() Thumbnails {
+	var t Thumbnails
+	Config.Viper.UnmarshalKey("Thumbnails", &t)
+	return t
+}
+
+// GetTorrents returns settings of seeding published files.
+func GetTorrents() Torrents {
+	var t Torrents
+	Config.Viper.UnmarshalKey("Torrents", &t)
+	return t
+}
+
+// GetCDNPurge returns settings of purging CDN URLs.
+func GetCDNPurge() CDNPurge {
+	var p CDNPurge
+	Config.Viper.UnmarshalKey("CDNPurge", &p)
+	return p
+}
+
+// GetCloudImports returns limits for files imported from cloud storage.
+func GetCloudImports() CloudImports {
+	var i CloudImports
+	Config.Viper.UnmarshalKey("CloudImports", &i)
+	return i
+}
+
+// GetRemoteSources returns limits for files downloaded from source_url of publish requests.
+func GetRemoteSources() RemoteSources {
+	var s RemoteSources
+	Config.Viper.UnmarshalKey("RemoteSources", &s)
+	return s
+}
+
+// GetUploadLimits returns upload size and daily quota limits.
+func GetUploadLimits() UploadLimits {
+	var l UploadLimits
+	Config.Viper.UnmarshalKey("UploadLimits", &l)
+	return l
+}
+
+// GetUploadScanner returns settings of malware scanning of uploaded files.
+func GetUploadScanner() UploadScanner {
+	var s UploadScanner
+	Config.Viper.UnmarshalKey("UploadScanner", &s)
+	return s
+}
+
+// GetUploadMediaTypes returns media types uploaded files are checked against.
+func GetUploadMediaTypes() UploadMediaTypes {
+	var t UploadMediaTypes
+	Config.Viper.UnmarshalKey("UploadMediaTypes", &t)
+	return t
+}
+
+// GetOutbox returns settings of outbox message delivery.
+func GetOutbox() Outbox {
+	var o Outbox
+	Config.Viper.UnmarshalKey("Outbox", &o)
+	return o
+}
+
+// GetCredentialLifecycle returns settings of API key and secret rotation.
+func GetCredentialLifecycle() CredentialLifecycle {
+	var l CredentialLifecycle
+	Config.Viper.UnmarshalKey("CredentialLifecycle", &l)
+	return l
+}
+
+// GetSDKRetry returns settings of SDK call retries.
+func GetSDKRetry() SDKRetry {
+	var r SDKRetry
+	Config.Viper.UnmarshalKey("SDKRetry", &r)
+	return r
+}
+
+// GetAuthThrottle returns settings of auth failure throttling.
+func GetAuthThrottle() AuthThrottle {
+	var t AuthThrottle
+	Config.Viper.UnmarshalKey("AuthThrottle", &t)
+	return t
+}
+
+// GetAuthThrottleRedis returns settings of counting auth failures in Redis.
+func GetAuthThrottleRedis() AuthThrottleRedis {
+	var r AuthThrottleRedis
+	Config.Viper.UnmarshalKey("AuthThrottleRedis", &r)
+	return r
+}
+
+// GetUserSessions returns settings of user session tracking.
+func GetUserSessions() UserSessions {
+	var s UserSessions
+	Config.Viper.UnmarshalKey("UserSessions", &s)
+	return s
+}
+
+// GetUploadJanitor returns settings of orphaned upload removal.
+func GetUploadJanitor() UploadJanitor {
+	var j UploadJanitor
+	Config.Viper.UnmarshalKey("UploadJanitor", &j)
+	return j
+}
+
+// GetAPIKeySecurity returns settings of organization API key anomaly detection.
+func GetAPIKeySecurity() APIKeySecurity {
+	var s APIKeySecurity
+	Config.Viper.UnmarshalKey("APIKeySecurity", &s)
+	return s
+}
+
+// GetAsyncPublishes returns settings of background publish processing.
+func GetAsyncPublishes() AsyncPublishes {
+	var p AsyncPublishes
+	Config.Viper.UnmarshalKey("AsyncPublishes", &p)
+	return p
+}
+
+// GetPublishPolicies returns publish policies keyed by channel claim ID.
+func GetPublishPolicies() map[string]PublishPolicy {
+	policies := map[string]PublishPolicy{}
+	Config.Viper.UnmarshalKey("PublishPolicies", &policies)
+	return policies
+}
+
+// GetOrganizationUploadQuota returns the upload quota in bytes given to newly created organizations, 0 means unlimited.
+func GetOrganizationUploadQuota() int64 {
+	return Config.Viper.GetInt64("OrganizationUploadQuota")
+}
+
+// GetLatencyBudgets returns latency budgets keyed by method class (read, wallet, publish) or SDK method name.
+func GetLatencyBudgets() map[string]time.Duration {
+	budgets := map[string]time.Duration{}
+	Config.Viper.UnmarshalKey("LatencyBudgets", &budgets)
+	return budgets
+}
+
+// GetSDKMethodVersions returns minimum SDK versions keyed by method name, for methods not every SDK version handles.
+func GetSDKMethodVersions() map[string]string {
+	versions := map[string]string{}
+	Config.Viper.UnmarshalKey("SDKMethodVersions", &versions)
+	return versions
+}
+
+// GetClientDeadlines returns settings for honoring request deadlines sent by clients.
+func GetClientDeadlines() ClientDeadlines {
+	var d ClientDeadlines
+	Config.Viper.UnmarshalKey("ClientDeadlines", &d)
+	return d
+}
+
+// GetTelemetry returns usage telemetry settings. Telemetry is disabled when Endpoint is empty.
+func GetTelemetry() Telemetry {
+	var t Telemetry
+	Config.Viper.UnmarshalKey("Telemetry", &t)
+	return t
+}
+
+// GetSDKSigningSecrets returns secrets for signing requests to SDK nodes, keyed by lowercase SDK server name.
+func GetSDKSigningSecrets() map[string]string {
+	return Config.Viper.GetStringMapString("SDKSigningSecrets")
+}
+
+// GetWebhookSigningSecret returns the secret outbox webhooks are signed with until it's rotated.
+func GetWebhookSigningSecret() string {
+	return Config.Viper.GetString("WebhookSigningSecret")
+}
+
+// GetRecommendations returns settings of related claim computation.
+func GetRecommendations() Recommendations {
+	var r Recommendations
+	Config.Viper.UnmarshalKey("Recommendations", &r)
+	return r
+}
+
+// GetTrending returns settings of trending lists, which are disabled when no Categories are set.
+func GetTrending() Trending {
+	var t Trending
+	Config.Viper.UnmarshalKey("Trending", &t)
+	return t
+}
+
+// GetContentPage returns settings of composed content page data.
+func GetContentPage() ContentPage {
+	var p ContentPage
+	Config.Viper.UnmarshalKey("ContentPage", &p)
+	return p
+}
+
+// GetTransformRules returns settings of declarative transformation rules, which are disabled when File is empty.
+func GetTransformRules() TransformRules {
+	var r TransformRules
+	Config.Viper.UnmarshalKey("TransformRules", &r)
+	return r
+}
+
+// GetMethodPolicies returns settings of method policies, which are disabled when File is empty.
+func GetMethodPolicies() MethodPolicies {
+	var p MethodPolicies
+	Config.Viper.UnmarshalKey("MethodPolicies", &p)
+	return p
+}
+
+// GetSDKTLS returns mutual TLS settings for connections to SDK nodes, which is disabled when CertFile is empty.
+func GetSDKTLS() SDKTLS {
+	var t SDKTLS
+	Config.Viper.UnmarshalKey("SDKTLS", &t)
+	return t
+}
@@ -33,3 +33,17 @@ func TestGetTokenCacheTimeout(t *testing.T) {
 	defer Config.RestoreOverridden()
 	assert.Equal(t, 325*time.Second, GetTokenCacheTimeout())
 }
+
+func TestGetQueryCacheTTLs(t *testing.T) {
+	Config.Override("QueryCacheTTLs", map[string]interface{}{"resolve": "1m"})
+	ttls := GetQueryCacheTTLs()
+	assert.Equal(t, map[string]time.Duration{"resolve": time.Minute}, ttls)
+	// Parsed once until the config changes
+	ttls["resolve"] = time.Hour
+	assert.Equal(t, time.Hour, GetQueryCacheTTLs()["resolve"])
+	Config.RestoreOverridden()
+
+	Config.Override("QueryCacheTTLs", map[string]interface{}{"resolve": "2m"})
+	defer Config.RestoreOverridden()
+	assert.Equal(t, map[string]time.Duration{"resolve": 2 * time.Minute}, GetQueryCacheTTLs())
+}
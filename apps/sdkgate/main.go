@@ -0,0 +1,37 @@
+// sdkgate runs next to an SDK node and proxies to it only requests signed by lbrytv.
+// The secret must match the one configured for the node in lbrytv's SDKSigningSecrets, or the latest one
+// it has been rotated to via /api/v1/admin/secrets. lbrytv signs with both until the overlap passes.
+//
+//	SDKGATE_SECRET=change-me sdkgate -listen :5279 -upstream http://127.0.0.1:5280/
+package main
+
+import (
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/sdksign"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	listen := flag.String("listen", ":5279", "address to accept signed requests on")
+	upstream := flag.String("upstream", "http://127.0.0.1:5280/", "SDK node address")
+	maxSkew := flag.Duration("max-skew", time.Minute, "maximum difference between request timestamp and local clock")
+	flag.Parse()
+
+	secret := os.Getenv("SDKGATE_SECRET")
+	if secret == "" {
+		log.Fatal("SDKGATE_SECRET is not set")
+	}
+	target, err := url.Parse(*upstream)
+	if err != nil {
+		log.Fatalf("invalid upstream address: %v", err)
+	}
+
+	log.Infof("sdkgate listening on %v, proxying to %v", *listen, target)
+	log.Fatal(http.ListenAndServe(*listen, sdksign.NewGate(target, []byte(secret), *maxSkew)))
+}
@@ -0,0 +1,245 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/availability"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/deadletter"
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/organization"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/publish"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/retention"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/secrets"
+	"github.com/lbryio/lbrytv/app/taxonomy"
+	"github.com/lbryio/lbrytv/app/trending"
+	"github.com/lbryio/lbrytv/app/verification"
+	"github.com/lbryio/lbrytv/app/wallet"
+	"github.com/lbryio/lbrytv/app/wallet/tracker"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/jobs"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/ybbus/jsonrpc"
+)
+
+var logger = monitor.NewModuleLogger("cmd")
+
+// registerTaskKinds makes built-in task kinds available for ScheduledTasks in config.
+func registerTaskKinds(rt *sdkrouter.Router) {
+	// warm_query calls a cacheable SDK method so its result is already in cache when users request it.
+	jobs.RegisterKind("warm_query", func(params map[string]interface{}) (func() error, error) {
+		method, _ := params["method"].(string)
+		if method == "" {
+			return nil, errors.Err("method is required")
+		}
+		req := jsonrpc.NewRequest(method)
+		if p, ok := params["params"]; ok {
+			req = jsonrpc.NewRequest(method, normalizeParams(p))
+		}
+		return func() error {
+			c := query.NewCaller(rt.RandomServer().Address, 0)
+			c.Cache = cache.Shared()
+			res, err := c.Call(req)
+			if err != nil {
+				return err
+			}
+			if res.Error != nil {
+				return errors.Err(res.Error.Message)
+			}
+			return nil
+		}, nil
+	})
+
+	// refresh_channels re-fetches metadata of all channels in the channel metadata cache.
+	jobs.RegisterKind("refresh_channels", func(params map[string]interface{}) (func() error, error) {
+		return func() error {
+			c := channels.Current()
+			if c == nil {
+				return errors.Err("channel cache is not set up")
+			}
+			return c.Refresh()
+		}, nil
+	})
+
+	// refresh_trending recomputes trending lists of all categories.
+	jobs.RegisterKind("refresh_trending", func(params map[string]interface{}) (func() error, error) {
+		return func() error {
+			e := trending.Current()
+			if e == nil {
+				return errors.Err("trending is not set up")
+			}
+			return e.Refresh()
+		}, nil
+	})
+
+	// reload_blocklist picks up claims taken down on other instances.
+	jobs.RegisterKind("reload_blocklist", func(params map[string]interface{}) (func() error, error) {
+		return moderation.LoadBlocklist, nil
+	})
+
+	// reload_tags picks up tag taxonomy changes made on other instances.
+	jobs.RegisterKind("reload_tags", func(params map[string]interface{}) (func() error, error) {
+		return taxonomy.Load, nil
+	})
+
+	// reload_verified_channels picks up channels verified or unverified on other instances.
+	jobs.RegisterKind("reload_verified_channels", func(params map[string]interface{}) (func() error, error) {
+		return verification.Load, nil
+	})
+
+	// unload_wallets unloads wallets of users who were not active for older_than.
+	jobs.RegisterKind("unload_wallets", func(params map[string]interface{}) (func() error, error) {
+		olderThan, err := time.ParseDuration(fmt.Sprint(params["older_than"]))
+		if err != nil {
+			return nil, errors.Prefix("invalid older_than", err)
+		}
+		return func() error {
+			_, err := tracker.Unload(boil.GetDB(), olderThan)
+			return err
+		}, nil
+	})
+
+	// prune_outbox deletes outbox messages delivered more than older_than ago.
+	jobs.RegisterKind("prune_outbox", func(params map[string]interface{}) (func() error, error) {
+		olderThan, err := time.ParseDuration(fmt.Sprint(params["older_than"]))
+		if err != nil {
+			return nil, errors.Prefix("invalid older_than", err)
+		}
+		return func() error {
+			_, err := outbox.Prune(olderThan)
+			return err
+		}, nil
+	})
+
+	// expire_credentials deletes expired organization API keys and secrets retired after rotation.
+	jobs.RegisterKind("expire_credentials", func(params map[string]interface{}) (func() error, error) {
+		return func() error {
+			if _, err := organization.ExpireAPIKeys(); err != nil {
+				return err
+			}
+			_, err := secrets.Retire()
+			return err
+		}, nil
+	})
+
+	// prune_auth_failures deletes authentication failure counts which no longer throttle anyone.
+	jobs.RegisterKind("prune_auth_failures", func(params map[string]interface{}) (func() error, error) {
+		return func() error {
+			_, err := wallet.PruneAuthFailures()
+			return err
+		}, nil
+	})
+
+	// enforce_retention soft-deletes records in table older than delete_after and purges them purge_after later.
+	jobs.RegisterKind("enforce_retention", func(params map[string]interface{}) (func() error, error) {
+		p := retention.Policy{}
+		p.Table, _ = params["table"].(string)
+		deleteAfter, err := time.ParseDuration(fmt.Sprint(params["delete_after"]))
+		if err != nil {
+			return nil, errors.Prefix("invalid delete_after", err)
+		}
+		p.DeleteAfter = deleteAfter
+		if v, ok := params["purge_after"]; ok {
+			p.PurgeAfter, err = time.ParseDuration(fmt.Sprint(v))
+			if err != nil {
+				return nil, errors.Prefix("invalid purge_after", err)
+			}
+		}
+		if err := p.Validate(); err != nil {
+			return nil, err
+		}
+		return func() error {
+			_, _, err := retention.Enforce(boil.GetDB(), p)
+			return err
+		}, nil
+	})
+
+	// check_content_availability checks up to batch_size published claims not checked within recheck_after.
+	jobs.RegisterKind("check_content_availability", func(params map[string]interface{}) (func() error, error) {
+		batchSize, recheckAfter := 100, 24*time.Hour
+		if v, ok := params["batch_size"]; ok {
+			n, err := strconv.Atoi(fmt.Sprint(v))
+			if err != nil || n <= 0 {
+				return nil, errors.Err("invalid batch_size")
+			}
+			batchSize = n
+		}
+		if v, ok := params["recheck_after"]; ok {
+			d, err := time.ParseDuration(fmt.Sprint(v))
+			if err != nil {
+				return nil, errors.Prefix("invalid recheck_after", err)
+			}
+			recheckAfter = d
+		}
+		return func() error {
+			originals := publish.Handler{UploadPath: config.GetPublishSourceDir(), Storage: filestore.Default()}
+			c := availability.DefaultChecker(originals)
+			if c == nil {
+				return availability.ErrNotConfigured
+			}
+			_, err := c.CheckDue(rt.RandomServer().Address, batchSize, recheckAfter)
+			return err
+		}, nil
+	})
+}
+
+// newScheduler creates a scheduler for ScheduledTasks defined in config.
+func newScheduler(rt *sdkrouter.Router) (*jobs.Scheduler, error) {
+	registerTaskKinds(rt)
+	scheduled := []*jobs.Job{}
+	for _, t := range config.GetScheduledTasks() {
+		j, err := jobs.NewJob(t.Name, t.Schedule, t.Kind, t.Params)
+		if err != nil {
+			return nil, err
+		}
+		scheduled = append(scheduled, j)
+	}
+	s := jobs.NewScheduler(scheduled...)
+
+	// Failed runs are kept as dead letters, redelivering one runs its job again
+	s.OnFailure = func(j *jobs.Job, err error) {
+		_, dlErr := deadletter.Record(boil.GetDB(), deadletter.SourceJob, j.Kind, j.Name, normalizeParams(j.Params), 1, err)
+		if dlErr != nil {
+			logger.Log().Errorf("cannot record failed run of job %v: %v", j.Name, dlErr)
+		}
+	}
+	deadletter.RegisterRedeliverer(deadletter.SourceJob, func(l *deadletter.Letter) error {
+		return s.RunNow(l.Ref)
+	})
+	return s, nil
+}
+
+// normalizeParams converts maps decoded from YAML config into maps with string keys so they can be sent as JSON.
+func normalizeParams(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range typed {
+			m[fmt.Sprint(k)] = normalizeParams(val)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, val := range typed {
+			m[k] = normalizeParams(val)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(typed))
+		for i, val := range typed {
+			l[i] = normalizeParams(val)
+		}
+		return l
+	}
+	return v
+}
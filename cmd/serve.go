@@ -9,12 +9,41 @@ import (
 	"time"
 
 	"github.com/lbryio/lbrytv-player/pkg/paid"
+	"github.com/lbryio/lbrytv/app/balance"
+	"github.com/lbryio/lbrytv/app/canary"
+	"github.com/lbryio/lbrytv/app/channels"
+	"github.com/lbryio/lbrytv/app/filestore"
+	"github.com/lbryio/lbrytv/app/moderation"
+	"github.com/lbryio/lbrytv/app/outbox"
+	"github.com/lbryio/lbrytv/app/policy"
+	"github.com/lbryio/lbrytv/app/publish"
+	"github.com/lbryio/lbrytv/app/published"
+	"github.com/lbryio/lbrytv/app/quarantine"
+	"github.com/lbryio/lbrytv/app/query"
+	"github.com/lbryio/lbrytv/app/query/cache"
+	"github.com/lbryio/lbrytv/app/recommendations"
+	"github.com/lbryio/lbrytv/app/rules"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/app/taxonomy"
+	"github.com/lbryio/lbrytv/app/trending"
+	"github.com/lbryio/lbrytv/app/urlfilter"
+	"github.com/lbryio/lbrytv/app/verification"
 	"github.com/lbryio/lbrytv/app/wallet"
 	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/profiling"
+	"github.com/lbryio/lbrytv/internal/sdktls"
+	"github.com/lbryio/lbrytv/internal/slo"
+	"github.com/lbryio/lbrytv/internal/startup"
+	"github.com/lbryio/lbrytv/internal/storage"
+	"github.com/lbryio/lbrytv/internal/telemetry"
 	"github.com/lbryio/lbrytv/server"
+	"github.com/lbryio/lbrytv/version"
 
 	"github.com/spf13/cobra"
+	"github.com/volatiletech/sqlboiler/boil"
 )
 
 var rootCmd = &cobra.Command{
@@ -22,25 +51,212 @@ var rootCmd = &cobra.Command{
 	Short: "lbrytv is a backend API server for lbry.tv frontend",
 	Run: func(cmd *cobra.Command, args []string) {
 		rand.Seed(time.Now().UnixNano()) // always seed random!
-		sdkRouter := sdkrouter.New(config.GetLbrynetServers())
-		go sdkRouter.WatchLoad()
 
-		s := server.NewServer(config.GetAddress(), sdkRouter)
-		err := s.Start()
-		if err != nil {
+		var (
+			sdkRouter *sdkrouter.Router
+			s         *server.Server
+		)
+		boot := startup.New(
+			startup.Backoff(config.GetStartupRetry()),
+			startup.Step{Name: "config", Run: func() error {
+				ls := config.GetLogSampling()
+				monitor.SetSampling(monitor.SamplingConfig{Period: ls.Period, Initial: ls.Initial, Thereafter: ls.Thereafter})
+				lr := config.GetLogRedaction()
+				if err := monitor.SetRedaction(monitor.RedactionRules{Fields: lr.Fields, Patterns: lr.Patterns}); err != nil {
+					return err
+				}
+				if err := query.LoadSchemas(config.GetSDKSchemaDir(), config.GetSDKSchemaValidation()); err != nil {
+					return err
+				}
+				if cmd := config.GetUploadScanCommand(); len(cmd) > 0 {
+					quarantine.RegisterCheck("scanner", quarantine.CommandCheck(cmd))
+				}
+				if tc := config.GetSDKTLS(); tc.CertFile != "" {
+					src, err := sdktls.NewSource(sdktls.Config(tc))
+					if err != nil {
+						return err
+					}
+					sdktls.SetDefault(src)
+					go src.Watch()
+				}
+				st, err := filestore.New(config.GetUploadStorage())
+				if err != nil {
+					return err
+				}
+				filestore.SetDefault(st)
+				if rc := config.GetTransformRules(); rc.File != "" {
+					if err := rules.Load(rc.File); err != nil {
+						return err
+					}
+					go rules.Watch(rc.File, rc.ReloadInterval)
+				}
+				if pc := config.GetMethodPolicies(); pc.File != "" {
+					if err := policy.Load(pc.File); err != nil {
+						return err
+					}
+					go policy.Watch(pc.File, pc.ReloadInterval)
+				}
+				key, err := ioutil.ReadFile(config.GetPaidTokenPrivKey())
+				if err != nil {
+					return err
+				}
+				return paid.InitPrivateKey(key)
+			}},
+			startup.Step{Name: "db", Run: func() error {
+				if err := storage.Conn.DB.Ping(); err != nil {
+					return err
+				}
+				if err := moderation.LoadBlocklist(); err != nil {
+					return err
+				}
+				if err := taxonomy.Load(); err != nil {
+					return err
+				}
+				return verification.Load()
+			}},
+			startup.Step{Name: "cache", Run: func() error {
+				if rc := config.GetQueryCacheRedis(); rc.Address != "" {
+					qc, err := cache.NewRedisCache(rc)
+					if err != nil {
+						return err
+					}
+					cache.SetShared(qc)
+				}
+				if rc := config.GetAuthThrottleRedis(); rc.Address != "" {
+					ts, err := wallet.NewRedisThrottleStore(rc)
+					if err != nil {
+						return err
+					}
+					wallet.SetThrottleStore(ts)
+				}
+				wallet.SetTokenCache(wallet.NewTokenCache(config.GetTokenCacheTimeout()))
+				wallet.SetLoadCoordinator(wallet.NewLoadCoordinator(config.GetWalletLoadLockTimeout(), config.GetWalletLoadShareWindow()))
+				if ttl := config.GetPublishedEchoTTL(); ttl > 0 {
+					published.SetIndex(published.NewIndex(ttl, config.GetPublishedEchoSize()))
+				}
+				return nil
+			}},
+			startup.Step{Name: "router", Run: func() (err error) {
+				// Server list is loaded from the DB when it's not in the config, which panics on failure
+				defer errors.Recover(&err)
+				sdkRouter = sdkrouter.New(config.GetLbrynetServers())
+				channels.SetCache(channels.NewCache(channels.SDKFetcher(sdkRouter), config.GetChannelCacheTTL(), config.GetChannelCacheSize()))
+				if rc := config.GetRecommendations(); rc.IndexSize > 0 {
+					e := recommendations.NewEngine(rc.IndexTTL, rc.IndexSize, rc.ResultTTL)
+					e.Count, e.ChannelWeight, e.Annotate = rc.Count, rc.ChannelWeight, rc.AnnotateResolve
+					e.Lookup = recommendations.SDKLookup(sdkRouter)
+					if rc.ServiceURL != "" {
+						e.Service = recommendations.HTTPService(rc.ServiceURL, rc.ServiceTimeout)
+					}
+					recommendations.SetEngine(e)
+				}
+				if tc := config.GetTrending(); len(tc.Categories) > 0 {
+					e := trending.NewEngine(tc.Categories, trending.SDKSearch(sdkRouter, tc.MaxAge),
+						trending.APIViewCounts(config.GetInternalAPIHost(), tc.ViewCountTimeout))
+					e.HalfLife, e.Candidates, e.Size = tc.HalfLife, tc.Candidates, tc.Size
+					trending.SetEngine(e)
+					// Failures are logged per category, the scheduled task retries them
+					go e.Refresh()
+				}
+				return nil
+			}},
+			startup.Step{Name: "http", Run: func() error {
+				s = server.NewServer(config.GetAddress(), sdkRouter)
+				return s.Start()
+			}},
+		)
+		startup.SetDefault(boot)
+		if err := boot.Run(); err != nil {
 			log.Fatal(err)
 		}
 
-		key, err := ioutil.ReadFile(config.GetPaidTokenPrivKey())
-		if err != nil {
-			log.Fatal(err)
+		go sdkRouter.WatchLoad()
+		go slo.WatchBudgets()
+
+		if fc := config.GetMissingURLFilter(); fc.Source != "" {
+			src := urlfilter.NewSource(urlfilter.Config(fc))
+			if rc := config.GetMissingURLFilterRedis(); rc.Address != "" {
+				client, err := urlfilter.NewRedisClient(rc)
+				if err != nil {
+					log.Fatal(err)
+				}
+				src.ShareEntries(client, rc.Namespace+"entries")
+			}
+			urlfilter.SetDefault(src)
+			go src.Watch()
 		}
-		err = paid.InitPrivateKey(key)
+
+		if rc := config.GetBalancePollingRedis(); rc.Address != "" {
+			client, err := balance.NewRedisClient(rc)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if _, err := balance.ShareNotifications(client, rc.Namespace+"changes"); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if sc := config.GetStatsD(); sc.Address != "" {
+			sink, err := metrics.NewStatsDSink(sc.Address, sc.Prefix, sc.Format)
+			if err != nil {
+				log.Fatal(err)
+			}
+			go metrics.RunSinks(sc.Interval, map[string]string{
+				"version": version.GetVersion(),
+				"host":    os.Getenv("HOSTNAME"),
+			}, sink)
+		}
+
+		if pc := config.GetProfiling(); pc.ServerAddress != "" {
+			u := profiling.NewPyroscopeUploader(pc.ServerAddress, pc.AppName, pc.AuthToken, map[string]string{
+				"version":  version.GetVersion(),
+				"instance": os.Getenv("HOSTNAME"),
+			})
+			go profiling.NewProfiler(pc.Interval, u).Start()
+		}
+
+		if tc := config.GetTelemetry(); tc.Endpoint != "" {
+			t, err := telemetry.New(telemetry.Config(tc))
+			if err != nil {
+				log.Fatal(err)
+			}
+			telemetry.SetDefault(t)
+			go t.Start()
+		}
+
+		scheduler, err := newScheduler(sdkRouter)
 		if err != nil {
 			log.Fatal(err)
 		}
-		c := wallet.NewTokenCache(config.GetTokenCacheTimeout())
-		wallet.SetTokenCache(c)
+		go scheduler.Start()
+
+		if oc := config.GetOutbox(); oc.Interval > 0 {
+			d := outbox.NewDispatcher(oc.Interval)
+			d.BatchSize, d.MaxAttempts, d.RetryDelay, d.Timeout = oc.BatchSize, oc.MaxAttempts, oc.RetryDelay, oc.Timeout
+			go d.Start()
+		}
+
+		if tc := config.GetAuthThrottle(); tc.WebhookURL != "" {
+			wallet.AddLockoutHook(func(l wallet.Lockout) {
+				if _, err := outbox.Enqueue(boil.GetDB(), outbox.KindWebhook, tc.WebhookURL, l); err != nil {
+					logger.Log().Errorf("cannot report authentication lockout: %v", err)
+				}
+			})
+		}
+
+		if sc := config.GetUserSessions(); sc.RevocationPollInterval > 0 {
+			go wallet.WatchRevocations(sc.RevocationPollInterval)
+		}
+
+		if jc := config.GetUploadJanitor(); jc.Interval > 0 {
+			go publish.NewJanitor(config.GetPublishSourceDir(), jc.Interval, jc.MaxAge).Start()
+		}
+
+		if interval := config.GetCanaryInterval(); interval > 0 {
+			r := canary.NewRunner(interval, canary.DefaultProbes(sdkRouter)...)
+			canary.SetRunner(r)
+			go r.Start()
+		}
 
 		// ServeUntilShutdown is blocking, should be last
 		s.ServeUntilShutdown()
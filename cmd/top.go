@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/overview"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor to the top left corner and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// standaloneCommands don't need a DB connection or other setup done in main.
+var standaloneCommands = map[string]bool{"top": true}
+
+// IsStandalone returns true if args invoke a command which doesn't need a DB connection.
+func IsStandalone(args []string) bool {
+	return len(args) > 0 && standaloneCommands[args[0]]
+}
+
+var (
+	topURL      string
+	topToken    string
+	topInterval time.Duration
+)
+
+func init() {
+	topCmd.Flags().StringVar(&topURL, "url", "", "base URL of the instance (default is the local instance from config)")
+	topCmd.Flags().StringVar(&topToken, "token", "", "admin token (default is AdminToken from config)")
+	topCmd.Flags().DurationVar(&topInterval, "interval", 2*time.Second, "refresh interval")
+	rootCmd.AddCommand(topCmd)
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show a live view of in-flight requests, method throughput, node health and caches of an instance",
+	Run: func(cmd *cobra.Command, args []string) {
+		url := topURL
+		if url == "" {
+			addr := config.GetAddress()
+			if strings.HasPrefix(addr, ":") {
+				addr = "localhost" + addr
+			}
+			url = "http://" + addr
+		}
+		token := topToken
+		if token == "" {
+			token = config.GetAdminToken()
+		}
+
+		client := &http.Client{Timeout: 5 * time.Second}
+		var prev *overview.Overview
+		for {
+			out := &bytes.Buffer{}
+			fmt.Fprintf(out, "%slbrytv top: %v (every %v, ctrl-c to quit)\n", clearScreen, url, topInterval)
+			cur, err := overview.Fetch(client, url, token)
+			if err != nil {
+				fmt.Fprintf(out, "\nerror: %v\n", err)
+			} else {
+				overview.Render(out, prev, cur)
+				prev = cur
+			}
+			out.WriteTo(os.Stdout)
+			time.Sleep(topInterval)
+		}
+	},
+}
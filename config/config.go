@@ -1,6 +1,8 @@
 package config
 
 import (
+	"sync/atomic"
+
 	"github.com/spf13/viper"
 )
 
@@ -8,6 +10,7 @@ type ConfigWrapper struct {
 	Viper      *viper.Viper
 	configName string
 	overridden map[string]interface{}
+	generation uint64
 }
 
 type DBConfig struct {
@@ -69,6 +72,7 @@ func (c *ConfigWrapper) GetDatabase() DBConfig {
 func (c *ConfigWrapper) Override(key string, value interface{}) {
 	c.overridden[key] = c.Viper.Get(key)
 	c.Viper.Set(key, value)
+	atomic.AddUint64(&c.generation, 1)
 }
 
 // RestoreOverridden restores original v values overridden by Override
@@ -81,4 +85,11 @@ func (c *ConfigWrapper) RestoreOverridden() {
 		v.Set(k, val)
 	}
 	c.overridden = make(map[string]interface{})
+	atomic.AddUint64(&c.generation, 1)
+}
+
+// Generation changes every time settings are changed after the config has been read,
+// so values parsed from them can be kept until then.
+func (c *ConfigWrapper) Generation() uint64 {
+	return atomic.LoadUint64(&c.generation)
 }
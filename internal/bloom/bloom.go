@@ -0,0 +1,76 @@
+// Package bloom implements a concurrency-safe bloom filter for strings.
+package bloom
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"sync"
+)
+
+// Filter answers whether a string was possibly added (with a false positive rate it was sized for)
+// or definitely was not.
+type Filter struct {
+	mu    sync.RWMutex
+	bits  []uint64
+	m     uint64
+	k     uint64
+	added int
+}
+
+// New creates a filter sized for n strings with false positive rate p.
+func New(n int, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// hashes returns two independent hashes of s used for double hashing (Kirsch-Mitzenmacher).
+func hashes(s string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(s))
+	sum := h.Sum(nil)
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:]) | 1
+}
+
+// Add puts s into the filter.
+func (f *Filter) Add(s string) {
+	h1, h2 := hashes(s)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+	f.added++
+}
+
+// Test returns false if s was definitely never added.
+func (f *Filter) Test(s string) bool {
+	h1, h2 := hashes(s)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < f.k; i++ {
+		pos := (h1 + i*h2) % f.m
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Count returns how many strings were added, including duplicates.
+func (f *Filter) Count() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.added
+}
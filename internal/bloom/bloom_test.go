@@ -0,0 +1,34 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	f := New(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		f.Add(fmt.Sprintf("claim-%v", i))
+	}
+	assert.Equal(t, 10000, f.Count())
+
+	for i := 0; i < 10000; i++ {
+		assert.True(t, f.Test(fmt.Sprintf("claim-%v", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.Test(fmt.Sprintf("missing-%v", i)) {
+			falsePositives++
+		}
+	}
+	assert.Less(t, falsePositives, 200, "false positive rate is way over 1 percent")
+}
+
+func TestNew_Bounds(t *testing.T) {
+	f := New(0, 2)
+	f.Add("what")
+	assert.True(t, f.Test("what"))
+}
@@ -0,0 +1,125 @@
+// Package bufpool provides size-classed pools of byte slices and buffers for copying uploads
+// and serializing responses without allocating large buffers on every request.
+// Buffers larger than the biggest size class are never pooled, which keeps pooled memory bounded.
+package bufpool
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/lbryio/lbrytv/internal/metrics"
+)
+
+const (
+	kindBytes  = "bytes"
+	kindBuffer = "buffer"
+
+	resultHit       = "hit"
+	resultMiss      = "miss"
+	resultPooled    = "pooled"
+	resultDiscarded = "discarded"
+	classOversized  = "oversized"
+)
+
+// CopyBufferSize is the size of buffers used for io.CopyBuffer.
+const CopyBufferSize = 256 << 10
+
+type sizeClass struct {
+	size    int
+	label   string
+	bytes   sync.Pool
+	buffers sync.Pool
+}
+
+// classes must be sorted by size.
+var classes = newClasses(4<<10, 32<<10, 256<<10, 1<<20, 4<<20)
+
+func newClasses(sizes ...int) []*sizeClass {
+	cs := []*sizeClass{}
+	for _, s := range sizes {
+		cs = append(cs, &sizeClass{size: s, label: fmt.Sprintf("%dk", s>>10)})
+	}
+	return cs
+}
+
+// classFor returns the smallest class fitting size, or nil if size is larger than all classes.
+func classFor(size int) *sizeClass {
+	for _, c := range classes {
+		if size <= c.size {
+			return c
+		}
+	}
+	return nil
+}
+
+// classOf returns the largest class whose size does not exceed capacity, or nil.
+func classOf(capacity int) *sizeClass {
+	var found *sizeClass
+	for _, c := range classes {
+		if c.size > capacity {
+			break
+		}
+		found = c
+	}
+	return found
+}
+
+// GetBytes returns a byte slice of at least size bytes. It should be returned with PutBytes when no longer used.
+func GetBytes(size int) *[]byte {
+	c := classFor(size)
+	if c == nil {
+		metrics.BufferPoolGets.WithLabelValues(kindBytes, classOversized, resultMiss).Inc()
+		b := make([]byte, size)
+		return &b
+	}
+	if b, ok := c.bytes.Get().(*[]byte); ok {
+		metrics.BufferPoolGets.WithLabelValues(kindBytes, c.label, resultHit).Inc()
+		return b
+	}
+	metrics.BufferPoolGets.WithLabelValues(kindBytes, c.label, resultMiss).Inc()
+	b := make([]byte, c.size)
+	return &b
+}
+
+// PutBytes returns a byte slice obtained from GetBytes to the pool.
+func PutBytes(b *[]byte) {
+	c := classFor(cap(*b))
+	if c == nil || c.size != cap(*b) {
+		metrics.BufferPoolPuts.WithLabelValues(kindBytes, classOversized, resultDiscarded).Inc()
+		return
+	}
+	*b = (*b)[:c.size]
+	c.bytes.Put(b)
+	metrics.BufferPoolPuts.WithLabelValues(kindBytes, c.label, resultPooled).Inc()
+}
+
+// GetBuffer returns an empty buffer with capacity for at least sizeHint bytes.
+// It should be returned with PutBuffer when its contents are no longer used.
+func GetBuffer(sizeHint int) *bytes.Buffer {
+	c := classFor(sizeHint)
+	if c == nil {
+		metrics.BufferPoolGets.WithLabelValues(kindBuffer, classOversized, resultMiss).Inc()
+		return bytes.NewBuffer(make([]byte, 0, sizeHint))
+	}
+	if b, ok := c.buffers.Get().(*bytes.Buffer); ok {
+		metrics.BufferPoolGets.WithLabelValues(kindBuffer, c.label, resultHit).Inc()
+		return b
+	}
+	metrics.BufferPoolGets.WithLabelValues(kindBuffer, c.label, resultMiss).Inc()
+	return bytes.NewBuffer(make([]byte, 0, c.size))
+}
+
+// PutBuffer resets the buffer and returns it to the pool matching its capacity, which may have grown since GetBuffer.
+// Buffers grown beyond twice the largest class are discarded.
+func PutBuffer(b *bytes.Buffer) {
+	largest := classes[len(classes)-1]
+	c := classOf(b.Cap())
+	if c == nil || b.Cap() > 2*largest.size {
+		metrics.BufferPoolPuts.WithLabelValues(kindBuffer, classOversized, resultDiscarded).Inc()
+		return
+	}
+	b.Reset()
+	c.buffers.Put(b)
+	metrics.BufferPoolPuts.WithLabelValues(kindBuffer, c.label, resultPooled).Inc()
+}
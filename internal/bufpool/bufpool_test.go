@@ -0,0 +1,49 @@
+package bufpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBytes(t *testing.T) {
+	b := GetBytes(1000)
+	assert.Equal(t, 4<<10, len(*b))
+	PutBytes(b)
+
+	b = GetBytes(CopyBufferSize)
+	assert.Equal(t, CopyBufferSize, len(*b))
+	PutBytes(b)
+
+	b = GetBytes(10 << 20)
+	assert.Equal(t, 10<<20, len(*b))
+	PutBytes(b)
+}
+
+func TestPutBytes_RestoresLength(t *testing.T) {
+	b := GetBytes(100)
+	*b = (*b)[:10]
+	PutBytes(b)
+	b = GetBytes(100)
+	assert.Equal(t, 4<<10, len(*b))
+}
+
+func TestGetBuffer(t *testing.T) {
+	b := GetBuffer(100)
+	assert.Equal(t, 0, b.Len())
+	assert.GreaterOrEqual(t, b.Cap(), 100)
+	b.WriteString("leftover")
+	PutBuffer(b)
+
+	b = GetBuffer(100)
+	assert.Equal(t, 0, b.Len())
+	PutBuffer(b)
+}
+
+func TestClassOf(t *testing.T) {
+	assert.Nil(t, classOf(100))
+	assert.Equal(t, 4<<10, classOf(5000).size)
+	assert.Equal(t, 4<<20, classOf(100<<20).size)
+	assert.Nil(t, classFor(100<<20))
+	assert.Equal(t, 32<<10, classFor(5000).size)
+}
@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2020, 6, 10, 13, 27, 45, 0, time.UTC)
+
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2020, 6, 10, 13, 28, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, 6, 10, 13, 30, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2020, 6, 10, 13, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2020, 6, 10, 14, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2020, 6, 11, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2020, 6, 10, 17, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * mon", time.Date(2020, 6, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2020, 6, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * jan,feb *", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either of them matches
+		{"0 0 20 * fri", time.Date(2020, 6, 12, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 6, 11, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, 6, 10, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			s, err := ParseSchedule(c.expr)
+			require.NoError(t, err)
+			assert.Equal(t, c.next, s.Next(from))
+		})
+	}
+}
+
+func TestSchedule_NextImpossible(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, s.Next(time.Now()).IsZero())
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		_, err := ParseSchedule(expr)
+		assert.Error(t, err, expr)
+	}
+}
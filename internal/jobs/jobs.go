@@ -0,0 +1,207 @@
+// Package jobs runs periodic tasks on cron schedules. Task kinds are registered in code,
+// while particular tasks and their schedules are defined in config.
+package jobs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	logger = monitor.NewModuleLogger("jobs")
+
+	ErrUnknownJob = errors.Base("no such scheduled job")
+	ErrJobRunning = errors.Base("job is already running")
+
+	kindsMu sync.RWMutex
+	kinds   = map[string]Factory{}
+)
+
+// Factory creates a task function of a certain kind from params supplied in config.
+type Factory func(params map[string]interface{}) (func() error, error)
+
+// Job is a task executed on a schedule.
+type Job struct {
+	Name     string
+	Schedule *Schedule
+	Run      func() error
+	// Kind and Params the job was created from, empty for jobs created by hand.
+	Kind   string
+	Params map[string]interface{}
+}
+
+// RegisterKind makes a task kind available for scheduling from config.
+func RegisterKind(kind string, f Factory) {
+	kindsMu.Lock()
+	defer kindsMu.Unlock()
+	kinds[kind] = f
+}
+
+// NewJob creates a job of a registered kind running on the cron schedule.
+func NewJob(name, schedule, kind string, params map[string]interface{}) (*Job, error) {
+	s, err := ParseSchedule(schedule)
+	if err != nil {
+		return nil, errors.Prefix("job "+name, err)
+	}
+	kindsMu.RLock()
+	f, ok := kinds[kind]
+	kindsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("job %v: unknown task kind %q", name, kind)
+	}
+	run, err := f(params)
+	if err != nil {
+		return nil, errors.Prefix("job "+name, err)
+	}
+	return &Job{Name: name, Schedule: s, Run: run, Kind: kind, Params: params}, nil
+}
+
+// Scheduler runs jobs when their schedules are due. A job is skipped if its previous run is still in progress.
+type Scheduler struct {
+	// OnFailure is called after a job run fails, if set.
+	OnFailure func(j *Job, err error)
+
+	jobs []*Job
+	stop chan struct{}
+	now  func() time.Time
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler for jobs, which will be run once Start is called.
+func NewScheduler(jobs ...*Job) *Scheduler {
+	return &Scheduler{
+		jobs:    jobs,
+		stop:    make(chan struct{}),
+		now:     time.Now,
+		running: map[string]bool{},
+	}
+}
+
+// Start runs jobs on their schedules until Stop is called. It blocks.
+func (s *Scheduler) Start() {
+	if len(s.jobs) == 0 {
+		return
+	}
+	names := []string{}
+	for _, j := range s.jobs {
+		names = append(names, j.Name)
+	}
+	logger.Log().Infof("starting scheduled jobs: %v", names)
+
+	for {
+		now := s.now()
+		next, due := s.nextRun(now)
+		if next.IsZero() {
+			logger.Log().Warn("no scheduled jobs will ever run, stopping")
+			return
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-timer.C:
+			for _, j := range due {
+				s.launch(j)
+			}
+		case <-s.stop:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// Stop stops scheduling jobs and waits for the running ones to finish.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// RunNow launches the job named name outside of its schedule, unless it's already running.
+// It doesn't wait for the job to finish.
+func (s *Scheduler) RunNow(name string) error {
+	for _, j := range s.jobs {
+		if j.Name == name {
+			if !s.launch(j) {
+				return errors.Prefix(name, ErrJobRunning)
+			}
+			return nil
+		}
+	}
+	return errors.Prefix(name, ErrUnknownJob)
+}
+
+// nextRun returns the earliest scheduled time after now and all jobs due at that time.
+func (s *Scheduler) nextRun(now time.Time) (time.Time, []*Job) {
+	var (
+		next time.Time
+		due  []*Job
+	)
+	for _, j := range s.jobs {
+		t := j.Schedule.Next(now)
+		switch {
+		case t.IsZero():
+		case next.IsZero() || t.Before(next):
+			next, due = t, []*Job{j}
+		case t.Equal(next):
+			due = append(due, j)
+		}
+	}
+	sort.Slice(due, func(i, k int) bool { return due[i].Name < due[k].Name })
+	return next, due
+}
+
+// launch runs the job in the background and returns false if it was skipped because it's still running.
+func (s *Scheduler) launch(j *Job) bool {
+	s.mu.Lock()
+	if s.running[j.Name] {
+		s.mu.Unlock()
+		logger.WithFields(logrus.Fields{"job": j.Name}).Warn("previous run is still in progress, skipping")
+		metrics.JobRuns.WithLabelValues(j.Name, metrics.JobSkipped).Inc()
+		return false
+	}
+	s.running[j.Name] = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, j.Name)
+			s.mu.Unlock()
+		}()
+		s.run(j)
+	}()
+	return true
+}
+
+func (s *Scheduler) run(j *Job) {
+	l := logger.WithFields(logrus.Fields{"job": j.Name})
+	start := time.Now()
+	err := func() (err error) {
+		defer errors.Recover(&err)
+		return j.Run()
+	}()
+	duration := time.Since(start).Seconds()
+	metrics.JobDurations.WithLabelValues(j.Name).Observe(duration)
+	if err != nil {
+		metrics.JobRuns.WithLabelValues(j.Name, metrics.JobFailed).Inc()
+		l.WithField("duration", duration).Errorf("job failed: %v", err)
+		monitor.ErrorToSentry(err, map[string]string{"job": j.Name})
+		if s.OnFailure != nil {
+			s.OnFailure(j, err)
+		}
+		return
+	}
+	metrics.JobRuns.WithLabelValues(j.Name, metrics.JobSucceeded).Inc()
+	l.WithField("duration", duration).Info("job done")
+}
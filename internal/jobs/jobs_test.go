@@ -0,0 +1,121 @@
+package jobs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJob(t *testing.T) {
+	var gotParams map[string]interface{}
+	RegisterKind("test_kind", func(params map[string]interface{}) (func() error, error) {
+		if params["fail"] == true {
+			return nil, errors.New("bad params")
+		}
+		gotParams = params
+		return func() error { return nil }, nil
+	})
+
+	j, err := NewJob("test", "*/5 * * * *", "test_kind", map[string]interface{}{"a": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "test", j.Name)
+	assert.Equal(t, "test_kind", j.Kind)
+	assert.Equal(t, map[string]interface{}{"a": 1}, j.Params)
+	assert.Equal(t, map[string]interface{}{"a": 1}, gotParams)
+
+	_, err = NewJob("test", "*/5 * * *", "test_kind", nil)
+	assert.Error(t, err)
+	_, err = NewJob("test", "*/5 * * * *", "nonexistent", nil)
+	assert.EqualError(t, err, `job test: unknown task kind "nonexistent"`)
+	_, err = NewJob("test", "*/5 * * * *", "test_kind", map[string]interface{}{"fail": true})
+	assert.EqualError(t, err, "job test: bad params")
+}
+
+func TestScheduler_NextRun(t *testing.T) {
+	now := time.Date(2020, 6, 10, 13, 27, 0, 0, time.UTC)
+	mustParse := func(expr string) *Schedule {
+		s, err := ParseSchedule(expr)
+		require.NoError(t, err)
+		return s
+	}
+	s := NewScheduler(
+		&Job{Name: "hourly", Schedule: mustParse("@hourly")},
+		&Job{Name: "b", Schedule: mustParse("*/10 * * * *")},
+		&Job{Name: "a", Schedule: mustParse("30 * * * *")},
+		&Job{Name: "never", Schedule: mustParse("0 0 30 2 *")},
+	)
+	next, due := s.nextRun(now)
+	assert.Equal(t, time.Date(2020, 6, 10, 13, 30, 0, 0, time.UTC), next)
+	require.Len(t, due, 2)
+	assert.Equal(t, "a", due[0].Name)
+	assert.Equal(t, "b", due[1].Name)
+}
+
+func TestScheduler_SkipsOverlappingRuns(t *testing.T) {
+	release := make(chan struct{})
+	runs := 0
+	j := &Job{Name: "slow", Run: func() error {
+		runs++
+		<-release
+		return nil
+	}}
+	s := NewScheduler(j)
+	s.launch(j)
+	time.Sleep(10 * time.Millisecond)
+	s.launch(j)
+	close(release)
+	s.Stop()
+	assert.Equal(t, 1, runs)
+
+	s = NewScheduler(j)
+	s.launch(j)
+	s.Stop()
+	assert.Equal(t, 2, runs)
+}
+
+func TestScheduler_RecoversPanics(t *testing.T) {
+	j := &Job{Name: "panicky", Run: func() error { panic("oops") }}
+	s := NewScheduler(j)
+	assert.NotPanics(t, func() {
+		s.launch(j)
+		s.Stop()
+	})
+}
+
+func TestScheduler_OnFailure(t *testing.T) {
+	var (
+		failedJob *Job
+		failure   error
+	)
+	j := &Job{Name: "failing", Run: func() error { return errors.New("boom") }}
+	ok := &Job{Name: "ok", Run: func() error { return nil }}
+	s := NewScheduler(j, ok)
+	s.OnFailure = func(j *Job, err error) { failedJob, failure = j, err }
+
+	require.NoError(t, s.RunNow("ok"))
+	s.wg.Wait()
+	assert.Nil(t, failedJob)
+
+	require.NoError(t, s.RunNow("failing"))
+	s.Stop()
+	assert.Equal(t, j, failedJob)
+	assert.EqualError(t, failure, "boom")
+}
+
+func TestScheduler_RunNow(t *testing.T) {
+	release := make(chan struct{})
+	j := &Job{Name: "slow", Run: func() error {
+		<-release
+		return nil
+	}}
+	s := NewScheduler(j)
+	require.NoError(t, s.RunNow("slow"))
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, errors.Is(s.RunNow("slow"), ErrJobRunning))
+	assert.True(t, errors.Is(s.RunNow("nonexistent"), ErrUnknownJob))
+	close(release)
+	s.Stop()
+}
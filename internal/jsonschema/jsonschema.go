@@ -0,0 +1,276 @@
+// Package jsonschema validates decoded JSON values against a subset of JSON Schema (draft 7).
+//
+// Supported keywords are type, properties, required, additionalProperties (boolean only), items,
+// enum, minimum, maximum, minLength, maxLength, minItems, maxItems and pattern,
+// which is enough for describing RPC method params. Unsupported keywords are rejected when a schema is parsed
+// so a schema never silently validates less than its author expected.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+const (
+	TypeString  = "string"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeArray   = "array"
+	TypeObject  = "object"
+	TypeNull    = "null"
+)
+
+// Schema is a parsed JSON schema.
+type Schema struct {
+	SchemaURI            string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// Types is a list of allowed types, in a schema it can be written either as a single string or an array.
+type Types []string
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*t = Types{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(b, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// Violation describes a single place where a value doesn't match the schema.
+type Violation struct {
+	// Path is a dot-separated location of the offending value, e.g. `params.claim_ids[2]`.
+	Path    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%v: %v", v.Path, v.Message)
+}
+
+// Parse decodes a schema and compiles its patterns.
+func Parse(b []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var s Schema
+	if err := dec.Decode(&s); err != nil {
+		return nil, errors.Prefix("invalid schema", err)
+	}
+	if err := s.compile("#"); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) compile(path string) error {
+	for _, t := range s.Type {
+		switch t {
+		case TypeString, TypeInteger, TypeNumber, TypeBoolean, TypeArray, TypeObject, TypeNull:
+		default:
+			return errors.Err("%v: unknown type %q", path, t)
+		}
+	}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return errors.Err("%v: invalid pattern: %v", path, err)
+		}
+		s.pattern = re
+	}
+	for name, p := range s.Properties {
+		if err := p.compile(path + "/properties/" + name); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile(path + "/items")
+	}
+	return nil
+}
+
+// Validate returns all violations found in value, which should be decoded with encoding/json.
+// Violations are sorted by path.
+func (s *Schema) Validate(root string, value interface{}) []Violation {
+	var vs []Violation
+	s.validate(root, value, &vs)
+	sort.SliceStable(vs, func(i, j int) bool { return vs[i].Path < vs[j].Path })
+	return vs
+}
+
+func (s *Schema) validate(path string, value interface{}, vs *[]Violation) {
+	add := func(format string, a ...interface{}) {
+		*vs = append(*vs, Violation{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	actual := typeOf(value)
+	if len(s.Type) > 0 && !s.allows(actual) {
+		add("expected %v, got %v", strings.Join(s.Type, " or "), actual)
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if equal(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %v", formatEnum(s.Enum))
+		}
+	}
+
+	switch typed := value.(type) {
+	case string:
+		n := utf8.RuneCountInString(typed)
+		if s.MinLength != nil && n < *s.MinLength {
+			add("must be at least %v characters long", *s.MinLength)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			add("must be at most %v characters long", *s.MaxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(typed) {
+			add("must match %v", s.Pattern)
+		}
+	case []interface{}:
+		if s.MinItems != nil && len(typed) < *s.MinItems {
+			add("must have at least %v items", *s.MinItems)
+		}
+		if s.MaxItems != nil && len(typed) > *s.MaxItems {
+			add("must have at most %v items", *s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, v := range typed {
+				s.Items.validate(fmt.Sprintf("%v[%v]", path, i), v, vs)
+			}
+		}
+	case map[string]interface{}:
+		for _, r := range s.Required {
+			if _, ok := typed[r]; !ok {
+				*vs = append(*vs, Violation{Path: path + "." + r, Message: "is required"})
+			}
+		}
+		for k, v := range typed {
+			if p, ok := s.Properties[k]; ok {
+				p.validate(path+"."+k, v, vs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*vs = append(*vs, Violation{Path: path + "." + k, Message: "is not a known parameter"})
+			}
+		}
+	default:
+		if f, ok := toFloat(value); ok {
+			if s.Minimum != nil && f < *s.Minimum {
+				add("must be at least %v", *s.Minimum)
+			}
+			if s.Maximum != nil && f > *s.Maximum {
+				add("must be at most %v", *s.Maximum)
+			}
+		}
+	}
+}
+
+func (s *Schema) allows(actual string) bool {
+	for _, t := range s.Type {
+		if t == actual || (t == TypeNumber && actual == TypeInteger) {
+			return true
+		}
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch typed := value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBoolean
+	case string:
+		return TypeString
+	case []interface{}:
+		return TypeArray
+	case map[string]interface{}:
+		return TypeObject
+	case json.Number:
+		if _, err := typed.Int64(); err == nil {
+			return TypeInteger
+		}
+		return TypeNumber
+	}
+	if f, ok := toFloat(value); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return TypeInteger
+		}
+		return TypeNumber
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case float32:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int64:
+		return float64(typed), true
+	case json.Number:
+		f, err := typed.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func equal(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		fb, ok := toFloat(b)
+		return ok && fa == fb
+	}
+	switch a.(type) {
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
+
+func formatEnum(values []interface{}) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		b, _ := json.Marshal(v)
+		s[i] = string(b)
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,73 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const claimSearchSchema = `{
+	"type": "object",
+	"properties": {
+		"page_size": {"type": "integer", "minimum": 1, "maximum": 50},
+		"claim_ids": {"type": "array", "items": {"type": "string", "pattern": "^[0-9a-f]{40}$"}, "maxItems": 2},
+		"order_by": {"type": ["string", "array"], "items": {"type": "string"}},
+		"claim_type": {"enum": ["stream", "channel"]},
+		"text": {"type": "string", "maxLength": 5}
+	},
+	"required": ["page_size"],
+	"additionalProperties": false
+}`
+
+func decode(t *testing.T, s string) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(s), &v))
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Parse([]byte(claimSearchSchema))
+	require.NoError(t, err)
+
+	assert.Empty(t, s.Validate("params", decode(t, `{"page_size": 20, "order_by": "release_time", "claim_type": "stream"}`)))
+	assert.Empty(t, s.Validate("params", decode(t, `{"page_size": 1, "order_by": ["release_time"], "text": "café"}`)))
+
+	vs := s.Validate("params", decode(t, `{
+		"page_size": 2.5,
+		"claim_ids": ["3fda836a92faaceedfe398225fb9b2ee2ed1f01a", "nope", 1],
+		"order_by": {},
+		"claim_type": "collection",
+		"text": "too long",
+		"wallet": "x"
+	}`))
+	assert.Equal(t, []Violation{
+		{"params.claim_ids", "must have at most 2 items"},
+		{"params.claim_ids[1]", "must match ^[0-9a-f]{40}$"},
+		{"params.claim_ids[2]", "expected string, got integer"},
+		{"params.claim_type", `must be one of "stream", "channel"`},
+		{"params.order_by", "expected string or array, got object"},
+		{"params.page_size", "expected integer, got number"},
+		{"params.text", "must be at most 5 characters long"},
+		{"params.wallet", "is not a known parameter"},
+	}, vs)
+
+	vs = s.Validate("params", decode(t, `{"page_size": 100}`))
+	assert.Equal(t, []Violation{{"params.page_size", "must be at most 50"}}, vs)
+
+	vs = s.Validate("params", decode(t, `{}`))
+	assert.Equal(t, []Violation{{"params.page_size", "is required"}}, vs)
+
+	vs = s.Validate("params", decode(t, `["a"]`))
+	assert.Equal(t, []Violation{{"params", "expected object, got array"}}, vs)
+}
+
+func TestParse_Rejects(t *testing.T) {
+	_, err := Parse([]byte(`{"type": "object", "oneOf": []}`))
+	assert.Error(t, err, "unsupported keywords must not be ignored")
+	_, err = Parse([]byte(`{"type": "int"}`))
+	assert.Error(t, err)
+	_, err = Parse([]byte(`{"properties": {"a": {"pattern": "("}}}`))
+	assert.Error(t, err)
+}
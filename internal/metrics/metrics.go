@@ -1,6 +1,8 @@
 package metrics
 
 import (
+	"github.com/lbryio/lbrytv/internal/monitor"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	dto "github.com/prometheus/client_model/go"
@@ -34,6 +36,161 @@ const (
 	FailureKindAuth             = "auth"
 	FailureKindInternal         = "internal"
 	FailureKindLbrynetXMismatch = "xmismatch"
+	FailureKindTimeout          = "timeout"
+	FailureKindUnavailable      = "unavailable"
+
+	AttemptSucceeded = "succeeded"
+	AttemptRetried   = "retried"
+	AttemptFailed    = "failed"
+
+	UserCacheHit         = "hit"
+	UserCacheMiss        = "miss"
+	UserCacheInvalidated = "invalidated"
+
+	ResolveShardSucceeded = "succeeded"
+	ResolveShardFailed    = "failed"
+
+	InvalidParamsRejected = "rejected"
+	InvalidParamsAllowed  = "allowed"
+
+	PrefetchWarmed   = "warmed"
+	PrefetchCached   = "cached"
+	PrefetchInFlight = "in_flight"
+	PrefetchSkipped  = "skipped"
+	PrefetchFailed   = "failed"
+
+	EventDelivered = "delivered"
+	EventDropped   = "dropped"
+
+	FraudAllowed      = "allowed"
+	FraudApproved     = "approved"
+	FraudHeld         = "held"
+	FraudRejected     = "rejected"
+	FraudFailedOpen   = "failed_open"
+	FraudFailedClosed = "failed_closed"
+
+	ContentAvailable   = "available"
+	ContentUnavailable = "unavailable"
+	ContentUnknown     = "unknown"
+	ContentRepaired    = "repaired"
+	ContentRepairFail  = "failed"
+
+	EmbedCached      = "cached"
+	EmbedFetched     = "fetched"
+	EmbedRateLimited = "rate_limited"
+	EmbedOverloaded  = "overloaded"
+	EmbedFailed      = "failed"
+
+	BalancePollImmediate = "immediate"
+	BalancePollChanged   = "changed"
+	BalancePollTimedOut  = "timed_out"
+	BalancePollRejected  = "rejected"
+
+	ChannelCacheHit         = "hit"
+	ChannelCacheMiss        = "miss"
+	ChannelCacheInvalidated = "invalidated"
+
+	RecommendationsCached        = "cached"
+	RecommendationsComputed      = "computed"
+	RecommendationsService       = "service"
+	RecommendationsServiceFailed = "service_failed"
+
+	TrendingRefreshSucceeded = "succeeded"
+	TrendingRefreshFailed    = "failed"
+
+	ResolveFilterShortcut      = "shortcut"
+	ResolveFilterPassedThrough = "passed_through"
+	ResolveFilterMispredicted  = "mispredicted"
+
+	RetentionSoftDeleted = "soft_deleted"
+	RetentionPurged      = "purged"
+
+	PublishedAdded      = "added"
+	PublishedServed     = "served"
+	PublishedReconciled = "reconciled"
+	PublishedExpired    = "expired"
+
+	WalletLoadSharedLocal   = "local"
+	WalletLoadSharedCluster = "cluster"
+
+	DBTransactionCommit   = "commit"
+	DBTransactionRollback = "rollback"
+
+	// BudgetCauseUpstream means the latency budget ran out while waiting for the SDK.
+	BudgetCauseUpstream = "upstream"
+	// BudgetCauseProxy means the latency budget ran out before the query was sent to the SDK.
+	BudgetCauseProxy = "proxy"
+
+	ClientDeadlineApplied = "applied"
+	ClientDeadlineExpired = "expired"
+	ClientDeadlineInvalid = "invalid"
+
+	VersionRoutedCompatible = "compatible"
+	VersionRoutedNone       = "none"
+
+	UploadTokenIssued   = "issued"
+	UploadTokenConsumed = "consumed"
+	UploadTokenRejected = "rejected"
+
+	CloudImportStarted = "started"
+	CloudImportDone    = "done"
+	CloudImportFailed  = "failed"
+
+	UploadRejectedFileSize   = "file_size"
+	UploadRejectedDailyQuota = "daily_quota"
+	UploadRejectedMediaType  = "media_type"
+	UploadRejectedDuplicate  = "duplicate"
+
+	UploadChunkCompleted   = "completed"
+	UploadChunkInterrupted = "interrupted"
+
+	UploadHandedOver     = "handed_over"
+	UploadTakenOver      = "taken_over"
+	UploadHandoverFailed = "failed"
+
+	UploadScanClean    = "clean"
+	UploadScanInfected = "infected"
+	UploadScanFailed   = "failed"
+
+	RemoteSourceDownloaded = "downloaded"
+	RemoteSourceRejected   = "rejected"
+	RemoteSourceFailed     = "failed"
+
+	AsyncPublishQueued    = "queued"
+	AsyncPublishRejected  = "rejected"
+	AsyncPublishSucceeded = "succeeded"
+	AsyncPublishFailed    = "failed"
+
+	UploadAnalysisSuggested = "suggested"
+	UploadAnalysisNone      = "none"
+	UploadAnalysisFailed    = "failed"
+
+	ThumbnailMade   = "made"
+	ThumbnailFailed = "failed"
+
+	TorrentSeeded    = "seeded"
+	TorrentOverQuota = "over_quota"
+	TorrentFailed    = "failed"
+	TorrentRemoved   = "removed"
+
+	CDNPurgePurged  = "purged"
+	CDNPurgeRetried = "retried"
+	CDNPurgeFailed  = "failed"
+
+	OutboxEnqueued  = "enqueued"
+	OutboxDelivered = "delivered"
+	OutboxRetried   = "retried"
+	OutboxFailed    = "failed"
+
+	LegalHoldPlaced   = "placed"
+	LegalHoldReleased = "released"
+
+	SpillResultSpilled  = "spilled"
+	SpillResultRejected = "rejected"
+
+	JobSucceeded = "success"
+	JobFailed    = "failed"
+	JobSkipped   = "skipped"
 
 	GroupControl      = "control"
 	GroupExperimental = "experimental"
@@ -65,6 +222,30 @@ var (
 		Subsystem: "cache",
 		Name:      "misses",
 	})
+	AuthThrottled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsAuth,
+		Subsystem: "throttle",
+		Name:      "rejected",
+		Help:      "Authentication attempts refused after earlier failures, by scope and whether delayed or locked out",
+	}, []string{"scope", "kind"})
+	AuthLockouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsAuth,
+		Subsystem: "throttle",
+		Name:      "lockouts",
+		Help:      "Lockouts after repeated authentication failures, by scope",
+	}, []string{"scope"})
+	AuthSessionsRevoked = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsAuth,
+		Subsystem: "sessions",
+		Name:      "revoked",
+		Help:      "Number of sessions revoked by users",
+	})
+	APIKeySecurityEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsAuth,
+		Subsystem: "api_keys",
+		Name:      "security_events_count",
+		Help:      "Anomalies in organization API key usage reported to key webhooks",
+	}, []string{"event"})
 
 	ProxyE2ECallDurations = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -162,6 +343,539 @@ var (
 		Name:      "error_count",
 		Help:      "Total number of errors retrieving queries from the local cache",
 	}, []string{"method"})
+	ProxyQueryCacheStaleCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "stale_count",
+		Help:      "Total number of cached queries discarded for having been saved by a different lbrytv build or SDK version",
+	}, []string{"method"})
+	ProxyQueryCacheEvictedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "evicted_count",
+		Help:      "Total number of cached queries dropped after expiring, going stale or being invalidated",
+	}, []string{"method"})
+	ProxyQueryCacheInvalidatedCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "invalidated_count",
+		Help:      "Total number of cached queries invalidated by admins or by write calls changing them",
+	}, []string{"method"})
+	ProxyQueryCacheLocalHitCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "local_hit_count",
+		Help:      "Total number of queries served from the local layer of the Redis cache without asking Redis",
+	}, []string{"method"})
+	ProxyQueryCacheRedisErrorCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "redis_error_count",
+		Help:      "Total number of failed Redis cache operations, by operation",
+	}, []string{"op"})
+	ProxyUserQueryCacheCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "user_count",
+		Help:      "Per-user cache lookups by whether they hit, and invalidations by the write method causing them",
+	}, []string{"method", "result"})
+	ProxyQueryCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "size",
+		Help:      "Number of queries in the shared cache, including expired ones not evicted yet",
+	})
+
+	ProxyResponseSizeLimitCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "responses",
+		Name:      "size_limited_count",
+		Help:      "Total number of responses that exceeded the configured size limit",
+	}, []string{"method", "action"})
+	ProxyParamLimitCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "requests",
+		Name:      "param_limited_count",
+		Help:      "Total number of requests rejected for a param exceeding the configured limit",
+	}, []string{"method", "param"})
+
+	RecoveredPanics = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "http",
+		Name:      "recovered_panics_count",
+		Help:      "Total number of panics recovered while serving requests",
+	}, []string{"endpoint"})
+
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "jobs",
+		Name:      "runs_count",
+		Help:      "Total number of scheduled job runs",
+	}, []string{"job", "status"})
+	JobDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "jobs",
+		Name:      "duration_seconds",
+		Help:      "Scheduled job run duration",
+		Buckets:   callsSecondsBuckets,
+	}, []string{"job"})
+
+	CanaryProbeSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "canary",
+		Name:      "success",
+		Help:      "Whether the last canary probe run succeeded (1) or failed (0)",
+	}, []string{"probe"})
+	CanaryProbeDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "canary",
+		Name:      "duration_seconds",
+		Help:      "Canary probe latency",
+		Buckets:   callsSecondsBuckets,
+	}, []string{"probe", "status"})
+
+	SLOIndicator = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "slo",
+		Name:      "indicator_ratio",
+		Help:      "Fraction of good events over a rolling window",
+	}, []string{"class", "sli", "window"})
+	SLOBurnRate = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "slo",
+		Name:      "error_budget_burn_rate",
+		Help:      "Error budget burn rate over a rolling window, 1 means the budget is consumed exactly over the SLO period",
+	}, []string{"class", "sli", "window"})
+	SLOObjective = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "slo",
+		Name:      "objective_ratio",
+		Help:      "Configured service level objective",
+	}, []string{"class", "sli"})
+
+	BufferPoolGets = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "buffer_pool",
+		Name:      "get_count",
+		Help:      "Buffers requested from the pool, by whether they were reused (hit) or allocated (miss)",
+	}, []string{"kind", "class", "result"})
+	BufferPoolPuts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "buffer_pool",
+		Name:      "put_count",
+		Help:      "Buffers returned to the pool, by whether they were pooled or discarded",
+	}, []string{"kind", "class", "result"})
+
+	LatencyBudgetExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "budget_exceeded_count",
+		Help:      "Calls cut short because their latency budget ran out, by whether the SDK or the proxy was slow",
+	}, []string{"method", "cause"})
+	ProxyCallAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "attempts_count",
+		Help:      "Attempts at sending calls to the SDK, by whether they succeeded, failed and were retried or failed for good",
+	}, []string{"method", "endpoint", "outcome"})
+	ProxyBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "batch_size",
+		Help:      "Number of calls in JSON-RPC batches",
+		Buckets:   []float64{1, 2, 5, 10, 20, 50, 100},
+	})
+	ProxyCallsCancelled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "cancelled_count",
+		Help:      "SDK calls cancelled because the client went away before they completed",
+	}, []string{"method"})
+	ProxyWebSocketConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsProxy,
+		Subsystem: "websocket",
+		Name:      "connections",
+		Help:      "Open JSON-RPC WebSocket connections",
+	})
+	ProxyWebSocketMessages = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "websocket",
+		Name:      "messages_count",
+		Help:      "Calls and batches received over JSON-RPC WebSocket connections",
+	})
+	ProxyCallsCoalesced = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "coalesced_count",
+		Help:      "Calls which got the response to an identical call in flight instead of being sent to the SDK",
+	}, []string{"method"})
+	ProxyResolveShards = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "resolve_shards_count",
+		Help:      "Shards of large resolves sent to SDK servers, by whether they succeeded",
+	}, []string{"endpoint", "outcome"})
+	ClientDeadlines = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "client_deadline_count",
+		Help:      "Deadlines sent by trusted clients, by whether they were applied, already expired on arrival or invalid",
+	}, []string{"client", "result"})
+
+	ProxyInvalidParams = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "invalid_params_count",
+		Help:      "Calls with params not matching the SDK method schema, by whether they were rejected or let through",
+	}, []string{"method", "action"})
+	ProxyStrippedParams = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "calls",
+		Name:      "stripped_params_count",
+		Help:      "Server-controlled params dropped from client calls, by method and param",
+	}, []string{"method", "param"})
+
+	ProxyPrefetchCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "cache",
+		Name:      "prefetch_count",
+		Help:      "Queries prefetched after calls to a method, by whether they warmed the cache, were already cached or in flight, skipped over budget or failed",
+	}, []string{"method", "result"})
+
+	ResolveFilterCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "resolve_filter",
+		Name:      "count",
+		Help:      "Resolves of missing URLs answered locally or sampled to the SDK, and existing claims the filter considered missing",
+	}, []string{"result"})
+	ResolveFilterEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsProxy,
+		Subsystem: "resolve_filter",
+		Name:      "entries",
+		Help:      "Claim names and IDs in the last built missing URL filter",
+	})
+
+	DBQueryDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "query_seconds",
+		Help:      "DB query durations by operation and table",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	}, []string{"operation", "table"})
+	DBSlowQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "slow_queries",
+		Help:      "DB queries over the slow query threshold",
+	}, []string{"operation", "table"})
+	DBTransactionDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "transaction_seconds",
+		Help:      "DB transaction durations from begin to commit or rollback",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"result"})
+	DBSlowTransactions = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "slow_transactions",
+		Help:      "DB transactions over the slow transaction threshold",
+	})
+
+	RetentionRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "retention",
+		Name:      "records",
+		Help:      "Records soft-deleted or purged by retention policies",
+	}, []string{"table", "action"})
+
+	DeltaUploadBlocks = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "delta_uploads",
+		Name:      "reused_blocks_count",
+		Help:      "Blocks of previous uploads reused by delta uploads instead of being sent again",
+	})
+	UploadTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "upload_tokens",
+		Name:      "count",
+		Help:      "Upload tokens issued, consumed by uploads and rejected as invalid, expired or reused",
+	}, []string{"result"})
+	CloudImports = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "cloud_imports",
+		Name:      "count",
+		Help:      "Imports from cloud storage started, published and failed",
+	}, []string{"provider", "result"})
+	UploadsRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "uploads",
+		Name:      "rejected_count",
+		Help:      "Uploads rejected for going over the file size cap or the user's daily quota, for disallowed file types and duplicates",
+	}, []string{"reason"})
+	UploadChunks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "uploads",
+		Name:      "chunk_count",
+		Help:      "Chunks of resumable uploads received in full or interrupted",
+	}, []string{"result"})
+	UploadHandovers = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "uploads",
+		Name:      "handover_count",
+		Help:      "Unfinished uploads handed over to object storage on shutdown, taken over from it and failed to hand over",
+	}, []string{"result"})
+	UploadChunkSizeSuggested = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "uploads",
+		Name:      "chunk_size_suggested_bytes",
+		Help:      "Chunk sizes suggested to resumable upload clients",
+		Buckets:   prometheus.ExponentialBuckets(256*1024, 2, 10),
+	})
+	UploadScans = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "uploads",
+		Name:      "scan_count",
+		Help:      "Uploaded files scanned for malware, by result",
+	}, []string{"result"})
+	RemoteSources = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "publish",
+		Name:      "remote_sources_count",
+		Help:      "Files to publish downloaded from source_url, rejected for their URL or size and failed to download",
+	}, []string{"result"})
+	AsyncPublishes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "async_publishes",
+		Name:      "count",
+		Help:      "Asynchronous publishes queued, rejected over a full queue, succeeded and failed",
+	}, []string{"result"})
+	OrphanedUploadsRemoved = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "publish",
+		Name:      "orphaned_uploads_removed_count",
+		Help:      "Uploaded files left behind by interrupted publishes and removed by the upload janitor",
+	})
+	OrphanedUploadsReclaimedBytes = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "publish",
+		Name:      "orphaned_uploads_reclaimed_bytes",
+		Help:      "Disk space reclaimed by the upload janitor",
+	})
+	Thumbnails = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "publish",
+		Name:      "thumbnails_count",
+		Help:      "Thumbnails made for uploaded videos published without one and failures to make them",
+	}, []string{"result"})
+	Torrents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "torrents",
+		Name:      "count",
+		Help:      "Published files seeded, skipped over quota, failed to seed and removed from seeding",
+	}, []string{"result"})
+	CDNPurges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "cdn_purge",
+		Name:      "count",
+		Help:      "CDN purges done, retried and given up on",
+	}, []string{"result"})
+	OutboxMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "outbox",
+		Name:      "messages_count",
+		Help:      "Outbox messages enqueued, delivered, retried after a failed delivery and given up on, per kind",
+	}, []string{"kind", "result"})
+	DeadLetters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "deadletter",
+		Name:      "letters_count",
+		Help:      "Outbox messages and jobs which failed for good and were kept for redelivery, per source and kind",
+	}, []string{"source", "kind"})
+	ModerationCases = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "moderation",
+		Name:      "cases_count",
+		Help:      "Moderation cases flagged, dismissed and taken down",
+	}, []string{"status"})
+	ModerationBlockedClaims = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "moderation",
+		Name:      "blocked_claims",
+		Help:      "Claims on the blocklist",
+	})
+	UserBlockFiltered = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "userblock",
+		Name:      "filtered_count",
+		Help:      "Claims removed from claim_search responses for being from channels blocked by the user",
+	})
+	VerifiedChannels = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "verification",
+		Name:      "verified_channels",
+		Help:      "Channels verified by admins",
+	})
+	TaxonomyTags = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "taxonomy",
+		Name:      "canonical_tags",
+		Help:      "Canonical tags in the taxonomy",
+	})
+	PublishTagsRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "taxonomy",
+		Name:      "rejected_publish_count",
+		Help:      "Publishes rejected for having banned tags",
+	})
+	LegalHolds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "legal_holds",
+		Name:      "count",
+		Help:      "Legal holds placed and released",
+	}, []string{"action"})
+	TransformRulesApplied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "rules",
+		Name:      "applied",
+		Help:      "Queries transformed by declarative rules",
+	}, []string{"method", "stage"})
+	MethodPolicyDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "policy",
+		Name:      "decisions",
+		Help:      "Calls matched by method policies, by action taken",
+	}, []string{"method", "action"})
+	UploadAnalysis = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "upload_analysis",
+		Name:      "count",
+		Help:      "Uploaded files analyzed with languages or tags suggested, nothing to suggest and analysis failures",
+	}, []string{"result"})
+
+	EventSubscriptions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "events",
+		Name:      "subscriptions",
+		Help:      "Open client subscriptions to SDK events",
+	})
+	EventSDKConnections = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "events",
+		Name:      "sdk_connections",
+		Help:      "Open connections to SDK event streams, one for each user with subscriptions",
+	})
+	EventCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "events",
+		Name:      "count",
+		Help:      "SDK events sent to subscribers, by topic and whether they were delivered or dropped for a slow client",
+	}, []string{"topic", "outcome"})
+	FraudDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "fraud",
+		Name:      "decisions_count",
+		Help:      "Publishes and purchases scored for fraud risk, by kind and whether they were allowed, held or rejected",
+	}, []string{"kind", "decision"})
+	FraudScoringDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "fraud",
+		Name:      "scoring_seconds",
+		Help:      "Time taken by the fraud scoring service to score an operation",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5},
+	})
+	ContentChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "content_availability",
+		Name:      "checks_count",
+		Help:      "Published streams checked for retrievable blobs, by outcome",
+	}, []string{"status"})
+	ContentRepairs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "content_availability",
+		Name:      "repairs_count",
+		Help:      "Unavailable streams published again from kept originals, by result",
+	}, []string{"result"})
+	EmbedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "embed",
+		Name:      "requests_count",
+		Help:      "Embed player requests, by endpoint and whether they were served from cache, fetched or rejected",
+	}, []string{"endpoint", "outcome"})
+	BalancePolls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "balance",
+		Name:      "polls_count",
+		Help:      "Wallet balance long polls, by whether they returned at once, on a balance change, on timeout or were rejected",
+	}, []string{"outcome"})
+	BalanceWaiting = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "balance",
+		Name:      "waiting",
+		Help:      "Wallet balance long polls waiting for a change",
+	})
+	ChannelCacheCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "channel_cache",
+		Name:      "count",
+		Help:      "Channel metadata cache hits, misses and invalidations",
+	}, []string{"result"})
+	Recommendations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "recommendations",
+		Name:      "count",
+		Help:      "Recommendations served from cache, computed from indexed claims, from the external service and its failures",
+	}, []string{"source"})
+	TrendingRefreshes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "trending",
+		Name:      "refresh_count",
+		Help:      "Trending list computations per category, succeeded and failed",
+	}, []string{"result"})
+	ChannelCacheRefreshed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "channel_cache",
+		Name:      "refreshed_count",
+		Help:      "Channels re-fetched by scheduled refreshes",
+	})
+
+	ResponseSpillCount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "responses",
+		Name:      "spill_count",
+		Help:      "Large responses spilled to disk or rejected because too many spills were in progress",
+	}, []string{"result"})
+	ResponseSpillsInProgress = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsProxy,
+		Subsystem: "responses",
+		Name:      "spills_in_progress",
+		Help:      "Responses currently being streamed from disk",
+	})
+
+	SentryReportsQueued = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "sentry",
+		Name:      "queued_count",
+		Help:      "Sentry reports queued for background sending",
+	}, func() float64 { return float64(monitor.GetAsyncStats().Queued) })
+	SentryReportsDropped = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "sentry",
+		Name:      "dropped_count",
+		Help:      "Sentry reports dropped because the queue was full",
+	}, func() float64 { return float64(monitor.GetAsyncStats().Dropped) })
+	SentryReportsProcessed = promauto.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "sentry",
+		Name:      "processed_count",
+		Help:      "Sentry reports sent by background workers",
+	}, func() float64 { return float64(monitor.GetAsyncStats().Processed) })
+	SentryQueueLength = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "sentry",
+		Name:      "queue_length",
+		Help:      "Sentry reports waiting in the queue",
+	}, func() float64 { return float64(monitor.GetAsyncStats().QueueLength) })
 
 	LbrynetWalletsLoaded = promauto.NewGaugeVec(prometheus.GaugeOpts{
 		Namespace: nsLbrynet,
@@ -169,6 +883,44 @@ var (
 		Name:      "count",
 		Help:      "Number of wallets currently loaded",
 	}, []string{LabelSource})
+	LbrynetStatusLatency = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrynet,
+		Subsystem: "pool",
+		Name:      "status_latency_seconds",
+		Help:      "Time taken by the last status call of health checks",
+	}, []string{LabelSource})
+	LbrynetAvailable = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrynet,
+		Subsystem: "pool",
+		Name:      "available",
+		Help:      "Whether the server gets new users, 0 when it's unhealthy or drained",
+	}, []string{LabelSource})
+	LbrynetVersions = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsLbrynet,
+		Subsystem: "servers",
+		Name:      "version_count",
+		Help:      "Number of responding lbrynet servers running each SDK version",
+	}, []string{"version"})
+	LbrynetVersionRouted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrynet,
+		Subsystem: "servers",
+		Name:      "version_routed_count",
+		Help:      "Calls to methods requiring a minimum SDK version, by whether a compatible server was found",
+	}, []string{"method", "result"})
+
+	PublishedClaims = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "published",
+		Name:      "claims_count",
+		Help:      "Claims published through lbrytv served before the Hub indexes them, by event",
+	}, []string{"event"})
+
+	WalletLoadsShared = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "wallets",
+		Name:      "loads_shared_count",
+		Help:      "Wallet loads skipped because the same wallet was being or had just been loaded by this instance or another one",
+	}, []string{"scope"})
 
 	UIBufferCount = promauto.NewCounter(prometheus.CounterOpts{
 		Namespace: nsUI,
@@ -220,6 +972,12 @@ var (
 		Name:      "count",
 		Help:      "Total number of stream requests received",
 	}, []string{LabelNameType})
+	LbrytvQuarantinedFiles = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "quarantine",
+		Name:      "count",
+		Help:      "Uploaded files moved to quarantine, by the check or moderation stage which flagged them",
+	}, []string{LabelSource})
 
 	LbrytvDBOpenConnections = promauto.NewGauge(prometheus.GaugeOpts{
 		Namespace: nsLbrytv,
@@ -240,6 +998,37 @@ var (
 		Help:      "Number of idle db connections in the Go connection pool",
 	})
 
+	LbrytvDBWaitCount = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "conns_waited",
+		Help:      "Total number of times a query waited for a connection from the Go connection pool",
+	})
+	SDKBreakerOpen = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: nsProxy,
+		Subsystem: "sdk",
+		Name:      "breaker_open",
+		Help:      "Whether calls to the SDK server are failing fast because it stopped responding",
+	}, []string{"endpoint"})
+	SDKBreakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: nsProxy,
+		Subsystem: "sdk",
+		Name:      "breaker_rejected",
+		Help:      "Calls rejected without reaching the SDK server because its breaker was open",
+	}, []string{"endpoint"})
+	DBBreakerOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "breaker_open",
+		Help:      "Whether DB calls are failing fast because the DB is unavailable",
+	})
+	DBBreakerRejected = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: nsLbrytv,
+		Subsystem: "db",
+		Name:      "breaker_rejected",
+		Help:      "DB calls rejected without reaching the DB because the breaker was open",
+	})
+
 	LbrynetXCallDurations = promauto.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: nsLbrynext,
@@ -302,3 +1091,25 @@ func GetCounterValue(col prometheus.Collector) float64 {
 	m := GetMetric(col)
 	return *m.Counter.Value
 }
+
+// GetCounterValuesByLabel returns values of counters in col summed up by the value of label.
+func GetCounterValuesByLabel(col *prometheus.CounterVec, label string) map[string]float64 {
+	c := make(chan prometheus.Metric)
+	go func() {
+		col.Collect(c)
+		close(c)
+	}()
+	values := map[string]float64{}
+	for metric := range c {
+		m := dto.Metric{}
+		if err := metric.Write(&m); err != nil || m.Counter == nil {
+			continue
+		}
+		for _, l := range m.Label {
+			if l.GetName() == label {
+				values[l.GetValue()] += m.Counter.GetValue()
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Sink receives metric values collected from the Prometheus registry, which remains the single place
+// metrics are defined in. Prometheus itself is a pull sink served by promhttp at /internal/metrics,
+// push sinks like StatsD are fed by RunSinks.
+type Sink interface {
+	Name() string
+	Send(samples []Sample) error
+}
+
+// Sample is a single metric value with its labels converted into tags.
+// Counters and histogram/summary counts and sums are reported as deltas since the previous collection.
+type Sample struct {
+	Name  string
+	Type  dto.MetricType
+	Value float64
+	Tags  []Tag
+}
+
+// Tag is a metric label, tag order is stable across collections.
+type Tag struct {
+	Name  string
+	Value string
+}
+
+// Collector converts Prometheus metric families into samples, keeping track of cumulative values
+// so deltas can be computed.
+type Collector struct {
+	gatherer prometheus.Gatherer
+	tags     []Tag
+	previous map[string]float64
+}
+
+// NewCollector creates a sample collector for the gatherer. Global tags are added to every sample.
+func NewCollector(g prometheus.Gatherer, globalTags map[string]string) *Collector {
+	c := &Collector{gatherer: g, previous: map[string]float64{}}
+	for k, v := range globalTags {
+		c.tags = append(c.tags, Tag{k, v})
+	}
+	sort.Slice(c.tags, func(i, j int) bool { return c.tags[i].Name < c.tags[j].Name })
+	return c
+}
+
+// Collect gathers current metric values.
+func (c *Collector) Collect() ([]Sample, error) {
+	mfs, err := c.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	samples := []Sample{}
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			tags := append([]Tag{}, c.tags...)
+			for _, lp := range m.Label {
+				tags = append(tags, Tag{lp.GetName(), lp.GetValue()})
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = c.appendDelta(samples, mf.GetName(), m.Counter.GetValue(), tags)
+			case dto.MetricType_GAUGE:
+				samples = append(samples, Sample{Name: mf.GetName(), Type: dto.MetricType_GAUGE, Value: m.Gauge.GetValue(), Tags: tags})
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, Sample{Name: mf.GetName(), Type: dto.MetricType_GAUGE, Value: m.Untyped.GetValue(), Tags: tags})
+			case dto.MetricType_HISTOGRAM:
+				samples = c.appendDelta(samples, mf.GetName()+"_count", float64(m.Histogram.GetSampleCount()), tags)
+				samples = c.appendDelta(samples, mf.GetName()+"_sum", m.Histogram.GetSampleSum(), tags)
+			case dto.MetricType_SUMMARY:
+				samples = c.appendDelta(samples, mf.GetName()+"_count", float64(m.Summary.GetSampleCount()), tags)
+				samples = c.appendDelta(samples, mf.GetName()+"_sum", m.Summary.GetSampleSum(), tags)
+			}
+		}
+	}
+	return samples, nil
+}
+
+func (c *Collector) appendDelta(samples []Sample, name string, value float64, tags []Tag) []Sample {
+	key := seriesKey(name, tags)
+	delta := value - c.previous[key]
+	c.previous[key] = value
+	// Counter reset, e.g. after a collector was re-registered
+	if delta < 0 {
+		delta = value
+	}
+	if delta == 0 {
+		return samples
+	}
+	return append(samples, Sample{Name: name, Type: dto.MetricType_COUNTER, Value: delta, Tags: tags})
+}
+
+func seriesKey(name string, tags []Tag) string {
+	parts := []string{name}
+	for _, t := range tags {
+		parts = append(parts, t.Name+"="+t.Value)
+	}
+	return strings.Join(parts, "|")
+}
+
+// RunSinks collects metrics from the default Prometheus registry every interval and sends them to sinks. It blocks.
+func RunSinks(interval time.Duration, globalTags map[string]string, sinks ...Sink) {
+	c := NewCollector(prometheus.DefaultGatherer, globalTags)
+	// Prime cumulative values so the first push doesn't report everything since startup as a single delta
+	c.Collect()
+
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		samples, err := c.Collect()
+		if err != nil {
+			Logger.Log().Errorf("error gathering metrics: %v", err)
+			continue
+		}
+		for _, s := range sinks {
+			if err := s.Send(samples); err != nil {
+				Logger.Log().Errorf("error sending metrics to %v: %v", s.Name(), err)
+			}
+		}
+	}
+}
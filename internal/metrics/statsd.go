@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+const (
+	// StatsDFormatDatadog sends labels as DogStatsD tags.
+	StatsDFormatDatadog = "datadog"
+	// StatsDFormatPlain appends label values to metric names as plain StatsD has no tags.
+	StatsDFormatPlain = "statsd"
+
+	// statsDMaxPacketSize keeps UDP packets under a typical MTU.
+	statsDMaxPacketSize = 1432
+)
+
+// StatsDSink pushes metrics to a StatsD or Datadog agent over UDP.
+type StatsDSink struct {
+	conn   net.Conn
+	prefix string
+	format string
+}
+
+// NewStatsDSink creates a sink sending metrics to the StatsD server at address.
+// Metric names are prefixed with prefix followed by a dot, if it's not empty.
+func NewStatsDSink(address, prefix, format string) (*StatsDSink, error) {
+	if format != StatsDFormatDatadog && format != StatsDFormatPlain {
+		return nil, fmt.Errorf("unknown statsd format: %v", format)
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return &StatsDSink{conn: conn, prefix: prefix, format: format}, nil
+}
+
+// Name returns sink name for logging.
+func (s *StatsDSink) Name() string {
+	return "statsd:" + s.conn.RemoteAddr().String()
+}
+
+// Send writes samples in as few packets as possible.
+func (s *StatsDSink) Send(samples []Sample) error {
+	buf := &bytes.Buffer{}
+	for _, sample := range samples {
+		line := s.formatLine(sample)
+		if buf.Len() > 0 && buf.Len()+len(line)+1 > statsDMaxPacketSize {
+			if _, err := s.conn.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+	if buf.Len() > 0 {
+		_, err := s.conn.Write(buf.Bytes())
+		return err
+	}
+	return nil
+}
+
+// Close closes the UDP connection.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+func (s *StatsDSink) formatLine(sample Sample) string {
+	kind := "g"
+	if sample.Type == dto.MetricType_COUNTER {
+		kind = "c"
+	}
+	value := strconv.FormatFloat(sample.Value, 'f', -1, 64)
+
+	name := s.prefix + sample.Name
+	if s.format == StatsDFormatPlain {
+		for _, t := range sample.Tags {
+			name += "." + strings.ReplaceAll(sanitizeStatsD(t.Value), ".", "_")
+		}
+		return fmt.Sprintf("%v:%v|%v", name, value, kind)
+	}
+
+	tags := make([]string, len(sample.Tags))
+	for i, t := range sample.Tags {
+		tags[i] = sanitizeStatsD(t.Name) + ":" + sanitizeStatsD(t.Value)
+	}
+	line := fmt.Sprintf("%v:%v|%v", name, value, kind)
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+var statsDReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", ",", "_", "#", "_", "\n", "_", " ", "_")
+
+func sanitizeStatsD(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return statsDReplacer.Replace(s)
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollector_Deltas(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_count"}, []string{"method"})
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge"})
+	reg.MustRegister(counter, gauge)
+
+	c := NewCollector(reg, map[string]string{"host": "h1"})
+	counter.WithLabelValues("resolve").Add(3)
+	gauge.Set(7)
+
+	samples, err := c.Collect()
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "test_count", samples[0].Name)
+	assert.Equal(t, 3.0, samples[0].Value)
+	assert.Equal(t, []Tag{{"host", "h1"}, {"method", "resolve"}}, samples[0].Tags)
+	assert.Equal(t, 7.0, samples[1].Value)
+
+	counter.WithLabelValues("resolve").Add(2)
+	samples, err = c.Collect()
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, 2.0, samples[0].Value)
+
+	samples, err = c.Collect()
+	require.NoError(t, err)
+	require.Len(t, samples, 1, "unchanged counters should be skipped")
+}
+
+func TestStatsDSink_Send(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	samples := []Sample{
+		{Name: "calls", Type: dto.MetricType_COUNTER, Value: 2, Tags: []Tag{{"method", "resolve"}, {"endpoint", "http://sdk:5279"}}},
+		{Name: "load", Type: dto.MetricType_GAUGE, Value: 0.5},
+	}
+
+	cases := map[string]string{
+		StatsDFormatDatadog: "lbrytv.calls:2|c|#method:resolve,endpoint:http_//sdk_5279\nlbrytv.load:0.5|g",
+		StatsDFormatPlain:   "lbrytv.calls.resolve.http_//sdk_5279:2|c\nlbrytv.load:0.5|g",
+	}
+	for format, expected := range cases {
+		t.Run(format, func(t *testing.T) {
+			s, err := NewStatsDSink(pc.LocalAddr().String(), "lbrytv", format)
+			require.NoError(t, err)
+			defer s.Close()
+			require.NoError(t, s.Send(samples))
+
+			buf := make([]byte, statsDMaxPacketSize)
+			pc.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := pc.ReadFrom(buf)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(buf[:n]))
+		})
+	}
+}
+
+func TestStatsDSink_Batching(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer pc.Close()
+
+	s, err := NewStatsDSink(pc.LocalAddr().String(), "", StatsDFormatDatadog)
+	require.NoError(t, err)
+	defer s.Close()
+
+	samples := []Sample{}
+	for i := 0; i < 200; i++ {
+		samples = append(samples, Sample{Name: strings.Repeat("m", 20), Type: dto.MetricType_GAUGE, Value: 1})
+	}
+	require.NoError(t, s.Send(samples))
+
+	lines := 0
+	buf := make([]byte, 65535)
+	for lines < 200 {
+		pc.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, n, statsDMaxPacketSize)
+		lines += len(strings.Split(string(buf[:n]), "\n"))
+	}
+	assert.Equal(t, 200, lines)
+}
+
+func TestNewStatsDSink_UnknownFormat(t *testing.T) {
+	_, err := NewStatsDSink("127.0.0.1:8125", "", "graphite")
+	assert.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AsyncStats contains counters of the background reporting worker pool.
+type AsyncStats struct {
+	Queued      uint64
+	Dropped     uint64
+	Processed   uint64
+	QueueLength int
+}
+
+type workerPool struct {
+	queue chan func()
+	wg    sync.WaitGroup
+}
+
+var (
+	poolMu sync.RWMutex
+	pool   *workerPool
+
+	asyncQueued    uint64
+	asyncDropped   uint64
+	asyncProcessed uint64
+)
+
+// StartSentryWorkers moves Sentry reporting off the calling goroutine into a pool of workers
+// with a bounded queue. Reports which don't fit into the queue are dropped.
+// Until it's called, reports are sent synchronously.
+func StartSentryWorkers(workers, queueSize int) {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if pool != nil {
+		return
+	}
+	p := &workerPool{queue: make(chan func(), queueSize)}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for task := range p.queue {
+				task()
+				atomic.AddUint64(&asyncProcessed, 1)
+			}
+		}()
+	}
+	pool = p
+	logger.Log().Infof("started %v sentry workers with queue size %v", workers, queueSize)
+}
+
+// StopSentryWorkers waits up to timeout for queued reports to be processed and stops the workers.
+// Subsequent reports are sent synchronously.
+func StopSentryWorkers(timeout time.Duration) {
+	poolMu.Lock()
+	p := pool
+	pool = nil
+	poolMu.Unlock()
+	if p == nil {
+		return
+	}
+
+	close(p.queue)
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logger.Log().Warnf("sentry workers did not finish in %v, %v reports lost", timeout, len(p.queue))
+	}
+}
+
+// runAsync queues the task for a worker, runs it synchronously if workers are not started,
+// or drops it when the queue is full.
+func runAsync(task func()) {
+	poolMu.RLock()
+	defer poolMu.RUnlock()
+	if pool == nil {
+		task()
+		return
+	}
+	select {
+	case pool.queue <- task:
+		atomic.AddUint64(&asyncQueued, 1)
+	default:
+		atomic.AddUint64(&asyncDropped, 1)
+		logger.Log().Warn("sentry report queue is full, dropping report")
+	}
+}
+
+// GetAsyncStats returns worker pool counters.
+func GetAsyncStats() AsyncStats {
+	s := AsyncStats{
+		Queued:    atomic.LoadUint64(&asyncQueued),
+		Dropped:   atomic.LoadUint64(&asyncDropped),
+		Processed: atomic.LoadUint64(&asyncProcessed),
+	}
+	poolMu.RLock()
+	if pool != nil {
+		s.QueueLength = len(pool.queue)
+	}
+	poolMu.RUnlock()
+	return s
+}
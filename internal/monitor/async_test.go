@@ -0,0 +1,48 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunAsync_NoWorkers(t *testing.T) {
+	var ran bool
+	runAsync(func() { ran = true })
+	assert.True(t, ran)
+}
+
+func TestRunAsync_DropsOnOverflow(t *testing.T) {
+	StartSentryWorkers(0, 1)
+	defer StopSentryWorkers(0)
+
+	before := GetAsyncStats()
+	runAsync(func() {})
+	runAsync(func() {})
+	runAsync(func() {})
+
+	stats := GetAsyncStats()
+	assert.EqualValues(t, 1, stats.Queued-before.Queued)
+	assert.EqualValues(t, 2, stats.Dropped-before.Dropped)
+	assert.Equal(t, 1, stats.QueueLength)
+}
+
+func TestStopSentryWorkers_DrainsQueue(t *testing.T) {
+	StartSentryWorkers(2, 100)
+
+	before := GetAsyncStats()
+	done := make(chan struct{}, 50)
+	for i := 0; i < 50; i++ {
+		runAsync(func() { done <- struct{}{} })
+	}
+	StopSentryWorkers(5 * time.Second)
+
+	assert.Len(t, done, 50)
+	assert.EqualValues(t, 50, GetAsyncStats().Processed-before.Processed)
+	assert.Equal(t, 0, GetAsyncStats().QueueLength)
+
+	var ran bool
+	runAsync(func() { ran = true })
+	assert.True(t, ran)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/lbryio/lbrytv/internal/errors"
 	"github.com/lbryio/lbrytv/internal/responses"
@@ -76,6 +77,12 @@ func ErrorLoggingMiddleware(next http.Handler) http.Handler {
 		hub.Scope().SetRequest(r)
 		ctx := sentry.SetHubOnContext(r.Context(), hub)
 
+		// Upgraded connections are taken over by the handler, so there is no response to record
+		if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
 		// Record response from next handler, recovering any panics therein
 		recorder := &responseRecorder{}
 		recoveredErr := func() (err error) {
@@ -18,6 +18,7 @@ type ModuleLogger struct {
 func NewModuleLogger(moduleName string) ModuleLogger {
 	l := logrus.New()
 	configureLogLevelAndFormat(l)
+	registerLogger(l)
 	fields := logrus.Fields{
 		"module": moduleName,
 	}
@@ -51,6 +52,7 @@ func (m ModuleLogger) Log() *logrus.Entry {
 
 // Disable turns off logging output for this module logger
 func (m ModuleLogger) Disable() {
+	markLoggerDisabled(m.Entry.Logger)
 	m.Entry.Logger.SetLevel(logrus.PanicLevel)
 	m.Entry.Logger.SetOutput(ioutil.Discard)
 }
@@ -47,10 +47,10 @@ func LogMode() string {
 func configureLogLevelAndFormat(l *logrus.Logger) {
 	if isProduction() {
 		l.SetLevel(logrus.InfoLevel)
-		l.SetFormatter(&jsonFormatter)
+		l.SetFormatter(samplingFormatter{Formatter: redactingFormatter{&jsonFormatter, logRedactor}, sampler: logSampler})
 	} else {
 		l.SetLevel(logrus.TraceLevel)
-		l.SetFormatter(&textFormatter)
+		l.SetFormatter(samplingFormatter{Formatter: redactingFormatter{&textFormatter, logRedactor}, sampler: logSampler})
 	}
 }
 
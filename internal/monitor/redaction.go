@@ -0,0 +1,255 @@
+package monitor
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+// secretGroup is the name of a capture group in redaction patterns which limits masking to that group,
+// so a pattern can match `password=...` but only hide the value.
+const secretGroup = "secret"
+
+// RedactionRules define what is stripped from logs and Sentry reports.
+// Fields are matched against field names (case-insensitive) at any depth of a logged value,
+// Patterns are regular expressions applied to log messages and string values.
+type RedactionRules struct {
+	Fields   []string `json:"fields"`
+	Patterns []string `json:"patterns"`
+}
+
+// DefaultRedactionRules are always applied, rules from config are added on top of them.
+var DefaultRedactionRules = RedactionRules{
+	Fields: []string{
+		"password", "new_password", "wallet_password", "token", "auth_token", "x-lbry-auth-token",
+		"private_key", "seed", "data", "sync_data", "api_key", "x-lbrytv-api-key", "cookie", "authorization",
+	},
+	Patterns: []string{
+		`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`,
+		`(?i)"?(?:password|new_password|wallet_password|auth_token|token|private_key|seed)"?\s*[:=]\s*"?(?P<secret>[^"\s,&}]+)`,
+		`(?i)x-lbry-auth-token:?\s+(?P<secret>\S+)`,
+		`lbrytv_org_[0-9a-f]+`,
+	},
+}
+
+type redactor struct {
+	mu       sync.RWMutex
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+}
+
+var logRedactor = newRedactor()
+
+func newRedactor() *redactor {
+	r := &redactor{}
+	if err := r.configure(RedactionRules{}); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *redactor) configure(rules RedactionRules) error {
+	fields := map[string]bool{}
+	patterns := []*regexp.Regexp{}
+	for _, set := range []RedactionRules{DefaultRedactionRules, rules} {
+		for _, f := range set.Fields {
+			fields[strings.ToLower(f)] = true
+		}
+		for _, p := range set.Patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return errors.Prefix("invalid redaction pattern", err)
+			}
+			patterns = append(patterns, re)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fields = fields
+	r.patterns = patterns
+	return nil
+}
+
+func (r *redactor) isSensitive(field string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.fields[strings.ToLower(field)]
+}
+
+// String masks all pattern matches in s.
+func (r *redactor) String(s string) string {
+	r.mu.RLock()
+	patterns := r.patterns
+	r.mu.RUnlock()
+	for _, re := range patterns {
+		s = maskMatches(re, s)
+	}
+	return s
+}
+
+func maskMatches(re *regexp.Regexp, s string) string {
+	group := 0
+	for i, name := range re.SubexpNames() {
+		if name == secretGroup {
+			group = i
+		}
+	}
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if group > 0 {
+			start, end = m[2*group], m[2*group+1]
+			if start < 0 {
+				continue
+			}
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(valueMask)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// Value returns a copy of v with sensitive fields masked. Values other than strings and numbers
+// are converted to their generic JSON representation first so nested fields can be inspected.
+func (r *redactor) Value(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case nil, bool, int, int32, int64, uint, uint32, uint64, float32, float64, time.Duration, time.Time:
+		return v
+	case string:
+		return r.String(typed)
+	case error:
+		return r.String(typed.Error())
+	case map[string]interface{}, []interface{}:
+		return r.generic(typed)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var copied interface{}
+	if err := json.Unmarshal(b, &copied); err != nil {
+		return v
+	}
+	return r.generic(copied)
+}
+
+func (r *redactor) generic(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for k, val := range typed {
+			if r.isSensitive(k) {
+				copied[k] = valueMask
+			} else {
+				copied[k] = r.Value(val)
+			}
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, val := range typed {
+			copied[i] = r.Value(val)
+		}
+		return copied
+	}
+	return r.Value(v)
+}
+
+// Entry returns a copy of e with sensitive fields and message contents masked.
+func (r *redactor) Entry(e *logrus.Entry) *logrus.Entry {
+	redacted := *e
+	redacted.Message = r.String(e.Message)
+	redacted.Data = make(logrus.Fields, len(e.Data))
+	for k, v := range e.Data {
+		if r.isSensitive(k) {
+			redacted.Data[k] = valueMask
+		} else {
+			redacted.Data[k] = r.Value(v)
+		}
+	}
+	return &redacted
+}
+
+// Event masks sensitive contents of a Sentry event in place.
+func (r *redactor) Event(event *sentry.Event) *sentry.Event {
+	event.Message = r.String(event.Message)
+	for i := range event.Exception {
+		event.Exception[i].Value = r.String(event.Exception[i].Value)
+	}
+	for k, v := range event.Extra {
+		if r.isSensitive(k) {
+			event.Extra[k] = valueMask
+		} else {
+			event.Extra[k] = r.Value(v)
+		}
+	}
+	for k, v := range event.Tags {
+		event.Tags[k] = r.String(v)
+	}
+	for _, b := range event.Breadcrumbs {
+		b.Message = r.String(b.Message)
+		for k, v := range b.Data {
+			if r.isSensitive(k) {
+				b.Data[k] = valueMask
+			} else {
+				b.Data[k] = r.Value(v)
+			}
+		}
+	}
+	if req := event.Request; req != nil {
+		req.URL = r.String(req.URL)
+		req.QueryString = r.String(req.QueryString)
+		req.Data = r.String(req.Data)
+		if req.Cookies != "" {
+			req.Cookies = valueMask
+		}
+		for k, v := range req.Headers {
+			if r.isSensitive(k) {
+				req.Headers[k] = valueMask
+			} else {
+				req.Headers[k] = r.String(v)
+			}
+		}
+	}
+	return event
+}
+
+// redactingFormatter masks secrets in log entries before they are written.
+type redactingFormatter struct {
+	logrus.Formatter
+	redactor *redactor
+}
+
+func (f redactingFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	return f.Formatter.Format(f.redactor.Entry(e))
+}
+
+// SetRedaction replaces custom redaction rules for all logs and Sentry reports.
+// Default rules stay in effect.
+func SetRedaction(rules RedactionRules) error {
+	if err := logRedactor.configure(rules); err != nil {
+		return err
+	}
+	logger.Log().Infof("log redaction set to %v custom fields and %v custom patterns", len(rules.Fields), len(rules.Patterns))
+	return nil
+}
+
+// Redact returns a copy of v with sensitive contents masked according to current redaction rules,
+// for places where data leaves the process other than through logs.
+func Redact(v interface{}) interface{} {
+	return logRedactor.Value(v)
+}
@@ -0,0 +1,160 @@
+package monitor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SuppressedF is a log field name carrying the number of similar log lines dropped by the sampler.
+const SuppressedF = "suppressed"
+
+// maxPendingKeys caps the number of distinct log lines awaiting a suppression summary.
+const maxPendingKeys = 10000
+
+// SamplingConfig controls log sampling. Within each Period the first Initial identical log lines
+// (same module, level and message) are logged, after that only every Thereafter-th one is.
+// Sampling is disabled when Initial is zero.
+type SamplingConfig struct {
+	Period     time.Duration `json:"period"`
+	Initial    int           `json:"initial"`
+	Thereafter int           `json:"thereafter"`
+}
+
+type sampler struct {
+	mu          sync.Mutex
+	cfg         SamplingConfig
+	periodStart time.Time
+	counts      map[string]int
+	dropped     map[string]int
+	pending     map[string]int
+}
+
+var (
+	logSampler = newSampler()
+
+	loggersMu sync.Mutex
+	loggers   = map[*logrus.Logger]bool{}
+)
+
+func newSampler() *sampler {
+	return &sampler{counts: map[string]int{}, dropped: map[string]int{}, pending: map[string]int{}}
+}
+
+func (s *sampler) config() SamplingConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg
+}
+
+func (s *sampler) configure(cfg SamplingConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg.Period <= 0 {
+		cfg.Period = time.Second
+	}
+	s.cfg = cfg
+	s.periodStart = time.Time{}
+}
+
+// allow returns true if a log line identified by key should be written,
+// along with the number of similar lines suppressed since the last one written.
+func (s *sampler) allow(key string, now time.Time) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.Initial <= 0 {
+		return true, 0
+	}
+
+	if now.Sub(s.periodStart) >= s.cfg.Period {
+		if len(s.pending) > maxPendingKeys {
+			s.pending = map[string]int{}
+		}
+		for k, n := range s.dropped {
+			s.pending[k] += n
+		}
+		s.periodStart = now
+		s.counts = map[string]int{}
+		s.dropped = map[string]int{}
+	}
+
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	if n > s.cfg.Initial && (s.cfg.Thereafter <= 0 || (n-s.cfg.Initial)%s.cfg.Thereafter != 0) {
+		s.dropped[key]++
+		return false, 0
+	}
+	suppressed := s.pending[key] + s.dropped[key]
+	delete(s.pending, key)
+	delete(s.dropped, key)
+	return true, suppressed
+}
+
+// samplingFormatter drops log lines rejected by the sampler. Returning nothing from Format
+// is the only way to skip writing an entry in logrus as hooks cannot cancel it.
+type samplingFormatter struct {
+	logrus.Formatter
+	sampler *sampler
+}
+
+func (f samplingFormatter) Format(e *logrus.Entry) ([]byte, error) {
+	if e.Level <= logrus.FatalLevel {
+		return f.Formatter.Format(e)
+	}
+	ok, suppressed := f.sampler.allow(fmt.Sprintf("%v|%v|%v", e.Data["module"], e.Level, e.Message), e.Time)
+	if !ok {
+		return nil, nil
+	}
+	if suppressed > 0 {
+		annotated := *e
+		annotated.Data = logrus.Fields{SuppressedF: suppressed}
+		for k, v := range e.Data {
+			annotated.Data[k] = v
+		}
+		annotated.Message = fmt.Sprintf("%v (suppressed %v similar)", e.Message, suppressed)
+		return f.Formatter.Format(&annotated)
+	}
+	return f.Formatter.Format(e)
+}
+
+// SetSampling changes log sampling settings for all loggers at runtime.
+func SetSampling(cfg SamplingConfig) {
+	logSampler.configure(cfg)
+	logger.Log().Infof("log sampling set to %+v", logSampler.config())
+}
+
+// GetSampling returns current log sampling settings.
+func GetSampling() SamplingConfig {
+	return logSampler.config()
+}
+
+// SetLevel changes verbosity of the standard logger and all module loggers that haven't been disabled.
+func SetLevel(level logrus.Level) {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	logrus.SetLevel(level)
+	for l, disabled := range loggers {
+		if !disabled {
+			l.SetLevel(level)
+		}
+	}
+}
+
+// GetLevel returns the standard logger verbosity.
+func GetLevel() logrus.Level {
+	return logrus.GetLevel()
+}
+
+func registerLogger(l *logrus.Logger) {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	loggers[l] = false
+}
+
+func markLoggerDisabled(l *logrus.Logger) {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+	loggers[l] = true
+}
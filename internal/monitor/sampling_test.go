@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSampler_Allow(t *testing.T) {
+	s := newSampler()
+	s.configure(SamplingConfig{Period: time.Minute, Initial: 2, Thereafter: 3})
+	now := time.Now()
+
+	allowed := []bool{}
+	for i := 0; i < 8; i++ {
+		ok, _ := s.allow("a", now)
+		allowed = append(allowed, ok)
+	}
+	assert.Equal(t, []bool{true, true, false, false, true, false, false, true}, allowed)
+
+	ok, _ := s.allow("b", now)
+	assert.True(t, ok)
+
+	ok, suppressed := s.allow("a", now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 0, suppressed)
+}
+
+func TestSampler_SuppressedSummary(t *testing.T) {
+	s := newSampler()
+	s.configure(SamplingConfig{Period: time.Minute, Initial: 1})
+	now := time.Now()
+
+	for i := 0; i < 5; i++ {
+		s.allow("a", now)
+	}
+	ok, suppressed := s.allow("a", now.Add(time.Minute))
+	assert.True(t, ok)
+	assert.Equal(t, 4, suppressed)
+}
+
+func TestSampler_Disabled(t *testing.T) {
+	s := newSampler()
+	for i := 0; i < 1000; i++ {
+		ok, _ := s.allow("a", time.Now())
+		require.True(t, ok)
+	}
+}
+
+func TestSamplingFormatter(t *testing.T) {
+	s := newSampler()
+	s.configure(SamplingConfig{Period: time.Hour, Initial: 1, Thereafter: 3})
+	buf := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(buf)
+	l.SetFormatter(samplingFormatter{Formatter: &jsonFormatter, sampler: s})
+
+	for i := 0; i < 4; i++ {
+		l.WithField("module", "test").Info("same thing happened")
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.NotContains(t, lines[0], SuppressedF)
+	assert.Contains(t, lines[1], "same thing happened (suppressed 2 similar)")
+	assert.Contains(t, lines[1], `"suppressed":2`)
+}
+
+func TestSetLevel(t *testing.T) {
+	defer SetLevel(logrus.InfoLevel)
+
+	enabled := NewModuleLogger("enabled")
+	disabled := NewModuleLogger("disabled")
+	disabled.Disable()
+
+	SetLevel(logrus.DebugLevel)
+	assert.Equal(t, logrus.DebugLevel, enabled.Entry.Logger.Level)
+	assert.Equal(t, logrus.PanicLevel, disabled.Entry.Logger.Level)
+	assert.Equal(t, logrus.DebugLevel, GetLevel())
+}
@@ -1,6 +1,10 @@
 package monitor
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"reflect"
+
 	"github.com/lbryio/lbrytv/internal/responses"
 
 	"github.com/getsentry/sentry-go"
@@ -29,7 +33,7 @@ func ConfigureSentry(dsn, release, env string) {
 					}
 				}
 			}
-			return event
+			return logRedactor.Event(event)
 		},
 	})
 	if err != nil {
@@ -40,34 +44,58 @@ func ConfigureSentry(dsn, release, env string) {
 }
 
 // ErrorToSentry sends to Sentry general exception info with some optional extra detail (like user email, claim url etc)
+// The event is built on the calling goroutine so the stack trace points to the caller, sending happens
+// in the background when Sentry workers are started.
 func ErrorToSentry(err error, params ...map[string]string) *sentry.EventID {
+	if err == nil {
+		return nil
+	}
 	var extra map[string]string
-	var eventID *sentry.EventID
 	if len(params) > 0 {
 		extra = params[0]
 	} else {
 		extra = map[string]string{}
 	}
 
-	sentry.WithScope(func(scope *sentry.Scope) {
-		for k, v := range extra {
-			scope.SetExtra(k, v)
-		}
-		sentry.CaptureException(err)
-	})
-	return eventID
+	event := sentry.NewEvent()
+	event.EventID = newEventID()
+	event.Level = sentry.LevelError
+	stacktrace := sentry.ExtractStacktrace(err)
+	if stacktrace == nil {
+		stacktrace = sentry.NewStacktrace()
+	}
+	event.Exception = []sentry.Exception{{
+		Value:      err.Error(),
+		Type:       reflect.TypeOf(err).String(),
+		Stacktrace: stacktrace,
+	}}
+	captureAsync(event, extra)
+	return &event.EventID
 }
 
 func MessageToSentry(msg string, level sentry.Level, params map[string]string) *sentry.EventID {
-	var eventID *sentry.EventID
-	sentry.WithScope(func(scope *sentry.Scope) {
-		for k, v := range params {
-			scope.SetExtra(k, v)
-		}
-		event := sentry.NewEvent()
-		event.Level = level
-		event.Message = msg
-		eventID = sentry.CaptureEvent(event)
+	event := sentry.NewEvent()
+	event.EventID = newEventID()
+	event.Level = level
+	event.Message = msg
+	captureAsync(event, params)
+	return &event.EventID
+}
+
+func captureAsync(event *sentry.Event, extra map[string]string) {
+	runAsync(func() {
+		sentry.WithScope(func(scope *sentry.Scope) {
+			for k, v := range extra {
+				scope.SetExtra(k, v)
+			}
+			sentry.CaptureEvent(event)
+		})
 	})
-	return eventID
+}
+
+// newEventID generates an event ID upfront so it can be returned before the event is actually sent.
+func newEventID() sentry.EventID {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return sentry.EventID(hex.EncodeToString(b))
 }
@@ -0,0 +1,94 @@
+// Package profiling continuously collects pprof profiles and pushes them to a profiling backend,
+// so allocation and CPU hotspots can be looked at for any period after the fact.
+package profiling
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	ProfileCPU  = "cpu"
+	ProfileHeap = "heap"
+)
+
+var logger = monitor.NewModuleLogger("profiling")
+
+// Profile is a single pprof-encoded profile covering the period between Start and End.
+type Profile struct {
+	Type  string
+	Data  []byte
+	Start time.Time
+	End   time.Time
+}
+
+// Uploader sends collected profiles to a profiling backend.
+type Uploader interface {
+	Upload(p Profile) error
+}
+
+// Profiler collects CPU and heap profiles every interval and passes them to the uploader.
+type Profiler struct {
+	interval time.Duration
+	uploader Uploader
+	stop     chan struct{}
+}
+
+// NewProfiler creates a profiler. CPU profiles are collected continuously in interval-long chunks.
+func NewProfiler(interval time.Duration, uploader Uploader) *Profiler {
+	return &Profiler{interval: interval, uploader: uploader, stop: make(chan struct{})}
+}
+
+// Start collects and uploads profiles until Stop is called. It blocks.
+func (p *Profiler) Start() {
+	logger.Log().Infof("continuous profiling started with %v interval", p.interval)
+	for {
+		select {
+		case <-p.stop:
+			return
+		default:
+		}
+		for _, prof := range p.collect() {
+			if err := p.uploader.Upload(prof); err != nil {
+				logger.Log().Errorf("error uploading %v profile: %v", prof.Type, err)
+			}
+		}
+	}
+}
+
+// Stop stops profiling after the current cycle completes.
+func (p *Profiler) Stop() {
+	close(p.stop)
+}
+
+// collect runs a single profiling cycle, taking at least the profiler interval.
+func (p *Profiler) collect() []Profile {
+	profiles := []Profile{}
+	start := time.Now()
+
+	cpu := &bytes.Buffer{}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		// Most likely someone is using /debug/pprof, skip CPU profile for this cycle
+		logger.Log().Warnf("cannot start cpu profile: %v", err)
+		cpu = nil
+	}
+	select {
+	case <-time.After(p.interval):
+	case <-p.stop:
+	}
+	if cpu != nil {
+		pprof.StopCPUProfile()
+		profiles = append(profiles, Profile{Type: ProfileCPU, Data: cpu.Bytes(), Start: start, End: time.Now()})
+	}
+
+	heap := &bytes.Buffer{}
+	if err := pprof.Lookup(ProfileHeap).WriteTo(heap, 0); err != nil {
+		logger.Log().Errorf("cannot write heap profile: %v", err)
+	} else {
+		profiles = append(profiles, Profile{Type: ProfileHeap, Data: heap.Bytes(), Start: start, End: time.Now()})
+	}
+	return profiles
+}
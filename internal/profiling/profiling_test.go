@@ -0,0 +1,72 @@
+package profiling
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memUploader struct {
+	mu       sync.Mutex
+	profiles []Profile
+}
+
+func (u *memUploader) Upload(p Profile) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.profiles = append(u.profiles, p)
+	return nil
+}
+
+func TestProfiler_Collect(t *testing.T) {
+	p := NewProfiler(50*time.Millisecond, &memUploader{})
+	profiles := p.collect()
+	require.Len(t, profiles, 2)
+	assert.Equal(t, ProfileCPU, profiles[0].Type)
+	assert.Equal(t, ProfileHeap, profiles[1].Type)
+	for _, prof := range profiles {
+		assert.NotEmpty(t, prof.Data)
+		assert.False(t, prof.End.Before(prof.Start))
+	}
+}
+
+func TestPyroscopeUploader_Upload(t *testing.T) {
+	var received *http.Request
+	var data []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		f, _, err := r.FormFile("profile")
+		require.NoError(t, err)
+		data, _ = ioutil.ReadAll(f)
+	}))
+	defer ts.Close()
+
+	u := NewPyroscopeUploader(ts.URL+"/", "lbrytv", "t0ken", map[string]string{"version": "v1", "instance": "api1"})
+	start := time.Unix(1600000000, 0)
+	err := u.Upload(Profile{Type: ProfileHeap, Data: []byte("pprof"), Start: start, End: start.Add(10 * time.Second)})
+	require.NoError(t, err)
+
+	require.NotNil(t, received)
+	assert.Equal(t, "/ingest", received.URL.Path)
+	assert.Equal(t, "lbrytv.heap{instance=api1,version=v1}", received.URL.Query().Get("name"))
+	assert.Equal(t, "1600000000", received.URL.Query().Get("from"))
+	assert.Equal(t, "1600000010", received.URL.Query().Get("until"))
+	assert.Equal(t, "Bearer t0ken", received.Header.Get("Authorization"))
+	assert.Equal(t, []byte("pprof"), data)
+}
+
+func TestPyroscopeUploader_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	err := NewPyroscopeUploader(ts.URL, "lbrytv", "", nil).Upload(Profile{Type: ProfileCPU})
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package profiling
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PyroscopeUploader pushes profiles to Pyroscope ingestion API.
+type PyroscopeUploader struct {
+	serverURL string
+	appName   string
+	authToken string
+	tags      map[string]string
+	client    *http.Client
+}
+
+// NewPyroscopeUploader creates an uploader for Pyroscope server at serverURL.
+// Tags (like version and instance) are attached to every profile.
+func NewPyroscopeUploader(serverURL, appName, authToken string, tags map[string]string) *PyroscopeUploader {
+	return &PyroscopeUploader{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		appName:   appName,
+		authToken: authToken,
+		tags:      tags,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Upload sends the profile as a multipart form, as expected by Pyroscope for pprof format.
+func (u *PyroscopeUploader) Upload(p Profile) error {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	fw, err := w.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := fw.Write(p.Data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("name", u.appName+"."+p.Type+u.formatTags())
+	q.Set("from", fmt.Sprintf("%d", p.Start.Unix()))
+	q.Set("until", fmt.Sprintf("%d", p.End.Unix()))
+	q.Set("format", "pprof")
+	q.Set("spyName", "gospy")
+
+	req, err := http.NewRequest(http.MethodPost, u.serverURL+"/ingest?"+q.Encode(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if u.authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+u.authToken)
+	}
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("pyroscope responded with %v: %s", res.StatusCode, msg)
+	}
+	return nil
+}
+
+// formatTags renders tags in Pyroscope application name format: {key=value,...}
+func (u *PyroscopeUploader) formatTags() string {
+	if len(u.tags) == 0 {
+		return ""
+	}
+	pairs := []string{}
+	for k, v := range u.tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
@@ -0,0 +1,91 @@
+// Package ratelimit reports where clients stand against limits of the routes they call, in the standard
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers. Handlers of limited routes
+// Report the status once they've counted the request, Middleware sets the headers on the response.
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	LimitHeader     = "X-RateLimit-Limit"
+	RemainingHeader = "X-RateLimit-Remaining"
+	// ResetHeader carries the Unix time the limit renews at.
+	ResetHeader = "X-RateLimit-Reset"
+)
+
+var headers = []string{LimitHeader, RemainingHeader, ResetHeader}
+
+type ctxKey int
+
+const contextKey ctxKey = iota
+
+// Status is where a client stands against a limit. Reset is the Unix time it renews at.
+type Status struct {
+	Limit     int64
+	Remaining int64
+	Reset     int64
+}
+
+// report holds the status reported by the handler until the response gets written.
+type report struct {
+	status *Status
+}
+
+// Report sets the status of the client making r, to be sent in response headers. It does nothing
+// for requests which didn't go through Middleware.
+func Report(r *http.Request, s Status) {
+	if rep, ok := r.Context().Value(contextKey).(*report); ok {
+		if s.Remaining < 0 {
+			s.Remaining = 0
+		}
+		rep.status = &s
+	}
+}
+
+// Middleware sets rate limit headers on responses to requests the handler reported a status for.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rep := &report{}
+		rw := &writer{ResponseWriter: w, report: rep}
+		next.ServeHTTP(rw, r.Clone(context.WithValue(r.Context(), contextKey, rep)))
+		// Responses without a body haven't been written yet
+		rw.setHeaders()
+	})
+}
+
+// writer sets the headers right before the response is written, after the handler has reported the status.
+type writer struct {
+	http.ResponseWriter
+	report  *report
+	written bool
+}
+
+func (w *writer) setHeaders() {
+	if w.written {
+		return
+	}
+	w.written = true
+	s := w.report.status
+	if s == nil {
+		return
+	}
+	h := w.Header()
+	h.Set(LimitHeader, strconv.FormatInt(s.Limit, 10))
+	h.Set(RemainingHeader, strconv.FormatInt(s.Remaining, 10))
+	h.Set(ResetHeader, strconv.FormatInt(s.Reset, 10))
+	h.Add("Access-Control-Expose-Headers", strings.Join(headers, ", "))
+}
+
+func (w *writer) WriteHeader(code int) {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *writer) Write(b []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(b)
+}
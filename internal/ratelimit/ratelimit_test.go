@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Report(r, Status{Limit: 100, Remaining: 40, Reset: 1600000000})
+		w.WriteHeader(http.StatusCreated)
+		// Reported after the response is written, too late to be sent
+		Report(r, Status{Limit: 100, Remaining: 10, Reset: 1600000000})
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "100", rr.Header().Get(LimitHeader))
+	assert.Equal(t, "40", rr.Header().Get(RemainingHeader))
+	assert.Equal(t, "1600000000", rr.Header().Get(ResetHeader))
+	assert.Contains(t, rr.Header().Get("Access-Control-Expose-Headers"), RemainingHeader)
+}
+
+func TestMiddleware_NoBody(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Report(r, Status{Limit: 5, Remaining: -1, Reset: 1600000000})
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/", nil))
+
+	assert.Equal(t, "5", rr.Header().Get(LimitHeader))
+	assert.Equal(t, "0", rr.Header().Get(RemainingHeader))
+}
+
+func TestMiddleware_NotReported(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Empty(t, rr.Header().Get(LimitHeader))
+	assert.Empty(t, rr.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestReport_WithoutMiddleware(t *testing.T) {
+	assert.NotPanics(t, func() {
+		Report(httptest.NewRequest(http.MethodGet, "/", nil), Status{Limit: 1})
+	})
+}
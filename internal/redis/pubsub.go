@@ -0,0 +1,121 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// resubscribeInterval is how long a subscription waits before reconnecting after its connection broke.
+const resubscribeInterval = time.Second
+
+// Publish sends message to clients subscribed to channel and returns how many of them received it.
+func (c *Client) Publish(channel string, message []byte) (int, error) {
+	reply, err := c.Do("PUBLISH", channel, message)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return int(n), nil
+}
+
+// Subscription receives messages published on a channel, see Client.Subscribe.
+type Subscription struct {
+	client  *Client
+	channel string
+	handle  func(message []byte)
+
+	mu     sync.Mutex
+	cn     *conn
+	closed bool
+	done   chan struct{}
+}
+
+// Subscribe passes messages published on channel to handle until the subscription is closed. Subscriptions
+// have a connection of their own, outside of the pool, which is re-established when it breaks.
+// Messages published while it's down are lost.
+func (c *Client) Subscribe(channel string, handle func(message []byte)) (*Subscription, error) {
+	s := &Subscription{client: c, channel: channel, handle: handle, done: make(chan struct{})}
+	cn, err := s.connect()
+	if err != nil {
+		return nil, err
+	}
+	s.cn = cn
+	go s.listen(cn)
+	return s, nil
+}
+
+func (s *Subscription) connect() (*conn, error) {
+	cn, err := s.client.dial()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cn.do(s.client.cfg.Timeout, "SUBSCRIBE", s.channel); err != nil {
+		cn.Close()
+		return nil, err
+	}
+	// Messages may be far apart, so reads wait for as long as it takes
+	cn.SetDeadline(time.Time{})
+	return cn, nil
+}
+
+func (s *Subscription) listen(cn *conn) {
+	defer close(s.done)
+	for {
+		reply, err := readReply(cn.r)
+		if err != nil {
+			cn.Close()
+			if cn = s.reconnect(); cn == nil {
+				return
+			}
+			continue
+		}
+		// Messages are sent as ["message", channel, payload]
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 3 {
+			continue
+		}
+		if kind, _ := parts[0].([]byte); string(kind) != "message" {
+			continue
+		}
+		if payload, ok := parts[2].([]byte); ok {
+			s.handle(payload)
+		}
+	}
+}
+
+// reconnect retries connecting until it succeeds or the subscription is closed, in which case it returns nil.
+func (s *Subscription) reconnect() *conn {
+	for {
+		s.mu.Lock()
+		closed := s.closed
+		s.mu.Unlock()
+		if closed {
+			return nil
+		}
+		cn, err := s.connect()
+		if err == nil {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.closed {
+				cn.Close()
+				return nil
+			}
+			s.cn = cn
+			return cn
+		}
+		time.Sleep(resubscribeInterval)
+	}
+}
+
+// Close stops the subscription and closes its connection.
+func (s *Subscription) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	s.cn.Close()
+	s.mu.Unlock()
+	<-s.done
+}
@@ -0,0 +1,291 @@
+// Package redis is a minimal Redis client covering the few commands lbrytv needs.
+// It speaks RESP over a small pool of connections, so there's no dependency on a full-featured client.
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+var (
+	// ErrProtocol is returned when the server sends something that isn't a valid RESP reply.
+	ErrProtocol = errors.Base("redis protocol error")
+	// ErrPoolExhausted is returned when all PoolSize connections stay busy for Timeout.
+	ErrPoolExhausted = errors.Base("redis connection pool exhausted")
+)
+
+// Error is an error reply sent by the server.
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Config tells how to connect to Redis. Connections are closed after Timeout of inactivity on a pending command.
+// No more than PoolSize connections are open at a time, commands wait up to Timeout for one to free up.
+type Config struct {
+	Address  string
+	Password string
+	DB       int
+	Timeout  time.Duration
+	PoolSize int
+}
+
+// Client sends commands to Redis, it's safe for concurrent use.
+type Client struct {
+	cfg  Config
+	pool chan *conn
+	// open holds a token for every open connection
+	open chan struct{}
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// New returns a client for the server set in cfg. Connections are established when they're first needed.
+func New(cfg Config) *Client {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = time.Second
+	}
+	if cfg.PoolSize <= 0 {
+		cfg.PoolSize = 10
+	}
+	return &Client{cfg: cfg, pool: make(chan *conn, cfg.PoolSize), open: make(chan struct{}, cfg.PoolSize)}
+}
+
+// Do sends a command with args, which are strings, byte slices or integers, and returns the reply.
+// Replies are strings for simple strings, []byte for bulk strings, int64 for integers and []interface{} for arrays.
+// Nil bulk strings and arrays are returned as nil. Error replies are returned as Error.
+func (c *Client) Do(args ...interface{}) (interface{}, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+	reply, err := cn.do(c.cfg.Timeout, args...)
+	if _, isReply := err.(Error); err != nil && !isReply {
+		c.close(cn)
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Ping checks that the server is reachable.
+func (c *Client) Ping() error {
+	_, err := c.Do("PING")
+	return err
+}
+
+// Get returns the value of key, or nil if it isn't set.
+func (c *Client) Get(key string) ([]byte, error) {
+	reply, err := c.Do("GET", key)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	v, ok := reply.([]byte)
+	if !ok {
+		return nil, errors.Err("%v: unexpected GET reply %T", ErrProtocol, reply)
+	}
+	return v, nil
+}
+
+// Set sets the value of key, expiring after ttl unless it's zero.
+func (c *Client) Set(key string, value []byte, ttl time.Duration) error {
+	args := []interface{}{"SET", key, value}
+	if ttl > 0 {
+		args = append(args, "PX", int64(ttl/time.Millisecond))
+	}
+	_, err := c.Do(args...)
+	return err
+}
+
+// Del deletes keys and returns how many of them existed.
+func (c *Client) Del(keys ...string) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{"DEL"}
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	reply, err := c.Do(args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := reply.(int64)
+	return int(n), nil
+}
+
+// Scan returns all keys matching the glob-style pattern. It iterates with SCAN so the server isn't blocked,
+// keys changed while it's running may or may not be returned.
+func (c *Client) Scan(match string) ([]string, error) {
+	keys := []string{}
+	cursor := "0"
+	for {
+		reply, err := c.Do("SCAN", cursor, "MATCH", match, "COUNT", 1000)
+		if err != nil {
+			return nil, err
+		}
+		parts, ok := reply.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, errors.Err("%v: unexpected SCAN reply", ErrProtocol)
+		}
+		next, _ := parts[0].([]byte)
+		batch, _ := parts[1].([]interface{})
+		for _, k := range batch {
+			if b, ok := k.([]byte); ok {
+				keys = append(keys, string(b))
+			}
+		}
+		cursor = string(next)
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+func (c *Client) get() (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+	}
+	timer := time.NewTimer(c.cfg.Timeout)
+	defer timer.Stop()
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	case c.open <- struct{}{}:
+	case <-timer.C:
+		return nil, ErrPoolExhausted
+	}
+	cn, err := c.dial()
+	if err != nil {
+		<-c.open
+		return nil, err
+	}
+	return cn, nil
+}
+
+func (c *Client) dial() (*conn, error) {
+	nc, err := net.DialTimeout("tcp", c.cfg.Address, c.cfg.Timeout)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	if c.cfg.Password != "" {
+		if _, err := cn.do(c.cfg.Timeout, "AUTH", c.cfg.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.cfg.DB != 0 {
+		if _, err := cn.do(c.cfg.Timeout, "SELECT", c.cfg.DB); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// put returns cn to the pool, which has room for every open connection.
+func (c *Client) put(cn *conn) {
+	c.pool <- cn
+}
+
+func (c *Client) close(cn *conn) {
+	cn.Close()
+	<-c.open
+}
+
+func (cn *conn) do(timeout time.Duration, args ...interface{}) (interface{}, error) {
+	cn.SetDeadline(time.Now().Add(timeout))
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, a := range args {
+		var b []byte
+		switch v := a.(type) {
+		case string:
+			b = []byte(v)
+		case []byte:
+			b = v
+		case int:
+			b = strconv.AppendInt(nil, int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(nil, v, 10)
+		default:
+			return nil, errors.Err("unsupported redis argument type %T", a)
+		}
+		fmt.Fprintf(cn.w, "$%d\r\n", len(b))
+		cn.w.Write(b)
+		cn.w.WriteString("\r\n")
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, errors.Err(err)
+	}
+	return readReply(cn.r)
+}
+
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.Err("%v: malformed line %q", ErrProtocol, line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		n, err := strconv.ParseInt(body, 10, 64)
+		if err != nil {
+			return nil, errors.Err("%v: %v", ErrProtocol, err)
+		}
+		return n, nil
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.Err("%v: %v", ErrProtocol, err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, errors.Err(err)
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, errors.Err("%v: %v", ErrProtocol, err)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			items[i], err = readReply(r)
+			if e, ok := err.(Error); ok {
+				// Keep reading so the rest of the array doesn't end up in the next reply
+				items[i] = e
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, errors.Err("%v: unknown reply type %q", ErrProtocol, kind)
+}
@@ -0,0 +1,190 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer handles GET, SET, DEL, SCAN and PING on an in-memory map, returning all keys in a single SCAN batch,
+// and PUBLISH and SUBSCRIBE to a single channel per connection.
+type fakeServer struct {
+	net.Listener
+	mu          sync.Mutex
+	values      map[string]string
+	subscribers map[string][]net.Conn
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{Listener: l, values: map[string]string{}, subscribers: map[string][]net.Conn{}}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		args := []string{}
+		for _, a := range reply.([]interface{}) {
+			args = append(args, string(a.([]byte)))
+		}
+		s.mu.Lock()
+		switch strings.ToUpper(args[0]) {
+		case "PING":
+			fmt.Fprint(conn, "+PONG\r\n")
+		case "GET":
+			if v, ok := s.values[args[1]]; ok {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+			} else {
+				fmt.Fprint(conn, "$-1\r\n")
+			}
+		case "SET":
+			s.values[args[1]] = args[2]
+			fmt.Fprint(conn, "+OK\r\n")
+		case "DEL":
+			n := 0
+			for _, k := range args[1:] {
+				if _, ok := s.values[k]; ok {
+					delete(s.values, k)
+					n++
+				}
+			}
+			fmt.Fprintf(conn, ":%d\r\n", n)
+		case "SCAN":
+			prefix := strings.TrimSuffix(args[3], "*")
+			keys := []string{}
+			for k := range s.values {
+				if strings.HasPrefix(k, prefix) {
+					keys = append(keys, k)
+				}
+			}
+			fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(keys))
+			for _, k := range keys {
+				fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+			}
+		case "SUBSCRIBE":
+			s.subscribers[args[1]] = append(s.subscribers[args[1]], conn)
+			fmt.Fprintf(conn, "*3\r\n$9\r\nsubscribe\r\n$%d\r\n%s\r\n:1\r\n", len(args[1]), args[1])
+		case "PUBLISH":
+			for _, sc := range s.subscribers[args[1]] {
+				fmt.Fprintf(sc, "*3\r\n$7\r\nmessage\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(args[1]), args[1], len(args[2]), args[2])
+			}
+			fmt.Fprintf(conn, ":%d\r\n", len(s.subscribers[args[1]]))
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	c := New(Config{Address: s.Addr().String(), Timeout: time.Second})
+	require.NoError(t, c.Ping())
+
+	v, err := c.Get("missing")
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	require.NoError(t, c.Set("a:1", []byte("one\r\ntwo"), time.Minute))
+	require.NoError(t, c.Set("a:2", []byte("2"), 0))
+	require.NoError(t, c.Set("b:1", []byte("3"), 0))
+	v, err = c.Get("a:1")
+	require.NoError(t, err)
+	assert.Equal(t, "one\r\ntwo", string(v))
+
+	keys, err := c.Scan("a:*")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a:1", "a:2"}, keys)
+
+	n, err := c.Del("a:1", "a:2", "a:3")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = c.Do("FLUSHALL")
+	assert.Equal(t, Error("ERR unknown command 'FLUSHALL'"), err)
+	// The connection is still usable after an error reply
+	v, err = c.Get("b:1")
+	require.NoError(t, err)
+	assert.Equal(t, "3", string(v))
+}
+
+func TestClient_Unreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	l.Close()
+
+	c := New(Config{Address: addr, Timeout: 100 * time.Millisecond})
+	assert.Error(t, c.Ping())
+}
+
+func TestClient_PoolSize(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	c := New(Config{Address: s.Addr().String(), Timeout: 100 * time.Millisecond, PoolSize: 1})
+	cn, err := c.get()
+	require.NoError(t, err)
+	// Commands wait for the only connection to be returned
+	assert.True(t, errors.Is(c.Ping(), ErrPoolExhausted))
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		c.put(cn)
+	}()
+	assert.NoError(t, c.Ping())
+}
+
+func TestClient_PubSub(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	c := New(Config{Address: s.Addr().String(), Timeout: time.Second})
+	received := make(chan string, 1)
+	sub, err := c.Subscribe("changes", func(m []byte) { received <- string(m) })
+	require.NoError(t, err)
+
+	n, err := c.Publish("changes", []byte("hello\r\nthere"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	select {
+	case m := <-received:
+		assert.Equal(t, "hello\r\nthere", m)
+	case <-time.After(time.Second):
+		t.Fatal("message was not received")
+	}
+
+	n, err = c.Publish("other", []byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	sub.Close()
+	sub.Close()
+}
@@ -1,7 +1,9 @@
 package responses
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/lbryio/lbrytv/internal/errors"
@@ -31,3 +33,48 @@ func JSONRPCSerialize(r *jsonrpc.RPCResponse) ([]byte, error) {
 	}
 	return b, nil
 }
+
+// JSONRPCSerializeTo is like JSONRPCSerialize but writes into the supplied buffer,
+// which allows using pooled buffers for large responses.
+// Results already serialized as json.RawMessage (like cache hits) are written as is.
+func JSONRPCSerializeTo(b *bytes.Buffer, r *jsonrpc.RPCResponse) (e error) {
+	defer errors.Recover(&e)
+	if result, ok := r.Result.(json.RawMessage); ok && r.Error == nil {
+		version, err := json.Marshal(r.JSONRPC)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "{\n  \"jsonrpc\": %s,\n  \"result\": %s,\n  \"id\": %d\n}", version, result, r.ID)
+		return nil
+	}
+	enc := json.NewEncoder(b)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	// Encoder terminates output with a newline which MarshalIndent doesn't do
+	b.Truncate(b.Len() - 1)
+	return nil
+}
+
+// ErrorResponse is the body of error responses of REST endpoints.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// WriteJSON serializes v and writes it to the response with the supplied HTTP status code.
+func WriteJSON(w http.ResponseWriter, status int, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	AddJSONContentType(w)
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
+
+// WriteError responds with err in an ErrorResponse with the supplied HTTP status code.
+func WriteError(w http.ResponseWriter, status int, err error) {
+	WriteJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,129 @@
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/ybbus/jsonrpc"
+)
+
+const indent = "  "
+
+// JSONRPCSerializeStream writes the response to w in the same format as JSONRPCSerialize,
+// without holding the whole serialized response in memory. Decoded objects and lists are written
+// element by element, so memory use is bounded by the largest scalar or non-generic value instead.
+func JSONRPCSerializeStream(w io.Writer, r *jsonrpc.RPCResponse) (e error) {
+	defer errors.Recover(&e)
+	sw := &stickyWriter{w: w}
+
+	version, err := json.Marshal(r.JSONRPC)
+	if err != nil {
+		return err
+	}
+	sw.printf("{\n%s\"jsonrpc\": %s,\n", indent, version)
+	if r.Result != nil {
+		sw.printf("%s\"result\": ", indent)
+		if err := streamValue(sw, r.Result, 1); err != nil {
+			return err
+		}
+		sw.printf(",\n")
+	}
+	if r.Error != nil {
+		b, err := json.MarshalIndent(r.Error, indent, indent)
+		if err != nil {
+			return err
+		}
+		sw.printf("%s\"error\": %s,\n", indent, b)
+	}
+	sw.printf("%s\"id\": %d\n}", indent, r.ID)
+	return sw.err
+}
+
+func streamValue(sw *stickyWriter, v interface{}, depth int) error {
+	prefix := strings.Repeat(indent, depth)
+	switch typed := v.(type) {
+	case json.RawMessage:
+		// Cache hits are already serialized with the right indentation, see query.Caller
+		sw.write(typed)
+	case map[string]interface{}:
+		if typed == nil {
+			sw.printf("null")
+			break
+		}
+		if len(typed) == 0 {
+			sw.printf("{}")
+			break
+		}
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		sw.printf("{\n")
+		for i, k := range keys {
+			key, err := json.Marshal(k)
+			if err != nil {
+				return err
+			}
+			sw.printf("%s%s%s: ", prefix, indent, key)
+			if err := streamValue(sw, typed[k], depth+1); err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				sw.printf(",")
+			}
+			sw.printf("\n")
+		}
+		sw.printf("%s}", prefix)
+	case []interface{}:
+		if typed == nil {
+			sw.printf("null")
+			break
+		}
+		if len(typed) == 0 {
+			sw.printf("[]")
+			break
+		}
+		sw.printf("[\n")
+		for i, item := range typed {
+			sw.printf("%s%s", prefix, indent)
+			if err := streamValue(sw, item, depth+1); err != nil {
+				return err
+			}
+			if i < len(typed)-1 {
+				sw.printf(",")
+			}
+			sw.printf("\n")
+		}
+		sw.printf("%s]", prefix)
+	default:
+		b, err := json.MarshalIndent(v, prefix, indent)
+		if err != nil {
+			return err
+		}
+		sw.write(b)
+	}
+	return sw.err
+}
+
+// stickyWriter remembers the first write error and skips all subsequent writes.
+type stickyWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *stickyWriter) write(b []byte) {
+	if sw.err == nil {
+		_, sw.err = sw.w.Write(b)
+	}
+}
+
+func (sw *stickyWriter) printf(format string, a ...interface{}) {
+	if sw.err == nil {
+		_, sw.err = fmt.Fprintf(sw.w, format, a...)
+	}
+}
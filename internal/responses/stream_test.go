@@ -0,0 +1,37 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ybbus/jsonrpc"
+)
+
+func TestJSONRPCSerializeStream(t *testing.T) {
+	var result interface{}
+	err := json.Unmarshal([]byte(`{
+		"items": [{"amount": 1.5, "title": "<b>&</b>", "tags": [], "meta": {}, "x": null, "nested": [1, [2, {"y": true}]]}],
+		"page": 1, "empty": "", "deep": {"a": {"b": [null]}}}`), &result)
+	require.NoError(t, err)
+
+	cases := map[string]*jsonrpc.RPCResponse{
+		"result":     {JSONRPC: "2.0", Result: result, ID: 7},
+		"error":      {JSONRPC: "2.0", Error: &jsonrpc.RPCError{Code: -32603, Message: "oops", Data: map[string]interface{}{"k": []interface{}{1}}}, ID: 1},
+		"empty list": {JSONRPC: "2.0", Result: []interface{}{}},
+		"nil map":    {JSONRPC: "2.0", Result: map[string]interface{}(nil)},
+		"scalar":     {JSONRPC: "2.0", Result: "string"},
+		"struct":     {JSONRPC: "2.0", Result: struct{ A []int }{[]int{1, 2}}},
+	}
+	for name, r := range cases {
+		t.Run(name, func(t *testing.T) {
+			expected, err := JSONRPCSerialize(r)
+			require.NoError(t, err)
+			b := &bytes.Buffer{}
+			require.NoError(t, JSONRPCSerializeStream(b, r))
+			assert.Equal(t, string(expected), b.String())
+		})
+	}
+}
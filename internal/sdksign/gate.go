@@ -0,0 +1,26 @@
+package sdksign
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// NewGate returns a reverse proxy to the SDK at upstream which only lets through requests signed with secret.
+// It is meant to be the only way to reach the SDK node from the network.
+func NewGate(upstream *url.URL, secret []byte, maxSkew time.Duration) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := Verify(r, secret, time.Now(), maxSkew); err != nil {
+			logger.WithFields(logrus.Fields{"remote_addr": r.RemoteAddr, "path": r.URL.Path}).Warnf("rejected request: %v", err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		r.Header.Del(TimestampHeader)
+		r.Header.Del(SignatureHeader)
+		proxy.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,196 @@
+// Package sdksign signs requests sent to SDK nodes with HMAC-SHA256 using a secret shared with each node,
+// and verifies those signatures in front of the SDK, so nodes on a shared network only serve lbrytv.
+//
+// The signature covers request method, path, timestamp and a SHA-256 hash of the body.
+// Requests with timestamps too far from the verifier's clock are rejected to limit replays.
+// While a secret is being rotated, requests carry comma-separated signatures made with both the old and the new one,
+// so the verifier can be switched to the new secret at any time.
+package sdksign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	TimestampHeader = "X-Lbrytv-Timestamp"
+	SignatureHeader = "X-Lbrytv-Signature"
+)
+
+var (
+	logger = monitor.NewModuleLogger("sdksign")
+
+	ErrMissingSignature = errors.Base("request is not signed")
+	ErrInvalidSignature = errors.Base("request signature is invalid")
+	ErrStaleSignature   = errors.Base("request signature has expired")
+
+	// DefaultKeyring holds secrets of SDK nodes lbrytv is talking to.
+	DefaultKeyring = NewKeyring()
+)
+
+// Keyring maps SDK node hosts to their secrets.
+type Keyring struct {
+	mu      sync.RWMutex
+	secrets map[string][][]byte
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{secrets: map[string][][]byte{}}
+}
+
+// Set stores secrets for the node at address, which is an URL like http://lbrynet1:5279/.
+// Requests are signed with each of them. Setting no secrets, or only empty ones, removes them.
+func (k *Keyring) Set(address string, secrets ...string) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		logger.Log().Errorf("cannot set signing secret for malformed SDK address %q", address)
+		return
+	}
+	var keys [][]byte
+	for _, s := range secrets {
+		if s != "" {
+			keys = append(keys, []byte(s))
+		}
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if len(keys) == 0 {
+		delete(k.secrets, u.Host)
+		return
+	}
+	k.secrets[u.Host] = keys
+}
+
+// Secret returns the newest secret for the host or nil if there's none.
+func (k *Keyring) Secret(host string) []byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if len(k.secrets[host]) == 0 {
+		return nil
+	}
+	return k.secrets[host][0]
+}
+
+// Secrets returns all secrets for the host.
+func (k *Keyring) Secrets(host string) [][]byte {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.secrets[host]
+}
+
+// Sign adds timestamp and signature headers to the request.
+func Sign(r *http.Request, secret []byte, now time.Time) error {
+	return SignAll(r, [][]byte{secret}, now)
+}
+
+// SignAll adds timestamp and signature headers to the request, with a signature made with each of secrets.
+func SignAll(r *http.Request, secrets [][]byte, now time.Time) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(now.Unix(), 10)
+	sigs := make([]string, len(secrets))
+	for i, secret := range secrets {
+		sigs[i] = signature(secret, r.Method, requestPath(r), ts, body)
+	}
+	r.Header.Set(TimestampHeader, ts)
+	r.Header.Set(SignatureHeader, strings.Join(sigs, ","))
+	return nil
+}
+
+// Verify checks that one of the request signatures is made with secret and that its timestamp is within maxSkew of now.
+func Verify(r *http.Request, secret []byte, now time.Time, maxSkew time.Duration) error {
+	ts, sig := r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader)
+	if ts == "" || sig == "" {
+		return ErrMissingSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	skew := now.Sub(time.Unix(unix, 0))
+	if skew > maxSkew || skew < -maxSkew {
+		return ErrStaleSignature
+	}
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	expected := signature(secret, r.Method, requestPath(r), ts, body)
+	for _, s := range strings.Split(sig, ",") {
+		if hmac.Equal([]byte(strings.ToLower(strings.TrimSpace(s))), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// Transport signs requests to hosts present in the keyring and passes the rest through unchanged.
+type Transport struct {
+	Base    http.RoundTripper
+	Keyring *Keyring
+}
+
+// NewTransport wraps base, which is used to send all requests.
+func NewTransport(base http.RoundTripper, keyring *Keyring) *Transport {
+	return &Transport{Base: base, Keyring: keyring}
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	secrets := t.Keyring.Secrets(r.URL.Host)
+	if len(secrets) == 0 {
+		return t.Base.RoundTrip(r)
+	}
+	// RoundTripper must not modify the original request
+	signed := r.Clone(r.Context())
+	if err := SignAll(signed, secrets, time.Now()); err != nil {
+		return nil, err
+	}
+	return t.Base.RoundTrip(signed)
+}
+
+func signature(secret []byte, method, path, ts string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(method + "\n" + path + "\n" + ts + "\n" + hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// requestPath returns the path as the server sees it, clients may send requests for bare hosts.
+func requestPath(r *http.Request) string {
+	if p := r.URL.EscapedPath(); p != "" {
+		return p
+	}
+	return "/"
+}
+
+// readBody reads the request body and puts it back so the request can still be sent.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return []byte{}, nil
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
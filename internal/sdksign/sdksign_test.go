@@ -0,0 +1,105 @@
+package sdksign
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var secret = []byte("s3cr3t")
+
+func newRequest(t *testing.T, body string) *http.Request {
+	r, err := http.NewRequest(http.MethodPost, "http://lbrynet:5279/", bytes.NewBufferString(body))
+	require.NoError(t, err)
+	return r
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Now()
+	r := newRequest(t, `{"method": "status"}`)
+	require.NoError(t, Sign(r, secret, now))
+	assert.NoError(t, Verify(r, secret, now.Add(10*time.Second), time.Minute))
+
+	body, err := ioutil.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"method": "status"}`, string(body))
+}
+
+func TestSignAll(t *testing.T) {
+	now := time.Now()
+	rotated := []byte("n3w s3cr3t")
+	r := newRequest(t, `{"method": "status"}`)
+	require.NoError(t, SignAll(r, [][]byte{rotated, secret}, now))
+	assert.NoError(t, Verify(r, secret, now, time.Minute))
+	assert.NoError(t, Verify(r, rotated, now, time.Minute))
+	assert.True(t, errors.Is(Verify(r, []byte("other"), now, time.Minute), ErrInvalidSignature))
+
+	k := NewKeyring()
+	k.Set("http://lbrynet:5279/", string(rotated), string(secret))
+	assert.Equal(t, rotated, k.Secret("lbrynet:5279"))
+	assert.Len(t, k.Secrets("lbrynet:5279"), 2)
+	k.Set("http://lbrynet:5279/", "")
+	assert.Nil(t, k.Secret("lbrynet:5279"))
+}
+
+func TestVerify_Rejects(t *testing.T) {
+	now := time.Now()
+
+	r := newRequest(t, `{"method": "status"}`)
+	assert.True(t, errors.Is(Verify(r, secret, now, time.Minute), ErrMissingSignature))
+
+	r = newRequest(t, `{"method": "status"}`)
+	require.NoError(t, Sign(r, secret, now))
+	assert.True(t, errors.Is(Verify(r, []byte("other"), now, time.Minute), ErrInvalidSignature))
+
+	r = newRequest(t, `{"method": "status"}`)
+	require.NoError(t, Sign(r, secret, now))
+	r.Body = ioutil.NopCloser(bytes.NewBufferString(`{"method": "wallet_send"}`))
+	assert.True(t, errors.Is(Verify(r, secret, now, time.Minute), ErrInvalidSignature))
+
+	r = newRequest(t, `{"method": "status"}`)
+	require.NoError(t, Sign(r, secret, now.Add(-2*time.Minute)))
+	assert.True(t, errors.Is(Verify(r, secret, now, time.Minute), ErrStaleSignature))
+}
+
+func TestGate(t *testing.T) {
+	var received string
+	sdk := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		assert.Empty(t, r.Header.Get(SignatureHeader))
+		w.Write([]byte(`{"result": "ok"}`))
+	}))
+	defer sdk.Close()
+	target, err := url.Parse(sdk.URL)
+	require.NoError(t, err)
+	gate := httptest.NewServer(NewGate(target, secret, time.Minute))
+	defer gate.Close()
+
+	res, err := http.Post(gate.URL, "application/json", bytes.NewBufferString(`{"method": "status"}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Empty(t, received)
+
+	keyring := NewKeyring()
+	keyring.Set(gate.URL+"/", string(secret))
+	client := &http.Client{Transport: NewTransport(http.DefaultTransport, keyring)}
+	res, err = client.Post(gate.URL, "application/json", bytes.NewBufferString(`{"method": "status"}`))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, `{"result": "ok"}`, string(body))
+	assert.Equal(t, `{"method": "status"}`, received)
+}
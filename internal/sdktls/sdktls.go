@@ -0,0 +1,284 @@
+// Package sdktls provides mutual TLS for connections to SDK nodes.
+//
+// Client certificate, key and CA bundle are read from files and reloaded when they change,
+// so certificates can be rotated by whatever renews them (cert-manager, spiffe-helper etc) without a restart.
+// When SPIFFE IDs are configured, SDK nodes are authenticated by the SPIFFE ID in their X.509-SVID
+// instead of by hostname.
+package sdktls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+var (
+	logger = monitor.NewModuleLogger("sdktls")
+
+	ErrUnknownSPIFFEID = errors.Base("SDK node presented an unexpected SPIFFE ID")
+
+	defaultMu     sync.RWMutex
+	defaultSource *Source
+
+	hostsMu  sync.RWMutex
+	sdkHosts = map[string]bool{}
+)
+
+// Config defines where certificates are read from.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// SPIFFEIDs are SPIFFE IDs accepted from SDK nodes, e.g. spiffe://lbry.tv/lbrynet.
+	SPIFFEIDs      []string
+	ReloadInterval time.Duration
+}
+
+// Source keeps the current client certificate and CA pool.
+type Source struct {
+	cfg Config
+
+	mu         sync.RWMutex
+	cert       *tls.Certificate
+	roots      *x509.CertPool
+	modTimes   map[string]time.Time
+	generation int
+}
+
+// NewSource loads certificates from files in cfg.
+func NewSource(cfg Config) (*Source, error) {
+	s := &Source{cfg: cfg, modTimes: map[string]time.Time{}}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Source) load() error {
+	cert, err := tls.LoadX509KeyPair(s.cfg.CertFile, s.cfg.KeyFile)
+	if err != nil {
+		return errors.Prefix("cannot load client certificate", err)
+	}
+	ca, err := ioutil.ReadFile(s.cfg.CAFile)
+	if err != nil {
+		return errors.Prefix("cannot read CA bundle", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return errors.Err("no certificates found in %v", s.cfg.CAFile)
+	}
+
+	modTimes := map[string]time.Time{}
+	for _, f := range s.files() {
+		if st, err := os.Stat(f); err == nil {
+			modTimes[f] = st.ModTime()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cert = &cert
+	s.roots = roots
+	s.modTimes = modTimes
+	s.generation++
+	return nil
+}
+
+func (s *Source) files() []string {
+	return []string{s.cfg.CertFile, s.cfg.KeyFile, s.cfg.CAFile}
+}
+
+func (s *Source) changed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.files() {
+		st, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !st.ModTime().Equal(s.modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reload reads certificates again if any of the files has changed.
+// Previous certificates are kept when the new ones cannot be loaded.
+func (s *Source) Reload() (bool, error) {
+	if !s.changed() {
+		return false, nil
+	}
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Watch keeps reloading certificates every ReloadInterval.
+func (s *Source) Watch() {
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	for range ticker.C {
+		reloaded, err := s.Reload()
+		if err != nil {
+			logger.Log().Errorf("error reloading SDK TLS certificates: %v", err)
+			monitor.ErrorToSentry(err)
+		} else if reloaded {
+			logger.Log().Info("SDK TLS certificates reloaded")
+		}
+	}
+}
+
+// Generation is increased every time certificates are reloaded.
+func (s *Source) Generation() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generation
+}
+
+// ClientConfig returns TLS config for connecting to SDK nodes with the current CA pool.
+// The client certificate is looked up on every handshake so it can be rotated on existing configs,
+// CA changes require a new config, see Generation.
+func (s *Source) ClientConfig() *tls.Config {
+	s.mu.RLock()
+	roots := s.roots
+	s.mu.RUnlock()
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    roots,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			return s.cert, nil
+		},
+	}
+	if len(s.cfg.SPIFFEIDs) > 0 {
+		// SVIDs carry identity in the URI SAN instead of DNS names, so the chain is verified here
+		// and the hostname check done by crypto/tls is skipped.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return verifySVID(raw, roots, s.cfg.SPIFFEIDs)
+		}
+	}
+	return cfg
+}
+
+func verifySVID(raw [][]byte, roots *x509.CertPool, allowed []string) error {
+	if len(raw) == 0 {
+		return errors.Err("SDK node presented no certificate")
+	}
+	certs := make([]*x509.Certificate, len(raw))
+	for i, b := range raw {
+		c, err := x509.ParseCertificate(b)
+		if err != nil {
+			return errors.Err(err)
+		}
+		certs[i] = c
+	}
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return errors.Err(err)
+	}
+	for _, u := range certs[0].URIs {
+		for _, id := range allowed {
+			if u.String() == id {
+				return nil
+			}
+		}
+	}
+	return ErrUnknownSPIFFEID
+}
+
+// SetDefault makes s the source used for connections to SDK nodes.
+func SetDefault(s *Source) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultSource = s
+}
+
+func getDefault() *Source {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultSource
+}
+
+// ClientConfig returns TLS config of the default source or nil when mutual TLS is not configured.
+func ClientConfig() *tls.Config {
+	if s := getDefault(); s != nil {
+		return s.ClientConfig()
+	}
+	return nil
+}
+
+// SetSDKAddresses registers addresses of SDK nodes, requests to them go over mutual TLS in Transport.
+func SetSDKAddresses(addresses []string) {
+	hosts := map[string]bool{}
+	for _, a := range addresses {
+		if u, err := url.Parse(a); err == nil && u.Host != "" {
+			hosts[u.Host] = true
+		}
+	}
+	hostsMu.Lock()
+	defer hostsMu.Unlock()
+	sdkHosts = hosts
+}
+
+func isSDKHost(host string) bool {
+	hostsMu.RLock()
+	defer hostsMu.RUnlock()
+	return sdkHosts[host]
+}
+
+// Transport sends https requests to SDK nodes over mutual TLS with the default source
+// and all other requests via Base unchanged.
+type Transport struct {
+	Base *http.Transport
+
+	mu         sync.Mutex
+	sdk        *http.Transport
+	generation int
+}
+
+func NewTransport(base *http.Transport) *Transport {
+	return &Transport{Base: base}
+}
+
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	s := getDefault()
+	if s == nil || r.URL.Scheme != "https" || !isSDKHost(r.URL.Host) {
+		return t.Base.RoundTrip(r)
+	}
+	return t.sdkTransport(s).RoundTrip(r)
+}
+
+// sdkTransport returns a transport using the current CA pool, replacing the old one after certificates are reloaded.
+func (t *Transport) sdkTransport(s *Source) *http.Transport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if gen := s.Generation(); t.sdk == nil || gen != t.generation {
+		if t.sdk != nil {
+			t.sdk.CloseIdleConnections()
+		}
+		t.sdk = t.Base.Clone()
+		t.sdk.TLSClientConfig = s.ClientConfig()
+		t.generation = gen
+	}
+	return t.sdk
+}
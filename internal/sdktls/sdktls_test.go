@@ -0,0 +1,181 @@
+package sdktls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM-encoded certificate and key, spiffeID is added as URI SAN when not empty.
+func (ca *testCA) issue(t *testing.T, serial int64, spiffeID string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if spiffeID != "" {
+		u, err := url.Parse(spiffeID)
+		require.NoError(t, err)
+		tpl.URIs = []*url.URL{u}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+func writeFiles(t *testing.T, dir string, ca *testCA, serial int64) Config {
+	cert, key := ca.issue(t, serial, "spiffe://lbry.tv/lbrytv", x509.ExtKeyUsageClientAuth)
+	cfg := Config{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "ca.pem"),
+	}
+	require.NoError(t, ioutil.WriteFile(cfg.CertFile, cert, 0600))
+	require.NoError(t, ioutil.WriteFile(cfg.KeyFile, key, 0600))
+	require.NoError(t, ioutil.WriteFile(cfg.CAFile, ca.pem, 0600))
+	return cfg
+}
+
+// newSDK starts a TLS server requiring client certificates issued by ca.
+func newSDK(t *testing.T, ca *testCA, spiffeID string) (*httptest.Server, chan *big.Int) {
+	serials := make(chan *big.Int, 10)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serials <- r.TLS.PeerCertificates[0].SerialNumber
+		w.Write([]byte(`{"result": "ok"}`))
+	}))
+	cert, key := ca.issue(t, 100, spiffeID, x509.ExtKeyUsageServerAuth)
+	pair, err := tls.X509KeyPair(cert, key)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	return srv, serials
+}
+
+func TestTransport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdktls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newCA(t)
+	sdk, serials := newSDK(t, ca, "")
+	defer sdk.Close()
+
+	src, err := NewSource(writeFiles(t, dir, ca, 1))
+	require.NoError(t, err)
+	SetDefault(src)
+	defer SetDefault(nil)
+
+	tr := NewTransport(http.DefaultTransport.(*http.Transport).Clone())
+	client := &http.Client{Transport: tr}
+
+	// Not registered as an SDK node, so no client certificate is presented and the CA isn't trusted
+	_, err = client.Get(sdk.URL)
+	require.Error(t, err)
+
+	SetSDKAddresses([]string{sdk.URL + "/"})
+	defer SetSDKAddresses(nil)
+	res, err := client.Get(sdk.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.EqualValues(t, 1, (<-serials).Int64())
+
+	// Rotating the client certificate
+	time.Sleep(10 * time.Millisecond)
+	writeFiles(t, dir, ca, 2)
+	reloaded, err := src.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	res, err = client.Get(sdk.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.EqualValues(t, 2, (<-serials).Int64())
+
+	reloaded, err = src.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded)
+}
+
+func TestSPIFFE(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sdktls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := newCA(t)
+	sdk, serials := newSDK(t, ca, "spiffe://lbry.tv/lbrynet")
+	defer sdk.Close()
+
+	cfg := writeFiles(t, dir, ca, 1)
+	cfg.SPIFFEIDs = []string{"spiffe://lbry.tv/lbrynet"}
+	src, err := NewSource(cfg)
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: src.ClientConfig()}}
+	res, err := client.Get(sdk.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.EqualValues(t, 1, (<-serials).Int64())
+
+	cfg.SPIFFEIDs = []string{"spiffe://lbry.tv/something-else"}
+	src, err = NewSource(cfg)
+	require.NoError(t, err)
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: src.ClientConfig()}}
+	_, err = client.Get(sdk.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrUnknownSPIFFEID.Error())
+}
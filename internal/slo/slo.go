@@ -0,0 +1,216 @@
+// Package slo computes availability and latency SLIs per endpoint class over rolling windows
+// and exports error budget burn rates, so alerts can be based on error budgets instead of raw error counts.
+package slo
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+const (
+	// ClassRead covers SDK calls which don't need a wallet, like resolve and claim_search.
+	ClassRead = "read"
+	// ClassWallet covers SDK calls operating on user wallets.
+	ClassWallet = "wallet"
+	// ClassPublish covers uploads.
+	ClassPublish = "publish"
+
+	SLIAvailability = "availability"
+	SLILatency      = "latency"
+
+	bucketSize    = time.Minute
+	bucketCount   = 360
+	cycleInterval = 30 * time.Second
+)
+
+var (
+	logger = monitor.NewModuleLogger("slo")
+
+	// Windows are rolling windows SLIs are computed over.
+	// Short and long windows are paired for multi-window burn rate alerts (5m/1h and 30m/6h).
+	Windows = map[string]time.Duration{
+		"5m":  5 * time.Minute,
+		"30m": 30 * time.Minute,
+		"1h":  time.Hour,
+		"6h":  6 * time.Hour,
+	}
+
+	defaultObjectives = map[string]config.SLO{
+		ClassRead:    {Availability: 0.999, Latency: time.Second, LatencyTarget: 0.99},
+		ClassWallet:  {Availability: 0.995, Latency: 5 * time.Second, LatencyTarget: 0.95},
+		ClassPublish: {Availability: 0.99, Latency: time.Minute, LatencyTarget: 0.95},
+	}
+
+	// serverFailureKinds are failure kinds that count against the error budget.
+	// Client errors are not our fault and are counted as good events.
+	serverFailureKinds = map[string]bool{
+		metrics.FailureKindNet:              true,
+		metrics.FailureKindRPCJSON:          true,
+		metrics.FailureKindInternal:         true,
+		metrics.FailureKindLbrynetXMismatch: true,
+		metrics.FailureKindTimeout:          true,
+		// Calls turned down by an open circuit breaker are unavailability all the same
+		metrics.FailureKindUnavailable: true,
+	}
+
+	defaultTracker = NewTracker(Objectives())
+)
+
+// Objectives returns SLOs for all endpoint classes, with values from the config taking precedence over defaults.
+func Objectives() map[string]config.SLO {
+	objectives := map[string]config.SLO{}
+	for class, o := range defaultObjectives {
+		objectives[class] = o
+	}
+	for class, o := range config.GetSLOs() {
+		objectives[class] = o
+	}
+	return objectives
+}
+
+type bucket struct {
+	start int64
+	total int
+	bad   int
+	slow  int
+}
+
+// series is a ring of per-minute event counters covering the longest window.
+type series [bucketCount]bucket
+
+func (s *series) add(now time.Time, bad, slow bool) {
+	start := now.Truncate(bucketSize).Unix()
+	b := &s[(start/int64(bucketSize.Seconds()))%bucketCount]
+	if b.start != start {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if bad {
+		b.bad++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+func (s *series) sum(now time.Time, window time.Duration) (total, bad, slow int) {
+	cutoff := now.Add(-window).Unix()
+	for _, b := range s {
+		if b.total > 0 && b.start > cutoff-int64(bucketSize.Seconds()) && b.start <= now.Unix() {
+			total += b.total
+			bad += b.bad
+			slow += b.slow
+		}
+	}
+	return
+}
+
+// SLI holds indicators computed over a single window.
+type SLI struct {
+	Total        int
+	Availability float64
+	Latency      float64
+}
+
+// Tracker accumulates events per endpoint class.
+type Tracker struct {
+	mu         sync.Mutex
+	objectives map[string]config.SLO
+	series     map[string]*series
+}
+
+// NewTracker creates a tracker for the provided objectives. Events for classes without an objective are ignored.
+func NewTracker(objectives map[string]config.SLO) *Tracker {
+	t := &Tracker{objectives: objectives, series: map[string]*series{}}
+	for class := range objectives {
+		t.series[class] = &series{}
+	}
+	return t
+}
+
+// Observe records a request of the class which took d seconds and failed with failureKind (empty on success).
+func (t *Tracker) Observe(class string, d float64, failureKind string) {
+	o, ok := t.objectives[class]
+	if !ok {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.series[class].add(time.Now(), serverFailureKinds[failureKind], d > o.Latency.Seconds())
+}
+
+// SLI returns indicators for the class over the window. Both are 1 when there were no events.
+func (t *Tracker) SLI(class string, window time.Duration) SLI {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s, ok := t.series[class]
+	if !ok {
+		return SLI{Availability: 1, Latency: 1}
+	}
+	total, bad, slow := s.sum(time.Now(), window)
+	if total == 0 {
+		return SLI{Availability: 1, Latency: 1}
+	}
+	return SLI{
+		Total:        total,
+		Availability: 1 - float64(bad)/float64(total),
+		Latency:      1 - float64(slow)/float64(total),
+	}
+}
+
+// SLIs returns indicators for all tracked classes over the window.
+func (t *Tracker) SLIs(window time.Duration) map[string]SLI {
+	slis := map[string]SLI{}
+	for class := range t.objectives {
+		slis[class] = t.SLI(class, window)
+	}
+	return slis
+}
+
+// BurnRate returns how fast the error budget is consumed: 1 means the budget would be exactly used up
+// over the SLO period, higher values mean it would run out sooner.
+func BurnRate(sli, objective float64) float64 {
+	if objective >= 1 {
+		return 0
+	}
+	return (1 - sli) / (1 - objective)
+}
+
+// UpdateMetrics exports SLIs and burn rates for all classes and windows.
+func (t *Tracker) UpdateMetrics() {
+	for class, o := range t.objectives {
+		for name, window := range Windows {
+			sli := t.SLI(class, window)
+			metrics.SLOIndicator.WithLabelValues(class, SLIAvailability, name).Set(sli.Availability)
+			metrics.SLOIndicator.WithLabelValues(class, SLILatency, name).Set(sli.Latency)
+			metrics.SLOBurnRate.WithLabelValues(class, SLIAvailability, name).Set(BurnRate(sli.Availability, o.Availability))
+			metrics.SLOBurnRate.WithLabelValues(class, SLILatency, name).Set(BurnRate(sli.Latency, o.LatencyTarget))
+		}
+		metrics.SLOObjective.WithLabelValues(class, SLIAvailability).Set(o.Availability)
+		metrics.SLOObjective.WithLabelValues(class, SLILatency).Set(o.LatencyTarget)
+	}
+}
+
+// WatchBudgets keeps SLO metrics up to date. It blocks.
+func WatchBudgets() {
+	logger.Log().Infof("tracking SLOs for %d endpoint classes", len(defaultTracker.objectives))
+	ticker := time.NewTicker(cycleInterval)
+	for {
+		defaultTracker.UpdateMetrics()
+		<-ticker.C
+	}
+}
+
+// SLIs returns indicators of the default tracker. See Tracker.SLIs.
+func SLIs(window time.Duration) map[string]SLI {
+	return defaultTracker.SLIs(window)
+}
+
+// Observe records a request in the default tracker. See Tracker.Observe.
+func Observe(class string, d float64, failureKind string) {
+	defaultTracker.Observe(class, d, failureKind)
+}
@@ -0,0 +1,76 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker_SLI(t *testing.T) {
+	tr := NewTracker(map[string]config.SLO{
+		ClassRead: {Availability: 0.99, Latency: time.Second, LatencyTarget: 0.9},
+	})
+
+	sli := tr.SLI(ClassRead, time.Hour)
+	assert.Equal(t, 1.0, sli.Availability)
+	assert.Equal(t, 1.0, sli.Latency)
+
+	for i := 0; i < 6; i++ {
+		tr.Observe(ClassRead, 0.1, "")
+	}
+	tr.Observe(ClassRead, 0.1, metrics.FailureKindClientJSON)
+	tr.Observe(ClassRead, 2, "")
+	tr.Observe(ClassRead, 0.1, metrics.FailureKindNet)
+	tr.Observe(ClassRead, 0.1, metrics.FailureKindInternal)
+	tr.Observe("unknown", 0.1, metrics.FailureKindNet)
+
+	sli = tr.SLI(ClassRead, 5*time.Minute)
+	assert.Equal(t, 10, sli.Total)
+	assert.InDelta(t, 0.8, sli.Availability, 0.0001)
+	assert.InDelta(t, 0.9, sli.Latency, 0.0001)
+	assert.Equal(t, 0, tr.SLI("unknown", time.Hour).Total)
+}
+
+func TestTracker_SLI_BreakerOpen(t *testing.T) {
+	tr := NewTracker(map[string]config.SLO{
+		ClassRead: {Availability: 0.99, Latency: time.Second, LatencyTarget: 0.9},
+	})
+	for i := 0; i < 3; i++ {
+		tr.Observe(ClassRead, 0.1, "")
+	}
+	tr.Observe(ClassRead, 0.01, metrics.FailureKindUnavailable)
+
+	sli := tr.SLI(ClassRead, 5*time.Minute)
+	assert.InDelta(t, 0.75, sli.Availability, 0.0001)
+}
+
+func TestSeries_Window(t *testing.T) {
+	s := &series{}
+	now := time.Now()
+	s.add(now.Add(-2*time.Hour), true, false)
+	s.add(now.Add(-10*time.Minute), true, true)
+	s.add(now, false, false)
+
+	total, bad, slow := s.sum(now, 5*time.Minute)
+	assert.Equal(t, []int{1, 0, 0}, []int{total, bad, slow})
+	total, bad, slow = s.sum(now, time.Hour)
+	assert.Equal(t, []int{2, 1, 1}, []int{total, bad, slow})
+	total, bad, _ = s.sum(now, 6*time.Hour)
+	assert.Equal(t, []int{3, 2}, []int{total, bad})
+
+	// Buckets from an earlier lap of the ring are not counted
+	s.add(now.Add(bucketCount*bucketSize), false, false)
+	total, _, _ = s.sum(now.Add(bucketCount*bucketSize), 5*time.Minute)
+	assert.Equal(t, 1, total)
+}
+
+func TestBurnRate(t *testing.T) {
+	assert.InDelta(t, 1.0, BurnRate(0.999, 0.999), 0.0001)
+	assert.InDelta(t, 10.0, BurnRate(0.99, 0.999), 0.0001)
+	assert.Equal(t, 0.0, BurnRate(1, 0.999))
+	assert.Equal(t, 0.0, BurnRate(0.5, 1))
+}
@@ -0,0 +1,139 @@
+// Package spill provides a writer which keeps small payloads in memory and moves large ones
+// to temporary files, so a handful of giant responses cannot exhaust process memory.
+package spill
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+// ErrTooManySpills is returned by Writer.Write when payload exceeds the threshold
+// but the maximum number of concurrent spills has been reached.
+var ErrTooManySpills = errors.Base("too many large responses are being processed, please retry later")
+
+var (
+	logger = monitor.NewModuleLogger("spill")
+	slots  = make(chan struct{}, config.GetResponseSpillMaxConcurrent())
+)
+
+const spillBufferSize = 64 << 10
+
+// Writer buffers written data in memory until it exceeds threshold, then moves it into a temporary file
+// and keeps writing there. Close must be called to remove the file and release the spill slot.
+type Writer struct {
+	buf       *bytes.Buffer
+	threshold int
+	dir       string
+	slots     chan struct{}
+	file      *os.File
+	// fw buffers writes to file so serializers making many small writes don't turn each into a syscall
+	fw   *bufio.Writer
+	size int64
+}
+
+// NewWriter creates a writer using buf for in-memory data, with threshold and directory from the config.
+func NewWriter(buf *bytes.Buffer) *Writer {
+	return newWriter(buf, config.GetResponseSpillThreshold(), config.GetResponseSpillDir(), slots)
+}
+
+func newWriter(buf *bytes.Buffer, threshold int, dir string, slots chan struct{}) *Writer {
+	return &Writer{buf: buf, threshold: threshold, dir: dir, slots: slots}
+}
+
+// Write implements io.Writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.file == nil && w.threshold > 0 && w.buf.Len()+len(p) > w.threshold {
+		if err := w.spill(); err != nil {
+			return 0, err
+		}
+	}
+	var (
+		n   int
+		err error
+	)
+	if w.file != nil {
+		n, err = w.fw.Write(p)
+	} else {
+		n, err = w.buf.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) spill() error {
+	select {
+	case w.slots <- struct{}{}:
+	default:
+		metrics.ResponseSpillCount.WithLabelValues(metrics.SpillResultRejected).Inc()
+		return ErrTooManySpills
+	}
+
+	f, err := ioutil.TempFile(w.dir, "response-*.json")
+	if err != nil {
+		<-w.slots
+		return err
+	}
+	if _, err := w.buf.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		<-w.slots
+		return err
+	}
+	w.file = f
+	w.fw = bufio.NewWriterSize(f, spillBufferSize)
+	w.buf.Reset()
+	metrics.ResponseSpillCount.WithLabelValues(metrics.SpillResultSpilled).Inc()
+	metrics.ResponseSpillsInProgress.Inc()
+	logger.Log().Infof("response exceeded %v bytes, spilled to %v", w.threshold, f.Name())
+	return nil
+}
+
+// Spilled returns true if written data has been moved to a file.
+func (w *Writer) Spilled() bool {
+	return w.file != nil
+}
+
+// Len returns the total number of bytes written.
+func (w *Writer) Len() int64 {
+	return w.size
+}
+
+// WriteTo copies all written data into dst.
+func (w *Writer) WriteTo(dst io.Writer) (int64, error) {
+	if w.file == nil {
+		return w.buf.WriteTo(dst)
+	}
+	if err := w.fw.Flush(); err != nil {
+		return 0, err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.Copy(dst, w.file)
+}
+
+// Close removes the temporary file, if any, and releases the spill slot.
+func (w *Writer) Close() error {
+	if w.file == nil {
+		return nil
+	}
+	// Buffered data is flushed before closing so write errors are reported rather than dropped with the file
+	err := w.fw.Flush()
+	w.file.Close()
+	if rmErr := os.Remove(w.file.Name()); err == nil {
+		err = rmErr
+	}
+	w.file = nil
+	w.fw = nil
+	<-w.slots
+	metrics.ResponseSpillsInProgress.Dec()
+	return err
+}
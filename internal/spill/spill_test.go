@@ -0,0 +1,78 @@
+package spill
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter_InMemory(t *testing.T) {
+	w := newWriter(&bytes.Buffer{}, 10, os.TempDir(), make(chan struct{}, 1))
+	defer w.Close()
+
+	w.Write([]byte("hello"))
+	w.Write([]byte("world"))
+	assert.False(t, w.Spilled())
+	assert.EqualValues(t, 10, w.Len())
+
+	out := &bytes.Buffer{}
+	_, err := w.WriteTo(out)
+	require.NoError(t, err)
+	assert.Equal(t, "helloworld", out.String())
+}
+
+func TestWriter_Spill(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spill")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	slots := make(chan struct{}, 1)
+	buf := &bytes.Buffer{}
+	w := newWriter(buf, 10, dir, slots)
+
+	w.Write([]byte("hello"))
+	w.Write([]byte("world!"))
+	w.Write([]byte("more"))
+	assert.True(t, w.Spilled())
+	assert.Equal(t, 0, buf.Len())
+	assert.EqualValues(t, 15, w.Len())
+	assert.Len(t, slots, 1)
+
+	out := &bytes.Buffer{}
+	_, err = w.WriteTo(out)
+	require.NoError(t, err)
+	assert.Equal(t, "helloworld!more", out.String())
+
+	require.NoError(t, w.Close())
+	assert.Len(t, slots, 0)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestWriter_TooManySpills(t *testing.T) {
+	slots := make(chan struct{}, 1)
+	w1 := newWriter(&bytes.Buffer{}, 1, os.TempDir(), slots)
+	defer w1.Close()
+	w2 := newWriter(&bytes.Buffer{}, 1, os.TempDir(), slots)
+	defer w2.Close()
+
+	_, err := w1.Write([]byte("big"))
+	require.NoError(t, err)
+	_, err = w2.Write([]byte("big"))
+	assert.True(t, errors.Is(err, ErrTooManySpills))
+	assert.False(t, w2.Spilled())
+}
+
+func TestWriter_Disabled(t *testing.T) {
+	w := newWriter(&bytes.Buffer{}, 0, os.TempDir(), make(chan struct{}))
+	_, err := w.Write(make([]byte, 1000))
+	require.NoError(t, err)
+	assert.False(t, w.Spilled())
+}
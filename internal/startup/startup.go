@@ -0,0 +1,193 @@
+// Package startup brings service subsystems up in dependency order, retrying transient failures,
+// so a dependency which is briefly unavailable doesn't crash the whole service.
+package startup
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/monitor"
+	"github.com/lbryio/lbrytv/internal/responses"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	StatusPending  = "pending"
+	StatusStarting = "starting"
+	StatusReady    = "ready"
+	StatusFailed   = "failed"
+)
+
+var (
+	logger = monitor.NewModuleLogger("startup")
+
+	orchestratorMu      sync.RWMutex
+	defaultOrchestrator *Orchestrator
+)
+
+// Backoff defines how failing steps are retried. The delay doubles after each attempt up to MaxInterval.
+// Attempts is the total number of attempts, a step is tried once when it's not positive.
+type Backoff struct {
+	Attempts        int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+}
+
+// Step is a subsystem initialization. Run should return an error if the subsystem could not be brought up.
+type Step struct {
+	Name string
+	Run  func() error
+}
+
+// StepState describes the progress of a single step.
+type StepState struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Orchestrator runs steps one after another, each one is started only after the previous ones succeeded.
+type Orchestrator struct {
+	backoff Backoff
+	steps   []Step
+	sleep   func(time.Duration)
+
+	mu     sync.RWMutex
+	states []StepState
+}
+
+// New creates an orchestrator for steps in the order they should be brought up in.
+func New(backoff Backoff, steps ...Step) *Orchestrator {
+	o := &Orchestrator{backoff: backoff, steps: steps, sleep: time.Sleep}
+	for _, s := range steps {
+		o.states = append(o.states, StepState{Name: s.Name, Status: StatusPending})
+	}
+	return o
+}
+
+// SetDefault sets the orchestrator whose state is reported by HandleReadyz.
+func SetDefault(o *Orchestrator) {
+	orchestratorMu.Lock()
+	defer orchestratorMu.Unlock()
+	defaultOrchestrator = o
+}
+
+func getDefault() *Orchestrator {
+	orchestratorMu.RLock()
+	defer orchestratorMu.RUnlock()
+	return defaultOrchestrator
+}
+
+// Run brings all steps up, returning an error if any of them still fails after all retry attempts.
+// Steps following the failed one are not run.
+func (o *Orchestrator) Run() error {
+	for i, s := range o.steps {
+		o.setState(i, StatusStarting, 0, nil)
+		start := time.Now()
+		err := retry(o.backoff, o.sleep, s.Name, func(attempt int, err error) {
+			o.setState(i, StatusStarting, attempt, err)
+		}, s.Run)
+		if err != nil {
+			o.setState(i, StatusFailed, o.states[i].Attempts, err)
+			return errors.Prefix("startup step "+s.Name+" failed", err)
+		}
+		o.setState(i, StatusReady, o.states[i].Attempts, nil)
+		logger.WithFields(logrus.Fields{"step": s.Name, "duration": time.Since(start).Seconds()}).Info("startup step done")
+	}
+	logger.Log().Info("all startup steps done")
+	return nil
+}
+
+// Ready returns true when all steps are up.
+func (o *Orchestrator) Ready() bool {
+	return o.Status() == StatusReady
+}
+
+// Status returns overall startup state: ready once all steps are up, failed if any step failed
+// and starting otherwise.
+func (o *Orchestrator) Status() string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	status := StatusReady
+	for _, s := range o.states {
+		if s.Status == StatusFailed {
+			return StatusFailed
+		}
+		if s.Status != StatusReady {
+			status = StatusStarting
+		}
+	}
+	return status
+}
+
+// States returns the current state of all steps in their startup order.
+func (o *Orchestrator) States() []StepState {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	states := make([]StepState, len(o.states))
+	copy(states, o.states)
+	return states
+}
+
+func (o *Orchestrator) setState(i int, status string, attempts int, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.states[i].Status = status
+	o.states[i].Attempts = attempts
+	o.states[i].Error = ""
+	if err != nil {
+		o.states[i].Error = err.Error()
+	}
+}
+
+// Retry calls fn until it succeeds or backoff.Attempts are exhausted, returning the last error.
+func Retry(name string, backoff Backoff, fn func() error) error {
+	return retry(backoff, time.Sleep, name, nil, fn)
+}
+
+func retry(backoff Backoff, sleep func(time.Duration), name string, onAttempt func(int, error), fn func() error) error {
+	delay := backoff.InitialInterval
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if onAttempt != nil {
+			onAttempt(attempt, err)
+		}
+		if err == nil {
+			return nil
+		}
+		if attempt >= backoff.Attempts {
+			return err
+		}
+		logger.WithFields(logrus.Fields{"step": name, "attempt": attempt}).Warnf("%v failed, retrying in %v: %v", name, delay, err)
+		sleep(delay)
+		delay *= 2
+		if backoff.MaxInterval > 0 && delay > backoff.MaxInterval {
+			delay = backoff.MaxInterval
+		}
+	}
+}
+
+type readyzResponse struct {
+	Status string      `json:"status"`
+	Steps  []StepState `json:"steps"`
+}
+
+// HandleReadyz reports startup state, responding with 503 until all steps are up.
+func HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	rsp := readyzResponse{Status: StatusReady, Steps: []StepState{}}
+	status := http.StatusOK
+	if o := getDefault(); o != nil {
+		rsp.Steps = o.States()
+		rsp.Status = o.Status()
+		if rsp.Status != StatusReady {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if err := responses.WriteJSON(w, status, rsp); err != nil {
+		logger.Log().Error(err)
+	}
+}
@@ -0,0 +1,80 @@
+package startup
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestrator_Run(t *testing.T) {
+	var order []string
+	dbAttempts := 0
+	o := New(
+		Backoff{Attempts: 5, InitialInterval: time.Second, MaxInterval: 3 * time.Second},
+		Step{Name: "config", Run: func() error { order = append(order, "config"); return nil }},
+		Step{Name: "db", Run: func() error {
+			order = append(order, "db")
+			dbAttempts++
+			if dbAttempts < 4 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}},
+		Step{Name: "http", Run: func() error { order = append(order, "http"); return nil }},
+	)
+	var delays []time.Duration
+	o.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	assert.Equal(t, StatusPending, o.States()[0].Status)
+	assert.False(t, o.Ready())
+
+	require.NoError(t, o.Run())
+	assert.Equal(t, []string{"config", "db", "db", "db", "db", "http"}, order)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, delays)
+	assert.True(t, o.Ready())
+	assert.Equal(t, StepState{Name: "db", Status: StatusReady, Attempts: 4}, o.States()[1])
+}
+
+func TestOrchestrator_RunFails(t *testing.T) {
+	httpStarted := false
+	o := New(
+		Backoff{Attempts: 2},
+		Step{Name: "db", Run: func() error { return errors.New("connection refused") }},
+		Step{Name: "http", Run: func() error { httpStarted = true; return nil }},
+	)
+	o.sleep = func(time.Duration) {}
+
+	err := o.Run()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "startup step db failed")
+	assert.False(t, httpStarted)
+	assert.Equal(t, StatusFailed, o.Status())
+	assert.Equal(t, StepState{Name: "db", Status: StatusFailed, Attempts: 2, Error: "connection refused"}, o.States()[0])
+	assert.Equal(t, StatusPending, o.States()[1].Status)
+}
+
+func TestHandleReadyz(t *testing.T) {
+	o := New(Backoff{}, Step{Name: "db", Run: func() error { return nil }})
+	SetDefault(o)
+	defer SetDefault(nil)
+
+	rr := httptest.NewRecorder()
+	HandleReadyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+
+	require.NoError(t, o.Run())
+	rr = httptest.NewRecorder()
+	HandleReadyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var rsp readyzResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+	assert.Equal(t, StatusReady, rsp.Status)
+	assert.Equal(t, []StepState{{Name: "db", Status: StatusReady, Attempts: 1}}, rsp.Steps)
+}
@@ -0,0 +1,230 @@
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/canary"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
+	"github.com/lbryio/lbrytv/internal/responses"
+	"github.com/lbryio/lbrytv/internal/slo"
+	"github.com/lbryio/lbrytv/internal/storage"
+)
+
+const (
+	ComponentUp       = "up"
+	ComponentDegraded = "degraded"
+	ComponentDown     = "down"
+
+	ComponentAPI        = "api"
+	ComponentPublishing = "publishing"
+	ComponentStreaming  = "streaming"
+	ComponentWallet     = "wallet"
+
+	// componentWindow is the SLO window component status is derived from.
+	componentWindow = "5m"
+	// minComponentEvents is the number of requests below which SLIs are too noisy to tell anything.
+	minComponentEvents = 20
+	// degradedBurnRate is how many times over the error budget requests have to fail or be slow
+	// for a component to be reported as degraded, so short blips don't make it to users.
+	degradedBurnRate = 10
+	// downAvailability is the share of successful requests below which a component is reported as down.
+	downAvailability = 0.5
+
+	playerCheckTimeout = 5 * time.Second
+)
+
+// statusRank orders component statuses from best to worst.
+var statusRank = map[string]int{ComponentUp: 0, ComponentDegraded: 1, ComponentDown: 2}
+
+// checkPlayer returns true if a player server is responding, it's a variable so tests can replace it.
+var checkPlayer = func(url string) bool {
+	r, err := (&http.Client{Timeout: playerCheckTimeout}).Get(url)
+	if err != nil {
+		return false
+	}
+	r.Body.Close()
+	return r.StatusCode == http.StatusNotFound
+}
+
+func worst(statuses ...string) string {
+	w := ComponentUp
+	for _, s := range statuses {
+		if statusRank[s] > statusRank[w] {
+			w = s
+		}
+	}
+	return w
+}
+
+// health is the internal health data component status is derived from.
+type health struct {
+	slis       map[string]slo.SLI
+	objectives map[string]config.SLO
+	probes     []canary.Result
+	nodes      []sdkrouter.ServerStatus
+	dbDown     bool
+	// players holds whether each of the player servers is responding.
+	players []bool
+}
+
+func (h health) class(class string) string {
+	sli, ok := h.slis[class]
+	if !ok || sli.Total < minComponentEvents {
+		return ComponentUp
+	}
+	if sli.Availability < downAvailability {
+		return ComponentDown
+	}
+	o := h.objectives[class]
+	if 1-sli.Availability > degradedBurnRate*(1-o.Availability) || 1-sli.Latency > degradedBurnRate*(1-o.LatencyTarget) {
+		return ComponentDegraded
+	}
+	return ComponentUp
+}
+
+// probe reports a component as degraded if its canary is failing, since a single synthetic request
+// failing doesn't mean users can't get through.
+func (h health) probe(name string) string {
+	for _, p := range h.probes {
+		if p.Name == name && p.Status == canary.StatusFailing {
+			return ComponentDegraded
+		}
+	}
+	return ComponentUp
+}
+
+func (h health) sdkNodes() string {
+	checked, responding := 0, 0
+	for _, n := range h.nodes {
+		if n.CheckedAt.IsZero() {
+			continue
+		}
+		checked++
+		if n.Responding {
+			responding++
+		}
+	}
+	switch {
+	case checked > 0 && responding == 0:
+		return ComponentDown
+	case responding < checked:
+		return ComponentDegraded
+	}
+	return ComponentUp
+}
+
+func (h health) db() string {
+	if h.dbDown {
+		return ComponentDown
+	}
+	return ComponentUp
+}
+
+func (h health) streaming() string {
+	failing := 0
+	for _, ok := range h.players {
+		if !ok {
+			failing++
+		}
+	}
+	switch {
+	case len(h.players) > 0 && failing == len(h.players):
+		return ComponentDown
+	case failing > 0:
+		return ComponentDegraded
+	}
+	return ComponentUp
+}
+
+func (h health) components() map[string]string {
+	return map[string]string{
+		ComponentAPI:        worst(h.class(slo.ClassRead), h.probe(canary.ProbeResolve), h.sdkNodes()),
+		ComponentPublishing: worst(h.class(slo.ClassPublish), h.probe(canary.ProbePublish), h.db()),
+		ComponentWallet:     worst(h.class(slo.ClassWallet), h.sdkNodes(), h.db()),
+		ComponentStreaming:  h.streaming(),
+	}
+}
+
+type componentsResponse struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// ComponentsHandler reports whether user-facing components are up, degraded or down,
+// so the frontend can show a banner during incidents. Status is derived from internal health data
+// and served from memory for TTL, it is also cacheable by clients and proxies for that long.
+type ComponentsHandler struct {
+	Router *sdkrouter.Router
+	TTL    time.Duration
+
+	mu     sync.Mutex
+	cached *componentsResponse
+}
+
+// NewComponentsHandler creates a handler reporting SDK nodes of rt among other components.
+func NewComponentsHandler(rt *sdkrouter.Router, ttl time.Duration) *ComponentsHandler {
+	return &ComponentsHandler{Router: rt, TTL: ttl}
+}
+
+func (h *ComponentsHandler) collect() health {
+	hh := health{
+		slis:       slo.SLIs(slo.Windows[componentWindow]),
+		objectives: slo.Objectives(),
+		dbDown:     storage.Conn.BreakerOpen(),
+		players:    make([]bool, len(PlayerServers)),
+	}
+	if h.Router != nil {
+		hh.nodes = h.Router.Statuses()
+	}
+	if r := canary.Current(); r != nil {
+		hh.probes = r.Results()
+	}
+	var wg sync.WaitGroup
+	for i, ps := range PlayerServers {
+		wg.Add(1)
+		go func(i int, ps string) {
+			defer wg.Done()
+			hh.players[i] = checkPlayer(ps)
+		}(i, ps)
+	}
+	wg.Wait()
+	return hh
+}
+
+func (h *ComponentsHandler) get() componentsResponse {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cached != nil && time.Since(h.cached.UpdatedAt) < h.TTL {
+		return *h.cached
+	}
+
+	components := h.collect().components()
+	rsp := &componentsResponse{Status: ComponentUp, Components: components, UpdatedAt: time.Now().UTC()}
+	for _, s := range components {
+		rsp.Status = worst(rsp.Status, s)
+	}
+	if rsp.Status != ComponentUp {
+		logger.Log().Warnf("reporting components status as %v: %v", rsp.Status, components)
+	}
+	h.cached = rsp
+	return *rsp
+}
+
+// Handle responds with the current status of components. It requires no authentication.
+func (h *ComponentsHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	rsp := h.get()
+	maxAge := h.TTL - time.Since(rsp.UpdatedAt)
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := responses.WriteJSON(w, http.StatusOK, rsp); err != nil {
+		logger.Log().Error(err)
+	}
+}
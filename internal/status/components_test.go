@@ -0,0 +1,122 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/app/canary"
+	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/internal/slo"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthComponents(t *testing.T) {
+	checked := time.Now()
+	healthy := func() health {
+		return health{
+			slis: map[string]slo.SLI{
+				slo.ClassRead:    {Total: 1000, Availability: 0.9995, Latency: 0.995},
+				slo.ClassWallet:  {Total: 100, Availability: 1, Latency: 1},
+				slo.ClassPublish: {Total: 5, Availability: 0, Latency: 0},
+			},
+			objectives: slo.Objectives(),
+			probes:     []canary.Result{{Name: canary.ProbeResolve, Status: canary.StatusOK}},
+			nodes: []sdkrouter.ServerStatus{
+				{Name: "sdk1", Responding: true, CheckedAt: checked},
+				{Name: "sdk2", Responding: true, CheckedAt: checked},
+				{Name: "sdk3"},
+			},
+			players: []bool{true, true},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		mutate   func(h *health)
+		expected map[string]string
+	}{
+		{"healthy", func(h *health) {}, map[string]string{}},
+		{"read errors over budget", func(h *health) {
+			h.slis[slo.ClassRead] = slo.SLI{Total: 1000, Availability: 0.95, Latency: 1}
+		}, map[string]string{ComponentAPI: ComponentDegraded}},
+		{"wallet calls mostly failing", func(h *health) {
+			h.slis[slo.ClassWallet] = slo.SLI{Total: 100, Availability: 0.2, Latency: 1}
+		}, map[string]string{ComponentWallet: ComponentDown}},
+		{"resolve probe failing", func(h *health) {
+			h.probes[0].Status = canary.StatusFailing
+		}, map[string]string{ComponentAPI: ComponentDegraded}},
+		{"sdk node unresponsive", func(h *health) {
+			h.nodes[0].Responding = false
+		}, map[string]string{ComponentAPI: ComponentDegraded, ComponentWallet: ComponentDegraded}},
+		{"all sdk nodes unresponsive", func(h *health) {
+			h.nodes[0].Responding, h.nodes[1].Responding = false, false
+		}, map[string]string{ComponentAPI: ComponentDown, ComponentWallet: ComponentDown}},
+		{"db unavailable", func(h *health) {
+			h.dbDown = true
+		}, map[string]string{ComponentPublishing: ComponentDown, ComponentWallet: ComponentDown}},
+		{"player failing", func(h *health) {
+			h.players[1] = false
+		}, map[string]string{ComponentStreaming: ComponentDegraded}},
+		{"all players failing", func(h *health) {
+			h.players = []bool{false, false}
+		}, map[string]string{ComponentStreaming: ComponentDown}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := healthy()
+			c.mutate(&h)
+			components := h.components()
+			for _, name := range []string{ComponentAPI, ComponentPublishing, ComponentStreaming, ComponentWallet} {
+				expected := c.expected[name]
+				if expected == "" {
+					expected = ComponentUp
+				}
+				assert.Equal(t, expected, components[name], name)
+			}
+		})
+	}
+}
+
+func TestComponentsHandler(t *testing.T) {
+	var playersUp, checks int32 = 1, 0
+	origCheckPlayer := checkPlayer
+	defer func() { checkPlayer = origCheckPlayer }()
+	checkPlayer = func(string) bool {
+		atomic.AddInt32(&checks, 1)
+		return atomic.LoadInt32(&playersUp) == 1
+	}
+
+	h := NewComponentsHandler(nil, time.Minute)
+	get := func() (*httptest.ResponseRecorder, componentsResponse) {
+		rr := httptest.NewRecorder()
+		h.Handle(rr, httptest.NewRequest(http.MethodGet, "/api/v1/status/components", nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		var rsp componentsResponse
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &rsp))
+		return rr, rsp
+	}
+
+	rr, rsp := get()
+	assert.Equal(t, ComponentUp, rsp.Status)
+	assert.Equal(t, ComponentUp, rsp.Components[ComponentStreaming])
+	assert.Equal(t, "public, max-age=59", rr.Header().Get("Cache-Control"))
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.EqualValues(t, len(PlayerServers), atomic.LoadInt32(&checks))
+
+	// Served from memory until TTL passes
+	atomic.StoreInt32(&playersUp, 0)
+	_, rsp = get()
+	assert.Equal(t, ComponentUp, rsp.Status)
+	assert.EqualValues(t, len(PlayerServers), atomic.LoadInt32(&checks))
+
+	h.TTL = 0
+	_, rsp = get()
+	assert.Equal(t, ComponentDown, rsp.Status)
+	assert.Equal(t, ComponentDown, rsp.Components[ComponentStreaming])
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+	"github.com/lbryio/lbrytv/internal/metrics"
+
+	"github.com/lib/pq"
+)
+
+// ErrUnavailable is returned by Connection.Do when the DB is unreachable or too saturated to answer in time.
+var ErrUnavailable = errors.Base("storage unavailable")
+
+// BreakerConfig defines when Connection.Do stops sending calls to the DB.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive calls failing with ErrUnavailable that opens the breaker.
+	// The breaker is disabled when it's zero.
+	Threshold int
+	// Cooldown is how long calls fail right away once the breaker is open,
+	// after that a single call is let through to check if the DB has recovered.
+	Cooldown time.Duration
+	// Timeout limits each call, including the time spent waiting for a pooled connection.
+	Timeout time.Duration
+}
+
+type breaker struct {
+	cfg BreakerConfig
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(cfg BreakerConfig) *breaker {
+	return &breaker{cfg: cfg}
+}
+
+func (b *breaker) allow() bool {
+	if b == nil || b.cfg.Threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.cfg.Threshold {
+		return true
+	}
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+func (b *breaker) record(failed bool) {
+	if b == nil || b.cfg.Threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if !failed {
+		if b.failures >= b.cfg.Threshold {
+			dbLogger.Log().Info("DB breaker closed")
+			metrics.DBBreakerOpen.Set(0)
+		}
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.cfg.Threshold {
+		if b.failures == b.cfg.Threshold {
+			dbLogger.Log().Warnf("DB breaker opened after %v failed calls", b.failures)
+			metrics.DBBreakerOpen.Set(1)
+		}
+		b.openUntil = time.Now().Add(b.cfg.Cooldown)
+	}
+}
+
+// isUnavailable returns true for errors caused by the DB being down, overloaded or not answering in time,
+// as opposed to errors in the queries themselves.
+func isUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) {
+		// Connection exceptions, insufficient resources, statement timeouts and server shutdowns
+		return pgErr.Code.Class() == "08" || pgErr.Code.Class() == "53" ||
+			pgErr.Code == "57014" || strings.HasPrefix(string(pgErr.Code), "57P")
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// BreakerOpen returns true if DB calls are currently failing fast.
+func (c *Connection) BreakerOpen() bool {
+	if c == nil || c.breaker == nil || c.breaker.cfg.Threshold <= 0 {
+		return false
+	}
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.failures >= c.breaker.cfg.Threshold
+}
+
+// Do calls f with a context limited to the breaker timeout. Failures caused by the DB being unavailable
+// are returned wrapping ErrUnavailable, and once enough of them happen in a row,
+// calls fail with ErrUnavailable right away instead of piling up on the connection pool.
+// f is called directly if c is nil, which is the case in tests not touching the DB.
+func (c *Connection) Do(ctx context.Context, f func(context.Context) error) error {
+	if c == nil {
+		return f(ctx)
+	}
+	if !c.breaker.allow() {
+		metrics.DBBreakerRejected.Inc()
+		return errors.Err(ErrUnavailable)
+	}
+	if t := c.params.Breaker.Timeout; t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	err := f(ctx)
+	unavailable := isUnavailable(err)
+	c.breaker.record(unavailable)
+	if unavailable {
+		return errors.Err("%w: %v", ErrUnavailable, err)
+	}
+	return err
+}
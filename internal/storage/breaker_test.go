@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnection_DoBreaker(t *testing.T) {
+	c := InitConn(ConnParams{Breaker: BreakerConfig{Threshold: 2, Cooldown: 50 * time.Millisecond, Timeout: 10 * time.Millisecond}})
+	calls := 0
+	hang := func(ctx context.Context) error {
+		calls++
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	// Query errors don't count towards opening the breaker
+	err := c.Do(context.Background(), func(context.Context) error { return &pq.Error{Code: "23505"} })
+	assert.False(t, errors.Is(err, ErrUnavailable))
+
+	for i := 0; i < 2; i++ {
+		err = c.Do(context.Background(), hang)
+		assert.True(t, errors.Is(err, ErrUnavailable))
+	}
+	err = c.Do(context.Background(), hang)
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.Equal(t, 2, calls, "breaker should be open")
+
+	time.Sleep(60 * time.Millisecond)
+	assert.NoError(t, c.Do(context.Background(), func(context.Context) error { return nil }))
+	assert.NoError(t, c.Do(context.Background(), func(context.Context) error { return nil }))
+}
+
+func TestIsUnavailable(t *testing.T) {
+	assert.True(t, isUnavailable(&pq.Error{Code: "53300"}))
+	assert.True(t, isUnavailable(errors.Err(&pq.Error{Code: "57014"})))
+	assert.True(t, isUnavailable(&pq.Error{Code: "08006"}))
+	assert.False(t, isUnavailable(&pq.Error{Code: "23505"}))
+	assert.False(t, isUnavailable(context.Canceled))
+	assert.False(t, isUnavailable(nil))
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
@@ -26,6 +27,7 @@ type Connection struct {
 	dialect string
 	params  ConnParams
 	logger  monitor.ModuleLogger
+	breaker *breaker
 }
 
 // ConnParams are accepted by InitConn, containing database server parameters.
@@ -34,6 +36,15 @@ type ConnParams struct {
 	DBName         string
 	Options        string
 	MigrationsPath string
+
+	// MaxOpenConns, MaxIdleConns and ConnMaxLifetime configure the connection pool,
+	// zero values keep database/sql defaults.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// StatementTimeout makes the DB server cancel statements running longer than that.
+	StatementTimeout time.Duration
+	Breaker          BreakerConfig
 }
 
 // Conn holds a global database connection.
@@ -41,11 +52,21 @@ var Conn *Connection
 
 // MakeDSN generates DSN string from ConnParams.
 func MakeDSN(params ConnParams) string {
+	options := params.Options
+	if params.StatementTimeout > 0 {
+		// Unknown DSN options are passed by lib/pq to the server as session settings
+		timeout := fmt.Sprintf("statement_timeout=%d", params.StatementTimeout.Milliseconds())
+		if options == "" {
+			options = timeout
+		} else {
+			options += "&" + timeout
+		}
+	}
 	return fmt.Sprintf(
 		"%v/%v?%v",
 		params.Connection,
 		params.DBName,
-		params.Options,
+		options,
 	)
 }
 
@@ -55,6 +76,7 @@ func InitConn(params ConnParams) *Connection {
 		dialect: "postgres",
 		logger:  monitor.NewModuleLogger("storage"),
 		params:  params,
+		breaker: newBreaker(params.Breaker),
 	}
 	return c
 }
@@ -63,9 +85,19 @@ func InitConn(params ConnParams) *Connection {
 func (c *Connection) Connect() error {
 	dsn := MakeDSN(c.params)
 	c.logger.WithFields(logrus.Fields{"dsn": dsn}).Info("connecting to the DB")
-	var err error
-	db, err := sqlx.Connect(c.dialect, dsn)
+	rawDB, err := sql.Open(instrumentedDriverName, dsn)
 	if err != nil {
+		return err
+	}
+	// sqlx picks placeholder syntax by driver name, so it's given the dialect instead of the instrumented driver
+	db := sqlx.NewDb(rawDB, c.dialect)
+	db.SetMaxOpenConns(c.params.MaxOpenConns)
+	if c.params.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(c.params.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(c.params.ConnMaxLifetime)
+	if err := db.Ping(); err != nil {
+		db.Close()
 		c.logger.WithFields(logrus.Fields{"dsn": dsn}).Info("DB connection failed")
 		return err
 	}
@@ -79,6 +111,7 @@ func (c *Connection) Connect() error {
 func (c *Connection) SetDefaultConnection() {
 	boil.SetDB(c.DB)
 	Conn = c
+	explainDB = c.DB.DB
 }
 
 // Close terminates the database server connection.
@@ -106,5 +139,6 @@ func (c *Connection) WatchMetrics(interval time.Duration) {
 		metrics.LbrytvDBOpenConnections.Set(float64(stats.OpenConnections))
 		metrics.LbrytvDBInUseConnections.Set(float64(stats.InUse))
 		metrics.LbrytvDBIdleConnections.Set(float64(stats.Idle))
+		metrics.LbrytvDBWaitCount.Set(float64(stats.WaitCount))
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/lbryio/lbry.go/v2/extras/crypto"
 	"github.com/lbryio/lbrytv/apps/lbrytv/config"
@@ -87,4 +88,8 @@ func TestMakeDSN(t *testing.T) {
 		MakeDSN(ConnParams{Connection: "postgres://pg:pg@db", DBName: "test", Options: "sslmode=disable"}),
 		"postgres://pg:pg@db/test?sslmode=disable",
 	)
+	assert.Equal(t,
+		MakeDSN(ConnParams{Connection: "postgres://pg:pg@db", DBName: "test", Options: "sslmode=disable", StatementTimeout: 5 * time.Second}),
+		"postgres://pg:pg@db/test?sslmode=disable&statement_timeout=5000",
+	)
 }
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/metrics"
+	"github.com/lbryio/lbrytv/internal/monitor"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// instrumentedDriverName is the database/sql driver wrapping lib/pq with query timing,
+// used for all connections made by Connect.
+const instrumentedDriverName = "postgres-instrumented"
+
+// maxTxStatements is how many statements are listed in slow transaction warnings.
+const maxTxStatements = 10
+
+var (
+	dbLogger = monitor.NewModuleLogger("storage")
+
+	reTable = regexp.MustCompile(`(?i)\b(?:from|into|update|join)\s+"?([a-z0-9_]+)"?`)
+
+	instrumentationMu sync.RWMutex
+	instrumentation   = Instrumentation{
+		SlowQueryThreshold:       250 * time.Millisecond,
+		SlowTransactionThreshold: time.Second,
+	}
+	// explainDB is where EXPLAIN for slow queries is run, see Instrumentation.ExplainSlowQueries.
+	explainDB *sql.DB
+)
+
+// Instrumentation defines when DB queries and transactions are reported as slow.
+type Instrumentation struct {
+	SlowQueryThreshold       time.Duration
+	SlowTransactionThreshold time.Duration
+	// ExplainSlowQueries makes slow query warnings include the query plan. It runs an extra query
+	// for every slow SELECT, so it's meant for staging.
+	ExplainSlowQueries bool
+}
+
+func init() {
+	sql.Register(instrumentedDriverName, instrumentedDriver{&pq.Driver{}})
+}
+
+// SetInstrumentation changes slow query and transaction reporting settings.
+func SetInstrumentation(i Instrumentation) {
+	instrumentationMu.Lock()
+	defer instrumentationMu.Unlock()
+	instrumentation = i
+}
+
+func getInstrumentation() Instrumentation {
+	instrumentationMu.RLock()
+	defer instrumentationMu.RUnlock()
+	return instrumentation
+}
+
+// queryLabels returns the operation and the first table of a query for metric labels.
+func queryLabels(query string) (string, string) {
+	op := "other"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		switch kw := strings.ToLower(fields[0]); kw {
+		case "select", "insert", "update", "delete":
+			op = kw
+		}
+	}
+	table := "unknown"
+	if m := reTable.FindStringSubmatch(query); m != nil {
+		table = strings.ToLower(m[1])
+	}
+	return op, table
+}
+
+type instrumentedDriver struct {
+	driver.Driver
+}
+
+func (d instrumentedDriver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.Driver.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedConn{Conn: c}, nil
+}
+
+// instrumentedConn times queries and transactions. database/sql never uses a connection concurrently,
+// so the current transaction is tracked without locking.
+type instrumentedConn struct {
+	driver.Conn
+	tx *instrumentedTx
+}
+
+type instrumentedTx struct {
+	driver.Tx
+	conn       *instrumentedConn
+	start      time.Time
+	statements []string
+	queries    int
+}
+
+func (c *instrumentedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var (
+		tx  driver.Tx
+		err error
+	)
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.tx = &instrumentedTx{Tx: tx, conn: c, start: time.Now()}
+	return c.tx, nil
+}
+
+func (t *instrumentedTx) Commit() error {
+	err := t.Tx.Commit()
+	t.end(metrics.DBTransactionCommit)
+	return err
+}
+
+func (t *instrumentedTx) Rollback() error {
+	err := t.Tx.Rollback()
+	t.end(metrics.DBTransactionRollback)
+	return err
+}
+
+func (t *instrumentedTx) end(result string) {
+	t.conn.tx = nil
+	duration := time.Since(t.start)
+	metrics.DBTransactionDurations.WithLabelValues(result).Observe(duration.Seconds())
+	if threshold := getInstrumentation().SlowTransactionThreshold; threshold > 0 && duration > threshold {
+		metrics.DBSlowTransactions.Inc()
+		dbLogger.WithFields(logrus.Fields{
+			"duration":   duration.Seconds(),
+			"result":     result,
+			"queries":    t.queries,
+			"statements": t.statements,
+		}).Warn("slow DB transaction")
+	}
+}
+
+func (c *instrumentedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		c.observe(query, args, start)
+		return nil, err
+	}
+	// Rows are streamed, so the query is finished when they're closed
+	return &instrumentedRows{Rows: rows, conn: c, query: query, args: args, start: start}, nil
+}
+
+func (c *instrumentedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, query, args)
+	c.observe(query, args, start)
+	return res, err
+}
+
+func (c *instrumentedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *instrumentedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *instrumentedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func (c *instrumentedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// instrumentedStmt times executions of prepared statements. Only the context-aware methods are used
+// by database/sql when they're implemented, which they are by lib/pq.
+type instrumentedStmt struct {
+	driver.Stmt
+	conn  *instrumentedConn
+	query string
+}
+
+func (s *instrumentedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	res, err := e.ExecContext(ctx, args)
+	s.conn.observe(s.query, args, start)
+	return res, err
+}
+
+func (s *instrumentedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, args)
+	if err != nil {
+		s.conn.observe(s.query, args, start)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, conn: s.conn, query: s.query, args: args, start: start}, nil
+}
+
+type instrumentedRows struct {
+	driver.Rows
+	conn   *instrumentedConn
+	query  string
+	args   []driver.NamedValue
+	start  time.Time
+	closed bool
+}
+
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		r.conn.observe(r.query, r.args, r.start)
+	}
+	return err
+}
+
+func (r *instrumentedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *instrumentedRows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(interface{})).Elem()
+}
+
+func (r *instrumentedRows) HasNextResultSet() bool {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *instrumentedRows) NextResultSet() error {
+	if n, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return n.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (c *instrumentedConn) observe(query string, args []driver.NamedValue, start time.Time) {
+	duration := time.Since(start)
+	op, table := queryLabels(query)
+	metrics.DBQueryDurations.WithLabelValues(op, table).Observe(duration.Seconds())
+
+	if c.tx != nil {
+		c.tx.queries++
+		if len(c.tx.statements) < maxTxStatements {
+			c.tx.statements = append(c.tx.statements, query)
+		}
+	}
+
+	i := getInstrumentation()
+	if i.SlowQueryThreshold <= 0 || duration <= i.SlowQueryThreshold || strings.HasPrefix(query, "EXPLAIN") {
+		return
+	}
+	metrics.DBSlowQueries.WithLabelValues(op, table).Inc()
+	log := dbLogger.WithFields(logrus.Fields{"duration": duration.Seconds(), "query": query, "in_tx": c.tx != nil})
+	if i.ExplainSlowQueries && op == "select" && explainDB != nil {
+		// Args are copied as the driver may reuse them once the query returns
+		values := make([]interface{}, len(args))
+		for n, a := range args {
+			values[n] = a.Value
+		}
+		go explain(log, query, values)
+		return
+	}
+	log.Warn("slow DB query")
+}
+
+// explain logs a slow query warning with the query plan. The plan is obtained on a separate connection
+// so it doesn't interfere with a transaction the query might be a part of.
+func explain(log *logrus.Entry, query string, args []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows, err := explainDB.QueryContext(ctx, "EXPLAIN "+query, args...)
+	if err != nil {
+		log.Warnf("slow DB query (EXPLAIN failed: %v)", err)
+		return
+	}
+	defer rows.Close()
+	plan := []string{}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			break
+		}
+		plan = append(plan, line)
+	}
+	log.WithField("plan", strings.Join(plan, "\n")).Warn("slow DB query")
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryLabels(t *testing.T) {
+	for _, c := range []struct{ query, op, table string }{
+		{`SELECT "users".* FROM "users" WHERE ("users"."id" = $1) LIMIT 1;`, "select", "users"},
+		{`INSERT INTO "uploads" ("id","user_id") VALUES ($1,$2)`, "insert", "uploads"},
+		{`update lbrynet_servers set weight = $1`, "update", "lbrynet_servers"},
+		{`DELETE FROM jobs WHERE id = $1`, "delete", "jobs"},
+		{"  select count(*) from users u join uploads p on p.user_id = u.id", "select", "users"},
+		{"BEGIN", "other", "unknown"},
+	} {
+		op, table := queryLabels(c.query)
+		assert.Equal(t, c.op, op, c.query)
+		assert.Equal(t, c.table, table, c.query)
+	}
+}
+
+func TestConnection_Instrumented(t *testing.T) {
+	tx, err := testConn.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tx.Query("SELECT 1")
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	assert.NoError(t, tx.Rollback())
+	_, ok := testConn.DB.Driver().(instrumentedDriver)
+	assert.True(t, ok)
+}
@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "channel_delegations" (
+    "id" SERIAL PRIMARY KEY,
+    "channel_id" varchar NOT NULL,
+    "owner_id" uinteger NOT NULL,
+    "delegate_id" uinteger NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+
+    UNIQUE ("channel_id", "delegate_id")
+);
+CREATE INDEX channel_delegations_delegate_id_idx ON channel_delegations(delegate_id);
+CREATE INDEX channel_delegations_owner_id_idx ON channel_delegations(owner_id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "channel_delegations";
+-- +migrate StatementEnd
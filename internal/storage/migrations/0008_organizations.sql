@@ -0,0 +1,66 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "organizations" (
+    "id" SERIAL PRIMARY KEY,
+    "name" varchar NOT NULL UNIQUE,
+    "upload_quota" bigint NOT NULL DEFAULT 0,
+    "upload_used" bigint NOT NULL DEFAULT 0,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "updated_at" timestamp NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_members" (
+    "id" SERIAL PRIMARY KEY,
+    "organization_id" integer NOT NULL,
+    "user_id" uinteger NOT NULL UNIQUE,
+    "role" varchar NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX organization_members_organization_id_idx ON organization_members(organization_id);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_drafts" (
+    "id" SERIAL PRIMARY KEY,
+    "organization_id" integer NOT NULL,
+    "user_id" uinteger NOT NULL,
+    "title" varchar NOT NULL,
+    "params" jsonb,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "updated_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX organization_drafts_organization_id_idx ON organization_drafts(organization_id);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_api_keys" (
+    "id" SERIAL PRIMARY KEY,
+    "organization_id" integer NOT NULL,
+    "user_id" uinteger NOT NULL,
+    "name" varchar NOT NULL,
+    "key_hash" varchar NOT NULL UNIQUE,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX organization_api_keys_organization_id_idx ON organization_api_keys(organization_id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "organization_api_keys";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+DROP TABLE "organization_drafts";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+DROP TABLE "organization_members";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+DROP TABLE "organizations";
+-- +migrate StatementEnd
@@ -0,0 +1,27 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "quarantined_files" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" uinteger NOT NULL,
+    "file_name" varchar NOT NULL,
+    "path" varchar NOT NULL,
+    "size" bigint NOT NULL,
+    "sha256" varchar NOT NULL,
+    "source" varchar NOT NULL,
+    "reason" text NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "reviewed_by" varchar,
+    "review_note" text,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "reviewed_at" timestamp
+);
+CREATE INDEX quarantined_files_status_idx ON quarantined_files(status);
+CREATE INDEX quarantined_files_user_id_idx ON quarantined_files(user_id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "quarantined_files";
+-- +migrate StatementEnd
@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+ALTER TABLE "query_log" ADD COLUMN "deleted_at" timestamp;
+CREATE INDEX query_log_deleted_at_idx ON query_log(deleted_at);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+ALTER TABLE "quarantined_files" ADD COLUMN "deleted_at" timestamp;
+CREATE INDEX quarantined_files_deleted_at_idx ON quarantined_files(deleted_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+ALTER TABLE "quarantined_files" DROP COLUMN "deleted_at";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+ALTER TABLE "query_log" DROP COLUMN "deleted_at";
+-- +migrate StatementEnd
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "wallet_loads" (
+    "sdk_address" varchar NOT NULL,
+    "wallet_id" varchar NOT NULL,
+    "loaded_at" timestamp NOT NULL,
+    PRIMARY KEY ("sdk_address", "wallet_id")
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "wallet_loads";
+-- +migrate StatementEnd
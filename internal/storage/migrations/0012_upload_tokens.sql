@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "upload_tokens" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" uinteger NOT NULL,
+    "token_hash" varchar NOT NULL UNIQUE,
+    "max_size" bigint NOT NULL,
+    "content_types" varchar NOT NULL DEFAULT '',
+    "expires_at" timestamp NOT NULL,
+    "used_at" timestamp,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX upload_tokens_expires_at_idx ON upload_tokens(expires_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "upload_tokens";
+-- +migrate StatementEnd
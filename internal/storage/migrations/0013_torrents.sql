@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "torrents" (
+    "id" SERIAL PRIMARY KEY,
+    "claim_id" varchar NOT NULL UNIQUE,
+    "user_id" uinteger NOT NULL,
+    "info_hash" varchar NOT NULL,
+    "file_name" varchar NOT NULL,
+    "size" bigint NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'seeding',
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX torrents_status_idx ON torrents(status);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "torrents";
+-- +migrate StatementEnd
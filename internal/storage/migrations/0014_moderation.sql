@@ -0,0 +1,43 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "moderation_cases" (
+    "id" SERIAL PRIMARY KEY,
+    "claim_id" varchar NOT NULL,
+    "claim_name" varchar NOT NULL DEFAULT '',
+    "uploader_id" uinteger,
+    "reason" varchar NOT NULL,
+    "flagged_by" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'flagged',
+    "reviewed_by" varchar,
+    "note" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "updated_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX moderation_cases_status_idx ON moderation_cases(status);
+CREATE INDEX moderation_cases_claim_id_idx ON moderation_cases(claim_id);
+
+CREATE TABLE "moderation_actions" (
+    "id" SERIAL PRIMARY KEY,
+    "case_id" integer NOT NULL REFERENCES moderation_cases(id) ON DELETE CASCADE,
+    "action" varchar NOT NULL,
+    "actor" varchar NOT NULL,
+    "details" jsonb,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX moderation_actions_case_id_idx ON moderation_actions(case_id);
+
+CREATE TABLE "blocked_claims" (
+    "claim_id" varchar PRIMARY KEY,
+    "case_id" integer NOT NULL REFERENCES moderation_cases(id),
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "blocked_claims";
+DROP TABLE "moderation_actions";
+DROP TABLE "moderation_cases";
+-- +migrate StatementEnd
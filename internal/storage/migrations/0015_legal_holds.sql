@@ -0,0 +1,25 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "legal_holds" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" uinteger,
+    "claim_id" varchar,
+    "country" varchar(2) NOT NULL,
+    "reference" varchar NOT NULL,
+    "placed_by" varchar NOT NULL,
+    "note" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "released_at" timestamp,
+    "released_by" varchar,
+    CHECK ("user_id" IS NOT NULL OR "claim_id" IS NOT NULL)
+);
+CREATE INDEX legal_holds_user_id_idx ON legal_holds(user_id) WHERE released_at IS NULL;
+CREATE INDEX legal_holds_claim_id_idx ON legal_holds(claim_id) WHERE released_at IS NULL;
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "legal_holds";
+-- +migrate StatementEnd
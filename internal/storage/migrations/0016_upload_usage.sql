@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "upload_usage" (
+    "user_id" uinteger NOT NULL,
+    "day" date NOT NULL,
+    "bytes" bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY ("user_id", "day")
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "upload_usage";
+-- +migrate StatementEnd
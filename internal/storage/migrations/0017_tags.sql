@@ -0,0 +1,19 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "tags" (
+    "name" varchar PRIMARY KEY,
+    "canonical" varchar REFERENCES "tags" ("name") ON DELETE CASCADE,
+    "banned" boolean NOT NULL DEFAULT false,
+    "uses" integer NOT NULL DEFAULT 0,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    CHECK ("canonical" IS NULL OR NOT "banned")
+);
+CREATE INDEX tags_name_prefix_idx ON tags(name varchar_pattern_ops) WHERE canonical IS NULL AND NOT banned;
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "tags";
+-- +migrate StatementEnd
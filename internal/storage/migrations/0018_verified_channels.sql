@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "verified_channels" (
+    "claim_id" varchar PRIMARY KEY,
+    "verified_by" varchar NOT NULL,
+    "note" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "verified_channels";
+-- +migrate StatementEnd
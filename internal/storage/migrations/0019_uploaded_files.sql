@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "uploaded_files" (
+    "user_id" uinteger NOT NULL,
+    "file_hash" varchar(96) NOT NULL,
+    "claim_id" varchar,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY ("user_id", "file_hash")
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "uploaded_files";
+-- +migrate StatementEnd
@@ -0,0 +1,16 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "user_blocked_channels" (
+    "user_id" uinteger NOT NULL,
+    "channel_id" varchar NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY ("user_id", "channel_id")
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "user_blocked_channels";
+-- +migrate StatementEnd
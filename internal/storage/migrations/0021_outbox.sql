@@ -0,0 +1,23 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "outbox_messages" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "destination" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "attempts" uinteger NOT NULL DEFAULT 0,
+    "last_error" varchar,
+    "next_attempt_at" timestamp NOT NULL DEFAULT now(),
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "delivered_at" timestamp
+);
+CREATE INDEX outbox_messages_status_next_attempt_at_idx ON outbox_messages(status, next_attempt_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "outbox_messages";
+-- +migrate StatementEnd
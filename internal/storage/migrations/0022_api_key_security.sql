@@ -0,0 +1,46 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_api_key_security" (
+    "key_id" integer PRIMARY KEY REFERENCES organization_api_keys(id) ON DELETE CASCADE,
+    "webhook_url" varchar NOT NULL DEFAULT '',
+    "rotate_at" timestamp,
+    "updated_at" timestamp NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_api_key_networks" (
+    "key_id" integer NOT NULL REFERENCES organization_api_keys(id) ON DELETE CASCADE,
+    "network" varchar NOT NULL,
+    "first_seen_at" timestamp NOT NULL DEFAULT now(),
+    "last_seen_at" timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY ("key_id", "network")
+);
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "organization_api_key_events" (
+    "id" SERIAL PRIMARY KEY,
+    "key_id" integer NOT NULL REFERENCES organization_api_keys(id) ON DELETE CASCADE,
+    "kind" varchar NOT NULL,
+    "network" varchar NOT NULL DEFAULT '',
+    "outbox_message_id" bigint NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+CREATE INDEX organization_api_key_events_key_id_kind_idx ON organization_api_key_events(key_id, kind, network, created_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "organization_api_key_events";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+DROP TABLE "organization_api_key_networks";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+DROP TABLE "organization_api_key_security";
+-- +migrate StatementEnd
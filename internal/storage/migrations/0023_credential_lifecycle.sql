@@ -0,0 +1,32 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+ALTER TABLE "organization_api_key_security"
+    ADD COLUMN "expires_at" timestamp,
+    ADD COLUMN "last_used_at" timestamp,
+    ADD COLUMN "replaced_by" integer;
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+CREATE TABLE "secrets" (
+    "id" SERIAL PRIMARY KEY,
+    "name" varchar NOT NULL,
+    "value" varchar NOT NULL,
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "retires_at" timestamp
+);
+CREATE INDEX secrets_name_idx ON secrets(name);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "secrets";
+-- +migrate StatementEnd
+
+-- +migrate StatementBegin
+ALTER TABLE "organization_api_key_security"
+    DROP COLUMN "expires_at",
+    DROP COLUMN "last_used_at",
+    DROP COLUMN "replaced_by";
+-- +migrate StatementEnd
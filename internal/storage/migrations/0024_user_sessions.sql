@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "user_sessions" (
+    "id" SERIAL PRIMARY KEY,
+    "user_id" uinteger NOT NULL,
+    "token_hash" varchar NOT NULL UNIQUE,
+    "ip" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "last_seen_at" timestamp NOT NULL DEFAULT now(),
+    "revoked_at" timestamp
+);
+CREATE INDEX user_sessions_user_id_idx ON user_sessions(user_id);
+CREATE INDEX user_sessions_revoked_at_idx ON user_sessions(revoked_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "user_sessions";
+-- +migrate StatementEnd
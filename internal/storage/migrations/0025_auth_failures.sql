@@ -0,0 +1,17 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "auth_failures" (
+    "key" varchar PRIMARY KEY,
+    "failures" integer NOT NULL DEFAULT 0,
+    "last_failure_at" timestamp NOT NULL DEFAULT now(),
+    "locked_until" timestamp
+);
+CREATE INDEX auth_failures_last_failure_at_idx ON auth_failures(last_failure_at);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "auth_failures";
+-- +migrate StatementEnd
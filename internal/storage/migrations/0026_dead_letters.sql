@@ -0,0 +1,23 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "dead_letters" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "source" varchar NOT NULL,
+    "kind" varchar NOT NULL,
+    "ref" varchar NOT NULL DEFAULT '',
+    "payload" jsonb NOT NULL,
+    "error" varchar NOT NULL DEFAULT '',
+    "attempts" uinteger NOT NULL DEFAULT 0,
+    "status" varchar NOT NULL DEFAULT 'dead',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "redelivered_at" timestamp
+);
+CREATE INDEX dead_letters_status_idx ON dead_letters(status, id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "dead_letters";
+-- +migrate StatementEnd
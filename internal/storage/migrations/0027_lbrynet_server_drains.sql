@@ -0,0 +1,14 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "lbrynet_server_drains" (
+    "name" varchar PRIMARY KEY,
+    "created_at" timestamp NOT NULL DEFAULT now()
+);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "lbrynet_server_drains";
+-- +migrate StatementEnd
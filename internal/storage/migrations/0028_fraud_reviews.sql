@@ -0,0 +1,26 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "fraud_reviews" (
+    "id" BIGSERIAL PRIMARY KEY,
+    "user_id" uinteger NOT NULL,
+    "kind" varchar NOT NULL,
+    "method" varchar NOT NULL,
+    "fingerprint" varchar NOT NULL,
+    "score" double precision NOT NULL,
+    "reasons" jsonb NOT NULL DEFAULT '[]',
+    "params" jsonb NOT NULL,
+    "remote_ip" varchar NOT NULL DEFAULT '',
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "reviewed_at" timestamp
+);
+CREATE INDEX fraud_reviews_user_fingerprint_idx ON fraud_reviews(user_id, fingerprint);
+CREATE INDEX fraud_reviews_status_idx ON fraud_reviews(status, id);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "fraud_reviews";
+-- +migrate StatementEnd
@@ -0,0 +1,25 @@
+-- +migrate Up
+
+-- +migrate StatementBegin
+CREATE TABLE "content_checks" (
+    "claim_id" varchar PRIMARY KEY,
+    "user_id" uinteger NOT NULL,
+    "sd_hash" varchar NOT NULL DEFAULT '',
+    "status" varchar NOT NULL DEFAULT 'unknown',
+    "total_blobs" integer NOT NULL DEFAULT 0,
+    "missing_blobs" jsonb NOT NULL DEFAULT '[]',
+    "error" varchar NOT NULL DEFAULT '',
+    "created_at" timestamp NOT NULL DEFAULT now(),
+    "checked_at" timestamp,
+    "notified_at" timestamp,
+    "repaired_at" timestamp
+);
+CREATE INDEX content_checks_user_id_idx ON content_checks(user_id);
+CREATE INDEX content_checks_checked_at_idx ON content_checks(checked_at NULLS FIRST);
+-- +migrate StatementEnd
+
+-- +migrate Down
+
+-- +migrate StatementBegin
+DROP TABLE "content_checks";
+-- +migrate StatementEnd
@@ -0,0 +1,221 @@
+// Package telemetry ships aggregated SDK method usage to a collection endpoint, to help decide what to optimize.
+//
+// It is off unless an endpoint is configured. Reports contain nothing but method names, which come
+// from the fixed set of proxied methods, call and failure counts and latency histograms.
+// Calls are sampled before being counted and every reported count has Laplace noise added
+// (see Config.Epsilon), so reports don't reveal whether any single call took place.
+package telemetry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/monitor"
+)
+
+var (
+	logger = monitor.NewModuleLogger("telemetry")
+
+	defaultMu        sync.RWMutex
+	defaultCollector *Collector
+
+	// Buckets are upper bounds of latency histogram buckets in seconds, the last bucket counts slower calls.
+	Buckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
+
+// Config defines where and how often usage reports are sent.
+type Config struct {
+	Endpoint string
+	Interval time.Duration
+	// SampleRate is the fraction of calls which are counted.
+	SampleRate float64
+	// Epsilon is the differential privacy budget of each reported count, lower values add more noise.
+	// It has to be positive.
+	Epsilon float64
+}
+
+// MethodUsage holds noised counts for a single method. Latency has a count for each of Buckets and one for slower calls.
+type MethodUsage struct {
+	Calls    int64   `json:"calls"`
+	Failures int64   `json:"failures"`
+	Latency  []int64 `json:"latency"`
+}
+
+// Report is the usage over a period.
+type Report struct {
+	Start      time.Time              `json:"start"`
+	End        time.Time              `json:"end"`
+	SampleRate float64                `json:"sample_rate"`
+	Epsilon    float64                `json:"epsilon"`
+	Buckets    []float64              `json:"buckets"`
+	Methods    map[string]MethodUsage `json:"methods"`
+}
+
+type usage struct {
+	calls    int64
+	failures int64
+	latency  []int64
+}
+
+// Collector counts sampled calls and periodically sends noised reports.
+type Collector struct {
+	cfg    Config
+	client *http.Client
+	stop   chan struct{}
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	start  time.Time
+	counts map[string]*usage
+}
+
+// New creates a collector sending reports to cfg.Endpoint once started.
+func New(cfg Config) (*Collector, error) {
+	if cfg.Epsilon <= 0 {
+		return nil, fmt.Errorf("telemetry epsilon must be positive, got %v", cfg.Epsilon)
+	}
+	if cfg.SampleRate <= 0 || cfg.SampleRate > 1 {
+		return nil, fmt.Errorf("telemetry sample rate must be between 0 and 1, got %v", cfg.SampleRate)
+	}
+	return &Collector{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		stop:   make(chan struct{}),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		start:  time.Now(),
+		counts: map[string]*usage{},
+	}, nil
+}
+
+// SetDefault sets the collector calls are reported to by Observe.
+func SetDefault(c *Collector) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultCollector = c
+}
+
+// Observe counts a call with the default collector, if telemetry is enabled.
+func Observe(method string, seconds float64, failed bool) {
+	defaultMu.RLock()
+	c := defaultCollector
+	defaultMu.RUnlock()
+	if c != nil {
+		c.Observe(method, seconds, failed)
+	}
+}
+
+// Observe counts a call, subject to sampling.
+func (c *Collector) Observe(method string, seconds float64, failed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rand.Float64() >= c.cfg.SampleRate {
+		return
+	}
+	u, ok := c.counts[method]
+	if !ok {
+		u = &usage{latency: make([]int64, len(Buckets)+1)}
+		c.counts[method] = u
+	}
+	u.calls++
+	if failed {
+		u.failures++
+	}
+	u.latency[sort.SearchFloat64s(Buckets, seconds)]++
+}
+
+// laplace returns a sample from the Laplace distribution centered at zero.
+// Counts have a sensitivity of 1 as a single call adds at most one to each of them.
+func (c *Collector) laplace() float64 {
+	u := c.rand.Float64() - 0.5
+	if u == -0.5 {
+		// The only value the logarithm below is infinite for
+		u = 0
+	}
+	scale := 1 / c.cfg.Epsilon
+	if u < 0 {
+		return scale * math.Log(1+2*u)
+	}
+	return -scale * math.Log(1-2*u)
+}
+
+func (c *Collector) noised(n int64) int64 {
+	v := int64(math.Round(float64(n) + c.laplace()))
+	if v < 0 {
+		return 0
+	}
+	return v
+}
+
+// Report returns noised usage since the previous report and starts counting anew.
+func (c *Collector) Report() Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	r := Report{
+		Start:      c.start.UTC(),
+		End:        now.UTC(),
+		SampleRate: c.cfg.SampleRate,
+		Epsilon:    c.cfg.Epsilon,
+		Buckets:    Buckets,
+		Methods:    map[string]MethodUsage{},
+	}
+	for method, u := range c.counts {
+		m := MethodUsage{Calls: c.noised(u.calls), Failures: c.noised(u.failures), Latency: make([]int64, len(u.latency))}
+		for i, n := range u.latency {
+			m.Latency[i] = c.noised(n)
+		}
+		r.Methods[method] = m
+	}
+	c.start = now
+	c.counts = map[string]*usage{}
+	return r
+}
+
+// Send posts the report to the endpoint as JSON.
+func (c *Collector) Send(r Report) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	res, err := c.client.Post(c.cfg.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("telemetry endpoint responded with %v: %s", res.StatusCode, msg)
+	}
+	return nil
+}
+
+// Start sends a report every interval until Stop is called. It blocks.
+// Reports which fail to send are dropped rather than retried, telemetry is not worth piling up memory for.
+func (c *Collector) Start() {
+	logger.Log().Infof("sending usage telemetry every %v, sampling %v of calls", c.cfg.Interval, c.cfg.SampleRate)
+	ticker := time.NewTicker(c.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Send(c.Report()); err != nil {
+				logger.Log().Warnf("error sending usage telemetry: %v", err)
+			}
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// Stop stops sending reports.
+func (c *Collector) Stop() {
+	close(c.stop)
+}
@@ -0,0 +1,105 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Validates(t *testing.T) {
+	_, err := New(Config{SampleRate: 1})
+	assert.Error(t, err)
+	_, err = New(Config{SampleRate: 0, Epsilon: 1})
+	assert.Error(t, err)
+	_, err = New(Config{SampleRate: 1.5, Epsilon: 1})
+	assert.Error(t, err)
+}
+
+func TestCollector_Report(t *testing.T) {
+	// Noise is negligible with a very large epsilon
+	c, err := New(Config{SampleRate: 1, Epsilon: 1e9})
+	require.NoError(t, err)
+	c.Observe("resolve", 0.01, false)
+	c.Observe("resolve", 0.3, true)
+	c.Observe("resolve", 60, false)
+	c.Observe("claim_search", 0.05, false)
+
+	r := c.Report()
+	assert.Equal(t, Buckets, r.Buckets)
+	require.Len(t, r.Methods, 2)
+	res := r.Methods["resolve"]
+	assert.EqualValues(t, 3, res.Calls)
+	assert.EqualValues(t, 1, res.Failures)
+	assert.Equal(t, []int64{1, 0, 0, 1, 0, 0, 0, 0, 0, 1}, res.Latency)
+	assert.EqualValues(t, 1, r.Methods["claim_search"].Latency[0])
+
+	// Counting starts anew after each report
+	r2 := c.Report()
+	assert.Empty(t, r2.Methods)
+	assert.Equal(t, r.End, r2.Start)
+}
+
+func TestCollector_Noise(t *testing.T) {
+	c, err := New(Config{SampleRate: 1, Epsilon: 0.1})
+	require.NoError(t, err)
+	differ := false
+	for i := 0; i < 100; i++ {
+		if c.noised(50) != 50 {
+			differ = true
+		}
+	}
+	assert.True(t, differ)
+	// Counts can't go negative
+	for i := 0; i < 100; i++ {
+		assert.True(t, c.noised(0) >= 0)
+	}
+}
+
+func TestCollector_Sampling(t *testing.T) {
+	c, err := New(Config{SampleRate: 0.01, Epsilon: 1e9})
+	require.NoError(t, err)
+	for i := 0; i < 10000; i++ {
+		c.Observe("resolve", 0.01, false)
+	}
+	calls := c.Report().Methods["resolve"].Calls
+	assert.True(t, calls > 0 && calls < 1000, calls)
+}
+
+func TestObserve_Disabled(t *testing.T) {
+	SetDefault(nil)
+	Observe("resolve", 0.1, false)
+
+	c, err := New(Config{SampleRate: 1, Epsilon: 1e9})
+	require.NoError(t, err)
+	SetDefault(c)
+	defer SetDefault(nil)
+	Observe("resolve", 0.1, false)
+	assert.EqualValues(t, 1, c.Report().Methods["resolve"].Calls)
+}
+
+func TestCollector_Send(t *testing.T) {
+	var received Report
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received = Report{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		if len(received.Methods) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(Config{Endpoint: ts.URL, Interval: time.Hour, SampleRate: 1, Epsilon: 1e9})
+	require.NoError(t, err)
+	c.Observe("resolve", 0.1, false)
+	require.NoError(t, c.Send(c.Report()))
+	assert.EqualValues(t, 1, received.Methods["resolve"].Calls)
+	assert.Equal(t, 1.0, received.SampleRate)
+
+	assert.Error(t, c.Send(c.Report()))
+}
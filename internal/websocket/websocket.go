@@ -0,0 +1,379 @@
+// Package websocket implements the server side of the WebSocket protocol (RFC 6455) as far as lbrytv needs it:
+// pushing text messages to clients, receiving text messages from them on connections opened by Accept
+// and noticing when they go away. Other data messages from clients are discarded. Dial opens client connections
+// for subscribing to events of other services.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+const (
+	acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	// maxControlPayload is the most a control frame can carry, data frames from clients are read up to maxFrameSize.
+	maxControlPayload = 125
+	maxFrameSize      = 64 * 1024
+	// MaxMessageSize is the largest message, possibly fragmented, received on connections opened by Accept.
+	MaxMessageSize = 1024 * 1024
+
+	closeNormal   = 1000
+	closeTooLarge = 1009
+
+	writeTimeout = 10 * time.Second
+)
+
+// ErrNotWebSocket is returned by Upgrade for requests which are not WebSocket handshakes.
+var ErrNotWebSocket = errors.Base("not a websocket handshake")
+
+// Conn is a server side WebSocket connection, safe for concurrent writes.
+type Conn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+
+	// handle, when set, gets text messages from the other side, see Accept and Dial
+	handle      func(c *Conn, message []byte)
+	idleTimeout time.Duration
+	// client is set for connections opened by Dial, which mask their frames
+	client bool
+
+	mu        sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// AcceptKey computes the Sec-WebSocket-Accept header value for a Sec-WebSocket-Key.
+func AcceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func headerContains(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade completes the handshake and takes over the connection of r. Nothing is written to w
+// when ErrNotWebSocket is returned, so the caller can respond with an error.
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	c, err := upgrade(w, r)
+	if err != nil {
+		return nil, err
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Accept is Upgrade for connections clients send messages over. Text messages of up to MaxMessageSize are passed
+// to handle from the goroutine reading the connection, so no more are read until it returns. The connection
+// is closed when nothing, not even a ping, is received from the client for idleTimeout, zero means no limit.
+func Accept(w http.ResponseWriter, r *http.Request, handle func(c *Conn, message []byte), idleTimeout time.Duration) (*Conn, error) {
+	c, err := upgrade(w, r)
+	if err != nil {
+		return nil, err
+	}
+	c.handle = handle
+	c.idleTimeout = idleTimeout
+	go c.readLoop()
+	return c, nil
+}
+
+func upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, ErrNotWebSocket
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, errors.Prefix("unsupported version", ErrNotWebSocket)
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, errors.Err("connection cannot be taken over")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + AcceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, errors.Err(err)
+	}
+	return &Conn{conn: conn, rw: rw, done: make(chan struct{})}, nil
+}
+
+// Dial opens a connection to the WebSocket server at rawURL (ws:// or wss://) and passes text messages
+// it sends to handle from the goroutine reading the connection. tlsConfig is used for wss:// and may be nil.
+func Dial(rawURL string, tlsConfig *tls.Config, handle func(c *Conn, message []byte), timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ws":
+		conn, err = dialer.Dial("tcp", host)
+	case "wss":
+		conn, err = tls.DialWithDialer(dialer, "tcp", host, tlsConfig)
+	default:
+		return nil, errors.Err("unsupported scheme %v", u.Scheme)
+	}
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req := &http.Request{
+		Method: http.MethodGet,
+		URL:    &url.URL{Path: u.Path, RawQuery: u.RawQuery},
+		Host:   u.Host,
+		Header: http.Header{
+			"Connection":            {"Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {key},
+		},
+	}
+	if req.URL.Path == "" {
+		req.URL.Path = "/"
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Err(err)
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Err(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusSwitchingProtocols || res.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		conn.Close()
+		return nil, errors.Err("websocket handshake with %v failed: %v", u.Host, res.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &Conn{
+		conn:   conn,
+		rw:     bufio.NewReadWriter(br, bufio.NewWriter(conn)),
+		done:   make(chan struct{}),
+		handle: handle,
+		client: true,
+	}
+	go c.readLoop()
+	return c, nil
+}
+
+// Done is closed once the client has closed the connection or it has broken.
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// WriteText sends data as a text message.
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+// WriteJSON sends v serialized as a text message.
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.Err(err)
+	}
+	return c.WriteText(data)
+}
+
+// Close sends a normal closure to the client and closes the connection.
+func (c *Conn) Close() error {
+	c.closeWith(closeNormal)
+	return nil
+}
+
+func (c *Conn) closeWith(code uint16) {
+	var payload [2]byte
+	binary.BigEndian.PutUint16(payload[:], code)
+	c.writeFrame(opClose, payload[:])
+	c.finish()
+}
+
+func (c *Conn) finish() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return errors.Err("connection closed")
+	default:
+	}
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126, byte(n>>8), byte(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	if c.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	c.rw.Write(header)
+	c.rw.Write(payload)
+	return errors.Err(c.rw.Flush())
+}
+
+// readLoop answers pings and closes and passes text messages to handle, discarding everything else,
+// until the connection goes away.
+func (c *Conn) readLoop() {
+	defer c.finish()
+	var (
+		message    []byte
+		assembling bool
+	)
+	for {
+		if c.idleTimeout > 0 {
+			c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+		}
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case opClose:
+			c.writeFrame(opClose, payload)
+			return
+		case opPing:
+			c.writeFrame(opPong, payload)
+		case opText:
+			message, assembling = payload, !fin
+		case opContinuation:
+			if !assembling {
+				continue
+			}
+			if len(message)+len(payload) > MaxMessageSize {
+				c.closeWith(closeTooLarge)
+				return
+			}
+			message, assembling = append(message, payload...), !fin
+		}
+		if c.handle != nil && message != nil && !assembling {
+			c.handle(c, message)
+			message = nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	opcode := head[0] & 0x0f
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7f)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	// Text messages are kept when there's a handler for them
+	keep := opcode >= opClose || (c.handle != nil && (opcode == opText || opcode == opContinuation))
+	limit := uint64(maxFrameSize)
+	if keep && opcode < opClose {
+		limit = MaxMessageSize
+	}
+	// Clients must mask their frames, servers must not
+	if masked == c.client || n > limit || (opcode >= opClose && n > maxControlPayload) {
+		return false, 0, nil, errors.Err("protocol error")
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	if !keep {
+		_, err := io.CopyN(ioutil.Discard, c.rw, int64(n))
+		return fin, opcode, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, opcode, payload, nil
+}
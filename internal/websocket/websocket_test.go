@@ -0,0 +1,161 @@
+package websocket
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcceptKey(t *testing.T) {
+	// Example from RFC 6455, section 1.3
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+}
+
+func dial(t *testing.T, url string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	io.WriteString(conn, "GET / HTTP/1.1\r\nHost: lbry.tv\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get("Sec-WebSocket-Accept"))
+	return conn, br
+}
+
+func readFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	var head [2]byte
+	_, err := io.ReadFull(br, head[:])
+	require.NoError(t, err)
+	payload := make([]byte, head[1]&0x7f)
+	_, err = io.ReadFull(br, payload)
+	require.NoError(t, err)
+	return head[0] & 0x0f, payload
+}
+
+func writeMasked(conn net.Conn, opcode byte, payload []byte) {
+	writeFragment(conn, true, opcode, payload)
+}
+
+func writeFragment(conn net.Conn, fin bool, opcode byte, payload []byte) {
+	mask := []byte{1, 2, 3, 4}
+	if fin {
+		opcode |= 0x80
+	}
+	frame := append([]byte{opcode, 0x80 | byte(len(payload))}, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	conn.Write(frame)
+}
+
+func TestConn(t *testing.T) {
+	closed := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Upgrade(w, r)
+		require.NoError(t, err)
+		require.NoError(t, c.WriteJSON(map[string]int{"bytes": 10}))
+		<-c.Done()
+		close(closed)
+	}))
+	defer ts.Close()
+
+	conn, br := dial(t, ts.URL)
+	defer conn.Close()
+	opcode, payload := readFrame(t, br)
+	assert.EqualValues(t, opText, opcode)
+	assert.Equal(t, `{"bytes":10}`, string(payload))
+
+	writeMasked(conn, opText, []byte("ignored"))
+	writeMasked(conn, opPing, []byte("hi"))
+	opcode, payload = readFrame(t, br)
+	assert.EqualValues(t, opPong, opcode)
+	assert.Equal(t, "hi", string(payload))
+
+	writeMasked(conn, opClose, []byte{0x03, 0xe8})
+	opcode, _ = readFrame(t, br)
+	assert.EqualValues(t, opClose, opcode)
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed")
+	}
+}
+
+func TestAccept(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := Accept(w, r, func(c *Conn, message []byte) {
+			c.WriteText(append([]byte("echo: "), message...))
+		}, time.Second)
+		require.NoError(t, err)
+		<-c.Done()
+	}))
+	defer ts.Close()
+
+	conn, br := dial(t, ts.URL)
+	defer conn.Close()
+	writeMasked(conn, opText, []byte("one"))
+	opcode, payload := readFrame(t, br)
+	assert.EqualValues(t, opText, opcode)
+	assert.Equal(t, "echo: one", string(payload))
+
+	// Fragments are put together, with control frames in between
+	writeFragment(conn, false, opText, []byte("tw"))
+	writeMasked(conn, opPing, []byte("hi"))
+	writeFragment(conn, true, opContinuation, []byte("o"))
+	opcode, _ = readFrame(t, br)
+	assert.EqualValues(t, opPong, opcode)
+	_, payload = readFrame(t, br)
+	assert.Equal(t, "echo: two", string(payload))
+
+	// Idle connections are closed
+	_, err := br.ReadByte()
+	assert.Error(t, err)
+}
+
+func TestDial(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ws", r.URL.Path)
+		c, err := Accept(w, r, func(c *Conn, message []byte) {
+			c.WriteText(append([]byte("echo: "), message...))
+		}, 0)
+		require.NoError(t, err)
+		<-c.Done()
+	}))
+	defer ts.Close()
+
+	received := make(chan string)
+	c, err := Dial(strings.Replace(ts.URL, "http://", "ws://", 1)+"/ws", nil, func(c *Conn, message []byte) {
+		received <- string(message)
+	}, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, c.WriteText([]byte("hello")))
+	select {
+	case m := <-received:
+		assert.Equal(t, "echo: hello", m)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	c.Close()
+
+	_, err = Dial("http://lbry.tv/ws", nil, nil, time.Second)
+	assert.Error(t, err)
+}
+
+func TestUpgrade_NotWebSocket(t *testing.T) {
+	rr := httptest.NewRecorder()
+	_, err := Upgrade(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, errors.Is(err, ErrNotWebSocket))
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"math/rand"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -11,6 +12,9 @@ import (
 	"github.com/lbryio/lbrytv/cmd"
 	"github.com/lbryio/lbrytv/internal/monitor"
 	"github.com/lbryio/lbrytv/internal/reflection"
+	"github.com/lbryio/lbrytv/internal/sdksign"
+	"github.com/lbryio/lbrytv/internal/sdktls"
+	"github.com/lbryio/lbrytv/internal/startup"
 	"github.com/lbryio/lbrytv/internal/storage"
 	"github.com/lbryio/lbrytv/version"
 )
@@ -21,22 +25,46 @@ func main() {
 	// this is a *client-side* timeout (for when we make http requests, not when we serve them)
 	//https://blog.cloudflare.com/the-complete-guide-to-golang-net-http-timeouts/
 	http.DefaultClient.Timeout = 20 * time.Second
+	// lbry.go SDK clients use the default transport: requests to SDK nodes that have a secret get signed
+	// and go over mutual TLS once it's configured
+	http.DefaultTransport = sdksign.NewTransport(
+		sdktls.NewTransport(http.DefaultTransport.(*http.Transport)), sdksign.DefaultKeyring)
 
 	defer func() {
 		sentry.Flush(3 * time.Second)
 		sentry.Recover()
 	}()
 
+	// Commands inspecting a running instance shouldn't depend on the DB being reachable
+	if cmd.IsStandalone(os.Args[1:]) {
+		cmd.Execute()
+		return
+	}
+
 	dbConfig := config.GetDatabase()
 	monitor.IsProduction = config.IsProduction()
 	monitor.ConfigureSentry(config.GetSentryDSN(), version.GetDevVersion(), monitor.LogMode())
+	monitor.StartSentryWorkers(config.GetSentryWorkers(), config.GetSentryQueueSize())
+	// Deferred after sentry.Flush so it runs before it
+	defer monitor.StopSentryWorkers(3 * time.Second)
+	storage.SetInstrumentation(storage.Instrumentation{
+		SlowQueryThreshold:       config.GetDBSlowQueryThreshold(),
+		SlowTransactionThreshold: config.GetDBSlowTransactionThreshold(),
+		ExplainSlowQueries:       config.GetDBExplainSlowQueries(),
+	})
+	dbPool := config.GetDBPool()
 	conn := storage.InitConn(storage.ConnParams{
-		Connection: dbConfig.Connection,
-		DBName:     dbConfig.DBName,
-		Options:    dbConfig.Options,
+		Connection:       dbConfig.Connection,
+		DBName:           dbConfig.DBName,
+		Options:          dbConfig.Options,
+		MaxOpenConns:     dbPool.MaxOpenConns,
+		MaxIdleConns:     dbPool.MaxIdleConns,
+		ConnMaxLifetime:  dbPool.ConnMaxLifetime,
+		StatementTimeout: dbPool.StatementTimeout,
+		Breaker:          storage.BreakerConfig(config.GetDBBreaker()),
 	})
 
-	err := conn.Connect()
+	err := startup.Retry("db connection", startup.Backoff(config.GetStartupRetry()), conn.Connect)
 	if err != nil {
 		panic(err)
 	}
@@ -12,87 +12,165 @@ import "testing"
 // It does NOT run each operation group in parallel.
 // Separating the tests thusly grants avoidance of Postgres deadlocks.
 func TestParent(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegations)
 	t.Run("GorpMigrations", testGorpMigrations)
 	t.Run("LbrynetServers", testLbrynetServers)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeys)
+	t.Run("OrganizationDrafts", testOrganizationDrafts)
+	t.Run("OrganizationMembers", testOrganizationMembers)
+	t.Run("Organizations", testOrganizations)
+	t.Run("QuarantinedFiles", testQuarantinedFiles)
 	t.Run("QueryLogs", testQueryLogs)
 	t.Run("Users", testUsers)
 }
 
 func TestDelete(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsDelete)
 	t.Run("GorpMigrations", testGorpMigrationsDelete)
 	t.Run("LbrynetServers", testLbrynetServersDelete)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysDelete)
+	t.Run("OrganizationDrafts", testOrganizationDraftsDelete)
+	t.Run("OrganizationMembers", testOrganizationMembersDelete)
+	t.Run("Organizations", testOrganizationsDelete)
+	t.Run("QuarantinedFiles", testQuarantinedFilesDelete)
 	t.Run("QueryLogs", testQueryLogsDelete)
 	t.Run("Users", testUsersDelete)
 }
 
 func TestQueryDeleteAll(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsQueryDeleteAll)
 	t.Run("GorpMigrations", testGorpMigrationsQueryDeleteAll)
 	t.Run("LbrynetServers", testLbrynetServersQueryDeleteAll)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysQueryDeleteAll)
+	t.Run("OrganizationDrafts", testOrganizationDraftsQueryDeleteAll)
+	t.Run("OrganizationMembers", testOrganizationMembersQueryDeleteAll)
+	t.Run("Organizations", testOrganizationsQueryDeleteAll)
+	t.Run("QuarantinedFiles", testQuarantinedFilesQueryDeleteAll)
 	t.Run("QueryLogs", testQueryLogsQueryDeleteAll)
 	t.Run("Users", testUsersQueryDeleteAll)
 }
 
 func TestSliceDeleteAll(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsSliceDeleteAll)
 	t.Run("GorpMigrations", testGorpMigrationsSliceDeleteAll)
 	t.Run("LbrynetServers", testLbrynetServersSliceDeleteAll)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysSliceDeleteAll)
+	t.Run("OrganizationDrafts", testOrganizationDraftsSliceDeleteAll)
+	t.Run("OrganizationMembers", testOrganizationMembersSliceDeleteAll)
+	t.Run("Organizations", testOrganizationsSliceDeleteAll)
+	t.Run("QuarantinedFiles", testQuarantinedFilesSliceDeleteAll)
 	t.Run("QueryLogs", testQueryLogsSliceDeleteAll)
 	t.Run("Users", testUsersSliceDeleteAll)
 }
 
 func TestExists(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsExists)
 	t.Run("GorpMigrations", testGorpMigrationsExists)
 	t.Run("LbrynetServers", testLbrynetServersExists)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysExists)
+	t.Run("OrganizationDrafts", testOrganizationDraftsExists)
+	t.Run("OrganizationMembers", testOrganizationMembersExists)
+	t.Run("Organizations", testOrganizationsExists)
+	t.Run("QuarantinedFiles", testQuarantinedFilesExists)
 	t.Run("QueryLogs", testQueryLogsExists)
 	t.Run("Users", testUsersExists)
 }
 
 func TestFind(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsFind)
 	t.Run("GorpMigrations", testGorpMigrationsFind)
 	t.Run("LbrynetServers", testLbrynetServersFind)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysFind)
+	t.Run("OrganizationDrafts", testOrganizationDraftsFind)
+	t.Run("OrganizationMembers", testOrganizationMembersFind)
+	t.Run("Organizations", testOrganizationsFind)
+	t.Run("QuarantinedFiles", testQuarantinedFilesFind)
 	t.Run("QueryLogs", testQueryLogsFind)
 	t.Run("Users", testUsersFind)
 }
 
 func TestBind(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsBind)
 	t.Run("GorpMigrations", testGorpMigrationsBind)
 	t.Run("LbrynetServers", testLbrynetServersBind)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysBind)
+	t.Run("OrganizationDrafts", testOrganizationDraftsBind)
+	t.Run("OrganizationMembers", testOrganizationMembersBind)
+	t.Run("Organizations", testOrganizationsBind)
+	t.Run("QuarantinedFiles", testQuarantinedFilesBind)
 	t.Run("QueryLogs", testQueryLogsBind)
 	t.Run("Users", testUsersBind)
 }
 
 func TestOne(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsOne)
 	t.Run("GorpMigrations", testGorpMigrationsOne)
 	t.Run("LbrynetServers", testLbrynetServersOne)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysOne)
+	t.Run("OrganizationDrafts", testOrganizationDraftsOne)
+	t.Run("OrganizationMembers", testOrganizationMembersOne)
+	t.Run("Organizations", testOrganizationsOne)
+	t.Run("QuarantinedFiles", testQuarantinedFilesOne)
 	t.Run("QueryLogs", testQueryLogsOne)
 	t.Run("Users", testUsersOne)
 }
 
 func TestAll(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsAll)
 	t.Run("GorpMigrations", testGorpMigrationsAll)
 	t.Run("LbrynetServers", testLbrynetServersAll)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysAll)
+	t.Run("OrganizationDrafts", testOrganizationDraftsAll)
+	t.Run("OrganizationMembers", testOrganizationMembersAll)
+	t.Run("Organizations", testOrganizationsAll)
+	t.Run("QuarantinedFiles", testQuarantinedFilesAll)
 	t.Run("QueryLogs", testQueryLogsAll)
 	t.Run("Users", testUsersAll)
 }
 
 func TestCount(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsCount)
 	t.Run("GorpMigrations", testGorpMigrationsCount)
 	t.Run("LbrynetServers", testLbrynetServersCount)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysCount)
+	t.Run("OrganizationDrafts", testOrganizationDraftsCount)
+	t.Run("OrganizationMembers", testOrganizationMembersCount)
+	t.Run("Organizations", testOrganizationsCount)
+	t.Run("QuarantinedFiles", testQuarantinedFilesCount)
 	t.Run("QueryLogs", testQueryLogsCount)
 	t.Run("Users", testUsersCount)
 }
 
 func TestHooks(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsHooks)
 	t.Run("GorpMigrations", testGorpMigrationsHooks)
 	t.Run("LbrynetServers", testLbrynetServersHooks)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysHooks)
+	t.Run("OrganizationDrafts", testOrganizationDraftsHooks)
+	t.Run("OrganizationMembers", testOrganizationMembersHooks)
+	t.Run("Organizations", testOrganizationsHooks)
+	t.Run("QuarantinedFiles", testQuarantinedFilesHooks)
 	t.Run("QueryLogs", testQueryLogsHooks)
 	t.Run("Users", testUsersHooks)
 }
 
 func TestInsert(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsInsert)
+	t.Run("ChannelDelegations", testChannelDelegationsInsertWhitelist)
 	t.Run("GorpMigrations", testGorpMigrationsInsert)
 	t.Run("GorpMigrations", testGorpMigrationsInsertWhitelist)
 	t.Run("LbrynetServers", testLbrynetServersInsert)
 	t.Run("LbrynetServers", testLbrynetServersInsertWhitelist)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysInsert)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysInsertWhitelist)
+	t.Run("OrganizationDrafts", testOrganizationDraftsInsert)
+	t.Run("OrganizationDrafts", testOrganizationDraftsInsertWhitelist)
+	t.Run("OrganizationMembers", testOrganizationMembersInsert)
+	t.Run("OrganizationMembers", testOrganizationMembersInsertWhitelist)
+	t.Run("Organizations", testOrganizationsInsert)
+	t.Run("Organizations", testOrganizationsInsertWhitelist)
+	t.Run("QuarantinedFiles", testQuarantinedFilesInsert)
+	t.Run("QuarantinedFiles", testQuarantinedFilesInsertWhitelist)
 	t.Run("QueryLogs", testQueryLogsInsert)
 	t.Run("QueryLogs", testQueryLogsInsertWhitelist)
 	t.Run("Users", testUsersInsert)
@@ -154,36 +232,66 @@ func TestToManyRemove(t *testing.T) {
 }
 
 func TestReload(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsReload)
 	t.Run("GorpMigrations", testGorpMigrationsReload)
 	t.Run("LbrynetServers", testLbrynetServersReload)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysReload)
+	t.Run("OrganizationDrafts", testOrganizationDraftsReload)
+	t.Run("OrganizationMembers", testOrganizationMembersReload)
+	t.Run("Organizations", testOrganizationsReload)
+	t.Run("QuarantinedFiles", testQuarantinedFilesReload)
 	t.Run("QueryLogs", testQueryLogsReload)
 	t.Run("Users", testUsersReload)
 }
 
 func TestReloadAll(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsReloadAll)
 	t.Run("GorpMigrations", testGorpMigrationsReloadAll)
 	t.Run("LbrynetServers", testLbrynetServersReloadAll)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysReloadAll)
+	t.Run("OrganizationDrafts", testOrganizationDraftsReloadAll)
+	t.Run("OrganizationMembers", testOrganizationMembersReloadAll)
+	t.Run("Organizations", testOrganizationsReloadAll)
+	t.Run("QuarantinedFiles", testQuarantinedFilesReloadAll)
 	t.Run("QueryLogs", testQueryLogsReloadAll)
 	t.Run("Users", testUsersReloadAll)
 }
 
 func TestSelect(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsSelect)
 	t.Run("GorpMigrations", testGorpMigrationsSelect)
 	t.Run("LbrynetServers", testLbrynetServersSelect)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysSelect)
+	t.Run("OrganizationDrafts", testOrganizationDraftsSelect)
+	t.Run("OrganizationMembers", testOrganizationMembersSelect)
+	t.Run("Organizations", testOrganizationsSelect)
+	t.Run("QuarantinedFiles", testQuarantinedFilesSelect)
 	t.Run("QueryLogs", testQueryLogsSelect)
 	t.Run("Users", testUsersSelect)
 }
 
 func TestUpdate(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsUpdate)
 	t.Run("GorpMigrations", testGorpMigrationsUpdate)
 	t.Run("LbrynetServers", testLbrynetServersUpdate)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysUpdate)
+	t.Run("OrganizationDrafts", testOrganizationDraftsUpdate)
+	t.Run("OrganizationMembers", testOrganizationMembersUpdate)
+	t.Run("Organizations", testOrganizationsUpdate)
+	t.Run("QuarantinedFiles", testQuarantinedFilesUpdate)
 	t.Run("QueryLogs", testQueryLogsUpdate)
 	t.Run("Users", testUsersUpdate)
 }
 
 func TestSliceUpdateAll(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsSliceUpdateAll)
 	t.Run("GorpMigrations", testGorpMigrationsSliceUpdateAll)
 	t.Run("LbrynetServers", testLbrynetServersSliceUpdateAll)
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysSliceUpdateAll)
+	t.Run("OrganizationDrafts", testOrganizationDraftsSliceUpdateAll)
+	t.Run("OrganizationMembers", testOrganizationMembersSliceUpdateAll)
+	t.Run("Organizations", testOrganizationsSliceUpdateAll)
+	t.Run("QuarantinedFiles", testQuarantinedFilesSliceUpdateAll)
 	t.Run("QueryLogs", testQueryLogsSliceUpdateAll)
 	t.Run("Users", testUsersSliceUpdateAll)
 }
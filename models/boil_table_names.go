@@ -4,13 +4,25 @@
 package models
 
 var TableNames = struct {
-	GorpMigrations string
-	LbrynetServers string
-	QueryLog       string
-	Users          string
+	ChannelDelegations  string
+	GorpMigrations      string
+	LbrynetServers      string
+	OrganizationAPIKeys string
+	OrganizationDrafts  string
+	OrganizationMembers string
+	Organizations       string
+	QuarantinedFiles    string
+	QueryLog            string
+	Users               string
 }{
-	GorpMigrations: "gorp_migrations",
-	LbrynetServers: "lbrynet_servers",
-	QueryLog:       "query_log",
-	Users:          "users",
+	ChannelDelegations:  "channel_delegations",
+	GorpMigrations:      "gorp_migrations",
+	LbrynetServers:      "lbrynet_servers",
+	OrganizationAPIKeys: "organization_api_keys",
+	OrganizationDrafts:  "organization_drafts",
+	OrganizationMembers: "organization_members",
+	Organizations:       "organizations",
+	QuarantinedFiles:    "quarantined_files",
+	QueryLog:            "query_log",
+	Users:               "users",
 }
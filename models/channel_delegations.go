@@ -0,0 +1,980 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+	"github.com/volatiletech/sqlboiler/queries/qmhelper"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+// ChannelDelegation is an object representing the database table.
+type ChannelDelegation struct {
+	ID         int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	ChannelID  string    `boil:"channel_id" json:"channel_id" toml:"channel_id" yaml:"channel_id"`
+	OwnerID    int       `boil:"owner_id" json:"owner_id" toml:"owner_id" yaml:"owner_id"`
+	DelegateID int       `boil:"delegate_id" json:"delegate_id" toml:"delegate_id" yaml:"delegate_id"`
+	CreatedAt  time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+
+	R *channelDelegationR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L channelDelegationL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var ChannelDelegationColumns = struct {
+	ID         string
+	ChannelID  string
+	OwnerID    string
+	DelegateID string
+	CreatedAt  string
+}{
+	ID:         "id",
+	ChannelID:  "channel_id",
+	OwnerID:    "owner_id",
+	DelegateID: "delegate_id",
+	CreatedAt:  "created_at",
+}
+
+// Generated where
+
+type whereHelperint struct{ field string }
+
+func (w whereHelperint) EQ(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperint) NEQ(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperint) LT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperint) LTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperint) GT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperint) GTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
+type whereHelperstring struct{ field string }
+
+func (w whereHelperstring) EQ(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperstring) NEQ(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperstring) LT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperstring) LTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperstring) GT(x string) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperstring) GTE(x string) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
+type whereHelpertime_Time struct{ field string }
+
+func (w whereHelpertime_Time) EQ(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.EQ, x)
+}
+func (w whereHelpertime_Time) NEQ(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.NEQ, x)
+}
+func (w whereHelpertime_Time) LT(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpertime_Time) LTE(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpertime_Time) GT(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpertime_Time) GTE(x time.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+var ChannelDelegationWhere = struct {
+	ID         whereHelperint
+	ChannelID  whereHelperstring
+	OwnerID    whereHelperint
+	DelegateID whereHelperint
+	CreatedAt  whereHelpertime_Time
+}{
+	ID:         whereHelperint{field: "\"channel_delegations\".\"id\""},
+	ChannelID:  whereHelperstring{field: "\"channel_delegations\".\"channel_id\""},
+	OwnerID:    whereHelperint{field: "\"channel_delegations\".\"owner_id\""},
+	DelegateID: whereHelperint{field: "\"channel_delegations\".\"delegate_id\""},
+	CreatedAt:  whereHelpertime_Time{field: "\"channel_delegations\".\"created_at\""},
+}
+
+// ChannelDelegationRels is where relationship names are stored.
+var ChannelDelegationRels = struct {
+}{}
+
+// channelDelegationR is where relationships are stored.
+type channelDelegationR struct {
+}
+
+// NewStruct creates a new relationship struct
+func (*channelDelegationR) NewStruct() *channelDelegationR {
+	return &channelDelegationR{}
+}
+
+// channelDelegationL is where Load methods for each relationship are stored.
+type channelDelegationL struct{}
+
+var (
+	channelDelegationAllColumns            = []string{"id", "channel_id", "owner_id", "delegate_id", "created_at"}
+	channelDelegationColumnsWithoutDefault = []string{"channel_id", "owner_id", "delegate_id"}
+	channelDelegationColumnsWithDefault    = []string{"id", "created_at"}
+	channelDelegationPrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// ChannelDelegationSlice is an alias for a slice of pointers to ChannelDelegation.
+	// This should generally be used opposed to []ChannelDelegation.
+	ChannelDelegationSlice []*ChannelDelegation
+	// ChannelDelegationHook is the signature for custom ChannelDelegation hook methods
+	ChannelDelegationHook func(boil.Executor, *ChannelDelegation) error
+
+	channelDelegationQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	channelDelegationType                 = reflect.TypeOf(&ChannelDelegation{})
+	channelDelegationMapping              = queries.MakeStructMapping(channelDelegationType)
+	channelDelegationPrimaryKeyMapping, _ = queries.BindMapping(channelDelegationType, channelDelegationMapping, channelDelegationPrimaryKeyColumns)
+	channelDelegationInsertCacheMut       sync.RWMutex
+	channelDelegationInsertCache          = make(map[string]insertCache)
+	channelDelegationUpdateCacheMut       sync.RWMutex
+	channelDelegationUpdateCache          = make(map[string]updateCache)
+	channelDelegationUpsertCacheMut       sync.RWMutex
+	channelDelegationUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var channelDelegationBeforeInsertHooks []ChannelDelegationHook
+var channelDelegationBeforeUpdateHooks []ChannelDelegationHook
+var channelDelegationBeforeDeleteHooks []ChannelDelegationHook
+var channelDelegationBeforeUpsertHooks []ChannelDelegationHook
+
+var channelDelegationAfterInsertHooks []ChannelDelegationHook
+var channelDelegationAfterSelectHooks []ChannelDelegationHook
+var channelDelegationAfterUpdateHooks []ChannelDelegationHook
+var channelDelegationAfterDeleteHooks []ChannelDelegationHook
+var channelDelegationAfterUpsertHooks []ChannelDelegationHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *ChannelDelegation) doBeforeInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationBeforeInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *ChannelDelegation) doBeforeUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationBeforeUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *ChannelDelegation) doBeforeDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationBeforeDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *ChannelDelegation) doBeforeUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationBeforeUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *ChannelDelegation) doAfterInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationAfterInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *ChannelDelegation) doAfterSelectHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationAfterSelectHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *ChannelDelegation) doAfterUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationAfterUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *ChannelDelegation) doAfterDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationAfterDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *ChannelDelegation) doAfterUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range channelDelegationAfterUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddChannelDelegationHook registers your hook function for all future operations.
+func AddChannelDelegationHook(hookPoint boil.HookPoint, channelDelegationHook ChannelDelegationHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		channelDelegationBeforeInsertHooks = append(channelDelegationBeforeInsertHooks, channelDelegationHook)
+	case boil.BeforeUpdateHook:
+		channelDelegationBeforeUpdateHooks = append(channelDelegationBeforeUpdateHooks, channelDelegationHook)
+	case boil.BeforeDeleteHook:
+		channelDelegationBeforeDeleteHooks = append(channelDelegationBeforeDeleteHooks, channelDelegationHook)
+	case boil.BeforeUpsertHook:
+		channelDelegationBeforeUpsertHooks = append(channelDelegationBeforeUpsertHooks, channelDelegationHook)
+	case boil.AfterInsertHook:
+		channelDelegationAfterInsertHooks = append(channelDelegationAfterInsertHooks, channelDelegationHook)
+	case boil.AfterSelectHook:
+		channelDelegationAfterSelectHooks = append(channelDelegationAfterSelectHooks, channelDelegationHook)
+	case boil.AfterUpdateHook:
+		channelDelegationAfterUpdateHooks = append(channelDelegationAfterUpdateHooks, channelDelegationHook)
+	case boil.AfterDeleteHook:
+		channelDelegationAfterDeleteHooks = append(channelDelegationAfterDeleteHooks, channelDelegationHook)
+	case boil.AfterUpsertHook:
+		channelDelegationAfterUpsertHooks = append(channelDelegationAfterUpsertHooks, channelDelegationHook)
+	}
+}
+
+// OneG returns a single channelDelegation record from the query using the global executor.
+func (q channelDelegationQuery) OneG() (*ChannelDelegation, error) {
+	return q.One(boil.GetDB())
+}
+
+// One returns a single channelDelegation record from the query.
+func (q channelDelegationQuery) One(exec boil.Executor) (*ChannelDelegation, error) {
+	o := &ChannelDelegation{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(nil, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for channel_delegations")
+	}
+
+	if err := o.doAfterSelectHooks(exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all ChannelDelegation records from the query using the global executor.
+func (q channelDelegationQuery) AllG() (ChannelDelegationSlice, error) {
+	return q.All(boil.GetDB())
+}
+
+// All returns all ChannelDelegation records from the query.
+func (q channelDelegationQuery) All(exec boil.Executor) (ChannelDelegationSlice, error) {
+	var o []*ChannelDelegation
+
+	err := q.Bind(nil, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to ChannelDelegation slice")
+	}
+
+	if len(channelDelegationAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all ChannelDelegation records in the query, and panics on error.
+func (q channelDelegationQuery) CountG() (int64, error) {
+	return q.Count(boil.GetDB())
+}
+
+// Count returns the count of all ChannelDelegation records in the query.
+func (q channelDelegationQuery) Count(exec boil.Executor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count channel_delegations rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table, and panics on error.
+func (q channelDelegationQuery) ExistsG() (bool, error) {
+	return q.Exists(boil.GetDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q channelDelegationQuery) Exists(exec boil.Executor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if channel_delegations exists")
+	}
+
+	return count > 0, nil
+}
+
+// ChannelDelegations retrieves all the records using an executor.
+func ChannelDelegations(mods ...qm.QueryMod) channelDelegationQuery {
+	mods = append(mods, qm.From("\"channel_delegations\""))
+	return channelDelegationQuery{NewQuery(mods...)}
+}
+
+// FindChannelDelegationG retrieves a single record by ID.
+func FindChannelDelegationG(iD int, selectCols ...string) (*ChannelDelegation, error) {
+	return FindChannelDelegation(boil.GetDB(), iD, selectCols...)
+}
+
+// FindChannelDelegation retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindChannelDelegation(exec boil.Executor, iD int, selectCols ...string) (*ChannelDelegation, error) {
+	channelDelegationObj := &ChannelDelegation{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"channel_delegations\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(nil, exec, channelDelegationObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from channel_delegations")
+	}
+
+	return channelDelegationObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *ChannelDelegation) InsertG(columns boil.Columns) error {
+	return o.Insert(boil.GetDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *ChannelDelegation) Insert(exec boil.Executor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no channel_delegations provided for insertion")
+	}
+
+	var err error
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeInsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(channelDelegationColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	channelDelegationInsertCacheMut.RLock()
+	cache, cached := channelDelegationInsertCache[key]
+	channelDelegationInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			channelDelegationAllColumns,
+			channelDelegationColumnsWithDefault,
+			channelDelegationColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(channelDelegationType, channelDelegationMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(channelDelegationType, channelDelegationMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"channel_delegations\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"channel_delegations\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into channel_delegations")
+	}
+
+	if !cached {
+		channelDelegationInsertCacheMut.Lock()
+		channelDelegationInsertCache[key] = cache
+		channelDelegationInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(exec)
+}
+
+// UpdateG a single ChannelDelegation record using the global executor.
+// See Update for more documentation.
+func (o *ChannelDelegation) UpdateG(columns boil.Columns) (int64, error) {
+	return o.Update(boil.GetDB(), columns)
+}
+
+// Update uses an executor to update the ChannelDelegation.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *ChannelDelegation) Update(exec boil.Executor, columns boil.Columns) (int64, error) {
+	var err error
+	if err = o.doBeforeUpdateHooks(exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	channelDelegationUpdateCacheMut.RLock()
+	cache, cached := channelDelegationUpdateCache[key]
+	channelDelegationUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			channelDelegationAllColumns,
+			channelDelegationPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update channel_delegations, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"channel_delegations\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, channelDelegationPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(channelDelegationType, channelDelegationMapping, append(wl, channelDelegationPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, values)
+	}
+
+	var result sql.Result
+	result, err = exec.Exec(cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update channel_delegations row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for channel_delegations")
+	}
+
+	if !cached {
+		channelDelegationUpdateCacheMut.Lock()
+		channelDelegationUpdateCache[key] = cache
+		channelDelegationUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q channelDelegationQuery) UpdateAllG(cols M) (int64, error) {
+	return q.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q channelDelegationQuery) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for channel_delegations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for channel_delegations")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o ChannelDelegationSlice) UpdateAllG(cols M) (int64, error) {
+	return o.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o ChannelDelegationSlice) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), channelDelegationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"channel_delegations\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, channelDelegationPrimaryKeyColumns, len(o)))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in channelDelegation slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all channelDelegation")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *ChannelDelegation) UpsertG(updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(boil.GetDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *ChannelDelegation) Upsert(exec boil.Executor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no channel_delegations provided for upsert")
+	}
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeUpsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(channelDelegationColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	channelDelegationUpsertCacheMut.RLock()
+	cache, cached := channelDelegationUpsertCache[key]
+	channelDelegationUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			channelDelegationAllColumns,
+			channelDelegationColumnsWithDefault,
+			channelDelegationColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			channelDelegationAllColumns,
+			channelDelegationPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert channel_delegations, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(channelDelegationPrimaryKeyColumns))
+			copy(conflict, channelDelegationPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"channel_delegations\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(channelDelegationType, channelDelegationMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(channelDelegationType, channelDelegationMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert channel_delegations")
+	}
+
+	if !cached {
+		channelDelegationUpsertCacheMut.Lock()
+		channelDelegationUpsertCache[key] = cache
+		channelDelegationUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(exec)
+}
+
+// DeleteG deletes a single ChannelDelegation record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *ChannelDelegation) DeleteG() (int64, error) {
+	return o.Delete(boil.GetDB())
+}
+
+// Delete deletes a single ChannelDelegation record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *ChannelDelegation) Delete(exec boil.Executor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no ChannelDelegation provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), channelDelegationPrimaryKeyMapping)
+	sql := "DELETE FROM \"channel_delegations\" WHERE \"id\"=$1"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from channel_delegations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for channel_delegations")
+	}
+
+	if err := o.doAfterDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q channelDelegationQuery) DeleteAll(exec boil.Executor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no channelDelegationQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from channel_delegations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for channel_delegations")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o ChannelDelegationSlice) DeleteAllG() (int64, error) {
+	return o.DeleteAll(boil.GetDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o ChannelDelegationSlice) DeleteAll(exec boil.Executor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(channelDelegationBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), channelDelegationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"channel_delegations\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, channelDelegationPrimaryKeyColumns, len(o))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from channelDelegation slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for channel_delegations")
+	}
+
+	if len(channelDelegationAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *ChannelDelegation) ReloadG() error {
+	if o == nil {
+		return errors.New("models: no ChannelDelegation provided for reload")
+	}
+
+	return o.Reload(boil.GetDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *ChannelDelegation) Reload(exec boil.Executor) error {
+	ret, err := FindChannelDelegation(exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *ChannelDelegationSlice) ReloadAllG() error {
+	if o == nil {
+		return errors.New("models: empty ChannelDelegationSlice provided for reload all")
+	}
+
+	return o.ReloadAll(boil.GetDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *ChannelDelegationSlice) ReloadAll(exec boil.Executor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := ChannelDelegationSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), channelDelegationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"channel_delegations\".* FROM \"channel_delegations\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, channelDelegationPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(nil, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in ChannelDelegationSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// ChannelDelegationExistsG checks if the ChannelDelegation row exists.
+func ChannelDelegationExistsG(iD int) (bool, error) {
+	return ChannelDelegationExists(boil.GetDB(), iD)
+}
+
+// ChannelDelegationExists checks if the ChannelDelegation row exists.
+func ChannelDelegationExists(exec boil.Executor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"channel_delegations\" where \"id\"=$1 limit 1)"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, iD)
+	}
+
+	row := exec.QueryRow(sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if channel_delegations exists")
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testChannelDelegations(t *testing.T) {
+	t.Parallel()
+
+	query := ChannelDelegations()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testChannelDelegationsDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testChannelDelegationsQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := ChannelDelegations().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testChannelDelegationsSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := ChannelDelegationSlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testChannelDelegationsExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := ChannelDelegationExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if ChannelDelegation exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected ChannelDelegationExists to return true, but got false.")
+	}
+}
+
+func testChannelDelegationsFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	channelDelegationFound, err := FindChannelDelegation(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if channelDelegationFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testChannelDelegationsBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = ChannelDelegations().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testChannelDelegationsOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := ChannelDelegations().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testChannelDelegationsAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	channelDelegationOne := &ChannelDelegation{}
+	channelDelegationTwo := &ChannelDelegation{}
+	if err = randomize.Struct(seed, channelDelegationOne, channelDelegationDBTypes, false, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+	if err = randomize.Struct(seed, channelDelegationTwo, channelDelegationDBTypes, false, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = channelDelegationOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = channelDelegationTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := ChannelDelegations().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testChannelDelegationsCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	channelDelegationOne := &ChannelDelegation{}
+	channelDelegationTwo := &ChannelDelegation{}
+	if err = randomize.Struct(seed, channelDelegationOne, channelDelegationDBTypes, false, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+	if err = randomize.Struct(seed, channelDelegationTwo, channelDelegationDBTypes, false, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = channelDelegationOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = channelDelegationTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func channelDelegationBeforeInsertHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationAfterInsertHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationAfterSelectHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationBeforeUpdateHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationAfterUpdateHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationBeforeDeleteHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationAfterDeleteHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationBeforeUpsertHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func channelDelegationAfterUpsertHook(e boil.Executor, o *ChannelDelegation) error {
+	*o = ChannelDelegation{}
+	return nil
+}
+
+func testChannelDelegationsHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &ChannelDelegation{}
+	o := &ChannelDelegation{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation object: %s", err)
+	}
+
+	AddChannelDelegationHook(boil.BeforeInsertHook, channelDelegationBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationBeforeInsertHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.AfterInsertHook, channelDelegationAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationAfterInsertHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.AfterSelectHook, channelDelegationAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationAfterSelectHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.BeforeUpdateHook, channelDelegationBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationBeforeUpdateHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.AfterUpdateHook, channelDelegationAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationAfterUpdateHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.BeforeDeleteHook, channelDelegationBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationBeforeDeleteHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.AfterDeleteHook, channelDelegationAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationAfterDeleteHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.BeforeUpsertHook, channelDelegationBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationBeforeUpsertHooks = []ChannelDelegationHook{}
+
+	AddChannelDelegationHook(boil.AfterUpsertHook, channelDelegationAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	channelDelegationAfterUpsertHooks = []ChannelDelegationHook{}
+}
+
+func testChannelDelegationsInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testChannelDelegationsInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(channelDelegationColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testChannelDelegationsReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testChannelDelegationsReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := ChannelDelegationSlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testChannelDelegationsSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := ChannelDelegations().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	channelDelegationDBTypes = map[string]string{`ID`: `integer`, `ChannelID`: `character varying`, `OwnerID`: `integer`, `DelegateID`: `integer`, `CreatedAt`: `timestamp without time zone`}
+	_                        = bytes.MinRead
+)
+
+func testChannelDelegationsUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(channelDelegationPrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(channelDelegationAllColumns) == len(channelDelegationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testChannelDelegationsSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(channelDelegationAllColumns) == len(channelDelegationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &ChannelDelegation{}
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, channelDelegationDBTypes, true, channelDelegationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(channelDelegationAllColumns, channelDelegationPrimaryKeyColumns) {
+		fields = channelDelegationAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			channelDelegationAllColumns,
+			channelDelegationPrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := ChannelDelegationSlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testChannelDelegationsUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(channelDelegationAllColumns) == len(channelDelegationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := ChannelDelegation{}
+	if err = randomize.Struct(seed, &o, channelDelegationDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert ChannelDelegation: %s", err)
+	}
+
+	count, err := ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, channelDelegationDBTypes, false, channelDelegationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize ChannelDelegation struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert ChannelDelegation: %s", err)
+	}
+
+	count, err = ChannelDelegations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
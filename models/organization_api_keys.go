@@ -0,0 +1,946 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+	"github.com/volatiletech/sqlboiler/queries/qmhelper"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+// OrganizationAPIKey is an object representing the database table.
+type OrganizationAPIKey struct {
+	ID             int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	OrganizationID int       `boil:"organization_id" json:"organization_id" toml:"organization_id" yaml:"organization_id"`
+	UserID         int       `boil:"user_id" json:"user_id" toml:"user_id" yaml:"user_id"`
+	Name           string    `boil:"name" json:"name" toml:"name" yaml:"name"`
+	KeyHash        string    `boil:"key_hash" json:"key_hash" toml:"key_hash" yaml:"key_hash"`
+	CreatedAt      time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+
+	R *organizationAPIKeyR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L organizationAPIKeyL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var OrganizationAPIKeyColumns = struct {
+	ID             string
+	OrganizationID string
+	UserID         string
+	Name           string
+	KeyHash        string
+	CreatedAt      string
+}{
+	ID:             "id",
+	OrganizationID: "organization_id",
+	UserID:         "user_id",
+	Name:           "name",
+	KeyHash:        "key_hash",
+	CreatedAt:      "created_at",
+}
+
+// Generated where
+
+var OrganizationAPIKeyWhere = struct {
+	ID             whereHelperint
+	OrganizationID whereHelperint
+	UserID         whereHelperint
+	Name           whereHelperstring
+	KeyHash        whereHelperstring
+	CreatedAt      whereHelpertime_Time
+}{
+	ID:             whereHelperint{field: "\"organization_api_keys\".\"id\""},
+	OrganizationID: whereHelperint{field: "\"organization_api_keys\".\"organization_id\""},
+	UserID:         whereHelperint{field: "\"organization_api_keys\".\"user_id\""},
+	Name:           whereHelperstring{field: "\"organization_api_keys\".\"name\""},
+	KeyHash:        whereHelperstring{field: "\"organization_api_keys\".\"key_hash\""},
+	CreatedAt:      whereHelpertime_Time{field: "\"organization_api_keys\".\"created_at\""},
+}
+
+// OrganizationAPIKeyRels is where relationship names are stored.
+var OrganizationAPIKeyRels = struct {
+}{}
+
+// organizationAPIKeyR is where relationships are stored.
+type organizationAPIKeyR struct {
+}
+
+// NewStruct creates a new relationship struct
+func (*organizationAPIKeyR) NewStruct() *organizationAPIKeyR {
+	return &organizationAPIKeyR{}
+}
+
+// organizationAPIKeyL is where Load methods for each relationship are stored.
+type organizationAPIKeyL struct{}
+
+var (
+	organizationAPIKeyAllColumns            = []string{"id", "organization_id", "user_id", "name", "key_hash", "created_at"}
+	organizationAPIKeyColumnsWithoutDefault = []string{"organization_id", "user_id", "name", "key_hash"}
+	organizationAPIKeyColumnsWithDefault    = []string{"id", "created_at"}
+	organizationAPIKeyPrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// OrganizationAPIKeySlice is an alias for a slice of pointers to OrganizationAPIKey.
+	// This should generally be used opposed to []OrganizationAPIKey.
+	OrganizationAPIKeySlice []*OrganizationAPIKey
+	// OrganizationAPIKeyHook is the signature for custom OrganizationAPIKey hook methods
+	OrganizationAPIKeyHook func(boil.Executor, *OrganizationAPIKey) error
+
+	organizationAPIKeyQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	organizationAPIKeyType                 = reflect.TypeOf(&OrganizationAPIKey{})
+	organizationAPIKeyMapping              = queries.MakeStructMapping(organizationAPIKeyType)
+	organizationAPIKeyPrimaryKeyMapping, _ = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, organizationAPIKeyPrimaryKeyColumns)
+	organizationAPIKeyInsertCacheMut       sync.RWMutex
+	organizationAPIKeyInsertCache          = make(map[string]insertCache)
+	organizationAPIKeyUpdateCacheMut       sync.RWMutex
+	organizationAPIKeyUpdateCache          = make(map[string]updateCache)
+	organizationAPIKeyUpsertCacheMut       sync.RWMutex
+	organizationAPIKeyUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var organizationAPIKeyBeforeInsertHooks []OrganizationAPIKeyHook
+var organizationAPIKeyBeforeUpdateHooks []OrganizationAPIKeyHook
+var organizationAPIKeyBeforeDeleteHooks []OrganizationAPIKeyHook
+var organizationAPIKeyBeforeUpsertHooks []OrganizationAPIKeyHook
+
+var organizationAPIKeyAfterInsertHooks []OrganizationAPIKeyHook
+var organizationAPIKeyAfterSelectHooks []OrganizationAPIKeyHook
+var organizationAPIKeyAfterUpdateHooks []OrganizationAPIKeyHook
+var organizationAPIKeyAfterDeleteHooks []OrganizationAPIKeyHook
+var organizationAPIKeyAfterUpsertHooks []OrganizationAPIKeyHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *OrganizationAPIKey) doBeforeInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyBeforeInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *OrganizationAPIKey) doBeforeUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyBeforeUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *OrganizationAPIKey) doBeforeDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyBeforeDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *OrganizationAPIKey) doBeforeUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyBeforeUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *OrganizationAPIKey) doAfterInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyAfterInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *OrganizationAPIKey) doAfterSelectHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyAfterSelectHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *OrganizationAPIKey) doAfterUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyAfterUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *OrganizationAPIKey) doAfterDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyAfterDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *OrganizationAPIKey) doAfterUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAPIKeyAfterUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddOrganizationAPIKeyHook registers your hook function for all future operations.
+func AddOrganizationAPIKeyHook(hookPoint boil.HookPoint, organizationAPIKeyHook OrganizationAPIKeyHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		organizationAPIKeyBeforeInsertHooks = append(organizationAPIKeyBeforeInsertHooks, organizationAPIKeyHook)
+	case boil.BeforeUpdateHook:
+		organizationAPIKeyBeforeUpdateHooks = append(organizationAPIKeyBeforeUpdateHooks, organizationAPIKeyHook)
+	case boil.BeforeDeleteHook:
+		organizationAPIKeyBeforeDeleteHooks = append(organizationAPIKeyBeforeDeleteHooks, organizationAPIKeyHook)
+	case boil.BeforeUpsertHook:
+		organizationAPIKeyBeforeUpsertHooks = append(organizationAPIKeyBeforeUpsertHooks, organizationAPIKeyHook)
+	case boil.AfterInsertHook:
+		organizationAPIKeyAfterInsertHooks = append(organizationAPIKeyAfterInsertHooks, organizationAPIKeyHook)
+	case boil.AfterSelectHook:
+		organizationAPIKeyAfterSelectHooks = append(organizationAPIKeyAfterSelectHooks, organizationAPIKeyHook)
+	case boil.AfterUpdateHook:
+		organizationAPIKeyAfterUpdateHooks = append(organizationAPIKeyAfterUpdateHooks, organizationAPIKeyHook)
+	case boil.AfterDeleteHook:
+		organizationAPIKeyAfterDeleteHooks = append(organizationAPIKeyAfterDeleteHooks, organizationAPIKeyHook)
+	case boil.AfterUpsertHook:
+		organizationAPIKeyAfterUpsertHooks = append(organizationAPIKeyAfterUpsertHooks, organizationAPIKeyHook)
+	}
+}
+
+// OneG returns a single organizationAPIKey record from the query using the global executor.
+func (q organizationAPIKeyQuery) OneG() (*OrganizationAPIKey, error) {
+	return q.One(boil.GetDB())
+}
+
+// One returns a single organizationAPIKey record from the query.
+func (q organizationAPIKeyQuery) One(exec boil.Executor) (*OrganizationAPIKey, error) {
+	o := &OrganizationAPIKey{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(nil, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for organization_api_keys")
+	}
+
+	if err := o.doAfterSelectHooks(exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all OrganizationAPIKey records from the query using the global executor.
+func (q organizationAPIKeyQuery) AllG() (OrganizationAPIKeySlice, error) {
+	return q.All(boil.GetDB())
+}
+
+// All returns all OrganizationAPIKey records from the query.
+func (q organizationAPIKeyQuery) All(exec boil.Executor) (OrganizationAPIKeySlice, error) {
+	var o []*OrganizationAPIKey
+
+	err := q.Bind(nil, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to OrganizationAPIKey slice")
+	}
+
+	if len(organizationAPIKeyAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all OrganizationAPIKey records in the query, and panics on error.
+func (q organizationAPIKeyQuery) CountG() (int64, error) {
+	return q.Count(boil.GetDB())
+}
+
+// Count returns the count of all OrganizationAPIKey records in the query.
+func (q organizationAPIKeyQuery) Count(exec boil.Executor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count organization_api_keys rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table, and panics on error.
+func (q organizationAPIKeyQuery) ExistsG() (bool, error) {
+	return q.Exists(boil.GetDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q organizationAPIKeyQuery) Exists(exec boil.Executor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if organization_api_keys exists")
+	}
+
+	return count > 0, nil
+}
+
+// OrganizationAPIKeys retrieves all the records using an executor.
+func OrganizationAPIKeys(mods ...qm.QueryMod) organizationAPIKeyQuery {
+	mods = append(mods, qm.From("\"organization_api_keys\""))
+	return organizationAPIKeyQuery{NewQuery(mods...)}
+}
+
+// FindOrganizationAPIKeyG retrieves a single record by ID.
+func FindOrganizationAPIKeyG(iD int, selectCols ...string) (*OrganizationAPIKey, error) {
+	return FindOrganizationAPIKey(boil.GetDB(), iD, selectCols...)
+}
+
+// FindOrganizationAPIKey retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindOrganizationAPIKey(exec boil.Executor, iD int, selectCols ...string) (*OrganizationAPIKey, error) {
+	organizationAPIKeyObj := &OrganizationAPIKey{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"organization_api_keys\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(nil, exec, organizationAPIKeyObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from organization_api_keys")
+	}
+
+	return organizationAPIKeyObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *OrganizationAPIKey) InsertG(columns boil.Columns) error {
+	return o.Insert(boil.GetDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *OrganizationAPIKey) Insert(exec boil.Executor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organization_api_keys provided for insertion")
+	}
+
+	var err error
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeInsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationAPIKeyColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	organizationAPIKeyInsertCacheMut.RLock()
+	cache, cached := organizationAPIKeyInsertCache[key]
+	organizationAPIKeyInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			organizationAPIKeyAllColumns,
+			organizationAPIKeyColumnsWithDefault,
+			organizationAPIKeyColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"organization_api_keys\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"organization_api_keys\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into organization_api_keys")
+	}
+
+	if !cached {
+		organizationAPIKeyInsertCacheMut.Lock()
+		organizationAPIKeyInsertCache[key] = cache
+		organizationAPIKeyInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(exec)
+}
+
+// UpdateG a single OrganizationAPIKey record using the global executor.
+// See Update for more documentation.
+func (o *OrganizationAPIKey) UpdateG(columns boil.Columns) (int64, error) {
+	return o.Update(boil.GetDB(), columns)
+}
+
+// Update uses an executor to update the OrganizationAPIKey.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *OrganizationAPIKey) Update(exec boil.Executor, columns boil.Columns) (int64, error) {
+	var err error
+	if err = o.doBeforeUpdateHooks(exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	organizationAPIKeyUpdateCacheMut.RLock()
+	cache, cached := organizationAPIKeyUpdateCache[key]
+	organizationAPIKeyUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			organizationAPIKeyAllColumns,
+			organizationAPIKeyPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update organization_api_keys, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"organization_api_keys\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, organizationAPIKeyPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, append(wl, organizationAPIKeyPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, values)
+	}
+
+	var result sql.Result
+	result, err = exec.Exec(cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update organization_api_keys row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for organization_api_keys")
+	}
+
+	if !cached {
+		organizationAPIKeyUpdateCacheMut.Lock()
+		organizationAPIKeyUpdateCache[key] = cache
+		organizationAPIKeyUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q organizationAPIKeyQuery) UpdateAllG(cols M) (int64, error) {
+	return q.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q organizationAPIKeyQuery) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for organization_api_keys")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for organization_api_keys")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o OrganizationAPIKeySlice) UpdateAllG(cols M) (int64, error) {
+	return o.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o OrganizationAPIKeySlice) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationAPIKeyPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"organization_api_keys\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, organizationAPIKeyPrimaryKeyColumns, len(o)))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in organizationAPIKey slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all organizationAPIKey")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *OrganizationAPIKey) UpsertG(updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(boil.GetDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *OrganizationAPIKey) Upsert(exec boil.Executor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organization_api_keys provided for upsert")
+	}
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeUpsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationAPIKeyColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	organizationAPIKeyUpsertCacheMut.RLock()
+	cache, cached := organizationAPIKeyUpsertCache[key]
+	organizationAPIKeyUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			organizationAPIKeyAllColumns,
+			organizationAPIKeyColumnsWithDefault,
+			organizationAPIKeyColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			organizationAPIKeyAllColumns,
+			organizationAPIKeyPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert organization_api_keys, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(organizationAPIKeyPrimaryKeyColumns))
+			copy(conflict, organizationAPIKeyPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"organization_api_keys\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(organizationAPIKeyType, organizationAPIKeyMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert organization_api_keys")
+	}
+
+	if !cached {
+		organizationAPIKeyUpsertCacheMut.Lock()
+		organizationAPIKeyUpsertCache[key] = cache
+		organizationAPIKeyUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(exec)
+}
+
+// DeleteG deletes a single OrganizationAPIKey record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *OrganizationAPIKey) DeleteG() (int64, error) {
+	return o.Delete(boil.GetDB())
+}
+
+// Delete deletes a single OrganizationAPIKey record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *OrganizationAPIKey) Delete(exec boil.Executor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no OrganizationAPIKey provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), organizationAPIKeyPrimaryKeyMapping)
+	sql := "DELETE FROM \"organization_api_keys\" WHERE \"id\"=$1"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from organization_api_keys")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for organization_api_keys")
+	}
+
+	if err := o.doAfterDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q organizationAPIKeyQuery) DeleteAll(exec boil.Executor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no organizationAPIKeyQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organization_api_keys")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organization_api_keys")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o OrganizationAPIKeySlice) DeleteAllG() (int64, error) {
+	return o.DeleteAll(boil.GetDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o OrganizationAPIKeySlice) DeleteAll(exec boil.Executor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(organizationAPIKeyBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationAPIKeyPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"organization_api_keys\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationAPIKeyPrimaryKeyColumns, len(o))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organizationAPIKey slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organization_api_keys")
+	}
+
+	if len(organizationAPIKeyAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *OrganizationAPIKey) ReloadG() error {
+	if o == nil {
+		return errors.New("models: no OrganizationAPIKey provided for reload")
+	}
+
+	return o.Reload(boil.GetDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *OrganizationAPIKey) Reload(exec boil.Executor) error {
+	ret, err := FindOrganizationAPIKey(exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationAPIKeySlice) ReloadAllG() error {
+	if o == nil {
+		return errors.New("models: empty OrganizationAPIKeySlice provided for reload all")
+	}
+
+	return o.ReloadAll(boil.GetDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationAPIKeySlice) ReloadAll(exec boil.Executor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := OrganizationAPIKeySlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationAPIKeyPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"organization_api_keys\".* FROM \"organization_api_keys\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationAPIKeyPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(nil, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in OrganizationAPIKeySlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// OrganizationAPIKeyExistsG checks if the OrganizationAPIKey row exists.
+func OrganizationAPIKeyExistsG(iD int) (bool, error) {
+	return OrganizationAPIKeyExists(boil.GetDB(), iD)
+}
+
+// OrganizationAPIKeyExists checks if the OrganizationAPIKey row exists.
+func OrganizationAPIKeyExists(exec boil.Executor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"organization_api_keys\" where \"id\"=$1 limit 1)"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, iD)
+	}
+
+	row := exec.QueryRow(sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if organization_api_keys exists")
+	}
+
+	return exists, nil
+}
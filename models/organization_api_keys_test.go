@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testOrganizationAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	query := OrganizationAPIKeys()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testOrganizationAPIKeysDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationAPIKeysQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := OrganizationAPIKeys().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationAPIKeysSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationAPIKeySlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationAPIKeysExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := OrganizationAPIKeyExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if OrganizationAPIKey exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected OrganizationAPIKeyExists to return true, but got false.")
+	}
+}
+
+func testOrganizationAPIKeysFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	organizationAPIKeyFound, err := FindOrganizationAPIKey(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if organizationAPIKeyFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testOrganizationAPIKeysBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = OrganizationAPIKeys().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationAPIKeysOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := OrganizationAPIKeys().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testOrganizationAPIKeysAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	organizationAPIKeyOne := &OrganizationAPIKey{}
+	organizationAPIKeyTwo := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, organizationAPIKeyOne, organizationAPIKeyDBTypes, false, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationAPIKeyTwo, organizationAPIKeyDBTypes, false, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationAPIKeyOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationAPIKeyTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationAPIKeys().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testOrganizationAPIKeysCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	organizationAPIKeyOne := &OrganizationAPIKey{}
+	organizationAPIKeyTwo := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, organizationAPIKeyOne, organizationAPIKeyDBTypes, false, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationAPIKeyTwo, organizationAPIKeyDBTypes, false, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationAPIKeyOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationAPIKeyTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func organizationAPIKeyBeforeInsertHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyAfterInsertHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyAfterSelectHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyBeforeUpdateHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyAfterUpdateHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyBeforeDeleteHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyAfterDeleteHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyBeforeUpsertHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func organizationAPIKeyAfterUpsertHook(e boil.Executor, o *OrganizationAPIKey) error {
+	*o = OrganizationAPIKey{}
+	return nil
+}
+
+func testOrganizationAPIKeysHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &OrganizationAPIKey{}
+	o := &OrganizationAPIKey{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey object: %s", err)
+	}
+
+	AddOrganizationAPIKeyHook(boil.BeforeInsertHook, organizationAPIKeyBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyBeforeInsertHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.AfterInsertHook, organizationAPIKeyAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyAfterInsertHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.AfterSelectHook, organizationAPIKeyAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyAfterSelectHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.BeforeUpdateHook, organizationAPIKeyBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyBeforeUpdateHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.AfterUpdateHook, organizationAPIKeyAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyAfterUpdateHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.BeforeDeleteHook, organizationAPIKeyBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyBeforeDeleteHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.AfterDeleteHook, organizationAPIKeyAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyAfterDeleteHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.BeforeUpsertHook, organizationAPIKeyBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyBeforeUpsertHooks = []OrganizationAPIKeyHook{}
+
+	AddOrganizationAPIKeyHook(boil.AfterUpsertHook, organizationAPIKeyAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAPIKeyAfterUpsertHooks = []OrganizationAPIKeyHook{}
+}
+
+func testOrganizationAPIKeysInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationAPIKeysInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(organizationAPIKeyColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationAPIKeysReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationAPIKeysReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationAPIKeySlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationAPIKeysSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationAPIKeys().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	organizationAPIKeyDBTypes = map[string]string{`ID`: `integer`, `OrganizationID`: `integer`, `UserID`: `integer`, `Name`: `character varying`, `KeyHash`: `character varying`, `CreatedAt`: `timestamp without time zone`}
+	_                         = bytes.MinRead
+)
+
+func testOrganizationAPIKeysUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(organizationAPIKeyPrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(organizationAPIKeyAllColumns) == len(organizationAPIKeyPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testOrganizationAPIKeysSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationAPIKeyAllColumns) == len(organizationAPIKeyPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationAPIKey{}
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationAPIKeyDBTypes, true, organizationAPIKeyPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(organizationAPIKeyAllColumns, organizationAPIKeyPrimaryKeyColumns) {
+		fields = organizationAPIKeyAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			organizationAPIKeyAllColumns,
+			organizationAPIKeyPrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := OrganizationAPIKeySlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testOrganizationAPIKeysUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationAPIKeyAllColumns) == len(organizationAPIKeyPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := OrganizationAPIKey{}
+	if err = randomize.Struct(seed, &o, organizationAPIKeyDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationAPIKey: %s", err)
+	}
+
+	count, err := OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, organizationAPIKeyDBTypes, false, organizationAPIKeyPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationAPIKey struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationAPIKey: %s", err)
+	}
+
+	count, err = OrganizationAPIKeys().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
@@ -0,0 +1,983 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+	"github.com/volatiletech/sqlboiler/queries/qmhelper"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+// OrganizationDraft is an object representing the database table.
+type OrganizationDraft struct {
+	ID             int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	OrganizationID int       `boil:"organization_id" json:"organization_id" toml:"organization_id" yaml:"organization_id"`
+	UserID         int       `boil:"user_id" json:"user_id" toml:"user_id" yaml:"user_id"`
+	Title          string    `boil:"title" json:"title" toml:"title" yaml:"title"`
+	Params         null.JSON `boil:"params" json:"params,omitempty" toml:"params" yaml:"params,omitempty"`
+	CreatedAt      time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	UpdatedAt      time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+
+	R *organizationDraftR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L organizationDraftL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var OrganizationDraftColumns = struct {
+	ID             string
+	OrganizationID string
+	UserID         string
+	Title          string
+	Params         string
+	CreatedAt      string
+	UpdatedAt      string
+}{
+	ID:             "id",
+	OrganizationID: "organization_id",
+	UserID:         "user_id",
+	Title:          "title",
+	Params:         "params",
+	CreatedAt:      "created_at",
+	UpdatedAt:      "updated_at",
+}
+
+// Generated where
+
+type whereHelpernull_JSON struct{ field string }
+
+func (w whereHelpernull_JSON) EQ(x null.JSON) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_JSON) NEQ(x null.JSON) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_JSON) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_JSON) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+func (w whereHelpernull_JSON) LT(x null.JSON) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_JSON) LTE(x null.JSON) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_JSON) GT(x null.JSON) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_JSON) GTE(x null.JSON) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+var OrganizationDraftWhere = struct {
+	ID             whereHelperint
+	OrganizationID whereHelperint
+	UserID         whereHelperint
+	Title          whereHelperstring
+	Params         whereHelpernull_JSON
+	CreatedAt      whereHelpertime_Time
+	UpdatedAt      whereHelpertime_Time
+}{
+	ID:             whereHelperint{field: "\"organization_drafts\".\"id\""},
+	OrganizationID: whereHelperint{field: "\"organization_drafts\".\"organization_id\""},
+	UserID:         whereHelperint{field: "\"organization_drafts\".\"user_id\""},
+	Title:          whereHelperstring{field: "\"organization_drafts\".\"title\""},
+	Params:         whereHelpernull_JSON{field: "\"organization_drafts\".\"params\""},
+	CreatedAt:      whereHelpertime_Time{field: "\"organization_drafts\".\"created_at\""},
+	UpdatedAt:      whereHelpertime_Time{field: "\"organization_drafts\".\"updated_at\""},
+}
+
+// OrganizationDraftRels is where relationship names are stored.
+var OrganizationDraftRels = struct {
+}{}
+
+// organizationDraftR is where relationships are stored.
+type organizationDraftR struct {
+}
+
+// NewStruct creates a new relationship struct
+func (*organizationDraftR) NewStruct() *organizationDraftR {
+	return &organizationDraftR{}
+}
+
+// organizationDraftL is where Load methods for each relationship are stored.
+type organizationDraftL struct{}
+
+var (
+	organizationDraftAllColumns            = []string{"id", "organization_id", "user_id", "title", "params", "created_at", "updated_at"}
+	organizationDraftColumnsWithoutDefault = []string{"organization_id", "user_id", "title", "params"}
+	organizationDraftColumnsWithDefault    = []string{"id", "created_at", "updated_at"}
+	organizationDraftPrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// OrganizationDraftSlice is an alias for a slice of pointers to OrganizationDraft.
+	// This should generally be used opposed to []OrganizationDraft.
+	OrganizationDraftSlice []*OrganizationDraft
+	// OrganizationDraftHook is the signature for custom OrganizationDraft hook methods
+	OrganizationDraftHook func(boil.Executor, *OrganizationDraft) error
+
+	organizationDraftQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	organizationDraftType                 = reflect.TypeOf(&OrganizationDraft{})
+	organizationDraftMapping              = queries.MakeStructMapping(organizationDraftType)
+	organizationDraftPrimaryKeyMapping, _ = queries.BindMapping(organizationDraftType, organizationDraftMapping, organizationDraftPrimaryKeyColumns)
+	organizationDraftInsertCacheMut       sync.RWMutex
+	organizationDraftInsertCache          = make(map[string]insertCache)
+	organizationDraftUpdateCacheMut       sync.RWMutex
+	organizationDraftUpdateCache          = make(map[string]updateCache)
+	organizationDraftUpsertCacheMut       sync.RWMutex
+	organizationDraftUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var organizationDraftBeforeInsertHooks []OrganizationDraftHook
+var organizationDraftBeforeUpdateHooks []OrganizationDraftHook
+var organizationDraftBeforeDeleteHooks []OrganizationDraftHook
+var organizationDraftBeforeUpsertHooks []OrganizationDraftHook
+
+var organizationDraftAfterInsertHooks []OrganizationDraftHook
+var organizationDraftAfterSelectHooks []OrganizationDraftHook
+var organizationDraftAfterUpdateHooks []OrganizationDraftHook
+var organizationDraftAfterDeleteHooks []OrganizationDraftHook
+var organizationDraftAfterUpsertHooks []OrganizationDraftHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *OrganizationDraft) doBeforeInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftBeforeInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *OrganizationDraft) doBeforeUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftBeforeUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *OrganizationDraft) doBeforeDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftBeforeDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *OrganizationDraft) doBeforeUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftBeforeUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *OrganizationDraft) doAfterInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftAfterInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *OrganizationDraft) doAfterSelectHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftAfterSelectHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *OrganizationDraft) doAfterUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftAfterUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *OrganizationDraft) doAfterDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftAfterDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *OrganizationDraft) doAfterUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationDraftAfterUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddOrganizationDraftHook registers your hook function for all future operations.
+func AddOrganizationDraftHook(hookPoint boil.HookPoint, organizationDraftHook OrganizationDraftHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		organizationDraftBeforeInsertHooks = append(organizationDraftBeforeInsertHooks, organizationDraftHook)
+	case boil.BeforeUpdateHook:
+		organizationDraftBeforeUpdateHooks = append(organizationDraftBeforeUpdateHooks, organizationDraftHook)
+	case boil.BeforeDeleteHook:
+		organizationDraftBeforeDeleteHooks = append(organizationDraftBeforeDeleteHooks, organizationDraftHook)
+	case boil.BeforeUpsertHook:
+		organizationDraftBeforeUpsertHooks = append(organizationDraftBeforeUpsertHooks, organizationDraftHook)
+	case boil.AfterInsertHook:
+		organizationDraftAfterInsertHooks = append(organizationDraftAfterInsertHooks, organizationDraftHook)
+	case boil.AfterSelectHook:
+		organizationDraftAfterSelectHooks = append(organizationDraftAfterSelectHooks, organizationDraftHook)
+	case boil.AfterUpdateHook:
+		organizationDraftAfterUpdateHooks = append(organizationDraftAfterUpdateHooks, organizationDraftHook)
+	case boil.AfterDeleteHook:
+		organizationDraftAfterDeleteHooks = append(organizationDraftAfterDeleteHooks, organizationDraftHook)
+	case boil.AfterUpsertHook:
+		organizationDraftAfterUpsertHooks = append(organizationDraftAfterUpsertHooks, organizationDraftHook)
+	}
+}
+
+// OneG returns a single organizationDraft record from the query using the global executor.
+func (q organizationDraftQuery) OneG() (*OrganizationDraft, error) {
+	return q.One(boil.GetDB())
+}
+
+// One returns a single organizationDraft record from the query.
+func (q organizationDraftQuery) One(exec boil.Executor) (*OrganizationDraft, error) {
+	o := &OrganizationDraft{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(nil, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for organization_drafts")
+	}
+
+	if err := o.doAfterSelectHooks(exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all OrganizationDraft records from the query using the global executor.
+func (q organizationDraftQuery) AllG() (OrganizationDraftSlice, error) {
+	return q.All(boil.GetDB())
+}
+
+// All returns all OrganizationDraft records from the query.
+func (q organizationDraftQuery) All(exec boil.Executor) (OrganizationDraftSlice, error) {
+	var o []*OrganizationDraft
+
+	err := q.Bind(nil, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to OrganizationDraft slice")
+	}
+
+	if len(organizationDraftAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all OrganizationDraft records in the query, and panics on error.
+func (q organizationDraftQuery) CountG() (int64, error) {
+	return q.Count(boil.GetDB())
+}
+
+// Count returns the count of all OrganizationDraft records in the query.
+func (q organizationDraftQuery) Count(exec boil.Executor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count organization_drafts rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table, and panics on error.
+func (q organizationDraftQuery) ExistsG() (bool, error) {
+	return q.Exists(boil.GetDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q organizationDraftQuery) Exists(exec boil.Executor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if organization_drafts exists")
+	}
+
+	return count > 0, nil
+}
+
+// OrganizationDrafts retrieves all the records using an executor.
+func OrganizationDrafts(mods ...qm.QueryMod) organizationDraftQuery {
+	mods = append(mods, qm.From("\"organization_drafts\""))
+	return organizationDraftQuery{NewQuery(mods...)}
+}
+
+// FindOrganizationDraftG retrieves a single record by ID.
+func FindOrganizationDraftG(iD int, selectCols ...string) (*OrganizationDraft, error) {
+	return FindOrganizationDraft(boil.GetDB(), iD, selectCols...)
+}
+
+// FindOrganizationDraft retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindOrganizationDraft(exec boil.Executor, iD int, selectCols ...string) (*OrganizationDraft, error) {
+	organizationDraftObj := &OrganizationDraft{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"organization_drafts\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(nil, exec, organizationDraftObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from organization_drafts")
+	}
+
+	return organizationDraftObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *OrganizationDraft) InsertG(columns boil.Columns) error {
+	return o.Insert(boil.GetDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *OrganizationDraft) Insert(exec boil.Executor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organization_drafts provided for insertion")
+	}
+
+	var err error
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = currTime
+	}
+
+	if err := o.doBeforeInsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationDraftColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	organizationDraftInsertCacheMut.RLock()
+	cache, cached := organizationDraftInsertCache[key]
+	organizationDraftInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			organizationDraftAllColumns,
+			organizationDraftColumnsWithDefault,
+			organizationDraftColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(organizationDraftType, organizationDraftMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(organizationDraftType, organizationDraftMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"organization_drafts\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"organization_drafts\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into organization_drafts")
+	}
+
+	if !cached {
+		organizationDraftInsertCacheMut.Lock()
+		organizationDraftInsertCache[key] = cache
+		organizationDraftInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(exec)
+}
+
+// UpdateG a single OrganizationDraft record using the global executor.
+// See Update for more documentation.
+func (o *OrganizationDraft) UpdateG(columns boil.Columns) (int64, error) {
+	return o.Update(boil.GetDB(), columns)
+}
+
+// Update uses an executor to update the OrganizationDraft.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *OrganizationDraft) Update(exec boil.Executor, columns boil.Columns) (int64, error) {
+	currTime := time.Now().In(boil.GetLocation())
+
+	o.UpdatedAt = currTime
+
+	var err error
+	if err = o.doBeforeUpdateHooks(exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	organizationDraftUpdateCacheMut.RLock()
+	cache, cached := organizationDraftUpdateCache[key]
+	organizationDraftUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			organizationDraftAllColumns,
+			organizationDraftPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update organization_drafts, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"organization_drafts\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, organizationDraftPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(organizationDraftType, organizationDraftMapping, append(wl, organizationDraftPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, values)
+	}
+
+	var result sql.Result
+	result, err = exec.Exec(cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update organization_drafts row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for organization_drafts")
+	}
+
+	if !cached {
+		organizationDraftUpdateCacheMut.Lock()
+		organizationDraftUpdateCache[key] = cache
+		organizationDraftUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q organizationDraftQuery) UpdateAllG(cols M) (int64, error) {
+	return q.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q organizationDraftQuery) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for organization_drafts")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for organization_drafts")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o OrganizationDraftSlice) UpdateAllG(cols M) (int64, error) {
+	return o.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o OrganizationDraftSlice) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationDraftPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"organization_drafts\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, organizationDraftPrimaryKeyColumns, len(o)))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in organizationDraft slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all organizationDraft")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *OrganizationDraft) UpsertG(updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(boil.GetDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *OrganizationDraft) Upsert(exec boil.Executor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organization_drafts provided for upsert")
+	}
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+	o.UpdatedAt = currTime
+
+	if err := o.doBeforeUpsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationDraftColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	organizationDraftUpsertCacheMut.RLock()
+	cache, cached := organizationDraftUpsertCache[key]
+	organizationDraftUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			organizationDraftAllColumns,
+			organizationDraftColumnsWithDefault,
+			organizationDraftColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			organizationDraftAllColumns,
+			organizationDraftPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert organization_drafts, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(organizationDraftPrimaryKeyColumns))
+			copy(conflict, organizationDraftPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"organization_drafts\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(organizationDraftType, organizationDraftMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(organizationDraftType, organizationDraftMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert organization_drafts")
+	}
+
+	if !cached {
+		organizationDraftUpsertCacheMut.Lock()
+		organizationDraftUpsertCache[key] = cache
+		organizationDraftUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(exec)
+}
+
+// DeleteG deletes a single OrganizationDraft record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *OrganizationDraft) DeleteG() (int64, error) {
+	return o.Delete(boil.GetDB())
+}
+
+// Delete deletes a single OrganizationDraft record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *OrganizationDraft) Delete(exec boil.Executor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no OrganizationDraft provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), organizationDraftPrimaryKeyMapping)
+	sql := "DELETE FROM \"organization_drafts\" WHERE \"id\"=$1"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from organization_drafts")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for organization_drafts")
+	}
+
+	if err := o.doAfterDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q organizationDraftQuery) DeleteAll(exec boil.Executor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no organizationDraftQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organization_drafts")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organization_drafts")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o OrganizationDraftSlice) DeleteAllG() (int64, error) {
+	return o.DeleteAll(boil.GetDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o OrganizationDraftSlice) DeleteAll(exec boil.Executor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(organizationDraftBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationDraftPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"organization_drafts\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationDraftPrimaryKeyColumns, len(o))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organizationDraft slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organization_drafts")
+	}
+
+	if len(organizationDraftAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *OrganizationDraft) ReloadG() error {
+	if o == nil {
+		return errors.New("models: no OrganizationDraft provided for reload")
+	}
+
+	return o.Reload(boil.GetDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *OrganizationDraft) Reload(exec boil.Executor) error {
+	ret, err := FindOrganizationDraft(exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationDraftSlice) ReloadAllG() error {
+	if o == nil {
+		return errors.New("models: empty OrganizationDraftSlice provided for reload all")
+	}
+
+	return o.ReloadAll(boil.GetDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationDraftSlice) ReloadAll(exec boil.Executor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := OrganizationDraftSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationDraftPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"organization_drafts\".* FROM \"organization_drafts\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationDraftPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(nil, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in OrganizationDraftSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// OrganizationDraftExistsG checks if the OrganizationDraft row exists.
+func OrganizationDraftExistsG(iD int) (bool, error) {
+	return OrganizationDraftExists(boil.GetDB(), iD)
+}
+
+// OrganizationDraftExists checks if the OrganizationDraft row exists.
+func OrganizationDraftExists(exec boil.Executor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"organization_drafts\" where \"id\"=$1 limit 1)"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, iD)
+	}
+
+	row := exec.QueryRow(sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if organization_drafts exists")
+	}
+
+	return exists, nil
+}
@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testOrganizationDrafts(t *testing.T) {
+	t.Parallel()
+
+	query := OrganizationDrafts()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testOrganizationDraftsDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationDraftsQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := OrganizationDrafts().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationDraftsSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationDraftSlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationDraftsExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := OrganizationDraftExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if OrganizationDraft exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected OrganizationDraftExists to return true, but got false.")
+	}
+}
+
+func testOrganizationDraftsFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	organizationDraftFound, err := FindOrganizationDraft(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if organizationDraftFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testOrganizationDraftsBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = OrganizationDrafts().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationDraftsOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := OrganizationDrafts().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testOrganizationDraftsAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	organizationDraftOne := &OrganizationDraft{}
+	organizationDraftTwo := &OrganizationDraft{}
+	if err = randomize.Struct(seed, organizationDraftOne, organizationDraftDBTypes, false, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationDraftTwo, organizationDraftDBTypes, false, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationDraftOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationDraftTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationDrafts().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testOrganizationDraftsCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	organizationDraftOne := &OrganizationDraft{}
+	organizationDraftTwo := &OrganizationDraft{}
+	if err = randomize.Struct(seed, organizationDraftOne, organizationDraftDBTypes, false, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationDraftTwo, organizationDraftDBTypes, false, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationDraftOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationDraftTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func organizationDraftBeforeInsertHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftAfterInsertHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftAfterSelectHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftBeforeUpdateHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftAfterUpdateHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftBeforeDeleteHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftAfterDeleteHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftBeforeUpsertHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func organizationDraftAfterUpsertHook(e boil.Executor, o *OrganizationDraft) error {
+	*o = OrganizationDraft{}
+	return nil
+}
+
+func testOrganizationDraftsHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &OrganizationDraft{}
+	o := &OrganizationDraft{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft object: %s", err)
+	}
+
+	AddOrganizationDraftHook(boil.BeforeInsertHook, organizationDraftBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftBeforeInsertHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.AfterInsertHook, organizationDraftAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftAfterInsertHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.AfterSelectHook, organizationDraftAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftAfterSelectHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.BeforeUpdateHook, organizationDraftBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftBeforeUpdateHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.AfterUpdateHook, organizationDraftAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftAfterUpdateHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.BeforeDeleteHook, organizationDraftBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftBeforeDeleteHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.AfterDeleteHook, organizationDraftAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftAfterDeleteHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.BeforeUpsertHook, organizationDraftBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftBeforeUpsertHooks = []OrganizationDraftHook{}
+
+	AddOrganizationDraftHook(boil.AfterUpsertHook, organizationDraftAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationDraftAfterUpsertHooks = []OrganizationDraftHook{}
+}
+
+func testOrganizationDraftsInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationDraftsInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(organizationDraftColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationDraftsReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationDraftsReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationDraftSlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationDraftsSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationDrafts().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	organizationDraftDBTypes = map[string]string{`ID`: `integer`, `OrganizationID`: `integer`, `UserID`: `integer`, `Title`: `character varying`, `Params`: `jsonb`, `CreatedAt`: `timestamp without time zone`, `UpdatedAt`: `timestamp without time zone`}
+	_                        = bytes.MinRead
+)
+
+func testOrganizationDraftsUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(organizationDraftPrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(organizationDraftAllColumns) == len(organizationDraftPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testOrganizationDraftsSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationDraftAllColumns) == len(organizationDraftPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationDraft{}
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationDraftDBTypes, true, organizationDraftPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(organizationDraftAllColumns, organizationDraftPrimaryKeyColumns) {
+		fields = organizationDraftAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			organizationDraftAllColumns,
+			organizationDraftPrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := OrganizationDraftSlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testOrganizationDraftsUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationDraftAllColumns) == len(organizationDraftPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := OrganizationDraft{}
+	if err = randomize.Struct(seed, &o, organizationDraftDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationDraft: %s", err)
+	}
+
+	count, err := OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, organizationDraftDBTypes, false, organizationDraftPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationDraft struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationDraft: %s", err)
+	}
+
+	count, err = OrganizationDrafts().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testOrganizationMembers(t *testing.T) {
+	t.Parallel()
+
+	query := OrganizationMembers()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testOrganizationMembersDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationMembersQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := OrganizationMembers().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationMembersSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationMemberSlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationMembersExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := OrganizationMemberExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if OrganizationMember exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected OrganizationMemberExists to return true, but got false.")
+	}
+}
+
+func testOrganizationMembersFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	organizationMemberFound, err := FindOrganizationMember(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if organizationMemberFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testOrganizationMembersBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = OrganizationMembers().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationMembersOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := OrganizationMembers().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testOrganizationMembersAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	organizationMemberOne := &OrganizationMember{}
+	organizationMemberTwo := &OrganizationMember{}
+	if err = randomize.Struct(seed, organizationMemberOne, organizationMemberDBTypes, false, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationMemberTwo, organizationMemberDBTypes, false, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationMemberOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationMemberTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationMembers().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testOrganizationMembersCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	organizationMemberOne := &OrganizationMember{}
+	organizationMemberTwo := &OrganizationMember{}
+	if err = randomize.Struct(seed, organizationMemberOne, organizationMemberDBTypes, false, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationMemberTwo, organizationMemberDBTypes, false, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationMemberOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationMemberTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func organizationMemberBeforeInsertHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberAfterInsertHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberAfterSelectHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberBeforeUpdateHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberAfterUpdateHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberBeforeDeleteHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberAfterDeleteHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberBeforeUpsertHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func organizationMemberAfterUpsertHook(e boil.Executor, o *OrganizationMember) error {
+	*o = OrganizationMember{}
+	return nil
+}
+
+func testOrganizationMembersHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &OrganizationMember{}
+	o := &OrganizationMember{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember object: %s", err)
+	}
+
+	AddOrganizationMemberHook(boil.BeforeInsertHook, organizationMemberBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberBeforeInsertHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.AfterInsertHook, organizationMemberAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberAfterInsertHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.AfterSelectHook, organizationMemberAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberAfterSelectHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.BeforeUpdateHook, organizationMemberBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberBeforeUpdateHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.AfterUpdateHook, organizationMemberAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberAfterUpdateHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.BeforeDeleteHook, organizationMemberBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberBeforeDeleteHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.AfterDeleteHook, organizationMemberAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberAfterDeleteHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.BeforeUpsertHook, organizationMemberBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberBeforeUpsertHooks = []OrganizationMemberHook{}
+
+	AddOrganizationMemberHook(boil.AfterUpsertHook, organizationMemberAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationMemberAfterUpsertHooks = []OrganizationMemberHook{}
+}
+
+func testOrganizationMembersInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationMembersInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(organizationMemberColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationMembersReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationMembersReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationMemberSlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationMembersSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := OrganizationMembers().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	organizationMemberDBTypes = map[string]string{`ID`: `integer`, `OrganizationID`: `integer`, `UserID`: `integer`, `Role`: `character varying`, `CreatedAt`: `timestamp without time zone`}
+	_                         = bytes.MinRead
+)
+
+func testOrganizationMembersUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(organizationMemberPrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(organizationMemberAllColumns) == len(organizationMemberPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testOrganizationMembersSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationMemberAllColumns) == len(organizationMemberPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &OrganizationMember{}
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationMemberDBTypes, true, organizationMemberPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(organizationMemberAllColumns, organizationMemberPrimaryKeyColumns) {
+		fields = organizationMemberAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			organizationMemberAllColumns,
+			organizationMemberPrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := OrganizationMemberSlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testOrganizationMembersUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationMemberAllColumns) == len(organizationMemberPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := OrganizationMember{}
+	if err = randomize.Struct(seed, &o, organizationMemberDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationMember: %s", err)
+	}
+
+	count, err := OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, organizationMemberDBTypes, false, organizationMemberPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize OrganizationMember struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert OrganizationMember: %s", err)
+	}
+
+	count, err = OrganizationMembers().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
@@ -0,0 +1,963 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+	"github.com/volatiletech/sqlboiler/queries/qmhelper"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+// Organization is an object representing the database table.
+type Organization struct {
+	ID          int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Name        string    `boil:"name" json:"name" toml:"name" yaml:"name"`
+	UploadQuota int64     `boil:"upload_quota" json:"upload_quota" toml:"upload_quota" yaml:"upload_quota"`
+	UploadUsed  int64     `boil:"upload_used" json:"upload_used" toml:"upload_used" yaml:"upload_used"`
+	CreatedAt   time.Time `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+
+	R *organizationR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L organizationL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var OrganizationColumns = struct {
+	ID          string
+	Name        string
+	UploadQuota string
+	UploadUsed  string
+	CreatedAt   string
+	UpdatedAt   string
+}{
+	ID:          "id",
+	Name:        "name",
+	UploadQuota: "upload_quota",
+	UploadUsed:  "upload_used",
+	CreatedAt:   "created_at",
+	UpdatedAt:   "updated_at",
+}
+
+// Generated where
+
+type whereHelperint64 struct{ field string }
+
+func (w whereHelperint64) EQ(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperint64) NEQ(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperint64) LT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperint64) LTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperint64) GT(x int64) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperint64) GTE(x int64) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+
+var OrganizationWhere = struct {
+	ID          whereHelperint
+	Name        whereHelperstring
+	UploadQuota whereHelperint64
+	UploadUsed  whereHelperint64
+	CreatedAt   whereHelpertime_Time
+	UpdatedAt   whereHelpertime_Time
+}{
+	ID:          whereHelperint{field: "\"organizations\".\"id\""},
+	Name:        whereHelperstring{field: "\"organizations\".\"name\""},
+	UploadQuota: whereHelperint64{field: "\"organizations\".\"upload_quota\""},
+	UploadUsed:  whereHelperint64{field: "\"organizations\".\"upload_used\""},
+	CreatedAt:   whereHelpertime_Time{field: "\"organizations\".\"created_at\""},
+	UpdatedAt:   whereHelpertime_Time{field: "\"organizations\".\"updated_at\""},
+}
+
+// OrganizationRels is where relationship names are stored.
+var OrganizationRels = struct {
+}{}
+
+// organizationR is where relationships are stored.
+type organizationR struct {
+}
+
+// NewStruct creates a new relationship struct
+func (*organizationR) NewStruct() *organizationR {
+	return &organizationR{}
+}
+
+// organizationL is where Load methods for each relationship are stored.
+type organizationL struct{}
+
+var (
+	organizationAllColumns            = []string{"id", "name", "upload_quota", "upload_used", "created_at", "updated_at"}
+	organizationColumnsWithoutDefault = []string{"name"}
+	organizationColumnsWithDefault    = []string{"id", "upload_quota", "upload_used", "created_at", "updated_at"}
+	organizationPrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// OrganizationSlice is an alias for a slice of pointers to Organization.
+	// This should generally be used opposed to []Organization.
+	OrganizationSlice []*Organization
+	// OrganizationHook is the signature for custom Organization hook methods
+	OrganizationHook func(boil.Executor, *Organization) error
+
+	organizationQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	organizationType                 = reflect.TypeOf(&Organization{})
+	organizationMapping              = queries.MakeStructMapping(organizationType)
+	organizationPrimaryKeyMapping, _ = queries.BindMapping(organizationType, organizationMapping, organizationPrimaryKeyColumns)
+	organizationInsertCacheMut       sync.RWMutex
+	organizationInsertCache          = make(map[string]insertCache)
+	organizationUpdateCacheMut       sync.RWMutex
+	organizationUpdateCache          = make(map[string]updateCache)
+	organizationUpsertCacheMut       sync.RWMutex
+	organizationUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var organizationBeforeInsertHooks []OrganizationHook
+var organizationBeforeUpdateHooks []OrganizationHook
+var organizationBeforeDeleteHooks []OrganizationHook
+var organizationBeforeUpsertHooks []OrganizationHook
+
+var organizationAfterInsertHooks []OrganizationHook
+var organizationAfterSelectHooks []OrganizationHook
+var organizationAfterUpdateHooks []OrganizationHook
+var organizationAfterDeleteHooks []OrganizationHook
+var organizationAfterUpsertHooks []OrganizationHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *Organization) doBeforeInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationBeforeInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *Organization) doBeforeUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationBeforeUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *Organization) doBeforeDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationBeforeDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *Organization) doBeforeUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationBeforeUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *Organization) doAfterInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAfterInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *Organization) doAfterSelectHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAfterSelectHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *Organization) doAfterUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAfterUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *Organization) doAfterDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAfterDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *Organization) doAfterUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range organizationAfterUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddOrganizationHook registers your hook function for all future operations.
+func AddOrganizationHook(hookPoint boil.HookPoint, organizationHook OrganizationHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		organizationBeforeInsertHooks = append(organizationBeforeInsertHooks, organizationHook)
+	case boil.BeforeUpdateHook:
+		organizationBeforeUpdateHooks = append(organizationBeforeUpdateHooks, organizationHook)
+	case boil.BeforeDeleteHook:
+		organizationBeforeDeleteHooks = append(organizationBeforeDeleteHooks, organizationHook)
+	case boil.BeforeUpsertHook:
+		organizationBeforeUpsertHooks = append(organizationBeforeUpsertHooks, organizationHook)
+	case boil.AfterInsertHook:
+		organizationAfterInsertHooks = append(organizationAfterInsertHooks, organizationHook)
+	case boil.AfterSelectHook:
+		organizationAfterSelectHooks = append(organizationAfterSelectHooks, organizationHook)
+	case boil.AfterUpdateHook:
+		organizationAfterUpdateHooks = append(organizationAfterUpdateHooks, organizationHook)
+	case boil.AfterDeleteHook:
+		organizationAfterDeleteHooks = append(organizationAfterDeleteHooks, organizationHook)
+	case boil.AfterUpsertHook:
+		organizationAfterUpsertHooks = append(organizationAfterUpsertHooks, organizationHook)
+	}
+}
+
+// OneG returns a single organization record from the query using the global executor.
+func (q organizationQuery) OneG() (*Organization, error) {
+	return q.One(boil.GetDB())
+}
+
+// One returns a single organization record from the query.
+func (q organizationQuery) One(exec boil.Executor) (*Organization, error) {
+	o := &Organization{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(nil, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for organizations")
+	}
+
+	if err := o.doAfterSelectHooks(exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all Organization records from the query using the global executor.
+func (q organizationQuery) AllG() (OrganizationSlice, error) {
+	return q.All(boil.GetDB())
+}
+
+// All returns all Organization records from the query.
+func (q organizationQuery) All(exec boil.Executor) (OrganizationSlice, error) {
+	var o []*Organization
+
+	err := q.Bind(nil, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to Organization slice")
+	}
+
+	if len(organizationAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all Organization records in the query, and panics on error.
+func (q organizationQuery) CountG() (int64, error) {
+	return q.Count(boil.GetDB())
+}
+
+// Count returns the count of all Organization records in the query.
+func (q organizationQuery) Count(exec boil.Executor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count organizations rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table, and panics on error.
+func (q organizationQuery) ExistsG() (bool, error) {
+	return q.Exists(boil.GetDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q organizationQuery) Exists(exec boil.Executor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if organizations exists")
+	}
+
+	return count > 0, nil
+}
+
+// Organizations retrieves all the records using an executor.
+func Organizations(mods ...qm.QueryMod) organizationQuery {
+	mods = append(mods, qm.From("\"organizations\""))
+	return organizationQuery{NewQuery(mods...)}
+}
+
+// FindOrganizationG retrieves a single record by ID.
+func FindOrganizationG(iD int, selectCols ...string) (*Organization, error) {
+	return FindOrganization(boil.GetDB(), iD, selectCols...)
+}
+
+// FindOrganization retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindOrganization(exec boil.Executor, iD int, selectCols ...string) (*Organization, error) {
+	organizationObj := &Organization{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"organizations\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(nil, exec, organizationObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from organizations")
+	}
+
+	return organizationObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *Organization) InsertG(columns boil.Columns) error {
+	return o.Insert(boil.GetDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *Organization) Insert(exec boil.Executor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organizations provided for insertion")
+	}
+
+	var err error
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+	if o.UpdatedAt.IsZero() {
+		o.UpdatedAt = currTime
+	}
+
+	if err := o.doBeforeInsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	organizationInsertCacheMut.RLock()
+	cache, cached := organizationInsertCache[key]
+	organizationInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			organizationAllColumns,
+			organizationColumnsWithDefault,
+			organizationColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(organizationType, organizationMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(organizationType, organizationMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"organizations\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"organizations\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into organizations")
+	}
+
+	if !cached {
+		organizationInsertCacheMut.Lock()
+		organizationInsertCache[key] = cache
+		organizationInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(exec)
+}
+
+// UpdateG a single Organization record using the global executor.
+// See Update for more documentation.
+func (o *Organization) UpdateG(columns boil.Columns) (int64, error) {
+	return o.Update(boil.GetDB(), columns)
+}
+
+// Update uses an executor to update the Organization.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *Organization) Update(exec boil.Executor, columns boil.Columns) (int64, error) {
+	currTime := time.Now().In(boil.GetLocation())
+
+	o.UpdatedAt = currTime
+
+	var err error
+	if err = o.doBeforeUpdateHooks(exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	organizationUpdateCacheMut.RLock()
+	cache, cached := organizationUpdateCache[key]
+	organizationUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			organizationAllColumns,
+			organizationPrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update organizations, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"organizations\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, organizationPrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(organizationType, organizationMapping, append(wl, organizationPrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, values)
+	}
+
+	var result sql.Result
+	result, err = exec.Exec(cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update organizations row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for organizations")
+	}
+
+	if !cached {
+		organizationUpdateCacheMut.Lock()
+		organizationUpdateCache[key] = cache
+		organizationUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q organizationQuery) UpdateAllG(cols M) (int64, error) {
+	return q.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q organizationQuery) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for organizations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for organizations")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o OrganizationSlice) UpdateAllG(cols M) (int64, error) {
+	return o.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o OrganizationSlice) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"organizations\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, organizationPrimaryKeyColumns, len(o)))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in organization slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all organization")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *Organization) UpsertG(updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(boil.GetDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *Organization) Upsert(exec boil.Executor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no organizations provided for upsert")
+	}
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+	o.UpdatedAt = currTime
+
+	if err := o.doBeforeUpsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(organizationColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	organizationUpsertCacheMut.RLock()
+	cache, cached := organizationUpsertCache[key]
+	organizationUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			organizationAllColumns,
+			organizationColumnsWithDefault,
+			organizationColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			organizationAllColumns,
+			organizationPrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert organizations, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(organizationPrimaryKeyColumns))
+			copy(conflict, organizationPrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"organizations\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(organizationType, organizationMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(organizationType, organizationMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert organizations")
+	}
+
+	if !cached {
+		organizationUpsertCacheMut.Lock()
+		organizationUpsertCache[key] = cache
+		organizationUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(exec)
+}
+
+// DeleteG deletes a single Organization record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *Organization) DeleteG() (int64, error) {
+	return o.Delete(boil.GetDB())
+}
+
+// Delete deletes a single Organization record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *Organization) Delete(exec boil.Executor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no Organization provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), organizationPrimaryKeyMapping)
+	sql := "DELETE FROM \"organizations\" WHERE \"id\"=$1"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from organizations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for organizations")
+	}
+
+	if err := o.doAfterDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q organizationQuery) DeleteAll(exec boil.Executor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no organizationQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organizations")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organizations")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o OrganizationSlice) DeleteAllG() (int64, error) {
+	return o.DeleteAll(boil.GetDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o OrganizationSlice) DeleteAll(exec boil.Executor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(organizationBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"organizations\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationPrimaryKeyColumns, len(o))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from organization slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for organizations")
+	}
+
+	if len(organizationAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *Organization) ReloadG() error {
+	if o == nil {
+		return errors.New("models: no Organization provided for reload")
+	}
+
+	return o.Reload(boil.GetDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *Organization) Reload(exec boil.Executor) error {
+	ret, err := FindOrganization(exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationSlice) ReloadAllG() error {
+	if o == nil {
+		return errors.New("models: empty OrganizationSlice provided for reload all")
+	}
+
+	return o.ReloadAll(boil.GetDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *OrganizationSlice) ReloadAll(exec boil.Executor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := OrganizationSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), organizationPrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"organizations\".* FROM \"organizations\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, organizationPrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(nil, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in OrganizationSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// OrganizationExistsG checks if the Organization row exists.
+func OrganizationExistsG(iD int) (bool, error) {
+	return OrganizationExists(boil.GetDB(), iD)
+}
+
+// OrganizationExists checks if the Organization row exists.
+func OrganizationExists(exec boil.Executor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"organizations\" where \"id\"=$1 limit 1)"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, iD)
+	}
+
+	row := exec.QueryRow(sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if organizations exists")
+	}
+
+	return exists, nil
+}
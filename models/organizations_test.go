@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testOrganizations(t *testing.T) {
+	t.Parallel()
+
+	query := Organizations()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testOrganizationsDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationsQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := Organizations().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationsSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationSlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testOrganizationsExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := OrganizationExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if Organization exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected OrganizationExists to return true, but got false.")
+	}
+}
+
+func testOrganizationsFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	organizationFound, err := FindOrganization(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if organizationFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testOrganizationsBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = Organizations().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationsOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := Organizations().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testOrganizationsAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	organizationOne := &Organization{}
+	organizationTwo := &Organization{}
+	if err = randomize.Struct(seed, organizationOne, organizationDBTypes, false, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationTwo, organizationDBTypes, false, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := Organizations().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testOrganizationsCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	organizationOne := &Organization{}
+	organizationTwo := &Organization{}
+	if err = randomize.Struct(seed, organizationOne, organizationDBTypes, false, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+	if err = randomize.Struct(seed, organizationTwo, organizationDBTypes, false, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = organizationOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = organizationTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func organizationBeforeInsertHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationAfterInsertHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationAfterSelectHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationBeforeUpdateHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationAfterUpdateHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationBeforeDeleteHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationAfterDeleteHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationBeforeUpsertHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func organizationAfterUpsertHook(e boil.Executor, o *Organization) error {
+	*o = Organization{}
+	return nil
+}
+
+func testOrganizationsHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &Organization{}
+	o := &Organization{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, organizationDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize Organization object: %s", err)
+	}
+
+	AddOrganizationHook(boil.BeforeInsertHook, organizationBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationBeforeInsertHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.AfterInsertHook, organizationAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAfterInsertHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.AfterSelectHook, organizationAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	organizationAfterSelectHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.BeforeUpdateHook, organizationBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationBeforeUpdateHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.AfterUpdateHook, organizationAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	organizationAfterUpdateHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.BeforeDeleteHook, organizationBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationBeforeDeleteHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.AfterDeleteHook, organizationAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	organizationAfterDeleteHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.BeforeUpsertHook, organizationBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationBeforeUpsertHooks = []OrganizationHook{}
+
+	AddOrganizationHook(boil.AfterUpsertHook, organizationAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	organizationAfterUpsertHooks = []OrganizationHook{}
+}
+
+func testOrganizationsInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationsInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(organizationColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testOrganizationsReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationsReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := OrganizationSlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testOrganizationsSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := Organizations().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	organizationDBTypes = map[string]string{`ID`: `integer`, `Name`: `character varying`, `UploadQuota`: `bigint`, `UploadUsed`: `bigint`, `CreatedAt`: `timestamp without time zone`, `UpdatedAt`: `timestamp without time zone`}
+	_                   = bytes.MinRead
+)
+
+func testOrganizationsUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(organizationPrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(organizationAllColumns) == len(organizationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testOrganizationsSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationAllColumns) == len(organizationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &Organization{}
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, organizationDBTypes, true, organizationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(organizationAllColumns, organizationPrimaryKeyColumns) {
+		fields = organizationAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			organizationAllColumns,
+			organizationPrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := OrganizationSlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testOrganizationsUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(organizationAllColumns) == len(organizationPrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := Organization{}
+	if err = randomize.Struct(seed, &o, organizationDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert Organization: %s", err)
+	}
+
+	count, err := Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, organizationDBTypes, false, organizationPrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize Organization struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert Organization: %s", err)
+	}
+
+	count, err = Organizations().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
@@ -6,10 +6,22 @@ package models
 import "testing"
 
 func TestUpsert(t *testing.T) {
+	t.Run("ChannelDelegations", testChannelDelegationsUpsert)
+
 	t.Run("GorpMigrations", testGorpMigrationsUpsert)
 
 	t.Run("LbrynetServers", testLbrynetServersUpsert)
 
+	t.Run("OrganizationAPIKeys", testOrganizationAPIKeysUpsert)
+
+	t.Run("OrganizationDrafts", testOrganizationDraftsUpsert)
+
+	t.Run("OrganizationMembers", testOrganizationMembersUpsert)
+
+	t.Run("Organizations", testOrganizationsUpsert)
+
+	t.Run("QuarantinedFiles", testQuarantinedFilesUpsert)
+
 	t.Run("QueryLogs", testQueryLogsUpsert)
 
 	t.Run("Users", testUsersUpsert)
@@ -0,0 +1,1010 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/volatiletech/null"
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/queries/qm"
+	"github.com/volatiletech/sqlboiler/queries/qmhelper"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+// QuarantinedFile is an object representing the database table.
+type QuarantinedFile struct {
+	ID         int         `boil:"id" json:"id" toml:"id" yaml:"id"`
+	UserID     int         `boil:"user_id" json:"user_id" toml:"user_id" yaml:"user_id"`
+	FileName   string      `boil:"file_name" json:"file_name" toml:"file_name" yaml:"file_name"`
+	Path       string      `boil:"path" json:"path" toml:"path" yaml:"path"`
+	Size       int64       `boil:"size" json:"size" toml:"size" yaml:"size"`
+	Sha256     string      `boil:"sha256" json:"sha256" toml:"sha256" yaml:"sha256"`
+	Source     string      `boil:"source" json:"source" toml:"source" yaml:"source"`
+	Reason     string      `boil:"reason" json:"reason" toml:"reason" yaml:"reason"`
+	Status     string      `boil:"status" json:"status" toml:"status" yaml:"status"`
+	ReviewedBy null.String `boil:"reviewed_by" json:"reviewed_by,omitempty" toml:"reviewed_by" yaml:"reviewed_by,omitempty"`
+	ReviewNote null.String `boil:"review_note" json:"review_note,omitempty" toml:"review_note" yaml:"review_note,omitempty"`
+	CreatedAt  time.Time   `boil:"created_at" json:"created_at" toml:"created_at" yaml:"created_at"`
+	ReviewedAt null.Time   `boil:"reviewed_at" json:"reviewed_at,omitempty" toml:"reviewed_at" yaml:"reviewed_at,omitempty"`
+	DeletedAt  null.Time   `boil:"deleted_at" json:"deleted_at,omitempty" toml:"deleted_at" yaml:"deleted_at,omitempty"`
+
+	R *quarantinedFileR `boil:"-" json:"-" toml:"-" yaml:"-"`
+	L quarantinedFileL  `boil:"-" json:"-" toml:"-" yaml:"-"`
+}
+
+var QuarantinedFileColumns = struct {
+	ID         string
+	UserID     string
+	FileName   string
+	Path       string
+	Size       string
+	Sha256     string
+	Source     string
+	Reason     string
+	Status     string
+	ReviewedBy string
+	ReviewNote string
+	CreatedAt  string
+	ReviewedAt string
+	DeletedAt  string
+}{
+	ID:         "id",
+	UserID:     "user_id",
+	FileName:   "file_name",
+	Path:       "path",
+	Size:       "size",
+	Sha256:     "sha256",
+	Source:     "source",
+	Reason:     "reason",
+	Status:     "status",
+	ReviewedBy: "reviewed_by",
+	ReviewNote: "review_note",
+	CreatedAt:  "created_at",
+	ReviewedAt: "reviewed_at",
+	DeletedAt:  "deleted_at",
+}
+
+// Generated where
+
+type whereHelpernull_String struct{ field string }
+
+func (w whereHelpernull_String) EQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_String) NEQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_String) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_String) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+func (w whereHelpernull_String) LT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_String) LTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_String) GT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_String) GTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+var QuarantinedFileWhere = struct {
+	ID         whereHelperint
+	UserID     whereHelperint
+	FileName   whereHelperstring
+	Path       whereHelperstring
+	Size       whereHelperint64
+	Sha256     whereHelperstring
+	Source     whereHelperstring
+	Reason     whereHelperstring
+	Status     whereHelperstring
+	ReviewedBy whereHelpernull_String
+	ReviewNote whereHelpernull_String
+	CreatedAt  whereHelpertime_Time
+	ReviewedAt whereHelpernull_Time
+	DeletedAt  whereHelpernull_Time
+}{
+	ID:         whereHelperint{field: "\"quarantined_files\".\"id\""},
+	UserID:     whereHelperint{field: "\"quarantined_files\".\"user_id\""},
+	FileName:   whereHelperstring{field: "\"quarantined_files\".\"file_name\""},
+	Path:       whereHelperstring{field: "\"quarantined_files\".\"path\""},
+	Size:       whereHelperint64{field: "\"quarantined_files\".\"size\""},
+	Sha256:     whereHelperstring{field: "\"quarantined_files\".\"sha256\""},
+	Source:     whereHelperstring{field: "\"quarantined_files\".\"source\""},
+	Reason:     whereHelperstring{field: "\"quarantined_files\".\"reason\""},
+	Status:     whereHelperstring{field: "\"quarantined_files\".\"status\""},
+	ReviewedBy: whereHelpernull_String{field: "\"quarantined_files\".\"reviewed_by\""},
+	ReviewNote: whereHelpernull_String{field: "\"quarantined_files\".\"review_note\""},
+	CreatedAt:  whereHelpertime_Time{field: "\"quarantined_files\".\"created_at\""},
+	ReviewedAt: whereHelpernull_Time{field: "\"quarantined_files\".\"reviewed_at\""},
+	DeletedAt:  whereHelpernull_Time{field: "\"quarantined_files\".\"deleted_at\""},
+}
+
+// QuarantinedFileRels is where relationship names are stored.
+var QuarantinedFileRels = struct {
+}{}
+
+// quarantinedFileR is where relationships are stored.
+type quarantinedFileR struct {
+}
+
+// NewStruct creates a new relationship struct
+func (*quarantinedFileR) NewStruct() *quarantinedFileR {
+	return &quarantinedFileR{}
+}
+
+// quarantinedFileL is where Load methods for each relationship are stored.
+type quarantinedFileL struct{}
+
+var (
+	quarantinedFileAllColumns            = []string{"id", "user_id", "file_name", "path", "size", "sha256", "source", "reason", "status", "reviewed_by", "review_note", "created_at", "reviewed_at", "deleted_at"}
+	quarantinedFileColumnsWithoutDefault = []string{"user_id", "file_name", "path", "size", "sha256", "source", "reason", "reviewed_by", "review_note", "reviewed_at", "deleted_at"}
+	quarantinedFileColumnsWithDefault    = []string{"id", "status", "created_at"}
+	quarantinedFilePrimaryKeyColumns     = []string{"id"}
+)
+
+type (
+	// QuarantinedFileSlice is an alias for a slice of pointers to QuarantinedFile.
+	// This should generally be used opposed to []QuarantinedFile.
+	QuarantinedFileSlice []*QuarantinedFile
+	// QuarantinedFileHook is the signature for custom QuarantinedFile hook methods
+	QuarantinedFileHook func(boil.Executor, *QuarantinedFile) error
+
+	quarantinedFileQuery struct {
+		*queries.Query
+	}
+)
+
+// Cache for insert, update and upsert
+var (
+	quarantinedFileType                 = reflect.TypeOf(&QuarantinedFile{})
+	quarantinedFileMapping              = queries.MakeStructMapping(quarantinedFileType)
+	quarantinedFilePrimaryKeyMapping, _ = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, quarantinedFilePrimaryKeyColumns)
+	quarantinedFileInsertCacheMut       sync.RWMutex
+	quarantinedFileInsertCache          = make(map[string]insertCache)
+	quarantinedFileUpdateCacheMut       sync.RWMutex
+	quarantinedFileUpdateCache          = make(map[string]updateCache)
+	quarantinedFileUpsertCacheMut       sync.RWMutex
+	quarantinedFileUpsertCache          = make(map[string]insertCache)
+)
+
+var (
+	// Force time package dependency for automated UpdatedAt/CreatedAt.
+	_ = time.Second
+	// Force qmhelper dependency for where clause generation (which doesn't
+	// always happen)
+	_ = qmhelper.Where
+)
+
+var quarantinedFileBeforeInsertHooks []QuarantinedFileHook
+var quarantinedFileBeforeUpdateHooks []QuarantinedFileHook
+var quarantinedFileBeforeDeleteHooks []QuarantinedFileHook
+var quarantinedFileBeforeUpsertHooks []QuarantinedFileHook
+
+var quarantinedFileAfterInsertHooks []QuarantinedFileHook
+var quarantinedFileAfterSelectHooks []QuarantinedFileHook
+var quarantinedFileAfterUpdateHooks []QuarantinedFileHook
+var quarantinedFileAfterDeleteHooks []QuarantinedFileHook
+var quarantinedFileAfterUpsertHooks []QuarantinedFileHook
+
+// doBeforeInsertHooks executes all "before insert" hooks.
+func (o *QuarantinedFile) doBeforeInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileBeforeInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpdateHooks executes all "before Update" hooks.
+func (o *QuarantinedFile) doBeforeUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileBeforeUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeDeleteHooks executes all "before Delete" hooks.
+func (o *QuarantinedFile) doBeforeDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileBeforeDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doBeforeUpsertHooks executes all "before Upsert" hooks.
+func (o *QuarantinedFile) doBeforeUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileBeforeUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterInsertHooks executes all "after Insert" hooks.
+func (o *QuarantinedFile) doAfterInsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileAfterInsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterSelectHooks executes all "after Select" hooks.
+func (o *QuarantinedFile) doAfterSelectHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileAfterSelectHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpdateHooks executes all "after Update" hooks.
+func (o *QuarantinedFile) doAfterUpdateHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileAfterUpdateHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterDeleteHooks executes all "after Delete" hooks.
+func (o *QuarantinedFile) doAfterDeleteHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileAfterDeleteHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// doAfterUpsertHooks executes all "after Upsert" hooks.
+func (o *QuarantinedFile) doAfterUpsertHooks(exec boil.Executor) (err error) {
+	for _, hook := range quarantinedFileAfterUpsertHooks {
+		if err := hook(exec, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddQuarantinedFileHook registers your hook function for all future operations.
+func AddQuarantinedFileHook(hookPoint boil.HookPoint, quarantinedFileHook QuarantinedFileHook) {
+	switch hookPoint {
+	case boil.BeforeInsertHook:
+		quarantinedFileBeforeInsertHooks = append(quarantinedFileBeforeInsertHooks, quarantinedFileHook)
+	case boil.BeforeUpdateHook:
+		quarantinedFileBeforeUpdateHooks = append(quarantinedFileBeforeUpdateHooks, quarantinedFileHook)
+	case boil.BeforeDeleteHook:
+		quarantinedFileBeforeDeleteHooks = append(quarantinedFileBeforeDeleteHooks, quarantinedFileHook)
+	case boil.BeforeUpsertHook:
+		quarantinedFileBeforeUpsertHooks = append(quarantinedFileBeforeUpsertHooks, quarantinedFileHook)
+	case boil.AfterInsertHook:
+		quarantinedFileAfterInsertHooks = append(quarantinedFileAfterInsertHooks, quarantinedFileHook)
+	case boil.AfterSelectHook:
+		quarantinedFileAfterSelectHooks = append(quarantinedFileAfterSelectHooks, quarantinedFileHook)
+	case boil.AfterUpdateHook:
+		quarantinedFileAfterUpdateHooks = append(quarantinedFileAfterUpdateHooks, quarantinedFileHook)
+	case boil.AfterDeleteHook:
+		quarantinedFileAfterDeleteHooks = append(quarantinedFileAfterDeleteHooks, quarantinedFileHook)
+	case boil.AfterUpsertHook:
+		quarantinedFileAfterUpsertHooks = append(quarantinedFileAfterUpsertHooks, quarantinedFileHook)
+	}
+}
+
+// OneG returns a single quarantinedFile record from the query using the global executor.
+func (q quarantinedFileQuery) OneG() (*QuarantinedFile, error) {
+	return q.One(boil.GetDB())
+}
+
+// One returns a single quarantinedFile record from the query.
+func (q quarantinedFileQuery) One(exec boil.Executor) (*QuarantinedFile, error) {
+	o := &QuarantinedFile{}
+
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Bind(nil, exec, o)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: failed to execute a one query for quarantined_files")
+	}
+
+	if err := o.doAfterSelectHooks(exec); err != nil {
+		return o, err
+	}
+
+	return o, nil
+}
+
+// AllG returns all QuarantinedFile records from the query using the global executor.
+func (q quarantinedFileQuery) AllG() (QuarantinedFileSlice, error) {
+	return q.All(boil.GetDB())
+}
+
+// All returns all QuarantinedFile records from the query.
+func (q quarantinedFileQuery) All(exec boil.Executor) (QuarantinedFileSlice, error) {
+	var o []*QuarantinedFile
+
+	err := q.Bind(nil, exec, &o)
+	if err != nil {
+		return nil, errors.Wrap(err, "models: failed to assign all query results to QuarantinedFile slice")
+	}
+
+	if len(quarantinedFileAfterSelectHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterSelectHooks(exec); err != nil {
+				return o, err
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// CountG returns the count of all QuarantinedFile records in the query, and panics on error.
+func (q quarantinedFileQuery) CountG() (int64, error) {
+	return q.Count(boil.GetDB())
+}
+
+// Count returns the count of all QuarantinedFile records in the query.
+func (q quarantinedFileQuery) Count(exec boil.Executor) (int64, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to count quarantined_files rows")
+	}
+
+	return count, nil
+}
+
+// ExistsG checks if the row exists in the table, and panics on error.
+func (q quarantinedFileQuery) ExistsG() (bool, error) {
+	return q.Exists(boil.GetDB())
+}
+
+// Exists checks if the row exists in the table.
+func (q quarantinedFileQuery) Exists(exec boil.Executor) (bool, error) {
+	var count int64
+
+	queries.SetSelect(q.Query, nil)
+	queries.SetCount(q.Query)
+	queries.SetLimit(q.Query, 1)
+
+	err := q.Query.QueryRow(exec).Scan(&count)
+	if err != nil {
+		return false, errors.Wrap(err, "models: failed to check if quarantined_files exists")
+	}
+
+	return count > 0, nil
+}
+
+// QuarantinedFiles retrieves all the records using an executor.
+func QuarantinedFiles(mods ...qm.QueryMod) quarantinedFileQuery {
+	mods = append(mods, qm.From("\"quarantined_files\""))
+	return quarantinedFileQuery{NewQuery(mods...)}
+}
+
+// FindQuarantinedFileG retrieves a single record by ID.
+func FindQuarantinedFileG(iD int, selectCols ...string) (*QuarantinedFile, error) {
+	return FindQuarantinedFile(boil.GetDB(), iD, selectCols...)
+}
+
+// FindQuarantinedFile retrieves a single record by ID with an executor.
+// If selectCols is empty Find will return all columns.
+func FindQuarantinedFile(exec boil.Executor, iD int, selectCols ...string) (*QuarantinedFile, error) {
+	quarantinedFileObj := &QuarantinedFile{}
+
+	sel := "*"
+	if len(selectCols) > 0 {
+		sel = strings.Join(strmangle.IdentQuoteSlice(dialect.LQ, dialect.RQ, selectCols), ",")
+	}
+	query := fmt.Sprintf(
+		"select %s from \"quarantined_files\" where \"id\"=$1", sel,
+	)
+
+	q := queries.Raw(query, iD)
+
+	err := q.Bind(nil, exec, quarantinedFileObj)
+	if err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return nil, sql.ErrNoRows
+		}
+		return nil, errors.Wrap(err, "models: unable to select from quarantined_files")
+	}
+
+	return quarantinedFileObj, nil
+}
+
+// InsertG a single record. See Insert for whitelist behavior description.
+func (o *QuarantinedFile) InsertG(columns boil.Columns) error {
+	return o.Insert(boil.GetDB(), columns)
+}
+
+// Insert a single record using an executor.
+// See boil.Columns.InsertColumnSet documentation to understand column list inference for inserts.
+func (o *QuarantinedFile) Insert(exec boil.Executor, columns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no quarantined_files provided for insertion")
+	}
+
+	var err error
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeInsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(quarantinedFileColumnsWithDefault, o)
+
+	key := makeCacheKey(columns, nzDefaults)
+	quarantinedFileInsertCacheMut.RLock()
+	cache, cached := quarantinedFileInsertCache[key]
+	quarantinedFileInsertCacheMut.RUnlock()
+
+	if !cached {
+		wl, returnColumns := columns.InsertColumnSet(
+			quarantinedFileAllColumns,
+			quarantinedFileColumnsWithDefault,
+			quarantinedFileColumnsWithoutDefault,
+			nzDefaults,
+		)
+
+		cache.valueMapping, err = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, wl)
+		if err != nil {
+			return err
+		}
+		cache.retMapping, err = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, returnColumns)
+		if err != nil {
+			return err
+		}
+		if len(wl) != 0 {
+			cache.query = fmt.Sprintf("INSERT INTO \"quarantined_files\" (\"%s\") %%sVALUES (%s)%%s", strings.Join(wl, "\",\""), strmangle.Placeholders(dialect.UseIndexPlaceholders, len(wl), 1, 1))
+		} else {
+			cache.query = "INSERT INTO \"quarantined_files\" %sDEFAULT VALUES%s"
+		}
+
+		var queryOutput, queryReturning string
+
+		if len(cache.retMapping) != 0 {
+			queryReturning = fmt.Sprintf(" RETURNING \"%s\"", strings.Join(returnColumns, "\",\""))
+		}
+
+		cache.query = fmt.Sprintf(cache.query, queryOutput, queryReturning)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(queries.PtrsFromMapping(value, cache.retMapping)...)
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "models: unable to insert into quarantined_files")
+	}
+
+	if !cached {
+		quarantinedFileInsertCacheMut.Lock()
+		quarantinedFileInsertCache[key] = cache
+		quarantinedFileInsertCacheMut.Unlock()
+	}
+
+	return o.doAfterInsertHooks(exec)
+}
+
+// UpdateG a single QuarantinedFile record using the global executor.
+// See Update for more documentation.
+func (o *QuarantinedFile) UpdateG(columns boil.Columns) (int64, error) {
+	return o.Update(boil.GetDB(), columns)
+}
+
+// Update uses an executor to update the QuarantinedFile.
+// See boil.Columns.UpdateColumnSet documentation to understand column list inference for updates.
+// Update does not automatically update the record in case of default values. Use .Reload() to refresh the records.
+func (o *QuarantinedFile) Update(exec boil.Executor, columns boil.Columns) (int64, error) {
+	var err error
+	if err = o.doBeforeUpdateHooks(exec); err != nil {
+		return 0, err
+	}
+	key := makeCacheKey(columns, nil)
+	quarantinedFileUpdateCacheMut.RLock()
+	cache, cached := quarantinedFileUpdateCache[key]
+	quarantinedFileUpdateCacheMut.RUnlock()
+
+	if !cached {
+		wl := columns.UpdateColumnSet(
+			quarantinedFileAllColumns,
+			quarantinedFilePrimaryKeyColumns,
+		)
+
+		if !columns.IsWhitelist() {
+			wl = strmangle.SetComplement(wl, []string{"created_at"})
+		}
+		if len(wl) == 0 {
+			return 0, errors.New("models: unable to update quarantined_files, could not build whitelist")
+		}
+
+		cache.query = fmt.Sprintf("UPDATE \"quarantined_files\" SET %s WHERE %s",
+			strmangle.SetParamNames("\"", "\"", 1, wl),
+			strmangle.WhereClause("\"", "\"", len(wl)+1, quarantinedFilePrimaryKeyColumns),
+		)
+		cache.valueMapping, err = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, append(wl, quarantinedFilePrimaryKeyColumns...))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	values := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), cache.valueMapping)
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, values)
+	}
+
+	var result sql.Result
+	result, err = exec.Exec(cache.query, values...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update quarantined_files row")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by update for quarantined_files")
+	}
+
+	if !cached {
+		quarantinedFileUpdateCacheMut.Lock()
+		quarantinedFileUpdateCache[key] = cache
+		quarantinedFileUpdateCacheMut.Unlock()
+	}
+
+	return rowsAff, o.doAfterUpdateHooks(exec)
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (q quarantinedFileQuery) UpdateAllG(cols M) (int64, error) {
+	return q.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values.
+func (q quarantinedFileQuery) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	queries.SetUpdate(q.Query, cols)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all for quarantined_files")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected for quarantined_files")
+	}
+
+	return rowsAff, nil
+}
+
+// UpdateAllG updates all rows with the specified column values.
+func (o QuarantinedFileSlice) UpdateAllG(cols M) (int64, error) {
+	return o.UpdateAll(boil.GetDB(), cols)
+}
+
+// UpdateAll updates all rows with the specified column values, using an executor.
+func (o QuarantinedFileSlice) UpdateAll(exec boil.Executor, cols M) (int64, error) {
+	ln := int64(len(o))
+	if ln == 0 {
+		return 0, nil
+	}
+
+	if len(cols) == 0 {
+		return 0, errors.New("models: update all requires at least one column argument")
+	}
+
+	colNames := make([]string, len(cols))
+	args := make([]interface{}, len(cols))
+
+	i := 0
+	for name, value := range cols {
+		colNames[i] = name
+		args[i] = value
+		i++
+	}
+
+	// Append all of the primary key values for each column
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), quarantinedFilePrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := fmt.Sprintf("UPDATE \"quarantined_files\" SET %s WHERE %s",
+		strmangle.SetParamNames("\"", "\"", 1, colNames),
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), len(colNames)+1, quarantinedFilePrimaryKeyColumns, len(o)))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to update all in quarantinedFile slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to retrieve rows affected all in update all quarantinedFile")
+	}
+	return rowsAff, nil
+}
+
+// UpsertG attempts an insert, and does an update or ignore on conflict.
+func (o *QuarantinedFile) UpsertG(updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	return o.Upsert(boil.GetDB(), updateOnConflict, conflictColumns, updateColumns, insertColumns)
+}
+
+// Upsert attempts an insert using an executor, and does an update or ignore on conflict.
+// See boil.Columns documentation for how to properly use updateColumns and insertColumns.
+func (o *QuarantinedFile) Upsert(exec boil.Executor, updateOnConflict bool, conflictColumns []string, updateColumns, insertColumns boil.Columns) error {
+	if o == nil {
+		return errors.New("models: no quarantined_files provided for upsert")
+	}
+	currTime := time.Now().In(boil.GetLocation())
+
+	if o.CreatedAt.IsZero() {
+		o.CreatedAt = currTime
+	}
+
+	if err := o.doBeforeUpsertHooks(exec); err != nil {
+		return err
+	}
+
+	nzDefaults := queries.NonZeroDefaultSet(quarantinedFileColumnsWithDefault, o)
+
+	// Build cache key in-line uglily - mysql vs psql problems
+	buf := strmangle.GetBuffer()
+	if updateOnConflict {
+		buf.WriteByte('t')
+	} else {
+		buf.WriteByte('f')
+	}
+	buf.WriteByte('.')
+	for _, c := range conflictColumns {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(updateColumns.Kind))
+	for _, c := range updateColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	buf.WriteString(strconv.Itoa(insertColumns.Kind))
+	for _, c := range insertColumns.Cols {
+		buf.WriteString(c)
+	}
+	buf.WriteByte('.')
+	for _, c := range nzDefaults {
+		buf.WriteString(c)
+	}
+	key := buf.String()
+	strmangle.PutBuffer(buf)
+
+	quarantinedFileUpsertCacheMut.RLock()
+	cache, cached := quarantinedFileUpsertCache[key]
+	quarantinedFileUpsertCacheMut.RUnlock()
+
+	var err error
+
+	if !cached {
+		insert, ret := insertColumns.InsertColumnSet(
+			quarantinedFileAllColumns,
+			quarantinedFileColumnsWithDefault,
+			quarantinedFileColumnsWithoutDefault,
+			nzDefaults,
+		)
+		update := updateColumns.UpdateColumnSet(
+			quarantinedFileAllColumns,
+			quarantinedFilePrimaryKeyColumns,
+		)
+
+		if updateOnConflict && len(update) == 0 {
+			return errors.New("models: unable to upsert quarantined_files, could not build update column list")
+		}
+
+		conflict := conflictColumns
+		if len(conflict) == 0 {
+			conflict = make([]string, len(quarantinedFilePrimaryKeyColumns))
+			copy(conflict, quarantinedFilePrimaryKeyColumns)
+		}
+		cache.query = buildUpsertQueryPostgres(dialect, "\"quarantined_files\"", updateOnConflict, ret, update, conflict, insert)
+
+		cache.valueMapping, err = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, insert)
+		if err != nil {
+			return err
+		}
+		if len(ret) != 0 {
+			cache.retMapping, err = queries.BindMapping(quarantinedFileType, quarantinedFileMapping, ret)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	vals := queries.ValuesFromMapping(value, cache.valueMapping)
+	var returns []interface{}
+	if len(cache.retMapping) != 0 {
+		returns = queries.PtrsFromMapping(value, cache.retMapping)
+	}
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, cache.query)
+		fmt.Fprintln(boil.DebugWriter, vals)
+	}
+
+	if len(cache.retMapping) != 0 {
+		err = exec.QueryRow(cache.query, vals...).Scan(returns...)
+		if err == sql.ErrNoRows {
+			err = nil // Postgres doesn't return anything when there's no update
+		}
+	} else {
+		_, err = exec.Exec(cache.query, vals...)
+	}
+	if err != nil {
+		return errors.Wrap(err, "models: unable to upsert quarantined_files")
+	}
+
+	if !cached {
+		quarantinedFileUpsertCacheMut.Lock()
+		quarantinedFileUpsertCache[key] = cache
+		quarantinedFileUpsertCacheMut.Unlock()
+	}
+
+	return o.doAfterUpsertHooks(exec)
+}
+
+// DeleteG deletes a single QuarantinedFile record.
+// DeleteG will match against the primary key column to find the record to delete.
+func (o *QuarantinedFile) DeleteG() (int64, error) {
+	return o.Delete(boil.GetDB())
+}
+
+// Delete deletes a single QuarantinedFile record with an executor.
+// Delete will match against the primary key column to find the record to delete.
+func (o *QuarantinedFile) Delete(exec boil.Executor) (int64, error) {
+	if o == nil {
+		return 0, errors.New("models: no QuarantinedFile provided for delete")
+	}
+
+	if err := o.doBeforeDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	args := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(o)), quarantinedFilePrimaryKeyMapping)
+	sql := "DELETE FROM \"quarantined_files\" WHERE \"id\"=$1"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args...)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete from quarantined_files")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by delete for quarantined_files")
+	}
+
+	if err := o.doAfterDeleteHooks(exec); err != nil {
+		return 0, err
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAll deletes all matching rows.
+func (q quarantinedFileQuery) DeleteAll(exec boil.Executor) (int64, error) {
+	if q.Query == nil {
+		return 0, errors.New("models: no quarantinedFileQuery provided for delete all")
+	}
+
+	queries.SetDelete(q.Query)
+
+	result, err := q.Query.Exec(exec)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from quarantined_files")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for quarantined_files")
+	}
+
+	return rowsAff, nil
+}
+
+// DeleteAllG deletes all rows in the slice.
+func (o QuarantinedFileSlice) DeleteAllG() (int64, error) {
+	return o.DeleteAll(boil.GetDB())
+}
+
+// DeleteAll deletes all rows in the slice, using an executor.
+func (o QuarantinedFileSlice) DeleteAll(exec boil.Executor) (int64, error) {
+	if len(o) == 0 {
+		return 0, nil
+	}
+
+	if len(quarantinedFileBeforeDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doBeforeDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	var args []interface{}
+	for _, obj := range o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), quarantinedFilePrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "DELETE FROM \"quarantined_files\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, quarantinedFilePrimaryKeyColumns, len(o))
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, args)
+	}
+
+	result, err := exec.Exec(sql, args...)
+	if err != nil {
+		return 0, errors.Wrap(err, "models: unable to delete all from quarantinedFile slice")
+	}
+
+	rowsAff, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "models: failed to get rows affected by deleteall for quarantined_files")
+	}
+
+	if len(quarantinedFileAfterDeleteHooks) != 0 {
+		for _, obj := range o {
+			if err := obj.doAfterDeleteHooks(exec); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return rowsAff, nil
+}
+
+// ReloadG refetches the object from the database using the primary keys.
+func (o *QuarantinedFile) ReloadG() error {
+	if o == nil {
+		return errors.New("models: no QuarantinedFile provided for reload")
+	}
+
+	return o.Reload(boil.GetDB())
+}
+
+// Reload refetches the object from the database
+// using the primary keys with an executor.
+func (o *QuarantinedFile) Reload(exec boil.Executor) error {
+	ret, err := FindQuarantinedFile(exec, o.ID)
+	if err != nil {
+		return err
+	}
+
+	*o = *ret
+	return nil
+}
+
+// ReloadAllG refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *QuarantinedFileSlice) ReloadAllG() error {
+	if o == nil {
+		return errors.New("models: empty QuarantinedFileSlice provided for reload all")
+	}
+
+	return o.ReloadAll(boil.GetDB())
+}
+
+// ReloadAll refetches every row with matching primary key column values
+// and overwrites the original object slice with the newly updated slice.
+func (o *QuarantinedFileSlice) ReloadAll(exec boil.Executor) error {
+	if o == nil || len(*o) == 0 {
+		return nil
+	}
+
+	slice := QuarantinedFileSlice{}
+	var args []interface{}
+	for _, obj := range *o {
+		pkeyArgs := queries.ValuesFromMapping(reflect.Indirect(reflect.ValueOf(obj)), quarantinedFilePrimaryKeyMapping)
+		args = append(args, pkeyArgs...)
+	}
+
+	sql := "SELECT \"quarantined_files\".* FROM \"quarantined_files\" WHERE " +
+		strmangle.WhereClauseRepeated(string(dialect.LQ), string(dialect.RQ), 1, quarantinedFilePrimaryKeyColumns, len(*o))
+
+	q := queries.Raw(sql, args...)
+
+	err := q.Bind(nil, exec, &slice)
+	if err != nil {
+		return errors.Wrap(err, "models: unable to reload all in QuarantinedFileSlice")
+	}
+
+	*o = slice
+
+	return nil
+}
+
+// QuarantinedFileExistsG checks if the QuarantinedFile row exists.
+func QuarantinedFileExistsG(iD int) (bool, error) {
+	return QuarantinedFileExists(boil.GetDB(), iD)
+}
+
+// QuarantinedFileExists checks if the QuarantinedFile row exists.
+func QuarantinedFileExists(exec boil.Executor, iD int) (bool, error) {
+	var exists bool
+	sql := "select exists(select 1 from \"quarantined_files\" where \"id\"=$1 limit 1)"
+
+	if boil.DebugMode {
+		fmt.Fprintln(boil.DebugWriter, sql)
+		fmt.Fprintln(boil.DebugWriter, iD)
+	}
+
+	row := exec.QueryRow(sql, iD)
+
+	err := row.Scan(&exists)
+	if err != nil {
+		return false, errors.Wrap(err, "models: unable to check if quarantined_files exists")
+	}
+
+	return exists, nil
+}
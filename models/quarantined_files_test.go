@@ -0,0 +1,713 @@
+// Code generated by SQLBoiler (https://github.com/volatiletech/sqlboiler). DO NOT EDIT.
+// This file is meant to be re-generated in place and/or deleted at any time.
+
+package models
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/volatiletech/sqlboiler/boil"
+	"github.com/volatiletech/sqlboiler/queries"
+	"github.com/volatiletech/sqlboiler/randomize"
+	"github.com/volatiletech/sqlboiler/strmangle"
+)
+
+var (
+	// Relationships sometimes use the reflection helper queries.Equal/queries.Assign
+	// so force a package dependency in case they don't.
+	_ = queries.Equal
+)
+
+func testQuarantinedFiles(t *testing.T) {
+	t.Parallel()
+
+	query := QuarantinedFiles()
+
+	if query.Query == nil {
+		t.Error("expected a query, got nothing")
+	}
+}
+
+func testQuarantinedFilesDelete(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := o.Delete(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testQuarantinedFilesQueryDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if rowsAff, err := QuarantinedFiles().DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testQuarantinedFilesSliceDeleteAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := QuarantinedFileSlice{o}
+
+	if rowsAff, err := slice.DeleteAll(tx); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only have deleted one row, but affected:", rowsAff)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 0 {
+		t.Error("want zero records, got:", count)
+	}
+}
+
+func testQuarantinedFilesExists(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	e, err := QuarantinedFileExists(tx, o.ID)
+	if err != nil {
+		t.Errorf("Unable to check if QuarantinedFile exists: %s", err)
+	}
+	if !e {
+		t.Errorf("Expected QuarantinedFileExists to return true, but got false.")
+	}
+}
+
+func testQuarantinedFilesFind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	quarantinedFileFound, err := FindQuarantinedFile(tx, o.ID)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if quarantinedFileFound == nil {
+		t.Error("want a record, got nil")
+	}
+}
+
+func testQuarantinedFilesBind(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = QuarantinedFiles().Bind(nil, tx, o); err != nil {
+		t.Error(err)
+	}
+}
+
+func testQuarantinedFilesOne(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if x, err := QuarantinedFiles().One(tx); err != nil {
+		t.Error(err)
+	} else if x == nil {
+		t.Error("expected to get a non nil record")
+	}
+}
+
+func testQuarantinedFilesAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	quarantinedFileOne := &QuarantinedFile{}
+	quarantinedFileTwo := &QuarantinedFile{}
+	if err = randomize.Struct(seed, quarantinedFileOne, quarantinedFileDBTypes, false, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+	if err = randomize.Struct(seed, quarantinedFileTwo, quarantinedFileDBTypes, false, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = quarantinedFileOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = quarantinedFileTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := QuarantinedFiles().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 2 {
+		t.Error("want 2 records, got:", len(slice))
+	}
+}
+
+func testQuarantinedFilesCount(t *testing.T) {
+	t.Parallel()
+
+	var err error
+	seed := randomize.NewSeed()
+	quarantinedFileOne := &QuarantinedFile{}
+	quarantinedFileTwo := &QuarantinedFile{}
+	if err = randomize.Struct(seed, quarantinedFileOne, quarantinedFileDBTypes, false, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+	if err = randomize.Struct(seed, quarantinedFileTwo, quarantinedFileDBTypes, false, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = quarantinedFileOne.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+	if err = quarantinedFileTwo.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 2 {
+		t.Error("want 2 records, got:", count)
+	}
+}
+
+func quarantinedFileBeforeInsertHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileAfterInsertHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileAfterSelectHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileBeforeUpdateHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileAfterUpdateHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileBeforeDeleteHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileAfterDeleteHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileBeforeUpsertHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func quarantinedFileAfterUpsertHook(e boil.Executor, o *QuarantinedFile) error {
+	*o = QuarantinedFile{}
+	return nil
+}
+
+func testQuarantinedFilesHooks(t *testing.T) {
+	t.Parallel()
+
+	var err error
+
+	empty := &QuarantinedFile{}
+	o := &QuarantinedFile{}
+
+	seed := randomize.NewSeed()
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, false); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile object: %s", err)
+	}
+
+	AddQuarantinedFileHook(boil.BeforeInsertHook, quarantinedFileBeforeInsertHook)
+	if err = o.doBeforeInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeInsertHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileBeforeInsertHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.AfterInsertHook, quarantinedFileAfterInsertHook)
+	if err = o.doAfterInsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterInsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterInsertHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileAfterInsertHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.AfterSelectHook, quarantinedFileAfterSelectHook)
+	if err = o.doAfterSelectHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterSelectHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterSelectHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileAfterSelectHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.BeforeUpdateHook, quarantinedFileBeforeUpdateHook)
+	if err = o.doBeforeUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpdateHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileBeforeUpdateHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.AfterUpdateHook, quarantinedFileAfterUpdateHook)
+	if err = o.doAfterUpdateHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpdateHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpdateHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileAfterUpdateHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.BeforeDeleteHook, quarantinedFileBeforeDeleteHook)
+	if err = o.doBeforeDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeDeleteHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileBeforeDeleteHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.AfterDeleteHook, quarantinedFileAfterDeleteHook)
+	if err = o.doAfterDeleteHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterDeleteHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterDeleteHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileAfterDeleteHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.BeforeUpsertHook, quarantinedFileBeforeUpsertHook)
+	if err = o.doBeforeUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doBeforeUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected BeforeUpsertHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileBeforeUpsertHooks = []QuarantinedFileHook{}
+
+	AddQuarantinedFileHook(boil.AfterUpsertHook, quarantinedFileAfterUpsertHook)
+	if err = o.doAfterUpsertHooks(nil); err != nil {
+		t.Errorf("Unable to execute doAfterUpsertHooks: %s", err)
+	}
+	if !reflect.DeepEqual(o, empty) {
+		t.Errorf("Expected AfterUpsertHook function to empty object, but got: %#v", o)
+	}
+	quarantinedFileAfterUpsertHooks = []QuarantinedFileHook{}
+}
+
+func testQuarantinedFilesInsert(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testQuarantinedFilesInsertWhitelist(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Whitelist(quarantinedFileColumnsWithoutDefault...)); err != nil {
+		t.Error(err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
+
+func testQuarantinedFilesReload(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	if err = o.Reload(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testQuarantinedFilesReloadAll(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice := QuarantinedFileSlice{o}
+
+	if err = slice.ReloadAll(tx); err != nil {
+		t.Error(err)
+	}
+}
+
+func testQuarantinedFilesSelect(t *testing.T) {
+	t.Parallel()
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	slice, err := QuarantinedFiles().All(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(slice) != 1 {
+		t.Error("want one record, got:", len(slice))
+	}
+}
+
+var (
+	quarantinedFileDBTypes = map[string]string{`ID`: `integer`, `UserID`: `integer`, `FileName`: `character varying`, `Path`: `character varying`, `Size`: `bigint`, `Sha256`: `character varying`, `Source`: `character varying`, `Reason`: `text`, `Status`: `character varying`, `ReviewedBy`: `character varying`, `ReviewNote`: `text`, `CreatedAt`: `timestamp without time zone`, `ReviewedAt`: `timestamp without time zone`}
+	_                      = bytes.MinRead
+)
+
+func testQuarantinedFilesUpdate(t *testing.T) {
+	t.Parallel()
+
+	if 0 == len(quarantinedFilePrimaryKeyColumns) {
+		t.Skip("Skipping table with no primary key columns")
+	}
+	if len(quarantinedFileAllColumns) == len(quarantinedFilePrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFilePrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	if rowsAff, err := o.Update(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("should only affect one row but affected", rowsAff)
+	}
+}
+
+func testQuarantinedFilesSliceUpdateAll(t *testing.T) {
+	t.Parallel()
+
+	if len(quarantinedFileAllColumns) == len(quarantinedFilePrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	o := &QuarantinedFile{}
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFileColumnsWithDefault...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Insert(tx, boil.Infer()); err != nil {
+		t.Error(err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	if err = randomize.Struct(seed, o, quarantinedFileDBTypes, true, quarantinedFilePrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	// Remove Primary keys and unique columns from what we plan to update
+	var fields []string
+	if strmangle.StringSliceMatch(quarantinedFileAllColumns, quarantinedFilePrimaryKeyColumns) {
+		fields = quarantinedFileAllColumns
+	} else {
+		fields = strmangle.SetComplement(
+			quarantinedFileAllColumns,
+			quarantinedFilePrimaryKeyColumns,
+		)
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(o))
+	typ := reflect.TypeOf(o).Elem()
+	n := typ.NumField()
+
+	updateMap := M{}
+	for _, col := range fields {
+		for i := 0; i < n; i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("boil") == col {
+				updateMap[col] = value.Field(i).Interface()
+			}
+		}
+	}
+
+	slice := QuarantinedFileSlice{o}
+	if rowsAff, err := slice.UpdateAll(tx, updateMap); err != nil {
+		t.Error(err)
+	} else if rowsAff != 1 {
+		t.Error("wanted one record updated but got", rowsAff)
+	}
+}
+
+func testQuarantinedFilesUpsert(t *testing.T) {
+	t.Parallel()
+
+	if len(quarantinedFileAllColumns) == len(quarantinedFilePrimaryKeyColumns) {
+		t.Skip("Skipping table with only primary key columns")
+	}
+
+	seed := randomize.NewSeed()
+	var err error
+	// Attempt the INSERT side of an UPSERT
+	o := QuarantinedFile{}
+	if err = randomize.Struct(seed, &o, quarantinedFileDBTypes, true); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	tx := MustTx(boil.Begin())
+	defer func() { _ = tx.Rollback() }()
+	if err = o.Upsert(tx, false, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert QuarantinedFile: %s", err)
+	}
+
+	count, err := QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+
+	// Attempt the UPDATE side of an UPSERT
+	if err = randomize.Struct(seed, &o, quarantinedFileDBTypes, false, quarantinedFilePrimaryKeyColumns...); err != nil {
+		t.Errorf("Unable to randomize QuarantinedFile struct: %s", err)
+	}
+
+	if err = o.Upsert(tx, true, nil, boil.Infer(), boil.Infer()); err != nil {
+		t.Errorf("Unable to upsert QuarantinedFile: %s", err)
+	}
+
+	count, err = QuarantinedFiles().Count(tx)
+	if err != nil {
+		t.Error(err)
+	}
+	if count != 1 {
+		t.Error("want one record, got:", count)
+	}
+}
@@ -29,6 +29,7 @@ type QueryLog struct {
 	UserID    null.Int  `boil:"user_id" json:"user_id,omitempty" toml:"user_id" yaml:"user_id,omitempty"`
 	RemoteIP  string    `boil:"remote_ip" json:"remote_ip" toml:"remote_ip" yaml:"remote_ip"`
 	Body      null.JSON `boil:"body" json:"body,omitempty" toml:"body" yaml:"body,omitempty"`
+	DeletedAt null.Time `boil:"deleted_at" json:"deleted_at,omitempty" toml:"deleted_at" yaml:"deleted_at,omitempty"`
 
 	R *queryLogR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L queryLogL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -41,6 +42,7 @@ var QueryLogColumns = struct {
 	UserID    string
 	RemoteIP  string
 	Body      string
+	DeletedAt string
 }{
 	ID:        "id",
 	Method:    "method",
@@ -48,6 +50,7 @@ var QueryLogColumns = struct {
 	UserID:    "user_id",
 	RemoteIP:  "remote_ip",
 	Body:      "body",
+	DeletedAt: "deleted_at",
 }
 
 // Generated where
@@ -75,29 +78,6 @@ func (w whereHelpernull_Int) GTE(x null.Int) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GTE, x)
 }
 
-type whereHelpernull_JSON struct{ field string }
-
-func (w whereHelpernull_JSON) EQ(x null.JSON) qm.QueryMod {
-	return qmhelper.WhereNullEQ(w.field, false, x)
-}
-func (w whereHelpernull_JSON) NEQ(x null.JSON) qm.QueryMod {
-	return qmhelper.WhereNullEQ(w.field, true, x)
-}
-func (w whereHelpernull_JSON) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
-func (w whereHelpernull_JSON) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
-func (w whereHelpernull_JSON) LT(x null.JSON) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.LT, x)
-}
-func (w whereHelpernull_JSON) LTE(x null.JSON) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.LTE, x)
-}
-func (w whereHelpernull_JSON) GT(x null.JSON) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.GT, x)
-}
-func (w whereHelpernull_JSON) GTE(x null.JSON) qm.QueryMod {
-	return qmhelper.Where(w.field, qmhelper.GTE, x)
-}
-
 var QueryLogWhere = struct {
 	ID        whereHelperint
 	Method    whereHelperstring
@@ -105,6 +85,7 @@ var QueryLogWhere = struct {
 	UserID    whereHelpernull_Int
 	RemoteIP  whereHelperstring
 	Body      whereHelpernull_JSON
+	DeletedAt whereHelpernull_Time
 }{
 	ID:        whereHelperint{field: "\"query_log\".\"id\""},
 	Method:    whereHelperstring{field: "\"query_log\".\"method\""},
@@ -112,6 +93,7 @@ var QueryLogWhere = struct {
 	UserID:    whereHelpernull_Int{field: "\"query_log\".\"user_id\""},
 	RemoteIP:  whereHelperstring{field: "\"query_log\".\"remote_ip\""},
 	Body:      whereHelpernull_JSON{field: "\"query_log\".\"body\""},
+	DeletedAt: whereHelpernull_Time{field: "\"query_log\".\"deleted_at\""},
 }
 
 // QueryLogRels is where relationship names are stored.
@@ -131,8 +113,8 @@ func (*queryLogR) NewStruct() *queryLogR {
 type queryLogL struct{}
 
 var (
-	queryLogAllColumns            = []string{"id", "method", "timestamp", "user_id", "remote_ip", "body"}
-	queryLogColumnsWithoutDefault = []string{"method", "user_id", "remote_ip", "body"}
+	queryLogAllColumns            = []string{"id", "method", "timestamp", "user_id", "remote_ip", "body", "deleted_at"}
+	queryLogColumnsWithoutDefault = []string{"method", "user_id", "remote_ip", "body", "deleted_at"}
 	queryLogColumnsWithDefault    = []string{"id", "timestamp"}
 	queryLogPrimaryKeyColumns     = []string{"id"}
 )
@@ -0,0 +1,184 @@
+// Package webhook lets receivers of lbrytv webhooks authenticate deliveries and reject replays.
+//
+// Every delivery carries a timestamp, a random nonce and HMAC-SHA256 signatures of
+// "<timestamp>.<nonce>.<body>", one for each webhook signing secret which is valid at the time, comma-separated.
+// Receivers should accept a delivery if any signature matches one of their secrets, its timestamp is recent
+// and its nonce hasn't been seen before. Retried deliveries of the same message get new nonces,
+// the message ID header stays the same and should be used to recognize them.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+)
+
+const (
+	MessageIDHeader = "X-Outbox-Message-ID"
+	TimestampHeader = "X-Outbox-Timestamp"
+	NonceHeader     = "X-Outbox-Nonce"
+	SignatureHeader = "X-Outbox-Signature"
+
+	// DefaultMaxSkew is how far delivery timestamps may be from the receiver clock by default.
+	DefaultMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrMissingSignature = errors.Base("webhook delivery is not signed")
+	ErrInvalidSignature = errors.Base("webhook signature is invalid")
+	ErrStaleSignature   = errors.Base("webhook signature has expired")
+	ErrReplayed         = errors.Base("webhook delivery has already been received")
+)
+
+// NewNonce returns a random nonce for a delivery.
+func NewNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Err(err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns signatures of the delivery made with each of secrets, comma-separated.
+func Sign(secrets []string, timestamp time.Time, nonce string, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	sigs := make([]string, len(secrets))
+	for i, s := range secrets {
+		sigs[i] = signature([]byte(s), ts, nonce, body)
+	}
+	return strings.Join(sigs, ",")
+}
+
+// SetHeaders adds timestamp, nonce and signature headers for body to the request.
+func SetHeaders(r *http.Request, secrets []string, body []byte) error {
+	nonce, err := NewNonce()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	r.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	r.Header.Set(NonceHeader, nonce)
+	r.Header.Set(SignatureHeader, Sign(secrets, now, nonce, body))
+	return nil
+}
+
+// NonceStore remembers nonces of received deliveries. Receivers running several instances
+// should share one, a MemoryNonceStore is enough otherwise.
+type NonceStore interface {
+	// Seen records the nonce, which can be forgotten after expires, and returns true if it was already recorded.
+	Seen(nonce string, expires time.Time) bool
+}
+
+// MemoryNonceStore keeps nonces in memory.
+type MemoryNonceStore struct {
+	mu      sync.Mutex
+	nonces  map[string]time.Time
+	checked time.Time
+}
+
+// NewMemoryNonceStore returns an empty MemoryNonceStore.
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: map[string]time.Time{}}
+}
+
+func (s *MemoryNonceStore) Seen(nonce string, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	// Expired nonces are dropped once a minute at most so Seen stays cheap
+	if now.Sub(s.checked) > time.Minute {
+		for n, e := range s.nonces {
+			if now.After(e) {
+				delete(s.nonces, n)
+			}
+		}
+		s.checked = now
+	}
+	if e, ok := s.nonces[nonce]; ok && now.Before(e) {
+		return true
+	}
+	s.nonces[nonce] = expires
+	return false
+}
+
+// Verifier checks deliveries received by a webhook.
+type Verifier struct {
+	// Secrets are webhook signing secrets accepted by the receiver. While a secret is being rotated,
+	// both the old and the new one should be set.
+	Secrets []string
+	// MaxSkew is how far delivery timestamps may be from the receiver clock, DefaultMaxSkew when zero.
+	MaxSkew time.Duration
+	// Nonces rejects replayed deliveries. Replays within MaxSkew are not detected when it's nil.
+	Nonces NonceStore
+}
+
+// NewVerifier returns a verifier accepting deliveries signed with any of secrets and remembering nonces in memory.
+func NewVerifier(secrets ...string) *Verifier {
+	return &Verifier{Secrets: secrets, MaxSkew: DefaultMaxSkew, Nonces: NewMemoryNonceStore()}
+}
+
+// Verify checks that the delivery is signed with one of the secrets, is recent and hasn't been received before.
+// It returns the request body, which is put back so handlers can read it again.
+func (v *Verifier) Verify(r *http.Request) ([]byte, error) {
+	ts, nonce, sig := r.Header.Get(TimestampHeader), r.Header.Get(NonceHeader), r.Header.Get(SignatureHeader)
+	if ts == "" || nonce == "" || sig == "" {
+		return nil, ErrMissingSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	maxSkew := v.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = DefaultMaxSkew
+	}
+	sent := time.Unix(unix, 0)
+	if skew := time.Since(sent); skew > maxSkew || skew < -maxSkew {
+		return nil, ErrStaleSignature
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, errors.Err(err)
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if !v.matches(ts, nonce, sig, body) {
+		return nil, ErrInvalidSignature
+	}
+	// Deliveries older than that are rejected as stale anyway
+	if v.Nonces != nil && v.Nonces.Seen(nonce, sent.Add(maxSkew)) {
+		return nil, ErrReplayed
+	}
+	return body, nil
+}
+
+func (v *Verifier) matches(ts, nonce, sig string, body []byte) bool {
+	for _, secret := range v.Secrets {
+		expected := signature([]byte(secret), ts, nonce, body)
+		for _, s := range strings.Split(sig, ",") {
+			if hmac.Equal([]byte(strings.ToLower(strings.TrimSpace(s))), []byte(expected)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func signature(secret []byte, ts, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(ts + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,89 @@
+package webhook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lbryio/lbrytv/internal/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func delivery(t *testing.T, secrets []string, body string) *http.Request {
+	r, err := http.NewRequest(http.MethodPost, "http://receiver/hook", bytes.NewReader([]byte(body)))
+	require.NoError(t, err)
+	require.NoError(t, SetHeaders(r, secrets, []byte(body)))
+	return r
+}
+
+func TestVerify(t *testing.T) {
+	v := NewVerifier("new")
+
+	r := delivery(t, []string{"new", "old"}, `{"a":1}`)
+	body, err := v.Verify(r)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(body))
+	// The body can still be read by the handler
+	rest, err := ioutil.ReadAll(r.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(rest))
+
+	_, err = v.Verify(delivery(t, []string{"old"}, `{"a":1}`))
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	r = delivery(t, []string{"new"}, `{"a":1}`)
+	r.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"a":2}`)))
+	_, err = v.Verify(r)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	r = delivery(t, []string{"new"}, `{"a":1}`)
+	r.Header.Set(NonceHeader, "other")
+	_, err = v.Verify(r)
+	assert.True(t, errors.Is(err, ErrInvalidSignature))
+
+	r = delivery(t, []string{"new"}, `{"a":1}`)
+	r.Header.Del(SignatureHeader)
+	_, err = v.Verify(r)
+	assert.True(t, errors.Is(err, ErrMissingSignature))
+}
+
+func TestVerify_Replayed(t *testing.T) {
+	v := NewVerifier("s3cr3t")
+	r := delivery(t, []string{"s3cr3t"}, `{}`)
+	replay := r.Clone(r.Context())
+	replay.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{}`)))
+
+	_, err := v.Verify(r)
+	require.NoError(t, err)
+	_, err = v.Verify(replay)
+	assert.True(t, errors.Is(err, ErrReplayed))
+}
+
+func TestVerify_Stale(t *testing.T) {
+	v := NewVerifier("s3cr3t")
+	sent := time.Now().Add(-10 * time.Minute)
+	r, err := http.NewRequest(http.MethodPost, "http://receiver/hook", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	r.Header.Set(TimestampHeader, strconv.FormatInt(sent.Unix(), 10))
+	r.Header.Set(NonceHeader, "abc")
+	r.Header.Set(SignatureHeader, Sign([]string{"s3cr3t"}, sent, "abc", []byte(`{}`)))
+
+	_, err = v.Verify(r)
+	assert.True(t, errors.Is(err, ErrStaleSignature))
+	v.MaxSkew = time.Hour
+	_, err = v.Verify(r)
+	assert.NoError(t, err)
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	s := NewMemoryNonceStore()
+	assert.False(t, s.Seen("a", time.Now().Add(time.Minute)))
+	assert.True(t, s.Seen("a", time.Now().Add(time.Minute)))
+	assert.False(t, s.Seen("b", time.Now().Add(-time.Second)))
+	assert.False(t, s.Seen("b", time.Now().Add(time.Minute)), "expired nonces are forgotten")
+}
@@ -9,7 +9,10 @@ import (
 	"time"
 
 	"github.com/lbryio/lbrytv/api"
+	"github.com/lbryio/lbrytv/app/grpcapi"
+	"github.com/lbryio/lbrytv/app/publish"
 	"github.com/lbryio/lbrytv/app/sdkrouter"
+	"github.com/lbryio/lbrytv/apps/lbrytv/config"
 	"github.com/lbryio/lbrytv/internal/monitor"
 
 	"github.com/gorilla/mux"
@@ -21,8 +24,10 @@ var logger = monitor.NewModuleLogger("server")
 type Server struct {
 	address  string
 	listener *http.Server
-	stopChan chan os.Signal
-	stopWait time.Duration
+	// grpcListener serves the gRPC Proxy service when it's enabled, see config.GRPC
+	grpcListener *http.Server
+	stopChan     chan os.Signal
+	stopWait     time.Duration
 }
 
 // NewServer returns a server initialized with settings from supplied options.
@@ -35,13 +40,13 @@ func NewServer(address string, sdkRouter *sdkrouter.Router) *Server {
 		"Access-Control-Allow-Origin": "*",
 	}))
 
-	return &Server{
+	s := &Server{
 		address:  address,
 		stopWait: 15 * time.Second,
 		stopChan: make(chan os.Signal),
 		listener: &http.Server{
 			Addr:    address,
-			Handler: r,
+			Handler: publish.TrackProgress(r),
 			// We need this for long uploads
 			WriteTimeout: 0,
 			// prev WriteTimeout was (sdkrouter.RPCTimeout + (1 * time.Second)). it must be longer than rpc timeout to allow those timeouts to be handled
@@ -49,6 +54,18 @@ func NewServer(address string, sdkRouter *sdkrouter.Router) *Server {
 			ReadHeaderTimeout: 10 * time.Second,
 		},
 	}
+	if addr := config.GetGRPC().Address; addr != "" {
+		// gRPC needs HTTP/2, spoken in cleartext as TLS is terminated by the load balancer
+		protocols := new(http.Protocols)
+		protocols.SetUnencryptedHTTP2(true)
+		s.grpcListener = &http.Server{
+			Addr:              addr,
+			Handler:           grpcapi.NewServer(publish.TrackProgress(r)),
+			Protocols:         protocols,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return s
 }
 
 func defaultHeadersMiddleware(defaultHeaders map[string]string) mux.MiddlewareFunc {
@@ -72,6 +89,14 @@ func (s *Server) Start() error {
 		}
 	}()
 	logger.Log().Infof("http server listening on %v", s.listener.Addr)
+	if s.grpcListener != nil {
+		go func() {
+			if err := s.grpcListener.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logger.Log().Error(err)
+			}
+		}()
+		logger.Log().Infof("grpc server listening on %v", s.grpcListener.Addr)
+	}
 	return nil
 }
 
@@ -90,10 +115,18 @@ func (s *Server) ServeUntilShutdown() {
 	}
 }
 
-// Shutdown gracefully shuts down the peer server.
+// Shutdown gracefully shuts down the peer server. Uploads in progress are cut short
+// and handed over to other instances, see publish.HandOver.
 func (s *Server) Shutdown() error {
 	ctx, cancel := context.WithTimeout(context.Background(), s.stopWait)
 	defer cancel()
+	publish.Drain()
 	err := s.listener.Shutdown(ctx)
+	if s.grpcListener != nil {
+		if gerr := s.grpcListener.Shutdown(ctx); err == nil {
+			err = gerr
+		}
+	}
+	publish.HandOver()
 	return err
 }